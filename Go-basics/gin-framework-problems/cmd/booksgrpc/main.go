@@ -0,0 +1,37 @@
+// Command booksgrpc runs the books example with its gRPC service (REST and grpc-gateway on -addr, gRPC on -grpc-addr). Settings come from internal/config.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/config"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/books"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/booksgrpc"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/seed"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/server"
+)
+
+func main() {
+	cfg, err := config.Load(flag.CommandLine, os.Args[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var hooks server.Hooks
+	deps := server.Deps{Config: cfg, Hooks: &hooks}
+	catalog := books.NewCatalog(deps)
+	if cfg.Seed.Enabled {
+		if err := seed.Load(context.Background(), cfg, seed.Stores{Books: catalog}); err != nil {
+			log.Fatal(err)
+		}
+	}
+	router := booksgrpc.NewRouter(booksgrpc.Deps{Deps: deps, Books: catalog})
+
+	err = server.Run(router, cfg.Server.Addr, server.WithConfig(cfg.Server), server.WithHooks(hooks))
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,27 @@
+// Command caching runs the caching example. Settings come from internal/config.
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/config"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/caching"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/server"
+)
+
+func main() {
+	cfg, err := config.Load(flag.CommandLine, os.Args[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var hooks server.Hooks
+	router := caching.NewRouter(caching.Deps{Deps: server.Deps{Config: cfg, Hooks: &hooks}})
+
+	err = server.Run(router, cfg.Server.Addr, server.WithConfig(cfg.Server), server.WithHooks(hooks))
+	if err != nil {
+		log.Fatal(err)
+	}
+}
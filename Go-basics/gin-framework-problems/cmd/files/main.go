@@ -0,0 +1,36 @@
+// Command files runs the file upload example. Settings come from internal/config.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/config"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/files"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/seed"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/server"
+)
+
+func main() {
+	cfg, err := config.Load(flag.CommandLine, os.Args[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var hooks server.Hooks
+	deps := server.Deps{Config: cfg, Hooks: &hooks}
+	uploads := files.NewUploads(deps)
+	if cfg.Seed.Enabled {
+		if err := seed.Load(context.Background(), cfg, seed.Stores{Files: uploads}); err != nil {
+			log.Fatal(err)
+		}
+	}
+	router := files.NewRouter(files.Deps{Deps: deps, Files: uploads})
+
+	err = server.Run(router, cfg.Server.Addr, server.WithConfig(cfg.Server), server.WithHooks(hooks))
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,38 @@
+// Command graphqlapi runs the GraphQL example. Settings come from internal/config.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/config"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/books"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/graphqlapi"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/seed"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/users"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/server"
+)
+
+func main() {
+	cfg, err := config.Load(flag.CommandLine, os.Args[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var hooks server.Hooks
+	deps := server.Deps{Config: cfg, Hooks: &hooks}
+	stores := seed.Stores{Users: users.NewAccounts(deps), Books: books.NewCatalog(deps)}
+	if cfg.Seed.Enabled {
+		if err := seed.Load(context.Background(), cfg, stores); err != nil {
+			log.Fatal(err)
+		}
+	}
+	router := graphqlapi.NewRouter(graphqlapi.Deps{Deps: deps, Books: stores.Books, Users: stores.Users})
+
+	err = server.Run(router, cfg.Server.Addr, server.WithConfig(cfg.Server), server.WithHooks(hooks))
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,117 @@
+// Command grpc-client logs in over REST and then calls every Greeter RPC with
+// the same token. Start the server first with `go run ./cmd/grpcbasics`.
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/grpcbasics/greeterpb"
+)
+
+func login(restURL, username, password string) (string, error) {
+	body, _ := json.Marshal(map[string]string{"username": username, "password": password})
+	resp, err := http.Post(restURL+"/login", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("login: %s", resp.Status)
+	}
+	var out struct {
+		Token string `json:"token"`
+	}
+	return out.Token, json.NewDecoder(resp.Body).Decode(&out)
+}
+
+func main() {
+	restURL := flag.String("rest", "http://localhost:8080", "REST base URL")
+	grpcAddr := flag.String("grpc", "localhost:9090", "gRPC address")
+	username := flag.String("user", "alice", "username")
+	password := flag.String("password", "password1", "password")
+	flag.Parse()
+
+	token, err := login(*restURL, *username, *password)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	conn, err := grpc.NewClient(*grpcAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer conn.Close()
+	client := greeterpb.NewGreeterClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+
+	// unary
+	hello, err := client.SayHello(ctx, &greeterpb.HelloRequest{})
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("SayHello:", hello.GetMessage())
+
+	// server streaming
+	countdown, err := client.Countdown(ctx, &greeterpb.CountdownRequest{From: 3, IntervalMs: 100})
+	if err != nil {
+		log.Fatal(err)
+	}
+	for {
+		msg, err := countdown.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println("Countdown:", msg.GetValue())
+	}
+
+	// client streaming
+	sum, err := client.Sum(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, v := range []int64{1, 2, 3, 4} {
+		if err := sum.Send(&greeterpb.SumRequest{Value: v}); err != nil {
+			log.Fatal(err)
+		}
+	}
+	total, err := sum.CloseAndRecv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Sum: %d over %d values\n", total.GetTotal(), total.GetCount())
+
+	// bidirectional streaming
+	echo, err := client.Echo(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, text := range []string{"ping", "pong"} {
+		if err := echo.Send(&greeterpb.EchoMessage{Text: text}); err != nil {
+			log.Fatal(err)
+		}
+		reply, err := echo.Recv()
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("Echo: %s says %q\n", reply.GetUser(), reply.GetText())
+	}
+	echo.CloseSend()
+}
@@ -0,0 +1,159 @@
+// Command hub runs any of the gin examples from a single binary:
+//
+//	go run ./cmd/hub serve users|books|files|auth|ratelimit|tracing|chat|notify|grpc|graphql|jobs|caching|web|transactions|gateway|webhooks|shortener|orders
+//
+// hub serve -help lists the flags, such as -port, -mode and -data-dir.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/config"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/auth"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/books"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/booksgrpc"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/caching"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/chat"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/files"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/gateway"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/graphqlapi"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/grpcbasics"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/jobs"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/notify"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/orders"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/ratelimit"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/seed"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/shortener"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/tracing"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/transactions"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/users"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/web"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/webhooks"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/server"
+)
+
+// examples maps a subcommand argument to the router it serves.
+var examples = map[string]func(server.Deps) *gin.Engine{
+	"users":        usersRouter,
+	"books":        booksRouter,
+	"files":        filesRouter,
+	"auth":         func(d server.Deps) *gin.Engine { return auth.NewRouter(auth.Deps{Deps: d}) },
+	"ratelimit":    func(d server.Deps) *gin.Engine { return ratelimit.NewRouter(ratelimit.Deps{Deps: d}) },
+	"tracing":      tracing.NewRouter,
+	"chat":         chat.NewRouter,
+	"notify":       notify.NewRouter,
+	"grpc":         func(d server.Deps) *gin.Engine { return grpcbasics.NewRouter(grpcbasics.Deps{Deps: d}) },
+	"booksgrpc":    booksgrpcRouter,
+	"graphql":      graphqlRouter,
+	"jobs":         jobs.NewRouter,
+	"caching":      func(d server.Deps) *gin.Engine { return caching.NewRouter(caching.Deps{Deps: d}) },
+	"web":          webRouter,
+	"transactions": transactions.NewRouter,
+	"gateway":      gateway.NewRouter,
+	"webhooks":     func(d server.Deps) *gin.Engine { return webhooks.NewRouter(webhooks.Deps{Deps: d}) },
+	"shortener":    func(d server.Deps) *gin.Engine { return shortener.NewRouter(shortener.Deps{Deps: d}) },
+	"orders":       orders.NewRouter,
+}
+
+// load adds the fixtures to stores when -seed is set. The examples with
+// fixtures build their servers first, so that they serve what was seeded.
+func load(d server.Deps, stores seed.Stores) {
+	if !d.Config.Seed.Enabled {
+		return
+	}
+	if err := seed.Load(context.Background(), d.Config, stores); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func usersRouter(d server.Deps) *gin.Engine {
+	accounts := users.NewAccounts(d)
+	load(d, seed.Stores{Users: accounts})
+	return users.NewRouter(users.Deps{Deps: d, Users: accounts})
+}
+
+func booksRouter(d server.Deps) *gin.Engine {
+	catalog := books.NewCatalog(d)
+	load(d, seed.Stores{Books: catalog})
+	return books.NewRouter(books.Deps{Deps: d, Books: catalog})
+}
+
+func filesRouter(d server.Deps) *gin.Engine {
+	uploads := files.NewUploads(d)
+	load(d, seed.Stores{Files: uploads})
+	return files.NewRouter(files.Deps{Deps: d, Files: uploads})
+}
+
+func booksgrpcRouter(d server.Deps) *gin.Engine {
+	catalog := books.NewCatalog(d)
+	load(d, seed.Stores{Books: catalog})
+	return booksgrpc.NewRouter(booksgrpc.Deps{Deps: d, Books: catalog})
+}
+
+func graphqlRouter(d server.Deps) *gin.Engine {
+	stores := seed.Stores{Users: users.NewAccounts(d), Books: books.NewCatalog(d)}
+	load(d, stores)
+	return graphqlapi.NewRouter(graphqlapi.Deps{Deps: d, Books: stores.Books, Users: stores.Users})
+}
+
+func webRouter(d server.Deps) *gin.Engine {
+	stores := seed.Stores{Users: users.NewAccounts(d), Books: books.NewCatalog(d), Files: files.NewUploads(d)}
+	load(d, stores)
+	return web.NewRouter(web.Deps{Deps: d, Books: stores.Books, Users: stores.Users, Files: stores.Files})
+}
+
+func exampleNames() string {
+	names := make([]string, 0, len(examples))
+	for name := range examples {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, "|")
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: hub serve [flags] %s\n", exampleNames())
+	fmt.Fprintln(os.Stderr, "run hub serve -help for the flags")
+	os.Exit(2)
+}
+
+func main() {
+	if len(os.Args) < 2 || os.Args[1] != "serve" {
+		usage()
+	}
+
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: hub serve [flags] %s\n\n", exampleNames())
+		fmt.Fprintln(os.Stderr, "Each flag overrides its HUB_* environment variable, which overrides -config.")
+		fmt.Fprintln(os.Stderr)
+		fs.PrintDefaults()
+	}
+	cfg, err := config.Load(fs, os.Args[2:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	newRouter, ok := examples[fs.Arg(0)]
+	if fs.NArg() != 1 || !ok {
+		usage()
+	}
+
+	var hooks server.Hooks
+	router := newRouter(server.Deps{Config: cfg, Hooks: &hooks})
+
+	err = server.Run(router, cfg.Server.Addr, server.WithConfig(cfg.Server), server.WithHooks(hooks))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
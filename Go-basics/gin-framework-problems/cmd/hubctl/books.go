@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/hubclient"
+)
+
+func newBooksCmd() *cobra.Command {
+	cmd := &cobra.Command{Use: "books", Short: "List and import books (books example)"}
+	cmd.AddCommand(newBooksListCmd(), newBooksImportCmd())
+	return cmd
+}
+
+func newBooksListCmd() *cobra.Command {
+	var limit, offset int
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List books",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := client("books-url")
+			if err != nil {
+				return err
+			}
+			resp, err := c.ListBooksWithResponse(cmd.Context(), &hubclient.ListBooksParams{Limit: &limit, Offset: &offset})
+			if err != nil {
+				return err
+			}
+			if resp.JSON200 == nil {
+				return apiError(resp.HTTPResponse, resp.Body, resp.JSONDefault)
+			}
+			if cfg.GetBool("json") {
+				return printJSON(cmd, resp.JSON200)
+			}
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tTITLE\tAUTHOR\tYEAR")
+			for _, b := range resp.JSON200.Items {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%d\n", deref(b.Id), b.Title, b.Author, b.Year)
+			}
+			fmt.Fprintf(w, "\n%d of %d\n", len(resp.JSON200.Items), resp.JSON200.Total)
+			return w.Flush()
+		},
+	}
+	cmd.Flags().IntVar(&limit, "limit", 20, "page size")
+	cmd.Flags().IntVar(&offset, "offset", 0, "items to skip")
+	return cmd
+}
+
+// newBooksImportCmd creates one book per CSV row. The header names the
+// columns: title, author and year are required, isbn is optional.
+func newBooksImportCmd() *cobra.Command {
+	var dryRun bool
+	cmd := &cobra.Command{
+		Use:   "import FILE.csv",
+		Short: "Create books from a CSV file",
+		Long: "Create one book per row of a CSV file with a header row naming the\n" +
+			"columns title, author, year and optionally isbn. Rows that fail are\n" +
+			"reported and skipped; the command fails if any did.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			f, err := os.Open(args[0])
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			books, err := readBooksCSV(f)
+			if err != nil {
+				return fmt.Errorf("%s: %w", args[0], err)
+			}
+			if dryRun {
+				return printJSON(cmd, books)
+			}
+
+			c, err := client("books-url")
+			if err != nil {
+				return err
+			}
+			failed := 0
+			for i, b := range books {
+				line := i + 2 // 1-based, after the header
+				resp, err := c.CreateBookWithResponse(cmd.Context(), b)
+				if err == nil && resp.JSON201 == nil {
+					err = apiError(resp.HTTPResponse, resp.Body, resp.JSONDefault)
+				}
+				if err != nil {
+					failed++
+					fmt.Fprintf(cmd.ErrOrStderr(), "line %d: %v\n", line, err)
+					continue
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "line %d: created book %s %q\n", line, deref(resp.JSON201.Id), b.Title)
+			}
+			if failed > 0 {
+				return fmt.Errorf("%d of %d rows failed", failed, len(books))
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "parse the file and print the books without creating them")
+	return cmd
+}
+
+func readBooksCSV(r io.Reader) ([]hubclient.Book, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading header: %w", err)
+	}
+	col := map[string]int{}
+	for i, name := range header {
+		col[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"title", "author", "year"} {
+		if _, ok := col[name]; !ok {
+			return nil, fmt.Errorf("missing %q column", name)
+		}
+	}
+
+	var books []hubclient.Book
+	for {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return books, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := cr.FieldPos(0)
+		year, err := strconv.Atoi(rec[col["year"]])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: year %q is not a number", line, rec[col["year"]])
+		}
+		b := hubclient.Book{Title: rec[col["title"]], Author: rec[col["author"]], Year: year}
+		if i, ok := col["isbn"]; ok && rec[i] != "" {
+			b.Isbn = &rec[i]
+		}
+		books = append(books, b)
+	}
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/hubclient"
+)
+
+func newFilesCmd() *cobra.Command {
+	cmd := &cobra.Command{Use: "files", Short: "Upload and list files (files example)"}
+	cmd.AddCommand(newFilesUploadCmd(), newFilesListCmd())
+	return cmd
+}
+
+func newFilesUploadCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "upload FILE...",
+		Short: "Upload files one request at a time",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := client("files-url")
+			if err != nil {
+				return err
+			}
+			for _, path := range args {
+				body, contentType, err := multipartFile(path)
+				if err != nil {
+					return err
+				}
+				resp, err := c.UploadFileWithBodyWithResponse(cmd.Context(), contentType, body)
+				if err != nil {
+					return err
+				}
+				if resp.JSON201 == nil {
+					return fmt.Errorf("%s: %w", path, apiError(resp.HTTPResponse, resp.Body, resp.JSONDefault))
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "uploaded %s as %s\n", resp.JSON201.Filename, resp.JSON201.Id)
+			}
+			return nil
+		},
+	}
+}
+
+// multipartFile builds the form the files example expects: the content under
+// a "file" field.
+func multipartFile(path string) (io.Reader, string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, "", err
+	}
+	defer f.Close()
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	part, err := w.CreateFormFile("file", filepath.Base(path))
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err := io.Copy(part, f); err != nil {
+		return nil, "", err
+	}
+	if err := w.Close(); err != nil {
+		return nil, "", err
+	}
+	return &buf, w.FormDataContentType(), nil
+}
+
+func newFilesListCmd() *cobra.Command {
+	var limit, offset int
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List uploaded files",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := client("files-url")
+			if err != nil {
+				return err
+			}
+			resp, err := c.ListFilesWithResponse(cmd.Context(), &hubclient.ListFilesParams{Limit: &limit, Offset: &offset})
+			if err != nil {
+				return err
+			}
+			if resp.JSON200 == nil {
+				return apiError(resp.HTTPResponse, resp.Body, resp.JSONDefault)
+			}
+			if cfg.GetBool("json") {
+				return printJSON(cmd, resp.JSON200)
+			}
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tFILENAME\tSIZE\tTYPE")
+			for _, f := range resp.JSON200.Items {
+				fmt.Fprintf(w, "%s\t%s\t%d\t%s\n", f.Id, f.Filename, f.Size, f.ContentType)
+			}
+			fmt.Fprintf(w, "\n%d of %d\n", len(resp.JSON200.Items), resp.JSON200.Total)
+			return w.Flush()
+		},
+	}
+	cmd.Flags().IntVar(&limit, "limit", 20, "page size")
+	cmd.Flags().IntVar(&offset, "offset", 0, "items to skip")
+	return cmd
+}
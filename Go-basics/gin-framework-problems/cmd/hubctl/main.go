@@ -0,0 +1,24 @@
+// Command hubctl calls the running example APIs from the command line:
+//
+//	hubctl users create --username alice --email alice@example.com --password password1
+//	hubctl login --username alice --password password1
+//	hubctl books import books.csv
+//	hubctl files upload notes.pdf
+//	hubctl ratelimit status
+//
+// Each example runs as its own server, so every API has its own base URL
+// (flags, HUBCTL_* environment variables or ~/.hubctl.yaml). The HTTP client
+// is generated from internal/hubclient/openapi.yaml.
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+func main() {
+	if err := newRootCmd().Execute(); err != nil {
+		fmt.Fprintln(os.Stderr, "hubctl:", err)
+		os.Exit(1)
+	}
+}
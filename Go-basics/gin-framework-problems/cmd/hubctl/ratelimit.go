@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func newRateLimitCmd() *cobra.Command {
+	cmd := &cobra.Command{Use: "ratelimit", Short: "Inspect rate limiting (ratelimit example)"}
+	cmd.AddCommand(&cobra.Command{
+		Use:   "status",
+		Short: "Show this client's remaining request budget",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := client("ratelimit-url")
+			if err != nil {
+				return err
+			}
+			resp, err := c.RateLimitStatusWithResponse(cmd.Context())
+			if err != nil {
+				return err
+			}
+			if resp.JSON200 == nil {
+				return apiError(resp.HTTPResponse, resp.Body, resp.JSONDefault)
+			}
+			if cfg.GetBool("json") {
+				return printJSON(cmd, resp.JSON200)
+			}
+			s := resp.JSON200
+			fmt.Fprintf(cmd.OutOrStdout(), "%d of %d requests left in a %ds window\n", s.Remaining, s.Limit, s.WindowSeconds)
+			if s.ResetSeconds != nil && *s.ResetSeconds > 0 {
+				fmt.Fprintf(cmd.OutOrStdout(), "full again in %ds\n", *s.ResetSeconds)
+			}
+			return nil
+		},
+	})
+	return cmd
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/hubclient"
+)
+
+// cfg holds the merged settings: flags win over HUBCTL_* variables, which
+// win over the config file.
+var cfg = viper.New()
+
+func newRootCmd() *cobra.Command {
+	var cfgFile string
+	root := &cobra.Command{
+		Use:           "hubctl",
+		Short:         "Call the Tech Learning Hub example APIs",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return loadConfig(cfgFile)
+		},
+	}
+
+	flags := root.PersistentFlags()
+	flags.StringVar(&cfgFile, "config", "", "config file (default $HOME/.hubctl.yaml)")
+	flags.String("users-url", "http://localhost:8080", "users example base URL")
+	flags.String("books-url", "http://localhost:8080", "books example base URL")
+	flags.String("files-url", "http://localhost:8080", "files example base URL")
+	flags.String("ratelimit-url", "http://localhost:8080", "ratelimit example base URL")
+	flags.String("token", "", "bearer token sent with every request")
+	flags.Duration("timeout", 10*time.Second, "per-request timeout")
+	flags.Bool("json", false, "print raw JSON responses")
+	for _, name := range []string{"users-url", "books-url", "files-url", "ratelimit-url", "token", "timeout", "json"} {
+		if err := cfg.BindPFlag(name, flags.Lookup(name)); err != nil {
+			panic(err)
+		}
+	}
+	cfg.SetEnvPrefix("hubctl")
+	cfg.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
+	cfg.AutomaticEnv()
+
+	root.AddCommand(newUsersCmd(), newLoginCmd(), newBooksCmd(), newFilesCmd(), newRateLimitCmd())
+	return root
+}
+
+// loadConfig reads the config file. Without --config a missing
+// ~/.hubctl.yaml is fine.
+func loadConfig(path string) error {
+	if path != "" {
+		cfg.SetConfigFile(path)
+	} else {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil
+		}
+		cfg.AddConfigPath(home)
+		cfg.SetConfigName(".hubctl")
+		cfg.SetConfigType("yaml")
+	}
+	if err := cfg.ReadInConfig(); err != nil {
+		var notFound viper.ConfigFileNotFoundError
+		if path == "" && errors.As(err, &notFound) {
+			return nil
+		}
+		return fmt.Errorf("config: %w", err)
+	}
+	return nil
+}
+
+// client returns a generated client for the API whose base URL is under
+// urlKey, sending the configured token if there is one.
+func client(urlKey string) (*hubclient.ClientWithResponses, error) {
+	opts := []hubclient.ClientOption{
+		hubclient.WithHTTPClient(&http.Client{Timeout: cfg.GetDuration("timeout")}),
+	}
+	if token := cfg.GetString("token"); token != "" {
+		opts = append(opts, hubclient.WithRequestEditorFn(func(_ context.Context, req *http.Request) error {
+			req.Header.Set("Authorization", "Bearer "+token)
+			return nil
+		}))
+	}
+	return hubclient.NewClientWithResponses(cfg.GetString(urlKey), opts...)
+}
+
+// apiError describes a response that wasn't the expected status, using the
+// examples' error envelope when the body is one.
+func apiError(resp *http.Response, body []byte, e *hubclient.Error) error {
+	if e == nil {
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	msg := fmt.Sprintf("%s: %s (%s)", resp.Status, e.Error, e.Code)
+	if e.RequestId != nil {
+		msg += " request_id=" + *e.RequestId
+	}
+	return errors.New(msg)
+}
+
+// printJSON writes v indented; used for --json and for commands whose result
+// has no better rendering.
+func printJSON(cmd *cobra.Command, v any) error {
+	enc := json.NewEncoder(cmd.OutOrStdout())
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/hubclient"
+)
+
+func newUsersCmd() *cobra.Command {
+	cmd := &cobra.Command{Use: "users", Short: "Manage users (users example)"}
+	cmd.AddCommand(newUsersCreateCmd())
+	return cmd
+}
+
+func newUsersCreateCmd() *cobra.Command {
+	var req hubclient.RegisterRequest
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "Register a user",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := client("users-url")
+			if err != nil {
+				return err
+			}
+			resp, err := c.RegisterWithResponse(cmd.Context(), req)
+			if err != nil {
+				return err
+			}
+			if resp.JSON201 == nil {
+				return apiError(resp.HTTPResponse, resp.Body, resp.JSONDefault)
+			}
+			if cfg.GetBool("json") {
+				return printJSON(cmd, resp.JSON201)
+			}
+			u := resp.JSON201
+			fmt.Fprintf(cmd.OutOrStdout(), "created user %s (id %s, role %s)\n", u.Username, u.Id, u.Role)
+			return nil
+		},
+	}
+	f := cmd.Flags()
+	f.StringVar(&req.Username, "username", "", "username (3+ characters)")
+	f.StringVar(&req.Email, "email", "", "email address")
+	f.StringVar(&req.Password, "password", "", "password (8+ characters with a letter and a digit)")
+	for _, name := range []string{"username", "email", "password"} {
+		cmd.MarkFlagRequired(name)
+	}
+	return cmd
+}
+
+// newLoginCmd prints a token, so it can be captured:
+//
+//	export HUBCTL_TOKEN=$(hubctl login --username alice --password password1)
+func newLoginCmd() *cobra.Command {
+	var req hubclient.LoginRequest
+	cmd := &cobra.Command{
+		Use:   "login",
+		Short: "Log in and print a bearer token",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := client("users-url")
+			if err != nil {
+				return err
+			}
+			resp, err := c.LoginWithResponse(cmd.Context(), req)
+			if err != nil {
+				return err
+			}
+			if resp.JSON200 == nil {
+				return apiError(resp.HTTPResponse, resp.Body, resp.JSONDefault)
+			}
+			if cfg.GetBool("json") {
+				return printJSON(cmd, resp.JSON200)
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), resp.JSON200.Token)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&req.Username, "username", "", "username")
+	cmd.Flags().StringVar(&req.Password, "password", "", "password")
+	cmd.MarkFlagRequired("username")
+	cmd.MarkFlagRequired("password")
+	return cmd
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// headerFlags collects repeated -H "Name: value" flags.
+type headerFlags http.Header
+
+func (h headerFlags) String() string { return "" }
+
+func (h headerFlags) Set(s string) error {
+	name, value, ok := strings.Cut(s, ":")
+	if !ok {
+		return fmt.Errorf("header %q is not Name: value", s)
+	}
+	http.Header(h).Add(strings.TrimSpace(name), strings.TrimSpace(value))
+	return nil
+}
+
+type attack struct {
+	method   string
+	url      string
+	body     []byte
+	header   http.Header
+	workers  int
+	duration time.Duration
+	requests int // stop after this many, when above 0
+	client   *http.Client
+}
+
+// result is what one worker saw.
+type result struct {
+	latencies []time.Duration // of every response, whatever its status
+	statuses  map[string]int
+}
+
+func runHTTP(args []string) error {
+	fs := flag.NewFlagSet("http", flag.ExitOnError)
+	a := attack{header: http.Header{}}
+	var out reportFlags
+	var body string
+	var timeout time.Duration
+	fs.StringVar(&a.url, "url", "http://localhost:8080/books", "URL to request")
+	fs.StringVar(&a.method, "method", http.MethodGet, "HTTP method")
+	fs.StringVar(&body, "body", "", "request body; @file reads it from a file")
+	fs.Var(headerFlags(a.header), "H", `request header "Name: value", repeatable`)
+	fs.IntVar(&a.workers, "c", 10, "concurrent workers")
+	fs.DurationVar(&a.duration, "d", 10*time.Second, "how long to send requests")
+	fs.IntVar(&a.requests, "n", 0, "stop after this many requests (0: run for -d)")
+	fs.DurationVar(&timeout, "timeout", 10*time.Second, "per-request timeout")
+	out.register(fs)
+	fs.Parse(args)
+
+	if a.workers < 1 {
+		return errors.New("-c must be at least 1")
+	}
+	if path, ok := strings.CutPrefix(body, "@"); ok {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		a.body = data
+	} else {
+		a.body = []byte(body)
+	}
+	if len(a.body) > 0 && a.header.Get("Content-Type") == "" {
+		a.header.Set("Content-Type", "application/json")
+	}
+	// a plain client: the retries and circuit breaker of internal/httpclient
+	// would hide exactly what this is here to measure
+	a.client = &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			MaxIdleConns:        a.workers,
+			MaxIdleConnsPerHost: a.workers,
+		},
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	fmt.Fprintf(os.Stderr, "%s %s with %d workers for %s\n", a.method, a.url, a.workers, a.limit())
+	start := time.Now()
+	res := a.run(ctx)
+	elapsed := time.Since(start)
+
+	r := newReport("http", a.method+" "+a.url)
+	r.Statuses = res.statuses
+	r.Requests = len(res.latencies) + res.statuses["error"]
+	r.Metrics = append(r.Metrics, metric{
+		Name: "throughput", Value: float64(len(res.latencies)) / elapsed.Seconds(), Unit: "req/s", HigherIsBetter: true,
+	})
+	lat := res.latencies
+	slices.Sort(lat)
+	var total time.Duration
+	for _, d := range lat {
+		total += d
+	}
+	if len(lat) > 0 {
+		r.add("latency_mean", ms(total/time.Duration(len(lat))), "ms")
+	}
+	for _, p := range []float64{50, 90, 99} {
+		r.add("latency_p"+strconv.Itoa(int(p)), ms(percentile(lat, p)), "ms")
+	}
+	if len(lat) > 0 {
+		r.add("latency_max", ms(lat[len(lat)-1]), "ms")
+	}
+	r.add("failures", failureRate(res.statuses, r.Requests), "%")
+	return out.finish(r)
+}
+
+func (a *attack) limit() string {
+	if a.requests > 0 {
+		return strconv.Itoa(a.requests) + " requests"
+	}
+	return a.duration.String()
+}
+
+// run sends requests from a.workers goroutines until the duration is up, the
+// request budget is spent or ctx is cancelled, and merges what they saw.
+func (a *attack) run(ctx context.Context) result {
+	if a.requests == 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, a.duration)
+		defer cancel()
+	}
+	var sent atomic.Int64
+	results := make([]result, a.workers)
+	var wg sync.WaitGroup
+	for i := range results {
+		wg.Go(func() {
+			res := result{statuses: map[string]int{}}
+			for ctx.Err() == nil {
+				if a.requests > 0 && sent.Add(1) > int64(a.requests) {
+					break
+				}
+				status, d, err := a.do(ctx)
+				switch {
+				case ctx.Err() != nil:
+					// cut off by the deadline, not a failure of the server
+				case err != nil:
+					res.statuses["error"]++
+				default:
+					res.statuses[strconv.Itoa(status)]++
+					res.latencies = append(res.latencies, d)
+				}
+			}
+			results[i] = res
+		})
+	}
+	wg.Wait()
+
+	all := result{statuses: map[string]int{}}
+	for _, res := range results {
+		all.latencies = append(all.latencies, res.latencies...)
+		for k, n := range res.statuses {
+			all.statuses[k] += n
+		}
+	}
+	return all
+}
+
+// do sends one request and reads the whole response, which is part of the
+// latency.
+func (a *attack) do(ctx context.Context) (int, time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, a.method, a.url, bytes.NewReader(a.body))
+	if err != nil {
+		return 0, 0, err
+	}
+	req.Header = a.header.Clone()
+	start := time.Now()
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return 0, 0, err
+	}
+	_, err = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	return resp.StatusCode, time.Since(start), err
+}
+
+// percentile returns the p-th percentile of sorted, by nearest rank.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := int(float64(len(sorted))*p/100+0.5) - 1
+	return sorted[min(max(i, 0), len(sorted)-1)]
+}
+
+// failureRate is the percentage of requests that got a 5xx or no response.
+func failureRate(statuses map[string]int, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	failed := statuses["error"]
+	for code, n := range statuses {
+		if strings.HasPrefix(code, "5") {
+			failed += n
+		}
+	}
+	return float64(failed) / float64(total) * 100
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
@@ -0,0 +1,78 @@
+// Command loadtest measures the hub's performance against a running example:
+//
+//	go run ./cmd/loadtest http -url http://localhost:8080/books -c 20 -d 10s -o books.json
+//	go run ./cmd/loadtest http -url http://localhost:8080/books -n 5000 -baseline books.json
+//
+// http sends requests from a pool of -c workers for -d, or until -n requests,
+// and reports throughput, latency percentiles and status codes. The hot paths
+// in-process have Benchmark functions next to them, run with go test -bench.
+//
+// It writes its report as JSON with -o. With -baseline it prints each number
+// next to the baseline's and exits 1 when one got worse by more than
+// -max-regression percent, so a change can be checked before it's merged.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	var err error
+	switch os.Args[1] {
+	case "http":
+		err = runHTTP(os.Args[2:])
+	default:
+		usage()
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "loadtest:", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: loadtest http [flags]")
+	os.Exit(2)
+}
+
+// reportFlags are the output flags of a report.
+type reportFlags struct {
+	out           string
+	baseline      string
+	maxRegression float64
+}
+
+func (f *reportFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.out, "o", "", "write the report as JSON to this file")
+	fs.StringVar(&f.baseline, "baseline", "", "compare with the report in this file")
+	fs.Float64Var(&f.maxRegression, "max-regression", 10, "percent a metric may get worse than the baseline")
+}
+
+// finish prints r, compares it with the baseline and saves it.
+func (f *reportFlags) finish(r *report) error {
+	var base *report
+	if f.baseline != "" {
+		var err error
+		if base, err = loadReport(f.baseline); err != nil {
+			return err
+		}
+		if base.Kind != r.Kind {
+			return fmt.Errorf("baseline %s is a %s report, not %s", f.baseline, base.Kind, r.Kind)
+		}
+	}
+	regressed := r.print(os.Stdout, base, f.maxRegression)
+	if f.out != "" {
+		if err := r.save(f.out); err != nil {
+			return err
+		}
+	}
+	if len(regressed) > 0 {
+		return fmt.Errorf("worse than the baseline by more than %g%%: %v", f.maxRegression, regressed)
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"text/tabwriter"
+	"time"
+)
+
+// metric is one measured number. Lower is better unless HigherIsBetter.
+type metric struct {
+	Name           string  `json:"name"`
+	Value          float64 `json:"value"`
+	Unit           string  `json:"unit"`
+	HigherIsBetter bool    `json:"higher_is_better,omitempty"`
+}
+
+// report is the outcome of one run, saved with -o and read back with
+// -baseline.
+type report struct {
+	Kind       string         `json:"kind"` // http
+	Target     string         `json:"target,omitempty"`
+	GoVersion  string         `json:"go_version"`
+	GOMAXPROCS int            `json:"gomaxprocs"`
+	CreatedAt  time.Time      `json:"created_at"`
+	Requests   int            `json:"requests,omitempty"`
+	Statuses   map[string]int `json:"statuses,omitempty"` // by status code, or "error"
+	Metrics    []metric       `json:"metrics"`
+}
+
+func newReport(kind, target string) *report {
+	return &report{
+		Kind:       kind,
+		Target:     target,
+		GoVersion:  runtime.Version(),
+		GOMAXPROCS: runtime.GOMAXPROCS(0),
+		CreatedAt:  time.Now().UTC(),
+	}
+}
+
+func (r *report) add(name string, value float64, unit string) {
+	r.Metrics = append(r.Metrics, metric{Name: name, Value: value, Unit: unit})
+}
+
+func loadReport(path string) (*report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var r report
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	return &r, nil
+}
+
+func (r *report) save(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// print writes r as a table, next to base when it isn't nil, and returns the
+// metrics that got worse than base by more than maxRegression percent.
+// Metrics missing from either report are shown but not compared.
+func (r *report) print(w io.Writer, base *report, maxRegression float64) (regressed []string) {
+	if r.Target != "" {
+		fmt.Fprintf(w, "target: %s\n", r.Target)
+	}
+	if r.Requests > 0 {
+		fmt.Fprintf(w, "requests: %d, statuses: %v\n", r.Requests, r.Statuses)
+	}
+
+	before := map[string]metric{}
+	if base != nil {
+		fmt.Fprintf(w, "baseline: %s (%s, GOMAXPROCS=%d)\n", base.CreatedAt.Format(time.RFC3339), base.GoVersion, base.GOMAXPROCS)
+		for _, m := range base.Metrics {
+			before[m.Name] = m
+		}
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', tabwriter.AlignRight)
+	if base == nil {
+		fmt.Fprintln(tw, "METRIC\tVALUE\tUNIT\t")
+	} else {
+		fmt.Fprintln(tw, "METRIC\tVALUE\tBASELINE\tDELTA\tUNIT\t")
+	}
+	for _, m := range r.Metrics {
+		if base == nil {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t\n", m.Name, format(m.Value), m.Unit)
+			continue
+		}
+		old, ok := before[m.Name]
+		if !ok || old.Unit != m.Unit {
+			fmt.Fprintf(tw, "%s\t%s\t-\t-\t%s\t\n", m.Name, format(m.Value), m.Unit)
+			continue
+		}
+		delta, worse := change(old, m)
+		mark := ""
+		if worse > maxRegression {
+			mark = " !"
+			regressed = append(regressed, m.Name)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s%s\t%s\t\n", m.Name, format(m.Value), format(old.Value), delta, mark, m.Unit)
+	}
+	tw.Flush()
+	return regressed
+}
+
+// change describes the move from old to m in percent, and returns how much
+// worse m is, which is negative when it improved.
+func change(old, m metric) (string, float64) {
+	if old.Value == 0 {
+		if m.Value == 0 {
+			return "0.0%", 0
+		}
+		return "new", 0 // nothing to scale against
+	}
+	pct := (m.Value - old.Value) / old.Value * 100
+	worse := pct
+	if m.HigherIsBetter {
+		worse = -pct
+	}
+	return fmt.Sprintf("%+.1f%%", pct), worse
+}
+
+func format(v float64) string {
+	switch {
+	case v == float64(int64(v)):
+		return fmt.Sprintf("%d", int64(v))
+	case v >= 100:
+		return fmt.Sprintf("%.0f", v)
+	default:
+		return fmt.Sprintf("%.2f", v)
+	}
+}
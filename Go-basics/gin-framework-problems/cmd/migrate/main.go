@@ -0,0 +1,87 @@
+// Command migrate manages the SQLite schema in internal/database/migrations:
+//
+//	go run ./cmd/migrate [-db-path ./data/hub.db] up|down|status|version
+//
+// up applies every pending migration, down rolls back the latest one. The
+// database path comes from internal/config like every other setting.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/config"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/database"
+)
+
+var commands = map[string]bool{"up": true, "down": true, "status": true, "version": true}
+
+func main() {
+	cfg, err := config.Load(flag.CommandLine, os.Args[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
+	cmd := flag.Arg(0)
+	if flag.NArg() != 1 || !commands[cmd] {
+		fmt.Fprintln(os.Stderr, "usage: migrate [flags] up|down|status|version")
+		os.Exit(2)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	db, err := database.Open(ctx, cfg.Database.Path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+	m, err := database.NewMigrator(db)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	switch cmd {
+	case "up":
+		results, err := m.Up(ctx)
+		for _, r := range results {
+			fmt.Println(r)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(results) == 0 {
+			fmt.Println("no pending migrations")
+		}
+	case "down":
+		r, err := m.Down(ctx)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(r)
+	case "status":
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			log.Fatal(err)
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tFILE\tSTATE\tAPPLIED AT")
+		for _, s := range statuses {
+			applied := "-"
+			if !s.AppliedAt.IsZero() {
+				applied = s.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", s.Source.Version, s.Source.Path, s.State, applied)
+		}
+		w.Flush()
+	case "version":
+		v, err := m.Version(ctx)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(v)
+	}
+}
@@ -0,0 +1,27 @@
+// Package antivirus checks uploads for malware before they are stored. Clamd
+// talks to a ClamAV daemon; anything else that can say whether a stream is
+// infected can stand in for it as a Scanner.
+package antivirus
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrUnavailable wraps every failure to get a verdict: the scanner can't be
+// reached, times out or reports an error of its own. Callers decide whether
+// that refuses the upload or lets it through.
+var ErrUnavailable = errors.New("antivirus: scanner unavailable")
+
+// Verdict is what a scanner found in a stream.
+type Verdict struct {
+	Infected  bool
+	Signature string // the name of what was found, such as "Eicar-Signature"
+}
+
+// Scanner reads r to the end, or as far as it needs, and says whether it is
+// infected.
+type Scanner interface {
+	Scan(ctx context.Context, r io.Reader) (Verdict, error)
+}
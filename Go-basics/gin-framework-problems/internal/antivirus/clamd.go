@@ -0,0 +1,122 @@
+package antivirus
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// chunkSize is how much of the stream goes in each INSTREAM chunk.
+const chunkSize = 32 << 10
+
+// Clamd scans with a ClamAV daemon over its socket, one connection per scan,
+// sending the stream with the INSTREAM command. Files larger than clamd's
+// StreamMaxLength come back as errors, so raise it to at least the largest
+// upload.
+type Clamd struct {
+	network string // unix or tcp
+	addr    string
+	timeout time.Duration
+}
+
+// NewClamd connects to addr, a path such as /run/clamav/clamd.ctl for the
+// unix socket or host:port for TCP. Each scan gives up after timeout.
+func NewClamd(addr string, timeout time.Duration) *Clamd {
+	network := "tcp"
+	if strings.HasPrefix(addr, "/") {
+		network = "unix"
+	}
+	return &Clamd{network: network, addr: addr, timeout: timeout}
+}
+
+func (d *Clamd) Scan(ctx context.Context, r io.Reader) (Verdict, error) {
+	var readErr error // the upload's, which isn't the scanner being unavailable
+	reply, err := d.command(ctx, "INSTREAM", func(w io.Writer) error {
+		buf := make([]byte, chunkSize)
+		for {
+			n, err := io.ReadFull(r, buf)
+			if n > 0 {
+				if err := binary.Write(w, binary.BigEndian, uint32(n)); err != nil {
+					return err
+				}
+				if _, err := w.Write(buf[:n]); err != nil {
+					return err
+				}
+			}
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				// a zero-length chunk ends the stream
+				return binary.Write(w, binary.BigEndian, uint32(0))
+			}
+			if err != nil {
+				readErr = err
+				return err
+			}
+		}
+	})
+	if readErr != nil {
+		return Verdict{}, readErr
+	}
+	if err != nil {
+		return Verdict{}, err
+	}
+	// "stream: OK", "stream: <signature> FOUND" or "<message> ERROR"
+	result := strings.TrimPrefix(reply, "stream: ")
+	switch {
+	case result == "OK":
+		return Verdict{}, nil
+	case strings.HasSuffix(result, " FOUND"):
+		return Verdict{Infected: true, Signature: strings.TrimSuffix(result, " FOUND")}, nil
+	}
+	return Verdict{}, fmt.Errorf("%w: clamd: %s", ErrUnavailable, result)
+}
+
+// Ping is a readiness check: clamd must answer PING.
+func (d *Clamd) Ping(ctx context.Context) error {
+	reply, err := d.command(ctx, "PING", nil)
+	if err == nil && reply != "PONG" {
+		err = fmt.Errorf("%w: clamd: unexpected reply %q", ErrUnavailable, reply)
+	}
+	return err
+}
+
+// command sends a null-terminated command, then whatever send writes, and
+// reads the null-terminated reply.
+func (d *Clamd) command(ctx context.Context, cmd string, send func(io.Writer) error) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, d.timeout)
+	defer cancel()
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, d.network, d.addr)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	defer conn.Close()
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+
+	w := bufio.NewWriter(conn)
+	w.WriteString("z" + cmd + "\x00")
+	var sendErr error
+	if send != nil {
+		sendErr = send(w)
+	}
+	if sendErr == nil {
+		sendErr = w.Flush()
+	}
+	// clamd hangs up mid-stream when it's over StreamMaxLength, and says
+	// why, so read the reply even after a failed write
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil {
+		if sendErr != nil {
+			err = sendErr
+		}
+		return "", fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	return strings.TrimSuffix(reply, "\x00"), nil
+}
@@ -0,0 +1,76 @@
+package antivirus
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeClamd answers INSTREAM like clamd, flagging streams that contain
+// "EICAR", until the test ends. It returns its address.
+func fakeClamd(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				cmd, _ := r.ReadString(0)
+				if cmd == "zPING\x00" {
+					conn.Write([]byte("PONG\x00"))
+					return
+				}
+				var stream bytes.Buffer
+				for {
+					var n uint32
+					if binary.Read(r, binary.BigEndian, &n) != nil || n == 0 {
+						break
+					}
+					io.CopyN(&stream, r, int64(n))
+				}
+				if strings.Contains(stream.String(), "EICAR") {
+					conn.Write([]byte("stream: Eicar-Signature FOUND\x00"))
+				} else {
+					conn.Write([]byte("stream: OK\x00"))
+				}
+			}()
+		}
+	}()
+	return l.Addr().String()
+}
+
+func TestClamd(t *testing.T) {
+	d := NewClamd(fakeClamd(t), time.Second)
+	if err := d.Ping(t.Context()); err != nil {
+		t.Fatalf("Ping: %v", err)
+	}
+	// larger than one chunk, so the stream is split
+	clean := strings.Repeat("a", chunkSize+1)
+	if v, err := d.Scan(t.Context(), strings.NewReader(clean)); err != nil || v.Infected {
+		t.Errorf("clean file: %+v, %v", v, err)
+	}
+	v, err := d.Scan(t.Context(), strings.NewReader(clean+"EICAR"))
+	if err != nil || !v.Infected || v.Signature != "Eicar-Signature" {
+		t.Errorf("infected file: %+v, %v", v, err)
+	}
+
+	down := NewClamd("127.0.0.1:1", time.Second)
+	if _, err := down.Scan(t.Context(), strings.NewReader(clean)); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("scanner down: err = %v, want ErrUnavailable", err)
+	}
+}
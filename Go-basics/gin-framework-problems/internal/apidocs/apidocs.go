@@ -0,0 +1,180 @@
+// Package apidocs builds an OpenAPI 3 document of the running example from
+// the operations its router registers, and serves it at /openapi.json with
+// Swagger UI at /docs.
+//
+// The document is made from code rather than annotations: each example
+// lists its routes as Operations, with values of the Go types they read and
+// write, and the schemas follow those types' json and binding tags. Routes
+// left out aren't documented, so the document of an example without any is
+// empty.
+package apidocs
+
+import (
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Operation documents one route.
+type Operation struct {
+	Method string
+	// Path is the route as gin has it, such as /books/:id; its parameters
+	// are documented as strings.
+	Path    string
+	Summary string
+	Tag     string // groups operations in the UI, such as "books"
+	Auth    bool   // needs a bearer token
+	// Query describes query parameters by name.
+	Query map[string]string
+	// Request is a value of the JSON body's type, nil for no body. Upload
+	// takes a multipart form with a file field instead.
+	Request any
+	Upload  bool
+	// Status is the success status, 200 by default. Response is a value of
+	// its JSON body's type, nil for no body or one that isn't JSON; a map
+	// such as a gin.H is described by the values in it.
+	Status   int
+	Response any
+}
+
+// Registry collects the operations of the running example.
+type Registry struct {
+	mu  sync.Mutex
+	ops []Operation
+}
+
+// Default is the registry the examples add to and NewEngine serves.
+var Default = &Registry{}
+
+// Add documents ops, replacing what was documented for the same method and
+// path.
+func (r *Registry) Add(ops ...Operation) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, op := range ops {
+		i := slices.IndexFunc(r.ops, func(o Operation) bool { return o.Method == op.Method && o.Path == op.Path })
+		if i >= 0 {
+			r.ops[i] = op
+		} else {
+			r.ops = append(r.ops, op)
+		}
+	}
+}
+
+// Document is the OpenAPI 3 document of the operations added so far.
+func (r *Registry) Document(title, version string) map[string]any {
+	r.mu.Lock()
+	ops := slices.Clone(r.ops)
+	r.mu.Unlock()
+
+	// the error envelope every route may answer with
+	s := schemas{}
+	s["Error"] = s.object(errorType)
+	paths := map[string]map[string]any{}
+	for _, op := range ops {
+		path, params := openAPIPath(op.Path)
+		if paths[path] == nil {
+			paths[path] = map[string]any{}
+		}
+		paths[path][strings.ToLower(op.Method)] = s.operation(op, params)
+	}
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info":    map[string]any{"title": title, "version": version},
+		"paths":   paths,
+		"components": map[string]any{
+			"schemas": s,
+			"responses": map[string]any{
+				"Error": map[string]any{
+					"description": "Error",
+					"content":     jsonContent(ref("Error")),
+				},
+			},
+			"securitySchemes": map[string]any{
+				"bearer": map[string]any{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+			},
+		},
+	}
+}
+
+// Handler serves the document as JSON.
+func (r *Registry) Handler(title, version string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, r.Document(title, version))
+	}
+}
+
+func (s schemas) operation(op Operation, params []string) map[string]any {
+	var parameters []map[string]any
+	for _, name := range params {
+		parameters = append(parameters, map[string]any{
+			"name": name, "in": "path", "required": true, "schema": map[string]any{"type": "string"},
+		})
+	}
+	for _, name := range slices.Sorted(maps.Keys(op.Query)) {
+		parameters = append(parameters, map[string]any{
+			"name": name, "in": "query", "description": op.Query[name], "schema": map[string]any{"type": "string"},
+		})
+	}
+
+	out := map[string]any{"summary": op.Summary}
+	if op.Tag != "" {
+		out["tags"] = []string{op.Tag}
+	}
+	if len(parameters) > 0 {
+		out["parameters"] = parameters
+	}
+	switch {
+	case op.Upload:
+		out["requestBody"] = map[string]any{
+			"required": true,
+			"content": map[string]any{"multipart/form-data": map[string]any{"schema": map[string]any{
+				"type":       "object",
+				"required":   []string{"file"},
+				"properties": map[string]any{"file": map[string]any{"type": "string", "format": "binary"}},
+			}}},
+		}
+	case op.Request != nil:
+		out["requestBody"] = map[string]any{"required": true, "content": jsonContent(s.value(op.Request))}
+	}
+	if op.Auth {
+		out["security"] = []map[string][]string{{"bearer": {}}}
+	}
+
+	status := op.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success := map[string]any{"description": http.StatusText(status)}
+	if op.Response != nil {
+		success["content"] = jsonContent(s.value(op.Response))
+	}
+	out["responses"] = map[string]any{
+		strconv.Itoa(status): success,
+		"default":            map[string]any{"$ref": "#/components/responses/Error"},
+	}
+	return out
+}
+
+// openAPIPath turns gin's /books/:id and /folders/*path into OpenAPI's
+// /books/{id} and /folders/{path}, with the names of the parameters.
+func openAPIPath(path string) (string, []string) {
+	segments := strings.Split(path, "/")
+	var params []string
+	for i, seg := range segments {
+		if len(seg) > 1 && (seg[0] == ':' || seg[0] == '*') {
+			params = append(params, seg[1:])
+			segments[i] = "{" + seg[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/"), params
+}
+
+func jsonContent(schema map[string]any) map[string]any {
+	return map[string]any{"application/json": map[string]any{"schema": schema}}
+}
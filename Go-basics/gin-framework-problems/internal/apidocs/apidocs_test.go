@@ -0,0 +1,87 @@
+package apidocs_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/apidocs"
+)
+
+type note struct {
+	Title string   `json:"title" binding:"required"`
+	Email string   `json:"email,omitempty" binding:"required,email"`
+	Tags  []string `json:"tags,omitempty"`
+	Draft bool     `json:"-"`
+}
+
+// get follows a path of keys into a decoded document.
+func get(t *testing.T, doc map[string]any, keys ...string) any {
+	t.Helper()
+	var v any = doc
+	for _, k := range keys {
+		m, ok := v.(map[string]any)
+		if !ok {
+			t.Fatalf("no %s in %v", strings.Join(keys, "."), doc)
+		}
+		v = m[k]
+	}
+	return v
+}
+
+func TestDocument(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	reg := &apidocs.Registry{}
+	reg.Add(apidocs.Operation{Method: http.MethodGet, Path: "/notes/:id", Summary: "old"})
+	reg.Add(
+		apidocs.Operation{Method: http.MethodGet, Path: "/notes/:id", Summary: "Get a note", Response: note{}},
+		apidocs.Operation{Method: http.MethodPost, Path: "/notes", Auth: true, Request: note{}, Status: http.StatusCreated},
+	)
+	r := gin.New()
+	r.GET("/openapi.json", reg.Handler("Notes", "1.0.0"))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d", w.Code)
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	// the later Add replaced the first, and gin's :id became {id}
+	if got := get(t, doc, "paths", "/notes/{id}", "get", "summary"); got != "Get a note" {
+		t.Errorf("summary = %v", got)
+	}
+	params := get(t, doc, "paths", "/notes/{id}", "get", "parameters").([]any)
+	if len(params) != 1 || params[0].(map[string]any)["name"] != "id" || params[0].(map[string]any)["in"] != "path" {
+		t.Errorf("parameters = %v", params)
+	}
+	if get(t, doc, "paths", "/notes", "post", "responses", "201") == nil {
+		t.Error("no 201 response for POST /notes")
+	}
+	if get(t, doc, "paths", "/notes", "post", "security") == nil {
+		t.Error("POST /notes isn't marked as needing a token")
+	}
+
+	// omitempty fields are optional unless binding requires them
+	var required []string
+	for _, name := range get(t, doc, "components", "schemas", "note", "required").([]any) {
+		required = append(required, name.(string))
+	}
+	if !slices.Equal(required, []string{"title", "email"}) {
+		t.Errorf("required = %v, want [title email]", required)
+	}
+	props := get(t, doc, "components", "schemas", "note", "properties").(map[string]any)
+	if _, ok := props["Draft"]; ok {
+		t.Error(`json:"-" field documented`)
+	}
+	if got := get(t, doc, "components", "schemas", "note", "properties", "email", "format"); got != "email" {
+		t.Errorf("email format = %v", got)
+	}
+}
@@ -0,0 +1,136 @@
+package apidocs
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/apperror"
+)
+
+// schemas holds the named schemas of a document, by name.
+type schemas map[string]any
+
+var (
+	timeType  = reflect.TypeFor[time.Time]()
+	errorType = reflect.TypeFor[apperror.APIError]()
+)
+
+func ref(name string) map[string]any {
+	return map[string]any{"$ref": "#/components/schemas/" + name}
+}
+
+// value is the schema of v: a map of values by what it holds, anything
+// else by its type.
+func (s schemas) value(v any) map[string]any {
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() {
+		return map[string]any{}
+	}
+	if rv.Kind() != reflect.Map || rv.Type().Key().Kind() != reflect.String || rv.Len() == 0 {
+		return s.of(rv.Type())
+	}
+	props := map[string]any{}
+	var required []string
+	for _, k := range rv.MapKeys() {
+		name := k.String()
+		props[name] = s.value(rv.MapIndex(k).Interface())
+		required = append(required, name)
+	}
+	slices.Sort(required)
+	return map[string]any{"type": "object", "required": required, "properties": props}
+}
+
+// of is the schema of values of type t. Named structs are added to s and
+// referred to.
+func (s schemas) of(t reflect.Type) map[string]any {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return s.of(t.Elem())
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return map[string]any{"type": "integer"}
+	case reflect.Int64, reflect.Uint64:
+		return map[string]any{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "format": "byte"}
+		}
+		return map[string]any{"type": "array", "items": s.of(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": s.of(t.Elem())}
+	case reflect.Struct:
+		if t == timeType {
+			return map[string]any{"type": "string", "format": "date-time"}
+		}
+		if t.Name() == "" {
+			return s.object(t)
+		}
+		name := schemaName(t)
+		if _, ok := s[name]; !ok {
+			s[name] = nil // taken, in case t refers to itself
+			s[name] = s.object(t)
+		}
+		return ref(name)
+	}
+	return map[string]any{}
+}
+
+// object is the schema of a struct, following its json tags. A field is
+// required unless it is omitempty or omitzero, or binding requires it.
+func (s schemas) object(t reflect.Type) map[string]any {
+	props := map[string]any{}
+	var required []string
+	var add func(t reflect.Type)
+	add = func(t reflect.Type) {
+		for i := range t.NumField() {
+			f := t.Field(i)
+			tag := f.Tag.Get("json")
+			if !f.IsExported() || tag == "-" {
+				continue
+			}
+			name, opts, _ := strings.Cut(tag, ",")
+			if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+				add(f.Type)
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			schema := s.of(f.Type)
+			binding := strings.Split(f.Tag.Get("binding"), ",")
+			if slices.Contains(binding, "email") {
+				schema = map[string]any{"type": "string", "format": "email"}
+			}
+			props[name] = schema
+			omit := strings.Contains(opts, "omitempty") || strings.Contains(opts, "omitzero")
+			if !omit || slices.Contains(binding, "required") {
+				required = append(required, name)
+			}
+		}
+	}
+	add(t)
+	out := map[string]any{"type": "object", "properties": props}
+	if len(required) > 0 {
+		out["required"] = required
+	}
+	return out
+}
+
+// schemaName is t's name, with a generic one's type argument in front:
+// pagination.Page[books.Book] is BookPage.
+func schemaName(t reflect.Type) string {
+	name := t.Name()
+	base, arg, ok := strings.Cut(name, "[")
+	if !ok {
+		return name
+	}
+	arg = strings.TrimSuffix(arg, "]")
+	return arg[strings.LastIndex(arg, ".")+1:] + base
+}
@@ -0,0 +1,27 @@
+package apidocs
+
+import (
+	_ "embed"
+	"html/template"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+//go:embed swagger.html
+var swaggerHTML string
+
+var swaggerPage = template.Must(template.New("swagger").Parse(swaggerHTML))
+
+// UI serves Swagger UI showing the document at specURL, for trying the
+// routes from a browser. The page loads Swagger UI's scripts and styles
+// from unpkg.com.
+func UI(specURL string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Status(http.StatusOK)
+		c.Header("Content-Type", "text/html; charset=utf-8")
+		if err := swaggerPage.Execute(c.Writer, specURL); err != nil {
+			c.Error(err)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+// Package apiversion serves several versions of an API side by side. Mount
+// registers an API's routes once per version, under /api/v1, /api/v2 and so
+// on, and once more under the unversioned prefix, where the version comes
+// from the Accept header:
+//
+//	GET /api/v2/profile                                     v2
+//	GET /api/profile  Accept: application/vnd.hub.v2+json   v2
+//	GET /api/profile  Accept: application/json; version=2   v2
+//	GET /api/profile                                        the default, v1
+//
+// A version in the path wins over Accept. Most handlers serve every version
+// alike; one whose payload changed is registered with Handlers, which picks
+// the handler of the request's version. Responses carry the version that
+// served them in API-Version.
+package apiversion
+
+import (
+	"maps"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/apperror"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+)
+
+const (
+	// Key is the gin context key the request's version is stored under.
+	Key = "apiversion"
+	// Header names the version of a response.
+	Header = "API-Version"
+	// vendorType is the media type prefix of a version in Accept, as in
+	// application/vnd.hub.v2+json.
+	vendorType = "application/vnd.hub.v"
+)
+
+// Mount registers the routes routes adds to the group it is given under
+// prefix/vN for each of versions, and under prefix itself, where the version
+// is the one Accept asks for, or def. routes runs once per group, so it
+// must do nothing but register routes.
+func Mount(router gin.IRouter, prefix string, def int, versions []int, routes func(*gin.RouterGroup)) {
+	for _, v := range versions {
+		routes(router.Group(prefix+"/v"+strconv.Itoa(v), pin(v)))
+	}
+	routes(router.Group(prefix, negotiate(def, versions)))
+}
+
+// Version is the API version of the request, 0 outside Mount's groups.
+func Version(c *gin.Context) int {
+	return c.GetInt(Key)
+}
+
+// Handlers serves a route with the handler of the request's version: the
+// one given for the highest version not above it, or the lowest one given.
+// Versions after a breaking change get the new handler, and the ones before
+// keep the old.
+func Handlers(byVersion map[int]gin.HandlerFunc) gin.HandlerFunc {
+	versions := slices.Sorted(maps.Keys(byVersion))
+	return func(c *gin.Context) {
+		v := Version(c)
+		h := byVersion[versions[0]]
+		for _, since := range versions {
+			if since <= v {
+				h = byVersion[since]
+			}
+		}
+		h(c)
+	}
+}
+
+// pin serves a group of one version.
+func pin(v int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		set(c, v)
+		c.Next()
+	}
+}
+
+// negotiate serves the unversioned group, taking the version from Accept.
+// One it doesn't have is a 406.
+func negotiate(def int, versions []int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Writer.Header().Add("Vary", "Accept")
+		v, ok := fromAccept(c.GetHeader("Accept"))
+		switch {
+		case !ok:
+			v = def
+		case !slices.Contains(versions, v):
+			middleware.Fail(c, apperror.New(http.StatusNotAcceptable, apperror.CodeNotAcceptable,
+				"unsupported API version").WithDetails(map[string][]int{"supported": versions}))
+			return
+		}
+		set(c, v)
+		c.Next()
+	}
+}
+
+func set(c *gin.Context, v int) {
+	c.Set(Key, v)
+	c.Header(Header, strconv.Itoa(v))
+}
+
+// fromAccept finds the version Accept asks for, as
+// application/vnd.hub.vN+json or a version=N parameter of any type.
+func fromAccept(accept string) (int, bool) {
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(part)
+		if err != nil {
+			continue
+		}
+		if s, ok := params["version"]; ok {
+			if v, err := strconv.Atoi(strings.TrimPrefix(s, "v")); err == nil {
+				return v, true
+			}
+		}
+		if rest, ok := strings.CutPrefix(mediaType, vendorType); ok {
+			if n, ok := strings.CutSuffix(rest, "+json"); ok {
+				if v, err := strconv.Atoi(n); err == nil {
+					return v, true
+				}
+			}
+		}
+	}
+	return 0, false
+}
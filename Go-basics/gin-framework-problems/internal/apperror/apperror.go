@@ -0,0 +1,173 @@
+// Package apperror defines the errors handlers report with c.Error. Each
+// carries the HTTP status, a stable machine-readable code and a message safe
+// to show clients; the underlying cause is only ever logged.
+package apperror
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+)
+
+// Codes shared by the examples. Clients should branch on these, not on
+// messages, which are localized.
+const (
+	CodeBadRequest      = "bad_request"
+	CodeValidation      = "validation_failed"
+	CodeUnauthorized    = "unauthorized"
+	CodeForbidden       = "forbidden"
+	CodeNotFound        = "not_found"
+	CodeNotAcceptable   = "not_acceptable"
+	CodeConflict        = "conflict"
+	CodeGone            = "gone"
+	CodePrecondition    = "precondition_failed"
+	CodeTooLarge        = "too_large"
+	CodeUnsupported     = "unsupported_media_type"
+	CodeRange           = "range_not_satisfiable"
+	CodeMalware         = "malware_detected"
+	CodeChecksum        = "checksum_mismatch"
+	CodeRateLimited     = "rate_limited"
+	CodeCaptchaRequired = "captcha_required"
+	CodeUnavailable     = "unavailable"
+	CodeTimeout         = "timeout"
+	CodeInternal        = "internal"
+)
+
+// CodeForStatus is the code used for plain status-and-message errors.
+func CodeForStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeBadRequest
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusNotAcceptable:
+		return CodeNotAcceptable
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusGone:
+		return CodeGone
+	case http.StatusPreconditionFailed:
+		return CodePrecondition
+	case http.StatusRequestEntityTooLarge:
+		return CodeTooLarge
+	case http.StatusUnsupportedMediaType:
+		return CodeUnsupported
+	case http.StatusRequestedRangeNotSatisfiable:
+		return CodeRange
+	case http.StatusTooManyRequests:
+		return CodeRateLimited
+	case http.StatusServiceUnavailable, http.StatusBadGateway:
+		return CodeUnavailable
+	case http.StatusGatewayTimeout:
+		return CodeTimeout
+	}
+	if status >= http.StatusInternalServerError {
+		return CodeInternal
+	}
+	return CodeBadRequest
+}
+
+// APIError is the JSON body of every error response: the message, in the
+// client's language, the code to branch on, any details, and the request ID
+// to quote when reporting the problem.
+type APIError struct {
+	Message   string `json:"error"`
+	Code      string `json:"code"`
+	Details   any    `json:"details,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// Error is an error with everything needed to answer the client.
+type Error struct {
+	Status  int
+	Code    string
+	Message string
+	Details any    // optional structured data, e.g. per-field problems
+	Err     error  // cause; logged, never sent
+	Stack   []byte // captured for 5xx so the log shows where it started
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %v", e.Message, e.Err)
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// WithDetails returns a copy of e carrying details.
+func (e *Error) WithDetails(details any) *Error {
+	cp := *e
+	cp.Details = details
+	return &cp
+}
+
+// New returns an Error. Server errors (5xx) capture the current stack.
+func New(status int, code, msg string) *Error {
+	e := &Error{Status: status, Code: code, Message: msg}
+	if status >= http.StatusInternalServerError {
+		e.Stack = debug.Stack()
+	}
+	return e
+}
+
+func BadRequest(msg string) *Error   { return New(http.StatusBadRequest, CodeBadRequest, msg) }
+func Unauthorized(msg string) *Error { return New(http.StatusUnauthorized, CodeUnauthorized, msg) }
+func Forbidden(msg string) *Error    { return New(http.StatusForbidden, CodeForbidden, msg) }
+func NotFound(msg string) *Error     { return New(http.StatusNotFound, CodeNotFound, msg) }
+func Conflict(msg string) *Error     { return New(http.StatusConflict, CodeConflict, msg) }
+
+// PreconditionFailed reports an If-Match that no longer matches: someone
+// else changed the resource since the client read it.
+func PreconditionFailed(msg string) *Error {
+	return New(http.StatusPreconditionFailed, CodePrecondition, msg)
+}
+
+// Validation reports invalid input; details usually maps field to problem.
+func Validation(msg string, details any) *Error {
+	return New(http.StatusBadRequest, CodeValidation, msg).WithDetails(details)
+}
+
+// Internal wraps an unexpected failure. Clients only see a generic message.
+func Internal(err error) *Error {
+	e := New(http.StatusInternalServerError, CodeInternal, "internal server error")
+	e.Err = err
+	return e
+}
+
+// Timeout reports work abandoned because the request's deadline passed.
+func Timeout(err error) *Error {
+	e := New(http.StatusGatewayTimeout, CodeTimeout, "request timed out")
+	e.Err = err
+	return e
+}
+
+// TooLarge reports a request body over limit bytes.
+func TooLarge(limit int64) *Error {
+	return New(http.StatusRequestEntityTooLarge, CodeTooLarge, "request body too large").
+		WithDetails(map[string]int64{"limit_bytes": limit})
+}
+
+// From returns err as an *Error. A passed deadline becomes Timeout, a body
+// cut off by http.MaxBytesReader TooLarge, and anything else Internal.
+func From(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return TooLarge(tooLarge.Limit)
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return Timeout(err)
+	}
+	return Internal(err)
+}
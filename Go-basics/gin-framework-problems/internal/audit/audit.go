@@ -0,0 +1,130 @@
+// Package audit keeps an append-only record of security-relevant events:
+// sign-ins and failed sign-ins, registrations, account and role changes,
+// deletions and uploads, each with who did it, from where, when and how it
+// went.
+//
+// Events go to a Sink: memory (the default), a JSON-lines file or the SQLite
+// database. NewEngine picks one from the audit section of the config, and
+// the users example serves the log at GET /api/admin/audit.
+package audit
+
+import (
+	"context"
+	"crypto/rand"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/requestid"
+)
+
+// Actions the examples record.
+const (
+	Login          = "login"
+	LoginLockout   = "login_lockout" // an IP made to wait before trying a username again
+	Register       = "register"
+	RefreshReuse   = "refresh_token_reused" // a used refresh token came back
+	PasswordReset  = "password_reset"
+	PasswordChange = "password_change"
+	UserUpdate     = "user_update" // role, email or enabled changed by an admin
+	UserDelete     = "user_delete"
+	UserRestore    = "user_restore"
+	UserPurge      = "user_purge"
+	UserImport     = "user_import"    // a CSV of users, with created, skipped and errored counts
+	RoleUpdate     = "role_update"    // a role's permissions changed
+	IPListUpdate   = "ip_list_update" // the rate limiters' allow or deny list changed
+	FileUpload     = "file_upload"
+	FileDelete     = "file_delete"
+	FileMove       = "file_move"       // a file renamed or put in another folder, with both paths
+	FileQuarantine = "file_quarantine" // an upload the virus scanner flagged, with its signature
+	APIKeyCreate   = "api_key_create"
+	APIKeyRevoke   = "api_key_revoke"
+	TenantUpdate   = "tenant_update" // a tenant created, changed or deleted, with the change
+)
+
+// Outcomes.
+const (
+	Success = "success"
+	Failure = "failure"
+)
+
+// Event is one entry of the log.
+type Event struct {
+	ID        string            `json:"id"`
+	Time      time.Time         `json:"time"`
+	Action    string            `json:"action"`
+	Outcome   string            `json:"outcome"`
+	ActorID   string            `json:"actor_id,omitempty"` // the principal's Subject, when signed in
+	Actor     string            `json:"actor,omitempty"`    // a username, when known
+	Target    string            `json:"target,omitempty"`   // what was acted on: a user ID, a role, a file
+	IP        string            `json:"ip"`
+	UserAgent string            `json:"user_agent,omitempty"`
+	RequestID string            `json:"request_id,omitempty"`
+	Details   map[string]string `json:"details,omitempty"`
+}
+
+// Filter selects events. Zero fields match everything.
+type Filter struct {
+	From    time.Time // inclusive
+	To      time.Time // exclusive
+	Action  string
+	Actor   string // actor ID or username
+	Outcome string
+}
+
+// Match reports whether e passes f.
+func (f Filter) Match(e Event) bool {
+	switch {
+	case !f.From.IsZero() && e.Time.Before(f.From),
+		!f.To.IsZero() && !e.Time.Before(f.To),
+		f.Action != "" && e.Action != f.Action,
+		f.Actor != "" && e.ActorID != f.Actor && !strings.EqualFold(e.Actor, f.Actor),
+		f.Outcome != "" && e.Outcome != f.Outcome:
+		return false
+	}
+	return true
+}
+
+// Sink stores events. Nothing is ever changed or removed through it.
+type Sink interface {
+	Append(ctx context.Context, e Event) error
+	// Query returns the events matching f, newest first.
+	Query(ctx context.Context, f Filter) ([]Event, error)
+}
+
+// Default is the sink Record writes to and the admin endpoint reads. NewEngine
+// replaces it when audit.sink isn't memory.
+var Default Sink = NewMemorySink(10000)
+
+// subject is implemented by principals that log under an ID.
+type subject interface {
+	Subject() string
+}
+
+// Record completes e from the request (time, client IP, user agent, request
+// ID and, unless set, the signed-in principal or basic-auth user) and appends
+// it to Default. A sink that fails is logged rather than failing the request.
+func Record(c *gin.Context, e Event) {
+	ctx := c.Request.Context()
+	e.ID = strings.ToLower(rand.Text())
+	e.Time = time.Now().UTC()
+	e.IP = c.ClientIP()
+	e.UserAgent = c.Request.UserAgent()
+	e.RequestID = requestid.FromContext(ctx)
+	if e.ActorID == "" {
+		if p, ok := c.Get(middleware.UserKey); ok {
+			if s, ok := p.(subject); ok {
+				e.ActorID = s.Subject()
+			}
+		}
+	}
+	if e.Actor == "" {
+		e.Actor = c.GetString(gin.AuthUserKey)
+	}
+	if err := Default.Append(context.WithoutCancel(ctx), e); err != nil {
+		slog.ErrorContext(ctx, "audit event lost", "action", e.Action, "outcome", e.Outcome, "error", err)
+	}
+}
@@ -0,0 +1,51 @@
+package audit
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/apperror"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/pagination"
+)
+
+// Handler pages through the events in Default, newest first. It takes
+// ?from= and ?to= as RFC 3339 times (from inclusive, to exclusive) and
+// ?action=, ?actor= (an ID or username) and ?outcome= to narrow them down.
+func Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p, err := pagination.ParseParams(c)
+		if err != nil {
+			middleware.Error(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		f := Filter{Action: c.Query("action"), Actor: c.Query("actor"), Outcome: c.Query("outcome")}
+		details := map[string]string{}
+		for name, t := range map[string]*time.Time{"from": &f.From, "to": &f.To} {
+			if s := c.Query(name); s != "" {
+				if *t, err = time.Parse(time.RFC3339, s); err != nil {
+					details[name] = "must be an RFC 3339 time"
+				}
+			}
+		}
+		if f.Outcome != "" && f.Outcome != Success && f.Outcome != Failure {
+			details["outcome"] = "must be success or failure"
+		}
+		if len(details) == 0 && !f.From.IsZero() && !f.To.IsZero() && !f.From.Before(f.To) {
+			details["to"] = "must be after from"
+		}
+		if len(details) > 0 {
+			middleware.Fail(c, apperror.Validation("invalid query parameters", details))
+			return
+		}
+
+		events, err := Default.Query(c.Request.Context(), f)
+		if err != nil {
+			middleware.Fail(c, err)
+			return
+		}
+		pagination.Write(c, pagination.NewPage(events, p))
+	}
+}
@@ -0,0 +1,165 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// MemorySink keeps the latest events in memory, dropping the oldest past its
+// limit. Each process has its own.
+type MemorySink struct {
+	mu     sync.Mutex
+	limit  int
+	events []Event // oldest first
+}
+
+func NewMemorySink(limit int) *MemorySink {
+	return &MemorySink{limit: limit}
+}
+
+func (s *MemorySink) Append(_ context.Context, e Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.events) >= s.limit {
+		s.events = slices.Delete(s.events, 0, len(s.events)-s.limit+1)
+	}
+	s.events = append(s.events, e)
+	return nil
+}
+
+func (s *MemorySink) Query(_ context.Context, f Filter) ([]Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := []Event{}
+	for i := len(s.events) - 1; i >= 0; i-- {
+		if f.Match(s.events[i]) {
+			out = append(out, s.events[i])
+		}
+	}
+	return out, nil
+}
+
+// FileSink appends events to a file as JSON lines, which other processes
+// can share and log shippers can tail. Queries read the whole file.
+type FileSink struct {
+	mu   sync.Mutex
+	path string
+	f    *os.File
+}
+
+// NewFileSink opens path for appending, creating it and its directory.
+func NewFileSink(path string) (*FileSink, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("audit: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("audit: %w", err)
+	}
+	return &FileSink{path: path, f: f}, nil
+}
+
+func (s *FileSink) Append(_ context.Context, e Event) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// one write per event, so lines from concurrent appends don't interleave
+	_, err = s.f.Write(append(line, '\n'))
+	return err
+}
+
+func (s *FileSink) Query(_ context.Context, f Filter) ([]Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, err := os.Open(s.path)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	out := []Event{}
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1<<20)
+	for sc.Scan() {
+		var e Event
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("audit: %s: %w", s.path, err)
+		}
+		if f.Match(e) {
+			out = append(out, e)
+		}
+	}
+	slices.Reverse(out)
+	return out, sc.Err()
+}
+
+func (s *FileSink) Close(context.Context) error {
+	return s.f.Close()
+}
+
+// SQLSink keeps events in the audit_events table of the SQLite database.
+type SQLSink struct {
+	db *sql.DB
+}
+
+// NewSQLSink writes to db, whose schema must be migrated.
+func NewSQLSink(db *sql.DB) *SQLSink {
+	return &SQLSink{db: db}
+}
+
+func (s *SQLSink) Append(ctx context.Context, e Event) error {
+	details, err := json.Marshal(e.Details)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx,
+		`INSERT INTO audit_events (id, time, action, outcome, actor_id, actor, target, ip, user_agent, request_id, details)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		e.ID, e.Time, e.Action, e.Outcome, e.ActorID, e.Actor, e.Target, e.IP, e.UserAgent, e.RequestID, string(details))
+	return err
+}
+
+func (s *SQLSink) Query(ctx context.Context, f Filter) ([]Event, error) {
+	// zero times stand for open ends
+	from, to := f.From, f.To
+	if to.IsZero() {
+		to = time.Date(9999, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT id, time, action, outcome, actor_id, actor, target, ip, user_agent, request_id, details
+		 FROM audit_events
+		 WHERE time >= ? AND time < ?
+		   AND (? = '' OR action = ?)
+		   AND (? = '' OR actor_id = ? OR actor = ? COLLATE NOCASE)
+		   AND (? = '' OR outcome = ?)
+		 ORDER BY time DESC, rowid DESC`,
+		from, to, f.Action, f.Action, f.Actor, f.Actor, f.Actor, f.Outcome, f.Outcome)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []Event{}
+	for rows.Next() {
+		var e Event
+		var details string
+		if err := rows.Scan(&e.ID, &e.Time, &e.Action, &e.Outcome, &e.ActorID, &e.Actor, &e.Target,
+			&e.IP, &e.UserAgent, &e.RequestID, &details); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(details), &e.Details); err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,47 @@
+package audit
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Summary counts the events of a period.
+type Summary struct {
+	From     time.Time      `json:"from"`
+	To       time.Time      `json:"to"`
+	Events   int            `json:"events"`
+	Failures int            `json:"failures"`
+	ByAction map[string]int `json:"by_action"`
+}
+
+// Summarize counts the events sink has from from, inclusive, to to.
+func Summarize(ctx context.Context, sink Sink, from, to time.Time) (Summary, error) {
+	events, err := sink.Query(ctx, Filter{From: from, To: to})
+	if err != nil {
+		return Summary{}, err
+	}
+	s := Summary{From: from, To: to, Events: len(events), ByAction: map[string]int{}}
+	for _, e := range events {
+		s.ByAction[e.Action]++
+		if e.Outcome == Failure {
+			s.Failures++
+		}
+	}
+	return s, nil
+}
+
+// DailySummary returns a task that logs the summary of the previous UTC
+// day of Default, for the audit_summary scheduled task.
+func DailySummary(logger *slog.Logger) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		to := time.Now().UTC().Truncate(24 * time.Hour)
+		s, err := Summarize(ctx, Default, to.AddDate(0, 0, -1), to)
+		if err != nil {
+			return err
+		}
+		logger.InfoContext(ctx, "audit summary",
+			"from", s.From, "to", s.To, "events", s.Events, "failures", s.Failures, "by_action", s.ByAction)
+		return nil
+	}
+}
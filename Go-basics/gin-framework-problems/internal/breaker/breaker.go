@@ -0,0 +1,277 @@
+// Package breaker stops calling a dependency that keeps failing, so callers
+// fail fast, or fall back, instead of waiting on it, and it gets time to
+// recover. A Breaker is closed while calls go through, open while it refuses
+// them with ErrOpen, and half-open after a cooldown, when one call finds out
+// whether the dependency is back.
+//
+// It opens after a number of failures in a row, or once enough of the last
+// calls failed. Its state is exported as circuit_breaker_state{breaker},
+// 0 closed, 1 open and 2 half-open, with circuit_breaker_rejections_total.
+package breaker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// ErrOpen is returned without making the call while the circuit is open.
+var ErrOpen = errors.New("breaker: circuit open")
+
+var (
+	stateGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "circuit_breaker_state",
+		Help: "Circuit state by breaker: 0 closed, 1 open, 2 half-open.",
+	}, []string{"breaker"})
+
+	rejectionsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "circuit_breaker_rejections_total",
+		Help: "Calls refused with ErrOpen, by breaker.",
+	}, []string{"breaker"})
+)
+
+// State is where a circuit is.
+type State int
+
+const (
+	Closed State = iota
+	Open
+	HalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	}
+	return "closed"
+}
+
+// Result is how a call went, for the done func of Allow.
+type Result int
+
+const (
+	Success Result = iota
+	Failure
+	// Ignored is a call that says nothing about the dependency, such as
+	// one its caller canceled.
+	Ignored
+)
+
+// Option customizes a Breaker.
+type Option func(*Breaker)
+
+// WithConsecutiveFailures opens the circuit after n failed calls in a row
+// (default 5); 0 leaves it to WithFailureRate.
+func WithConsecutiveFailures(n int) Option {
+	return func(b *Breaker) { b.consecutive = n }
+}
+
+// WithFailureRate opens the circuit once rate (0 to 1) of the last window
+// calls failed, counted only after minCalls of them, so a few calls after a
+// quiet spell don't decide. Off by default.
+func WithFailureRate(rate float64, minCalls, window int) Option {
+	return func(b *Breaker) {
+		b.rate, b.minCalls = rate, minCalls
+		b.window = make([]bool, max(window, minCalls, 1))
+	}
+}
+
+// WithCooldown sets how long the circuit stays open before a call may try
+// again (default 30s).
+func WithCooldown(d time.Duration) Option {
+	return func(b *Breaker) { b.cooldown = d }
+}
+
+// WithOnStateChange has the breaker call fn on every change of state, with
+// its lock held, so fn must not call back into it.
+func WithOnStateChange(fn func(from, to State)) Option {
+	return func(b *Breaker) { b.onChange = fn }
+}
+
+// WithClock reads the time from now instead of time.Now, for tests.
+func WithClock(now func() time.Time) Option {
+	return func(b *Breaker) { b.now = now }
+}
+
+// Breaker guards calls to one dependency. Make one per dependency, or per
+// host of one, and share it between the goroutines calling it.
+type Breaker struct {
+	name        string
+	consecutive int
+	rate        float64
+	minCalls    int
+	cooldown    time.Duration
+	onChange    func(from, to State)
+	now         func() time.Time
+
+	mu        sync.Mutex
+	state     State
+	failures  int    // in a row
+	window    []bool // recent outcomes, true for failed, as a ring
+	next      int
+	calls     int // in the window, up to its length
+	failed    int // in the window
+	openUntil time.Time
+	probing   bool
+}
+
+// New returns a closed breaker labeled name in metrics.
+func New(name string, opts ...Option) *Breaker {
+	b := &Breaker{name: name, consecutive: 5, cooldown: 30 * time.Second, now: time.Now}
+	for _, opt := range opts {
+		opt(b)
+	}
+	stateGauge.WithLabelValues(name).Set(float64(Closed))
+	return b
+}
+
+// State is where the circuit is now. An open circuit whose cooldown is over
+// reports half-open, since the next call may go through.
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == Open && !b.now().Before(b.openUntil) {
+		return HalfOpen
+	}
+	return b.state
+}
+
+// Allow asks to make a call. It fails with ErrOpen while the circuit is
+// open, or half-open with its trial call still out. Otherwise the caller
+// makes the call and reports how it went with done, exactly once.
+func (b *Breaker) Allow() (done func(Result), err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	probe := false
+	switch b.state {
+	case Open:
+		if b.now().Before(b.openUntil) {
+			rejectionsTotal.WithLabelValues(b.name).Inc()
+			return nil, ErrOpen
+		}
+		b.set(HalfOpen)
+		fallthrough
+	case HalfOpen:
+		if b.probing {
+			rejectionsTotal.WithLabelValues(b.name).Inc()
+			return nil, ErrOpen
+		}
+		b.probing, probe = true, true
+	}
+	return func(r Result) { b.record(r, probe) }, nil
+}
+
+// Do calls fn through the breaker. An error from fn is a failure unless ctx
+// is done by then, when the caller gave up rather than the dependency.
+func (b *Breaker) Do(ctx context.Context, fn func(context.Context) error) error {
+	done, err := b.Allow()
+	if err != nil {
+		return err
+	}
+	err = fn(ctx)
+	switch {
+	case err == nil:
+		done(Success)
+	case ctx.Err() != nil:
+		done(Ignored)
+	default:
+		done(Failure)
+	}
+	return err
+}
+
+// Run is Do for calls that return a value, with a fallback: when the
+// circuit is open or fn fails, it returns what fallback makes of the error,
+// such as a cached or default answer, or the error again.
+func Run[T any](ctx context.Context, b *Breaker, fn func(context.Context) (T, error), fallback func(error) (T, error)) (T, error) {
+	var v T
+	err := b.Do(ctx, func(ctx context.Context) error {
+		var err error
+		v, err = fn(ctx)
+		return err
+	})
+	if err != nil && fallback != nil {
+		return fallback(err)
+	}
+	return v, err
+}
+
+func (b *Breaker) record(r Result, probe bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if probe {
+		b.probing = false
+	}
+	switch {
+	case r == Ignored:
+		return
+	case probe && r == Success:
+		b.reset()
+		b.set(Closed)
+		return
+	case probe:
+		b.trip()
+		return
+	case b.state != Closed:
+		// let through before the circuit opened; it already knows
+		return
+	}
+
+	failed := r == Failure
+	if failed {
+		b.failures++
+	} else {
+		b.failures = 0
+	}
+	if len(b.window) > 0 {
+		if b.calls == len(b.window) {
+			if b.window[b.next] {
+				b.failed--
+			}
+		} else {
+			b.calls++
+		}
+		b.window[b.next] = failed
+		b.next = (b.next + 1) % len(b.window)
+		if failed {
+			b.failed++
+		}
+	}
+	if b.consecutive > 0 && b.failures >= b.consecutive ||
+		b.rate > 0 && b.calls >= b.minCalls && float64(b.failed) >= b.rate*float64(b.calls) {
+		b.trip()
+	}
+}
+
+// trip opens the circuit for the cooldown. b.mu must be held.
+func (b *Breaker) trip() {
+	b.reset()
+	b.openUntil = b.now().Add(b.cooldown)
+	b.set(Open)
+}
+
+// reset forgets past calls. b.mu must be held.
+func (b *Breaker) reset() {
+	b.failures, b.next, b.calls, b.failed = 0, 0, 0, 0
+	clear(b.window)
+}
+
+// set moves the circuit to s. b.mu must be held.
+func (b *Breaker) set(s State) {
+	if b.state == s {
+		return
+	}
+	from := b.state
+	b.state = s
+	stateGauge.WithLabelValues(b.name).Set(float64(s))
+	if b.onChange != nil {
+		b.onChange(from, s)
+	}
+}
@@ -0,0 +1,132 @@
+package breaker_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/breaker"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/testutil"
+)
+
+var errDown = errors.New("down")
+
+// call runs one call through b that fails when fail is set.
+func call(b *breaker.Breaker, fail bool) error {
+	return b.Do(context.Background(), func(context.Context) error {
+		if fail {
+			return errDown
+		}
+		return nil
+	})
+}
+
+func TestBreakerConsecutiveFailures(t *testing.T) {
+	clock := testutil.NewClock(time.Unix(0, 0))
+	var changes []string
+	b := breaker.New("test_consecutive",
+		breaker.WithConsecutiveFailures(3),
+		breaker.WithCooldown(time.Minute),
+		breaker.WithClock(clock.Now),
+		breaker.WithOnStateChange(func(from, to breaker.State) { changes = append(changes, to.String()) }))
+
+	// a success in between starts the count over
+	for _, fail := range []bool{true, true, false, true, true} {
+		call(b, fail)
+	}
+	if s := b.State(); s != breaker.Closed {
+		t.Fatalf("state = %s after 2 failures in a row, want closed", s)
+	}
+	call(b, true)
+	if err := call(b, false); !errors.Is(err, breaker.ErrOpen) {
+		t.Fatalf("call while open = %v, want ErrOpen", err)
+	}
+
+	// a failed trial opens it for another cooldown
+	clock.Advance(time.Minute)
+	if s := b.State(); s != breaker.HalfOpen {
+		t.Fatalf("state = %s after the cooldown, want half-open", s)
+	}
+	call(b, true)
+	if err := call(b, false); !errors.Is(err, breaker.ErrOpen) {
+		t.Fatalf("call after a failed trial = %v, want ErrOpen", err)
+	}
+
+	clock.Advance(time.Minute)
+	if err := call(b, false); err != nil {
+		t.Fatalf("trial call = %v", err)
+	}
+	if s := b.State(); s != breaker.Closed {
+		t.Fatalf("state = %s after a good trial, want closed", s)
+	}
+	want := []string{"open", "half-open", "open", "half-open", "closed"}
+	if len(changes) != len(want) {
+		t.Fatalf("state changes %v, want %v", changes, want)
+	}
+	for i := range want {
+		if changes[i] != want[i] {
+			t.Fatalf("state changes %v, want %v", changes, want)
+		}
+	}
+}
+
+func TestBreakerFailureRate(t *testing.T) {
+	b := breaker.New("test_rate",
+		breaker.WithConsecutiveFailures(0),
+		breaker.WithFailureRate(0.5, 4, 10))
+
+	// never two in a row, but half of them
+	for i, fail := range []bool{true, false, true} {
+		call(b, fail)
+		if s := b.State(); s != breaker.Closed {
+			t.Fatalf("state = %s after %d calls, want closed before minCalls", s, i+1)
+		}
+	}
+	call(b, false)
+	if s := b.State(); s != breaker.Open {
+		t.Fatalf("state = %s with 2 of 4 failed, want open", s)
+	}
+}
+
+func TestBreakerHalfOpenOneTrial(t *testing.T) {
+	clock := testutil.NewClock(time.Unix(0, 0))
+	b := breaker.New("test_trial", breaker.WithConsecutiveFailures(1), breaker.WithClock(clock.Now))
+	call(b, true)
+	clock.Advance(time.Hour)
+
+	done, err := b.Allow()
+	if err != nil {
+		t.Fatalf("trial = %v", err)
+	}
+	if _, err := b.Allow(); !errors.Is(err, breaker.ErrOpen) {
+		t.Fatalf("second call during the trial = %v, want ErrOpen", err)
+	}
+	// a canceled trial lets the next call try
+	done(breaker.Ignored)
+	if _, err := b.Allow(); err != nil {
+		t.Fatalf("call after an ignored trial = %v", err)
+	}
+}
+
+func TestRunFallback(t *testing.T) {
+	b := breaker.New("test_fallback", breaker.WithConsecutiveFailures(1))
+	lookup := func(context.Context) (string, error) { return "", errDown }
+	fallback := func(err error) (string, error) { return "cached", nil }
+
+	for i := range 2 {
+		// the first fails, the second is refused; both fall back
+		got, err := breaker.Run(context.Background(), b, lookup, fallback)
+		if err != nil || got != "cached" {
+			t.Errorf("call %d = %q, %v, want the fallback", i, got, err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	c := breaker.New("test_canceled", breaker.WithConsecutiveFailures(1))
+	c.Do(ctx, func(context.Context) error { return context.Canceled })
+	if s := c.State(); s != breaker.Closed {
+		t.Errorf("state = %s after a canceled call, want closed", s)
+	}
+}
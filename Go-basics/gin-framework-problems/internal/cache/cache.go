@@ -0,0 +1,106 @@
+// Package cache implements cache-aside reads over Redis (or memory, when no
+// Redis is configured): look in the cache, on a miss load from the source and
+// store the result with a TTL. Writers call Delete to invalidate. Responses
+// caches whole GET responses in memory the same way.
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"golang.org/x/sync/singleflight"
+)
+
+// ErrMiss is returned by Store.Get when the key is absent or expired.
+var ErrMiss = errors.New("cache: miss")
+
+// Store is the key/value backend of a Cache.
+type Store interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+}
+
+var requests = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "cache_requests_total",
+	Help: "Cache lookups by cache name and result (hit, miss or error).",
+}, []string{"cache", "result"})
+
+// Cache reads through to a loader on a miss. Concurrent misses for the same
+// key share one load, so an expired hot key doesn't send every waiting
+// request to the source at once.
+type Cache struct {
+	name  string
+	store Store
+	group singleflight.Group
+}
+
+// New returns a cache over store. name labels its metrics and prefixes its
+// keys, so several caches can share one Redis.
+func New(name string, store Store) *Cache {
+	return &Cache{name: name, store: store}
+}
+
+func (c *Cache) key(k string) string {
+	return c.name + ":" + k
+}
+
+// GetOrLoad fills dst (a pointer) from the cache, or from load on a miss,
+// storing load's result for ttl. A broken cache is logged and counted but
+// never fails the read: the loader is the source of truth.
+func (c *Cache) GetOrLoad(ctx context.Context, key string, ttl time.Duration, dst any, load func(context.Context) (any, error)) error {
+	k := c.key(key)
+	data, err := c.store.Get(ctx, k)
+	switch {
+	case err == nil:
+		requests.WithLabelValues(c.name, "hit").Inc()
+		return json.Unmarshal(data, dst)
+	case errors.Is(err, ErrMiss):
+		requests.WithLabelValues(c.name, "miss").Inc()
+	default:
+		requests.WithLabelValues(c.name, "error").Inc()
+		slog.WarnContext(ctx, "cache get failed", "cache", c.name, "key", key, "error", err)
+	}
+
+	ch := c.group.DoChan(k, func() (any, error) {
+		// detach from the first caller's cancellation: the others wait on it too
+		ctx := context.WithoutCancel(ctx)
+		val, err := load(ctx)
+		if err != nil {
+			return nil, err
+		}
+		data, err := json.Marshal(val)
+		if err != nil {
+			return nil, err
+		}
+		if err := c.store.Set(ctx, k, data, ttl); err != nil {
+			slog.WarnContext(ctx, "cache set failed", "cache", c.name, "key", key, "error", err)
+		}
+		return data, nil
+	})
+	// the shared load carries on for the others, but this caller stops
+	// waiting once its own request is cancelled or times out
+	select {
+	case res := <-ch:
+		if res.Err != nil {
+			return res.Err
+		}
+		return json.Unmarshal(res.Val.([]byte), dst)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Delete invalidates keys. Call it after the write to the source succeeds.
+func (c *Cache) Delete(ctx context.Context, keys ...string) error {
+	full := make([]string, len(keys))
+	for i, k := range keys {
+		full[i] = c.key(k)
+	}
+	return c.store.Delete(ctx, full...)
+}
@@ -0,0 +1,208 @@
+package cache
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+)
+
+// maxResponseBody caps the responses kept; bigger ones are sent but not
+// cached.
+const maxResponseBody = 1 << 20
+
+// Responses keeps whole GET responses in memory, evicting the least recently
+// used beyond its size, for routes whose answer only changes when the data
+// behind it is written. Writers call Invalidate with the path they changed.
+// Each instance keeps its own copies, so the TTL bounds how stale another
+// instance's writes can look.
+//
+// A nil *Responses caches nothing, so callers needn't check whether caching
+// is configured.
+type Responses struct {
+	name string
+	ttl  time.Duration
+	max  int
+
+	mu      sync.Mutex
+	order   *list.List // of *response, most recently used first
+	entries map[string]*list.Element
+}
+
+type response struct {
+	key     string
+	path    string
+	status  int
+	header  http.Header
+	body    []byte
+	expires time.Time
+}
+
+// NewResponses returns a cache keeping up to maxEntries responses for ttl
+// each. name labels its metrics, as New's does.
+func NewResponses(name string, ttl time.Duration, maxEntries int) *Responses {
+	return &Responses{
+		name:    name,
+		ttl:     ttl,
+		max:     maxEntries,
+		order:   list.New(),
+		entries: map[string]*list.Element{},
+	}
+}
+
+// Middleware answers GET requests from the cache, marked X-Cache: HIT, and
+// keeps the 200 responses it doesn't have yet. Entries are keyed by path,
+// query, credentials and the Accept headers, so callers never get each
+// other's answer or another representation of it. Mount it after Auth, so
+// a revoked token isn't answered from the cache.
+//
+// Conditional requests pass through, for the handler to answer 304.
+func (r *Responses) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if r == nil || c.Request.Method != http.MethodGet ||
+			c.GetHeader("If-None-Match") != "" || c.GetHeader("If-Modified-Since") != "" {
+			c.Next()
+			return
+		}
+
+		key := responseKey(c)
+		if e, ok := r.get(key); ok {
+			requests.WithLabelValues(r.name, "hit").Inc()
+			for name, values := range e.header {
+				c.Writer.Header()[name] = values
+			}
+			c.Header("X-Cache", "HIT")
+			c.Status(e.status)
+			c.Writer.Write(e.body)
+			c.Abort()
+			return
+		}
+		requests.WithLabelValues(r.name, "miss").Inc()
+
+		w := &recorder{ResponseWriter: c.Writer}
+		c.Writer = w
+		c.Header("X-Cache", "MISS")
+		c.Next()
+		c.Writer = w.ResponseWriter
+
+		if w.Status() != http.StatusOK || w.overflow || len(c.Errors) > 0 || w.Header().Get("Set-Cookie") != "" {
+			return
+		}
+		r.put(&response{
+			key:     key,
+			path:    c.Request.URL.Path,
+			status:  w.Status(),
+			header:  cachedHeader(w.Header()),
+			body:    w.body,
+			expires: time.Now().Add(r.ttl),
+		})
+	}
+}
+
+// Invalidate drops the responses cached for each path and everything under
+// it: Invalidate("/books") drops /books, /books?page=2 and /books/7.
+func (r *Responses) Invalidate(paths ...string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for key, el := range r.entries {
+		e := el.Value.(*response)
+		for _, p := range paths {
+			if e.path == p || strings.HasPrefix(e.path, strings.TrimSuffix(p, "/")+"/") {
+				r.order.Remove(el)
+				delete(r.entries, key)
+				break
+			}
+		}
+	}
+}
+
+func (r *Responses) get(key string) (*response, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	el, ok := r.entries[key]
+	if !ok {
+		return nil, false
+	}
+	e := el.Value.(*response)
+	if time.Now().After(e.expires) {
+		r.order.Remove(el)
+		delete(r.entries, key)
+		return nil, false
+	}
+	r.order.MoveToFront(el)
+	return e, true
+}
+
+func (r *Responses) put(e *response) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if el, ok := r.entries[e.key]; ok {
+		el.Value = e
+		r.order.MoveToFront(el)
+		return
+	}
+	r.entries[e.key] = r.order.PushFront(e)
+	for r.order.Len() > r.max {
+		oldest := r.order.Back()
+		r.order.Remove(oldest)
+		delete(r.entries, oldest.Value.(*response).key)
+	}
+}
+
+// responseKey identifies what the request asks for and who asks. The
+// credentials are hashed, so the keys don't hold tokens.
+func responseKey(c *gin.Context) string {
+	h := sha256.New()
+	for _, name := range []string{"Authorization", "X-API-Key", "Accept", "Accept-Language"} {
+		h.Write([]byte(c.GetHeader(name)))
+		h.Write([]byte{0})
+	}
+	// tenants see different data at the same URL
+	h.Write([]byte(c.GetString(middleware.TenantKey)))
+	// Encode sorts the parameters, so their order doesn't matter
+	return c.Request.URL.Path + "?" + c.Request.URL.Query().Encode() + "#" + hex.EncodeToString(h.Sum(nil))
+}
+
+// cachedHeader keeps the response headers a hit should repeat. Each
+// response gets its own request ID and date, and Compress encodes every
+// response for its own client.
+func cachedHeader(h http.Header) http.Header {
+	out := h.Clone()
+	for _, name := range []string{"Date", "Content-Length", "Content-Encoding", "X-Request-Id", "X-Cache"} {
+		out.Del(name)
+	}
+	return out
+}
+
+// recorder copies the response body while passing it through.
+type recorder struct {
+	gin.ResponseWriter
+	body     []byte
+	overflow bool
+}
+
+func (w *recorder) Write(p []byte) (int, error) {
+	if !w.overflow {
+		if len(w.body)+len(p) > maxResponseBody {
+			w.overflow = true
+			w.body = nil
+		} else {
+			w.body = append(w.body, p...)
+		}
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *recorder) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
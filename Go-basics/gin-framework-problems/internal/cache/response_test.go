@@ -0,0 +1,99 @@
+package cache_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/cache"
+)
+
+// counting serves GET /items/*path under r's cache, answering with how many
+// times the handler ran.
+func counting(r *cache.Responses) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	calls := 0
+	engine.GET("/items/*path", r.Middleware(), func(c *gin.Context) {
+		calls++
+		if c.Query("fail") != "" {
+			c.String(http.StatusInternalServerError, strconv.Itoa(calls))
+			return
+		}
+		c.String(http.StatusOK, strconv.Itoa(calls))
+	})
+	return engine
+}
+
+func get(h http.Handler, path string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func TestResponses(t *testing.T) {
+	r := cache.NewResponses("test", time.Minute, 2)
+	h := counting(r)
+	alice := map[string]string{"Authorization": "Bearer alice"}
+
+	steps := []struct {
+		name, path string
+		headers    map[string]string
+		body, hit  string
+	}{
+		{"first", "/items/a?x=1&y=2", nil, "1", "MISS"},
+		{"again", "/items/a?x=1&y=2", nil, "1", "HIT"},
+		{"query in another order", "/items/a?y=2&x=1", nil, "1", "HIT"},
+		{"another caller", "/items/a?x=1&y=2", alice, "2", "MISS"},
+		{"another representation", "/items/a?x=1&y=2", map[string]string{"Accept": "application/xml"}, "3", "MISS"},
+		// two entries at most: the anonymous one was used least recently
+		{"evicted", "/items/a?x=1&y=2", nil, "4", "MISS"},
+		{"errors aren't kept", "/items/a?fail=1", nil, "5", "MISS"},
+		{"errors aren't kept, again", "/items/a?fail=1", nil, "6", "MISS"},
+		{"conditional", "/items/a?x=1&y=2", map[string]string{"If-None-Match": `"v1"`}, "7", ""},
+	}
+	for _, s := range steps {
+		w := get(h, s.path, s.headers)
+		if w.Body.String() != s.body || w.Header().Get("X-Cache") != s.hit {
+			t.Errorf("%s: body %s, X-Cache %q; want %s, %q", s.name, w.Body, w.Header().Get("X-Cache"), s.body, s.hit)
+		}
+	}
+
+	get(h, "/items/b", nil)
+	get(h, "/items/other", nil)
+	// drops /items/b and what's under it, not /items/other
+	r.Invalidate("/items/b")
+	if w := get(h, "/items/b", nil); w.Header().Get("X-Cache") != "MISS" {
+		t.Errorf("after Invalidate, /items/b is a %s", w.Header().Get("X-Cache"))
+	}
+	if w := get(h, "/items/other", nil); w.Header().Get("X-Cache") != "HIT" {
+		t.Errorf("after Invalidate, /items/other is a %s", w.Header().Get("X-Cache"))
+	}
+}
+
+func TestResponsesExpire(t *testing.T) {
+	h := counting(cache.NewResponses("test", time.Nanosecond, 10))
+	get(h, "/items/a", nil)
+	time.Sleep(time.Millisecond)
+	if w := get(h, "/items/a", nil); w.Header().Get("X-Cache") != "MISS" || w.Body.String() != "2" {
+		t.Errorf("after the TTL: body %s, X-Cache %q; want a fresh answer", w.Body, w.Header().Get("X-Cache"))
+	}
+}
+
+func TestNilResponses(t *testing.T) {
+	var r *cache.Responses
+	h := counting(r)
+	get(h, "/items/a", nil)
+	r.Invalidate("/items")
+	if w := get(h, "/items/a", nil); w.Body.String() != "2" || w.Header().Get("X-Cache") != "" {
+		t.Errorf("nil cache: body %s, X-Cache %q; want every request handled", w.Body, w.Header().Get("X-Cache"))
+	}
+}
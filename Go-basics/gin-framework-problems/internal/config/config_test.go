@@ -0,0 +1,145 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, yaml string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(yaml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadFileExpandsEnv(t *testing.T) {
+	tests := []struct {
+		name   string
+		secret string
+	}{
+		{"plain", "s3cret"},
+		{"colon and space", "a: b"},
+		{"comment marker", "abc #def"},
+		{"newline", "line1\nline2"},
+		{"alias marker", "*notanalias"},
+		{"anchor marker", "&anchor"},
+		{"tag marker", "!tag"},
+		{"looks like a bool", "true"},
+		{"quotes", `it's "quoted"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("HUB_TEST_SECRET", tt.secret)
+			path := writeConfig(t, "auth:\n  token_secret: ${HUB_TEST_SECRET}\n  token_issuer: hub\n")
+			cfg := Default()
+			if err := cfg.loadFile(path); err != nil {
+				t.Fatalf("loadFile: %v", err)
+			}
+			if cfg.Auth.TokenSecret != tt.secret {
+				t.Errorf("token_secret = %q, want %q", cfg.Auth.TokenSecret, tt.secret)
+			}
+			if cfg.Auth.TokenIssuer != "hub" {
+				t.Errorf("token_issuer = %q, want the next key untouched", cfg.Auth.TokenIssuer)
+			}
+		})
+	}
+}
+
+func TestLoadFileTypesExpandedScalars(t *testing.T) {
+	t.Setenv("HUB_TEST_WORKERS", "7")
+	t.Setenv("HUB_TEST_TIMEOUT", "3s")
+	path := writeConfig(t, "jobs:\n  workers: ${HUB_TEST_WORKERS}\nserver:\n  read_timeout: ${HUB_TEST_TIMEOUT}\n")
+	cfg := Default()
+	if err := cfg.loadFile(path); err != nil {
+		t.Fatalf("loadFile: %v", err)
+	}
+	if cfg.Jobs.Workers != 7 {
+		t.Errorf("jobs.workers = %d, want 7", cfg.Jobs.Workers)
+	}
+	if cfg.Server.ReadTimeout != 3*time.Second {
+		t.Errorf("server.read_timeout = %s, want 3s", cfg.Server.ReadTimeout)
+	}
+}
+
+func TestLoadFileErrors(t *testing.T) {
+	tests := []struct {
+		name, yaml, want string
+	}{
+		{"unset variable", "auth:\n  token_secret: ${HUB_TEST_UNSET}\n", "HUB_TEST_UNSET"},
+		{"unknown key", "auth:\n  token_secrte: x\n", "token_secrte"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Default().loadFile(writeConfig(t, tt.yaml))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("loadFile error = %v, want one mentioning %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadFileEmpty(t *testing.T) {
+	cfg := Default()
+	if err := cfg.loadFile(writeConfig(t, "")); err != nil {
+		t.Fatalf("loadFile: %v", err)
+	}
+}
+
+func TestExampleConfigLoads(t *testing.T) {
+	cfg := Default()
+	if err := cfg.loadFile("../../config.example.yaml"); err != nil {
+		t.Fatalf("loadFile: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+}
+
+func TestTLSProxied(t *testing.T) {
+	cfg := Default()
+	if err := cfg.loadFile("../../config.example.yaml"); err != nil {
+		t.Fatalf("loadFile: %v", err)
+	}
+	if err := cfg.set("tls-proxied", "true"); err != nil || !cfg.Server.TLS.Proxied {
+		t.Fatalf("set tls-proxied: %v, Proxied = %v", err, cfg.Server.TLS.Proxied)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	// the server's own certificate and a proxy ending TLS contradict
+	cfg.Server.TLS.CertFile, cfg.Server.TLS.KeyFile = "cert.pem", "key.pem"
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "server.tls.proxied") {
+		t.Errorf("Validate with a certificate = %v, want a proxied error", err)
+	}
+}
+
+func TestLoadHostPortDataDir(t *testing.T) {
+	t.Setenv("HUB_ADDR", "127.0.0.1:9000")
+	t.Setenv("HUB_PORT", "9100")
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	cfg, err := Load(fs, []string{"-host", "0.0.0.0", "-data-dir", "/srv/hub", "-mode", "release"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Server.Addr != "0.0.0.0:9100" {
+		t.Errorf("addr = %q, want 0.0.0.0:9100", cfg.Server.Addr)
+	}
+	if cfg.Server.Mode != "release" {
+		t.Errorf("mode = %q, want release", cfg.Server.Mode)
+	}
+	if want := filepath.Join("/srv/hub", Default().Storage.UploadDir); cfg.Storage.UploadDir != want {
+		t.Errorf("upload dir = %q, want %q", cfg.Storage.UploadDir, want)
+	}
+
+	for _, args := range [][]string{{"-port", "http"}, {"-mode", "prod"}} {
+		if _, err := Load(flag.NewFlagSet("test", flag.ContinueOnError), args); err == nil {
+			t.Errorf("Load(%q) succeeded, want an error", args)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+// Package database opens the SQLite database the examples can persist to and
+// owns its schema, as goose migrations embedded from migrations/*.sql.
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/mattn/go-sqlite3" // also registers the "sqlite3" driver
+)
+
+// Open opens (creating if needed) the SQLite database at path with foreign
+// keys enforced, WAL journaling and a busy timeout, and checks it answers.
+func Open(ctx context.Context, path string) (*sql.DB, error) {
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("database: %w", err)
+		}
+	}
+	dsn := "file:" + path + "?_foreign_keys=on&_journal_mode=WAL&_busy_timeout=5000"
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("database: %w", err)
+	}
+	// SQLite allows one writer at a time; one connection avoids "database is
+	// locked" errors at the cost of concurrency the examples don't need
+	db.SetMaxOpenConns(1)
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("database: open %s: %w", path, err)
+	}
+	return db, nil
+}
+
+// IsUniqueViolation reports whether err is a UNIQUE or PRIMARY KEY constraint
+// failure, which callers usually turn into a 409.
+func IsUniqueViolation(err error) bool {
+	var e sqlite3.Error
+	return errors.As(err, &e) &&
+		(e.ExtendedCode == sqlite3.ErrConstraintUnique || e.ExtendedCode == sqlite3.ErrConstraintPrimaryKey)
+}
@@ -0,0 +1,15 @@
+-- +goose Up
+CREATE TABLE books (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    title      TEXT NOT NULL,
+    author     TEXT NOT NULL,
+    year       INTEGER NOT NULL CHECK (year BETWEEN 1000 AND 2100),
+    isbn       TEXT,
+    owner_id   TEXT REFERENCES users (id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX books_owner_id ON books (owner_id);
+
+-- +goose Down
+DROP TABLE books;
@@ -0,0 +1,10 @@
+-- +goose Up
+CREATE TABLE files (
+    name         TEXT PRIMARY KEY,
+    size         INTEGER NOT NULL,
+    content_type TEXT NOT NULL DEFAULT 'application/octet-stream',
+    uploaded_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- +goose Down
+DROP TABLE files;
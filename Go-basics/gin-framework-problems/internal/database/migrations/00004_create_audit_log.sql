@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE audit_log (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    actor_id   TEXT REFERENCES users (id) ON DELETE SET NULL,
+    action     TEXT NOT NULL,
+    subject    TEXT NOT NULL,
+    detail     TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX audit_log_subject ON audit_log (subject);
+
+-- +goose Down
+DROP TABLE audit_log;
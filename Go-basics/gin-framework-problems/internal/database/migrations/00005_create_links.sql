@@ -0,0 +1,16 @@
+-- +goose Up
+-- owner is the username from the auth example's login, not a users row
+CREATE TABLE links (
+    code        TEXT PRIMARY KEY,
+    url         TEXT NOT NULL,
+    owner       TEXT NOT NULL,
+    hits        INTEGER NOT NULL DEFAULT 0,
+    created_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at  TIMESTAMP,
+    last_hit_at TIMESTAMP
+);
+CREATE INDEX links_owner ON links (owner, created_at);
+CREATE INDEX links_expires_at ON links (expires_at) WHERE expires_at IS NOT NULL;
+
+-- +goose Down
+DROP TABLE links;
@@ -0,0 +1,19 @@
+-- +goose Up
+-- the audit log's sqlite sink; rows are only ever inserted
+CREATE TABLE audit_events (
+    id         TEXT PRIMARY KEY,
+    time       TIMESTAMP NOT NULL,
+    action     TEXT NOT NULL,
+    outcome    TEXT NOT NULL,
+    actor_id   TEXT NOT NULL DEFAULT '',
+    actor      TEXT NOT NULL DEFAULT '',
+    target     TEXT NOT NULL DEFAULT '',
+    ip         TEXT NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+    request_id TEXT NOT NULL DEFAULT '',
+    details    TEXT NOT NULL DEFAULT 'null'
+);
+CREATE INDEX audit_events_time ON audit_events (time);
+
+-- +goose Down
+DROP TABLE audit_events;
@@ -0,0 +1,47 @@
+-- +goose NO TRANSACTION
+-- The users example keeps its users here when users.store is sqlite. SQLite
+-- can't add the columns' constraints or drop the role CHECK in place, so the
+-- table is rebuilt; foreign keys are off meanwhile, or dropping the old table
+-- would cascade to tokens. Emails needn't be unique, as in the memory store.
+
+-- +goose Up
+PRAGMA foreign_keys = OFF;
+BEGIN;
+CREATE TABLE users_new (
+    id         TEXT PRIMARY KEY,
+    username   TEXT NOT NULL UNIQUE,
+    email      TEXT NOT NULL,
+    password   TEXT NOT NULL,
+    role       TEXT NOT NULL DEFAULT 'user',
+    verified   BOOLEAN NOT NULL DEFAULT FALSE,
+    disabled   BOOLEAN NOT NULL DEFAULT FALSE,
+    deleted_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+INSERT INTO users_new (id, username, email, password, role, created_at)
+    SELECT id, username, email, password, role, created_at FROM users;
+DROP TABLE users;
+ALTER TABLE users_new RENAME TO users;
+CREATE INDEX users_email ON users (email);
+COMMIT;
+PRAGMA foreign_keys = ON;
+
+-- +goose Down
+PRAGMA foreign_keys = OFF;
+BEGIN;
+CREATE TABLE users_old (
+    id         TEXT PRIMARY KEY,
+    username   TEXT NOT NULL UNIQUE,
+    email      TEXT NOT NULL UNIQUE,
+    password   TEXT NOT NULL,
+    role       TEXT NOT NULL DEFAULT 'user' CHECK (role IN ('user', 'admin')),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+-- roles added since and repeated emails don't fit the old table
+INSERT OR IGNORE INTO users_old (id, username, email, password, role, created_at)
+    SELECT id, username, email, password, CASE WHEN role IN ('user', 'admin') THEN role ELSE 'user' END, created_at
+    FROM users ORDER BY created_at;
+DROP TABLE users;
+ALTER TABLE users_old RENAME TO users;
+COMMIT;
+PRAGMA foreign_keys = ON;
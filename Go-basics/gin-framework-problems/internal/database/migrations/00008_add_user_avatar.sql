@@ -0,0 +1,6 @@
+-- +goose Up
+-- the avatar's name under storage.upload_dir, empty without one
+ALTER TABLE users ADD COLUMN avatar TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN avatar;
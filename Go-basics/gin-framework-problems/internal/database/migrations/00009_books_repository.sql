@@ -0,0 +1,66 @@
+-- +goose NO TRANSACTION
+-- The books example keeps its books here when books.store is sqlite. The
+-- owner is a users-example ID, and users may live in memory, so owner_id
+-- loses its foreign key; SQLite can't drop one in place, so the table is
+-- rebuilt with foreign keys off. Tags are a JSON array; categories get a
+-- table of their own and a join table, cascading from both sides.
+
+-- +goose Up
+PRAGMA foreign_keys = OFF;
+BEGIN;
+CREATE TABLE books_new (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    title      TEXT NOT NULL,
+    author     TEXT NOT NULL,
+    year       INTEGER NOT NULL CHECK (year BETWEEN 1000 AND 2100),
+    isbn       TEXT,
+    owner_id   TEXT,
+    tags       TEXT NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+INSERT INTO books_new (id, title, author, year, isbn, owner_id, created_at, updated_at)
+    SELECT id, title, author, year, isbn, owner_id, created_at, updated_at FROM books;
+DROP TABLE books;
+ALTER TABLE books_new RENAME TO books;
+CREATE INDEX books_owner_id ON books (owner_id);
+
+CREATE TABLE categories (
+    slug        TEXT PRIMARY KEY,
+    name        TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT ''
+);
+CREATE TABLE book_categories (
+    book_id       INTEGER NOT NULL REFERENCES books (id) ON DELETE CASCADE,
+    category_slug TEXT NOT NULL REFERENCES categories (slug) ON DELETE CASCADE,
+    position      INTEGER NOT NULL, -- keeps the order the book listed them in
+    PRIMARY KEY (book_id, category_slug)
+);
+CREATE INDEX book_categories_category_slug ON book_categories (category_slug);
+COMMIT;
+PRAGMA foreign_keys = ON;
+
+-- +goose Down
+PRAGMA foreign_keys = OFF;
+BEGIN;
+DROP TABLE book_categories;
+DROP TABLE categories;
+CREATE TABLE books_old (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    title      TEXT NOT NULL,
+    author     TEXT NOT NULL,
+    year       INTEGER NOT NULL CHECK (year BETWEEN 1000 AND 2100),
+    isbn       TEXT,
+    owner_id   TEXT REFERENCES users (id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+-- owners that aren't users rows don't fit the old table's foreign key
+INSERT INTO books_old (id, title, author, year, isbn, owner_id, created_at, updated_at)
+    SELECT id, title, author, year, isbn, (SELECT u.id FROM users u WHERE u.id = books.owner_id), created_at, updated_at
+    FROM books;
+DROP TABLE books;
+ALTER TABLE books_old RENAME TO books;
+CREATE INDEX books_owner_id ON books (owner_id);
+COMMIT;
+PRAGMA foreign_keys = ON;
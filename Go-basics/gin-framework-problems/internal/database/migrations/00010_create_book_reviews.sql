@@ -0,0 +1,15 @@
+-- +goose Up
+-- Reviews of the books example's books. The author is the auth example's
+-- username, which has no table to refer to.
+CREATE TABLE book_reviews (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    book_id    INTEGER NOT NULL REFERENCES books (id) ON DELETE CASCADE,
+    rating     INTEGER NOT NULL CHECK (rating BETWEEN 1 AND 5),
+    text       TEXT NOT NULL DEFAULT '',
+    author     TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX book_reviews_book_id ON book_reviews (book_id);
+
+-- +goose Down
+DROP TABLE book_reviews;
@@ -0,0 +1,6 @@
+-- +goose Up
+-- when DELETE /books/:id hid the book, NULL while it's live
+ALTER TABLE books ADD COLUMN deleted_at TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE books DROP COLUMN deleted_at;
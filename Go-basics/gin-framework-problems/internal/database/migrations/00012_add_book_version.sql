@@ -0,0 +1,6 @@
+-- +goose Up
+-- bumped by every update, so a client can tell its copy is stale
+ALTER TABLE books ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+
+-- +goose Down
+ALTER TABLE books DROP COLUMN version;
@@ -0,0 +1,23 @@
+-- +goose Up
+-- Authors of the books example's books, one per name, ignoring case. Books
+-- keep the name in author and link the author by author_id, which has no
+-- foreign key so the column can be dropped again; the repository keeps it
+-- pointing at an author.
+CREATE TABLE authors (
+    id   INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE COLLATE NOCASE,
+    bio  TEXT NOT NULL DEFAULT ''
+);
+-- an author for every name the books already have, in one of its
+-- spellings, which then becomes every such book's
+INSERT INTO authors (name)
+    SELECT TRIM(author) FROM books GROUP BY TRIM(author) COLLATE NOCASE ORDER BY MIN(id);
+ALTER TABLE books ADD COLUMN author_id INTEGER;
+UPDATE books SET author_id = (SELECT a.id FROM authors a WHERE a.name = TRIM(books.author));
+UPDATE books SET author = (SELECT a.name FROM authors a WHERE a.id = books.author_id);
+CREATE INDEX books_author_id ON books (author_id);
+
+-- +goose Down
+DROP INDEX books_author_id;
+ALTER TABLE books DROP COLUMN author_id;
+DROP TABLE authors;
@@ -0,0 +1,17 @@
+-- +goose Up
+-- Checkouts of the books example's books. The borrower is the auth
+-- example's username. A book has at most one open loan, one without a
+-- returned_at, which the partial index enforces.
+CREATE TABLE book_loans (
+    id             INTEGER PRIMARY KEY AUTOINCREMENT,
+    book_id        INTEGER NOT NULL REFERENCES books (id) ON DELETE CASCADE,
+    borrower       TEXT NOT NULL,
+    checked_out_at TIMESTAMP NOT NULL,
+    due_at         TIMESTAMP NOT NULL,
+    returned_at    TIMESTAMP
+);
+CREATE INDEX book_loans_book_id ON book_loans (book_id);
+CREATE UNIQUE INDEX book_loans_open ON book_loans (book_id) WHERE returned_at IS NULL;
+
+-- +goose Down
+DROP TABLE book_loans;
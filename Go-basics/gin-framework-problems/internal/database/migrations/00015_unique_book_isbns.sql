@@ -0,0 +1,11 @@
+-- +goose Up
+-- ISBNs are kept without hyphens or spaces, and no two books share one.
+-- Of books already sharing an ISBN, the first added keeps it.
+UPDATE books SET isbn = NULLIF(UPPER(REPLACE(REPLACE(isbn, '-', ''), ' ', '')), '')
+    WHERE isbn IS NOT NULL;
+UPDATE books SET isbn = NULL
+    WHERE isbn IS NOT NULL AND id > (SELECT MIN(b.id) FROM books b WHERE b.isbn = books.isbn);
+CREATE UNIQUE INDEX books_isbn ON books (isbn);
+
+-- +goose Down
+DROP INDEX books_isbn;
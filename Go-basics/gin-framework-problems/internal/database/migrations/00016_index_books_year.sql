@@ -0,0 +1,6 @@
+-- +goose Up
+-- GET /books?year_gte=&year_lte= reads a range of years.
+CREATE INDEX books_year ON books (year);
+
+-- +goose Down
+DROP INDEX books_year;
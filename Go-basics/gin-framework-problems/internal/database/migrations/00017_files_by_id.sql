@@ -0,0 +1,30 @@
+-- +goose Up
+-- files were keyed by name, so an upload replaced any other of the same name;
+-- now each upload has its own ID. Existing rows keep their name as the ID,
+-- which is also where their content is stored.
+CREATE TABLE files_by_id (
+    id           TEXT PRIMARY KEY,
+    filename     TEXT NOT NULL,
+    size         INTEGER NOT NULL,
+    content_type TEXT NOT NULL DEFAULT 'application/octet-stream',
+    uploader     TEXT NOT NULL DEFAULT '',
+    uploaded_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+INSERT INTO files_by_id (id, filename, size, content_type, uploaded_at)
+    SELECT name, name, size, content_type, uploaded_at FROM files;
+DROP TABLE files;
+ALTER TABLE files_by_id RENAME TO files;
+CREATE INDEX files_uploaded_at ON files (uploaded_at, id);
+
+-- +goose Down
+CREATE TABLE files_by_name (
+    name         TEXT PRIMARY KEY,
+    size         INTEGER NOT NULL,
+    content_type TEXT NOT NULL DEFAULT 'application/octet-stream',
+    uploaded_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+-- of uploads sharing a name, the latest stays
+INSERT OR REPLACE INTO files_by_name (name, size, content_type, uploaded_at)
+    SELECT filename, size, content_type, uploaded_at FROM files ORDER BY uploaded_at, id;
+DROP TABLE files;
+ALTER TABLE files_by_name RENAME TO files;
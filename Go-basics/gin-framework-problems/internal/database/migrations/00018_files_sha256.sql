@@ -0,0 +1,11 @@
+-- +goose Up
+-- identical uploads share one blob, stored under the SHA-256 of the content
+-- and kept while any file has that digest. Files from before have none and
+-- keep their content under their ID.
+ALTER TABLE files ADD COLUMN sha256 TEXT NOT NULL DEFAULT '';
+CREATE INDEX files_sha256 ON files (sha256);
+
+-- +goose Down
+-- content stored since is under digests that no file names any more
+DROP INDEX files_sha256;
+ALTER TABLE files DROP COLUMN sha256;
@@ -0,0 +1,6 @@
+-- +goose Up
+-- for adding up each user's usage against files.quota_bytes
+CREATE INDEX files_uploader ON files (uploader);
+
+-- +goose Down
+DROP INDEX files_uploader;
@@ -0,0 +1,8 @@
+-- +goose Up
+-- uploads with a ttl, which file_expiry deletes once it is up
+ALTER TABLE files ADD COLUMN expires_at TIMESTAMP;
+CREATE INDEX files_expires_at ON files (expires_at) WHERE expires_at IS NOT NULL;
+
+-- +goose Down
+DROP INDEX files_expires_at;
+ALTER TABLE files DROP COLUMN expires_at;
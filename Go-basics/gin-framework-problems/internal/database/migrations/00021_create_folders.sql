@@ -0,0 +1,15 @@
+-- +goose Up
+-- folders only exist here: content is stored by digest wherever its file is
+CREATE TABLE folders (
+    path       TEXT PRIMARY KEY,
+    owner      TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+ALTER TABLE files ADD COLUMN folder TEXT NOT NULL DEFAULT '';
+CREATE INDEX files_folder ON files (folder);
+
+-- +goose Down
+-- files in folders end up in the root, next to any others of the same name
+DROP INDEX files_folder;
+ALTER TABLE files DROP COLUMN folder;
+DROP TABLE folders;
@@ -0,0 +1,17 @@
+-- +goose Up
+-- the tenant each user, book and file belongs to; everything stored before
+-- tenancy is the default tenant's
+ALTER TABLE users ADD COLUMN tenant TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE books ADD COLUMN tenant TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE files ADD COLUMN tenant TEXT NOT NULL DEFAULT 'default';
+CREATE INDEX users_tenant ON users (tenant);
+CREATE INDEX books_tenant ON books (tenant);
+CREATE INDEX files_tenant ON files (tenant);
+
+-- +goose Down
+DROP INDEX files_tenant;
+DROP INDEX books_tenant;
+DROP INDEX users_tenant;
+ALTER TABLE files DROP COLUMN tenant;
+ALTER TABLE books DROP COLUMN tenant;
+ALTER TABLE users DROP COLUMN tenant;
@@ -0,0 +1,88 @@
+// Package events is a small publish/subscribe bus for domain events. Examples
+// publish through Default; it is in-process unless NewEngine connects it to
+// NATS or to a RabbitMQ (AMQP) exchange, in which case events reach
+// subscribers in other processes too.
+//
+// Events are typed: a Topic pairs a subject with its payload type, so
+// publishers and subscribers can't disagree on the shape. On the wire each
+// is an Envelope in JSON, which consumers in any language can read.
+package events
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"time"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/requestid"
+)
+
+// Bus moves encoded events between publishers and subscribers.
+type Bus interface {
+	Publish(ctx context.Context, subject string, data []byte) error
+	// Subscribe calls h for every event on subject until unsubscribe is
+	// called. h runs on the bus's goroutine, not the publisher's.
+	Subscribe(subject string, h func(data []byte)) (unsubscribe func(), err error)
+	Close(ctx context.Context) error
+}
+
+// Default is the bus the examples publish to. NewEngine replaces it with a
+// NATS or AMQP bus when events.nats_url or events.amqp_url is set, so
+// subscribe after NewEngine.
+var Default Bus = NewMemoryBus(256)
+
+// Topic is a subject whose events carry a T.
+type Topic[T any] struct {
+	Subject string
+}
+
+// Version is the Envelope version Publish writes. Fields may be added to
+// envelopes and payloads within a version; renaming or removing one takes a
+// new version.
+const Version = 1
+
+// Envelope is the wire format. ID is unique per event, so consumers of an
+// at-least-once broker can drop duplicates, and the request ID travels with
+// the event so the subscriber's logs line up with the request that caused
+// it. Consumers that don't know the payload type can decode Data as a
+// json.RawMessage.
+type Envelope[T any] struct {
+	ID        string    `json:"id"`
+	Version   int       `json:"version"`
+	Subject   string    `json:"subject"`
+	Time      time.Time `json:"time"`
+	RequestID string    `json:"request_id,omitempty"`
+	Data      T         `json:"data"`
+}
+
+// Publish sends event on t through bus.
+func Publish[T any](ctx context.Context, bus Bus, t Topic[T], event T) error {
+	data, err := json.Marshal(Envelope[T]{
+		ID:        rand.Text(),
+		Version:   Version,
+		Subject:   t.Subject,
+		Time:      time.Now().UTC(),
+		RequestID: requestid.FromContext(ctx),
+		Data:      event,
+	})
+	if err != nil {
+		return err
+	}
+	return bus.Publish(ctx, t.Subject, data)
+}
+
+// Subscribe calls h with every event published on t. The context carries
+// the publishing request's ID. Events that don't decode are dropped.
+func Subscribe[T any](bus Bus, t Topic[T], h func(ctx context.Context, event T)) (func(), error) {
+	return bus.Subscribe(t.Subject, func(data []byte) {
+		var env Envelope[T]
+		if err := json.Unmarshal(data, &env); err != nil {
+			return
+		}
+		ctx := context.Background()
+		if env.RequestID != "" {
+			ctx = requestid.NewContext(ctx, env.RequestID)
+		}
+		h(ctx, env.Data)
+	})
+}
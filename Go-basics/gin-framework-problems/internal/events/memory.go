@@ -0,0 +1,101 @@
+package events
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+)
+
+// MemoryBus delivers events inside the process. Each subscription has its
+// own buffered queue and goroutine, so a slow subscriber never blocks the
+// publisher; when its queue is full, events for it are dropped and logged.
+type MemoryBus struct {
+	mu     sync.Mutex
+	size   int
+	subs   map[string]map[*subscription]struct{}
+	closed bool
+	wg     sync.WaitGroup
+}
+
+type subscription struct {
+	ch   chan []byte
+	once sync.Once
+}
+
+func (s *subscription) close() {
+	s.once.Do(func() { close(s.ch) })
+}
+
+// NewMemoryBus gives each subscription a queue of size events.
+func NewMemoryBus(size int) *MemoryBus {
+	return &MemoryBus{size: size, subs: map[string]map[*subscription]struct{}{}}
+}
+
+func (b *MemoryBus) Publish(ctx context.Context, subject string, data []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for s := range b.subs[subject] {
+		select {
+		case s.ch <- data:
+		default:
+			slog.WarnContext(ctx, "event dropped, subscriber queue full", "subject", subject)
+		}
+	}
+	return nil
+}
+
+func (b *MemoryBus) Subscribe(subject string, h func(data []byte)) (func(), error) {
+	s := &subscription{ch: make(chan []byte, b.size)}
+
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return func() {}, nil
+	}
+	if b.subs[subject] == nil {
+		b.subs[subject] = map[*subscription]struct{}{}
+	}
+	b.subs[subject][s] = struct{}{}
+	b.wg.Add(1)
+	b.mu.Unlock()
+
+	go func() {
+		defer b.wg.Done()
+		for data := range s.ch {
+			h(data)
+		}
+	}()
+
+	return func() {
+		b.mu.Lock()
+		delete(b.subs[subject], s)
+		b.mu.Unlock()
+		s.close()
+	}, nil
+}
+
+// Close stops accepting subscriptions and waits until the queued events are
+// handled or ctx expires.
+func (b *MemoryBus) Close(ctx context.Context) error {
+	b.mu.Lock()
+	b.closed = true
+	for _, subs := range b.subs {
+		for s := range subs {
+			s.close()
+		}
+	}
+	b.subs = map[string]map[*subscription]struct{}{}
+	b.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		b.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
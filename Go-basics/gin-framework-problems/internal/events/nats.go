@@ -0,0 +1,64 @@
+package events
+
+import (
+	"context"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// NATSBus publishes events as NATS messages, so subscribers in any process
+// connected to the same server receive them. Delivery is at most once.
+type NATSBus struct {
+	conn *nats.Conn
+}
+
+// ConnectNATS connects to the NATS server at url, reconnecting forever.
+func ConnectNATS(url, name string) (*NATSBus, error) {
+	conn, err := nats.Connect(url,
+		nats.Name(name),
+		nats.MaxReconnects(-1),
+		nats.ReconnectWait(time.Second),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &NATSBus{conn: conn}, nil
+}
+
+func (b *NATSBus) Publish(_ context.Context, subject string, data []byte) error {
+	return b.conn.Publish(subject, data)
+}
+
+func (b *NATSBus) Subscribe(subject string, h func(data []byte)) (func(), error) {
+	sub, err := b.conn.Subscribe(subject, func(msg *nats.Msg) { h(msg.Data) })
+	if err != nil {
+		return nil, err
+	}
+	return func() { sub.Unsubscribe() }, nil
+}
+
+// Close drains the connection: pending handlers finish, buffered publishes
+// are flushed.
+func (b *NATSBus) Close(ctx context.Context) error {
+	if err := b.conn.Drain(); err != nil {
+		return err
+	}
+	for !b.conn.IsClosed() {
+		select {
+		case <-ctx.Done():
+			b.conn.Close()
+			return ctx.Err()
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+	return nil
+}
+
+// Ping is a readiness check for the NATS connection.
+func (b *NATSBus) Ping(ctx context.Context) error {
+	if !b.conn.IsConnected() {
+		return nats.ErrConnectionClosed
+	}
+	return b.conn.FlushWithContext(ctx)
+}
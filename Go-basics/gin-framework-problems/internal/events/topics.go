@@ -0,0 +1,69 @@
+package events
+
+// The events the examples publish.
+var (
+	UserRegistered = Topic[UserRegisteredEvent]{Subject: "user.registered"}
+	UserCreated    = Topic[UserCreatedEvent]{Subject: "user.created"}
+	FileUploaded   = Topic[FileUploadedEvent]{Subject: "file.uploaded"}
+	FileDeleted    = Topic[FileDeletedEvent]{Subject: "file.deleted"}
+	BookUpdated    = Topic[BookUpdatedEvent]{Subject: "book.updated"}
+	BookDeleted    = Topic[BookDeletedEvent]{Subject: "book.deleted"}
+)
+
+// Subjects lists the subjects of every topic above, for consumers that
+// take them all.
+var Subjects = []string{
+	UserRegistered.Subject,
+	UserCreated.Subject,
+	FileUploaded.Subject,
+	FileDeleted.Subject,
+	BookUpdated.Subject,
+	BookDeleted.Subject,
+}
+
+type UserRegisteredEvent struct {
+	UserID   string `json:"user_id"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+}
+
+// UserCreatedEvent is a new account, however it came to be; Source says
+// how. Self-registrations are on UserRegistered as well.
+type UserCreatedEvent struct {
+	UserID   string `json:"user_id"`
+	Username string `json:"username"`
+	Role     string `json:"role"`
+	Source   string `json:"source"` // register or import
+}
+
+type FileUploadedEvent struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"` // as uploaded; several files can share it
+	Size     int64  `json:"size"`
+	SHA256   string `json:"sha256"`             // hex digest of the content
+	Uploader string `json:"uploader,omitempty"` // username; empty for anonymous uploads
+}
+
+// FileDeletedEvent is a file gone for good; Reason says why.
+type FileDeletedEvent struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Uploader string `json:"uploader,omitempty"`
+	Reason   string `json:"reason"` // deleted, or expired for file_expiry's
+}
+
+// BookUpdatedEvent covers every change to a book; Action says which.
+type BookUpdatedEvent struct {
+	BookID  string `json:"book_id"`
+	Action  string `json:"action"` // created, updated, deleted, restored, checked_out or returned
+	Title   string `json:"title,omitempty"`
+	OwnerID string `json:"owner_id,omitempty"` // the book's Book.OwnerID
+}
+
+// BookDeletedEvent is a book put aside, as BookUpdated with action deleted
+// also says; it can be restored.
+type BookDeletedEvent struct {
+	BookID  string `json:"book_id"`
+	Title   string `json:"title"`
+	OwnerID string `json:"owner_id,omitempty"`
+}
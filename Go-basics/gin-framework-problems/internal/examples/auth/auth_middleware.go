@@ -0,0 +1,192 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/audit"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/loginguard"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/metrics"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/rbac"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/server"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/tenant"
+)
+
+type LoginRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+type UserInfo struct {
+	Username string `json:"username"`
+	Role     string `json:"role"`
+	// Tenant is the tenant the user signed in to; the accounts are shared,
+	// the sessions are not. See internal/tenant.
+	Tenant string `json:"tenant,omitempty"`
+}
+
+// TenantID lets middleware.Auth refuse the token on other tenants' requests.
+// Sessions from before tenancy belong to the default tenant.
+func (u UserInfo) TenantID() string {
+	return tenant.Owner(u.Tenant)
+}
+
+// Subject identifies the user in request logs.
+func (u UserInfo) Subject() string {
+	return u.Username
+}
+
+// HasRole lets RequireRole and feature flags check the user's role.
+func (u UserInfo) HasRole(role string) bool {
+	return u.Role == role
+}
+
+// Can lets RequirePermission check what the user's role grants.
+func (u UserInfo) Can(permission string) bool {
+	return rbac.Default.Allowed(u.Role, permission)
+}
+
+var (
+	errInvalidToken = errors.New("invalid token")
+	errTokenExpired = errors.New("token has expired")
+)
+
+// Email is the address of the account username, if it has one.
+func (s *Server) Email(username string) (string, bool) {
+	a, ok := s.accounts[username]
+	return a.Email, ok && a.Email != ""
+}
+
+// Login issues a token for a valid username/password.
+func (s *Server) Login(c *gin.Context) {
+	var req LoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.BindError(c, err)
+		return
+	}
+
+	if err := loginguard.Default.Check(c, req.Username); err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	u, ok := s.accounts[req.Username]
+	if !ok || u.Password != req.Password {
+		loginguard.Default.Failed(c, req.Username)
+		audit.Record(c, audit.Event{Action: audit.Login, Outcome: audit.Failure, Actor: req.Username})
+		metrics.Login(false)
+		middleware.Error(c, http.StatusUnauthorized, "invalid credentials")
+		return
+	}
+	loginguard.Default.Succeeded(c, req.Username)
+	audit.Record(c, audit.Event{Action: audit.Login, Outcome: audit.Success, Actor: req.Username})
+	metrics.Login(true)
+
+	now := s.now()
+	token := newToken()
+	err := s.tokens.Put(c.Request.Context(), token, Session{
+		User:      UserInfo{Username: req.Username, Role: u.Role, Tenant: tenant.Assign(c.Request.Context())},
+		IssuedAt:  now,
+		ExpiresAt: now.Add(s.sessionTTL),
+	})
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"token": token, "expires_in": int(s.sessionTTL.Seconds())})
+}
+
+// Logout revokes the bearer token the request came with, on every instance
+// that shares the token store.
+func (s *Server) Logout(c *gin.Context) {
+	if err := s.tokens.Delete(c.Request.Context(), c.GetString(middleware.TokenKey)); err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// LookupToken resolves a bearer token issued by Login, refusing an expired
+// one as such. With sliding sessions it extends the token by the session
+// lifetime from now.
+func (s *Server) LookupToken(token string) (any, error) {
+	// the Authenticator signature has no request context
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	now := s.now()
+	sess, err := s.tokens.Get(ctx, token)
+	switch {
+	case errors.Is(err, errNoToken):
+		return nil, errInvalidToken
+	case err != nil:
+		return nil, errors.New("cannot verify token")
+	case !now.Before(sess.ExpiresAt):
+		return nil, errTokenExpired
+	}
+	if s.sliding {
+		// a failure leaves the token to expire when it would have
+		if err := s.tokens.Expire(ctx, token, now.Add(s.sessionTTL)); err != nil && !errors.Is(err, errNoToken) {
+			s.logger.WarnContext(ctx, "session not extended", "error", err)
+		}
+	}
+	return sess.User, nil
+}
+
+// CurrentUser is the user Auth(LookupToken) let in. ok is false without
+// one, as on a public route.
+func CurrentUser(c *gin.Context) (UserInfo, bool) {
+	return middleware.Principal[UserInfo](c)
+}
+
+// MustUser is CurrentUser for handlers behind Auth(LookupToken), which
+// always have a user. It panics, a 500, when they are mounted without it.
+func MustUser(c *gin.Context) UserInfo {
+	u, ok := CurrentUser(c)
+	if !ok {
+		panic("auth: no UserInfo in the context; mount the handler behind Auth(LookupToken)")
+	}
+	return u
+}
+
+func getProfile(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"profile": MustUser(c)})
+}
+
+// Routes mounts s's routes on router: POST /login, and the /api routes
+// behind its tokens.
+func (s *Server) Routes(router gin.IRouter) {
+	// Public
+	router.POST("/login", s.Login)
+
+	// Protected
+	protected := router.Group("/api")
+	protected.Use(middleware.Auth(s.LookupToken))
+	{
+		protected.GET("/profile", getProfile)
+		protected.POST("/logout", s.Logout)
+		protected.GET("/settings", s.getSettings)
+		protected.PUT("/settings", s.putSettings)
+	}
+}
+
+// Deps are what NewRouter builds the example from.
+type Deps struct {
+	server.Deps
+	// whose accounts and tokens are served; NewSessions' when nil
+	Auth *Server
+}
+
+// NewRouter builds the token auth example router, serving deps.Auth.
+func NewRouter(deps Deps) *gin.Engine {
+	s := deps.Auth
+	if s == nil {
+		s = NewSessions(deps.Deps)
+	}
+	router := server.NewEngine(deps.Deps)
+	s.Routes(router)
+	return router
+}
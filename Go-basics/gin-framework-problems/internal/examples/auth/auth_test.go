@@ -0,0 +1,186 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/config"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/tenant"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/testutil"
+)
+
+func TestLogin(t *testing.T) {
+	router, _, _ := newRouter(t, nil)
+	tests := []struct {
+		name   string
+		body   any
+		status int
+	}{
+		{"user", LoginRequest{Username: "alice", Password: "password1"}, http.StatusOK},
+		{"wrong password", LoginRequest{Username: "alice", Password: "nope"}, http.StatusUnauthorized},
+		{"unknown user", LoginRequest{Username: "mallory", Password: "password1"}, http.StatusUnauthorized},
+		{"missing password", map[string]string{"username": "alice"}, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := testutil.DoJSON(t, router, http.MethodPost, "/login", tt.body)
+			testutil.AssertStatus(t, w, tt.status)
+		})
+	}
+}
+
+func TestProfile(t *testing.T) {
+	router, _, _ := newRouter(t, nil)
+	token := testutil.Login(t, router, "/login", "bob", "adminpass")
+
+	testutil.AssertStatus(t, testutil.Do(t, router, http.MethodGet, "/api/profile", nil), http.StatusUnauthorized)
+	w := testutil.Do(t, router, http.MethodGet, "/api/profile", nil, testutil.WithToken(token))
+	testutil.AssertJSON(t, w, http.StatusOK, gin.H{"profile": UserInfo{Username: "bob", Role: "admin", Tenant: tenant.DefaultID}})
+}
+
+func TestSessionExpiry(t *testing.T) {
+	tests := []struct {
+		name    string
+		sliding bool
+	}{
+		{"fixed", false},
+		{"sliding", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testutil.Config(t)
+			cfg.Auth.SessionTTL = time.Hour
+			cfg.Auth.SlidingSessions = tt.sliding
+			router, s, clock := newRouter(t, cfg)
+			w := testutil.DoJSON(t, router, http.MethodPost, "/login", LoginRequest{Username: "alice", Password: "password1"})
+			got := testutil.Decode[struct {
+				Token     string `json:"token"`
+				ExpiresIn int    `json:"expires_in"`
+			}](t, w)
+			if got.ExpiresIn != 3600 {
+				t.Errorf("expires_in = %d, want 3600", got.ExpiresIn)
+			}
+			token := got.Token
+
+			// with a minute left, a use extends a sliding session to an hour
+			clock.Advance(59 * time.Minute)
+			testutil.AssertStatus(t, testutil.Do(t, router, http.MethodGet, "/api/profile", nil, testutil.WithToken(token)), http.StatusOK)
+			sess, err := s.tokens.Get(context.Background(), token)
+			if err != nil {
+				t.Fatal(err)
+			}
+			left := sess.ExpiresAt.Sub(clock.Now())
+			if extended := left > time.Minute; extended != tt.sliding {
+				t.Errorf("%s left after a use, want sliding = %v", left, tt.sliding)
+			}
+
+			clock.Advance(left)
+			w = testutil.Do(t, router, http.MethodGet, "/api/profile", nil, testutil.WithToken(token))
+			testutil.AssertJSON(t, w, http.StatusUnauthorized, gin.H{"code": "unauthorized", "error": "token has expired"}, "request_id")
+			// kept for a grace period, then forgotten
+			store := s.tokens.(*MemoryTokenStore)
+			if n := store.Purge(clock.Now()); n != 0 {
+				t.Errorf("Purge dropped %d tokens within the grace period", n)
+			}
+			if n := store.Purge(clock.Now().Add(expiredGrace)); n != 1 {
+				t.Errorf("Purge dropped %d tokens, want 1", n)
+			}
+			w = testutil.Do(t, router, http.MethodGet, "/api/profile", nil, testutil.WithToken(token))
+			testutil.AssertJSON(t, w, http.StatusUnauthorized, gin.H{"code": "unauthorized", "error": "invalid token"}, "request_id")
+		})
+	}
+}
+
+// TestFlow follows a user from login through their settings to logout and
+// back.
+func TestFlow(t *testing.T) {
+	router, _, _ := newRouter(t, nil)
+	token := testutil.Login(t, router, "/login", "alice", "password1")
+
+	w := testutil.Do(t, router, http.MethodGet, "/api/settings", nil, testutil.WithToken(token))
+	testutil.AssertJSON(t, w, http.StatusOK, defaultSettings)
+
+	steps := []struct {
+		name   string
+		body   any
+		status int
+		want   any
+	}{
+		{"theme", gin.H{"theme": "dark"}, http.StatusOK, gin.H{
+			"theme": "dark", "locale": "en",
+			"notifications": gin.H{"email": true, "push": false, "digest": "weekly"},
+		}},
+		{"digest kept theme", gin.H{"notifications": gin.H{"digest": "daily"}}, http.StatusOK, gin.H{
+			"theme": "dark", "locale": "en",
+			"notifications": gin.H{"email": true, "push": false, "digest": "daily"},
+		}},
+		{"bad theme", gin.H{"theme": "neon"}, http.StatusBadRequest, nil},
+	}
+	for _, step := range steps {
+		w := testutil.DoJSON(t, router, http.MethodPut, "/api/settings", step.body, testutil.WithToken(token))
+		if step.want == nil {
+			testutil.AssertStatus(t, w, step.status)
+			continue
+		}
+		testutil.AssertJSON(t, w, step.status, step.want)
+	}
+
+	testutil.AssertStatus(t, testutil.Do(t, router, http.MethodPost, "/api/logout", nil, testutil.WithToken(token)), http.StatusNoContent)
+	testutil.AssertStatus(t, testutil.Do(t, router, http.MethodGet, "/api/settings", nil, testutil.WithToken(token)), http.StatusUnauthorized)
+
+	// settings belong to the user, not the token
+	token = testutil.Login(t, router, "/login", "alice", "password1")
+	w = testutil.Do(t, router, http.MethodGet, "/api/settings", nil, testutil.WithToken(token))
+	testutil.AssertStatus(t, w, http.StatusOK)
+	if got := testutil.Decode[Settings](t, w); got.Notifications.Digest != "daily" {
+		t.Errorf("digest after logging in again = %q, want daily", got.Notifications.Digest)
+	}
+	bob := testutil.Login(t, router, "/login", "bob", "adminpass")
+	w = testutil.Do(t, router, http.MethodGet, "/api/settings", nil, testutil.WithToken(bob))
+	testutil.AssertJSON(t, w, http.StatusOK, defaultSettings)
+}
+
+func TestLogout(t *testing.T) {
+	router, _, _ := newRouter(t, nil)
+	token := testutil.Login(t, router, "/login", "alice", "password1")
+	other := testutil.Login(t, router, "/login", "alice", "password1")
+	if token == other {
+		t.Fatal("two logins got the same token")
+	}
+
+	testutil.AssertStatus(t, testutil.Do(t, router, http.MethodPost, "/api/logout", nil, testutil.WithToken(token)), http.StatusNoContent)
+	testutil.AssertStatus(t, testutil.Do(t, router, http.MethodGet, "/api/profile", nil, testutil.WithToken(token)), http.StatusUnauthorized)
+	// only the token it was sent with
+	testutil.AssertStatus(t, testutil.Do(t, router, http.MethodGet, "/api/profile", nil, testutil.WithToken(other)), http.StatusOK)
+}
+
+// TestServers checks that each Server has its own accounts and tokens.
+func TestServers(t *testing.T) {
+	routes := func(s *Server) *gin.Engine {
+		return NewRouter(Deps{Deps: testutil.Deps(t, nil), Auth: s})
+	}
+	a := routes(NewServer(Options{}))
+	b := routes(NewServer(Options{Accounts: map[string]Account{"carol": {Password: "password3", Role: "user"}}}))
+
+	token := testutil.Login(t, a, "/login", "alice", "password1")
+	testutil.AssertStatus(t, testutil.Do(t, b, http.MethodGet, "/api/profile", nil, testutil.WithToken(token)), http.StatusUnauthorized)
+	w := testutil.DoJSON(t, b, http.MethodPost, "/login", LoginRequest{Username: "alice", Password: "password1"})
+	testutil.AssertStatus(t, w, http.StatusUnauthorized)
+	token = testutil.Login(t, b, "/login", "carol", "password3")
+	testutil.AssertStatus(t, testutil.Do(t, b, http.MethodGet, "/api/profile", nil, testutil.WithToken(token)), http.StatusOK)
+}
+
+// newRouter builds the example on sessions of its own, configured by cfg
+// (testutil.Config when nil) and expiring by the clock it returns.
+func newRouter(t *testing.T, cfg *config.Config) (*gin.Engine, *Server, *testutil.Clock) {
+	t.Helper()
+	clock := testutil.NewClock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	deps := testutil.Deps(t, cfg)
+	deps.Clock = clock
+	s := NewSessions(deps)
+	return NewRouter(Deps{Deps: deps, Auth: s}), s, clock
+}
@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Account is a user of the example: a plaintext password, for the demo, a
+// role in rbac.Default and where to mail them, such as loan reminders.
+type Account struct {
+	Password string
+	Role     string
+	Email    string
+}
+
+// demoAccounts are the users Options leaves Accounts empty for.
+var demoAccounts = map[string]Account{
+	"alice": {Password: "password1", Role: "user", Email: "alice@example.com"},
+	"bob":   {Password: "adminpass", Role: "admin", Email: "bob@example.com"},
+}
+
+// Options are what a Server depends on. Zero fields take the defaults noted.
+type Options struct {
+	Accounts   map[string]Account // by username; alice (user) and bob (admin)
+	Tokens     TokenStore         // a NewMemoryTokenStore, never purged
+	SessionTTL time.Duration      // an hour, as in config.Default
+	Sliding    bool               // each use extends a token by SessionTTL
+	Now        func() time.Time   // time.Now
+	Logger     *slog.Logger       // slog.Default()
+}
+
+// Server is the token auth example: its accounts, the tokens it issued and
+// each user's settings. Every Server has its own, so tests and several
+// instances in one process don't share state. Other examples that sign
+// users in build their own with NewSessions and mount its handlers.
+type Server struct {
+	accounts   map[string]Account
+	tokens     TokenStore
+	sessionTTL time.Duration
+	sliding    bool
+	now        func() time.Time
+	logger     *slog.Logger
+
+	settingsMu sync.Mutex
+	settings   map[string]settingsOverrides // username -> what they set
+}
+
+// NewServer returns a Server with opts' dependencies.
+func NewServer(opts Options) *Server {
+	s := &Server{
+		accounts:   opts.Accounts,
+		tokens:     opts.Tokens,
+		sessionTTL: opts.SessionTTL,
+		sliding:    opts.Sliding,
+		now:        opts.Now,
+		logger:     opts.Logger,
+		settings:   map[string]settingsOverrides{},
+	}
+	if s.accounts == nil {
+		s.accounts = demoAccounts
+	}
+	if s.tokens == nil {
+		s.tokens = NewMemoryTokenStore()
+	}
+	if s.sessionTTL == 0 {
+		s.sessionTTL = time.Hour
+	}
+	if s.now == nil {
+		s.now = time.Now
+	}
+	if s.logger == nil {
+		s.logger = slog.Default()
+	}
+	return s
+}
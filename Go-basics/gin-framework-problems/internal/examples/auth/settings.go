@@ -0,0 +1,129 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+)
+
+// Settings is a user's preferences as clients see them: every key is always
+// present, from defaultSettings unless the user set it.
+type Settings struct {
+	Theme         string        `json:"theme"`  // light, dark or system
+	Locale        string        `json:"locale"` // a language internal/i18n has a catalog for
+	Notifications Notifications `json:"notifications"`
+}
+
+// Notifications says how a user wants to hear from the service.
+type Notifications struct {
+	Email  bool   `json:"email"`
+	Push   bool   `json:"push"`
+	Digest string `json:"digest"` // off, daily or weekly
+}
+
+// defaultSettings fills in every key a user hasn't set.
+var defaultSettings = Settings{
+	Theme:  "system",
+	Locale: "en",
+	Notifications: Notifications{
+		Email:  true,
+		Push:   false,
+		Digest: "weekly",
+	},
+}
+
+// settingsOverrides are the keys a user set; nil means the default. Only
+// these are stored, so a changed default reaches everyone who never picked
+// the key.
+type settingsOverrides struct {
+	Theme         *string                `json:"theme" binding:"omitempty,oneof=light dark system"`
+	Locale        *string                `json:"locale" binding:"omitempty,locale"`
+	Notifications *notificationOverrides `json:"notifications"`
+}
+
+type notificationOverrides struct {
+	Email  *bool   `json:"email"`
+	Push   *bool   `json:"push"`
+	Digest *string `json:"digest" binding:"omitempty,oneof=off daily weekly"`
+}
+
+// merge copies the keys set in o over s. An empty string clears a key, so
+// it goes back to its default.
+func (s *settingsOverrides) merge(o settingsOverrides) {
+	setString(&s.Theme, o.Theme)
+	setString(&s.Locale, o.Locale)
+	if n := o.Notifications; n != nil {
+		if s.Notifications == nil {
+			s.Notifications = &notificationOverrides{}
+		}
+		if n.Email != nil {
+			s.Notifications.Email = n.Email
+		}
+		if n.Push != nil {
+			s.Notifications.Push = n.Push
+		}
+		setString(&s.Notifications.Digest, n.Digest)
+	}
+}
+
+func setString(dst **string, v *string) {
+	switch {
+	case v == nil:
+	case *v == "":
+		*dst = nil
+	default:
+		*dst = v
+	}
+}
+
+// resolve fills the keys s doesn't set from defaultSettings.
+func (s settingsOverrides) resolve() Settings {
+	out := defaultSettings
+	if s.Theme != nil {
+		out.Theme = *s.Theme
+	}
+	if s.Locale != nil {
+		out.Locale = *s.Locale
+	}
+	if n := s.Notifications; n != nil {
+		if n.Email != nil {
+			out.Notifications.Email = *n.Email
+		}
+		if n.Push != nil {
+			out.Notifications.Push = *n.Push
+		}
+		if n.Digest != nil {
+			out.Notifications.Digest = *n.Digest
+		}
+	}
+	return out
+}
+
+// getSettings returns the caller's settings, defaults filled in.
+func (s *Server) getSettings(c *gin.Context) {
+	u := MustUser(c)
+	s.settingsMu.Lock()
+	out := s.settings[u.Username].resolve()
+	s.settingsMu.Unlock()
+	c.JSON(http.StatusOK, out)
+}
+
+// putSettings changes the keys in the body and leaves the rest as they are;
+// "" resets a string key to its default. It answers with the whole document.
+func (s *Server) putSettings(c *gin.Context) {
+	var req settingsOverrides
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.BindError(c, err)
+		return
+	}
+	u := MustUser(c)
+	s.settingsMu.Lock()
+	o := s.settings[u.Username]
+	o.merge(req)
+	s.settings[u.Username] = o
+	out := o.resolve()
+	s.settingsMu.Unlock()
+	c.JSON(http.StatusOK, out)
+}
@@ -0,0 +1,224 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/config"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/healthcheck"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/server"
+)
+
+// errNoToken is returned by TokenStore.Get and Expire for a token the store
+// doesn't have.
+var errNoToken = errors.New("auth: no such token")
+
+// expiredGrace is how long a store keeps a token after it expires, so it is
+// refused as expired rather than unknown for a while.
+const expiredGrace = time.Minute
+
+// Session is who a token stands for, and until when.
+type Session struct {
+	User      UserInfo  `json:"user"`
+	IssuedAt  time.Time `json:"issued_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// TokenStore keeps the tokens Login issues. Tokens in memory are
+// this instance's alone; in Redis, every instance pointed at it accepts
+// them. Stores may forget a token expiredGrace after it expires.
+type TokenStore interface {
+	Get(ctx context.Context, token string) (Session, error)
+	Put(ctx context.Context, token string, s Session) error
+	Delete(ctx context.Context, token string) error
+	// Expire moves the token's expiry to expires.
+	Expire(ctx context.Context, token string, expires time.Time) error
+}
+
+// NewSessions returns a Server configured by deps.Config: tokens kept where
+// auth.session_store says, lasting auth.session_ttl and extended on each use
+// with auth.sliding_sessions, expiring by deps' clock.
+func NewSessions(deps server.Deps) *Server {
+	cfg := deps.Config
+	return NewServer(Options{
+		Tokens:     NewTokenStore(cfg, deps.Hooks),
+		SessionTTL: cfg.Auth.SessionTTL,
+		Sliding:    cfg.Auth.SlidingSessions,
+		Now:        deps.Now,
+	})
+}
+
+// NewTokenStore returns the store auth.session_store names. Tokens in memory
+// are purged every minute until shutdown; Redis expires its own.
+func NewTokenStore(cfg *config.Config, hooks *server.Hooks) TokenStore {
+	if cfg.Auth.SessionStore == "redis" {
+		client := redis.NewClient(&redis.Options{Addr: cfg.Redis.Addr})
+		hooks.Add(func(context.Context) error { return client.Close() })
+		store := NewRedisTokenStore(client, "auth:token:")
+		healthcheck.Default.Register("redis", store.Ping)
+		return store
+	}
+	store := NewMemoryTokenStore()
+
+	// tokens live in this process, so every instance purges its own rather
+	// than through the scheduler, which may run on one instance
+	stop, done := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case now := <-ticker.C:
+				store.Purge(now)
+			}
+		}
+	}()
+	hooks.Add(func(ctx context.Context) error {
+		close(stop)
+		select {
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+	return store
+}
+
+// newToken is a random token, unguessable and unique across instances.
+func newToken() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return "tok_" + hex.EncodeToString(b)
+}
+
+// MemoryTokenStore keeps tokens in a map until Purge drops them.
+type MemoryTokenStore struct {
+	mu     sync.Mutex
+	tokens map[string]Session
+}
+
+func NewMemoryTokenStore() *MemoryTokenStore {
+	return &MemoryTokenStore{tokens: map[string]Session{}}
+}
+
+func (m *MemoryTokenStore) Get(_ context.Context, token string) (Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.tokens[token]
+	if !ok {
+		return Session{}, errNoToken
+	}
+	return s, nil
+}
+
+func (m *MemoryTokenStore) Put(_ context.Context, token string, s Session) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tokens[token] = s
+	return nil
+}
+
+func (m *MemoryTokenStore) Delete(_ context.Context, token string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.tokens, token)
+	return nil
+}
+
+func (m *MemoryTokenStore) Expire(_ context.Context, token string, expires time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.tokens[token]
+	if !ok {
+		return errNoToken
+	}
+	s.ExpiresAt = expires
+	m.tokens[token] = s
+	return nil
+}
+
+// Purge drops tokens that expired expiredGrace or more before now and
+// returns how many.
+func (m *MemoryTokenStore) Purge(now time.Time) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n := 0
+	for token, s := range m.tokens {
+		if !now.Before(s.ExpiresAt.Add(expiredGrace)) {
+			delete(m.tokens, token)
+			n++
+		}
+	}
+	return n
+}
+
+// RedisTokenStore keeps each token's session as JSON under prefix+token,
+// expiring with it, expiredGrace later.
+type RedisTokenStore struct {
+	client *redis.Client
+	prefix string
+}
+
+func NewRedisTokenStore(client *redis.Client, prefix string) *RedisTokenStore {
+	return &RedisTokenStore{client: client, prefix: prefix}
+}
+
+// Ping reports whether Redis is reachable, for the readiness check.
+func (r *RedisTokenStore) Ping(ctx context.Context) error {
+	return r.client.Ping(ctx).Err()
+}
+
+func (r *RedisTokenStore) Get(ctx context.Context, token string) (Session, error) {
+	data, err := r.client.Get(ctx, r.prefix+token).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return Session{}, errNoToken
+	}
+	if err != nil {
+		return Session{}, err
+	}
+	var s Session
+	err = json.Unmarshal(data, &s)
+	return s, err
+}
+
+func (r *RedisTokenStore) Put(ctx context.Context, token string, s Session) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return r.client.SetArgs(ctx, r.prefix+token, data, redis.SetArgs{ExpireAt: s.ExpiresAt.Add(expiredGrace)}).Err()
+}
+
+func (r *RedisTokenStore) Delete(ctx context.Context, token string) error {
+	return r.client.Del(ctx, r.prefix+token).Err()
+}
+
+// Expire rewrites the session only if it is still there, so a token deleted
+// meanwhile stays deleted.
+func (r *RedisTokenStore) Expire(ctx context.Context, token string, expires time.Time) error {
+	s, err := r.Get(ctx, token)
+	if err != nil {
+		return err
+	}
+	s.ExpiresAt = expires
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	err = r.client.SetArgs(ctx, r.prefix+token, data, redis.SetArgs{Mode: "XX", ExpireAt: expires.Add(expiredGrace)}).Err()
+	if errors.Is(err, redis.Nil) {
+		return errNoToken
+	}
+	return err
+}
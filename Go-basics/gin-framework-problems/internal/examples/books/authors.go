@@ -0,0 +1,119 @@
+package books
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/pagination"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/query"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/tracing"
+)
+
+// Author wrote books. A book links to one by Book.AuthorID and carries the
+// name in Book.Author too, so filters, sorting and older clients keep
+// working on it. Names are unique, ignoring case.
+type Author struct {
+	ID   string `json:"id"`
+	Name string `json:"name" binding:"required,notblank,max=200"`
+	Bio  string `json:"bio,omitempty" binding:"max=2000"`
+}
+
+// unknownAuthor is the validation detail for an author_id that isn't an
+// author's.
+func unknownAuthor() map[string]string {
+	return map[string]string{"author_id": "not in /authors"}
+}
+
+// listAuthors pages through the authors in the order they were added.
+func (s *Server) listAuthors(c *gin.Context) {
+	req, err := query.Parse[Author](c, nil, "", nil)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	all, err := s.repo.ListAuthors(c.Request.Context())
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	pagination.Write(c, pagination.NewPage(all, req.Page))
+}
+
+func (s *Server) getAuthor(c *gin.Context) {
+	a, err := s.repo.GetAuthor(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, a)
+}
+
+func (s *Server) createAuthor(c *gin.Context) {
+	var input Author
+	if err := c.ShouldBindJSON(&input); err != nil {
+		middleware.BindError(c, err)
+		return
+	}
+	a, err := s.repo.CreateAuthor(c.Request.Context(), input)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, a)
+}
+
+// updateAuthor replaces the name and bio. A new name shows on the author's
+// books at once.
+func (s *Server) updateAuthor(c *gin.Context) {
+	var input Author
+	if err := c.ShouldBindJSON(&input); err != nil {
+		middleware.BindError(c, err)
+		return
+	}
+	input.ID = c.Param("id")
+	a, err := s.repo.UpdateAuthor(c.Request.Context(), input)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	s.invalidateList()
+	c.JSON(http.StatusOK, a)
+}
+
+// deleteAuthor removes an author without books. Deleted books count, since
+// they can be restored.
+func (s *Server) deleteAuthor(c *gin.Context) {
+	if err := s.repo.DeleteAuthor(c.Request.Context(), c.Param("id")); err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// listAuthorBooks pages through the author's books, leaving out deleted
+// ones, in the order they were added.
+func (s *Server) listAuthorBooks(c *gin.Context) {
+	req, err := query.Parse[Book](c, nil, "", nil)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	id := c.Param("id")
+	ctx, span := tracing.Start(c.Request.Context(), "books.store.list_author_books", attribute.String("author.id", id))
+	a, err := s.repo.GetAuthor(ctx, id)
+	var all []Book
+	if err == nil {
+		all, err = s.repo.ListByAuthor(ctx, a.ID)
+	}
+	span.End()
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	all = slices.DeleteFunc(all, Book.Deleted)
+	pagination.Write(c, pagination.NewPage(all, req.Page))
+}
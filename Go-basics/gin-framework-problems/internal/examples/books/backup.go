@@ -0,0 +1,52 @@
+package books
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// keepBackups is how many snapshots the backup task leaves in the backup dir.
+const keepBackups = 7
+
+// backup returns a task that writes the book store to
+// <dir>/books-<UTC time>.json and deletes all but the newest keepBackups.
+func (s *Server) backup(dir string) func(context.Context) error {
+	return func(ctx context.Context) error {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+		all, err := s.List(ctx)
+		if err != nil {
+			return err
+		}
+		data, err := json.MarshalIndent(all, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		// write then rename, so a crash never leaves a truncated snapshot
+		name := filepath.Join(dir, "books-"+s.now().UTC().Format("20060102T150405Z")+".json")
+		if err := os.WriteFile(name+".tmp", data, 0644); err != nil {
+			return err
+		}
+		if err := os.Rename(name+".tmp", name); err != nil {
+			return err
+		}
+
+		old, err := filepath.Glob(filepath.Join(dir, "books-*.json"))
+		if err != nil {
+			return err
+		}
+		// the timestamp format sorts lexically
+		sort.Strings(old)
+		for _, f := range old[:max(len(old)-keepBackups, 0)] {
+			if err := os.Remove(f); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,167 @@
+package books
+
+import (
+	"encoding/json"
+	"errors"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/apperror"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/negotiate"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/tracing"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/validation"
+)
+
+// maxBatch caps the items of one batch request.
+const maxBatch = 100
+
+// BatchResult reports what became of one item of a batch.
+type BatchResult struct {
+	Index   int               `json:"index"` // in the request, from 0
+	ID      string            `json:"id,omitempty"`
+	Status  string            `json:"status"` // created, deleted, not_found or error
+	Error   string            `json:"error,omitempty"`
+	Details map[string]string `json:"details,omitempty"` // field → message, for an invalid book
+}
+
+// batch statuses
+const (
+	batchCreated  = "created"
+	batchDeleted  = "deleted"
+	batchNotFound = "not_found"
+	batchError    = "error"
+)
+
+// createBooks stores every valid book of a JSON array and reports on each
+// item. An item that isn't a book, or fails the rules of POST /books, is an
+// error and the others are created all the same.
+func (s *Server) createBooks(c *gin.Context) {
+	var items []json.RawMessage
+	if err := c.ShouldBindJSON(&items); err != nil {
+		middleware.BindError(c, err)
+		return
+	}
+	if err := checkBatchSize(len(items)); err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+
+	ctx, span := tracing.Start(c.Request.Context(), "books.store.create_batch")
+	defer span.End()
+	results := make([]BatchResult, len(items))
+	for i, raw := range items {
+		res := BatchResult{Index: i, Status: batchError}
+		var b Book
+		if err := json.Unmarshal(raw, &b); err != nil {
+			res.Error = err.Error()
+			results[i] = res
+			continue
+		}
+		if err := binding.Validator.ValidateStruct(&b); err != nil {
+			res.Error, res.Details = invalidBook(c, err)
+			results[i] = res
+			continue
+		}
+		b.OwnerID, b.DeletedAt = "", time.Time{}
+		b, err := s.repo.Create(ctx, b)
+		var unknown unknownCategoriesError
+		switch {
+		case errors.As(err, &unknown):
+			res.Error, res.Details = "unknown category", unknownCategories(unknown)
+		case errors.Is(err, errUnknownAuthor):
+			res.Error, res.Details = "unknown author", unknownAuthor()
+		case errors.Is(err, errISBNExists):
+			res.Error = errISBNExists.Message
+		case err != nil:
+			// the items before this one are stored; the report says which
+			middleware.Fail(c, err)
+			return
+		default:
+			s.publishUpdate(c, "created", b)
+			res.ID, res.Status = b.ID, batchCreated
+		}
+		results[i] = res
+	}
+	writeBatch(c, results)
+}
+
+// deleteBooks marks the books listed in {"ids": [...]} deleted, like
+// DELETE /books/:id, and reports on each ID. An ID that isn't there, or is
+// deleted already, maybe earlier in the same list, is not_found.
+func (s *Server) deleteBooks(c *gin.Context) {
+	var req struct {
+		IDs []string `json:"ids" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.BindError(c, err)
+		return
+	}
+	if err := checkBatchSize(len(req.IDs)); err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+
+	ctx, span := tracing.Start(c.Request.Context(), "books.store.delete_batch")
+	defer span.End()
+	results := make([]BatchResult, len(req.IDs))
+	for i, id := range req.IDs {
+		results[i] = BatchResult{Index: i, ID: id, Status: batchNotFound}
+		b, err := s.repo.Update(ctx, id, func(b *Book) error {
+			if b.Deleted() {
+				return ErrBookNotFound
+			}
+			b.DeletedAt = s.now().UTC()
+			return nil
+		})
+		switch {
+		case errors.Is(err, ErrBookNotFound):
+			continue
+		case err != nil:
+			middleware.Fail(c, err)
+			return
+		}
+		s.publishUpdate(c, "deleted", b)
+		results[i].Status = batchDeleted
+	}
+	writeBatch(c, results)
+}
+
+func checkBatchSize(n int) error {
+	if n > maxBatch {
+		return apperror.Validation("too many items",
+			map[string]string{"items": "at most " + strconv.Itoa(maxBatch) + " per batch"})
+	}
+	return nil
+}
+
+// invalidBook turns a validation error into one sentence and the messages
+// by field, both localized.
+func invalidBook(c *gin.Context, err error) (string, map[string]string) {
+	fields, ok := validation.Translate(c, err)
+	if !ok {
+		return err.Error(), nil
+	}
+	msgs := make([]string, 0, len(fields))
+	for _, name := range slices.Sorted(maps.Keys(fields)) {
+		msgs = append(msgs, fields[name])
+	}
+	return strings.Join(msgs, "; "), fields
+}
+
+// writeBatch sends the report with a count of each status. It's 200 even
+// when every item failed: the batch itself was understood.
+func writeBatch(c *gin.Context, results []BatchResult) {
+	counts := map[string]int{}
+	for _, res := range results {
+		counts[res.Status]++
+	}
+	negotiate.Render(c, http.StatusOK, gin.H{"counts": counts, "items": results})
+}
@@ -0,0 +1,502 @@
+package books
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/apperror"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/cache"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/config"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/events"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/auth"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/featureflags"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/httpclient"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/idempotency"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/jobs"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/logging"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/mail"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/negotiate"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/pagination"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/query"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/scheduler"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/server"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/tracing"
+)
+
+type Book struct {
+	ID     string `json:"id" xml:"id"`
+	Title  string `json:"title" xml:"title" binding:"required,notblank"`
+	Author string `json:"author" xml:"author" binding:"required_without=AuthorID,omitempty,notblank"`
+	// AuthorID links the book to one of /authors. Without it the book is
+	// linked to the author named in Author, who is added if need be; with
+	// it Author is filled in from the link.
+	AuthorID string `json:"author_id,omitempty" xml:"author_id"`
+	Year     int    `json:"year" xml:"year" binding:"required,min=1000,max=2100"`
+	ISBN     string `json:"isbn,omitempty" xml:"isbn" binding:"omitempty,isbn"`
+	// Tags are free-form labels; Categories are slugs from /categories.
+	Tags       []string `json:"tags,omitempty" xml:"tags" binding:"max=20,dive,notblank,max=30"`
+	Categories []string `json:"categories,omitempty" xml:"categories" binding:"max=10"`
+	// OwnerID is the users-example ID of whoever added the book through an
+	// authenticated API (GraphQL); the REST routes leave it alone.
+	OwnerID string `json:"owner_id,omitempty" xml:"owner_id"`
+	// AverageRating (to two decimals, 0 without reviews) and ReviewCount sum
+	// up the book's reviews. The repository keeps them; what a client sends
+	// is ignored.
+	AverageRating float64 `json:"average_rating" xml:"average_rating"`
+	ReviewCount   int     `json:"review_count" xml:"review_count"`
+	// Available is false while the book is checked out. The repository
+	// keeps it too.
+	Available bool `json:"available" xml:"available"`
+	// DeletedAt is set by DELETE /books/:id, which only hides the book.
+	DeletedAt time.Time `json:"deleted_at,omitzero" xml:"deleted_at"`
+	// Version goes up by one with every change. PUT and PATCH must send the
+	// version they're based on, and fail with 409 if it's no longer current.
+	Version int `json:"version" xml:"version"`
+	// Tenant is the internal/tenant the book belongs to. The repository
+	// sets it, so what a client sends there is ignored too.
+	Tenant string `json:"tenant,omitempty" xml:"-"`
+}
+
+// Deleted reports whether the book was deleted. Deleted books keep their
+// ID, reviews and cover, and can be restored.
+func (b Book) Deleted() bool {
+	return !b.DeletedAt.IsZero()
+}
+
+// listBooks pages through the books matching ?author=, ?year_gte=,
+// ?year_lte=, ?category= and ?tag=, ordered by ?sort=title|year|author with
+// a leading "-" for descending. Without ?sort= books come in the order they
+// were added. Deleted books are left out unless ?include_deleted=true, which
+// takes books:manage. The page carries a weak ETag, since compression changes its
+// bytes, and If-None-Match gets a 304. Unsorted, pages go by ID, so
+// next_cursor stays right while books come and go; such a cursor can't be
+// used with ?sort=.
+func (s *Server) listBooks(c *gin.Context) {
+	q, p, err := parseBookQuery(c)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	ctx, span := tracing.Start(c.Request.Context(), "books.store.list")
+	all, err := q.run(ctx, s.repo)
+	span.End()
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	page, err := pageBooks(all, func(b Book) string { return b.ID }, p, q)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	if notModified(c, "W/"+etag(page)) {
+		return
+	}
+	pagination.Write(c, page)
+}
+
+// pageBooks cuts the page p out of all, the result of q.run. Unsorted, that
+// is in ID order and pages go by ID; sorted listings page by offset and
+// refuse a cursor.
+func pageBooks[T any](all []T, id func(T) string, p pagination.Params, q bookQuery) (pagination.Page[T], error) {
+	switch {
+	case q.sort == (query.Sort{}):
+		return pagination.NewKeysetPage(all, p, id), nil
+	case p.After != "":
+		return pagination.Page[T]{}, errCursorSort
+	}
+	return pagination.NewPage(all, p), nil
+}
+
+// getBook sends the book with its ETag, or 304 when If-None-Match has it.
+func (s *Server) getBook(c *gin.Context) {
+	id := c.Param("id")
+	ctx, span := tracing.Start(c.Request.Context(), "books.store.get", attribute.String("book.id", id))
+	defer span.End()
+	b, err := s.Get(ctx, id)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	if notModified(c, etag(b)) {
+		return
+	}
+	negotiate.Render(c, http.StatusOK, b)
+}
+
+func (s *Server) createBook(c *gin.Context) {
+	var input Book
+	if err := negotiate.Bind(c, &input); err != nil {
+		middleware.BindError(c, err)
+		return
+	}
+
+	ctx, span := tracing.Start(c.Request.Context(), "books.store.create")
+	defer span.End()
+	input.OwnerID, input.DeletedAt = "", time.Time{}
+	b, err := s.repo.Create(ctx, input)
+	if err != nil {
+		middleware.Fail(c, bookError(err))
+		return
+	}
+	s.publishUpdate(c, "created", b)
+	c.Header("ETag", etag(b))
+	negotiate.Render(c, http.StatusCreated, b)
+}
+
+// updateBook replaces the book, if it's still at the version sent and,
+// with If-Match, has the tag. The checks run inside the update, so nothing
+// can slip in between.
+func (s *Server) updateBook(c *gin.Context) {
+	id := c.Param("id")
+	var input Book
+	if err := negotiate.Bind(c, &input); err != nil {
+		middleware.BindError(c, err)
+		return
+	}
+	if input.Version < 1 {
+		middleware.Fail(c, errVersionRequired)
+		return
+	}
+
+	ctx, span := tracing.Start(c.Request.Context(), "books.store.update", attribute.String("book.id", id))
+	defer span.End()
+	b, err := s.repo.Update(ctx, id, func(b *Book) error {
+		if err := checkLive(c, *b); err != nil {
+			return err
+		}
+		if err := checkVersion(*b, input.Version); err != nil {
+			return err
+		}
+		input.OwnerID, input.DeletedAt = b.OwnerID, b.DeletedAt
+		*b = input
+		return nil
+	})
+	if err != nil {
+		middleware.Fail(c, bookError(err))
+		return
+	}
+	s.publishUpdate(c, "updated", b)
+	c.Header("ETag", etag(b))
+	negotiate.Render(c, http.StatusOK, b)
+}
+
+// bookPatch is the body of PATCH /books/:id. Fields left out, or null, keep
+// their value; the ones sent are checked like a full Book's. An ISBN can be
+// changed but not removed this way; PUT a book without one for that. A new
+// author name links the book to that author; author_id, if also sent,
+// wins. Version and If-Match work as for PUT.
+type bookPatch struct {
+	Version  int     `json:"version" xml:"version" binding:"required,min=1"`
+	Title    *string `json:"title" xml:"title" binding:"omitnil,notblank"`
+	Author   *string `json:"author" xml:"author" binding:"omitnil,notblank"`
+	AuthorID *string `json:"author_id" xml:"author_id" binding:"omitnil,notblank"`
+	Year     *int    `json:"year" xml:"year" binding:"omitnil,min=1000,max=2100"`
+	ISBN     *string `json:"isbn" xml:"isbn" binding:"omitnil,isbn"`
+	// replace the whole list; [] empties it
+	Tags       *[]string `json:"tags" xml:"tags" binding:"omitnil,max=20,dive,notblank,max=30"`
+	Categories *[]string `json:"categories" xml:"categories" binding:"omitnil,max=10"`
+}
+
+func (s *Server) patchBook(c *gin.Context) {
+	id := c.Param("id")
+	var req bookPatch
+	if err := negotiate.Bind(c, &req); err != nil {
+		middleware.BindError(c, err)
+		return
+	}
+
+	ctx, span := tracing.Start(c.Request.Context(), "books.store.patch", attribute.String("book.id", id))
+	defer span.End()
+	b, err := s.repo.Update(ctx, id, func(b *Book) error {
+		if err := checkLive(c, *b); err != nil {
+			return err
+		}
+		if err := checkVersion(*b, req.Version); err != nil {
+			return err
+		}
+		if req.Title != nil {
+			b.Title = *req.Title
+		}
+		if req.Author != nil {
+			b.Author, b.AuthorID = *req.Author, ""
+		}
+		if req.AuthorID != nil {
+			b.AuthorID = *req.AuthorID
+		}
+		if req.Year != nil {
+			b.Year = *req.Year
+		}
+		if req.ISBN != nil {
+			b.ISBN = *req.ISBN
+		}
+		if req.Tags != nil {
+			b.Tags = *req.Tags
+		}
+		if req.Categories != nil {
+			b.Categories = *req.Categories
+		}
+		return nil
+	})
+	if err != nil {
+		middleware.Fail(c, bookError(err))
+		return
+	}
+	s.publishUpdate(c, "updated", b)
+	c.Header("ETag", etag(b))
+	negotiate.Render(c, http.StatusOK, b)
+}
+
+// deleteBook marks the book deleted, honoring If-Match like updateBook. It
+// drops out of every read, but keeps its reviews and cover, so
+// POST /books/:id/restore can bring it back.
+func (s *Server) deleteBook(c *gin.Context) {
+	id := c.Param("id")
+	ctx, span := tracing.Start(c.Request.Context(), "books.store.delete", attribute.String("book.id", id))
+	defer span.End()
+	b, err := s.repo.Update(ctx, id, func(b *Book) error {
+		if err := checkLive(c, *b); err != nil {
+			return err
+		}
+		b.DeletedAt = s.now().UTC()
+		return nil
+	})
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	s.publishUpdate(c, "deleted", b)
+	c.Status(http.StatusNoContent)
+}
+
+// restoreBook undoes deleteBook.
+func (s *Server) restoreBook(c *gin.Context) {
+	id := c.Param("id")
+	ctx, span := tracing.Start(c.Request.Context(), "books.store.restore", attribute.String("book.id", id))
+	defer span.End()
+	b, err := s.repo.Update(ctx, id, func(b *Book) error {
+		if !b.Deleted() {
+			return errBookNotDeleted
+		}
+		b.DeletedAt = time.Time{}
+		return nil
+	})
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	s.publishUpdate(c, "restored", b)
+	c.Header("ETag", etag(b))
+	negotiate.Render(c, http.StatusOK, b)
+}
+
+// checkLive lets a write to b go ahead if b isn't deleted and If-Match, if
+// sent, names it.
+func checkLive(c *gin.Context, b Book) error {
+	if b.Deleted() {
+		return ErrBookNotFound
+	}
+	return checkIfMatch(c, b)
+}
+
+// checkVersion fails with 409 unless version is b's, and tells the client
+// the current one, so it can fetch the book again and retry.
+func checkVersion(b Book, version int) error {
+	if version == b.Version {
+		return nil
+	}
+	return apperror.Conflict("book has changed").WithDetails(map[string]int{"version": b.Version})
+}
+
+// whenDeleted runs mw, in place of the rest of the chain, for requests with
+// ?include_deleted=true: signedIn, so parseBookQuery can tell who's asking.
+// Anyone else goes on without signing in.
+func whenDeleted(mw gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if ok, _ := strconv.ParseBool(c.Query("include_deleted")); ok {
+			mw(c)
+			return
+		}
+		c.Next()
+	}
+}
+
+// bookError turns the repository's unknownCategoriesError and
+// errUnknownAuthor into 400s.
+func bookError(err error) error {
+	var unknown unknownCategoriesError
+	if errors.As(err, &unknown) {
+		return apperror.Validation("unknown category", unknownCategories(unknown))
+	}
+	if errors.Is(err, errUnknownAuthor) {
+		return apperror.Validation("unknown author", unknownAuthor())
+	}
+	return err
+}
+
+// invalidateList drops the cached GET /books answers after a write that
+// shows in them.
+func (s *Server) invalidateList() {
+	s.listCache.Invalidate("/books")
+}
+
+// publishUpdate announces a change. A failed publish is recorded on the
+// request but doesn't undo the write.
+func (s *Server) publishUpdate(c *gin.Context, action string, b Book) {
+	if err := s.announce(c.Request.Context(), action, b); err != nil {
+		c.Error(err)
+	}
+}
+
+// announce publishes a change on events.BookUpdated and, for a deletion, on
+// events.BookDeleted too.
+func (s *Server) announce(ctx context.Context, action string, b Book) error {
+	s.invalidateList()
+	err := events.Publish(ctx, events.Default, events.BookUpdated,
+		events.BookUpdatedEvent{BookID: b.ID, Action: action, Title: b.Title, OwnerID: b.OwnerID})
+	if err == nil && action == "deleted" {
+		err = events.Publish(ctx, events.Default, events.BookDeleted,
+			events.BookDeletedEvent{BookID: b.ID, Title: b.Title, OwnerID: b.OwnerID})
+	}
+	return err
+}
+
+// NewCatalog returns a Server set up from deps.Config: books kept where
+// books.store says, imports and overdue reminders run on a worker pool,
+// covers under storage.upload_dir, ISBNs looked up at books.lookup_url and
+// GET /books answers cached when response_cache is enabled. Its users are
+// auth.NewSessions', and it is timed by deps' clock.
+func NewCatalog(deps server.Deps) *Server {
+	cfg, hooks := deps.Config, deps.Hooks
+	repo := newRepository(cfg, hooks)
+	// in memory: the jobs.queue Redis list is shared with other job types;
+	// added after the store so it stops first
+	pool := jobs.NewPool(
+		jobs.WithWorkers(cfg.Jobs.Workers),
+		jobs.WithMaxAttempts(cfg.Jobs.MaxAttempts),
+		jobs.WithBackoff(cfg.Jobs.Backoff, cfg.Jobs.MaxBackoff),
+		jobs.WithLogger(logging.New(cfg.Log)),
+	)
+	pool.Handle(mail.JobType, mail.Handler(mail.NewSender(cfg.Mail)))
+	mailer := mail.New(cfg.Mail)
+	mailer.UseQueue(pool)
+	var listCache *cache.Responses
+	if cfg.Responses.Enabled {
+		listCache = cache.NewResponses("books_list", cfg.Responses.TTL, cfg.Responses.MaxEntries)
+	}
+	s := NewServer(Options{
+		Repository:    repo,
+		Pool:          pool,
+		MaxCoverBytes: cfg.Storage.MaxCoverBytes,
+		LookupURL:     cfg.Books.LookupURL,
+		LookupClient:  httpclient.New("openlibrary", httpclient.WithTimeout(cfg.Books.LookupTimeout), httpclient.WithFailureRate(0.5, 10)),
+		ListCache:     listCache,
+		Mailer:        mailer,
+		BaseURL:       cfg.Mail.BaseURL,
+		Auth:          auth.NewSessions(deps),
+		UploadDir:     cfg.Storage.UploadDir,
+		Now:           deps.Now,
+	})
+	// once s handles imports
+	pool.Start()
+	hooks.Add(pool.Shutdown)
+	return s
+}
+
+// Deps are what NewRouter builds the example from.
+type Deps struct {
+	server.Deps
+	// the catalog served; NewCatalog's when nil
+	Books *Server
+}
+
+// NewRouter builds the books CRUD example router, serving deps.Books.
+func NewRouter(deps Deps) *gin.Engine {
+	cfg := deps.Config
+	s := deps.Books
+	if s == nil {
+		s = NewCatalog(deps.Deps)
+	}
+	router := server.NewEngine(deps.Deps)
+	tasks := deps.Tasks()
+	tasks.Register("books_backup", time.Hour, s.backup(cfg.Storage.BackupDir), scheduler.WithJitter(5*time.Minute))
+	tasks.Register("overdue_reminders", 24*time.Hour, s.remindOverdue, scheduler.WithJitter(time.Hour))
+	tasks.Register("rating_aggregates", time.Hour, s.aggregateRatings)
+
+	deps.APIDocs().Add(docs...)
+
+	// reviews are written by the auth example's users
+	router.POST("/login", s.auth.Login)
+	s.Routes(router, cfg)
+	return router
+}
+
+// Routes mounts s's routes on router: /books, /loans, /categories, /authors
+// and /v2/books, with reviews and lending behind the tokens of s's auth
+// server, and the request limits cfg sets.
+func (s *Server) Routes(router gin.IRouter, cfg *config.Config) {
+	signedIn := middleware.Auth(s.auth.LookupToken)
+
+	idem := idempotency.Middleware(idempotency.WithTTL(cfg.Idempotency.TTL))
+	upload := middleware.BodyLimit(cfg.Storage.MaxUploadBytes)
+	// export and import read ?format= as the file's format
+	router.GET("/books/export", whenDeleted(signedIn), s.exportBooks)
+	router.POST("/books/import", upload, idem, s.importBooks)
+	router.GET("/books/import/:job", s.importStatus)
+	router.GET("/books/top-rated", s.listTopRated)
+	booksGroup := router.Group("/books", negotiate.Middleware())
+	{
+		booksGroup.GET("", whenDeleted(signedIn), s.listCache.Middleware(), s.listBooks)
+		booksGroup.GET("/:id", s.getBook)
+		booksGroup.POST("", idem, s.createBook)
+		booksGroup.POST("/batch", idem, s.createBooks)
+		booksGroup.POST("/lookup", s.lookupBook)
+		booksGroup.DELETE("/batch", s.deleteBooks)
+		booksGroup.PUT("/:id", s.updateBook)
+		booksGroup.PATCH("/:id", s.patchBook)
+		booksGroup.DELETE("/:id", s.deleteBook)
+		booksGroup.POST("/:id/restore", s.restoreBook)
+		booksGroup.GET("/:id/cover", s.getCover)
+		booksGroup.PUT("/:id/cover", upload, s.putCover)
+		booksGroup.GET("/:id/reviews", s.listReviews)
+		booksGroup.POST("/:id/reviews", signedIn, s.createReview)
+		booksGroup.DELETE("/:id/reviews/:review", signedIn, s.deleteReview)
+		booksGroup.POST("/:id/checkout", signedIn, s.checkoutBook)
+		booksGroup.POST("/:id/return", signedIn, s.returnBook)
+	}
+
+	router.GET("/loans", signedIn, s.listLoans)
+
+	categoriesGroup := router.Group("/categories")
+	{
+		categoriesGroup.GET("", s.listCategories)
+		categoriesGroup.GET("/:slug", s.getCategory)
+		categoriesGroup.POST("", s.createCategory)
+		categoriesGroup.PUT("/:slug", s.updateCategory)
+		categoriesGroup.DELETE("/:slug", s.deleteCategory)
+	}
+
+	authorsGroup := router.Group("/authors")
+	{
+		authorsGroup.GET("", s.listAuthors)
+		authorsGroup.GET("/:id", s.getAuthor)
+		authorsGroup.GET("/:id/books", s.listAuthorBooks)
+		authorsGroup.POST("", s.createAuthor)
+		authorsGroup.PUT("/:id", s.updateAuthor)
+		authorsGroup.DELETE("/:id", s.deleteAuthor)
+	}
+
+	// the v2 shape is rolled out gradually; callers without the flag get 404
+	v2 := router.Group("/v2/books", featureflags.Default.Require("books_v2"))
+	{
+		v2.GET("", s.listBooksV2)
+		v2.GET("/:id", s.getBookV2)
+	}
+}
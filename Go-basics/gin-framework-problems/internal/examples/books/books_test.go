@@ -0,0 +1,1382 @@
+package books
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/cache"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/config"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/events"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/jobs"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/mail"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/pagination"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/server"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/tenant"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/testutil"
+)
+
+// newRouter builds the example on a Server of its own, configured by cfg
+// (testutil.Config when nil), and returns that Server too.
+func newRouter(t testing.TB, cfg *config.Config) (*gin.Engine, *Server) {
+	t.Helper()
+	deps := testutil.Deps(t, cfg)
+	s := NewCatalog(deps)
+	return NewRouter(Deps{Deps: deps, Books: s}), s
+}
+
+// repositories returns one of each BookRepository, the SQLite one in a temp
+// file.
+func repositories(t *testing.T) map[string]BookRepository {
+	var hooks server.Hooks
+	db := server.OpenDB(testutil.Config(t).Database.Path, &hooks)
+	// its own database, so its books don't clash with the sqlite run's
+	cachedDB := server.OpenDB(testutil.Config(t).Database.Path, &hooks)
+	t.Cleanup(func() {
+		for _, stop := range hooks {
+			stop(context.Background())
+		}
+	})
+	return map[string]BookRepository{
+		"memory": NewMemoryRepository(),
+		"sqlite": NewSQLiteRepository(db),
+		// a stale copy would fail the reads after each write
+		"cached sqlite": cached(NewSQLiteRepository(cachedDB), cache.New("book_test", cache.NewMemoryStore()), time.Minute),
+	}
+}
+
+func TestRepository(t *testing.T) {
+	for name, r := range repositories(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := t.Context()
+			for _, cat := range []Category{{Slug: "classics", Name: "Classics"}, {Slug: "sf", Name: "Science fiction"}} {
+				if err := r.CreateCategory(ctx, cat); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := r.CreateCategory(ctx, Category{Slug: "sf", Name: "Sci-fi"}); !errors.Is(err, errCategoryExists) {
+				t.Errorf("CreateCategory with a taken slug: %v, want errCategoryExists", err)
+			}
+
+			emma, err := r.Create(ctx, Book{Title: "Emma", Author: "Jane Austen", Year: 1815, Categories: []string{"classics", "classics"}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			dune, err := r.Create(ctx, Book{Title: "Dune", Author: "Frank Herbert", Year: 1965, OwnerID: "7", Tags: []string{"desert"}, Categories: []string{"sf", "classics"}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var unknown unknownCategoriesError
+			if _, err := r.Create(ctx, Book{Title: "Solaris", Author: "Stanisław Lem", Year: 1961, Categories: []string{"sf", "polish"}}); !errors.As(err, &unknown) || !slices.Equal(unknown, []string{"polish"}) {
+				t.Errorf("Create in an unknown category: %v, want polish unknown", err)
+			}
+
+			got, err := r.Get(ctx, dune.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(dune, got); diff != "" {
+				t.Errorf("Get (-want +got):\n%s", diff)
+			}
+			if !slices.Equal(emma.Categories, []string{"classics"}) {
+				t.Errorf("categories = %q, want the repeat dropped", emma.Categories)
+			}
+
+			updated, err := r.Update(ctx, emma.ID, func(b *Book) error {
+				b.Year = 1816
+				b.Categories = nil
+				return nil
+			})
+			if err != nil || updated.Year != 1816 || len(updated.Categories) != 0 || emma.Version != 1 || updated.Version != 2 {
+				t.Errorf("Update = %+v, %v", updated, err)
+			}
+			if _, err := r.Update(ctx, "404", func(*Book) error { return nil }); !errors.Is(err, ErrBookNotFound) {
+				t.Errorf("Update of a missing book: %v, want ErrBookNotFound", err)
+			}
+
+			if err := r.DeleteCategory(ctx, "classics"); err != nil {
+				t.Fatal(err)
+			}
+			list, err := r.List(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var titles []string
+			for _, b := range list {
+				titles = append(titles, b.Title)
+			}
+			if !slices.Equal(titles, []string{"Emma", "Dune"}) || !slices.Equal(list[1].Categories, []string{"sf"}) {
+				t.Errorf("after deleting a category: %+v", list)
+			}
+			for _, tt := range []struct {
+				from, to int
+				want     []string
+			}{{1815, 1815, nil}, {1800, 1900, []string{"Emma"}}, {1900, 0, []string{"Dune"}}, {0, 0, []string{"Emma", "Dune"}}} {
+				byYears, err := r.ListByYears(ctx, tt.from, tt.to)
+				var titles []string
+				for _, b := range byYears {
+					titles = append(titles, b.Title)
+				}
+				if err != nil || !slices.Equal(titles, tt.want) {
+					t.Errorf("ListByYears(%d, %d) = %q, %v; want %q", tt.from, tt.to, titles, err, tt.want)
+				}
+			}
+			if byAuthor, err := r.ListByAuthor(ctx, dune.AuthorID); err != nil || len(byAuthor) != 1 || byAuthor[0].ID != dune.ID {
+				t.Errorf("ListByAuthor = %+v, %v; want only Dune", byAuthor, err)
+			}
+
+			var first Review
+			for i, rating := range []int{5, 4, 4} {
+				rv, err := r.CreateReview(ctx, Review{BookID: dune.ID, Rating: rating, Author: "alice", CreatedAt: time.Now().UTC()})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if i == 0 {
+					first = rv
+				}
+			}
+			if _, err := r.CreateReview(ctx, Review{BookID: "404", Rating: 1}); !errors.Is(err, ErrBookNotFound) {
+				t.Errorf("CreateReview of a missing book: %v, want ErrBookNotFound", err)
+			}
+			if err := r.DeleteReview(ctx, dune.ID, first.ID); err != nil {
+				t.Fatal(err)
+			}
+			if err := r.DeleteReview(ctx, emma.ID, first.ID); !errors.Is(err, errReviewNotFound) {
+				t.Errorf("DeleteReview through another book: %v, want errReviewNotFound", err)
+			}
+			rated, err := r.Update(ctx, dune.ID, func(b *Book) error {
+				*b = Book{Title: "Dune", Author: "Frank Herbert", Year: 1965, ReviewCount: 99}
+				return nil
+			})
+			if err != nil || rated.AverageRating != 4 || rated.ReviewCount != 2 {
+				t.Errorf("rating after Update = %v from %d reviews (%v), want 4 from 2", rated.AverageRating, rated.ReviewCount, err)
+			}
+			r.CreateReview(ctx, Review{BookID: dune.ID, Rating: 2, Author: "bob", CreatedAt: time.Now().UTC()})
+			if got, _ := r.Get(ctx, dune.ID); got.AverageRating != 3.33 || got.ReviewCount != 3 {
+				t.Errorf("Get rating = %v from %d reviews, want 3.33 from 3", got.AverageRating, got.ReviewCount)
+			}
+
+			deletedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+			r.Update(ctx, dune.ID, func(b *Book) error { b.DeletedAt = deletedAt; return nil })
+			if got, err := r.Get(ctx, dune.ID); err != nil || !got.DeletedAt.Equal(deletedAt) {
+				t.Errorf("Get of a deleted book = %v, %v; want it kept, deleted at %v", got.DeletedAt, err, deletedAt)
+			}
+
+			if err := r.Delete(ctx, dune.ID); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := r.ListReviews(ctx, dune.ID); !errors.Is(err, ErrBookNotFound) {
+				t.Errorf("ListReviews after Delete: %v, want ErrBookNotFound", err)
+			}
+			if _, err := r.Get(ctx, dune.ID); !errors.Is(err, ErrBookNotFound) {
+				t.Errorf("Get after Delete: %v, want ErrBookNotFound", err)
+			}
+			if err := r.Delete(ctx, dune.ID); !errors.Is(err, ErrBookNotFound) {
+				t.Errorf("Delete twice: %v, want ErrBookNotFound", err)
+			}
+		})
+	}
+}
+
+func TestAuthorRepository(t *testing.T) {
+	for name, r := range repositories(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := t.Context()
+			dune, err := r.Create(ctx, Book{Title: "Dune", Author: "Frank Herbert", Year: 1965})
+			if err != nil {
+				t.Fatal(err)
+			}
+			// the same name, ignoring case and spaces, is the same author
+			messiah, err := r.Create(ctx, Book{Title: "Dune Messiah", Author: " frank HERBERT", Year: 1969})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if dune.AuthorID == "" || messiah.AuthorID != dune.AuthorID || messiah.Author != "Frank Herbert" {
+				t.Errorf("linked %q to %q and %q to %q, want the same author", dune.Author, dune.AuthorID, messiah.Author, messiah.AuthorID)
+			}
+			if _, err := r.Create(ctx, Book{Title: "Emma", AuthorID: "404", Year: 1815}); !errors.Is(err, errUnknownAuthor) {
+				t.Errorf("Create by an unknown author: %v, want errUnknownAuthor", err)
+			}
+			if _, err := r.CreateAuthor(ctx, Author{Name: "FRANK HERBERT"}); !errors.Is(err, errAuthorExists) {
+				t.Errorf("CreateAuthor with a taken name: %v, want errAuthorExists", err)
+			}
+
+			austen, err := r.CreateAuthor(ctx, Author{Name: "Jane Austen", Bio: "English novelist"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			emma, err := r.Create(ctx, Book{Title: "Emma", AuthorID: austen.ID, Year: 1815})
+			if err != nil || emma.Author != "Jane Austen" {
+				t.Errorf("Create by author ID = %+v, %v; want Jane Austen's", emma, err)
+			}
+			if _, err := r.UpdateAuthor(ctx, Author{ID: austen.ID, Name: "frank herbert"}); !errors.Is(err, errAuthorExists) {
+				t.Errorf("UpdateAuthor to a taken name: %v, want errAuthorExists", err)
+			}
+			if _, err := r.UpdateAuthor(ctx, Author{ID: austen.ID, Name: "J. Austen"}); err != nil {
+				t.Fatal(err)
+			}
+			if got, _ := r.Get(ctx, emma.ID); got.Author != "J. Austen" {
+				t.Errorf("book author after rename = %q, want J. Austen", got.Author)
+			}
+
+			if err := r.DeleteAuthor(ctx, austen.ID); !errors.Is(err, errAuthorHasBooks) {
+				t.Errorf("DeleteAuthor with books: %v, want errAuthorHasBooks", err)
+			}
+			if _, err := r.Update(ctx, emma.ID, func(b *Book) error { b.AuthorID = dune.AuthorID; return nil }); err != nil {
+				t.Fatal(err)
+			}
+			if err := r.DeleteAuthor(ctx, austen.ID); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := r.GetAuthor(ctx, austen.ID); !errors.Is(err, errAuthorNotFound) {
+				t.Errorf("GetAuthor after DeleteAuthor: %v, want errAuthorNotFound", err)
+			}
+			authors, err := r.ListAuthors(ctx)
+			if err != nil || len(authors) != 1 || authors[0].Name != "Frank Herbert" {
+				t.Errorf("ListAuthors = %+v, %v; want only Frank Herbert", authors, err)
+			}
+		})
+	}
+}
+
+func TestCachedRepository(t *testing.T) {
+	var hooks server.Hooks
+	db := server.OpenDB(testutil.Config(t).Database.Path, &hooks)
+	t.Cleanup(func() {
+		for _, stop := range hooks {
+			stop(context.Background())
+		}
+	})
+	r := cached(NewSQLiteRepository(db), cache.New("book_test", cache.NewMemoryStore()), time.Minute)
+	ctx := t.Context()
+	if err := r.CreateCategory(ctx, Category{Slug: "sf", Name: "Science fiction"}); err != nil {
+		t.Fatal(err)
+	}
+	dune, err := r.Create(ctx, Book{Title: "Dune", Author: "Frank Herbert", Year: 1965, Categories: []string{"sf"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// each write is read back after a Get that cached the book as it was
+	var loan Loan
+	writes := []struct {
+		name  string
+		write func() error
+		check func(Book) bool
+	}{
+		{"review", func() error {
+			_, err := r.CreateReview(ctx, Review{BookID: dune.ID, Rating: 4, Author: "ann"})
+			return err
+		}, func(b Book) bool { return b.ReviewCount == 1 }},
+		{"checkout", func() (err error) {
+			loan, err = r.CreateLoan(ctx, Loan{BookID: dune.ID, Borrower: "ann", CheckedOutAt: time.Now(), DueAt: time.Now().Add(time.Hour)})
+			return err
+		}, func(b Book) bool { return !b.Available }},
+		{"return", func() error {
+			_, err := r.ReturnLoan(ctx, loan.ID, time.Now())
+			return err
+		}, func(b Book) bool { return b.Available }},
+		{"author rename", func() error {
+			_, err := r.UpdateAuthor(ctx, Author{ID: dune.AuthorID, Name: "F. Herbert"})
+			return err
+		}, func(b Book) bool { return b.Author == "F. Herbert" }},
+		{"category delete", func() error { return r.DeleteCategory(ctx, "sf") }, func(b Book) bool { return len(b.Categories) == 0 }},
+	}
+	for _, w := range writes {
+		if _, err := r.Get(ctx, dune.ID); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.write(); err != nil {
+			t.Fatalf("%s: %v", w.name, err)
+		}
+		if b, err := r.Get(ctx, dune.ID); err != nil || !w.check(b) {
+			t.Errorf("after %s: Get = %+v, %v", w.name, b, err)
+		}
+	}
+}
+
+func TestLoanRepository(t *testing.T) {
+	for name, r := range repositories(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := t.Context()
+			dune, err := r.Create(ctx, Book{Title: "Dune", Author: "Frank Herbert", Year: 1965})
+			if err != nil || !dune.Available {
+				t.Fatalf("Create = %+v, %v; want it available", dune, err)
+			}
+			now := time.Now().UTC().Truncate(time.Second)
+			loan := Loan{BookID: dune.ID, Borrower: "alice", CheckedOutAt: now, DueAt: now.AddDate(0, 0, 14)}
+			first, err := r.CreateLoan(ctx, loan)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := r.CreateLoan(ctx, loan); !errors.Is(err, errBookOnLoan) {
+				t.Errorf("CreateLoan of a book on loan: %v, want errBookOnLoan", err)
+			}
+			if _, err := r.CreateLoan(ctx, Loan{BookID: "404", Borrower: "alice", CheckedOutAt: now, DueAt: now}); !errors.Is(err, ErrBookNotFound) {
+				t.Errorf("CreateLoan of a missing book: %v, want ErrBookNotFound", err)
+			}
+			// an update keeps the book on loan
+			updated, err := r.Update(ctx, dune.ID, func(b *Book) error { b.Available = true; return nil })
+			if err != nil || updated.Available {
+				t.Errorf("Update = %+v, %v; want it still on loan", updated, err)
+			}
+			open, err := r.OpenLoan(ctx, dune.ID)
+			if err != nil || open.ID != first.ID || !open.DueAt.Equal(loan.DueAt) {
+				t.Errorf("OpenLoan = %+v, %v; want %+v", open, err, first)
+			}
+
+			returned, err := r.ReturnLoan(ctx, first.ID, now.Add(time.Hour))
+			if err != nil || !returned.ReturnedAt.Equal(now.Add(time.Hour)) {
+				t.Errorf("ReturnLoan = %+v, %v", returned, err)
+			}
+			if _, err := r.ReturnLoan(ctx, first.ID, now); !errors.Is(err, errBookNotOnLoan) {
+				t.Errorf("ReturnLoan twice: %v, want errBookNotOnLoan", err)
+			}
+			if _, err := r.OpenLoan(ctx, dune.ID); !errors.Is(err, errBookNotOnLoan) {
+				t.Errorf("OpenLoan after the return: %v, want errBookNotOnLoan", err)
+			}
+			if got, _ := r.Get(ctx, dune.ID); !got.Available {
+				t.Error("book still unavailable after the return")
+			}
+			if _, err := r.CreateLoan(ctx, Loan{BookID: dune.ID, Borrower: "bob", CheckedOutAt: now, DueAt: now}); err != nil {
+				t.Fatal(err)
+			}
+			loans, err := r.ListLoans(ctx)
+			if err != nil || len(loans) != 2 || !loans[0].Returned() || loans[1].Borrower != "bob" {
+				t.Errorf("ListLoans = %+v, %v", loans, err)
+			}
+		})
+	}
+}
+
+func TestCreateBook(t *testing.T) {
+	router, _ := newRouter(t, nil)
+	tests := []struct {
+		name   string
+		body   any
+		status int
+	}{
+		{"ok", Book{Title: "Dune", Author: "Frank Herbert", Year: 1965}, http.StatusCreated},
+		{"no title", Book{Author: "Frank Herbert", Year: 1965}, http.StatusBadRequest},
+		{"no author", Book{Title: "Dune", Year: 1965}, http.StatusBadRequest},
+		{"year too early", Book{Title: "Dune", Author: "Frank Herbert", Year: 999}, http.StatusBadRequest},
+		{"malformed", `{"title":`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := testutil.DoJSON(t, router, http.MethodPost, "/books", tt.body)
+			testutil.AssertStatus(t, w, tt.status)
+		})
+	}
+}
+
+func TestServers(t *testing.T) {
+	a, _ := newRouter(t, nil)
+	b, _ := newRouter(t, nil)
+
+	w := testutil.DoJSON(t, a, http.MethodPost, "/books", Book{Title: "Dune", Author: "Frank Herbert", Year: 1965})
+	testutil.AssertStatus(t, w, http.StatusCreated)
+	path := "/books/" + testutil.Decode[Book](t, w).ID
+	testutil.AssertStatus(t, testutil.Do(t, a, http.MethodGet, path, nil), http.StatusOK)
+	testutil.AssertStatus(t, testutil.Do(t, b, http.MethodGet, path, nil), http.StatusNotFound)
+}
+
+func TestTenancy(t *testing.T) {
+	cfg := testutil.Config(t)
+	cfg.Tenancy.Enabled = true
+	cfg.Tenancy.Tenants = map[string]string{"acme": "Acme"}
+	router, _ := newRouter(t, cfg)
+	acme := testutil.WithHeader(cfg.Tenancy.Header, "acme")
+
+	w := testutil.Do(t, router, http.MethodPost, "/books", strings.NewReader(`{"title":"Dune","author":"Frank Herbert","year":1965}`), acme)
+	testutil.AssertStatus(t, w, http.StatusCreated)
+	dune := testutil.Decode[Book](t, w)
+	if dune.Tenant != "acme" {
+		t.Errorf("tenant = %q, want acme", dune.Tenant)
+	}
+	testutil.AssertStatus(t, testutil.DoJSON(t, router, http.MethodPost, "/books", Book{Title: "Emma", Author: "Jane Austen", Year: 1815}), http.StatusCreated)
+
+	path := "/books/" + dune.ID
+	testutil.AssertStatus(t, testutil.Do(t, router, http.MethodGet, path, nil, acme), http.StatusOK)
+	testutil.AssertStatus(t, testutil.Do(t, router, http.MethodGet, path, nil), http.StatusNotFound)
+	testutil.AssertStatus(t, testutil.Do(t, router, http.MethodDelete, path, nil), http.StatusNotFound)
+	for _, tt := range []struct {
+		opts []testutil.RequestOption
+		want string
+	}{{nil, "Emma"}, {[]testutil.RequestOption{acme}, "Dune"}} {
+		w := testutil.Do(t, router, http.MethodGet, "/books", nil, tt.opts...)
+		if got := testutil.Decode[pagination.Page[Book]](t, w); got.Total != 1 || got.Items[0].Title != tt.want {
+			t.Errorf("GET /books = %+v, want only %s", got, tt.want)
+		}
+	}
+	testutil.AssertStatus(t, testutil.Do(t, router, http.MethodGet, "/books", nil, testutil.WithHeader(cfg.Tenancy.Header, "nobody")), http.StatusNotFound)
+
+	// the auth example's accounts are shared, but a token is for the tenant
+	// it was issued on
+	alice := testutil.WithToken(testutil.Login(t, router, "/login", "alice", "password1", acme))
+	testutil.AssertStatus(t, testutil.Do(t, router, http.MethodGet, "/loans", nil, alice, acme), http.StatusOK)
+	testutil.AssertStatus(t, testutil.Do(t, router, http.MethodGet, "/loans", nil, alice), http.StatusForbidden)
+	testutil.AssertStatus(t, testutil.DoJSON(t, router, http.MethodPost, path+"/reviews", gin.H{"rating": 5}, alice), http.StatusForbidden)
+}
+
+func TestBookLifecycle(t *testing.T) {
+	router, _ := newRouter(t, nil)
+	w := testutil.DoJSON(t, router, http.MethodPost, "/books", Book{Title: "Dune", Author: "Frank Herbert", Year: 1965})
+	testutil.AssertStatus(t, w, http.StatusCreated)
+	path := "/books/" + testutil.Decode[Book](t, w).ID
+
+	tests := []struct {
+		name   string
+		method string
+		body   any
+		status int
+	}{
+		{"get", http.MethodGet, nil, http.StatusOK},
+		{"put", http.MethodPut, Book{Title: "Dune Messiah", Author: "Frank Herbert", Year: 1969, Version: 1}, http.StatusOK},
+		{"put an invalid book", http.MethodPut, Book{Title: "Dune Messiah", Year: 1969, Version: 2}, http.StatusBadRequest},
+		{"put without a version", http.MethodPut, Book{Title: "Dune Messiah", Author: "Frank Herbert", Year: 1969}, http.StatusBadRequest},
+		{"put a stale version", http.MethodPut, Book{Title: "Dune", Author: "Frank Herbert", Year: 1965, Version: 1}, http.StatusConflict},
+		{"patch the year", http.MethodPatch, map[string]any{"year": 1970, "version": 2}, http.StatusOK},
+		{"patch a blank title", http.MethodPatch, map[string]any{"title": " ", "version": 3}, http.StatusBadRequest},
+		{"patch a bad year", http.MethodPatch, map[string]any{"year": 99, "version": 3}, http.StatusBadRequest},
+		{"patch without a version", http.MethodPatch, map[string]any{"year": 1971}, http.StatusBadRequest},
+		{"patch a stale version", http.MethodPatch, map[string]any{"year": 1971, "version": 2}, http.StatusConflict},
+		{"patch nothing", http.MethodPatch, map[string]any{"version": 3}, http.StatusOK},
+		{"delete", http.MethodDelete, nil, http.StatusNoContent},
+		{"get deleted", http.MethodGet, nil, http.StatusNotFound},
+		{"put deleted", http.MethodPut, Book{Title: "Dune", Author: "Frank Herbert", Year: 1965, Version: 5}, http.StatusNotFound},
+		{"patch deleted", http.MethodPatch, map[string]any{"year": 1970, "version": 5}, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		w := testutil.DoJSON(t, router, tt.method, path, tt.body)
+		if w.Code != tt.status {
+			t.Fatalf("%s: status = %d, want %d; body: %s", tt.name, w.Code, tt.status, w.Body)
+		}
+	}
+}
+
+// BenchmarkListPage serializes a page of 100 books out of 500.
+func BenchmarkListPage(b *testing.B) {
+	router, _ := newRouter(b, nil)
+	for i := range 500 {
+		w := testutil.DoJSON(b, router, http.MethodPost, "/books", Book{Title: "Book " + strconv.Itoa(i), Author: "Author " + strconv.Itoa(i%50), Year: 1900 + i%120})
+		testutil.AssertStatus(b, w, http.StatusCreated)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/books?limit=100&offset=200", nil)
+	b.ReportAllocs()
+	for b.Loop() {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			b.Fatalf("status %d", w.Code)
+		}
+	}
+}
+
+// BenchmarkMemoryRepository works on one book, or an author's ten, out of
+// 100k.
+func BenchmarkMemoryRepository(b *testing.B) {
+	ctx := b.Context()
+	r := NewMemoryRepository()
+	for i := range 100_000 {
+		_, err := r.Create(ctx, Book{Title: "Book " + strconv.Itoa(i), Author: "Author " + strconv.Itoa(i%10_000), Year: 1900 + i%120})
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.Run("get", func(b *testing.B) {
+		for b.Loop() {
+			if _, err := r.Get(ctx, "54321"); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("update", func(b *testing.B) {
+		for b.Loop() {
+			if _, err := r.Update(ctx, "54321", func(bk *Book) error { bk.Year = 1950; return nil }); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("list_by_author", func(b *testing.B) {
+		for b.Loop() {
+			if books, err := r.ListByAuthor(ctx, "4321"); err != nil || len(books) != 10 {
+				b.Fatalf("%d books, %v", len(books), err)
+			}
+		}
+	})
+	b.Run("list_by_years", func(b *testing.B) {
+		for b.Loop() {
+			if _, err := r.ListByYears(ctx, 1950, 1950); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestListBooksQuery(t *testing.T) {
+	// the store is package state, and earlier tests leave books in it
+	router, _ := newRouter(t, nil)
+	for _, b := range []Book{
+		{Title: "Emma", Author: "Jane Austen", Year: 1815},
+		{Title: "Dune", Author: "Frank Herbert", Year: 1965},
+		{Title: "Persuasion", Author: "Jane Austen", Year: 1817},
+		{Title: "Children of Dune", Author: "Frank Herbert", Year: 1976},
+	} {
+		testutil.AssertStatus(t, testutil.DoJSON(t, router, http.MethodPost, "/books", b), http.StatusCreated)
+	}
+
+	tests := []struct {
+		query  string
+		status int
+		titles []string
+	}{
+		{"", http.StatusOK, []string{"Emma", "Dune", "Persuasion", "Children of Dune"}},
+		{"?author=austen", http.StatusOK, []string{"Emma", "Persuasion"}},
+		{"?year_gte=1816&year_lte=1970", http.StatusOK, []string{"Dune", "Persuasion"}},
+		{"?sort=title", http.StatusOK, []string{"Children of Dune", "Dune", "Emma", "Persuasion"}},
+		{"?sort=-year&limit=2", http.StatusOK, []string{"Children of Dune", "Dune"}},
+		{"?sort=author", http.StatusOK, []string{"Dune", "Children of Dune", "Emma", "Persuasion"}},
+		{"?sort=isbn", http.StatusBadRequest, nil},
+		{"?year_gte=recent", http.StatusBadRequest, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			w := testutil.DoJSON(t, router, http.MethodGet, "/books"+tt.query, nil)
+			testutil.AssertStatus(t, w, tt.status)
+			if tt.status != http.StatusOK {
+				return
+			}
+			var titles []string
+			for _, b := range testutil.Decode[pagination.Page[Book]](t, w).Items {
+				titles = append(titles, b.Title)
+			}
+			if !slices.Equal(titles, tt.titles) {
+				t.Errorf("titles = %q, want %q", titles, tt.titles)
+			}
+		})
+	}
+}
+
+func TestListBooksCursor(t *testing.T) {
+	router, _ := newRouter(t, nil)
+	var ids []string
+	for _, title := range []string{"Emma", "Dune", "Persuasion", "Ulysses"} {
+		w := testutil.DoJSON(t, router, http.MethodPost, "/books", Book{Title: title, Author: "Someone", Year: 1900})
+		testutil.AssertStatus(t, w, http.StatusCreated)
+		ids = append(ids, testutil.Decode[Book](t, w).ID)
+	}
+	w := testutil.DoJSON(t, router, http.MethodGet, "/books?limit=2", nil)
+	testutil.AssertStatus(t, w, http.StatusOK)
+	first := testutil.Decode[pagination.Page[Book]](t, w)
+
+	// deleting a book the client has already seen doesn't shift the next page
+	testutil.AssertStatus(t, testutil.DoJSON(t, router, http.MethodDelete, "/books/"+ids[0], nil), http.StatusNoContent)
+	w = testutil.DoJSON(t, router, http.MethodGet, "/books?cursor="+first.NextCursor, nil)
+	testutil.AssertStatus(t, w, http.StatusOK)
+	var titles []string
+	for _, b := range testutil.Decode[pagination.Page[Book]](t, w).Items {
+		titles = append(titles, b.Title)
+	}
+	if want := []string{"Persuasion", "Ulysses"}; !slices.Equal(titles, want) {
+		t.Errorf("second page = %q, want %q", titles, want)
+	}
+
+	testutil.AssertStatus(t, testutil.DoJSON(t, router, http.MethodGet, "/books?sort=title&cursor="+first.NextCursor, nil), http.StatusBadRequest)
+	testutil.AssertStatus(t, testutil.DoJSON(t, router, http.MethodGet, "/books?cursor="+first.NextCursor+"x", nil), http.StatusBadRequest)
+}
+
+func TestPatchBook(t *testing.T) {
+	router, _ := newRouter(t, nil)
+	w := testutil.DoJSON(t, router, http.MethodPost, "/books", Book{Title: "Dune", Author: "Frank Herbert", Year: 1965, ISBN: "9780441013593"})
+	testutil.AssertStatus(t, w, http.StatusCreated)
+	path := "/books/" + testutil.Decode[Book](t, w).ID
+
+	w = testutil.DoJSON(t, router, http.MethodPatch, path, map[string]any{"title": "Dune Messiah", "isbn": nil, "version": 1})
+	testutil.AssertJSON(t, w, http.StatusOK, Book{Title: "Dune Messiah", Author: "Frank Herbert", Year: 1965, ISBN: "9780441013593", Version: 2, Available: true, Tenant: tenant.DefaultID}, "id", "author_id")
+}
+
+func TestStaleVersion(t *testing.T) {
+	router, _ := newRouter(t, nil)
+	w := testutil.DoJSON(t, router, http.MethodPost, "/books", Book{Title: "Dune", Author: "Frank Herbert", Year: 1965})
+	testutil.AssertStatus(t, w, http.StatusCreated)
+	path := "/books/" + testutil.Decode[Book](t, w).ID
+	testutil.AssertStatus(t, testutil.DoJSON(t, router, http.MethodPatch, path, map[string]any{"year": 1966, "version": 1}), http.StatusOK)
+
+	// a second writer still holding version 1 is told the current one
+	w = testutil.DoJSON(t, router, http.MethodPatch, path, map[string]any{"year": 1967, "version": 1})
+	testutil.AssertStatus(t, w, http.StatusConflict)
+	got := testutil.Decode[struct {
+		Details map[string]int `json:"details"`
+	}](t, w)
+	if got.Details["version"] != 2 {
+		t.Errorf("details = %v, want version 2", got.Details)
+	}
+	w = testutil.DoJSON(t, router, http.MethodGet, path, nil)
+	testutil.AssertJSON(t, w, http.StatusOK, Book{Title: "Dune", Author: "Frank Herbert", Year: 1966, Version: 2, Available: true, Tenant: tenant.DefaultID}, "id", "author_id")
+}
+
+func TestETag(t *testing.T) {
+	router, _ := newRouter(t, nil)
+	w := testutil.DoJSON(t, router, http.MethodPost, "/books", Book{Title: "Dune", Author: "Frank Herbert", Year: 1965})
+	testutil.AssertStatus(t, w, http.StatusCreated)
+	path := "/books/" + testutil.Decode[Book](t, w).ID
+	tag := w.Header().Get("ETag")
+	if tag == "" {
+		t.Fatal("POST /books sent no ETag")
+	}
+	list := testutil.DoJSON(t, router, http.MethodGet, "/books", nil).Header().Get("ETag")
+	if !strings.HasPrefix(list, "W/") {
+		t.Fatalf("list ETag = %q, want a weak tag", list)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		header string
+		value  string
+		want   int
+	}{
+		{"fresh book", http.MethodGet, path, "If-None-Match", tag, http.StatusNotModified},
+		{"weak match", http.MethodGet, path, "If-None-Match", "W/" + tag, http.StatusNotModified},
+		{"one of several", http.MethodGet, path, "If-None-Match", `"other", ` + tag, http.StatusNotModified},
+		{"stale book", http.MethodGet, path, "If-None-Match", `"other"`, http.StatusOK},
+		{"fresh list", http.MethodGet, "/books", "If-None-Match", list, http.StatusNotModified},
+		{"stale If-Match", http.MethodPut, path, "If-Match", `"other"`, http.StatusPreconditionFailed},
+		{"weak If-Match", http.MethodPatch, path, "If-Match", "W/" + tag, http.StatusPreconditionFailed},
+		{"stale delete", http.MethodDelete, path, "If-Match", `"other"`, http.StatusPreconditionFailed},
+		{"current If-Match", http.MethodPatch, path, "If-Match", tag, http.StatusOK},
+		// the PATCH changed the book
+		{"old tag", http.MethodPut, path, "If-Match", tag, http.StatusPreconditionFailed},
+		{"any version", http.MethodDelete, path, "If-Match", "*", http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body any
+			switch tt.method {
+			case http.MethodPut:
+				body = Book{Title: "Dune", Author: "Frank Herbert", Year: 1965, Version: 1}
+			case http.MethodPatch:
+				body = map[string]any{"year": 1966, "version": 1}
+			}
+			w := testutil.DoJSON(t, router, tt.method, tt.path, body, testutil.WithHeader(tt.header, tt.value))
+			testutil.AssertStatus(t, w, tt.want)
+			if tt.want == http.StatusNotModified && w.Body.Len() != 0 {
+				t.Errorf("304 has a body: %s", w.Body)
+			}
+		})
+	}
+}
+
+func TestReviews(t *testing.T) {
+	router, _ := newRouter(t, nil)
+	w := testutil.DoJSON(t, router, http.MethodPost, "/books", Book{Title: "Dune", Author: "Frank Herbert", Year: 1965})
+	testutil.AssertStatus(t, w, http.StatusCreated)
+	path := "/books/" + testutil.Decode[Book](t, w).ID
+	alice := testutil.WithToken(testutil.Login(t, router, "/login", "alice", "password1"))
+	bob := testutil.WithToken(testutil.Login(t, router, "/login", "bob", "adminpass"))
+
+	review := map[string]any{"rating": 4, "text": "Spice!"}
+	testutil.AssertStatus(t, testutil.DoJSON(t, router, http.MethodPost, path+"/reviews", review), http.StatusUnauthorized)
+	testutil.AssertStatus(t, testutil.DoJSON(t, router, http.MethodPost, path+"/reviews", map[string]any{"rating": 6}, alice), http.StatusBadRequest)
+	testutil.AssertStatus(t, testutil.DoJSON(t, router, http.MethodPost, "/books/404/reviews", review, alice), http.StatusNotFound)
+	w = testutil.DoJSON(t, router, http.MethodPost, path+"/reviews", review, alice)
+	testutil.AssertStatus(t, w, http.StatusCreated)
+	mine := testutil.Decode[Review](t, w)
+	if mine.Author != "alice" {
+		t.Errorf("author = %q, want alice", mine.Author)
+	}
+	w = testutil.DoJSON(t, router, http.MethodPost, path+"/reviews", map[string]any{"rating": 1}, bob)
+	testutil.AssertStatus(t, w, http.StatusCreated)
+	bobs := testutil.Decode[Review](t, w)
+
+	got := testutil.Decode[Book](t, testutil.DoJSON(t, router, http.MethodGet, path, nil))
+	if got.AverageRating != 2.5 || got.ReviewCount != 2 {
+		t.Errorf("rating = %v from %d reviews, want 2.5 from 2", got.AverageRating, got.ReviewCount)
+	}
+	w = testutil.DoJSON(t, router, http.MethodGet, path+"/reviews?limit=1", nil)
+	testutil.AssertStatus(t, w, http.StatusOK)
+	page := testutil.Decode[pagination.Page[Review]](t, w)
+	if page.Total != 2 || len(page.Items) != 1 || page.Items[0].ID != mine.ID {
+		t.Errorf("first page = %+v, want alice's review of 2", page)
+	}
+
+	// alice may delete only her own; bob, an admin, anyone's
+	testutil.AssertStatus(t, testutil.DoJSON(t, router, http.MethodDelete, path+"/reviews/"+bobs.ID, nil, alice), http.StatusForbidden)
+	testutil.AssertStatus(t, testutil.DoJSON(t, router, http.MethodDelete, path+"/reviews/"+bobs.ID, nil, bob), http.StatusNoContent)
+	testutil.AssertStatus(t, testutil.DoJSON(t, router, http.MethodDelete, path+"/reviews/"+mine.ID, nil, bob), http.StatusNoContent)
+	testutil.AssertStatus(t, testutil.DoJSON(t, router, http.MethodDelete, path+"/reviews/"+mine.ID, nil, alice), http.StatusNotFound)
+}
+
+func TestSoftDelete(t *testing.T) {
+	router, _ := newRouter(t, nil)
+	deleted := make(chan events.BookDeletedEvent, 1)
+	_, err := events.Subscribe(events.Default, events.BookDeleted, func(_ context.Context, ev events.BookDeletedEvent) {
+		deleted <- ev
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, b := range []Book{{Title: "Dune", Author: "Frank Herbert", Year: 1965}, {Title: "Emma", Author: "Jane Austen", Year: 1815}} {
+		w := testutil.DoJSON(t, router, http.MethodPost, "/books", b)
+		testutil.AssertStatus(t, w, http.StatusCreated)
+		ids = append(ids, testutil.Decode[Book](t, w).ID)
+	}
+	dune := "/books/" + ids[0]
+	alice := testutil.WithToken(testutil.Login(t, router, "/login", "alice", "password1"))
+	bob := testutil.WithToken(testutil.Login(t, router, "/login", "bob", "adminpass"))
+
+	testutil.AssertStatus(t, testutil.DoJSON(t, router, http.MethodPost, dune+"/restore", nil), http.StatusConflict)
+	testutil.AssertStatus(t, testutil.DoJSON(t, router, http.MethodDelete, dune, nil), http.StatusNoContent)
+	select {
+	case ev := <-deleted:
+		if ev.BookID != ids[0] || ev.Title != "Dune" {
+			t.Errorf("book.deleted = %+v, want Dune's", ev)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("no book.deleted for the deleted book")
+	}
+	for _, method := range []string{http.MethodGet, http.MethodPatch, http.MethodDelete} {
+		testutil.AssertStatus(t, testutil.DoJSON(t, router, method, dune, map[string]any{"year": 1966, "version": 2}), http.StatusNotFound)
+	}
+	testutil.AssertStatus(t, testutil.DoJSON(t, router, http.MethodGet, dune+"/reviews", nil), http.StatusNotFound)
+	w := testutil.DoJSON(t, router, http.MethodGet, "/books", nil)
+	if got := testutil.Decode[pagination.Page[Book]](t, w); got.Total != 1 || got.Items[0].Title != "Emma" {
+		t.Errorf("GET /books after delete = %+v, want only Emma", got)
+	}
+
+	// deleted books are listed for holders of books:manage only
+	testutil.AssertStatus(t, testutil.DoJSON(t, router, http.MethodGet, "/books?include_deleted=true", nil), http.StatusUnauthorized)
+	testutil.AssertStatus(t, testutil.DoJSON(t, router, http.MethodGet, "/books?include_deleted=true", nil, alice), http.StatusForbidden)
+	w = testutil.DoJSON(t, router, http.MethodGet, "/books?include_deleted=true", nil, bob)
+	testutil.AssertStatus(t, w, http.StatusOK)
+	if got := testutil.Decode[pagination.Page[Book]](t, w); got.Total != 2 || !got.Items[0].Deleted() {
+		t.Errorf("include_deleted = %+v, want both, Dune deleted", got)
+	}
+
+	w = testutil.DoJSON(t, router, http.MethodPost, dune+"/restore", nil)
+	testutil.AssertStatus(t, w, http.StatusOK)
+	if b := testutil.Decode[Book](t, w); b.Deleted() {
+		t.Errorf("restored book is still deleted: %+v", b)
+	}
+	testutil.AssertStatus(t, testutil.DoJSON(t, router, http.MethodGet, dune, nil), http.StatusOK)
+	testutil.AssertStatus(t, testutil.DoJSON(t, router, http.MethodPost, "/books/404/restore", nil), http.StatusNotFound)
+}
+
+func TestBatch(t *testing.T) {
+	router, _ := newRouter(t, nil)
+	type report struct {
+		Counts map[string]int `json:"counts"`
+		Items  []BatchResult  `json:"items"`
+	}
+
+	w := testutil.DoJSON(t, router, http.MethodPost, "/books/batch", []any{
+		Book{Title: "Dune", Author: "Frank Herbert", Year: 1965},
+		Book{Title: "Emma", Year: 1815},
+		"not a book",
+		Book{Title: "Persuasion", Author: "Jane Austen", Year: 1817},
+		Book{Title: "Emma", AuthorID: "404", Year: 1815},
+	})
+	testutil.AssertStatus(t, w, http.StatusOK)
+	created := testutil.Decode[report](t, w)
+	var statuses []string
+	for _, res := range created.Items {
+		statuses = append(statuses, res.Status)
+	}
+	if want := []string{batchCreated, batchError, batchError, batchCreated, batchError}; !slices.Equal(statuses, want) {
+		t.Fatalf("create statuses = %q, want %q", statuses, want)
+	}
+	if _, ok := created.Items[1].Details["author"]; !ok {
+		t.Errorf("missing author not reported: %+v", created.Items[1])
+	}
+	if _, ok := created.Items[4].Details["author_id"]; !ok {
+		t.Errorf("unknown author not reported: %+v", created.Items[4])
+	}
+
+	dune, persuasion := created.Items[0].ID, created.Items[3].ID
+	w = testutil.DoJSON(t, router, http.MethodDelete, "/books/batch", map[string]any{"ids": []string{dune, "nope", dune, persuasion}})
+	testutil.AssertStatus(t, w, http.StatusOK)
+	statuses = nil
+	for _, res := range testutil.Decode[report](t, w).Items {
+		statuses = append(statuses, res.Status)
+	}
+	if want := []string{batchDeleted, batchNotFound, batchNotFound, batchDeleted}; !slices.Equal(statuses, want) {
+		t.Errorf("delete statuses = %q, want %q", statuses, want)
+	}
+	testutil.AssertStatus(t, testutil.DoJSON(t, router, http.MethodGet, "/books/"+dune, nil), http.StatusNotFound)
+
+	w = testutil.DoJSON(t, router, http.MethodPost, "/books/batch", make([]Book, maxBatch+1))
+	testutil.AssertStatus(t, w, http.StatusBadRequest)
+}
+
+// upload sends content as the multipart file field, named name.
+func upload(t *testing.T, h http.Handler, method, path, field, name, content string) *httptest.ResponseRecorder {
+	t.Helper()
+	return testutil.DoMultipart(t, h, method, path, nil, []testutil.File{{Field: field, Name: name, Content: []byte(content)}})
+}
+
+// importReport waits for the import w started and returns its report.
+func importReport(t *testing.T, h http.Handler, w *httptest.ResponseRecorder) ImportReport {
+	t.Helper()
+	testutil.AssertStatus(t, w, http.StatusAccepted)
+	path := w.Header().Get("Location")
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		job := testutil.Decode[jobs.Job](t, testutil.Do(t, h, http.MethodGet, path, nil))
+		if job.Status == jobs.StatusSucceeded {
+			var report ImportReport
+			if err := json.Unmarshal(job.Result, &report); err != nil {
+				t.Fatalf("import result %s: %v", job.Result, err)
+			}
+			return report
+		}
+		if job.Status.Done() || time.Now().After(deadline) {
+			t.Fatalf("import %s is %s: %s", job.ID, job.Status, job.LastError)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestImportExport(t *testing.T) {
+	router, _ := newRouter(t, nil)
+	testutil.AssertStatus(t, testutil.DoJSON(t, router, http.MethodPost, "/books", Book{Title: "Dune", Author: "Frank Herbert", Year: 1965}), http.StatusCreated)
+	w := upload(t, router, http.MethodPost, "/books/import?map=Name:title,Writer:author", "file", "books.csv", "Name,Writer,year,tags\n"+
+		"dune,frank herbert,1965,\n"+ // stored already
+		"Emma,Jane Austen,1815,classic|romance\n"+
+		"EMMA,Jane Austen,1816,\n"+ // earlier in the file
+		"Persuasion,Jane Austen,soon,\n"+
+		"Solaris,Stanisław Lem\n")
+	got := importReport(t, router, w)
+	var statuses []string
+	for _, res := range got.Rows {
+		statuses = append(statuses, res.Status)
+	}
+	if want := []string{importSkipped, importCreated, importSkipped, importError, importError}; !slices.Equal(statuses, want) {
+		t.Fatalf("statuses = %q, want %q", statuses, want)
+	}
+	if got.Created != 1 || got.Skipped != 2 || got.Errored != 2 || got.Rows[3].Row != 5 {
+		t.Errorf("report = %+v", got)
+	}
+
+	for _, tt := range []struct{ name, path, file, content string }{
+		{"missing column", "/books/import", "books.csv", "title,author\nEmma,Jane Austen\n"},
+		{"unknown column", "/books/import", "books.csv", "title,author,year,pages\n"},
+		{"unknown format", "/books/import", "books.txt", "title,author,year\n"},
+		{"bad mapping", "/books/import?map=name:pages", "books.csv", "name,author,year\n"},
+		{"not an array", "/books/import", "books.json", `{"title":"Emma"}`},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			testutil.AssertStatus(t, upload(t, router, http.MethodPost, tt.path, "file", tt.file, tt.content), http.StatusBadRequest)
+		})
+	}
+
+	// each export imports again into an empty catalogue
+	for _, format := range []string{"csv", "json"} {
+		t.Run(format, func(t *testing.T) {
+			router, s := newRouter(t, nil)
+			for _, b := range []Book{{Title: "Dune", Author: "Frank Herbert", Year: 1965}, {Title: "Emma", Author: "Jane Austen", Year: 1815, Tags: []string{"classic", "romance"}}} {
+				testutil.AssertStatus(t, testutil.DoJSON(t, router, http.MethodPost, "/books", b), http.StatusCreated)
+			}
+			w := testutil.DoJSON(t, router, http.MethodGet, "/books/export?format="+format+"&sort=-year", nil)
+			testutil.AssertStatus(t, w, http.StatusOK)
+			exported := w.Body.String()
+
+			s.SetRepository(NewMemoryRepository())
+			w = upload(t, router, http.MethodPost, "/books/import", "file", "books."+format, exported)
+			if got := importReport(t, router, w); got.Created != 2 {
+				t.Fatalf("import of the export = %+v", got)
+			}
+			list, _ := s.List(t.Context())
+			if len(list) != 2 || list[0].Title != "Dune" || !slices.Equal(list[1].Tags, []string{"classic", "romance"}) {
+				t.Errorf("imported %+v", list)
+			}
+		})
+	}
+}
+
+func TestCover(t *testing.T) {
+	router, s := newRouter(t, nil)
+	w := testutil.DoJSON(t, router, http.MethodPost, "/books", Book{Title: "Dune", Author: "Frank Herbert", Year: 1965})
+	testutil.AssertStatus(t, w, http.StatusCreated)
+	path := "/books/" + testutil.Decode[Book](t, w).ID + "/cover"
+	png := "\x89PNG\r\n\x1a\n" + strings.Repeat("\x00", 32)
+	gif := "GIF89a" + strings.Repeat("\x00", 32)
+
+	testutil.AssertStatus(t, testutil.DoJSON(t, router, http.MethodGet, path, nil), http.StatusNotFound)
+	testutil.AssertStatus(t, upload(t, router, http.MethodPut, "/books/404/cover", "cover", "a.png", png), http.StatusNotFound)
+	testutil.AssertStatus(t, upload(t, router, http.MethodPut, path, "cover", "cover.png", "not an image"), http.StatusBadRequest)
+	testutil.AssertStatus(t, upload(t, router, http.MethodPut, path, "cover", "big.png", png+strings.Repeat("\x00", int(s.maxCoverBytes))), http.StatusRequestEntityTooLarge)
+	testutil.AssertStatus(t, upload(t, router, http.MethodPut, path, "cover", "cover.png", png), http.StatusOK)
+
+	// replacing it with another type leaves only the new one
+	testutil.AssertStatus(t, upload(t, router, http.MethodPut, path, "cover", "cover.png", gif), http.StatusOK)
+	w = testutil.DoJSON(t, router, http.MethodGet, path, nil)
+	testutil.AssertStatus(t, w, http.StatusOK)
+	if ct := w.Header().Get("Content-Type"); ct != "image/gif" || w.Body.String() != gif {
+		t.Errorf("cover is %s %q, want the GIF", ct, w.Body)
+	}
+	if w.Header().Get("Cache-Control") == "" {
+		t.Error("cover has no Cache-Control")
+	}
+	w = testutil.DoJSON(t, router, http.MethodGet, path, nil, testutil.WithHeader("If-None-Match", w.Header().Get("ETag")))
+	testutil.AssertStatus(t, w, http.StatusNotModified)
+
+	// a deleted book's cover is hidden with it, and back when it's restored
+	book := strings.TrimSuffix(path, "/cover")
+	testutil.AssertStatus(t, testutil.DoJSON(t, router, http.MethodDelete, book, nil), http.StatusNoContent)
+	testutil.AssertStatus(t, testutil.DoJSON(t, router, http.MethodGet, path, nil), http.StatusNotFound)
+	testutil.AssertStatus(t, testutil.DoJSON(t, router, http.MethodPost, book+"/restore", nil), http.StatusOK)
+	testutil.AssertStatus(t, testutil.DoJSON(t, router, http.MethodGet, path, nil), http.StatusOK)
+}
+
+func TestCategories(t *testing.T) {
+	router, _ := newRouter(t, nil)
+
+	for _, cat := range []Category{{Slug: "go", Name: "Go"}, {Slug: "science-fiction", Name: "Science fiction"}} {
+		testutil.AssertStatus(t, testutil.DoJSON(t, router, http.MethodPost, "/categories", cat), http.StatusCreated)
+	}
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   any
+		status int
+	}{
+		{"duplicate slug", http.MethodPost, "/categories", Category{Slug: "go", Name: "Golang"}, http.StatusConflict},
+		{"bad slug", http.MethodPost, "/categories", Category{Slug: "Sci Fi", Name: "Sci-fi"}, http.StatusBadRequest},
+		{"rename", http.MethodPut, "/categories/go", Category{Name: "The Go language"}, http.StatusOK},
+		{"rename missing", http.MethodPut, "/categories/rust", Category{Name: "Rust"}, http.StatusNotFound},
+		{"book in an unknown category", http.MethodPost, "/books", Book{Title: "Dune", Author: "Frank Herbert", Year: 1965, Categories: []string{"fantasy"}}, http.StatusBadRequest},
+		{"blank tag", http.MethodPost, "/books", Book{Title: "Dune", Author: "Frank Herbert", Year: 1965, Tags: []string{" "}}, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		w := testutil.DoJSON(t, router, tt.method, tt.path, tt.body)
+		if w.Code != tt.status {
+			t.Fatalf("%s: status = %d, want %d; body: %s", tt.name, w.Code, tt.status, w.Body)
+		}
+	}
+
+	w := testutil.DoJSON(t, router, http.MethodPost, "/books", Book{Title: "Dune", Author: "Frank Herbert", Year: 1965, Categories: []string{"science-fiction", "science-fiction"}, Tags: []string{"classic"}})
+	testutil.AssertStatus(t, w, http.StatusCreated)
+	dune := testutil.Decode[Book](t, w)
+	if !slices.Equal(dune.Categories, []string{"science-fiction"}) {
+		t.Errorf("categories = %q, want the repeat dropped", dune.Categories)
+	}
+	w = testutil.DoJSON(t, router, http.MethodPost, "/books", Book{Title: "The Go Programming Language", Author: "Donovan & Kernighan", Year: 2015, Categories: []string{"go"}})
+	testutil.AssertStatus(t, w, http.StatusCreated)
+
+	for query, want := range map[string]int{"?category=go": 1, "?category=science-fiction&tag=classic": 1, "?tag=classic&category=go": 0} {
+		w = testutil.DoJSON(t, router, http.MethodGet, "/books"+query, nil)
+		testutil.AssertStatus(t, w, http.StatusOK)
+		if got := testutil.Decode[pagination.Page[Book]](t, w).Total; got != want {
+			t.Errorf("%s: %d books, want %d", query, got, want)
+		}
+	}
+
+	testutil.AssertStatus(t, testutil.DoJSON(t, router, http.MethodDelete, "/categories/science-fiction", nil), http.StatusNoContent)
+	w = testutil.DoJSON(t, router, http.MethodGet, "/books/"+dune.ID, nil)
+	testutil.AssertStatus(t, w, http.StatusOK)
+	if cats := testutil.Decode[Book](t, w).Categories; len(cats) != 0 {
+		t.Errorf("deleted category still on the book: %q", cats)
+	}
+}
+
+func TestAuthors(t *testing.T) {
+	router, _ := newRouter(t, nil)
+	w := testutil.DoJSON(t, router, http.MethodPost, "/authors", Author{Name: "Ursula K. Le Guin"})
+	testutil.AssertStatus(t, w, http.StatusCreated)
+	leGuin := testutil.Decode[Author](t, w)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   any
+		status int
+	}{
+		{"taken name", http.MethodPost, "/authors", Author{Name: "ursula k. le guin"}, http.StatusConflict},
+		{"blank name", http.MethodPost, "/authors", Author{Name: " "}, http.StatusBadRequest},
+		{"missing", http.MethodGet, "/authors/404", nil, http.StatusNotFound},
+		{"book by ID", http.MethodPost, "/books", Book{Title: "The Dispossessed", AuthorID: leGuin.ID, Year: 1974}, http.StatusCreated},
+		{"book by name", http.MethodPost, "/books", Book{Title: "The Lathe of Heaven", Author: "Ursula K. Le Guin", Year: 1971}, http.StatusCreated},
+		{"book by a new name", http.MethodPost, "/books", Book{Title: "Dune", Author: "Frank Herbert", Year: 1965}, http.StatusCreated},
+		{"book by an unknown ID", http.MethodPost, "/books", Book{Title: "Emma", AuthorID: "404", Year: 1815}, http.StatusBadRequest},
+		{"book without an author", http.MethodPost, "/books", Book{Title: "Emma", Year: 1815}, http.StatusBadRequest},
+		{"rename", http.MethodPut, "/authors/" + leGuin.ID, Author{Name: "Ursula Le Guin"}, http.StatusOK},
+		{"delete with books", http.MethodDelete, "/authors/" + leGuin.ID, nil, http.StatusConflict},
+	}
+	for _, tt := range tests {
+		w := testutil.DoJSON(t, router, tt.method, tt.path, tt.body)
+		if w.Code != tt.status {
+			t.Fatalf("%s: status = %d, want %d; body: %s", tt.name, w.Code, tt.status, w.Body)
+		}
+	}
+
+	w = testutil.DoJSON(t, router, http.MethodGet, "/authors/"+leGuin.ID+"/books", nil)
+	testutil.AssertStatus(t, w, http.StatusOK)
+	var titles []string
+	for _, b := range testutil.Decode[pagination.Page[Book]](t, w).Items {
+		titles = append(titles, b.Title+" by "+b.Author)
+	}
+	if want := []string{"The Dispossessed by Ursula Le Guin", "The Lathe of Heaven by Ursula Le Guin"}; !slices.Equal(titles, want) {
+		t.Errorf("author's books = %q, want %q", titles, want)
+	}
+
+	// a new name moves the book to that author
+	w = testutil.DoJSON(t, router, http.MethodGet, "/authors", nil)
+	if got := testutil.Decode[pagination.Page[Author]](t, w).Total; got != 2 {
+		t.Errorf("%d authors, want 2", got)
+	}
+	w = testutil.DoJSON(t, router, http.MethodPatch, "/books/1", map[string]any{"author": "Frank Herbert", "version": 1})
+	testutil.AssertStatus(t, w, http.StatusOK)
+	if b := testutil.Decode[Book](t, w); b.AuthorID == leGuin.ID {
+		t.Errorf("PATCH of the author name kept author_id %s", b.AuthorID)
+	}
+	testutil.AssertStatus(t, testutil.DoJSON(t, router, http.MethodDelete, "/books/2", nil), http.StatusNoContent)
+	// the deleted book can be restored, so it still counts
+	testutil.AssertStatus(t, testutil.DoJSON(t, router, http.MethodDelete, "/authors/"+leGuin.ID, nil), http.StatusConflict)
+	w = testutil.DoJSON(t, router, http.MethodGet, "/authors/"+leGuin.ID+"/books", nil)
+	if got := testutil.Decode[pagination.Page[Book]](t, w); got.Total != 0 || got.Items == nil {
+		t.Errorf("author's books after delete = %+v, want an empty page", got)
+	}
+}
+
+func TestListCache(t *testing.T) {
+	cfg := testutil.Config(t)
+	cfg.Responses.Enabled = true
+	router, _ := newRouter(t, cfg)
+	w := testutil.DoJSON(t, router, http.MethodPost, "/books", Book{Title: "Dune", Author: "Frank Herbert", Year: 1965})
+	testutil.AssertStatus(t, w, http.StatusCreated)
+	dune := testutil.Decode[Book](t, w)
+
+	// list asks for GET /books and checks where the answer came from
+	list := func(step, cached string) []Book {
+		t.Helper()
+		w := testutil.Do(t, router, http.MethodGet, "/books", nil)
+		testutil.AssertStatus(t, w, http.StatusOK)
+		if got := w.Header().Get("X-Cache"); got != cached {
+			t.Errorf("%s: X-Cache = %q, want %q", step, got, cached)
+		}
+		return testutil.Decode[pagination.Page[Book]](t, w).Items
+	}
+	list("first", "MISS")
+	list("again", "HIT")
+
+	writes := []struct {
+		name, method, path string
+		body               any
+		status             int
+	}{
+		{"create", http.MethodPost, "/books", Book{Title: "Emma", Author: "Jane Austen", Year: 1815}, http.StatusCreated},
+		{"update", http.MethodPatch, "/books/" + dune.ID, map[string]any{"year": 1966, "version": dune.Version}, http.StatusOK},
+		{"rename the author", http.MethodPut, "/authors/" + dune.AuthorID, Author{Name: "F. Herbert"}, http.StatusOK},
+		{"delete", http.MethodDelete, "/books/" + dune.ID, nil, http.StatusNoContent},
+	}
+	for _, tt := range writes {
+		testutil.AssertStatus(t, testutil.DoJSON(t, router, tt.method, tt.path, tt.body), tt.status)
+		list("after "+tt.name, "MISS")
+		list("again after "+tt.name, "HIT")
+	}
+	if got := list("at the end", "HIT"); len(got) != 1 || got[0].Title != "Emma" {
+		t.Errorf("books = %+v, want only Emma", got)
+	}
+
+	// a router built without the cache doesn't keep the last one's
+	router, _ = newRouter(t, nil)
+	list("without the cache", "")
+}
+
+func TestLoans(t *testing.T) {
+	router, s := newRouter(t, nil)
+	var paths []string
+	for _, b := range []Book{{Title: "Dune", Author: "Frank Herbert", Year: 1965}, {Title: "Emma", Author: "Jane Austen", Year: 1815}} {
+		w := testutil.DoJSON(t, router, http.MethodPost, "/books", b)
+		testutil.AssertStatus(t, w, http.StatusCreated)
+		paths = append(paths, "/books/"+testutil.Decode[Book](t, w).ID)
+	}
+	dune, emma := paths[0], paths[1]
+	alice := testutil.WithToken(testutil.Login(t, router, "/login", "alice", "password1"))
+	bob := testutil.WithToken(testutil.Login(t, router, "/login", "bob", "adminpass"))
+
+	testutil.AssertStatus(t, testutil.DoJSON(t, router, http.MethodPost, dune+"/checkout", nil), http.StatusUnauthorized)
+	w := testutil.DoJSON(t, router, http.MethodPost, dune+"/checkout", map[string]any{"days": 7}, alice)
+	testutil.AssertStatus(t, w, http.StatusCreated)
+	if l := testutil.Decode[Loan](t, w); l.Borrower != "alice" || l.DueAt.Sub(l.CheckedOutAt) != 7*24*time.Hour || l.Overdue {
+		t.Errorf("checkout = %+v, want alice's for 7 days", l)
+	}
+	if testutil.Decode[Book](t, testutil.DoJSON(t, router, http.MethodGet, dune, nil)).Available {
+		t.Error("checked-out book is available")
+	}
+
+	tests := []struct {
+		name   string
+		path   string
+		body   any
+		opt    testutil.RequestOption
+		status int
+	}{
+		{"double loan", dune + "/checkout", nil, bob, http.StatusConflict},
+		{"too long", emma + "/checkout", map[string]any{"days": 61}, bob, http.StatusBadRequest},
+		{"missing book", "/books/404/checkout", nil, bob, http.StatusNotFound},
+		{"return a book not out", emma + "/return", nil, alice, http.StatusConflict},
+		{"own return", dune + "/return", nil, alice, http.StatusOK},
+		{"return twice", dune + "/return", nil, alice, http.StatusConflict},
+		{"checkout after the return", dune + "/checkout", nil, bob, http.StatusCreated},
+		{"return someone else's", dune + "/return", nil, alice, http.StatusForbidden},
+		{"own return again", dune + "/return", nil, bob, http.StatusOK},
+		{"checkout again", dune + "/checkout", nil, alice, http.StatusCreated},
+		// loans:manage returns anyone's
+		{"librarian return", dune + "/return", nil, bob, http.StatusOK},
+	}
+	for _, tt := range tests {
+		w := testutil.DoJSON(t, router, http.MethodPost, tt.path, tt.body, tt.opt)
+		if w.Code != tt.status {
+			t.Fatalf("%s: status = %d, want %d; body: %s", tt.name, w.Code, tt.status, w.Body)
+		}
+	}
+
+	now := time.Now().UTC()
+	if _, err := s.repo.CreateLoan(t.Context(), Loan{BookID: strings.TrimPrefix(emma, "/books/"), Borrower: "alice", CheckedOutAt: now.AddDate(0, 0, -20), DueAt: now.AddDate(0, 0, -6)}); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		query string
+		opt   testutil.RequestOption
+		want  []string
+	}{
+		{"", alice, []string{"alice", "alice", "alice"}},
+		{"", bob, []string{"alice", "bob", "alice", "alice"}},
+		{"?overdue=true", bob, []string{"alice"}},
+		{"?overdue=false", bob, []string{"alice", "bob", "alice"}},
+		{"?borrower=bob", bob, []string{"bob"}},
+	} {
+		w := testutil.DoJSON(t, router, http.MethodGet, "/loans"+tt.query, nil, tt.opt)
+		testutil.AssertStatus(t, w, http.StatusOK)
+		var borrowers []string
+		for _, l := range testutil.Decode[pagination.Page[Loan]](t, w).Items {
+			borrowers = append(borrowers, l.Borrower)
+		}
+		if !slices.Equal(borrowers, tt.want) {
+			t.Errorf("GET /loans%s: borrowers %q, want %q", tt.query, borrowers, tt.want)
+		}
+	}
+	testutil.AssertStatus(t, testutil.DoJSON(t, router, http.MethodGet, "/loans?borrower=bob", nil, alice), http.StatusForbidden)
+	testutil.AssertStatus(t, testutil.DoJSON(t, router, http.MethodGet, "/loans?overdue=soon", nil, bob), http.StatusBadRequest)
+}
+
+// TestLoanClock checks that loans fall due by the deps' clock.
+func TestLoanClock(t *testing.T) {
+	deps := testutil.Deps(t, nil)
+	clock := testutil.NewClock(time.Now())
+	deps.Clock = clock
+	router := NewRouter(Deps{Deps: deps})
+	w := testutil.DoJSON(t, router, http.MethodPost, "/books", Book{Title: "Dune", Author: "Frank Herbert", Year: 1965})
+	testutil.AssertStatus(t, w, http.StatusCreated)
+	path := "/books/" + testutil.Decode[Book](t, w).ID
+	alice := testutil.WithToken(testutil.Login(t, router, "/login", "alice", "password1"))
+	w = testutil.DoJSON(t, router, http.MethodPost, path+"/checkout", map[string]any{"days": 7}, alice)
+	testutil.AssertStatus(t, w, http.StatusCreated)
+	if l := testutil.Decode[Loan](t, w); !l.CheckedOutAt.Equal(clock.Now().UTC()) {
+		t.Errorf("checked out at %v, want %v", l.CheckedOutAt, clock.Now().UTC())
+	}
+
+	clock.Advance(8 * 24 * time.Hour)
+	// the session expired with the clock too
+	alice = testutil.WithToken(testutil.Login(t, router, "/login", "alice", "password1"))
+	w = testutil.DoJSON(t, router, http.MethodGet, "/loans?overdue=true", nil, alice)
+	testutil.AssertStatus(t, w, http.StatusOK)
+	if got := testutil.Decode[pagination.Page[Loan]](t, w).Items; len(got) != 1 || !got[0].Overdue {
+		t.Errorf("overdue loans = %+v, want alice's", got)
+	}
+}
+
+// sentMail records the messages sent through it.
+type sentMail []mail.Message
+
+func (s *sentMail) Send(_ context.Context, msg mail.Message) error {
+	*s = append(*s, msg)
+	return nil
+}
+
+func TestRemindOverdue(t *testing.T) {
+	var sent sentMail
+	s := NewServer(Options{Mailer: mail.NewMailer("hub@localhost", &sent), BaseURL: "http://hub"})
+	ctx := t.Context()
+	now := time.Now()
+	var ids []string
+	for _, loan := range []Loan{
+		{Borrower: "alice", DueAt: now.Add(-48 * time.Hour)},
+		{Borrower: "alice", DueAt: now.Add(time.Hour)},
+		// no such account, so no address
+		{Borrower: "zed", DueAt: now.Add(-time.Hour)},
+	} {
+		b, err := s.repo.Create(ctx, Book{Title: "Dune", Author: "Frank Herbert", Year: 1965})
+		if err != nil {
+			t.Fatal(err)
+		}
+		loan.BookID, loan.CheckedOutAt = b.ID, now.Add(-72*time.Hour)
+		if _, err := s.repo.CreateLoan(ctx, loan); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, b.ID)
+	}
+
+	if err := s.remindOverdue(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != 1 {
+		t.Fatalf("sent %d reminders, want 1", len(sent))
+	}
+	if want := []string{"alice@example.com"}; !slices.Equal(sent[0].To, want) {
+		t.Errorf("To = %q, want %q", sent[0].To, want)
+	}
+	if link := "http://hub/books/" + ids[0]; !strings.Contains(sent[0].Text, link) {
+		t.Errorf("text doesn't link to %s:\n%s", link, sent[0].Text)
+	}
+}
+
+func TestNegotiation(t *testing.T) {
+	router, _ := newRouter(t, nil)
+	w := testutil.Do(t, router, http.MethodPost, "/books",
+		strings.NewReader(`<book><title>Dune</title><author>Frank Herbert</author><year>1965</year></book>`),
+		testutil.WithHeader("Content-Type", "application/xml"), testutil.WithHeader("Accept", "application/xml"))
+	testutil.AssertStatus(t, w, http.StatusCreated)
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/xml") {
+		t.Fatalf("Content-Type = %q, want application/xml", ct)
+	}
+	var created Book
+	if err := xml.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatalf("decode XML: %v; body: %s", err, w.Body)
+	}
+	if created.Title != "Dune" || created.Year != 1965 || created.ID == "" {
+		t.Errorf("created = %+v", created)
+	}
+	path := "/books/" + created.ID
+
+	w = testutil.Do(t, router, http.MethodGet, path+"?format=yaml", nil)
+	testutil.AssertStatus(t, w, http.StatusOK)
+	if !strings.Contains(w.Body.String(), "title: Dune\n") {
+		t.Errorf("YAML body = %s", w.Body)
+	}
+	w = testutil.Do(t, router, http.MethodGet, "/books", nil, testutil.WithHeader("Accept", "application/xml"))
+	testutil.AssertStatus(t, w, http.StatusOK)
+	if !strings.Contains(w.Body.String(), "<page><items><id>"+created.ID+"</id>") {
+		t.Errorf("XML page = %s", w.Body)
+	}
+	testutil.AssertStatus(t, testutil.Do(t, router, http.MethodGet, path+"?format=csv", nil), http.StatusBadRequest)
+
+	// errors stay JSON
+	w = testutil.Do(t, router, http.MethodGet, "/books/404", nil, testutil.WithHeader("Accept", "application/xml"))
+	testutil.AssertStatus(t, w, http.StatusNotFound)
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+		t.Errorf("error Content-Type = %q, want application/json", ct)
+	}
+}
+
+func TestISBN(t *testing.T) {
+	for name, r := range repositories(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := t.Context()
+			dune, err := r.Create(ctx, Book{Title: "Dune", Author: "Frank Herbert", Year: 1965, ISBN: "978-0-441-01359-3"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if dune.ISBN != "9780441013593" {
+				t.Errorf("stored ISBN %q, want it without hyphens", dune.ISBN)
+			}
+			if _, err := r.Create(ctx, Book{Title: "Dune (again)", Author: "Frank Herbert", Year: 1965, ISBN: "9780441013593"}); !errors.Is(err, errISBNExists) {
+				t.Errorf("Create with a taken ISBN: %v, want errISBNExists", err)
+			}
+			emma, err := r.Create(ctx, Book{Title: "Emma", Author: "Jane Austen", Year: 1815, ISBN: "0-8044-2957-x"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if emma.ISBN != "080442957X" {
+				t.Errorf("stored ISBN %q, want 080442957X", emma.ISBN)
+			}
+			if _, err := r.Update(ctx, emma.ID, func(b *Book) error { b.ISBN = "978 0441013593"; return nil }); !errors.Is(err, errISBNExists) {
+				t.Errorf("Update to a taken ISBN: %v, want errISBNExists", err)
+			}
+			// a book keeps its own, and books without one don't clash
+			if _, err := r.Update(ctx, dune.ID, func(b *Book) error { b.Year = 1966; return nil }); err != nil {
+				t.Errorf("Update keeping the ISBN: %v", err)
+			}
+			for _, title := range []string{"Solaris", "Ubik"} {
+				if _, err := r.Create(ctx, Book{Title: title, Author: "Someone", Year: 1970}); err != nil {
+					t.Errorf("Create %s without an ISBN: %v", title, err)
+				}
+			}
+		})
+	}
+}
+
+func TestLookup(t *testing.T) {
+	openLibrary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("bibkeys") {
+		case "ISBN:9780441013593":
+			w.Write([]byte(`{"ISBN:9780441013593":{"title":"Dune","authors":[{"name":"Frank Herbert"},{"name":"Someone Else"}],"publish_date":"August 2, 2005"}}`))
+		case "ISBN:080442957X":
+			time.Sleep(200 * time.Millisecond)
+			w.Write([]byte(`{}`))
+		default:
+			w.Write([]byte(`{}`))
+		}
+	}))
+	defer openLibrary.Close()
+	cfg := testutil.Config(t)
+	cfg.Books.LookupURL, cfg.Books.LookupTimeout = openLibrary.URL, 50*time.Millisecond
+	router, s := newRouter(t, cfg)
+
+	tests := []struct {
+		name   string
+		isbn   string
+		status int
+		want   BookLookup
+	}{
+		{"found", "978-0-441-01359-3", http.StatusOK, BookLookup{ISBN: "9780441013593", Title: "Dune", Author: "Frank Herbert", Year: 2005, Status: lookupFound}},
+		{"not found", "9780141439587", http.StatusOK, BookLookup{ISBN: "9780141439587", Status: lookupNotFound}},
+		{"too slow", "0-8044-2957-x", http.StatusOK, BookLookup{ISBN: "080442957X", Status: lookupUnavailable}},
+		{"bad checksum", "9780441013594", http.StatusBadRequest, BookLookup{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := testutil.DoJSON(t, router, http.MethodPost, "/books/lookup", map[string]string{"isbn": tt.isbn})
+			if tt.status != http.StatusOK {
+				testutil.AssertStatus(t, w, tt.status)
+				return
+			}
+			testutil.AssertJSON(t, w, tt.status, tt.want)
+		})
+	}
+
+	openLibrary.Close()
+	w := testutil.DoJSON(t, router, http.MethodPost, "/books/lookup", map[string]string{"isbn": "9780441013593"})
+	testutil.AssertJSON(t, w, http.StatusOK, BookLookup{ISBN: "9780441013593", Status: lookupUnavailable})
+
+	// the lookup pre-fills a create; a second book with the ISBN is a 409
+	s.SetRepository(NewMemoryRepository())
+	book := Book{Title: "Dune", Author: "Frank Herbert", Year: 2005, ISBN: "9780441013593"}
+	testutil.AssertStatus(t, testutil.DoJSON(t, router, http.MethodPost, "/books", book), http.StatusCreated)
+	testutil.AssertStatus(t, testutil.DoJSON(t, router, http.MethodPost, "/books", book), http.StatusConflict)
+}
+
+func TestPublishYear(t *testing.T) {
+	for date, want := range map[string]int{"1965": 1965, "August 2, 2005": 2005, "2005-08-02": 2005, "c1999": 0, "": 0, "n.d.": 0} {
+		if got := publishYear(date); got != want {
+			t.Errorf("publishYear(%q) = %d, want %d", date, got, want)
+		}
+	}
+}
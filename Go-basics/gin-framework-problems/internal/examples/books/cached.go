@@ -0,0 +1,148 @@
+package books
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/cache"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/config"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/server"
+)
+
+// cachedRepository reads books by ID through a cache, in front of a
+// repository where every read is a query. Concurrent misses for one book
+// share a single load, and cache_requests_total{cache="book"} counts hits
+// and misses. Each write that changes what Get returns, reviews and loans
+// included, drops the cached copy once it succeeds; the TTL bounds how stale
+// a copy can get when that fails. Lists always go to the repository.
+type cachedRepository struct {
+	BookRepository
+	cache *cache.Cache
+	ttl   time.Duration
+}
+
+func cached(r BookRepository, c *cache.Cache, ttl time.Duration) BookRepository {
+	return cachedRepository{BookRepository: r, cache: c, ttl: ttl}
+}
+
+// newCacheStore is Redis when redis.addr is set, else memory.
+func newCacheStore(cfg *config.Config, hooks *server.Hooks) cache.Store {
+	if cfg.Redis.Addr == "" {
+		return cache.NewMemoryStore()
+	}
+	client := redis.NewClient(&redis.Options{Addr: cfg.Redis.Addr})
+	hooks.Add(func(context.Context) error { return client.Close() })
+	return cache.NewRedisStore(client)
+}
+
+func (r cachedRepository) Get(ctx context.Context, id string) (Book, error) {
+	var b Book
+	// a missing book is an error, which isn't cached
+	err := r.cache.GetOrLoad(ctx, id, r.ttl, &b, func(ctx context.Context) (any, error) {
+		return r.BookRepository.Get(ctx, id)
+	})
+	return b, err
+}
+
+// invalidate drops the cached copies of the books with ids. Failing to is
+// only logged: the write is done, and the copies expire with the TTL.
+func (r cachedRepository) invalidate(ctx context.Context, ids ...string) {
+	if len(ids) == 0 {
+		return
+	}
+	if err := r.cache.Delete(context.WithoutCancel(ctx), ids...); err != nil {
+		slog.WarnContext(ctx, "book cache invalidation failed", "books", ids, "error", err)
+	}
+}
+
+func (r cachedRepository) Update(ctx context.Context, id string, fn func(*Book) error) (Book, error) {
+	b, err := r.BookRepository.Update(ctx, id, fn)
+	if err == nil {
+		r.invalidate(ctx, id)
+	}
+	return b, err
+}
+
+func (r cachedRepository) Delete(ctx context.Context, id string) error {
+	err := r.BookRepository.Delete(ctx, id)
+	if err == nil {
+		r.invalidate(ctx, id)
+	}
+	return err
+}
+
+func (r cachedRepository) DeleteCategory(ctx context.Context, slug string) error {
+	// the books it is taken off, found before it is gone
+	all, err := r.BookRepository.List(ctx)
+	if err != nil {
+		return err
+	}
+	var ids []string
+	for _, b := range all {
+		if slices.Contains(b.Categories, slug) {
+			ids = append(ids, b.ID)
+		}
+	}
+	if err := r.BookRepository.DeleteCategory(ctx, slug); err != nil {
+		return err
+	}
+	r.invalidate(ctx, ids...)
+	return nil
+}
+
+func (r cachedRepository) UpdateAuthor(ctx context.Context, a Author) (Author, error) {
+	a, err := r.BookRepository.UpdateAuthor(ctx, a)
+	if err != nil {
+		return a, err
+	}
+	// their books carry the name
+	list, err := r.BookRepository.ListByAuthor(ctx, a.ID)
+	if err != nil {
+		slog.WarnContext(ctx, "book cache invalidation failed", "author", a.ID, "error", err)
+		return a, nil
+	}
+	ids := make([]string, len(list))
+	for i, b := range list {
+		ids[i] = b.ID
+	}
+	r.invalidate(ctx, ids...)
+	return a, nil
+}
+
+// Reviews and loans show in the book's rating and availability.
+
+func (r cachedRepository) CreateReview(ctx context.Context, rv Review) (Review, error) {
+	rv, err := r.BookRepository.CreateReview(ctx, rv)
+	if err == nil {
+		r.invalidate(ctx, rv.BookID)
+	}
+	return rv, err
+}
+
+func (r cachedRepository) DeleteReview(ctx context.Context, bookID, id string) error {
+	err := r.BookRepository.DeleteReview(ctx, bookID, id)
+	if err == nil {
+		r.invalidate(ctx, bookID)
+	}
+	return err
+}
+
+func (r cachedRepository) CreateLoan(ctx context.Context, l Loan) (Loan, error) {
+	l, err := r.BookRepository.CreateLoan(ctx, l)
+	if err == nil {
+		r.invalidate(ctx, l.BookID)
+	}
+	return l, err
+}
+
+func (r cachedRepository) ReturnLoan(ctx context.Context, id string, at time.Time) (Loan, error) {
+	l, err := r.BookRepository.ReturnLoan(ctx, id, at)
+	if err == nil {
+		r.invalidate(ctx, l.BookID)
+	}
+	return l, err
+}
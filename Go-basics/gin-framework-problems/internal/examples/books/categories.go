@@ -0,0 +1,92 @@
+package books
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/pagination"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/query"
+)
+
+// Category groups books. A book lists the slugs of its categories in
+// Book.Categories, and a category can hold any number of books.
+type Category struct {
+	Slug        string `json:"slug" binding:"required,max=40,slug"`
+	Name        string `json:"name" binding:"required,notblank,max=100"`
+	Description string `json:"description,omitempty" binding:"max=500"`
+}
+
+// unknownCategories are the validation details for slugs that aren't
+// categories.
+func unknownCategories(unknown []string) map[string]string {
+	return map[string]string{"categories": strings.Join(unknown, ", ") + " not in /categories"}
+}
+
+// listCategories pages through the categories ordered by slug.
+func (s *Server) listCategories(c *gin.Context) {
+	req, err := query.Parse[Category](c, nil, "", nil)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	all, err := s.repo.ListCategories(c.Request.Context())
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	pagination.Write(c, pagination.NewPage(all, req.Page))
+}
+
+func (s *Server) getCategory(c *gin.Context) {
+	cat, err := s.repo.GetCategory(c.Request.Context(), c.Param("slug"))
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, cat)
+}
+
+func (s *Server) createCategory(c *gin.Context) {
+	var input Category
+	if err := c.ShouldBindJSON(&input); err != nil {
+		middleware.BindError(c, err)
+		return
+	}
+	if err := s.repo.CreateCategory(c.Request.Context(), input); err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, input)
+}
+
+// updateCategory replaces the name and description. The slug stays, since
+// books refer to the category by it.
+func (s *Server) updateCategory(c *gin.Context) {
+	var req struct {
+		Name        string `json:"name" binding:"required,notblank,max=100"`
+		Description string `json:"description" binding:"max=500"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.BindError(c, err)
+		return
+	}
+	cat := Category{Slug: c.Param("slug"), Name: req.Name, Description: req.Description}
+	if err := s.repo.UpdateCategory(c.Request.Context(), cat); err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, cat)
+}
+
+// deleteCategory removes the category and takes it off every book in it.
+func (s *Server) deleteCategory(c *gin.Context) {
+	if err := s.repo.DeleteCategory(c.Request.Context(), c.Param("slug")); err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	s.invalidateList()
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,139 @@
+package books
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/apperror"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/files"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/negotiate"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/tracing"
+)
+
+// coverDir is where covers go under storage.upload_dir, one per book,
+// named after its ID.
+const coverDir = "covers"
+
+// cover file extensions by the content type sniffed from the upload; the
+// client's filename and Content-Type aren't trusted
+var coverTypes = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+var errCoverNotFound = apperror.NotFound("cover not found")
+
+// coverName is where book id's cover of type ext is stored.
+func coverName(id, ext string) string {
+	return coverDir + "/" + id + ext
+}
+
+// findCover returns the stored cover of book id, or false without one.
+func (s *Server) findCover(id string) (string, os.FileInfo, bool) {
+	for _, ext := range coverTypes {
+		p := s.covers.Path(coverName(id, ext))
+		if info, err := os.Stat(p); err == nil && info.Mode().IsRegular() {
+			return p, info, true
+		}
+	}
+	return "", nil, false
+}
+
+// sniffCover returns the extension for file's content, or false if it isn't
+// an image type covers may have.
+func sniffCover(file io.Reader) (string, bool) {
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return "", false
+	}
+	ext, ok := coverTypes[http.DetectContentType(head[:n])]
+	return ext, ok
+}
+
+// putCover stores the image in the "cover" form field as the book's cover,
+// replacing any it had.
+func (s *Server) putCover(c *gin.Context) {
+	ctx, span := tracing.Start(c.Request.Context(), "books.store.put_cover", attribute.String("book.id", c.Param("id")))
+	defer span.End()
+	// the stored ID, not the one in the URL, names the file: "01" finds
+	// book 1 in SQLite
+	b, err := s.Get(ctx, c.Param("id"))
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	file, err := c.FormFile("cover")
+	if files.TooLarge(err) {
+		middleware.Fail(c, err)
+		return
+	}
+	if err != nil {
+		middleware.Error(c, http.StatusBadRequest, "file is required")
+		return
+	}
+	if file.Size > s.maxCoverBytes {
+		middleware.Fail(c, apperror.TooLarge(s.maxCoverBytes))
+		return
+	}
+	f, err := file.Open()
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	ext, ok := sniffCover(f)
+	f.Close()
+	if !ok {
+		middleware.Error(c, http.StatusBadRequest, "cover must be a PNG, JPEG, GIF or WebP image")
+		return
+	}
+
+	name := coverName(b.ID, ext)
+	if err := s.covers.Save(c, file, name); err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	// a cover of another type would otherwise be found instead
+	for _, other := range coverTypes {
+		if other != ext {
+			s.removeCover(c, coverName(b.ID, other))
+		}
+	}
+	negotiate.Render(c, http.StatusOK, gin.H{"cover_url": "/books/" + b.ID + "/cover", "size": file.Size})
+}
+
+// getCover serves the book's cover. Its URL stays the same when the cover
+// is replaced, so caches keep it for an hour and then revalidate with
+// If-None-Match or If-Modified-Since.
+func (s *Server) getCover(c *gin.Context) {
+	// only a stored book's ID is safe in a path
+	b, err := s.Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	p, info, ok := s.findCover(b.ID)
+	if !ok {
+		middleware.Fail(c, errCoverNotFound)
+		return
+	}
+	c.Header("Cache-Control", "public, max-age=3600")
+	c.Header("ETag", etag([]any{info.Size(), info.ModTime()}))
+	c.File(p)
+}
+
+// removeCover deletes a stored cover. A failure only leaves a stray file
+// behind, so it is logged rather than returned.
+func (s *Server) removeCover(c *gin.Context, name string) {
+	if err := s.covers.Remove(name); err != nil {
+		slog.ErrorContext(c.Request.Context(), "remove cover", "file", name, "error", err)
+	}
+}
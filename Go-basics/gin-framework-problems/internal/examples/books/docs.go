@@ -0,0 +1,52 @@
+package books
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/apidocs"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/jobs"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/pagination"
+)
+
+// docs documents the books API at /openapi.json. The book routes also
+// answer in XML and YAML; see internal/negotiate.
+var docs = []apidocs.Operation{
+	{Method: http.MethodGet, Path: "/books", Tag: "books", Summary: "Page through the catalog",
+		Query: map[string]string{
+			"author":          "books by this author",
+			"category":        "books in this category",
+			"tag":             "books with this tag",
+			"year_gte":        "published in or after this year",
+			"year_lte":        "published in or before this year",
+			"sort":            "title, year or author, with a leading - for descending",
+			"limit":           "items per page, at most 100",
+			"offset":          "items to skip",
+			"page":            "page to return, from 1, instead of offset",
+			"cursor":          "next_cursor of the previous page, instead of offset",
+			"include_deleted": "true to add the deleted books, with books:manage",
+			"format":          "json, xml or yaml, whatever Accept says",
+		},
+		Response: pagination.Page[Book]{}},
+	{Method: http.MethodGet, Path: "/books/:id", Tag: "books", Summary: "A book", Response: Book{}},
+	{Method: http.MethodGet, Path: "/books/top-rated", Tag: "books",
+		Summary:  "The best-rated books with at least 3 reviews, as of the last rating_aggregates run",
+		Response: gin.H{"books": []Book{}, "computed_at": ""}},
+	{Method: http.MethodPost, Path: "/books", Tag: "books", Summary: "Add a book",
+		Request: Book{}, Status: http.StatusCreated, Response: Book{}},
+	{Method: http.MethodPut, Path: "/books/:id", Tag: "books", Summary: "Replace a book, sending the version it was based on",
+		Request: Book{}, Response: Book{}},
+	{Method: http.MethodDelete, Path: "/books/:id", Tag: "books", Summary: "Put a book aside; POST .../restore brings it back",
+		Status: http.StatusNoContent},
+	{Method: http.MethodPost, Path: "/books/lookup", Tag: "books", Summary: "Draft a book from OpenLibrary by its ISBN",
+		Request: gin.H{"isbn": ""}, Response: BookLookup{}},
+	{Method: http.MethodPost, Path: "/books/import", Tag: "books", Summary: "Import a CSV or JSON file of books in the background",
+		Query: map[string]string{
+			"format": "csv or json, by default the file's extension",
+			"map":    "column:field pairs, such as name:title,writer:author",
+		},
+		Upload: true, Status: http.StatusAccepted, Response: jobs.Job{}},
+	{Method: http.MethodGet, Path: "/books/import/:job", Tag: "books", Summary: "An import's job, with its report as the result once done",
+		Response: jobs.Job{}},
+}
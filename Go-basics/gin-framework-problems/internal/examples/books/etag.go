@@ -0,0 +1,73 @@
+package books
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/apperror"
+)
+
+// errBookChanged is the answer to an If-Match that names an older version.
+var errBookChanged = apperror.PreconditionFailed("book has changed")
+
+// etag is a strong entity tag for v: a hash of its JSON, so it changes
+// whenever anything a client can see does.
+func etag(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		// books and their pages always marshal
+		panic(err)
+	}
+	sum := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// notModified sets the ETag header and, when If-None-Match names it,
+// answers 304. The comparison is weak, as RFC 9110 asks for GET.
+func notModified(c *gin.Context, tag string) bool {
+	c.Header("ETag", tag)
+	if !matchTag(c.GetHeader("If-None-Match"), tag, false) {
+		return false
+	}
+	c.Status(http.StatusNotModified)
+	return true
+}
+
+// checkIfMatch lets a write go ahead when there's no If-Match, when it's
+// "*" (the book exists, or we wouldn't have it) or when it names b's
+// current tag; otherwise the client read an older version and the write
+// would lose someone else's change.
+func checkIfMatch(c *gin.Context, b Book) error {
+	header := c.GetHeader("If-Match")
+	if header == "" || matchTag(header, etag(b), true) {
+		return nil
+	}
+	return errBookChanged
+}
+
+// matchTag reports whether the comma-separated list in header holds tag or
+// is "*". A weak comparison ignores the W/ prefix; a strong one never
+// matches a weak tag.
+func matchTag(header, tag string, strong bool) bool {
+	for _, t := range strings.Split(header, ",") {
+		t = strings.TrimSpace(t)
+		if t == "*" {
+			return true
+		}
+		if weak, ok := strings.CutPrefix(t, "W/"); ok {
+			if strong {
+				continue
+			}
+			t = weak
+		}
+		if t == strings.TrimPrefix(tag, "W/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,480 @@
+package books
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/apperror"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/files"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/jobs"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/tracing"
+)
+
+// maxImportRows caps one import.
+const maxImportRows = 1000
+
+// exportColumns are the columns of a CSV export. Tags and categories are
+// joined with listSeparator.
+var exportColumns = []string{"id", "title", "author", "year", "isbn", "tags", "categories", "average_rating", "review_count"}
+
+// importFields are the fields an import sets; title, author and year are
+// required. ignoredFields may be there too, so an export can be imported
+// again, but the repository decides them.
+var (
+	importFields  = []string{"title", "author", "year", "isbn", "tags", "categories"}
+	ignoredFields = []string{"id", "author_id", "owner_id", "average_rating", "review_count", "available", "deleted_at", "version", "tenant"}
+)
+
+// listSeparator joins tags and categories in a CSV cell.
+const listSeparator = "|"
+
+// ImportResult reports what became of one row of an import.
+type ImportResult struct {
+	Row     int               `json:"row"` // CSV line, the header being 1, or place in the JSON array from 1
+	Title   string            `json:"title,omitempty"`
+	Status  string            `json:"status"` // created, skipped or error
+	Error   string            `json:"error,omitempty"`
+	Details map[string]string `json:"details,omitempty"` // field → message, for an invalid book
+	ID      string            `json:"id,omitempty"`      // of the created book
+}
+
+// import statuses
+const (
+	importCreated = "created"
+	importSkipped = "skipped"
+	importError   = "error"
+)
+
+// importRow is a book read from an import, or why it couldn't be.
+type importRow struct {
+	row  int
+	book Book
+	err  error
+}
+
+// importItem is a row of an import as the request read and validated it,
+// for the import job to store.
+type importItem struct {
+	Row     int               `json:"row"`
+	Book    Book              `json:"book"`
+	Error   string            `json:"error,omitempty"` // why it can't be stored
+	Details map[string]string `json:"details,omitempty"`
+}
+
+// importJobType is the job type importBooks enqueues.
+const importJobType = "import_books"
+
+// exportBooks streams the books matching the filters of GET /books, in its
+// order, as ?format=csv or json (the default).
+func (s *Server) exportBooks(c *gin.Context) {
+	format := c.DefaultQuery("format", "json")
+	if format != "csv" && format != "json" {
+		middleware.Fail(c, apperror.Validation("invalid query parameters",
+			map[string]string{"format": "must be csv or json"}))
+		return
+	}
+	q, _, err := parseBookQuery(c)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	ctx, span := tracing.Start(c.Request.Context(), "books.store.export")
+	list, err := q.run(ctx, s.repo)
+	span.End()
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="books.`+format+`"`)
+	if format == "csv" {
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Status(http.StatusOK)
+		err = writeCSV(c.Writer, list)
+	} else {
+		c.Header("Content-Type", "application/json; charset=utf-8")
+		c.Status(http.StatusOK)
+		err = writeJSON(c.Writer, list)
+	}
+	if err != nil {
+		// the status is out already; the client sees a cut-off file
+		c.Error(err)
+	}
+}
+
+func writeCSV(w io.Writer, list []Book) error {
+	cw := csv.NewWriter(w)
+	cw.Write(exportColumns)
+	for _, b := range list {
+		cw.Write([]string{
+			b.ID,
+			b.Title,
+			b.Author,
+			strconv.Itoa(b.Year),
+			b.ISBN,
+			strings.Join(b.Tags, listSeparator),
+			strings.Join(b.Categories, listSeparator),
+			strconv.FormatFloat(b.AverageRating, 'f', -1, 64),
+			strconv.Itoa(b.ReviewCount),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeJSON writes one book at a time rather than marshalling the list.
+func writeJSON(w io.Writer, list []Book) error {
+	if _, err := io.WriteString(w, "["); err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	for i, b := range list {
+		if i > 0 {
+			io.WriteString(w, ",")
+		}
+		if err := enc.Encode(b); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(w, "]\n")
+	return err
+}
+
+// importBooks creates books from the CSV or JSON array in the "file" form
+// field in the background, and answers 202 with the job, to be polled at
+// /books/import/:job. ?format= names the format, or else the file's
+// extension does. ?map=Name:title,Writer:author renames the file's columns,
+// or keys, to the fields of a book. The file is read and its rows validated
+// before answering, so a broken file is a 400; runImport stores the rest.
+func (s *Server) importBooks(c *gin.Context) {
+	mapping, err := parseMapping(c.Query("map"))
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	file, err := c.FormFile("file")
+	if files.TooLarge(err) {
+		middleware.Fail(c, err)
+		return
+	}
+	if err != nil {
+		middleware.Error(c, http.StatusBadRequest, "file is required")
+		return
+	}
+	format := c.Query("format")
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(file.Filename)), ".")
+	}
+	if format != "csv" && format != "json" {
+		middleware.Fail(c, apperror.Validation("invalid query parameters",
+			map[string]string{"format": "must be csv or json, or the file end in .csv or .json"}))
+		return
+	}
+	f, err := file.Open()
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	defer f.Close()
+
+	// read it all first, so a bad header or an oversized file is refused
+	// before anything is stored
+	var rows []importRow
+	if format == "csv" {
+		rows, err = readCSV(f, mapping)
+	} else {
+		rows, err = readJSON(f, mapping)
+	}
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	if len(rows) > maxImportRows {
+		middleware.Fail(c, apperror.Validation("too many rows",
+			map[string]string{"file": "at most " + strconv.Itoa(maxImportRows) + " rows per import"}))
+		return
+	}
+
+	// validated here, where messages can follow the request's language
+	items := make([]importItem, len(rows))
+	for i, row := range rows {
+		items[i] = importItem{Row: row.row, Book: row.book}
+		if row.err != nil {
+			items[i].Error = row.err.Error()
+		} else if err := binding.Validator.ValidateStruct(&items[i].Book); err != nil {
+			items[i].Error, items[i].Details = invalidBook(c, err)
+		}
+	}
+	if s.pool == nil {
+		middleware.Fail(c, apperror.New(http.StatusServiceUnavailable, apperror.CodeUnavailable, "imports aren't running"))
+		return
+	}
+	job, err := s.pool.Enqueue(c.Request.Context(), importJobType, items)
+	if errors.Is(err, jobs.ErrQueueFull) || errors.Is(err, jobs.ErrClosed) {
+		c.Header("Retry-After", "5")
+		middleware.Fail(c, apperror.New(http.StatusServiceUnavailable, apperror.CodeUnavailable, "import queue is full"))
+		return
+	}
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	c.Header("Location", "/books/import/"+job.ID)
+	c.JSON(http.StatusAccepted, job)
+}
+
+// importStatus answers with an import's job, whose result is the report
+// once it has run.
+func (s *Server) importStatus(c *gin.Context) {
+	if s.pool == nil {
+		middleware.Fail(c, apperror.NotFound("import not found"))
+		return
+	}
+	job, ok := s.pool.Get(c.Param("job"))
+	if !ok || job.Type != importJobType {
+		middleware.Fail(c, apperror.NotFound("import not found"))
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+// ImportReport is the result of an import job.
+type ImportReport struct {
+	Created int            `json:"created"`
+	Skipped int            `json:"skipped"`
+	Errored int            `json:"errored"`
+	Rows    []ImportResult `json:"rows"`
+}
+
+func newImportReport(results []ImportResult) ImportReport {
+	r := ImportReport{Rows: results}
+	for _, res := range results {
+		switch res.Status {
+		case importCreated:
+			r.Created++
+		case importSkipped:
+			r.Skipped++
+		default:
+			r.Errored++
+		}
+	}
+	return r
+}
+
+// runImport is the import job: it stores the valid rows and reports on
+// every row as the job's result. A row with the title and author of a
+// stored book, or of an earlier row, is skipped. When the store fails, the
+// job is retried, and the rows stored before are then skipped.
+func (s *Server) runImport(ctx context.Context, payload json.RawMessage) error {
+	var items []importItem
+	if err := json.Unmarshal(payload, &items); err != nil {
+		return jobs.Permanent(err)
+	}
+	ctx, span := tracing.Start(ctx, "books.store.import")
+	defer span.End()
+	stored, err := s.List(ctx)
+	if err != nil {
+		return err
+	}
+	seen := map[string]bool{}
+	for _, b := range stored {
+		seen[duplicateKey(b)] = true
+	}
+
+	results := make([]ImportResult, 0, len(items))
+	for _, item := range items {
+		res := ImportResult{Row: item.Row, Title: item.Book.Title, Status: importError,
+			Error: item.Error, Details: item.Details}
+		if item.Error != "" {
+			results = append(results, res)
+			continue
+		}
+		if seen[duplicateKey(item.Book)] {
+			res.Status, res.Error = importSkipped, "a book with this title and author exists"
+			results = append(results, res)
+			continue
+		}
+		b, err := s.Create(ctx, item.Book)
+		var unknown unknownCategoriesError
+		switch {
+		case errors.As(err, &unknown):
+			res.Error, res.Details = "unknown category", unknownCategories(unknown)
+		case errors.Is(err, errISBNExists):
+			res.Status, res.Error = importSkipped, "a book with this ISBN exists"
+		case err != nil:
+			// the rows before this one are stored; the report says which
+			jobs.SetResult(ctx, newImportReport(results))
+			return err
+		default:
+			seen[duplicateKey(b)] = true
+			res.ID, res.Status = b.ID, importCreated
+		}
+		results = append(results, res)
+	}
+	return jobs.SetResult(ctx, newImportReport(results))
+}
+
+// duplicateKey is what two books with the same title and author share.
+func duplicateKey(b Book) string {
+	return strings.ToLower(strings.TrimSpace(b.Title)) + "\x00" + strings.ToLower(strings.TrimSpace(b.Author))
+}
+
+// parseMapping reads ?map=from:to,... into lower-cased names of the file
+// and the fields they stand for.
+func parseMapping(s string) (map[string]string, error) {
+	mapping := map[string]string{}
+	if s == "" {
+		return mapping, nil
+	}
+	for _, pair := range strings.Split(s, ",") {
+		from, to, ok := strings.Cut(pair, ":")
+		from, to = strings.ToLower(strings.TrimSpace(from)), strings.ToLower(strings.TrimSpace(to))
+		if !ok || from == "" || !slices.Contains(importFields, to) {
+			return nil, apperror.Validation("invalid query parameters",
+				map[string]string{"map": "must be column:field pairs, the fields among " + strings.Join(importFields, ", ")})
+		}
+		mapping[from] = to
+	}
+	return mapping, nil
+}
+
+// fieldName is the book field a column or key name stands for once mapped,
+// or the name itself.
+func fieldName(name string, mapping map[string]string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if to, ok := mapping[name]; ok {
+		return to
+	}
+	return name
+}
+
+// readCSV reads the rows under the header. It refuses unknown or repeated
+// columns, since a typo would otherwise drop a column silently.
+func readCSV(f io.Reader, mapping map[string]string) ([]importRow, error) {
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1 // checked per row, so one bad row doesn't end the import
+	r.TrimLeadingSpace = true
+	header, err := r.Read()
+	if err != nil {
+		return nil, apperror.Validation("the CSV file has no header row", nil)
+	}
+	index := make(map[string]int, len(header))
+	details := map[string]string{}
+	for i, name := range header {
+		field := fieldName(name, mapping)
+		switch _, dup := index[field]; {
+		case !slices.Contains(importFields, field) && !slices.Contains(ignoredFields, field):
+			details[name] = "is not a column; use " + strings.Join(importFields, ", ") + " or ?map="
+		case dup:
+			details[field] = "appears more than once"
+		}
+		index[field] = i
+	}
+	for _, name := range []string{"title", "author", "year"} {
+		if _, ok := index[name]; !ok {
+			details[name] = "column is required"
+		}
+	}
+	if len(details) > 0 {
+		return nil, apperror.Validation("invalid CSV header", details)
+	}
+
+	var rows []importRow
+	for len(rows) <= maxImportRows {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		row := importRow{err: err}
+		var perr *csv.ParseError
+		switch {
+		case errors.As(err, &perr):
+			row.row = perr.StartLine
+		case err != nil:
+			// the upload itself can't be read; there's no next row
+			return nil, err
+		case len(record) != len(header):
+			row.row, _ = r.FieldPos(0)
+			row.err = errors.New("has " + strconv.Itoa(len(record)) + " fields, the header " + strconv.Itoa(len(header)))
+		default:
+			row.row, _ = r.FieldPos(0)
+			row.book, row.err = csvBook(record, index)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// csvBook builds a book from a record whose columns index names.
+func csvBook(record []string, index map[string]int) (Book, error) {
+	field := func(name string) string {
+		if i, ok := index[name]; ok {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+	list := func(name string) []string {
+		var out []string
+		for _, s := range strings.Split(field(name), listSeparator) {
+			if s = strings.TrimSpace(s); s != "" {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	b := Book{Title: field("title"), Author: field("author"), ISBN: field("isbn"), Tags: list("tags"), Categories: list("categories")}
+	if year := field("year"); year != "" {
+		var err error
+		if b.Year, err = strconv.Atoi(year); err != nil {
+			return b, errors.New("year must be a whole number")
+		}
+	}
+	return b, nil
+}
+
+// readJSON reads an array of objects. An object with a key that's neither
+// a field nor mapped to one is an error; like the CSV header, it's likely
+// a typo.
+func readJSON(f io.Reader, mapping map[string]string) ([]importRow, error) {
+	var items []map[string]json.RawMessage
+	if err := json.NewDecoder(f).Decode(&items); err != nil {
+		if files.TooLarge(err) {
+			return nil, err
+		}
+		return nil, apperror.Validation("the file is not a JSON array of books", nil)
+	}
+	rows := make([]importRow, len(items))
+	for i, item := range items {
+		rows[i].row = i + 1
+		fields := make(map[string]json.RawMessage, len(item))
+		for key, value := range item {
+			switch field := fieldName(key, mapping); {
+			case slices.Contains(ignoredFields, field):
+			case !slices.Contains(importFields, field):
+				rows[i].err = errors.New(key + " is not a field; use " + strings.Join(importFields, ", ") + " or ?map=")
+			default:
+				fields[field] = value
+			}
+		}
+		if rows[i].err != nil {
+			continue
+		}
+		data, _ := json.Marshal(fields)
+		if err := json.Unmarshal(data, &rows[i].book); err != nil {
+			rows[i].err = err
+		}
+	}
+	return rows, nil
+}
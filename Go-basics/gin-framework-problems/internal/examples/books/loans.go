@@ -0,0 +1,154 @@
+package books
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/apperror"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/negotiate"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/pagination"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/query"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/rbac"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/tracing"
+)
+
+// Loan is one checkout of a book, by a user of the auth example. A book has
+// at most one open loan, and is available while it has none.
+type Loan struct {
+	ID           string    `json:"id"`
+	BookID       string    `json:"book_id"`
+	Borrower     string    `json:"borrower"`
+	CheckedOutAt time.Time `json:"checked_out_at"`
+	DueAt        time.Time `json:"due_at"`
+	ReturnedAt   time.Time `json:"returned_at,omitzero"`
+	// Overdue is worked out when the loan is sent: it's open and past due.
+	Overdue bool `json:"overdue"`
+}
+
+// Returned reports whether the book was brought back.
+func (l Loan) Returned() bool {
+	return !l.ReturnedAt.IsZero()
+}
+
+// withOverdue sets l.Overdue as of now.
+func (l Loan) withOverdue(now time.Time) Loan {
+	l.Overdue = !l.Returned() && now.After(l.DueAt)
+	return l
+}
+
+// defaultLoanDays is how long a loan lasts unless the checkout says.
+const defaultLoanDays = 14
+
+var errNotYourLoan = apperror.Forbidden("only its borrower or a librarian can return a book")
+
+// checkoutBook lends the book to the signed-in user for the days in an
+// optional {"days": n}, at most 60. A book that's out already is a 409.
+func (s *Server) checkoutBook(c *gin.Context) {
+	var req struct {
+		Days int `json:"days" xml:"days" binding:"omitempty,min=1,max=60"`
+	}
+	// the body is optional
+	if err := negotiate.Bind(c, &req); err != nil && !errors.Is(err, io.EOF) {
+		middleware.BindError(c, err)
+		return
+	}
+	if req.Days == 0 {
+		req.Days = defaultLoanDays
+	}
+
+	id := c.Param("id")
+	ctx, span := tracing.Start(c.Request.Context(), "books.store.checkout", attribute.String("book.id", id))
+	defer span.End()
+	b, err := s.Get(ctx, id)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	now := s.now().UTC()
+	l, err := s.repo.CreateLoan(ctx, Loan{
+		BookID:       b.ID,
+		Borrower:     principal(c).Username,
+		CheckedOutAt: now,
+		DueAt:        now.AddDate(0, 0, req.Days),
+	})
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	s.publishUpdate(c, "checked_out", b)
+	negotiate.Render(c, http.StatusCreated, l.withOverdue(now))
+}
+
+// returnBook closes the book's open loan. Its borrower can return it, and
+// so can holders of loans:manage.
+func (s *Server) returnBook(c *gin.Context) {
+	id := c.Param("id")
+	ctx, span := tracing.Start(c.Request.Context(), "books.store.return", attribute.String("book.id", id))
+	defer span.End()
+	b, err := s.Get(ctx, id)
+	var l Loan
+	if err == nil {
+		l, err = s.repo.OpenLoan(ctx, b.ID)
+	}
+	if err == nil && l.Borrower != principal(c).Username && !principal(c).Can(rbac.LoansManage) {
+		err = errNotYourLoan
+	}
+	// by the loan's ID, so a return racing this one can't make it close
+	// the next borrower's loan
+	if err == nil {
+		l, err = s.repo.ReturnLoan(ctx, l.ID, s.now().UTC())
+	}
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	s.publishUpdate(c, "returned", b)
+	negotiate.Render(c, http.StatusOK, l)
+}
+
+// listLoans pages through loans, oldest first: the signed-in user's, or
+// with loans:manage everyone's, or ?borrower='s. ?overdue=true keeps the
+// open loans past their due date, ?overdue=false the rest.
+func (s *Server) listLoans(c *gin.Context) {
+	var f struct {
+		Borrower string `form:"borrower"`
+		Overdue  *bool  `form:"overdue"` // nil for all
+	}
+	req, err := query.Parse[Loan](c, nil, "", &f)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	borrower := f.Borrower
+	me := principal(c)
+	if !me.Can(rbac.LoansManage) {
+		if borrower != "" && borrower != me.Username {
+			middleware.Fail(c, apperror.Forbidden("missing permission "+rbac.LoansManage))
+			return
+		}
+		borrower = me.Username
+	}
+
+	ctx, span := tracing.Start(c.Request.Context(), "books.store.list_loans")
+	all, err := s.repo.ListLoans(ctx)
+	span.End()
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	now := s.now()
+	for i := range all {
+		all[i] = all[i].withOverdue(now)
+	}
+	all = slices.DeleteFunc(all, func(l Loan) bool {
+		return borrower != "" && l.Borrower != borrower || f.Overdue != nil && l.Overdue != *f.Overdue
+	})
+	pagination.Write(c, pagination.NewPage(all, req.Page))
+}
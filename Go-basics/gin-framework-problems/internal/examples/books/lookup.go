@@ -0,0 +1,124 @@
+package books
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/negotiate"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/tracing"
+)
+
+// How POST /books/lookup got on with OpenLibrary.
+const (
+	lookupFound       = "found"
+	lookupNotFound    = "not_found"
+	lookupUnavailable = "unavailable"
+)
+
+// BookLookup is the answer to POST /books/lookup: the start of a book for
+// POST /books, with whatever OpenLibrary knows about the ISBN filled in.
+type BookLookup struct {
+	ISBN   string `json:"isbn" xml:"isbn"`
+	Title  string `json:"title,omitempty" xml:"title"`
+	Author string `json:"author,omitempty" xml:"author"`
+	Year   int    `json:"year,omitempty" xml:"year"`
+	// Status is found, not_found when OpenLibrary doesn't know the ISBN,
+	// or unavailable when it couldn't be asked; the last two come with
+	// the ISBN alone.
+	Status string `json:"status" xml:"status"`
+}
+
+// lookupBook pre-fills a book from the ISBN in {"isbn": "..."}. OpenLibrary
+// being slow or down isn't an error: the client gets the ISBN back, marked
+// unavailable, and fills in the rest by hand.
+func (s *Server) lookupBook(c *gin.Context) {
+	var req struct {
+		ISBN string `json:"isbn" xml:"isbn" binding:"required,isbn"`
+	}
+	if err := negotiate.Bind(c, &req); err != nil {
+		middleware.BindError(c, err)
+		return
+	}
+
+	isbn := normalizeISBN(req.ISBN)
+	ctx, span := tracing.Start(c.Request.Context(), "books.lookup", attribute.String("book.isbn", isbn))
+	defer span.End()
+	res, err := s.fetchOpenLibrary(ctx, isbn)
+	if err != nil {
+		slog.WarnContext(ctx, "openlibrary lookup", "isbn", isbn, "error", err)
+		res = BookLookup{ISBN: isbn, Status: lookupUnavailable}
+	}
+	span.SetAttributes(attribute.String("books.lookup.status", res.Status))
+	negotiate.Render(c, http.StatusOK, res)
+}
+
+// openLibraryBook is the part of an OpenLibrary /api/books?jscmd=data entry
+// a lookup uses.
+type openLibraryBook struct {
+	Title   string `json:"title"`
+	Authors []struct {
+		Name string `json:"name"`
+	} `json:"authors"`
+	PublishDate string `json:"publish_date"`
+}
+
+// fetchOpenLibrary asks OpenLibrary about isbn. Only the first of several
+// authors is kept, since a book links to one.
+func (s *Server) fetchOpenLibrary(ctx context.Context, isbn string) (BookLookup, error) {
+	if s.lookupURL == "" {
+		return BookLookup{}, errors.New("books.lookup_url is not set")
+	}
+	key := "ISBN:" + isbn
+	q := url.Values{"bibkeys": {key}, "format": {"json"}, "jscmd": {"data"}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.lookupURL+"/api/books?"+q.Encode(), nil)
+	if err != nil {
+		return BookLookup{}, err
+	}
+	resp, err := s.lookupClient.Do(req)
+	if err != nil {
+		return BookLookup{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return BookLookup{}, fmt.Errorf("openlibrary answered %s", resp.Status)
+	}
+	// an ISBN it doesn't know is left out of an otherwise empty object
+	var found map[string]openLibraryBook
+	if err := json.NewDecoder(resp.Body).Decode(&found); err != nil {
+		return BookLookup{}, fmt.Errorf("decode openlibrary answer: %w", err)
+	}
+	b, ok := found[key]
+	if !ok {
+		return BookLookup{ISBN: isbn, Status: lookupNotFound}, nil
+	}
+	res := BookLookup{ISBN: isbn, Title: b.Title, Year: publishYear(b.PublishDate), Status: lookupFound}
+	if len(b.Authors) > 0 {
+		res.Author = b.Authors[0].Name
+	}
+	return res, nil
+}
+
+var yearPattern = regexp.MustCompile(`\b\d{4}\b`)
+
+// publishYear finds the year in an OpenLibrary publish_date, which may be
+// "1965", "August 2, 2005" or "2005-08-02", or 0 if there's none a book
+// could have.
+func publishYear(date string) int {
+	for _, s := range yearPattern.FindAllString(date, -1) {
+		if y, _ := strconv.Atoi(s); y >= 1000 && y <= 2100 {
+			return y
+		}
+	}
+	return 0
+}
@@ -0,0 +1,70 @@
+package books
+
+import (
+	"cmp"
+	"context"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/tenant"
+)
+
+const (
+	// topRatedSize is how many books GET /books/top-rated lists.
+	topRatedSize = 20
+	// minReviews keeps a single five-star review from topping the list.
+	minReviews = 3
+)
+
+// aggregateRatings ranks the books that aren't deleted and have at least
+// minReviews reviews by average rating, then review count, then title.
+func (s *Server) aggregateRatings(ctx context.Context) error {
+	list, err := s.List(tenant.Unscoped(ctx))
+	if err != nil {
+		return err
+	}
+	ranked := slices.DeleteFunc(list, func(b Book) bool { return b.Deleted() || b.ReviewCount < minReviews })
+	slices.SortFunc(ranked, func(a, b Book) int {
+		return cmp.Or(
+			cmp.Compare(b.AverageRating, a.AverageRating),
+			cmp.Compare(b.ReviewCount, a.ReviewCount),
+			cmp.Compare(a.Title, b.Title),
+		)
+	})
+
+	s.topRated.Lock()
+	s.topRated.books, s.topRated.computed = ranked, s.now().UTC()
+	s.topRated.Unlock()
+	return nil
+}
+
+// listTopRated serves the last ranking, computing one first if the task
+// hasn't run yet.
+func (s *Server) listTopRated(c *gin.Context) {
+	s.topRated.RLock()
+	computed := s.topRated.computed
+	s.topRated.RUnlock()
+	if computed.IsZero() {
+		if err := s.aggregateRatings(c.Request.Context()); err != nil {
+			middleware.Fail(c, err)
+			return
+		}
+	}
+
+	ctx := c.Request.Context()
+	s.topRated.RLock()
+	defer s.topRated.RUnlock()
+	books := make([]Book, 0, topRatedSize)
+	for _, b := range s.topRated.books {
+		if len(books) == topRatedSize {
+			break
+		}
+		if tenant.Visible(ctx, b.Tenant) {
+			books = append(books, b)
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"books": books, "computed_at": s.topRated.computed})
+}
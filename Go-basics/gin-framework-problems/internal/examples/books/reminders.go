@@ -0,0 +1,59 @@
+package books
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// overdueMail is the data of the overdue_loan template.
+type overdueMail struct {
+	Username string
+	Title    string
+	DueAt    time.Time
+	DaysLate int
+	Link     string
+}
+
+// remindOverdue mails the borrower of every overdue loan. The
+// overdue_reminders task runs it daily, so a late book gets a reminder a
+// day until it is back. Borrowers without an address are skipped, and so is
+// everyone while s has no mailer.
+func (s *Server) remindOverdue(ctx context.Context) error {
+	if s.mailer == nil {
+		return nil
+	}
+	loans, err := s.repo.ListLoans(ctx)
+	if err != nil {
+		return err
+	}
+	now := s.now()
+	for _, l := range loans {
+		if !l.withOverdue(now).Overdue {
+			continue
+		}
+		to, ok := s.auth.Email(l.Borrower)
+		if !ok {
+			continue
+		}
+		b, err := s.Get(ctx, l.BookID)
+		if errors.Is(err, ErrBookNotFound) {
+			// deleted while out; there's nothing to link to
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		err = s.mailer.Send(ctx, "overdue_loan", to, overdueMail{
+			Username: l.Borrower,
+			Title:    b.Title,
+			DueAt:    l.DueAt,
+			DaysLate: max(1, int(now.Sub(l.DueAt)/(24*time.Hour))),
+			Link:     s.baseURL + "/books/" + b.ID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,1231 @@
+package books
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/apperror"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/cache"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/config"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/database"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/server"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/tenant"
+)
+
+var (
+	// apperrors, so handlers can pass them to middleware.Fail
+	ErrBookNotFound     = apperror.NotFound("book not found")
+	errCategoryNotFound = apperror.NotFound("category not found")
+	errCategoryExists   = apperror.Conflict("a category with this slug already exists")
+	errReviewNotFound   = apperror.NotFound("review not found")
+	errBookNotDeleted   = apperror.Conflict("book is not deleted")
+	errVersionRequired  = apperror.Validation("version is required", map[string]string{"version": "version is required"})
+	errCursorSort       = apperror.Validation("invalid query parameters", map[string]string{"cursor": "pages by ID and can't be used with sort"})
+	errAuthorNotFound   = apperror.NotFound("author not found")
+	errAuthorExists     = apperror.Conflict("an author with this name already exists")
+	errAuthorHasBooks   = apperror.Conflict("author still has books")
+	errISBNExists       = apperror.Conflict("a book with this ISBN already exists")
+
+	// errUnknownAuthor is a book's AuthorID that isn't an author's, or a
+	// book with neither an AuthorID nor a name.
+	errUnknownAuthor = errors.New("unknown author")
+
+	errBookOnLoan    = apperror.Conflict("book is already checked out")
+	errBookNotOnLoan = apperror.Conflict("book is not checked out")
+)
+
+// unknownCategoriesError lists the slugs a book named that aren't
+// categories.
+type unknownCategoriesError []string
+
+func (e unknownCategoriesError) Error() string {
+	return "unknown categories: " + strings.Join(e, ", ")
+}
+
+// BookRepository keeps books, deleted ones included, and the categories
+// they're filed under: what deletion means is up to the callers. Get, Update
+// and Delete fail with ErrBookNotFound for a book that isn't stored;
+// GetCategory, UpdateCategory and DeleteCategory with errCategoryNotFound.
+// A book's AverageRating and ReviewCount come from its reviews, Available
+// from its loans, and its Version counts its writes, from 1: Create and
+// Update ignore the ones they're given. They also link the book to its
+// author: the one its AuthorID names, failing with errUnknownAuthor if
+// there's none, or else the one named in Author, ignoring case, who is added
+// if need be. Either way Author is set to the author's name. They store the
+// ISBN without hyphens or spaces, and fail with errISBNExists when another
+// book, deleted or not, has it.
+type BookRepository interface {
+	// Create stores b under a new ID and returns it with the ID set, and
+	// the default tenant's without a Tenant. Like Update, it drops repeated
+	// categories and fails with an unknownCategoriesError when b names one
+	// that doesn't exist.
+	Create(ctx context.Context, b Book) (Book, error)
+	Get(ctx context.Context, id string) (Book, error)
+	// List returns every book in the order they were added.
+	List(ctx context.Context) ([]Book, error)
+	// ListByAuthor returns the author's books in the order they were added.
+	ListByAuthor(ctx context.Context, authorID string) ([]Book, error)
+	// ListByYears returns the books published from one year to another,
+	// both included, in the order they were added. A 0 leaves that end
+	// open.
+	ListByYears(ctx context.Context, from, to int) ([]Book, error)
+	// Update applies fn to the stored book and saves the result, with no
+	// other update in between. An error from fn is returned and leaves the
+	// book as it was.
+	Update(ctx context.Context, id string, fn func(*Book) error) (Book, error)
+	Delete(ctx context.Context, id string) error
+
+	// CreateCategory fails with errCategoryExists when the slug is taken.
+	CreateCategory(ctx context.Context, cat Category) error
+	GetCategory(ctx context.Context, slug string) (Category, error)
+	// ListCategories returns every category ordered by slug.
+	ListCategories(ctx context.Context) ([]Category, error)
+	// UpdateCategory replaces the name and description of cat.Slug.
+	UpdateCategory(ctx context.Context, cat Category) error
+	// DeleteCategory also takes the category off every book in it.
+	DeleteCategory(ctx context.Context, slug string) error
+
+	// CreateReview stores rv under a new ID and returns it with the ID set.
+	// It and ListReviews fail with ErrBookNotFound when rv.BookID isn't a
+	// book; GetReview and DeleteReview with errReviewNotFound when the
+	// review isn't one of the book's. Deleting a book deletes its reviews.
+	CreateReview(ctx context.Context, rv Review) (Review, error)
+	GetReview(ctx context.Context, bookID, id string) (Review, error)
+	// ListReviews returns the book's reviews, oldest first.
+	ListReviews(ctx context.Context, bookID string) ([]Review, error)
+	DeleteReview(ctx context.Context, bookID, id string) error
+
+	// CreateAuthor stores a under a new ID and returns it with the ID set.
+	// It and UpdateAuthor fail with errAuthorExists when another author has
+	// the name, ignoring case; GetAuthor, UpdateAuthor and DeleteAuthor
+	// with errAuthorNotFound.
+	CreateAuthor(ctx context.Context, a Author) (Author, error)
+	GetAuthor(ctx context.Context, id string) (Author, error)
+	// ListAuthors returns every author in the order they were added.
+	ListAuthors(ctx context.Context) ([]Author, error)
+	// UpdateAuthor replaces the name and bio of a.ID, and the author's
+	// books take the new name.
+	UpdateAuthor(ctx context.Context, a Author) (Author, error)
+	// DeleteAuthor fails with errAuthorHasBooks while any book, deleted or
+	// not, links to the author.
+	DeleteAuthor(ctx context.Context, id string) error
+
+	// CreateLoan stores l, an open loan, under a new ID and returns it with
+	// the ID set. It fails with ErrBookNotFound when l.BookID isn't a book
+	// and errBookOnLoan when the book has an open loan already. Deleting a
+	// book deletes its loans.
+	CreateLoan(ctx context.Context, l Loan) (Loan, error)
+	// OpenLoan returns the book's open loan, or errBookNotOnLoan.
+	OpenLoan(ctx context.Context, bookID string) (Loan, error)
+	// ReturnLoan closes the loan id as of at, failing with errBookNotOnLoan
+	// if it isn't open.
+	ReturnLoan(ctx context.Context, id string, at time.Time) (Loan, error)
+	// ListLoans returns every loan, open or not, oldest first.
+	ListLoans(ctx context.Context) ([]Loan, error)
+}
+
+// SetRepository replaces where s's books are kept, e.g. with a fresh
+// NewMemoryRepository in tests. Call it before serving.
+func (s *Server) SetRepository(r BookRepository) {
+	s.repo = scoped(r)
+}
+
+// newRepository keeps books where books.store says: in memory, or in the
+// SQLite database at database.path, where they survive restarts, read
+// through a cache for books.cache_ttl.
+func newRepository(cfg *config.Config, hooks *server.Hooks) BookRepository {
+	if cfg.Books.Store != "sqlite" {
+		return NewMemoryRepository()
+	}
+	r := NewSQLiteRepository(server.OpenDB(cfg.Database.Path, hooks))
+	if cfg.Books.CacheTTL > 0 {
+		r = cached(r, cache.New("book", newCacheStore(cfg, hooks)), cfg.Books.CacheTTL)
+	}
+	return r
+}
+
+// memoryRepository keeps books in a map by ID, numbering them from 1, and
+// indexes them by author, year and ISBN, so working on one book, or on an
+// author's books, never walks them all. Authors are kept by ID and name.
+// Reads share the lock. Everything is lost on restart.
+type memoryRepository struct {
+	mu          sync.RWMutex
+	books       map[string]Book
+	ids         []string                   // of every book, in the order they were added
+	byAuthor    map[string]map[string]bool // author ID to the IDs of their books
+	byYear      map[int]map[string]bool    // year to the IDs of the books from it
+	byISBN      map[string]string          // ISBN to the ID of the book with it
+	categories  map[string]Category
+	reviews     map[string][]Review // by book ID, oldest first
+	authors     map[string]Author
+	authorNames map[string]string // lower-cased name to the ID of the author with it
+	loans       []Loan
+	seq         int
+	reviewSeq   int
+	authorSeq   int
+	loanSeq     int
+}
+
+func NewMemoryRepository() BookRepository {
+	return &memoryRepository{
+		books:       map[string]Book{},
+		byAuthor:    map[string]map[string]bool{},
+		byYear:      map[int]map[string]bool{},
+		byISBN:      map[string]string{},
+		categories:  map[string]Category{},
+		reviews:     map[string][]Review{},
+		authors:     map[string]Author{},
+		authorNames: map[string]string{},
+	}
+}
+
+// compareIDs orders IDs numbered from 1 the way their numbers go: the
+// shorter is the smaller.
+func compareIDs(a, b string) int {
+	return cmp.Or(cmp.Compare(len(a), len(b)), strings.Compare(a, b))
+}
+
+// putLocked stores b in place of the book with its ID, if any, and updates
+// the indexes.
+func (r *memoryRepository) putLocked(b Book) {
+	if old, ok := r.books[b.ID]; ok {
+		r.unindexLocked(old)
+	}
+	r.books[b.ID] = b
+	addTo(r.byAuthor, b.AuthorID, b.ID)
+	addTo(r.byYear, b.Year, b.ID)
+	if b.ISBN != "" {
+		r.byISBN[b.ISBN] = b.ID
+	}
+}
+
+func (r *memoryRepository) unindexLocked(b Book) {
+	removeFrom(r.byAuthor, b.AuthorID, b.ID)
+	removeFrom(r.byYear, b.Year, b.ID)
+	if r.byISBN[b.ISBN] == b.ID {
+		delete(r.byISBN, b.ISBN)
+	}
+}
+
+func addTo[K comparable](index map[K]map[string]bool, key K, id string) {
+	if index[key] == nil {
+		index[key] = map[string]bool{}
+	}
+	index[key][id] = true
+}
+
+func removeFrom[K comparable](index map[K]map[string]bool, key K, id string) {
+	delete(index[key], id)
+	if len(index[key]) == 0 {
+		delete(index, key)
+	}
+}
+
+// listLocked returns the books with the given IDs in the order they were
+// added.
+func (r *memoryRepository) listLocked(ids []string) []Book {
+	slices.SortFunc(ids, compareIDs)
+	out := make([]Book, len(ids))
+	for i, id := range ids {
+		out[i] = r.books[id]
+	}
+	return out
+}
+
+// resolveLocked drops b's repeated categories and checks the rest exist. It
+// copies b's slices, so callers can't change the stored book through theirs.
+func (r *memoryRepository) resolveLocked(b *Book) error {
+	var known, unknown []string
+	for _, slug := range b.Categories {
+		switch _, ok := r.categories[slug]; {
+		case !ok:
+			unknown = append(unknown, slug)
+		case !slices.Contains(known, slug):
+			known = append(known, slug)
+		}
+	}
+	if len(unknown) > 0 {
+		return unknownCategoriesError(unknown)
+	}
+	b.Categories = known
+	b.Tags = slices.Clone(b.Tags)
+	return nil
+}
+
+func (r *memoryRepository) Create(_ context.Context, b Book) (Book, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.resolveLocked(&b); err != nil {
+		return Book{}, err
+	}
+	if err := r.linkAuthorLocked(&b); err != nil {
+		return Book{}, err
+	}
+	b.ISBN = normalizeISBN(b.ISBN)
+	b.Tenant = tenant.Owner(b.Tenant)
+	if r.isbnTakenLocked(b.ISBN, "") {
+		return Book{}, errISBNExists
+	}
+	b.AverageRating, b.ReviewCount, b.Available, b.Version = 0, 0, true, 1
+	r.seq++
+	b.ID = strconv.Itoa(r.seq)
+	r.putLocked(b)
+	r.ids = append(r.ids, b.ID)
+	return b, nil
+}
+
+// isbnTakenLocked reports whether a book other than id has the ISBN.
+func (r *memoryRepository) isbnTakenLocked(isbn, id string) bool {
+	other, ok := r.byISBN[isbn]
+	return isbn != "" && ok && other != id
+}
+
+func (r *memoryRepository) Get(_ context.Context, id string) (Book, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	b, ok := r.books[id]
+	if !ok {
+		return Book{}, ErrBookNotFound
+	}
+	return b, nil
+}
+
+func (r *memoryRepository) List(context.Context) ([]Book, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]Book, len(r.ids))
+	for i, id := range r.ids {
+		out[i] = r.books[id]
+	}
+	return out, nil
+}
+
+func (r *memoryRepository) ListByAuthor(_ context.Context, authorID string) ([]Book, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.listLocked(slices.Collect(maps.Keys(r.byAuthor[authorID]))), nil
+}
+
+func (r *memoryRepository) ListByYears(_ context.Context, from, to int) ([]Book, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var ids []string
+	for year, books := range r.byYear {
+		if (from == 0 || year >= from) && (to == 0 || year <= to) {
+			ids = slices.AppendSeq(ids, maps.Keys(books))
+		}
+	}
+	return r.listLocked(ids), nil
+}
+
+func (r *memoryRepository) Update(_ context.Context, id string, fn func(*Book) error) (Book, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	old, ok := r.books[id]
+	if !ok {
+		return Book{}, ErrBookNotFound
+	}
+	b := old
+	if err := fn(&b); err != nil {
+		return Book{}, err
+	}
+	b.ID = id
+	b.AverageRating, b.ReviewCount, b.Available = old.AverageRating, old.ReviewCount, old.Available
+	b.Version = old.Version + 1
+	if err := r.resolveLocked(&b); err != nil {
+		return Book{}, err
+	}
+	if err := r.linkAuthorLocked(&b); err != nil {
+		return Book{}, err
+	}
+	b.ISBN = normalizeISBN(b.ISBN)
+	if r.isbnTakenLocked(b.ISBN, id) {
+		return Book{}, errISBNExists
+	}
+	r.putLocked(b)
+	return b, nil
+}
+
+func (r *memoryRepository) Delete(_ context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b, ok := r.books[id]
+	if !ok {
+		return ErrBookNotFound
+	}
+	r.unindexLocked(b)
+	delete(r.books, id)
+	if i, ok := slices.BinarySearchFunc(r.ids, id, compareIDs); ok {
+		r.ids = slices.Delete(r.ids, i, i+1)
+	}
+	delete(r.reviews, id)
+	r.loans = slices.DeleteFunc(r.loans, func(l Loan) bool { return l.BookID == id })
+	return nil
+}
+
+func (r *memoryRepository) CreateCategory(_ context.Context, cat Category) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.categories[cat.Slug]; ok {
+		return errCategoryExists
+	}
+	r.categories[cat.Slug] = cat
+	return nil
+}
+
+func (r *memoryRepository) GetCategory(_ context.Context, slug string) (Category, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	cat, ok := r.categories[slug]
+	if !ok {
+		return Category{}, errCategoryNotFound
+	}
+	return cat, nil
+}
+
+func (r *memoryRepository) ListCategories(context.Context) ([]Category, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := slices.Collect(maps.Values(r.categories))
+	slices.SortFunc(out, func(a, b Category) int { return strings.Compare(a.Slug, b.Slug) })
+	return out, nil
+}
+
+func (r *memoryRepository) UpdateCategory(_ context.Context, cat Category) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.categories[cat.Slug]; !ok {
+		return errCategoryNotFound
+	}
+	r.categories[cat.Slug] = cat
+	return nil
+}
+
+func (r *memoryRepository) DeleteCategory(_ context.Context, slug string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.categories[slug]; !ok {
+		return errCategoryNotFound
+	}
+	delete(r.categories, slug)
+	for id, b := range r.books {
+		if j := slices.Index(b.Categories, slug); j >= 0 {
+			// a copy handed out by List may share the slice
+			b.Categories = slices.Delete(slices.Clone(b.Categories), j, j+1)
+			r.books[id] = b
+		}
+	}
+	return nil
+}
+
+// rateLocked recomputes the rating of the book id from its reviews.
+func (r *memoryRepository) rateLocked(id string) {
+	sum := 0
+	for _, rv := range r.reviews[id] {
+		sum += rv.Rating
+	}
+	b := r.books[id]
+	b.AverageRating, b.ReviewCount = averageRating(sum, len(r.reviews[id])), len(r.reviews[id])
+	r.books[id] = b
+}
+
+// averageRating is sum/n to two decimals, or 0 for no ratings.
+func averageRating(sum, n int) float64 {
+	if n == 0 {
+		return 0
+	}
+	return math.Round(float64(sum)/float64(n)*100) / 100
+}
+
+func (r *memoryRepository) CreateReview(_ context.Context, rv Review) (Review, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.books[rv.BookID]; !ok {
+		return Review{}, ErrBookNotFound
+	}
+	r.reviewSeq++
+	rv.ID = strconv.Itoa(r.reviewSeq)
+	r.reviews[rv.BookID] = append(r.reviews[rv.BookID], rv)
+	r.rateLocked(rv.BookID)
+	return rv, nil
+}
+
+func (r *memoryRepository) GetReview(_ context.Context, bookID, id string) (Review, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, rv := range r.reviews[bookID] {
+		if rv.ID == id {
+			return rv, nil
+		}
+	}
+	return Review{}, errReviewNotFound
+}
+
+func (r *memoryRepository) ListReviews(_ context.Context, bookID string) ([]Review, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if _, ok := r.books[bookID]; !ok {
+		return nil, ErrBookNotFound
+	}
+	return append([]Review{}, r.reviews[bookID]...), nil
+}
+
+func (r *memoryRepository) DeleteReview(_ context.Context, bookID, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	j := slices.IndexFunc(r.reviews[bookID], func(rv Review) bool { return rv.ID == id })
+	if j < 0 {
+		return errReviewNotFound
+	}
+	r.reviews[bookID] = slices.Delete(r.reviews[bookID], j, j+1)
+	r.rateLocked(bookID)
+	return nil
+}
+
+// nameTakenLocked reports whether an author other than id is called name,
+// ignoring case.
+func (r *memoryRepository) nameTakenLocked(name, id string) bool {
+	other, ok := r.authorNames[strings.ToLower(name)]
+	return ok && other != id
+}
+
+// putAuthorLocked stores a in place of the author with its ID, if any.
+func (r *memoryRepository) putAuthorLocked(a Author) {
+	if old, ok := r.authors[a.ID]; ok {
+		delete(r.authorNames, strings.ToLower(old.Name))
+	}
+	r.authors[a.ID] = a
+	r.authorNames[strings.ToLower(a.Name)] = a.ID
+}
+
+// linkAuthorLocked links b to its author, adding one by the name in
+// b.Author if need be. It runs last, so a book that fails to store adds
+// no author.
+func (r *memoryRepository) linkAuthorLocked(b *Book) error {
+	if b.AuthorID != "" {
+		a, ok := r.authors[b.AuthorID]
+		if !ok {
+			return errUnknownAuthor
+		}
+		b.Author = a.Name
+		return nil
+	}
+	name := strings.TrimSpace(b.Author)
+	if name == "" {
+		return errUnknownAuthor
+	}
+	id, ok := r.authorNames[strings.ToLower(name)]
+	if !ok {
+		r.authorSeq++
+		id = strconv.Itoa(r.authorSeq)
+		r.putAuthorLocked(Author{ID: id, Name: name})
+	}
+	b.AuthorID, b.Author = id, r.authors[id].Name
+	return nil
+}
+
+func (r *memoryRepository) CreateAuthor(_ context.Context, a Author) (Author, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.nameTakenLocked(a.Name, "") {
+		return Author{}, errAuthorExists
+	}
+	r.authorSeq++
+	a.ID = strconv.Itoa(r.authorSeq)
+	r.putAuthorLocked(a)
+	return a, nil
+}
+
+func (r *memoryRepository) GetAuthor(_ context.Context, id string) (Author, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	a, ok := r.authors[id]
+	if !ok {
+		return Author{}, errAuthorNotFound
+	}
+	return a, nil
+}
+
+func (r *memoryRepository) ListAuthors(context.Context) ([]Author, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := slices.Collect(maps.Values(r.authors))
+	slices.SortFunc(out, func(a, b Author) int { return compareIDs(a.ID, b.ID) })
+	return out, nil
+}
+
+func (r *memoryRepository) UpdateAuthor(_ context.Context, a Author) (Author, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.authors[a.ID]; !ok {
+		return Author{}, errAuthorNotFound
+	}
+	if r.nameTakenLocked(a.Name, a.ID) {
+		return Author{}, errAuthorExists
+	}
+	r.putAuthorLocked(a)
+	for id := range r.byAuthor[a.ID] {
+		b := r.books[id]
+		b.Author = a.Name
+		r.books[id] = b
+	}
+	return a, nil
+}
+
+func (r *memoryRepository) DeleteAuthor(_ context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	a, ok := r.authors[id]
+	if !ok {
+		return errAuthorNotFound
+	}
+	if len(r.byAuthor[id]) > 0 {
+		return errAuthorHasBooks
+	}
+	delete(r.authors, id)
+	delete(r.authorNames, strings.ToLower(a.Name))
+	return nil
+}
+
+func (r *memoryRepository) CreateLoan(_ context.Context, l Loan) (Loan, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b, ok := r.books[l.BookID]
+	if !ok {
+		return Loan{}, ErrBookNotFound
+	}
+	if !b.Available {
+		return Loan{}, errBookOnLoan
+	}
+	r.loanSeq++
+	l.ID, l.ReturnedAt = strconv.Itoa(r.loanSeq), time.Time{}
+	r.loans = append(r.loans, l)
+	b.Available = false
+	r.books[b.ID] = b
+	return l, nil
+}
+
+func (r *memoryRepository) OpenLoan(_ context.Context, bookID string) (Loan, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, l := range r.loans {
+		if l.BookID == bookID && !l.Returned() {
+			return l, nil
+		}
+	}
+	return Loan{}, errBookNotOnLoan
+}
+
+func (r *memoryRepository) ReturnLoan(_ context.Context, id string, at time.Time) (Loan, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	j := slices.IndexFunc(r.loans, func(l Loan) bool { return l.ID == id && !l.Returned() })
+	if j < 0 {
+		return Loan{}, errBookNotOnLoan
+	}
+	r.loans[j].ReturnedAt = at
+	if b, ok := r.books[r.loans[j].BookID]; ok {
+		b.Available = true
+		r.books[b.ID] = b
+	}
+	return r.loans[j], nil
+}
+
+func (r *memoryRepository) ListLoans(context.Context) ([]Loan, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]Loan{}, r.loans...), nil
+}
+
+// sqliteRepository keeps books in the books table, their categories in
+// book_categories, the categories themselves in categories, reviews in
+// book_reviews, authors in authors and loans in book_loans. Book, review,
+// author and loan IDs are the tables' row IDs.
+type sqliteRepository struct {
+	db *sql.DB
+}
+
+// NewSQLiteRepository keeps books in db, whose schema must be migrated.
+func NewSQLiteRepository(db *sql.DB) BookRepository {
+	return sqliteRepository{db: db}
+}
+
+// bookColumns work out the rating from book_reviews, and availability from
+// book_loans, as they go.
+const bookColumns = `id, title, author, author_id, year, isbn, owner_id, tags, deleted_at, version, tenant,
+	(SELECT ROUND(COALESCE(AVG(rating), 0), 2) FROM book_reviews WHERE book_id = books.id),
+	(SELECT COUNT(*) FROM book_reviews WHERE book_id = books.id),
+	NOT EXISTS (SELECT 1 FROM book_loans WHERE book_id = books.id AND returned_at IS NULL)`
+
+func scanBook(row interface{ Scan(...any) error }) (Book, error) {
+	var b Book
+	var authorID, isbn, owner sql.NullString
+	var tags string
+	var deletedAt sql.NullTime
+	err := row.Scan(&b.ID, &b.Title, &b.Author, &authorID, &b.Year, &isbn, &owner, &tags, &deletedAt, &b.Version, &b.Tenant, &b.AverageRating, &b.ReviewCount, &b.Available)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Book{}, ErrBookNotFound
+	}
+	if err != nil {
+		return Book{}, err
+	}
+	b.AuthorID, b.ISBN, b.OwnerID, b.DeletedAt = authorID.String, isbn.String, owner.String, deletedAt.Time
+	if err := json.Unmarshal([]byte(tags), &b.Tags); err != nil {
+		return Book{}, err
+	}
+	return b, nil
+}
+
+// nullString stores "" as NULL.
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// normalizeISBN drops the hyphens and spaces an ISBN may be written with,
+// and upper-cases an ISBN-10's X check digit, so each book has one spelling.
+func normalizeISBN(s string) string {
+	return strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(s))
+}
+
+// nullTime stores the zero time as NULL.
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
+
+// bookCategories returns the category slugs of the books matching where, in
+// the order they were given, by book ID.
+func bookCategories(ctx context.Context, q database.Querier, where string, args ...any) (map[string][]string, error) {
+	rows, err := q.QueryContext(ctx,
+		`SELECT book_id, category_slug FROM book_categories `+where+` ORDER BY book_id, position`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := map[string][]string{}
+	for rows.Next() {
+		var id, slug string
+		if err := rows.Scan(&id, &slug); err != nil {
+			return nil, err
+		}
+		out[id] = append(out[id], slug)
+	}
+	return out, rows.Err()
+}
+
+// selectBook loads the book id with its categories.
+func selectBook(ctx context.Context, q database.Querier, id string) (Book, error) {
+	b, err := scanBook(q.QueryRowContext(ctx, `SELECT `+bookColumns+` FROM books WHERE id = ?`, id))
+	if err != nil {
+		return Book{}, err
+	}
+	cats, err := bookCategories(ctx, q, `WHERE book_id = ?`, b.ID)
+	if err != nil {
+		return Book{}, err
+	}
+	b.Categories = cats[b.ID]
+	return b, nil
+}
+
+// setCategories drops b's repeated categories, checks the rest exist and
+// replaces b's rows in book_categories with them.
+func setCategories(ctx context.Context, tx *sql.Tx, b *Book) error {
+	var known, unknown []string
+	for _, slug := range b.Categories {
+		if slices.Contains(known, slug) || slices.Contains(unknown, slug) {
+			continue
+		}
+		var n int
+		if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM categories WHERE slug = ?`, slug).Scan(&n); err != nil {
+			return err
+		}
+		if n == 0 {
+			unknown = append(unknown, slug)
+		} else {
+			known = append(known, slug)
+		}
+	}
+	if len(unknown) > 0 {
+		return unknownCategoriesError(unknown)
+	}
+	b.Categories = known
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM book_categories WHERE book_id = ?`, b.ID); err != nil {
+		return err
+	}
+	for i, slug := range known {
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO book_categories (book_id, category_slug, position) VALUES (?, ?, ?)`, b.ID, slug, i)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// marshalTags stores no tags as an empty array, so the column is never NULL.
+func marshalTags(tags []string) string {
+	if tags == nil {
+		tags = []string{}
+	}
+	data, _ := json.Marshal(tags)
+	return string(data)
+}
+
+func (r sqliteRepository) Create(ctx context.Context, b Book) (Book, error) {
+	b.AverageRating, b.ReviewCount, b.Available, b.Version = 0, 0, true, 1
+	b.ISBN = normalizeISBN(b.ISBN)
+	b.Tenant = tenant.Owner(b.Tenant)
+	err := database.WithTx(ctx, r.db, func(tx *sql.Tx) error {
+		if err := linkAuthor(ctx, tx, &b); err != nil {
+			return err
+		}
+		res, err := tx.ExecContext(ctx,
+			`INSERT INTO books (title, author, author_id, year, isbn, owner_id, tags, deleted_at, tenant) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			b.Title, b.Author, b.AuthorID, b.Year, nullString(b.ISBN), nullString(b.OwnerID), marshalTags(b.Tags), nullTime(b.DeletedAt), b.Tenant)
+		if database.IsUniqueViolation(err) {
+			return errISBNExists
+		}
+		if err != nil {
+			return err
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return err
+		}
+		b.ID = strconv.FormatInt(id, 10)
+		return setCategories(ctx, tx, &b)
+	})
+	if err != nil {
+		return Book{}, err
+	}
+	return b, nil
+}
+
+func (r sqliteRepository) Get(ctx context.Context, id string) (Book, error) {
+	return selectBook(ctx, r.db, id)
+}
+
+func (r sqliteRepository) List(ctx context.Context) ([]Book, error) {
+	return r.listWhere(ctx, `TRUE`)
+}
+
+func (r sqliteRepository) ListByAuthor(ctx context.Context, authorID string) ([]Book, error) {
+	return r.listWhere(ctx, `author_id = ?`, authorID)
+}
+
+func (r sqliteRepository) ListByYears(ctx context.Context, from, to int) ([]Book, error) {
+	// conditions only for the ends given, so books_year is used
+	where, args := `TRUE`, []any{}
+	if from != 0 {
+		where, args = where+` AND year >= ?`, append(args, from)
+	}
+	if to != 0 {
+		where, args = where+` AND year <= ?`, append(args, to)
+	}
+	return r.listWhere(ctx, where, args...)
+}
+
+// listWhere returns the books matching the SQL condition where, in ID
+// order.
+func (r sqliteRepository) listWhere(ctx context.Context, where string, args ...any) ([]Book, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+bookColumns+` FROM books WHERE `+where+` ORDER BY id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Book
+	for rows.Next() {
+		b, err := scanBook(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// rows is done with the one connection by now
+	cats, err := bookCategories(ctx, r.db, `WHERE book_id IN (SELECT id FROM books WHERE `+where+`)`, args...)
+	if err != nil {
+		return nil, err
+	}
+	for i := range out {
+		out[i].Categories = cats[out[i].ID]
+	}
+	return out, nil
+}
+
+func (r sqliteRepository) Update(ctx context.Context, id string, fn func(*Book) error) (Book, error) {
+	var b Book
+	err := database.WithTx(ctx, r.db, func(tx *sql.Tx) error {
+		var err error
+		if b, err = selectBook(ctx, tx, id); err != nil {
+			return err
+		}
+		old := b
+		if err := fn(&b); err != nil {
+			return err
+		}
+		b.ID = id
+		b.AverageRating, b.ReviewCount, b.Available, b.Version = old.AverageRating, old.ReviewCount, old.Available, old.Version+1
+		if err := linkAuthor(ctx, tx, &b); err != nil {
+			return err
+		}
+		b.ISBN = normalizeISBN(b.ISBN)
+		_, err = tx.ExecContext(ctx,
+			`UPDATE books SET title = ?, author = ?, author_id = ?, year = ?, isbn = ?, owner_id = ?, tags = ?, deleted_at = ?, version = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`,
+			b.Title, b.Author, b.AuthorID, b.Year, nullString(b.ISBN), nullString(b.OwnerID), marshalTags(b.Tags), nullTime(b.DeletedAt), b.Version, id)
+		if database.IsUniqueViolation(err) {
+			return errISBNExists
+		}
+		if err != nil {
+			return err
+		}
+		return setCategories(ctx, tx, &b)
+	})
+	if err != nil {
+		return Book{}, err
+	}
+	return b, nil
+}
+
+// rowsAffected turns an UPDATE or DELETE that matched nothing into notFound.
+func rowsAffected(res sql.Result, err error, notFound error) error {
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return notFound
+	}
+	return nil
+}
+
+func (r sqliteRepository) Delete(ctx context.Context, id string) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM books WHERE id = ?`, id)
+	return rowsAffected(res, err, ErrBookNotFound)
+}
+
+const categoryColumns = `slug, name, description`
+
+func scanCategory(row interface{ Scan(...any) error }) (Category, error) {
+	var cat Category
+	err := row.Scan(&cat.Slug, &cat.Name, &cat.Description)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Category{}, errCategoryNotFound
+	}
+	return cat, err
+}
+
+func (r sqliteRepository) CreateCategory(ctx context.Context, cat Category) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO categories (`+categoryColumns+`) VALUES (?, ?, ?)`, cat.Slug, cat.Name, cat.Description)
+	if database.IsUniqueViolation(err) {
+		return errCategoryExists
+	}
+	return err
+}
+
+func (r sqliteRepository) GetCategory(ctx context.Context, slug string) (Category, error) {
+	return scanCategory(r.db.QueryRowContext(ctx, `SELECT `+categoryColumns+` FROM categories WHERE slug = ?`, slug))
+}
+
+func (r sqliteRepository) ListCategories(ctx context.Context) ([]Category, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+categoryColumns+` FROM categories ORDER BY slug`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []Category{}
+	for rows.Next() {
+		cat, err := scanCategory(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, cat)
+	}
+	return out, rows.Err()
+}
+
+func (r sqliteRepository) UpdateCategory(ctx context.Context, cat Category) error {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE categories SET name = ?, description = ? WHERE slug = ?`, cat.Name, cat.Description, cat.Slug)
+	return rowsAffected(res, err, errCategoryNotFound)
+}
+
+// DeleteCategory relies on book_categories cascading the delete.
+func (r sqliteRepository) DeleteCategory(ctx context.Context, slug string) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM categories WHERE slug = ?`, slug)
+	return rowsAffected(res, err, errCategoryNotFound)
+}
+
+const reviewColumns = `id, book_id, rating, text, author, created_at`
+
+func scanReview(row interface{ Scan(...any) error }) (Review, error) {
+	var rv Review
+	err := row.Scan(&rv.ID, &rv.BookID, &rv.Rating, &rv.Text, &rv.Author, &rv.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Review{}, errReviewNotFound
+	}
+	return rv, err
+}
+
+// bookExists fails with ErrBookNotFound unless the book id is stored.
+func bookExists(ctx context.Context, q database.Querier, id string) error {
+	var n int
+	if err := q.QueryRowContext(ctx, `SELECT COUNT(*) FROM books WHERE id = ?`, id).Scan(&n); err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrBookNotFound
+	}
+	return nil
+}
+
+func (r sqliteRepository) CreateReview(ctx context.Context, rv Review) (Review, error) {
+	err := database.WithTx(ctx, r.db, func(tx *sql.Tx) error {
+		if err := bookExists(ctx, tx, rv.BookID); err != nil {
+			return err
+		}
+		res, err := tx.ExecContext(ctx,
+			`INSERT INTO book_reviews (book_id, rating, text, author, created_at) VALUES (?, ?, ?, ?, ?)`,
+			rv.BookID, rv.Rating, rv.Text, rv.Author, rv.CreatedAt)
+		if err != nil {
+			return err
+		}
+		id, err := res.LastInsertId()
+		rv.ID = strconv.FormatInt(id, 10)
+		return err
+	})
+	if err != nil {
+		return Review{}, err
+	}
+	return rv, nil
+}
+
+func (r sqliteRepository) GetReview(ctx context.Context, bookID, id string) (Review, error) {
+	return scanReview(r.db.QueryRowContext(ctx,
+		`SELECT `+reviewColumns+` FROM book_reviews WHERE id = ? AND book_id = ?`, id, bookID))
+}
+
+func (r sqliteRepository) ListReviews(ctx context.Context, bookID string) ([]Review, error) {
+	if err := bookExists(ctx, r.db, bookID); err != nil {
+		return nil, err
+	}
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+reviewColumns+` FROM book_reviews WHERE book_id = ? ORDER BY id`, bookID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []Review{}
+	for rows.Next() {
+		rv, err := scanReview(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, rv)
+	}
+	return out, rows.Err()
+}
+
+func (r sqliteRepository) DeleteReview(ctx context.Context, bookID, id string) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM book_reviews WHERE id = ? AND book_id = ?`, id, bookID)
+	return rowsAffected(res, err, errReviewNotFound)
+}
+
+const authorColumns = `id, name, bio`
+
+func scanAuthor(row interface{ Scan(...any) error }) (Author, error) {
+	var a Author
+	err := row.Scan(&a.ID, &a.Name, &a.Bio)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Author{}, errAuthorNotFound
+	}
+	return a, err
+}
+
+// insertAuthor stores a under a new ID.
+func insertAuthor(ctx context.Context, q database.Querier, a Author) (Author, error) {
+	res, err := q.ExecContext(ctx, `INSERT INTO authors (name, bio) VALUES (?, ?)`, a.Name, a.Bio)
+	if database.IsUniqueViolation(err) {
+		return Author{}, errAuthorExists
+	}
+	if err != nil {
+		return Author{}, err
+	}
+	id, err := res.LastInsertId()
+	a.ID = strconv.FormatInt(id, 10)
+	return a, err
+}
+
+// linkAuthor is memoryRepository.linkAuthorLocked for SQLite; an author it
+// adds goes with the transaction if the book fails to store.
+func linkAuthor(ctx context.Context, tx *sql.Tx, b *Book) error {
+	if b.AuthorID != "" {
+		err := tx.QueryRowContext(ctx, `SELECT id, name FROM authors WHERE id = ?`, b.AuthorID).Scan(&b.AuthorID, &b.Author)
+		if errors.Is(err, sql.ErrNoRows) {
+			return errUnknownAuthor
+		}
+		return err
+	}
+	name := strings.TrimSpace(b.Author)
+	if name == "" {
+		return errUnknownAuthor
+	}
+	a, err := scanAuthor(tx.QueryRowContext(ctx, `SELECT `+authorColumns+` FROM authors WHERE name = ?`, name))
+	if errors.Is(err, errAuthorNotFound) {
+		a, err = insertAuthor(ctx, tx, Author{Name: name})
+	}
+	if err != nil {
+		return err
+	}
+	b.AuthorID, b.Author = a.ID, a.Name
+	return nil
+}
+
+func (r sqliteRepository) CreateAuthor(ctx context.Context, a Author) (Author, error) {
+	return insertAuthor(ctx, r.db, a)
+}
+
+func (r sqliteRepository) GetAuthor(ctx context.Context, id string) (Author, error) {
+	return scanAuthor(r.db.QueryRowContext(ctx, `SELECT `+authorColumns+` FROM authors WHERE id = ?`, id))
+}
+
+func (r sqliteRepository) ListAuthors(ctx context.Context) ([]Author, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+authorColumns+` FROM authors ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []Author{}
+	for rows.Next() {
+		a, err := scanAuthor(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
+
+func (r sqliteRepository) UpdateAuthor(ctx context.Context, a Author) (Author, error) {
+	err := database.WithTx(ctx, r.db, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, `UPDATE authors SET name = ?, bio = ? WHERE id = ?`, a.Name, a.Bio, a.ID)
+		if database.IsUniqueViolation(err) {
+			return errAuthorExists
+		}
+		if err := rowsAffected(res, err, errAuthorNotFound); err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `UPDATE books SET author = ? WHERE author_id = ?`, a.Name, a.ID)
+		return err
+	})
+	if err != nil {
+		return Author{}, err
+	}
+	return a, nil
+}
+
+func (r sqliteRepository) DeleteAuthor(ctx context.Context, id string) error {
+	return database.WithTx(ctx, r.db, func(tx *sql.Tx) error {
+		var n int
+		if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM books WHERE author_id = ?`, id).Scan(&n); err != nil {
+			return err
+		}
+		if n > 0 {
+			return errAuthorHasBooks
+		}
+		res, err := tx.ExecContext(ctx, `DELETE FROM authors WHERE id = ?`, id)
+		return rowsAffected(res, err, errAuthorNotFound)
+	})
+}
+
+const loanColumns = `id, book_id, borrower, checked_out_at, due_at, returned_at`
+
+func scanLoan(row interface{ Scan(...any) error }) (Loan, error) {
+	var l Loan
+	var returnedAt sql.NullTime
+	err := row.Scan(&l.ID, &l.BookID, &l.Borrower, &l.CheckedOutAt, &l.DueAt, &returnedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Loan{}, errBookNotOnLoan
+	}
+	l.ReturnedAt = returnedAt.Time
+	return l, err
+}
+
+// CreateLoan leaves double loans to the unique index on open loans.
+func (r sqliteRepository) CreateLoan(ctx context.Context, l Loan) (Loan, error) {
+	l.ReturnedAt = time.Time{}
+	err := database.WithTx(ctx, r.db, func(tx *sql.Tx) error {
+		if err := bookExists(ctx, tx, l.BookID); err != nil {
+			return err
+		}
+		res, err := tx.ExecContext(ctx,
+			`INSERT INTO book_loans (book_id, borrower, checked_out_at, due_at) VALUES (?, ?, ?, ?)`,
+			l.BookID, l.Borrower, l.CheckedOutAt, l.DueAt)
+		if database.IsUniqueViolation(err) {
+			return errBookOnLoan
+		}
+		if err != nil {
+			return err
+		}
+		id, err := res.LastInsertId()
+		l.ID = strconv.FormatInt(id, 10)
+		return err
+	})
+	if err != nil {
+		return Loan{}, err
+	}
+	return l, nil
+}
+
+func (r sqliteRepository) OpenLoan(ctx context.Context, bookID string) (Loan, error) {
+	return scanLoan(r.db.QueryRowContext(ctx,
+		`SELECT `+loanColumns+` FROM book_loans WHERE book_id = ? AND returned_at IS NULL`, bookID))
+}
+
+func (r sqliteRepository) ReturnLoan(ctx context.Context, id string, at time.Time) (Loan, error) {
+	var l Loan
+	err := database.WithTx(ctx, r.db, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, `UPDATE book_loans SET returned_at = ? WHERE id = ? AND returned_at IS NULL`, at, id)
+		if err := rowsAffected(res, err, errBookNotOnLoan); err != nil {
+			return err
+		}
+		l, err = scanLoan(tx.QueryRowContext(ctx, `SELECT `+loanColumns+` FROM book_loans WHERE id = ?`, id))
+		return err
+	})
+	if err != nil {
+		return Loan{}, err
+	}
+	return l, nil
+}
+
+func (r sqliteRepository) ListLoans(ctx context.Context) ([]Loan, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+loanColumns+` FROM book_loans ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []Loan{}
+	for rows.Next() {
+		l, err := scanLoan(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, l)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,118 @@
+package books
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/apperror"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/auth"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/negotiate"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/pagination"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/query"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/rbac"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/tracing"
+)
+
+// Review is one reader's rating of a book. Anyone can read reviews; writing
+// one takes a token from POST /login, whose username becomes the author.
+type Review struct {
+	ID        string    `json:"id" xml:"id"`
+	BookID    string    `json:"book_id" xml:"book_id"`
+	Rating    int       `json:"rating" xml:"rating" binding:"required,min=1,max=5"`
+	Text      string    `json:"text,omitempty" xml:"text" binding:"max=2000"`
+	Author    string    `json:"author" xml:"author"`
+	CreatedAt time.Time `json:"created_at" xml:"created_at"`
+}
+
+var errNotYourReview = apperror.Forbidden("only its author or a moderator can delete a review")
+
+// listReviews pages through the book's reviews, oldest first. A deleted
+// book's reviews are kept, but hidden with it, here and below.
+func (s *Server) listReviews(c *gin.Context) {
+	req, err := query.Parse[Review](c, nil, "", nil)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	id := c.Param("id")
+	ctx, span := tracing.Start(c.Request.Context(), "books.store.list_reviews", attribute.String("book.id", id))
+	_, err = s.Get(ctx, id)
+	var all []Review
+	if err == nil {
+		all, err = s.repo.ListReviews(ctx, id)
+	}
+	span.End()
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	pagination.Write(c, pagination.NewPage(all, req.Page))
+}
+
+// createReview adds the signed-in user's review. Its rating counts towards
+// the book's at once.
+func (s *Server) createReview(c *gin.Context) {
+	var input Review
+	if err := negotiate.Bind(c, &input); err != nil {
+		middleware.BindError(c, err)
+		return
+	}
+	id := c.Param("id")
+	ctx, span := tracing.Start(c.Request.Context(), "books.store.create_review", attribute.String("book.id", id))
+	defer span.End()
+	if _, err := s.Get(ctx, id); err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	rv, err := s.repo.CreateReview(ctx, Review{
+		BookID:    id,
+		Rating:    input.Rating,
+		Text:      input.Text,
+		Author:    principal(c).Username,
+		CreatedAt: s.now().UTC(),
+	})
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	// the list shows each book's rating
+	s.invalidateList()
+	negotiate.Render(c, http.StatusCreated, rv)
+}
+
+// deleteReview lets the review's author take it back, and moderators
+// (reviews:moderate) remove anyone's.
+func (s *Server) deleteReview(c *gin.Context) {
+	bookID, id := c.Param("id"), c.Param("review")
+	ctx, span := tracing.Start(c.Request.Context(), "books.store.delete_review",
+		attribute.String("book.id", bookID), attribute.String("review.id", id))
+	defer span.End()
+	_, err := s.Get(ctx, bookID)
+	var rv Review
+	if err == nil {
+		rv, err = s.repo.GetReview(ctx, bookID, id)
+	}
+	if err == nil && rv.Author != principal(c).Username && !principal(c).Can(rbac.ReviewsModerate) {
+		err = errNotYourReview
+	}
+	if err == nil {
+		err = s.repo.DeleteReview(ctx, bookID, id)
+	}
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	s.invalidateList()
+	c.Status(http.StatusNoContent)
+}
+
+// principal is the signed-in user, the zero UserInfo for anonymous
+// requests.
+func principal(c *gin.Context) auth.UserInfo {
+	u, _ := auth.CurrentUser(c)
+	return u
+}
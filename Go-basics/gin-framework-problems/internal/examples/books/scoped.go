@@ -0,0 +1,214 @@
+package books
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/tenant"
+)
+
+// scopedRepository keeps each tenant's books from the others: a book is
+// created for the tenant of the context, and to requests of any other
+// tenant it, its reviews and its loans are not found. An author is hidden
+// from a tenant when all of their books belong to others. Categories are
+// a catalog every tenant shares, and ISBNs stay unique across tenants.
+// Contexts without a tenant, as with tenancy off or in scheduled tasks, see
+// everything.
+type scopedRepository struct {
+	BookRepository
+}
+
+func scoped(r BookRepository) BookRepository {
+	return scopedRepository{r}
+}
+
+func (s scopedRepository) Create(ctx context.Context, b Book) (Book, error) {
+	b.Tenant = tenant.Assign(ctx)
+	return s.BookRepository.Create(ctx, b)
+}
+
+func (s scopedRepository) Get(ctx context.Context, id string) (Book, error) {
+	b, err := s.BookRepository.Get(ctx, id)
+	if err == nil && !tenant.Visible(ctx, b.Tenant) {
+		return Book{}, ErrBookNotFound
+	}
+	return b, err
+}
+
+func (s scopedRepository) List(ctx context.Context) ([]Book, error) {
+	return visibleBooks(ctx)(s.BookRepository.List(ctx))
+}
+
+func (s scopedRepository) ListByAuthor(ctx context.Context, authorID string) ([]Book, error) {
+	return visibleBooks(ctx)(s.BookRepository.ListByAuthor(ctx, authorID))
+}
+
+func (s scopedRepository) ListByYears(ctx context.Context, from, to int) ([]Book, error) {
+	return visibleBooks(ctx)(s.BookRepository.ListByYears(ctx, from, to))
+}
+
+// visibleBooks returns a filter of a list result down to the books of
+// ctx's tenant.
+func visibleBooks(ctx context.Context) func([]Book, error) ([]Book, error) {
+	return func(list []Book, err error) ([]Book, error) {
+		if err != nil {
+			return nil, err
+		}
+		return slices.DeleteFunc(list, func(b Book) bool { return !tenant.Visible(ctx, b.Tenant) }), nil
+	}
+}
+
+func (s scopedRepository) Update(ctx context.Context, id string, fn func(*Book) error) (Book, error) {
+	return s.BookRepository.Update(ctx, id, func(b *Book) error {
+		if !tenant.Visible(ctx, b.Tenant) {
+			return ErrBookNotFound
+		}
+		owner := b.Tenant
+		err := fn(b)
+		b.Tenant = owner
+		return err
+	})
+}
+
+func (s scopedRepository) Delete(ctx context.Context, id string) error {
+	if _, err := s.Get(ctx, id); err != nil {
+		return err
+	}
+	return s.BookRepository.Delete(ctx, id)
+}
+
+func (s scopedRepository) CreateReview(ctx context.Context, rv Review) (Review, error) {
+	if _, err := s.Get(ctx, rv.BookID); err != nil {
+		return Review{}, err
+	}
+	return s.BookRepository.CreateReview(ctx, rv)
+}
+
+func (s scopedRepository) GetReview(ctx context.Context, bookID, id string) (Review, error) {
+	if _, err := s.Get(ctx, bookID); err != nil {
+		return Review{}, err
+	}
+	return s.BookRepository.GetReview(ctx, bookID, id)
+}
+
+func (s scopedRepository) ListReviews(ctx context.Context, bookID string) ([]Review, error) {
+	if _, err := s.Get(ctx, bookID); err != nil {
+		return nil, err
+	}
+	return s.BookRepository.ListReviews(ctx, bookID)
+}
+
+func (s scopedRepository) DeleteReview(ctx context.Context, bookID, id string) error {
+	if _, err := s.Get(ctx, bookID); err != nil {
+		return err
+	}
+	return s.BookRepository.DeleteReview(ctx, bookID, id)
+}
+
+func (s scopedRepository) CreateLoan(ctx context.Context, l Loan) (Loan, error) {
+	if _, err := s.Get(ctx, l.BookID); err != nil {
+		return Loan{}, err
+	}
+	return s.BookRepository.CreateLoan(ctx, l)
+}
+
+func (s scopedRepository) OpenLoan(ctx context.Context, bookID string) (Loan, error) {
+	if _, err := s.Get(ctx, bookID); err != nil {
+		return Loan{}, err
+	}
+	return s.BookRepository.OpenLoan(ctx, bookID)
+}
+
+// ReturnLoan takes a loan ID, so it finds the book among the loans ctx may
+// see; another tenant's loan isn't open as far as ctx is concerned.
+func (s scopedRepository) ReturnLoan(ctx context.Context, id string, at time.Time) (Loan, error) {
+	if _, ok := tenant.FromContext(ctx); ok {
+		loans, err := s.ListLoans(ctx)
+		if err != nil {
+			return Loan{}, err
+		}
+		if !slices.ContainsFunc(loans, func(l Loan) bool { return l.ID == id }) {
+			return Loan{}, errBookNotOnLoan
+		}
+	}
+	return s.BookRepository.ReturnLoan(ctx, id, at)
+}
+
+func (s scopedRepository) ListLoans(ctx context.Context) ([]Loan, error) {
+	loans, err := s.BookRepository.ListLoans(ctx)
+	if _, scopedCtx := tenant.FromContext(ctx); err != nil || !scopedCtx {
+		return loans, err
+	}
+	books, err := s.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	visible := map[string]bool{}
+	for _, b := range books {
+		visible[b.ID] = true
+	}
+	return slices.DeleteFunc(loans, func(l Loan) bool { return !visible[l.BookID] }), nil
+}
+
+func (s scopedRepository) ListAuthors(ctx context.Context) ([]Author, error) {
+	authors, err := s.BookRepository.ListAuthors(ctx)
+	if err != nil {
+		return nil, err
+	}
+	hidden, err := s.hiddenAuthors(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(authors, func(a Author) bool { return hidden[a.ID] }), nil
+}
+
+func (s scopedRepository) GetAuthor(ctx context.Context, id string) (Author, error) {
+	hidden, err := s.hiddenAuthors(ctx)
+	if err != nil {
+		return Author{}, err
+	}
+	if hidden[id] {
+		return Author{}, errAuthorNotFound
+	}
+	return s.BookRepository.GetAuthor(ctx, id)
+}
+
+func (s scopedRepository) UpdateAuthor(ctx context.Context, a Author) (Author, error) {
+	if _, err := s.GetAuthor(ctx, a.ID); err != nil {
+		return Author{}, err
+	}
+	return s.BookRepository.UpdateAuthor(ctx, a)
+}
+
+func (s scopedRepository) DeleteAuthor(ctx context.Context, id string) error {
+	if _, err := s.GetAuthor(ctx, id); err != nil {
+		return err
+	}
+	return s.BookRepository.DeleteAuthor(ctx, id)
+}
+
+// hiddenAuthors are the IDs of the authors with books, none of them ctx's
+// tenant's.
+func (s scopedRepository) hiddenAuthors(ctx context.Context) (map[string]bool, error) {
+	hidden := map[string]bool{}
+	if _, ok := tenant.FromContext(ctx); !ok {
+		return hidden, nil
+	}
+	all, err := s.BookRepository.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	shown := map[string]bool{}
+	for _, b := range all {
+		if tenant.Visible(ctx, b.Tenant) {
+			shown[b.AuthorID] = true
+		}
+	}
+	for _, b := range all {
+		if !shown[b.AuthorID] {
+			hidden[b.AuthorID] = true
+		}
+	}
+	return hidden, nil
+}
@@ -0,0 +1,123 @@
+package books
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/cache"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/config"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/auth"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/files"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/httpclient"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/jobs"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/mail"
+)
+
+// Options are what a Server depends on. Zero fields take the defaults noted.
+type Options struct {
+	Repository    BookRepository   // a NewMemoryRepository
+	Pool          *jobs.Pool       // runs imports; none refuses them
+	MaxCoverBytes int64            // 5 MiB
+	LookupURL     string           // https://openlibrary.org
+	LookupClient  *http.Client     // with a 3s timeout
+	ListCache     *cache.Responses // caches nothing
+	Mailer        *mail.Mailer     // none sends no overdue reminders
+	BaseURL       string           // where reminders link to
+	Auth          *auth.Server     // an auth.NewServer with its demo accounts
+	UploadDir     string           // config.Default()'s storage.upload_dir
+	Now           func() time.Time // time.Now
+}
+
+// Server is the books CRUD example: its catalog and what serving it needs.
+// Every Server has its own, so tests and several instances in one process
+// don't share state. Other examples that share these books are handed the
+// Server, or build one with NewCatalog.
+type Server struct {
+	// keeps the books; NewCatalog picks it from books.store
+	repo BookRepository
+
+	// signs in reviewers and borrowers, and has their addresses
+	auth *auth.Server
+
+	// keeps the covers, under coverDir
+	covers files.Dir
+
+	// what loans, reviews, deletions and snapshots are timed by
+	now func() time.Time
+
+	// runs imports
+	pool *jobs.Pool
+
+	maxCoverBytes int64
+
+	// where ISBNs are looked up; an empty lookupURL turns lookups off
+	lookupURL    string
+	lookupClient *http.Client
+
+	// holds GET /books answers when response_cache is enabled; nil caches
+	// nothing
+	listCache *cache.Responses
+
+	// sends overdue loan reminders linking to baseURL; NewCatalog queues
+	// them on pool
+	mailer  *mail.Mailer
+	baseURL string
+
+	// the ranking the rating_aggregates task keeps, so GET /books/top-rated
+	// doesn't average every book's reviews on each request
+	topRated struct {
+		sync.RWMutex
+		books    []Book
+		computed time.Time // zero until the first run
+	}
+}
+
+// NewServer returns a Server with opts' dependencies.
+func NewServer(opts Options) *Server {
+	s := &Server{
+		maxCoverBytes: opts.MaxCoverBytes,
+		lookupURL:     strings.TrimSuffix(opts.LookupURL, "/"),
+		lookupClient:  opts.LookupClient,
+		listCache:     opts.ListCache,
+		mailer:        opts.Mailer,
+		baseURL:       opts.BaseURL,
+		auth:          opts.Auth,
+		covers:        files.Dir(opts.UploadDir),
+		now:           opts.Now,
+	}
+	r := opts.Repository
+	if r == nil {
+		r = NewMemoryRepository()
+	}
+	s.SetRepository(r)
+	if opts.Pool != nil {
+		s.usePool(opts.Pool)
+	}
+	if s.maxCoverBytes == 0 {
+		s.maxCoverBytes = 5 << 20
+	}
+	if s.lookupURL == "" {
+		s.lookupURL = "https://openlibrary.org"
+	}
+	if s.lookupClient == nil {
+		s.lookupClient = httpclient.New("openlibrary", httpclient.WithTimeout(3*time.Second))
+	}
+	if s.auth == nil {
+		s.auth = auth.NewServer(auth.Options{})
+	}
+	if s.covers == "" {
+		s.covers = files.Dir(config.Default().Storage.UploadDir)
+	}
+	if s.now == nil {
+		s.now = time.Now
+	}
+	return s
+}
+
+// usePool has p run s's imports.
+func (s *Server) usePool(p *jobs.Pool) {
+	s.pool = p
+	p.Handle(importJobType, s.runImport)
+}
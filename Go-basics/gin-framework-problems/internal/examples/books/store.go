@@ -0,0 +1,229 @@
+package books
+
+// Exported access to the book store, so other examples (GraphQL) share the
+// data the REST handlers work on.
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/apperror"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/pagination"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/query"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/rbac"
+)
+
+// List returns every book that isn't deleted in the order they were added.
+func (s *Server) List(ctx context.Context) ([]Book, error) {
+	all, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(all, Book.Deleted), nil
+}
+
+// Get returns the book with the given ID, or ErrBookNotFound, also for a
+// deleted one.
+func (s *Server) Get(ctx context.Context, id string) (Book, error) {
+	b, err := s.repo.Get(ctx, id)
+	if err == nil && b.Deleted() {
+		return Book{}, ErrBookNotFound
+	}
+	return b, err
+}
+
+// Create assigns b an ID, stores it and publishes events.BookUpdated.
+func (s *Server) Create(ctx context.Context, b Book) (Book, error) {
+	b, err := s.repo.Create(ctx, b)
+	if err != nil {
+		return Book{}, err
+	}
+	s.publish(ctx, "created", b)
+	return b, nil
+}
+
+// Update replaces the book with the given ID by b, as PUT /books/:id does:
+// b.Version must be the version b was read at. It publishes
+// events.BookUpdated.
+func (s *Server) Update(ctx context.Context, id string, b Book) (Book, error) {
+	if b.Version < 1 {
+		return Book{}, errVersionRequired
+	}
+	b, err := s.repo.Update(ctx, id, func(stored *Book) error {
+		if stored.Deleted() {
+			return ErrBookNotFound
+		}
+		if err := checkVersion(*stored, b.Version); err != nil {
+			return err
+		}
+		b.OwnerID, b.DeletedAt = stored.OwnerID, stored.DeletedAt
+		*stored = b
+		return nil
+	})
+	if err != nil {
+		return Book{}, bookError(err)
+	}
+	s.publish(ctx, "updated", b)
+	return b, nil
+}
+
+// Delete puts the book with the given ID aside, as DELETE /books/:id does,
+// and publishes events.BookUpdated and events.BookDeleted.
+func (s *Server) Delete(ctx context.Context, id string) error {
+	b, err := s.repo.Update(ctx, id, func(b *Book) error {
+		if b.Deleted() {
+			return ErrBookNotFound
+		}
+		b.DeletedAt = s.now().UTC()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	s.publish(ctx, "deleted", b)
+	return nil
+}
+
+// publish announces a change made outside a request, where a failure can
+// only be logged.
+func (s *Server) publish(ctx context.Context, action string, b Book) {
+	if err := s.announce(ctx, action, b); err != nil {
+		slog.WarnContext(ctx, "publish book event", "action", action, "error", err)
+	}
+}
+
+// CreateCategory stores cat, unless a category with its slug exists already.
+func (s *Server) CreateCategory(ctx context.Context, cat Category) error {
+	err := s.repo.CreateCategory(ctx, cat)
+	if errors.Is(err, errCategoryExists) {
+		return nil
+	}
+	return err
+}
+
+// ListByOwners returns the books of each owner in one pass, for batch loaders.
+func (s *Server) ListByOwners(ctx context.Context, ownerIDs []string) (map[string][]Book, error) {
+	want := make(map[string]bool, len(ownerIDs))
+	for _, id := range ownerIDs {
+		want[id] = true
+	}
+	all, err := s.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string][]Book, len(ownerIDs))
+	for _, b := range all {
+		if want[b.OwnerID] {
+			out[b.OwnerID] = append(out[b.OwnerID], b)
+		}
+	}
+	return out, nil
+}
+
+// bookQuery selects and orders books for GET /books. The filters are bound
+// from the parameters their form tags name.
+type bookQuery struct {
+	Author   string `form:"author"`                             // case-insensitive part of the author
+	YearGTE  int    `form:"year_gte" binding:"omitempty,min=1"` // 0 for no lower bound
+	YearLTE  int    `form:"year_lte" binding:"omitempty,min=1"` // 0 for no upper bound
+	Category string `form:"category"`                           // slug of a category the book is in
+	Tag      string `form:"tag"`                                // a tag the book has
+	// deleted books too; only for holders of books:manage
+	IncludeDeleted bool `form:"include_deleted"`
+
+	sort query.Sort // the zero Sort keeps store order
+}
+
+var bookSorts = query.Sorts[Book]{
+	"title":  func(a, b Book) int { return strings.Compare(strings.ToLower(a.Title), strings.ToLower(b.Title)) },
+	"year":   func(a, b Book) int { return cmp.Compare(a.Year, b.Year) },
+	"author": func(a, b Book) int { return strings.Compare(strings.ToLower(a.Author), strings.ToLower(b.Author)) },
+}
+
+// parseBookQuery reads the page, ?sort= and the filters of bookQuery.
+// ?include_deleted=true is refused unless whenDeleted signed in someone who
+// may see deleted books.
+func parseBookQuery(c *gin.Context) (bookQuery, pagination.Params, error) {
+	var q bookQuery
+	req, err := query.Parse(c, bookSorts, "", &q)
+	if err != nil {
+		return bookQuery{}, pagination.Params{}, err
+	}
+	if q.IncludeDeleted && !principal(c).Can(rbac.BooksManage) {
+		return bookQuery{}, pagination.Params{}, apperror.Forbidden("missing permission " + rbac.BooksManage)
+	}
+	q.sort = req.Sort
+	return q, req.Page, nil
+}
+
+// run returns the matching books in q's order. IDs break ties, so the order
+// is the same on every call and pages don't overlap. With a year bound, the
+// store's year index narrows the books down first.
+func (q bookQuery) run(ctx context.Context, repo BookRepository) ([]Book, error) {
+	list := repo.List
+	if q.YearGTE != 0 || q.YearLTE != 0 {
+		list = func(ctx context.Context) ([]Book, error) { return repo.ListByYears(ctx, q.YearGTE, q.YearLTE) }
+	}
+	all, err := list(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !q.IncludeDeleted {
+		all = slices.DeleteFunc(all, Book.Deleted)
+	}
+	author := strings.ToLower(q.Author)
+	out := slices.DeleteFunc(all, func(b Book) bool {
+		return author != "" && !strings.Contains(strings.ToLower(b.Author), author) ||
+			q.YearGTE != 0 && b.Year < q.YearGTE ||
+			q.YearLTE != 0 && b.Year > q.YearLTE ||
+			q.Category != "" && !slices.Contains(b.Categories, q.Category) ||
+			q.Tag != "" && !slices.Contains(b.Tags, q.Tag)
+	})
+	bookSorts.Apply(out, q.sort, func(a, b Book) int { return compareIDs(a.ID, b.ID) })
+	return out, nil
+}
+
+// AuthorsByID returns the authors with the given IDs, for batch loaders.
+// Unknown IDs are left out.
+func (s *Server) AuthorsByID(ctx context.Context, ids []string) (map[string]Author, error) {
+	want := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		want[id] = true
+	}
+
+	all, err := s.repo.ListAuthors(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]Author, len(ids))
+	for _, a := range all {
+		if want[a.ID] {
+			out[a.ID] = a
+		}
+	}
+	return out, nil
+}
+
+// ReviewsByBooks returns the reviews of each book, newest first, for batch
+// loaders. Unknown books have none.
+func (s *Server) ReviewsByBooks(ctx context.Context, bookIDs []string) (map[string][]Review, error) {
+	out := make(map[string][]Review, len(bookIDs))
+	for _, id := range bookIDs {
+		list, err := s.repo.ListReviews(ctx, id)
+		if errors.Is(err, ErrBookNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		slices.Reverse(list)
+		out[id] = list
+	}
+	return out, nil
+}
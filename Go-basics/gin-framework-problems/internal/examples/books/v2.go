@@ -0,0 +1,74 @@
+package books
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/pagination"
+)
+
+// BookV2 is the v2 shape of a book, served behind the books_v2 flag: authors
+// is a list (split on " & " or " and "), the year is named for what it is,
+// and every book links to itself.
+type BookV2 struct {
+	ID            string            `json:"id"`
+	Title         string            `json:"title"`
+	Authors       []string          `json:"authors"`
+	PublishedYear int               `json:"published_year"`
+	ISBN          string            `json:"isbn,omitempty"`
+	Links         map[string]string `json:"links"`
+}
+
+var authorSeparators = strings.NewReplacer(" and ", "\x00", " & ", "\x00")
+
+func toV2(b Book) BookV2 {
+	var authors []string
+	for _, a := range strings.Split(authorSeparators.Replace(b.Author), "\x00") {
+		if a = strings.TrimSpace(a); a != "" {
+			authors = append(authors, a)
+		}
+	}
+	return BookV2{
+		ID:            b.ID,
+		Title:         b.Title,
+		Authors:       authors,
+		PublishedYear: b.Year,
+		ISBN:          b.ISBN,
+		Links:         map[string]string{"self": "/v2/books/" + b.ID, "v1": "/books/" + b.ID},
+	}
+}
+
+func (s *Server) listBooksV2(c *gin.Context) {
+	q, p, err := parseBookQuery(c)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	all, err := q.run(c.Request.Context(), s.repo)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	out := make([]BookV2, len(all))
+	for i, b := range all {
+		out[i] = toV2(b)
+	}
+	page, err := pageBooks(out, func(b BookV2) string { return b.ID }, p, q)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	pagination.Write(c, page)
+}
+
+func (s *Server) getBookV2(c *gin.Context) {
+	b, err := s.Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, toV2(b))
+}
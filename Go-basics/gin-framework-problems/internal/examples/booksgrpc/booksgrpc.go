@@ -0,0 +1,276 @@
+// Package booksgrpc serves the books example's store three ways: the REST
+// /books routes, a gRPC Books service on cfg.GRPC.Addr, and grpc-gateway's
+// JSON mapping of that service under /v1/books. All of them read and write
+// the same books, so a book created over one is there on the others.
+package booksgrpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/apperror"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/books"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/booksgrpc/bookspb"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/grpcbasics"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/logging"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/requestid"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/server"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/tenant"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// service implements bookspb.BooksServer on a books example server. Its
+// errors are gRPC statuses already, since the gateway calls it directly,
+// past any interceptor.
+type service struct {
+	bookspb.UnimplementedBooksServer
+	books *books.Server
+}
+
+func (s service) ListBooks(ctx context.Context, req *bookspb.ListBooksRequest) (*bookspb.ListBooksResponse, error) {
+	size := int(req.GetPageSize())
+	switch {
+	case size < 0:
+		return nil, status.Error(codes.InvalidArgument, "page_size must not be negative")
+	case size == 0:
+		size = defaultPageSize
+	case size > maxPageSize:
+		size = maxPageSize
+	}
+	// the token is the offset of the page; books are listed in the order
+	// they were added, so it stays put while books are added
+	offset := 0
+	if tok := req.GetPageToken(); tok != "" {
+		n, err := strconv.Atoi(tok)
+		if err != nil || n < 0 {
+			return nil, status.Error(codes.InvalidArgument, "invalid page_token")
+		}
+		offset = n
+	}
+
+	all, err := s.books.List(ctx)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	offset = min(offset, len(all))
+	end := min(offset+size, len(all))
+	resp := &bookspb.ListBooksResponse{Books: make([]*bookspb.Book, 0, end-offset)}
+	for _, b := range all[offset:end] {
+		resp.Books = append(resp.Books, toProto(b))
+	}
+	if end < len(all) {
+		resp.NextPageToken = strconv.Itoa(end)
+	}
+	return resp, nil
+}
+
+func (s service) GetBook(ctx context.Context, req *bookspb.GetBookRequest) (*bookspb.Book, error) {
+	b, err := s.books.Get(ctx, req.GetId())
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return toProto(b), nil
+}
+
+func (s service) CreateBook(ctx context.Context, req *bookspb.CreateBookRequest) (*bookspb.Book, error) {
+	b, err := fromProto(req.GetBook())
+	if err != nil {
+		return nil, err
+	}
+	b, err = s.books.Create(ctx, b)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return toProto(b), nil
+}
+
+func (s service) UpdateBook(ctx context.Context, req *bookspb.UpdateBookRequest) (*bookspb.Book, error) {
+	b, err := fromProto(req.GetBook())
+	if err != nil {
+		return nil, err
+	}
+	b, err = s.books.Update(ctx, req.GetId(), b)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return toProto(b), nil
+}
+
+func (s service) DeleteBook(ctx context.Context, req *bookspb.DeleteBookRequest) (*bookspb.DeleteBookResponse, error) {
+	if err := s.books.Delete(ctx, req.GetId()); err != nil {
+		return nil, statusError(err)
+	}
+	return &bookspb.DeleteBookResponse{}, nil
+}
+
+func toProto(b books.Book) *bookspb.Book {
+	return &bookspb.Book{
+		Id:         b.ID,
+		Title:      b.Title,
+		Author:     b.Author,
+		Year:       int32(b.Year),
+		Isbn:       b.ISBN,
+		Categories: b.Categories,
+		Tags:       b.Tags,
+		Version:    int32(b.Version),
+	}
+}
+
+// fromProto converts pb and checks it against the binding rules of Book, as
+// the REST routes do for a body.
+func fromProto(pb *bookspb.Book) (books.Book, error) {
+	if pb == nil {
+		return books.Book{}, status.Error(codes.InvalidArgument, "book is required")
+	}
+	b := books.Book{
+		Title:      pb.GetTitle(),
+		Author:     pb.GetAuthor(),
+		Year:       int(pb.GetYear()),
+		ISBN:       pb.GetIsbn(),
+		Categories: pb.GetCategories(),
+		Tags:       pb.GetTags(),
+		Version:    int(pb.GetVersion()),
+	}
+	err := binding.Validator.ValidateStruct(&b)
+	var verrs validator.ValidationErrors
+	if errors.As(err, &verrs) {
+		problems := make([]string, len(verrs))
+		for i, fe := range verrs {
+			problems[i] = fe.Field() + ": " + fe.Tag()
+		}
+		return books.Book{}, status.Error(codes.InvalidArgument, "invalid book: "+strings.Join(problems, ", "))
+	}
+	if err != nil {
+		return books.Book{}, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return b, nil
+}
+
+// statusError turns a store error into the gRPC status matching the HTTP
+// status the REST routes answer it with.
+func statusError(err error) error {
+	e := apperror.From(err)
+	return status.Error(grpcCode(e.Status), e.Message)
+}
+
+func grpcCode(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		// mostly a stale version: read the book again and retry
+		return codes.Aborted
+	case http.StatusPreconditionFailed:
+		return codes.FailedPrecondition
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	case http.StatusGatewayTimeout:
+		return codes.DeadlineExceeded
+	}
+	return codes.Internal
+}
+
+// tenantScope scopes each call to a tenant the way the engine's middleware
+// scopes a request: the one the header metadata names, else the one whose
+// ID is the subdomain of baseDomain in :authority, else the default one.
+// The book RPCs take no credentials, like the REST routes, so this is what
+// keeps one tenant's books from another's.
+func tenantScope(header, baseDomain string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		var named, authority string
+		if v := md.Get(header); len(v) > 0 {
+			named = v[0]
+		}
+		if v := md.Get(":authority"); len(v) > 0 {
+			authority = v[0]
+		}
+		t, err := tenant.Default.Resolve(named, authority, baseDomain)
+		if err != nil {
+			return nil, statusError(err)
+		}
+		return handler(tenant.NewContext(ctx, t.ID), req)
+	}
+}
+
+// gatewayError answers a failed /v1 call with the error body the REST
+// routes use, instead of grpc-gateway's own.
+func gatewayError(_ context.Context, _ *runtime.ServeMux, _ runtime.Marshaler, w http.ResponseWriter, r *http.Request, err error) {
+	st := status.Convert(err)
+	code := runtime.HTTPStatusFromCode(st.Code())
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(apperror.APIError{
+		Message:   st.Message(),
+		Code:      apperror.CodeForStatus(code),
+		RequestID: requestid.FromContext(r.Context()),
+	})
+}
+
+// Deps are what NewRouter builds the example from.
+type Deps struct {
+	server.Deps
+	// the catalog both the REST routes and the service serve;
+	// books.NewCatalog's when nil
+	Books *books.Server
+}
+
+// NewRouter serves the books example's REST routes, starts the Books gRPC
+// service on cfg.GRPC.Addr and mounts its grpc-gateway mapping under /v1.
+// With tenancy enabled, gRPC calls are scoped to a tenant like requests.
+func NewRouter(deps Deps) *gin.Engine {
+	cfg, hooks := deps.Config, deps.Hooks
+	catalog := deps.Books
+	if catalog == nil {
+		catalog = books.NewCatalog(deps.Deps)
+	}
+	svc := service{books: catalog}
+	router := books.NewRouter(books.Deps{Deps: deps.Deps, Books: catalog})
+	logger := logging.New(cfg.Log)
+
+	interceptors := []grpc.UnaryServerInterceptor{grpcbasics.UnaryLogging(logger)}
+	if cfg.Tenancy.Enabled {
+		interceptors = append(interceptors, tenantScope(cfg.Tenancy.Header, cfg.Tenancy.BaseDomain))
+	}
+	srv := grpc.NewServer(grpc.ChainUnaryInterceptor(interceptors...))
+	bookspb.RegisterBooksServer(srv, svc)
+	// lets grpcurl discover the service without the .proto file
+	reflection.Register(srv)
+	grpcbasics.Serve(srv, cfg.GRPC.Addr, logger, hooks)
+
+	// the gateway calls the service in process rather than dialing the
+	// listener above; the engine's middleware still logs and traces it, and
+	// scopes it to a tenant
+	mux := runtime.NewServeMux(runtime.WithErrorHandler(gatewayError))
+	if err := bookspb.RegisterBooksHandlerServer(context.Background(), mux, svc); err != nil {
+		panic(err)
+	}
+	router.Any("/v1/*path", gin.WrapH(mux))
+	return router
+}
@@ -0,0 +1,97 @@
+package booksgrpc
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/apperror"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/books"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/booksgrpc/bookspb"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/testutil"
+)
+
+// newClient serves the Books service over catalog in memory and returns a
+// client for it.
+func newClient(t *testing.T, catalog *books.Server) bookspb.BooksClient {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	bookspb.RegisterBooksServer(srv, service{books: catalog})
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return bookspb.NewBooksClient(conn)
+}
+
+func TestSharedStore(t *testing.T) {
+	cfg := testutil.Config(t)
+	cfg.GRPC.Addr = "127.0.0.1:0"
+	deps := testutil.Deps(t, cfg)
+	catalog := books.NewCatalog(deps)
+	router := NewRouter(Deps{Deps: deps, Books: catalog})
+	client := newClient(t, catalog)
+	ctx := t.Context()
+
+	created, err := client.CreateBook(ctx, &bookspb.CreateBookRequest{Book: &bookspb.Book{Title: "Dune", Author: "Frank Herbert", Year: 1965}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the REST routes and the gateway see it
+	w := testutil.Do(t, router, http.MethodGet, "/books/"+created.Id, nil)
+	testutil.AssertStatus(t, w, http.StatusOK)
+	if got := testutil.Decode[books.Book](t, w); got.Title != "Dune" || got.Version != int(created.Version) {
+		t.Errorf("REST book = %+v", got)
+	}
+	w = testutil.Do(t, router, http.MethodGet, "/v1/books", nil)
+	testutil.AssertStatus(t, w, http.StatusOK)
+	if got := testutil.Decode[struct{ Books []struct{ ID string } }](t, w).Books; len(got) != 1 || got[0].ID != created.Id {
+		t.Errorf("gateway list = %+v", got)
+	}
+
+	if _, err := client.CreateBook(ctx, &bookspb.CreateBookRequest{Book: &bookspb.Book{Title: "Emma"}}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("CreateBook without author and year: %v, want InvalidArgument", err)
+	}
+	updated, err := client.UpdateBook(ctx, &bookspb.UpdateBookRequest{Id: created.Id,
+		Book: &bookspb.Book{Title: "Dune", Author: "Frank Herbert", Year: 1966, Version: created.Version}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Year != 1966 || updated.Version == created.Version {
+		t.Errorf("updated = %+v", updated)
+	}
+	// the version it was read at is stale now
+	stale := &bookspb.UpdateBookRequest{Id: created.Id,
+		Book: &bookspb.Book{Title: "Dune", Author: "Frank Herbert", Year: 1967, Version: created.Version}}
+	if _, err := client.UpdateBook(ctx, stale); status.Code(err) != codes.Aborted {
+		t.Errorf("UpdateBook at a stale version: %v, want Aborted", err)
+	}
+	w = testutil.DoJSON(t, router, http.MethodPut, "/v1/books/"+created.Id, gin.H{"title": "Dune", "author": "Frank Herbert", "year": 1967, "version": created.Version})
+	testutil.AssertStatus(t, w, http.StatusConflict)
+	if got := testutil.Decode[apperror.APIError](t, w).Code; got != apperror.CodeConflict {
+		t.Errorf("gateway error code = %q, want the REST envelope's %q", got, apperror.CodeConflict)
+	}
+
+	if _, err := client.DeleteBook(ctx, &bookspb.DeleteBookRequest{Id: created.Id}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.GetBook(ctx, &bookspb.GetBookRequest{Id: created.Id}); status.Code(err) != codes.NotFound {
+		t.Errorf("GetBook after delete: %v, want NotFound", err)
+	}
+	testutil.AssertStatus(t, testutil.Do(t, router, http.MethodGet, "/v1/books/"+created.Id, nil), http.StatusNotFound)
+}
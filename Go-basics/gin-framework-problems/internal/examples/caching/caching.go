@@ -0,0 +1,193 @@
+// Package caching puts a cache-aside layer in front of the books list and
+// user profiles. Reads go to the cache first, writes go to the store and
+// then invalidate the cached copy. Set redis.addr to cache in Redis; without
+// it the cache is in memory.
+//
+//	curl localhost:8080/books             # miss, then hits for 30s
+//	curl -X POST localhost:8080/books -d '{"title":"Go","author":"Pike","year":2015}'
+//	curl localhost:8080/metrics | grep cache_requests_total
+package caching
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/cache"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/config"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/books"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/users"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/healthcheck"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/server"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/tenant"
+)
+
+const (
+	// the list changes often and is cheap to rebuild
+	booksTTL = 30 * time.Second
+	// profiles rarely change, and every change invalidates explicitly
+	profileTTL = 5 * time.Minute
+
+	booksListKey = "list"
+)
+
+// listKey is where ctx's tenant's books list is cached, as each tenant
+// sees its own.
+func listKey(ctx context.Context) string {
+	return booksListKey + ":" + tenant.Assign(ctx)
+}
+
+var errUserGone = errors.New("user not found")
+
+type profile struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	Role     string `json:"role"`
+}
+
+func listBooks(catalog *books.Server, bookCache *cache.Cache) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var list []books.Book
+		err := bookCache.GetOrLoad(c.Request.Context(), listKey(c.Request.Context()), booksTTL, &list,
+			func(ctx context.Context) (any, error) { return catalog.List(ctx) })
+		if err != nil {
+			middleware.Error(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+		c.JSON(http.StatusOK, list)
+	}
+}
+
+func createBook(catalog *books.Server, bookCache *cache.Cache) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input books.Book
+		if err := c.ShouldBindJSON(&input); err != nil {
+			middleware.BindError(c, err)
+			return
+		}
+		input.OwnerID = ""
+		b, err := catalog.Create(c.Request.Context(), input)
+		if err != nil {
+			middleware.Fail(c, err)
+			return
+		}
+		if err := bookCache.Delete(c.Request.Context(), listKey(c.Request.Context())); err != nil {
+			// the stale list expires within booksTTL anyway
+			c.Error(err)
+		}
+		c.JSON(http.StatusCreated, b)
+	}
+}
+
+func getProfile(accounts *users.Server, profileCache *cache.Cache) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := users.MustUser(c).ID
+		var p profile
+		err := profileCache.GetOrLoad(c.Request.Context(), id, profileTTL, &p,
+			func(ctx context.Context) (any, error) {
+				u, err := accounts.Get(ctx, id)
+				if errors.Is(err, users.ErrUserNotFound) {
+					return nil, errUserGone
+				}
+				if err != nil {
+					return nil, err
+				}
+				return profile{ID: u.ID, Username: u.Username, Email: u.Email, Role: u.Role}, nil
+			})
+		if errors.Is(err, errUserGone) {
+			middleware.Error(c, http.StatusNotFound, err.Error())
+			return
+		}
+		if err != nil {
+			middleware.Error(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+		c.JSON(http.StatusOK, p)
+	}
+}
+
+func updateProfile(accounts *users.Server, profileCache *cache.Cache) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := users.MustUser(c).ID
+		var req struct {
+			Email string `json:"email" binding:"required,email"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			middleware.BindError(c, err)
+			return
+		}
+		u, err := accounts.UpdateEmail(c.Request.Context(), id, req.Email)
+		if errors.Is(err, users.ErrUserNotFound) {
+			middleware.Error(c, http.StatusNotFound, errUserGone.Error())
+			return
+		}
+		if err != nil {
+			middleware.Error(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if err := profileCache.Delete(c.Request.Context(), id); err != nil {
+			c.Error(err)
+		}
+		c.JSON(http.StatusOK, profile{ID: u.ID, Username: u.Username, Email: u.Email, Role: u.Role})
+	}
+}
+
+// newStore returns a Redis store when redis.addr is set, else a memory one.
+func newStore(cfg *config.Config, hooks *server.Hooks) cache.Store {
+	if cfg.Redis.Addr == "" {
+		return cache.NewMemoryStore()
+	}
+	client := redis.NewClient(&redis.Options{Addr: cfg.Redis.Addr})
+	hooks.Add(func(context.Context) error { return client.Close() })
+	store := cache.NewRedisStore(client)
+	healthcheck.Default.Register("redis", store.Ping)
+	return store
+}
+
+// Deps are what NewRouter builds the example from.
+type Deps struct {
+	server.Deps
+	// the books behind the cache; books.NewCatalog's when nil
+	Books *books.Server
+	// the users whose profiles are cached; users.NewAccounts' when nil
+	Users *users.Server
+}
+
+// NewRouter builds the caching example router.
+func NewRouter(deps Deps) *gin.Engine {
+	cfg, hooks := deps.Config, deps.Hooks
+	catalog := deps.Books
+	if catalog == nil {
+		catalog = books.NewCatalog(deps.Deps)
+	}
+	accounts := deps.Users
+	if accounts == nil {
+		accounts = users.NewAccounts(deps.Deps)
+	}
+	store := newStore(cfg, hooks)
+	bookCache := cache.New("books", store)
+	profileCache := cache.New("profile", store)
+
+	router := server.NewEngine(deps.Deps)
+	router.GET("/books", listBooks(catalog, bookCache))
+	router.POST("/books", createBook(catalog, bookCache))
+
+	api := router.Group("/api")
+	{
+		api.POST("/register", accounts.Register)
+		api.POST("/login", accounts.Login)
+	}
+	private := router.Group("/api")
+	private.Use(middleware.Auth(accounts.LookupToken))
+	{
+		private.GET("/profile", getProfile(accounts, profileCache))
+		private.PUT("/profile", updateProfile(accounts, profileCache))
+	}
+	return router
+}
@@ -0,0 +1,80 @@
+package caching
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/books"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/testutil"
+)
+
+func titles(t *testing.T, h http.Handler) []string {
+	t.Helper()
+	w := testutil.Do(t, h, http.MethodGet, "/books", nil)
+	testutil.AssertStatus(t, w, http.StatusOK)
+	var titles []string
+	for _, b := range testutil.Decode[[]books.Book](t, w) {
+		titles = append(titles, b.Title)
+	}
+	return titles
+}
+
+func TestBooksCacheAside(t *testing.T) {
+	deps := testutil.Deps(t, nil)
+	catalog := books.NewCatalog(deps)
+	router := NewRouter(Deps{Deps: deps, Books: catalog})
+	before := len(titles(t, router))
+
+	// a book stored behind the cache's back isn't seen until the list expires
+	catalog.Create(t.Context(), books.Book{Title: "Hidden", Author: "Nobody", Year: 2001})
+	if got := titles(t, router); len(got) != before {
+		t.Fatalf("list has %d books, want the cached %d", len(got), before)
+	}
+
+	// creating one through the API invalidates it
+	w := testutil.DoJSON(t, router, http.MethodPost, "/books", books.Book{Title: "Go", Author: "Pike", Year: 2015})
+	testutil.AssertStatus(t, w, http.StatusCreated)
+	if got := titles(t, router); len(got) != before+2 {
+		t.Errorf("list has %d books after POST, want %d", len(got), before+2)
+	}
+
+	w = testutil.DoJSON(t, router, http.MethodPost, "/books", books.Book{Author: "Pike", Year: 2015})
+	testutil.AssertStatus(t, w, http.StatusBadRequest)
+}
+
+func TestProfileCache(t *testing.T) {
+	router := NewRouter(Deps{Deps: testutil.Deps(t, nil)})
+	token := testutil.RegisterUser(t, router, "cachedcarol")
+
+	w := testutil.Do(t, router, http.MethodGet, "/api/profile", nil, testutil.WithToken(token))
+	testutil.AssertJSON(t, w, http.StatusOK, gin.H{
+		"username": "cachedcarol",
+		"email":    "cachedcarol@example.com",
+		"role":     "user",
+	}, "id")
+
+	tests := []struct {
+		name   string
+		email  string
+		status int
+	}{
+		{"new email", "carol@example.org", http.StatusOK},
+		{"not an email", "carol", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := testutil.DoJSON(t, router, http.MethodPut, "/api/profile", gin.H{"email": tt.email}, testutil.WithToken(token))
+			testutil.AssertStatus(t, w, tt.status)
+		})
+	}
+
+	// the update dropped the cached profile
+	w = testutil.Do(t, router, http.MethodGet, "/api/profile", nil, testutil.WithToken(token))
+	testutil.AssertStatus(t, w, http.StatusOK)
+	if got := testutil.Decode[profile](t, w).Email; got != "carol@example.org" {
+		t.Errorf("email = %q, want the updated one", got)
+	}
+	testutil.AssertStatus(t, testutil.Do(t, router, http.MethodGet, "/api/profile", nil), http.StatusUnauthorized)
+}
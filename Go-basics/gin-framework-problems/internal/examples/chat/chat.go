@@ -0,0 +1,85 @@
+// Package chat is a multi-room WebSocket chat. Users log in through the auth
+// example's /login and connect with their token:
+//
+//	ws://localhost:8080/rooms/general/ws?token=tok_alice_1
+//
+// then send {"text": "hi"} frames.
+package chat
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/auth"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/server"
+)
+
+// historySize is how many messages a room remembers for newcomers.
+const historySize = 50
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	// the example has no fixed front-end origin
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+func serveWS(hub *Hub) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := middleware.Principal[middleware.Subjecter](c)
+		if !ok {
+			middleware.Error(c, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+
+		conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+		if err != nil {
+			// Upgrade already wrote the error response
+			return
+		}
+
+		cl := &client{
+			hub:  hub,
+			conn: conn,
+			room: c.Param("room"),
+			user: user.Subject(),
+			send: make(chan Message, 32),
+		}
+		select {
+		case hub.register <- cl:
+		case <-hub.done:
+			conn.Close()
+			return
+		}
+
+		go cl.writePump()
+		go cl.readPump()
+	}
+}
+
+// NewRouter builds the chat example router.
+func NewRouter(deps server.Deps) *gin.Engine {
+	hooks := deps.Hooks
+	authn := auth.NewSessions(deps)
+	hub := NewHub(historySize)
+	go hub.Run()
+	hooks.Add(func(context.Context) error {
+		hub.Stop()
+		return nil
+	})
+
+	router := server.NewEngine(deps)
+	router.POST("/login", authn.Login)
+
+	rooms := router.Group("/rooms")
+	rooms.Use(middleware.Auth(authn.LookupToken, middleware.WithQueryToken("token")))
+	{
+		rooms.GET("/:room/ws", serveWS(hub))
+	}
+
+	return router
+}
@@ -0,0 +1,100 @@
+package chat
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/testutil"
+)
+
+func TestHistory(t *testing.T) {
+	tests := []struct {
+		name  string
+		size  int
+		added []string
+		want  []string
+	}{
+		{"empty", 3, nil, nil},
+		{"part full", 3, []string{"a", "b"}, []string{"a", "b"}},
+		{"full", 3, []string{"a", "b", "c"}, []string{"a", "b", "c"}},
+		{"wrapped", 3, []string{"a", "b", "c", "d", "e"}, []string{"c", "d", "e"}},
+		{"no room", 0, []string{"a"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newHistory(tt.size)
+			for _, text := range tt.added {
+				h.add(Message{Text: text})
+			}
+			var got []string
+			for _, m := range h.all() {
+				got = append(got, m.Text)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("history = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// dial joins room on srv as the holder of token.
+func dial(t *testing.T, url, room, token string) *websocket.Conn {
+	t.Helper()
+	conn, _, err := websocket.DefaultDialer.Dial(url+"/rooms/"+room+"/ws?token="+token, nil)
+	if err != nil {
+		t.Fatalf("dial %s: %v", room, err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func receive(t *testing.T, conn *websocket.Conn) Message {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var m Message
+	if err := conn.ReadJSON(&m); err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	return m
+}
+
+func TestChat(t *testing.T) {
+	router := testutil.Router(t, NewRouter, nil)
+	srv := testutil.Server(t, router)
+	url := "ws" + strings.TrimPrefix(srv.URL, "http")
+	alice := testutil.Login(t, router, "/login", "alice", "password1")
+	bob := testutil.Login(t, router, "/login", "bob", "adminpass")
+
+	_, resp, err := websocket.DefaultDialer.Dial(url+"/rooms/general/ws", nil)
+	if err == nil || resp == nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("dial without a token: %v, want a 401", err)
+	}
+
+	a := dial(t, url, "general", alice)
+	if err := a.WriteJSON(map[string]string{"text": "  hi  "}); err != nil {
+		t.Fatal(err)
+	}
+	if m := receive(t, a); m.User != "alice" || m.Room != "general" || m.Text != "hi" {
+		t.Fatalf("alice got %+v, want her own trimmed message", m)
+	}
+
+	// a newcomer catches up on the room's history
+	b := dial(t, url, "general", bob)
+	if m := receive(t, b); m.User != "alice" || m.Text != "hi" {
+		t.Fatalf("bob got %+v, want alice's message from the history", m)
+	}
+
+	// rooms don't hear each other
+	other := dial(t, url, "other", bob)
+	other.WriteJSON(map[string]string{"text": "elsewhere"})
+	receive(t, other)
+	b.WriteJSON(map[string]string{"text": "hello"})
+	if m := receive(t, a); m.User != "bob" || m.Text != "hello" {
+		t.Errorf("alice got %+v, want bob's message in general", m)
+	}
+}
@@ -0,0 +1,102 @@
+package chat
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	writeWait      = 10 * time.Second    // time allowed to write a message
+	pongWait       = 60 * time.Second    // time allowed to read the next pong
+	pingPeriod     = (pongWait * 9) / 10 // must be less than pongWait
+	maxMessageSize = 1024
+)
+
+// client is one websocket connection in one room.
+type client struct {
+	hub  *Hub
+	conn *websocket.Conn
+	room string
+	user string
+	send chan Message
+}
+
+// trySend queues m without blocking; it reports false if the buffer is full.
+// Only the hub goroutine calls it.
+func (c *client) trySend(m Message) bool {
+	select {
+	case c.send <- m:
+		return true
+	default:
+		return false
+	}
+}
+
+// readPump forwards incoming messages to the hub. It is the only reader of
+// the connection and unregisters the client when the connection drops.
+func (c *client) readPump() {
+	defer func() {
+		select {
+		case c.hub.unregister <- c:
+		case <-c.hub.done:
+		}
+		c.conn.Close()
+	}()
+
+	c.conn.SetReadLimit(maxMessageSize)
+	c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		var in struct {
+			Text string `json:"text"`
+		}
+		if err := c.conn.ReadJSON(&in); err != nil {
+			return
+		}
+		text := strings.TrimSpace(in.Text)
+		if text == "" {
+			continue
+		}
+		m := Message{Room: c.room, User: c.user, Text: text, Time: time.Now().UTC()}
+		select {
+		case c.hub.broadcast <- m:
+		case <-c.hub.done:
+			return
+		}
+	}
+}
+
+// writePump is the only writer of the connection: it sends queued messages
+// and pings the peer so dead connections are noticed.
+func (c *client) writePump() {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		c.conn.Close()
+	}()
+
+	for {
+		select {
+		case m, ok := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if !ok {
+				// the hub closed the channel
+				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+				return
+			}
+			if err := c.conn.WriteJSON(m); err != nil {
+				return
+			}
+		case <-ticker.C:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
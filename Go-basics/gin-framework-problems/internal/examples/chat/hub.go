@@ -0,0 +1,142 @@
+package chat
+
+import (
+	"time"
+)
+
+// Message is what clients send and receive. Clients only fill in Text; the
+// server stamps the rest.
+type Message struct {
+	Room string    `json:"room"`
+	User string    `json:"user"`
+	Text string    `json:"text"`
+	Time time.Time `json:"time"`
+}
+
+// history keeps the last N messages of a room in a ring buffer so new
+// members can catch up without unbounded memory.
+type history struct {
+	msgs  []Message
+	start int
+	count int
+}
+
+func newHistory(size int) *history {
+	return &history{msgs: make([]Message, size)}
+}
+
+func (h *history) add(m Message) {
+	if len(h.msgs) == 0 {
+		return
+	}
+	end := (h.start + h.count) % len(h.msgs)
+	h.msgs[end] = m
+	if h.count < len(h.msgs) {
+		h.count++
+	} else {
+		// full: overwrite the oldest
+		h.start = (h.start + 1) % len(h.msgs)
+	}
+}
+
+// all returns the messages oldest first.
+func (h *history) all() []Message {
+	out := make([]Message, 0, h.count)
+	for i := 0; i < h.count; i++ {
+		out = append(out, h.msgs[(h.start+i)%len(h.msgs)])
+	}
+	return out
+}
+
+type room struct {
+	clients map[*client]bool
+	history *history
+}
+
+// Hub owns every room. All room state is touched only by the run goroutine;
+// clients talk to it through channels.
+type Hub struct {
+	register    chan *client
+	unregister  chan *client
+	broadcast   chan Message
+	stop        chan struct{}
+	done        chan struct{}
+	historySize int
+	rooms       map[string]*room
+}
+
+func NewHub(historySize int) *Hub {
+	return &Hub{
+		register:    make(chan *client),
+		unregister:  make(chan *client),
+		broadcast:   make(chan Message, 64),
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+		historySize: historySize,
+		rooms:       map[string]*room{},
+	}
+}
+
+// Run processes registrations and messages until Stop is called.
+func (h *Hub) Run() {
+	defer close(h.done)
+	for {
+		select {
+		case c := <-h.register:
+			r := h.rooms[c.room]
+			if r == nil {
+				r = &room{clients: map[*client]bool{}, history: newHistory(h.historySize)}
+				h.rooms[c.room] = r
+			}
+			r.clients[c] = true
+			// replay history so the newcomer sees the conversation so far
+			for _, m := range r.history.all() {
+				c.trySend(m)
+			}
+
+		case c := <-h.unregister:
+			h.remove(c)
+
+		case m := <-h.broadcast:
+			r := h.rooms[m.Room]
+			if r == nil {
+				continue
+			}
+			r.history.add(m)
+			for c := range r.clients {
+				if !c.trySend(m) {
+					// slow consumer: drop it rather than block the room
+					h.remove(c)
+				}
+			}
+
+		case <-h.stop:
+			for _, r := range h.rooms {
+				for c := range r.clients {
+					close(c.send)
+				}
+			}
+			h.rooms = nil
+			return
+		}
+	}
+}
+
+func (h *Hub) remove(c *client) {
+	r := h.rooms[c.room]
+	if r == nil || !r.clients[c] {
+		return
+	}
+	delete(r.clients, c)
+	close(c.send)
+	if len(r.clients) == 0 {
+		// history goes with the room once everyone has left
+		delete(h.rooms, c.room)
+	}
+}
+
+// Stop closes every connection and waits for Run to return.
+func (h *Hub) Stop() {
+	close(h.stop)
+	<-h.done
+}
@@ -0,0 +1,131 @@
+package files
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/apperror"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+)
+
+// downloadArchive streams a ZIP of the files whose IDs the body lists, one
+// entry at a time, so memory use doesn't grow with the archive. Everything
+// that can fail the request is checked before the first byte: unknown or
+// expired IDs are a 404 listing them all, and more than files.archive_max_files files or
+// files.archive_max_bytes bytes a 413. A storage error after that can only
+// cut the archive short, which unzip then reports as corrupt.
+func (s *Server) downloadArchive(c *gin.Context) {
+	var req struct {
+		IDs []string `json:"ids" binding:"required,min=1,dive,required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.BindError(c, err)
+		return
+	}
+	ctx := c.Request.Context()
+	var (
+		files   []File
+		missing []string
+		total   int64
+		seen    = map[string]bool{}
+	)
+	for _, id := range req.IDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		if len(seen) > s.archiveMaxFiles {
+			// without looking up the rest
+			middleware.Fail(c, s.errArchiveTooLarge())
+			return
+		}
+		f, err := s.getFile(ctx, id)
+		if errors.Is(err, errFileNotFound) || errors.Is(err, errFileExpired) {
+			missing = append(missing, id)
+			continue
+		}
+		if err != nil {
+			middleware.Fail(c, err)
+			return
+		}
+		files = append(files, f)
+		total += f.Size
+	}
+	if len(missing) > 0 {
+		middleware.Fail(c, apperror.NotFound("some files were not found").
+			WithDetails(map[string][]string{"missing": missing}))
+		return
+	}
+	if total > s.archiveMaxBytes {
+		middleware.Fail(c, s.errArchiveTooLarge())
+		return
+	}
+
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", `attachment; filename="files.zip"`)
+	c.Status(http.StatusOK)
+	zw := zip.NewWriter(c.Writer)
+	names := map[string]bool{}
+	for _, f := range files {
+		if err := s.addToArchive(c, zw, f, entryName(f, names)); err != nil {
+			// the status is sent; leaving out the central directory is
+			// what tells the client
+			c.Error(fmt.Errorf("archive %s: %w", f.ID, err))
+			return
+		}
+	}
+	if err := zw.Close(); err != nil {
+		c.Error(err)
+	}
+}
+
+func (s *Server) errArchiveTooLarge() error {
+	return apperror.New(http.StatusRequestEntityTooLarge, apperror.CodeTooLarge, "the archive is larger than allowed").
+		WithDetails(map[string]int64{"limit_files": int64(s.archiveMaxFiles), "limit_bytes": s.archiveMaxBytes})
+}
+
+func (s *Server) addToArchive(c *gin.Context, zw *zip.Writer, f File, name string) error {
+	content, err := s.blobs.Open(c.Request.Context(), blobName(f))
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+	// images, PDFs and archives are compressed already
+	method := zip.Store
+	if strings.HasPrefix(f.ContentType, "text/") {
+		method = zip.Deflate
+	}
+	w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: method, Modified: f.UploadedAt})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, content)
+	return err
+}
+
+// entryName is f's path in the archive, its folders included, numbered
+// like "report (2).pdf" when an earlier entry has it; taken has the names
+// used so far.
+func entryName(f File, taken map[string]bool) string {
+	name := f.Filename
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		name = f.ID
+	}
+	if f.Folder != "" {
+		name = f.Folder + "/" + name
+	}
+	ext := path.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	for i := 2; taken[name]; i++ {
+		name = fmt.Sprintf("%s (%d)%s", base, i, ext)
+	}
+	taken[name] = true
+	return name
+}
@@ -0,0 +1,63 @@
+package files
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/testutil"
+)
+
+func TestDownloadArchive(t *testing.T) {
+	router, s := newRouter(t, nil)
+	var ids []string
+	for _, name := range []string{"a.txt", "a.txt", "b.txt"} {
+		w := upload(t, router, "/upload", "file", name)
+		testutil.AssertStatus(t, w, http.StatusCreated)
+		ids = append(ids, testutil.Decode[File](t, w).ID)
+	}
+
+	w := testutil.DoJSON(t, router, http.MethodPost, "/files/archive", gin.H{"ids": ids})
+	testutil.AssertStatus(t, w, http.StatusOK)
+	zr, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, _ := io.ReadAll(r)
+		r.Close()
+		want := map[string]string{"a.txt": "hello, a.txt", "a (2).txt": "hello, a.txt", "b.txt": "hello, b.txt"}[f.Name]
+		if string(content) != want {
+			t.Errorf("%s = %q, want %q", f.Name, content, want)
+		}
+	}
+	if want := []string{"a.txt", "a (2).txt", "b.txt"}; !slices.Equal(names, want) {
+		t.Errorf("entries = %q, want %q", names, want)
+	}
+
+	w = testutil.DoJSON(t, router, http.MethodPost, "/files/archive", gin.H{"ids": []string{ids[0], "missing"}})
+	testutil.AssertStatus(t, w, http.StatusNotFound)
+	details := testutil.Decode[struct {
+		Details map[string][]string `json:"details"`
+	}](t, w).Details
+	if !slices.Equal(details["missing"], []string{"missing"}) {
+		t.Errorf("details = %v, want the missing ID", details)
+	}
+	testutil.AssertStatus(t, testutil.DoJSON(t, router, http.MethodPost, "/files/archive", gin.H{"ids": []string{}}), http.StatusBadRequest)
+
+	s.archiveMaxFiles = 2
+	testutil.AssertStatus(t, testutil.DoJSON(t, router, http.MethodPost, "/files/archive", gin.H{"ids": ids}), http.StatusRequestEntityTooLarge)
+	s.archiveMaxFiles, s.archiveMaxBytes = 3, 10
+	testutil.AssertStatus(t, testutil.DoJSON(t, router, http.MethodPost, "/files/archive", gin.H{"ids": ids}), http.StatusRequestEntityTooLarge)
+}
@@ -0,0 +1,32 @@
+package files
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// idleBody moves the connection's read deadline out before every read of
+// a request body, so an upload may take as long as it keeps arriving and
+// is cut off only after readIdle without a byte. The server's read timeout
+// is for the whole request and would end any upload slower than that.
+type idleBody struct {
+	io.ReadCloser
+	rc   *http.ResponseController
+	idle time.Duration
+}
+
+func (b *idleBody) Read(p []byte) (int, error) {
+	// not supported by every writer, such as a test's recorder
+	b.rc.SetReadDeadline(time.Now().Add(b.idle))
+	return b.ReadCloser.Read(p)
+}
+
+// idleRead puts the body of c's request behind an idleBody.
+func (s *Server) idleRead(c *gin.Context) {
+	if s.readIdle > 0 {
+		c.Request.Body = &idleBody{ReadCloser: c.Request.Body, rc: http.NewResponseController(c.Writer), idle: s.readIdle}
+	}
+}
@@ -0,0 +1,132 @@
+package files
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"hash/crc32"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/apperror"
+)
+
+// Content is stored once per SHA-256 digest, as sha256/<hex> in storage,
+// however many files have it: the repository counts the files with each
+// digest, and the blob goes when the last of them does.
+
+// checksumHeader carries the SHA-256 of the file in hex, as the client
+// computed it, on POST /upload and POST /uploads/<id>/complete.
+const checksumHeader = "X-Checksum-SHA256"
+
+func (s *Server) lockBlob(sum string) func() {
+	m := &s.blobLocks[crc32.ChecksumIEEE([]byte(sum))%uint32(len(s.blobLocks))]
+	m.Lock()
+	return m.Unlock
+}
+
+// blobName is where f's content is stored.
+func blobName(f File) string {
+	if f.SHA256 == "" {
+		return f.ID
+	}
+	return "sha256/" + f.SHA256
+}
+
+// digest returns the hex SHA-256 of the content open returns.
+func digest(open func() (io.ReadCloser, error)) (string, error) {
+	r, err := open()
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// checkChecksum compares f's digest with checksumHeader, when the client
+// sent one.
+func checkChecksum(c *gin.Context, f File) error {
+	want := strings.ToLower(c.GetHeader(checksumHeader))
+	if want == "" {
+		return nil
+	}
+	if b, err := hex.DecodeString(want); err != nil || len(b) != sha256.Size {
+		return apperror.BadRequest("invalid " + checksumHeader + " header")
+	}
+	if want != f.SHA256 {
+		return apperror.New(http.StatusBadRequest, apperror.CodeChecksum, "checksum does not match the content").
+			WithDetails(map[string]string{"file": f.Filename, "expected": want, "actual": f.SHA256})
+	}
+	return nil
+}
+
+// commit stores f's metadata, and the content open returns unless another
+// file already has it.
+func (s *Server) commit(ctx context.Context, f File, open func() (io.ReadCloser, error)) error {
+	defer s.lockBlob(f.SHA256)()
+	refs, err := s.repo.Refs(ctx, f.SHA256)
+	if err != nil {
+		return err
+	}
+	if refs == 0 {
+		r, err := open()
+		if err != nil {
+			return err
+		}
+		err = s.blobs.Save(ctx, blobName(f), r, f.Size)
+		r.Close()
+		if err != nil {
+			return err
+		}
+	}
+	if err := s.repo.Create(ctx, f); err != nil {
+		if refs == 0 {
+			s.blobs.Delete(ctx, blobName(f))
+		}
+		return err
+	}
+	s.invalidateList()
+	return nil
+}
+
+// deleteUnused deletes the blob of digest sum if no file has it.
+func (s *Server) deleteUnused(ctx context.Context, sum string) error {
+	defer s.lockBlob(sum)()
+	refs, err := s.repo.Refs(ctx, sum)
+	if err != nil || refs > 0 {
+		return err
+	}
+	return s.blobs.Delete(ctx, "sha256/"+sum)
+}
+
+// removeFile deletes f's metadata, then its thumbnails and its content once
+// no other file has it. The file is gone with its metadata, so only failing
+// that is err; failing to delete the rest, which file_gc retries, is
+// leftover.
+func (s *Server) removeFile(ctx context.Context, f File) (leftover, err error) {
+	if f.SHA256 == "" {
+		if err := s.repo.Delete(ctx, f.ID); err != nil {
+			return nil, err
+		}
+		s.invalidateList()
+		return errors.Join(s.blobs.Delete(ctx, f.ID), s.deleteThumbnails(ctx, f)), nil
+	}
+	defer s.lockBlob(f.SHA256)()
+	if err := s.repo.Delete(ctx, f.ID); err != nil {
+		return nil, err
+	}
+	s.invalidateList()
+	refs, err := s.repo.Refs(ctx, f.SHA256)
+	if err == nil && refs == 0 {
+		err = s.blobs.Delete(ctx, blobName(f))
+	}
+	return errors.Join(err, s.deleteThumbnails(ctx, f)), nil
+}
@@ -0,0 +1,91 @@
+package files
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/apperror"
+)
+
+// activeTypes can run script when a browser renders them, which from this
+// origin would see its cookies and call its API, so they are only ever
+// served as attachments.
+var activeTypes = []string{
+	"text/html", "application/xhtml+xml", "image/svg+xml",
+	"text/xml", "application/xml",
+	"text/javascript", "application/javascript",
+}
+
+// contentType is what f is served as: the type sniffed when it was
+// uploaded, or, for files stored without one, what the first bytes of
+// content say. content is left at the start.
+func contentType(f File, content io.ReadSeeker) (string, error) {
+	if f.ContentType != "" && f.ContentType != "application/octet-stream" {
+		return f.ContentType, nil
+	}
+	typ, err := sniff(content)
+	if err != nil {
+		return "", err
+	}
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	return typ, nil
+}
+
+// disposition is how the client asks for a download to be served:
+// ?disposition=inline, the default, or attachment.
+func disposition(c *gin.Context) (string, error) {
+	kind := c.DefaultQuery("disposition", "inline")
+	if kind != "inline" && kind != "attachment" {
+		return "", apperror.Validation("invalid disposition",
+			map[string]string{"disposition": "must be inline or attachment"})
+	}
+	return kind, nil
+}
+
+// contentDisposition is a Content-Disposition header for filename as kind,
+// or as an attachment whatever kind says when typ is active. The plain
+// filename is ASCII for every client, with the name itself as filename*
+// (RFC 6266 and RFC 5987) when that had to change it.
+func contentDisposition(kind, typ, filename string) string {
+	if slices.Contains(activeTypes, typ) {
+		kind = "attachment"
+	}
+	fallback := asciiName(filename)
+	v := kind + `; filename="` + fallback + `"`
+	if fallback != filename {
+		v += "; filename*=UTF-8''" + percentEncode(filename)
+	}
+	return v
+}
+
+// asciiName is name with what can't go in a quoted string as it is, such as
+// non-ASCII letters, quotes and control characters, replaced by "_".
+func asciiName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r > 0x7e || r == '"' || r == '\\' {
+			return '_'
+		}
+		return r
+	}, name)
+}
+
+// percentEncode encodes s as RFC 5987's value-chars: every byte but letters,
+// digits and !#$&+-.^_`|~ as %XX.
+func percentEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		if 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || '0' <= ch && ch <= '9' || strings.IndexByte("!#$&+-.^_`|~", ch) >= 0 {
+			b.WriteByte(ch)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", ch)
+		}
+	}
+	return b.String()
+}
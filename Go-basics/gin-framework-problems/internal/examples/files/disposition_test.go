@@ -0,0 +1,52 @@
+package files
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"testing"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/testutil"
+)
+
+func TestContentDisposition(t *testing.T) {
+	tests := []struct {
+		kind, typ, name, want string
+	}{
+		{"inline", "text/plain", "a.txt", `inline; filename="a.txt"`},
+		{"attachment", "text/plain", "a.txt", `attachment; filename="a.txt"`},
+		{"inline", "text/html; charset=utf-8", "x.html", `inline; filename="x.html"`},
+		{"inline", "image/svg+xml", "x.svg", `attachment; filename="x.svg"`},
+		{"inline", "text/plain", `say "hi".txt`, `inline; filename="say _hi_.txt"; filename*=UTF-8''say%20%22hi%22.txt`},
+		{"inline", "text/plain", "நூல்.txt", `inline; filename="____.txt"; filename*=UTF-8''%E0%AE%A8%E0%AF%82%E0%AE%B2%E0%AF%8D.txt`},
+	}
+	for _, tt := range tests {
+		if got := contentDisposition(tt.kind, tt.typ, tt.name); got != tt.want {
+			t.Errorf("contentDisposition(%q, %q, %q) = %s, want %s", tt.kind, tt.typ, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDownloadDisposition(t *testing.T) {
+	cfg := testutil.Config(t)
+	cfg.Storage.Uploads.Single.AllowedTypes = nil // anything, HTML too
+	router, _ := newRouter(t, cfg)
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, _ := mw.CreateFormFile("file", "page.txt")
+	part.Write([]byte("<!DOCTYPE html><html><script>alert(1)</script></html>"))
+	mw.Close()
+	w := testutil.Do(t, router, http.MethodPost, "/upload", &body, testutil.WithHeader("Content-Type", mw.FormDataContentType()))
+	testutil.AssertStatus(t, w, http.StatusCreated)
+	id := testutil.Decode[File](t, w).ID
+
+	w = testutil.Do(t, router, http.MethodGet, "/files/"+id, nil)
+	testutil.AssertStatus(t, w, http.StatusOK)
+	if got := w.Header().Get("Content-Disposition"); got != `attachment; filename="page.txt"` {
+		t.Errorf("Content-Disposition = %s, want an attachment whatever the name says", got)
+	}
+	if got := w.Header().Get("X-Content-Type-Options"); got != "nosniff" {
+		t.Errorf("X-Content-Type-Options = %q, want nosniff", got)
+	}
+	testutil.AssertStatus(t, testutil.Do(t, router, http.MethodGet, "/files/"+id+"?disposition=open", nil), http.StatusBadRequest)
+}
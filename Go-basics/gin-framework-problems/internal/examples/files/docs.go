@@ -0,0 +1,29 @@
+package files
+
+import (
+	"net/http"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/apidocs"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/pagination"
+)
+
+// docs documents the files API at /openapi.json. Downloads, resumable
+// uploads and archives aren't JSON and are left out.
+var docs = []apidocs.Operation{
+	{Method: http.MethodPost, Path: "/upload", Tag: "files", Summary: "Upload a file, signed in or not",
+		Upload: true, Status: http.StatusCreated, Response: File{}},
+	{Method: http.MethodGet, Path: "/files", Tag: "files", Summary: "Page through the uploads, oldest first",
+		Query: map[string]string{
+			"folder": "files right in this folder, / for the root",
+			"prefix": "files whose path starts with this",
+			"sort":   "filename, size or uploaded_at, with a leading - for descending",
+			"limit":  "items per page, at most 100",
+			"offset": "items to skip",
+			"page":   "page to return, from 1, instead of offset",
+			"cursor": "next_cursor of the previous page, instead of offset",
+		},
+		Response: pagination.Page[File]{}},
+	{Method: http.MethodGet, Path: "/files/:id/meta", Tag: "files", Summary: "An upload's metadata", Response: File{}},
+	{Method: http.MethodDelete, Path: "/files/:id", Tag: "files", Summary: "Delete an upload", Auth: true,
+		Status: http.StatusNoContent},
+}
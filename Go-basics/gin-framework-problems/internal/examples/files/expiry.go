@@ -0,0 +1,77 @@
+package files
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/apperror"
+)
+
+// an apperror, so handlers can pass it to middleware.Fail for a 410
+var errFileExpired = apperror.New(http.StatusGone, apperror.CodeGone, "file has expired")
+
+// uploadTTL is how long the upload c carries is kept: ?ttl=, a duration
+// such as 24h, or else files.default_ttl. 0 keeps it for good.
+func (s *Server) uploadTTL(c *gin.Context) (time.Duration, error) {
+	raw := c.Query("ttl")
+	if raw == "" {
+		return s.defaultTTL, nil
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d <= 0 {
+		return 0, apperror.Validation("ttl must be a positive duration such as 24h",
+			map[string]string{"ttl": "must be a positive duration such as 24h"})
+	}
+	if s.maxTTL > 0 && d > s.maxTTL {
+		return 0, apperror.Validation("ttl is longer than allowed",
+			map[string]string{"ttl": "must be at most " + s.maxTTL.String()})
+	}
+	return d, nil
+}
+
+// expireAfter has f expire ttl after it was uploaded, unless ttl is 0.
+func (f *File) expireAfter(ttl time.Duration) {
+	if ttl > 0 {
+		expires := f.UploadedAt.Add(ttl)
+		f.ExpiresAt = &expires
+	}
+}
+
+// getFile is repo.Get for the routes that serve a file, where one whose
+// time is up is a 410 until file_expiry gets to it.
+func (s *Server) getFile(ctx context.Context, id string) (File, error) {
+	f, err := s.repo.Get(ctx, id)
+	if err == nil && f.Expired(s.now()) {
+		return File{}, errFileExpired
+	}
+	return f, err
+}
+
+// purgeExpired is the file_expiry task: it deletes the files whose time is
+// up, with their thumbnails and, unless another file has it, their content.
+func (s *Server) purgeExpired(ctx context.Context) error {
+	expired, err := s.repo.Expired(ctx, s.now())
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, f := range expired {
+		if ctx.Err() != nil {
+			break
+		}
+		leftover, err := s.removeFile(ctx, f)
+		if errors.Is(err, errFileNotFound) {
+			// deleted since
+			continue
+		}
+		if err == nil {
+			err = publishDeleted(ctx, f, "expired")
+		}
+		errs = append(errs, err, leftover)
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,67 @@
+package files
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/events"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/pagination"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/testutil"
+)
+
+func TestUploadTTL(t *testing.T) {
+	cfg := testutil.Config(t)
+	cfg.Files.DefaultTTL, cfg.Files.MaxTTL = time.Hour, 24*time.Hour
+	deps := testutil.Deps(t, cfg)
+	clock := testutil.NewClock(time.Now())
+	deps.Clock = clock
+	s := NewUploads(deps)
+	router := NewRouter(Deps{Deps: deps, Files: s})
+	deleted := make(chan events.FileDeletedEvent, 1)
+	_, err := events.Subscribe(events.Default, events.FileDeleted, func(_ context.Context, ev events.FileDeletedEvent) {
+		deleted <- ev
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := upload(t, router, "/upload", "file", "kept.txt")
+	testutil.AssertStatus(t, w, http.StatusCreated)
+	kept := testutil.Decode[File](t, w)
+	if kept.ExpiresAt == nil || !kept.ExpiresAt.Equal(kept.UploadedAt.Add(time.Hour)) {
+		t.Errorf("expires_at = %v, want files.default_ttl after upload", kept.ExpiresAt)
+	}
+	for _, ttl := range []string{"soon", "-1h", "48h"} {
+		testutil.AssertStatus(t, upload(t, router, "/upload?ttl="+ttl, "file", "a.txt"), http.StatusBadRequest)
+	}
+
+	w = upload(t, router, "/upload?ttl=1m", "file", "gone.txt")
+	testutil.AssertStatus(t, w, http.StatusCreated)
+	gone := testutil.Decode[File](t, w)
+	// files expire by the deps' clock
+	clock.Advance(2 * time.Minute)
+	testutil.AssertStatus(t, testutil.Do(t, router, http.MethodGet, "/files/"+gone.ID, nil), http.StatusGone)
+	w = testutil.Do(t, router, http.MethodGet, "/files", nil)
+	if got := testutil.Decode[pagination.Page[File]](t, w).Total; got != 1 {
+		t.Errorf("listed %d files, want the expired one left out", got)
+	}
+
+	if err := s.purgeExpired(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.repo.Get(t.Context(), gone.ID); !errors.Is(err, errFileNotFound) {
+		t.Errorf("expired file after purge: err = %v, want errFileNotFound", err)
+	}
+	select {
+	case ev := <-deleted:
+		if ev.ID != gone.ID || ev.Reason != "expired" {
+			t.Errorf("file.deleted = %+v, want %s expired", ev, gone.ID)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("no file.deleted for the purged file")
+	}
+	testutil.AssertStatus(t, testutil.Do(t, router, http.MethodGet, "/files/"+kept.ID, nil), http.StatusOK)
+}
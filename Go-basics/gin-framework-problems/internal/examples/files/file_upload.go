@@ -0,0 +1,565 @@
+package files
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/apperror"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/audit"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/cache"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/config"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/events"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/auth"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/jobs"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/healthcheck"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/idempotency"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/metrics"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/pagination"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/query"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/scheduler"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/server"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/storage"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/tenant"
+)
+
+// invalidateList drops the cached GET /files answers after a file was
+// stored, moved or deleted.
+func (s *Server) invalidateList() {
+	s.listCache.Invalidate("/files")
+}
+
+func (s *Server) ensureUploadDir() error {
+	return os.MkdirAll(s.uploadDir, 0755)
+}
+
+// Dir is a directory other examples keep their own uploads in, such as
+// avatars and covers, usually storage.upload_dir. Subdirectories stay out of
+// GET /files.
+type Dir string
+
+// Save stores file as name, a slash-separated path under d, and creates its
+// directories as needed.
+func (d Dir) Save(c *gin.Context, file *multipart.FileHeader, name string) error {
+	dst := d.Path(name)
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	return c.SaveUploadedFile(file, dst)
+}
+
+// Remove deletes the file Save stored as name. One that is already gone is
+// not an error.
+func (d Dir) Remove(name string) error {
+	err := os.Remove(d.Path(name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// Path is where Save stores name.
+func (d Dir) Path(name string) string {
+	return filepath.Join(string(d), filepath.FromSlash(name))
+}
+
+// checkUploadDir is a readiness check: the upload dir must exist and be writable.
+func (s *Server) checkUploadDir(ctx context.Context) error {
+	if err := s.ensureUploadDir(); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(s.uploadDir, ".healthcheck-*")
+	if err != nil {
+		return err
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
+
+// publishUploaded announces a stored upload on the event bus and records it
+// in the audit log and metrics.
+func publishUploaded(c *gin.Context, f File) {
+	metrics.Upload(f.Size)
+	audit.Record(c, audit.Event{Action: audit.FileUpload, Outcome: audit.Success, Target: f.ID,
+		Details: map[string]string{"filename": f.Filename, "size": strconv.FormatInt(f.Size, 10)}})
+	err := events.Publish(c.Request.Context(), events.Default, events.FileUploaded,
+		events.FileUploadedEvent{ID: f.ID, Name: f.Filename, Size: f.Size, SHA256: f.SHA256, Uploader: f.Uploader})
+	if err != nil {
+		c.Error(err)
+	}
+}
+
+// publishDeleted announces on the event bus that f is gone, for reason.
+func publishDeleted(ctx context.Context, f File, reason string) error {
+	return events.Publish(ctx, events.Default, events.FileDeleted,
+		events.FileDeletedEvent{ID: f.ID, Name: f.Filename, Uploader: f.Uploader, Reason: reason})
+}
+
+// newFile is the metadata of an upload the caller is about to store, under
+// a new ID.
+func (s *Server) newFile(c *gin.Context, filename string, size int64, contentType string) File {
+	return File{
+		ID:          uuid.New().String(),
+		Filename:    filepath.Base(filename),
+		Size:        size,
+		ContentType: contentType,
+		Uploader:    uploader(c),
+		UploadedAt:  s.now().UTC(),
+	}
+}
+
+// store saves an uploaded file as f and announces it.
+func (s *Server) store(c *gin.Context, f File, open func() (io.ReadCloser, error)) error {
+	if err := s.commit(c.Request.Context(), f, open); err != nil {
+		return err
+	}
+	publishUploaded(c, f)
+	s.makeThumbnails(c, f)
+	return nil
+}
+
+// uploaderKey is where identify leaves the uploader's name.
+const uploaderKey = "files.uploader"
+
+// identify records whose token, checked by lookup, the request carries, for
+// uploader. Uploads don't need a login, so a token lookup refuses, such as
+// the users example's JWT that the gateway passes on, counts as anonymous
+// instead of failing the upload.
+func identify(lookup middleware.Authenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, ok := middleware.BearerToken(c.GetHeader("Authorization"))
+		if !ok {
+			return
+		}
+		u, err := lookup(token)
+		if err != nil {
+			return
+		}
+		info, _ := u.(auth.UserInfo)
+		// a token of another tenant's doesn't sign the upload, or its files
+		// would be the user's to delete on this one
+		if tenant.Visible(c.Request.Context(), info.Tenant) {
+			c.Set(uploaderKey, info.Username)
+		}
+	}
+}
+
+// uploader is the name of the user identify found, or empty for an
+// anonymous upload.
+func uploader(c *gin.Context) string {
+	return c.GetString(uploaderKey)
+}
+
+// uploadSingle stores the file in the form's "file" field, in ?folder= and
+// for ?ttl= or files.default_ttl. The form is streamed to disk, see
+// readForm, and nothing is stored unless the whole of it passes.
+func (s *Server) uploadSingle(c *gin.Context) {
+	ttl, err := s.uploadTTL(c)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	folder, err := s.uploadFolder(c)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	if err := s.ensureUploadDir(); err != nil {
+		middleware.Error(c, http.StatusInternalServerError, "cannot create upload dir")
+		return
+	}
+	form, err := s.readForm(c, s.singleRule, "file")
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	defer removeForm(form)
+	if len(form) == 0 {
+		middleware.Error(c, http.StatusBadRequest, "file is required")
+		return
+	}
+	f := s.fileOf(c, form[0])
+	f.Folder = folder
+	f.expireAfter(ttl)
+	release, err := s.reserveQuota(c.Request.Context(), f.Uploader, f.Size)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	defer release()
+	if err := checkChecksum(c, f); err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	if err := s.scanFiles(c, []File{f}, form); err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	if err := s.store(c, f, form[0].open); err != nil {
+		middleware.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.Header("Location", "/files/"+f.ID)
+	c.JSON(http.StatusCreated, f)
+}
+
+// uploadMultiple stores the files in the form's "files" fields, all or none,
+// each in ?folder= and for ?ttl= or files.default_ttl.
+func (s *Server) uploadMultiple(c *gin.Context) {
+	ttl, err := s.uploadTTL(c)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	folder, err := s.uploadFolder(c)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	if err := s.ensureUploadDir(); err != nil {
+		middleware.Error(c, http.StatusInternalServerError, "cannot create upload dir")
+		return
+	}
+	form, err := s.readForm(c, s.multiRule, "files")
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	defer removeForm(form)
+	if len(form) == 0 {
+		middleware.Error(c, http.StatusBadRequest, "no files provided")
+		return
+	}
+	prepared := make([]File, len(form))
+	var total int64
+	for i, ff := range form {
+		prepared[i] = s.fileOf(c, ff)
+		prepared[i].Folder = folder
+		prepared[i].expireAfter(ttl)
+		total += ff.Size
+	}
+	release, err := s.reserveQuota(c.Request.Context(), prepared[0].Uploader, total)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	defer release()
+	if err := s.scanFiles(c, prepared, form); err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	for i, ff := range form {
+		if err := s.store(c, prepared[i], ff.open); err != nil {
+			middleware.Error(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+	c.JSON(http.StatusCreated, gin.H{"files": prepared})
+}
+
+// TooLarge reports whether err is the BodyLimit cutting the upload off.
+func TooLarge(err error) bool {
+	var e *http.MaxBytesError
+	return errors.As(err, &e)
+}
+
+var fileSorts = query.Sorts[File]{
+	"filename":    func(a, b File) int { return strings.Compare(strings.ToLower(a.Filename), strings.ToLower(b.Filename)) },
+	"size":        func(a, b File) int { return cmp.Compare(a.Size, b.Size) },
+	"uploaded_at": func(a, b File) int { return a.UploadedAt.Compare(b.UploadedAt) },
+}
+
+// listFiles pages through the uploads, oldest first unless ?sort= says
+// otherwise, leaving out expired ones. ?folder= lists the files right in a
+// folder, "/" for the root, and ?prefix= those whose path, such as
+// reports/2024/q3.pdf, starts with it.
+func (s *Server) listFiles(c *gin.Context) {
+	var f struct {
+		Folder string `form:"folder"`
+		Prefix string `form:"prefix"`
+	}
+	req, err := query.Parse(c, fileSorts, "", &f)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	// an empty ?folder= is the root too
+	_, byFolder := c.GetQuery("folder")
+	folder, err := folderPath(f.Folder)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	prefix := strings.TrimPrefix(f.Prefix, "/")
+	all, err := s.List(c.Request.Context())
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	all = slices.DeleteFunc(all, func(f File) bool {
+		return byFolder && f.Folder != folder || !strings.HasPrefix(filePath(f), prefix)
+	})
+	// IDs are unique, so ties come out the same way on every page
+	fileSorts.Apply(all, req.Sort, func(a, b File) int { return strings.Compare(a.ID, b.ID) })
+	pagination.Write(c, pagination.NewPage(all, req.Page))
+}
+
+// List returns every upload that hasn't expired, oldest first.
+func (s *Server) List(ctx context.Context) ([]File, error) {
+	all, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now := s.now()
+	return slices.DeleteFunc(all, func(f File) bool { return f.Expired(now) }), nil
+}
+
+// Add stores content as a new file named filename in the root folder, as if
+// uploader had uploaded it, and announces it. It skips the upload rules and
+// the virus scan, so only trusted content, such as fixtures, goes through it.
+func (s *Server) Add(ctx context.Context, filename, uploader string, content []byte) (File, error) {
+	sum := sha256.Sum256(content)
+	f := File{
+		ID:          uuid.New().String(),
+		Filename:    filepath.Base(filename),
+		Size:        int64(len(content)),
+		ContentType: http.DetectContentType(content),
+		SHA256:      hex.EncodeToString(sum[:]),
+		Uploader:    uploader,
+		UploadedAt:  s.now().UTC(),
+	}
+	open := func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(content)), nil }
+	if err := s.commit(ctx, f, open); err != nil {
+		return File{}, err
+	}
+	err := events.Publish(ctx, events.Default, events.FileUploaded,
+		events.FileUploadedEvent{ID: f.ID, Name: f.Filename, Size: f.Size, SHA256: f.SHA256, Uploader: f.Uploader})
+	if err != nil {
+		slog.WarnContext(ctx, "publish file.uploaded", "error", err)
+	}
+	return f, nil
+}
+
+// downloadFile serves an upload's content with the type it was sniffed as,
+// under its name, inline unless ?disposition=attachment. Range requests get
+// part of it, so players can seek and clients resume; the content under an
+// ID never changes, which makes the ID a strong ETag for If-Range.
+func (s *Server) downloadFile(c *gin.Context) {
+	kind, err := disposition(c)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	f, err := s.getFile(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	content, err := s.blobs.Open(c.Request.Context(), blobName(f))
+	if errors.Is(err, storage.ErrNotExist) {
+		middleware.Fail(c, errFileNotFound)
+		return
+	}
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	defer content.Close()
+	typ, err := contentType(f, content)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	c.Header("Content-Type", typ)
+	// so a browser takes the type as it is, rather than sniffing HTML out
+	// of a text file
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Content-Disposition", contentDisposition(kind, typ, f.Filename))
+	c.Header("ETag", `"`+f.ID+`"`)
+	if sum, err := hex.DecodeString(f.SHA256); err == nil && len(sum) > 0 {
+		// RFC 9530: the whole file's digest, on partial responses too
+		c.Header("Repr-Digest", "sha-256=:"+base64.StdEncoding.EncodeToString(sum)+":")
+	}
+	serveContent(c, f.Filename, f.UploadedAt, content)
+}
+
+// fileMeta answers with what is known about an upload, without its content.
+func (s *Server) fileMeta(c *gin.Context) {
+	f, err := s.getFile(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, f)
+}
+
+// configureBlobs keeps content where storage.backend says. A bucket that
+// can't be reached fails readiness rather than startup.
+func (s *Server) configureBlobs(cfg *config.Config) {
+	st, err := storage.New(cfg.Storage)
+	if err != nil {
+		panic(err)
+	}
+	if p, ok := st.(interface{ Ping(context.Context) error }); ok {
+		healthcheck.Default.Register("storage", p.Ping)
+	}
+	s.blobs = storage.Traced(st, cfg.Storage.Backend)
+}
+
+// deleteFile removes an upload: its metadata, thumbnails and content, unless
+// another file has the same. Only the user who uploaded it, or one holding
+// files:manage, may; anonymous uploads have no owner and need files:manage.
+func (s *Server) deleteFile(c *gin.Context) {
+	ctx := c.Request.Context()
+	f, err := s.repo.Get(ctx, c.Param("id"))
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	if !mayChange(currentUser(c), f.Uploader) {
+		middleware.Fail(c, apperror.Forbidden("only the uploader or a file manager may delete a file"))
+		return
+	}
+	leftover, err := s.removeFile(ctx, f)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	if leftover != nil {
+		c.Error(leftover)
+	}
+	if err := publishDeleted(ctx, f, "deleted"); err != nil {
+		c.Error(err)
+	}
+	audit.Record(c, audit.Event{Action: audit.FileDelete, Outcome: audit.Success, Target: f.ID,
+		Details: map[string]string{"filename": f.Filename}})
+	c.Status(http.StatusNoContent)
+}
+
+// NewUploads returns a Server set up from deps.Config: metadata where
+// files.store says, content where storage.backend says, scanned by
+// files.scan.clamd, thumbnailed on a worker pool and listed through the
+// response cache, timed by deps' clock.
+func NewUploads(deps server.Deps) *Server {
+	cfg, hooks := deps.Config, deps.Hooks
+	s := NewServer(Options{Config: cfg, Now: deps.Now})
+	s.configureStore(cfg, hooks)
+	s.configureBlobs(cfg)
+	s.configureScanner(cfg)
+	if sizes := cfg.Files.ThumbnailSizes; len(sizes) > 0 {
+		pool := jobs.NewPool(cfg, hooks)
+		pool.Start()
+		s.thumbnailSizes = sizes
+		s.queueThumbnails = func(ctx context.Context, f File) error {
+			_, err := pool.Enqueue(ctx, "thumbnail", gin.H{"file": f.ID, "blob": blobName(f), "sizes": sizes})
+			return err
+		}
+	}
+	if cfg.Responses.Enabled {
+		s.listCache = cache.NewResponses("files_list", cfg.Responses.TTL, cfg.Responses.MaxEntries)
+	}
+	return s
+}
+
+// Deps are what NewRouter builds the example from.
+type Deps struct {
+	server.Deps
+	// the uploads served; NewUploads' when nil
+	Files *Server
+	// issues the tokens /login hands out and the routes check;
+	// auth.NewSessions' when nil
+	Auth *auth.Server
+}
+
+// NewRouter builds the file upload example router, serving deps.Files.
+func NewRouter(deps Deps) *gin.Engine {
+	cfg, hooks := deps.Config, deps.Hooks
+	s := deps.Files
+	if s == nil {
+		s = NewUploads(deps.Deps)
+	}
+	authn := deps.Auth
+	if authn == nil {
+		authn = auth.NewSessions(deps.Deps)
+	}
+	healthcheck.Default.Register("upload_dir", s.checkUploadDir)
+	tasks := deps.Tasks()
+	tasks.Register("file_gc", time.Hour, s.collectGarbage, scheduler.WithJitter(5*time.Minute))
+	tasks.Register("file_expiry", cfg.Files.ExpiryInterval, s.purgeExpired)
+
+	deps.APIDocs().Add(docs...)
+
+	router := server.NewEngine(deps.Deps)
+	hooks.Add(s.closeWatchers)
+	router.POST("/login", authn.Login)
+	s.Routes(router, cfg, authn.LookupToken)
+
+	// allow static access too if desired:
+	// router.Static("/uploads", s.uploadDir)
+
+	return router
+}
+
+// Routes mounts s's routes on router: /upload, /uploads, /files and
+// /folders, with the request limits and download access cfg sets, signed in
+// by the tokens lookup accepts.
+func (s *Server) Routes(router gin.IRouter, cfg *config.Config, lookup middleware.Authenticator) {
+	idem := idempotency.Middleware(idempotency.WithTTL(cfg.Idempotency.TTL))
+	// before idem, which wraps the body
+	upload := middleware.BodyLimit(cfg.Storage.MaxUploadBytes)
+	// uploads are anonymous unless signed in, which records the uploader;
+	// deleting and presigning need a login, and so does content with
+	// files.private_downloads, unless a presigned link is used
+	login := middleware.Auth(lookup)
+	whoami := identify(lookup)
+	// downloads are served as stored, so byte ranges count stored bytes
+	raw := middleware.WithoutCompression()
+	var contentLogin gin.HandlerFunc
+	archive := []gin.HandlerFunc{raw, s.downloadArchive}
+	if cfg.Files.PrivateDownloads {
+		contentLogin = login
+		archive = []gin.HandlerFunc{raw, login, s.downloadArchive}
+	}
+	content := s.downloadAccess(contentLogin)
+
+	router.POST("/upload", upload, whoami, idem, s.uploadSingle)
+	router.POST("/upload/multi", upload, whoami, idem, s.uploadMultiple)
+	router.POST("/uploads", whoami, idem, s.createUpload)
+	router.GET("/uploads/:id", s.uploadStatus)
+	router.HEAD("/uploads/:id", s.uploadStatus)
+	router.GET("/uploads/:id/progress", middleware.WithoutTimeout(), s.uploadProgress)
+	router.PATCH("/uploads/:id", upload, s.uploadChunk)
+	router.POST("/uploads/:id/complete", s.completeUpload)
+	router.DELETE("/uploads/:id", s.abortUpload)
+	router.GET("/files", s.listCache.Middleware(), s.listFiles)
+	router.GET("/files/usage", login, s.fileUsage)
+	router.POST("/folders", login, s.createFolder)
+	router.GET("/folders", s.listFolders)
+	router.DELETE("/folders/*path", login, s.deleteFolder)
+	router.POST("/files/archive", archive...)
+	router.GET("/files/:id", raw, content, s.downloadFile)
+	router.HEAD("/files/:id", raw, content, s.downloadFile)
+	router.GET("/files/:id/meta", s.fileMeta)
+	router.GET("/files/:id/thumb", raw, content, s.getThumbnail)
+	router.PATCH("/files/:id", login, s.moveFile)
+	router.POST("/files/:id/presign", login, s.presignDownload)
+	router.DELETE("/files/:id", login, s.deleteFile)
+}
@@ -0,0 +1,525 @@
+package files
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/antivirus"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/config"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/pagination"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/server"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/storage"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/tenant"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/testutil"
+)
+
+// newRouter builds the example on a Server of its own, configured by cfg
+// (testutil.Config when nil), and returns that Server too.
+func newRouter(t *testing.T, cfg *config.Config) (*gin.Engine, *Server) {
+	t.Helper()
+	deps := testutil.Deps(t, cfg)
+	s := NewUploads(deps)
+	return NewRouter(Deps{Deps: deps, Files: s}), s
+}
+
+// upload posts content under each name as a multipart field.
+func upload(t *testing.T, h http.Handler, path, field string, names ...string) *httptest.ResponseRecorder {
+	t.Helper()
+	files := make([]testutil.File, len(names))
+	for i, name := range names {
+		files[i] = testutil.File{Field: field, Name: name, Content: []byte("hello, " + name)}
+	}
+	return testutil.DoMultipart(t, h, http.MethodPost, path, nil, files)
+}
+
+func TestUpload(t *testing.T) {
+	router, _ := newRouter(t, nil)
+	tests := []struct {
+		name   string
+		path   string
+		field  string
+		files  []string
+		status int
+	}{
+		{"single", "/upload", "file", []string{"a.txt"}, http.StatusCreated},
+		{"single, wrong field", "/upload", "files", []string{"a.txt"}, http.StatusBadRequest},
+		{"multi", "/upload/multi", "files", []string{"b.txt", "c.txt"}, http.StatusCreated},
+		{"multi, none", "/upload/multi", "files", nil, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testutil.AssertStatus(t, upload(t, router, tt.path, tt.field, tt.files...), tt.status)
+		})
+	}
+
+	w := testutil.Do(t, router, http.MethodGet, "/files", nil)
+	testutil.AssertStatus(t, w, http.StatusOK)
+	listed := testutil.Decode[pagination.Page[File]](t, w).Items
+	var names []string
+	for _, f := range listed {
+		names = append(names, f.Filename)
+	}
+	if !slices.Equal(names, []string{"a.txt", "b.txt", "c.txt"}) {
+		t.Fatalf("files = %q, want the three uploaded", names)
+	}
+	b := listed[1]
+	w = testutil.Do(t, router, http.MethodGet, "/files/"+b.ID, nil)
+	testutil.AssertStatus(t, w, http.StatusOK)
+	if got := w.Body.String(); got != "hello, b.txt" {
+		t.Errorf("downloaded %q, want the uploaded content", got)
+	}
+	if got := w.Header().Get("Content-Disposition"); !strings.Contains(got, "b.txt") {
+		t.Errorf("Content-Disposition = %q, want the uploaded name", got)
+	}
+	w = testutil.Do(t, router, http.MethodGet, "/files/"+b.ID+"/meta", nil)
+	testutil.AssertStatus(t, w, http.StatusOK)
+	if got := testutil.Decode[File](t, w); got.Filename != "b.txt" || got.Size != int64(len("hello, b.txt")) || got.ContentType != "text/plain" {
+		t.Errorf("meta = %+v, want b.txt's", got)
+	}
+	testutil.AssertStatus(t, testutil.Do(t, router, http.MethodGet, "/files/b.txt", nil), http.StatusNotFound)
+	testutil.AssertStatus(t, testutil.Do(t, router, http.MethodGet, "/files/missing/meta", nil), http.StatusNotFound)
+}
+
+// TestConcurrentUploads checks that uploads sent at once are each stored
+// whole under an ID of their own, and only on the Server they were sent to.
+func TestConcurrentUploads(t *testing.T) {
+	router, s := newRouter(t, nil)
+	other, _ := newRouter(t, nil)
+
+	const n = 20
+	ids := make([]string, n)
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Go(func() {
+			name := fmt.Sprintf("part-%02d.txt", i)
+			w := testutil.DoMultipart(t, router, http.MethodPost, "/upload", nil,
+				[]testutil.File{{Field: "file", Name: name, Content: []byte(strings.Repeat(name, 100))}})
+			if w.Code != http.StatusCreated {
+				t.Errorf("upload %s: status = %d; body: %s", name, w.Code, w.Body.String())
+				return
+			}
+			ids[i] = testutil.Decode[File](t, w).ID
+		})
+	}
+	wg.Wait()
+
+	all, err := s.List(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != n {
+		t.Fatalf("stored %d files, want %d", len(all), n)
+	}
+	for i, id := range ids {
+		name := fmt.Sprintf("part-%02d.txt", i)
+		w := testutil.Do(t, router, http.MethodGet, "/files/"+id, nil)
+		testutil.AssertStatus(t, w, http.StatusOK)
+		if got := w.Body.String(); got != strings.Repeat(name, 100) {
+			t.Errorf("%s: downloaded %d bytes, not its own content", name, len(got))
+		}
+		testutil.AssertStatus(t, testutil.Do(t, other, http.MethodGet, "/files/"+id, nil), http.StatusNotFound)
+	}
+}
+
+func TestUploadSameName(t *testing.T) {
+	router, _ := newRouter(t, nil)
+	first := testutil.Decode[File](t, upload(t, router, "/upload", "file", "report.txt"))
+	w := upload(t, router, "/upload", "file", "report.txt")
+	testutil.AssertStatus(t, w, http.StatusCreated)
+	second := testutil.Decode[File](t, w)
+	if first.ID == second.ID {
+		t.Fatalf("both uploads got ID %s", first.ID)
+	}
+	if got := w.Header().Get("Location"); got != "/files/"+second.ID {
+		t.Errorf("Location = %q, want the new file's URL", got)
+	}
+	w = testutil.Do(t, router, http.MethodGet, "/files", nil)
+	if got := testutil.Decode[pagination.Page[File]](t, w).Total; got != 2 {
+		t.Errorf("total = %d, want both uploads kept", got)
+	}
+}
+
+// repositories returns one of each FileRepository, the SQLite one in a temp
+// file.
+func repositories(t *testing.T) map[string]FileRepository {
+	var hooks server.Hooks
+	db := server.OpenDB(testutil.Config(t).Database.Path, &hooks)
+	t.Cleanup(func() {
+		for _, stop := range hooks {
+			stop(context.Background())
+		}
+	})
+	return map[string]FileRepository{"memory": NewMemoryRepository(), "sqlite": NewSQLiteRepository(db)}
+}
+
+func TestRepository(t *testing.T) {
+	for name, r := range repositories(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := t.Context()
+			at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+			// the scoped wrapper always sets a tenant, which SQLite needs
+			a := File{ID: "a", Filename: "same.txt", Size: 1, ContentType: "text/plain", SHA256: "ab", Uploader: "alice", UploadedAt: at, Tenant: tenant.DefaultID}
+			b := File{ID: "b", Filename: "same.txt", Size: 2, ContentType: "text/plain", SHA256: "ab", UploadedAt: at.Add(time.Second), Tenant: tenant.DefaultID}
+			for _, f := range []File{a, b} {
+				if err := r.Create(ctx, f); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got, err := r.Get(ctx, "a")
+			if err != nil || got != a {
+				t.Errorf("Get(a) = %+v, %v; want %+v", got, err, a)
+			}
+			all, err := r.List(ctx)
+			if err != nil || !slices.Equal(all, []File{a, b}) {
+				t.Errorf("List = %+v, %v; want both, oldest first", all, err)
+			}
+			if n, err := r.Refs(ctx, "ab"); err != nil || n != 2 {
+				t.Errorf("Refs = %d, %v; want both files", n, err)
+			}
+			if n, err := r.Usage(ctx, "alice"); err != nil || n != 1 {
+				t.Errorf("Usage(alice) = %d, %v; want 1", n, err)
+			}
+			if expired, err := r.Expired(ctx, at.Add(time.Hour)); err != nil || len(expired) != 0 {
+				t.Errorf("Expired = %+v, %v; want none of files kept for good", expired, err)
+			}
+			if err := r.Delete(ctx, "a"); err != nil {
+				t.Fatal(err)
+			}
+			if n, err := r.Refs(ctx, "ab"); err != nil || n != 1 {
+				t.Errorf("Refs after Delete = %d, %v; want 1", n, err)
+			}
+			if n, err := r.Usage(ctx, "alice"); err != nil || n != 0 {
+				t.Errorf("Usage(alice) after Delete = %d, %v; want 0", n, err)
+			}
+			if _, err := r.Get(ctx, "a"); !errors.Is(err, errFileNotFound) {
+				t.Errorf("Get after Delete: err = %v, want errFileNotFound", err)
+			}
+			if err := r.Delete(ctx, "a"); !errors.Is(err, errFileNotFound) {
+				t.Errorf("second Delete: err = %v, want errFileNotFound", err)
+			}
+		})
+	}
+}
+
+func TestUploadValidation(t *testing.T) {
+	router, s := newRouter(t, nil)
+	post := func(path string, files map[string]string) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		for name, content := range files {
+			part, err := mw.CreateFormFile("files", name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			part.Write([]byte(content))
+		}
+		mw.Close()
+		return testutil.Do(t, router, http.MethodPost, path, &body, testutil.WithHeader("Content-Type", mw.FormDataContentType()))
+	}
+
+	// the name says PNG, the content says program
+	w := post("/upload/multi", map[string]string{"ok.txt": "hello", "cat.png": "\x7fELF\x02\x01\x01\x00\x00\x00"})
+	testutil.AssertStatus(t, w, http.StatusUnsupportedMediaType)
+	w = testutil.Do(t, router, http.MethodGet, "/files", nil)
+	if got := testutil.Decode[pagination.Page[File]](t, w).Total; got != 0 {
+		t.Errorf("%d files stored, want none of a refused request", got)
+	}
+
+	many := map[string]string{}
+	for i := range 11 {
+		many["f"+strconv.Itoa(i)+".txt"] = "hello"
+	}
+	testutil.AssertStatus(t, post("/upload/multi", many), http.StatusRequestEntityTooLarge)
+
+	// cut off at the byte past the limit, leaving no temp file behind
+	big := strings.Repeat("x", int(s.multiRule.MaxFileBytes)+1)
+	testutil.AssertStatus(t, post("/upload/multi", map[string]string{"ok.txt": "hello", "big.txt": big}), http.StatusRequestEntityTooLarge)
+	if tmp, _ := filepath.Glob(filepath.Join(s.uploadDir, ".tmp-upload-*")); len(tmp) != 0 {
+		t.Errorf("temp files left: %v", tmp)
+	}
+}
+
+func TestResumableUpload(t *testing.T) {
+	router, _ := newRouter(t, nil)
+	w := testutil.DoJSON(t, router, http.MethodPost, "/uploads", map[string]any{"filename": "big.txt", "size": 10})
+	testutil.AssertStatus(t, w, http.StatusCreated)
+	path := w.Header().Get("Location")
+
+	chunk := func(offset, body string) *httptest.ResponseRecorder {
+		return testutil.Do(t, router, http.MethodPatch, path, strings.NewReader(body),
+			testutil.WithHeader("Content-Type", offsetContentType), testutil.WithHeader("Upload-Offset", offset))
+	}
+	testutil.AssertStatus(t, chunk("0", "hello"), http.StatusNoContent)
+	testutil.AssertStatus(t, testutil.Do(t, router, http.MethodPost, path+"/complete", nil), http.StatusConflict)
+
+	// a client that lost track is told where to carry on
+	w = chunk("2", "llo, ")
+	testutil.AssertStatus(t, w, http.StatusConflict)
+	if got := w.Header().Get("Upload-Offset"); got != "5" {
+		t.Errorf("Upload-Offset after a wrong offset = %q, want 5", got)
+	}
+	w = testutil.Do(t, router, http.MethodHead, path, nil)
+	if got := w.Header().Get("Upload-Offset"); got != "5" {
+		t.Errorf("HEAD Upload-Offset = %q, want 5", got)
+	}
+	testutil.AssertStatus(t, chunk("5", "world!"), http.StatusRequestEntityTooLarge)
+	testutil.AssertStatus(t, chunk("5", "world"), http.StatusNoContent)
+
+	w = testutil.Do(t, router, http.MethodPost, path+"/complete", nil)
+	testutil.AssertStatus(t, w, http.StatusCreated)
+	w = testutil.Do(t, router, http.MethodGet, w.Header().Get("Location"), nil)
+	testutil.AssertStatus(t, w, http.StatusOK)
+	if got := w.Body.String(); got != "helloworld" {
+		t.Errorf("downloaded %q, want the chunks joined", got)
+	}
+	testutil.AssertStatus(t, testutil.Do(t, router, http.MethodGet, path, nil), http.StatusNotFound)
+}
+
+func TestThumbnails(t *testing.T) {
+	router, _ := newRouter(t, nil)
+	var src bytes.Buffer
+	png.Encode(&src, image.NewRGBA(image.Rect(0, 0, 1000, 500)))
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, _ := mw.CreateFormFile("file", "wide.png")
+	part.Write(src.Bytes())
+	mw.Close()
+	w := testutil.Do(t, router, http.MethodPost, "/upload", &body, testutil.WithHeader("Content-Type", mw.FormDataContentType()))
+	testutil.AssertStatus(t, w, http.StatusCreated)
+	path := "/files/" + testutil.Decode[File](t, w).ID + "/thumb"
+
+	// made in the background, so wait for the larger one
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		w = testutil.Do(t, router, http.MethodGet, path+"?size=512", nil)
+		if w.Code != http.StatusNotFound || time.Now().After(deadline) {
+			break
+		}
+		if w.Header().Get("Retry-After") == "" {
+			t.Fatal("404 for a thumbnail not made yet has no Retry-After")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	testutil.AssertStatus(t, w, http.StatusOK)
+	for query, want := range map[string]image.Point{"?size=512": image.Pt(512, 256), "": image.Pt(128, 64)} {
+		w := testutil.Do(t, router, http.MethodGet, path+query, nil)
+		testutil.AssertStatus(t, w, http.StatusOK)
+		img, err := png.Decode(w.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := img.Bounds().Size(); got != want {
+			t.Errorf("thumbnail%s is %v, want %v", query, got, want)
+		}
+	}
+	testutil.AssertStatus(t, testutil.Do(t, router, http.MethodGet, path+"?size=100", nil), http.StatusBadRequest)
+
+	// other files skip the pipeline
+	w = upload(t, router, "/upload", "file", "a.txt")
+	id := testutil.Decode[File](t, w).ID
+	testutil.AssertStatus(t, testutil.Do(t, router, http.MethodGet, "/files/"+id+"/thumb", nil), http.StatusNotFound)
+}
+
+func TestDeleteFile(t *testing.T) {
+	router, s := newRouter(t, nil)
+	alice := testutil.Login(t, router, "/login", "alice", "password1")
+	bob := testutil.Login(t, router, "/login", "bob", "adminpass")
+	// upload posts as whoever the token belongs to
+	uploadAs := func(token string) File {
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		part, _ := mw.CreateFormFile("file", "a.txt")
+		part.Write([]byte("hello"))
+		mw.Close()
+		w := testutil.Do(t, router, http.MethodPost, "/upload", &body,
+			testutil.WithHeader("Content-Type", mw.FormDataContentType()), testutil.WithToken(token))
+		testutil.AssertStatus(t, w, http.StatusCreated)
+		return testutil.Decode[File](t, w)
+	}
+	mine, theirs, anonymous := uploadAs(alice), uploadAs(bob), uploadAs("")
+	if mine.Uploader != "alice" || anonymous.Uploader != "" {
+		t.Fatalf("uploaders = %q and %q, want alice and none", mine.Uploader, anonymous.Uploader)
+	}
+
+	tests := []struct {
+		name   string
+		id     string
+		token  string
+		status int
+	}{
+		{"no token", mine.ID, "", http.StatusUnauthorized},
+		{"someone else's", theirs.ID, alice, http.StatusForbidden},
+		{"anonymous upload", anonymous.ID, alice, http.StatusForbidden},
+		{"own", mine.ID, alice, http.StatusNoContent},
+		{"gone", mine.ID, alice, http.StatusNotFound},
+		{"admin, anonymous upload", anonymous.ID, bob, http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts []testutil.RequestOption
+			if tt.token != "" {
+				opts = append(opts, testutil.WithToken(tt.token))
+			}
+			testutil.AssertStatus(t, testutil.Do(t, router, http.MethodDelete, "/files/"+tt.id, nil, opts...), tt.status)
+		})
+	}
+	testutil.AssertStatus(t, testutil.Do(t, router, http.MethodGet, "/files/"+mine.ID, nil), http.StatusNotFound)
+	if _, err := s.blobs.Stat(t.Context(), mine.ID); !errors.Is(err, storage.ErrNotExist) {
+		t.Errorf("content after delete: err = %v, want ErrNotExist", err)
+	}
+	testutil.AssertStatus(t, testutil.Do(t, router, http.MethodGet, "/files/"+theirs.ID, nil), http.StatusOK)
+}
+
+// TestTenantTokens checks that the auth example's accounts, shared by every
+// tenant, only act on the tenant their token was issued on.
+func TestTenantTokens(t *testing.T) {
+	cfg := testutil.Config(t)
+	cfg.Tenancy.Enabled = true
+	cfg.Tenancy.Tenants = map[string]string{"acme": "Acme"}
+	router, _ := newRouter(t, cfg)
+	acme := testutil.WithHeader(cfg.Tenancy.Header, "acme")
+	alice := testutil.Login(t, router, "/login", "alice", "password1")
+	aliceAcme := testutil.Login(t, router, "/login", "alice", "password1", acme)
+
+	uploadAs := func(token string, opts ...testutil.RequestOption) File {
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		part, _ := mw.CreateFormFile("file", "a.txt")
+		part.Write([]byte("hello"))
+		mw.Close()
+		opts = append(opts, testutil.WithHeader("Content-Type", mw.FormDataContentType()), testutil.WithToken(token))
+		w := testutil.Do(t, router, http.MethodPost, "/upload", &body, opts...)
+		testutil.AssertStatus(t, w, http.StatusCreated)
+		return testutil.Decode[File](t, w)
+	}
+	mine := uploadAs(alice)
+	if f := uploadAs(aliceAcme); f.Uploader != "" {
+		t.Errorf("uploader with an acme token = %q, want none", f.Uploader)
+	}
+
+	path := "/files/" + mine.ID
+	testutil.AssertStatus(t, testutil.Do(t, router, http.MethodDelete, path, nil, testutil.WithToken(aliceAcme)), http.StatusForbidden)
+	testutil.AssertStatus(t, testutil.Do(t, router, http.MethodDelete, path, nil, testutil.WithToken(aliceAcme), acme), http.StatusNotFound)
+	testutil.AssertStatus(t, testutil.Do(t, router, http.MethodDelete, path, nil, testutil.WithToken(alice), acme), http.StatusForbidden)
+	testutil.AssertStatus(t, testutil.Do(t, router, http.MethodDelete, path, nil, testutil.WithToken(alice)), http.StatusNoContent)
+}
+
+// stubScanner flags content containing "EICAR", or fails as unavailable.
+type stubScanner struct{ down bool }
+
+func (s stubScanner) Scan(_ context.Context, r io.Reader) (antivirus.Verdict, error) {
+	if s.down {
+		return antivirus.Verdict{}, fmt.Errorf("%w: stub", antivirus.ErrUnavailable)
+	}
+	b, err := io.ReadAll(r)
+	if bytes.Contains(b, []byte("EICAR")) {
+		return antivirus.Verdict{Infected: true, Signature: "Eicar-Signature"}, err
+	}
+	return antivirus.Verdict{}, err
+}
+
+func TestUploadScan(t *testing.T) {
+	router, s := newRouter(t, nil)
+	t.Cleanup(func() { s.scanner, s.failOpen = nil, false })
+	post := func(content string) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		part, _ := mw.CreateFormFile("file", "a.txt")
+		part.Write([]byte(content))
+		mw.Close()
+		return testutil.Do(t, router, http.MethodPost, "/upload", &body, testutil.WithHeader("Content-Type", mw.FormDataContentType()))
+	}
+
+	s.scanner = stubScanner{}
+	testutil.AssertStatus(t, post("hello"), http.StatusCreated)
+	w := post("hello EICAR")
+	testutil.AssertStatus(t, w, http.StatusUnprocessableEntity)
+	id := testutil.Decode[struct {
+		Details map[string]string `json:"details"`
+	}](t, w).Details["quarantine_id"]
+	if _, err := s.blobs.Stat(t.Context(), "quarantine/"+id); err != nil {
+		t.Errorf("quarantined content: %v", err)
+	}
+
+	s.scanner = stubScanner{down: true}
+	testutil.AssertStatus(t, post("hello"), http.StatusServiceUnavailable)
+	s.failOpen = true
+	testutil.AssertStatus(t, post("hello"), http.StatusCreated)
+
+	w = testutil.Do(t, router, http.MethodGet, "/files", nil)
+	if got := testutil.Decode[pagination.Page[File]](t, w).Total; got != 2 {
+		t.Errorf("%d files stored, want the clean one and the one let through", got)
+	}
+}
+
+func TestUploadChecksum(t *testing.T) {
+	router, s := newRouter(t, nil)
+	post := func(checksum string) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		part, _ := mw.CreateFormFile("file", "same.txt")
+		part.Write([]byte("identical"))
+		mw.Close()
+		opts := []testutil.RequestOption{testutil.WithHeader("Content-Type", mw.FormDataContentType())}
+		if checksum != "" {
+			opts = append(opts, testutil.WithHeader(checksumHeader, checksum))
+		}
+		return testutil.Do(t, router, http.MethodPost, "/upload", &body, opts...)
+	}
+	sum := sha256.Sum256([]byte("identical"))
+	want := hex.EncodeToString(sum[:])
+
+	w := post(strings.ToUpper(want))
+	testutil.AssertStatus(t, w, http.StatusCreated)
+	first := testutil.Decode[File](t, w)
+	if first.SHA256 != want {
+		t.Errorf("sha256 = %q, want %q", first.SHA256, want)
+	}
+	testutil.AssertStatus(t, post(strings.Repeat("0", 64)), http.StatusBadRequest)
+	testutil.AssertStatus(t, post("xyz"), http.StatusBadRequest)
+	w = post("")
+	testutil.AssertStatus(t, w, http.StatusCreated)
+	second := testutil.Decode[File](t, w)
+
+	// two files, one blob
+	blobsList, err := s.blobs.List(t.Context(), "sha256/")
+	if err != nil || len(blobsList) != 1 {
+		t.Fatalf("blobs = %v, %v; want one for both files", blobsList, err)
+	}
+	w = testutil.Do(t, router, http.MethodGet, "/files/"+second.ID, nil)
+	if got := w.Header().Get("Repr-Digest"); got != "sha-256=:"+base64.StdEncoding.EncodeToString(sum[:])+":" {
+		t.Errorf("Repr-Digest = %q", got)
+	}
+
+	bob := testutil.Login(t, router, "/login", "bob", "adminpass")
+	testutil.AssertStatus(t, testutil.Do(t, router, http.MethodDelete, "/files/"+first.ID, nil, testutil.WithToken(bob)), http.StatusNoContent)
+	w = testutil.Do(t, router, http.MethodGet, "/files/"+second.ID, nil)
+	testutil.AssertStatus(t, w, http.StatusOK)
+	if got := w.Body.String(); got != "identical" {
+		t.Errorf("other file after delete = %q, want its content kept", got)
+	}
+	testutil.AssertStatus(t, testutil.Do(t, router, http.MethodDelete, "/files/"+second.ID, nil, testutil.WithToken(bob)), http.StatusNoContent)
+	if _, err := s.blobs.Stat(t.Context(), "sha256/"+want); !errors.Is(err, storage.ErrNotExist) {
+		t.Errorf("blob after the last file is deleted: err = %v, want ErrNotExist", err)
+	}
+}
@@ -0,0 +1,235 @@
+package files
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/apperror"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/audit"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/auth"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/rbac"
+)
+
+// Folders are paths such as reports/2024, kept in the metadata with the
+// files in them. A path from a client is checked, never cleaned up into
+// something else, and is only ever a key: nothing is stored under it.
+
+const (
+	maxFolderDepth = 16
+	maxNameBytes   = 255
+)
+
+// folderPath checks a folder path from a client: names separated by "/",
+// none empty, "." or "..", or with a backslash or control character, at
+// most maxFolderDepth of them. Slashes at either end are dropped, and ""
+// is the root.
+func folderPath(raw string) (string, error) {
+	path := strings.Trim(raw, "/")
+	if path == "" {
+		return "", nil
+	}
+	names := strings.Split(path, "/")
+	if len(names) > maxFolderDepth {
+		return "", apperror.Validation("invalid folder path",
+			map[string]string{"folder": fmt.Sprintf("must be at most %d folders deep", maxFolderDepth)})
+	}
+	for _, name := range names {
+		if !validName(name) {
+			return "", apperror.Validation("invalid folder path",
+				map[string]string{"folder": "must be names separated by /, none empty, . or .."})
+		}
+	}
+	return path, nil
+}
+
+// fileName checks a name a client gives a file: one name, not a path, and
+// not starting with a dot.
+func fileName(raw string) (string, error) {
+	name := strings.TrimSpace(raw)
+	if !validName(name) || strings.HasPrefix(name, ".") {
+		return "", apperror.Validation("invalid request body",
+			map[string]string{"filename": "must be a file name not starting with a dot"})
+	}
+	return name, nil
+}
+
+func validName(name string) bool {
+	return name != "" && name != "." && name != ".." && len(name) <= maxNameBytes &&
+		!strings.ContainsFunc(name, func(r rune) bool { return r == '/' || r == '\\' || unicode.IsControl(r) })
+}
+
+// parentFolder is the folder path is in, "" for the root.
+func parentFolder(path string) string {
+	i := strings.LastIndex(path, "/")
+	if i < 0 {
+		return ""
+	}
+	return path[:i]
+}
+
+// uploadFolder is the folder ?folder= puts an upload in, which must exist.
+// The repository checks again when the file is stored, in case it was
+// deleted since.
+func (s *Server) uploadFolder(c *gin.Context) (string, error) {
+	path, err := folderPath(c.Query("folder"))
+	if err != nil || path == "" {
+		return path, err
+	}
+	if _, err := s.repo.GetFolder(c.Request.Context(), path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// mayChange reports whether user may move or delete what owner created:
+// owners may, and so may holders of files:manage. Anything without an owner
+// needs files:manage.
+func mayChange(user auth.UserInfo, owner string) bool {
+	return owner != "" && owner == user.Username || user.Can(rbac.FilesManage)
+}
+
+func currentUser(c *gin.Context) auth.UserInfo {
+	u, _ := auth.CurrentUser(c)
+	return u
+}
+
+// createFolder makes the folder at the body's path, inside a parent that
+// must exist.
+func (s *Server) createFolder(c *gin.Context) {
+	var req struct {
+		Path string `json:"path" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.BindError(c, err)
+		return
+	}
+	path, err := folderPath(req.Path)
+	if err == nil && path == "" {
+		err = apperror.Validation("invalid folder path", map[string]string{"folder": "the root always exists"})
+	}
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	d := Folder{Path: path, Owner: currentUser(c).Username, CreatedAt: s.now().UTC()}
+	err = s.repo.CreateFolder(c.Request.Context(), d)
+	if errors.Is(err, errFolderNotFound) {
+		err = apperror.NotFound("parent folder not found")
+	}
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	c.Header("Location", "/folders/"+path)
+	c.JSON(http.StatusCreated, d)
+}
+
+// listFolders answers with the folders right inside ?parent=, the root by
+// default.
+func (s *Server) listFolders(c *gin.Context) {
+	ctx := c.Request.Context()
+	parent, err := folderPath(c.Query("parent"))
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	if parent != "" {
+		if _, err := s.repo.GetFolder(ctx, parent); err != nil {
+			middleware.Fail(c, err)
+			return
+		}
+	}
+	folders, err := s.repo.Folders(ctx, parent)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"parent": parent, "folders": folders})
+}
+
+// deleteFolder removes an empty folder. Its creator may, or a file manager.
+func (s *Server) deleteFolder(c *gin.Context) {
+	ctx := c.Request.Context()
+	path, err := folderPath(c.Param("path"))
+	if err == nil && path == "" {
+		err = apperror.Validation("invalid folder path", map[string]string{"folder": "the root can't be deleted"})
+	}
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	d, err := s.repo.GetFolder(ctx, path)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	if !mayChange(currentUser(c), d.Owner) {
+		middleware.Fail(c, apperror.Forbidden("only the creator or a file manager may delete a folder"))
+		return
+	}
+	if err := s.repo.DeleteFolder(ctx, path); err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// moveFile puts a file in another folder, renames it, or both:
+// {"folder": "reports/2024", "filename": "q3.pdf"}, either left out to keep
+// it. Its uploader may, or a file manager.
+func (s *Server) moveFile(c *gin.Context) {
+	var req struct {
+		Folder   *string `json:"folder"`
+		Filename *string `json:"filename"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.BindError(c, err)
+		return
+	}
+	ctx := c.Request.Context()
+	f, err := s.getFile(ctx, c.Param("id"))
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	if !mayChange(currentUser(c), f.Uploader) {
+		middleware.Fail(c, apperror.Forbidden("only the uploader or a file manager may move a file"))
+		return
+	}
+	folder, name := f.Folder, f.Filename
+	if req.Folder != nil {
+		if folder, err = folderPath(*req.Folder); err != nil {
+			middleware.Fail(c, err)
+			return
+		}
+	}
+	if req.Filename != nil {
+		if name, err = fileName(*req.Filename); err != nil {
+			middleware.Fail(c, err)
+			return
+		}
+	}
+	moved, err := s.repo.Move(ctx, f.ID, folder, name)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	s.invalidateList()
+	audit.Record(c, audit.Event{Action: audit.FileMove, Outcome: audit.Success, Target: f.ID,
+		Details: map[string]string{"from": filePath(f), "to": filePath(moved)}})
+	c.JSON(http.StatusOK, moved)
+}
+
+// filePath is where f is, such as reports/2024/q3.pdf.
+func filePath(f File) string {
+	if f.Folder == "" {
+		return f.Filename
+	}
+	return f.Folder + "/" + f.Filename
+}
@@ -0,0 +1,94 @@
+package files
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/pagination"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/testutil"
+)
+
+func TestFolderPath(t *testing.T) {
+	tests := []struct {
+		raw, want string
+		ok        bool
+	}{
+		{"", "", true},
+		{"/", "", true},
+		{"reports/2024/", "reports/2024", true},
+		{"reports//2024", "", false},
+		{"reports/../etc", "", false},
+		{"./reports", "", false},
+		{`reports\2024`, "", false},
+		{"reports/\x00", "", false},
+	}
+	for _, tt := range tests {
+		got, err := folderPath(tt.raw)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("folderPath(%q) = %q, %v; want %q, ok %v", tt.raw, got, err, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestFolders(t *testing.T) {
+	router, _ := newRouter(t, nil)
+	alice := testutil.Login(t, router, "/login", "alice", "password1")
+	mkdir := func(path string) int {
+		return testutil.DoJSON(t, router, http.MethodPost, "/folders", gin.H{"path": path}, testutil.WithToken(alice)).Code
+	}
+
+	if code := mkdir("reports/2024"); code != http.StatusNotFound {
+		t.Errorf("folder without its parent: status = %d, want 404", code)
+	}
+	if code := mkdir("reports"); code != http.StatusCreated {
+		t.Fatalf("status = %d, want 201", code)
+	}
+	if code := mkdir("reports"); code != http.StatusConflict {
+		t.Errorf("same folder twice: status = %d, want 409", code)
+	}
+	if code := mkdir("reports/2024"); code != http.StatusCreated {
+		t.Fatalf("status = %d, want 201", code)
+	}
+	testutil.AssertStatus(t, testutil.Do(t, router, http.MethodGet, "/folders?parent=reports", nil), http.StatusOK)
+
+	testutil.AssertStatus(t, upload(t, router, "/upload?folder=missing", "file", "a.txt"), http.StatusNotFound)
+	w := upload(t, router, "/upload?folder=reports/2024", "file", "a.txt")
+	testutil.AssertStatus(t, w, http.StatusCreated)
+	f := testutil.Decode[File](t, w)
+	if f.Folder != "reports/2024" {
+		t.Errorf("folder = %q, want reports/2024", f.Folder)
+	}
+	testutil.AssertStatus(t, upload(t, router, "/upload", "file", "b.txt"), http.StatusCreated)
+	list := func(query string) int {
+		w := testutil.Do(t, router, http.MethodGet, "/files?"+query, nil)
+		testutil.AssertStatus(t, w, http.StatusOK)
+		return testutil.Decode[pagination.Page[File]](t, w).Total
+	}
+	if n := list("folder=reports/2024"); n != 1 {
+		t.Errorf("files in reports/2024 = %d, want 1", n)
+	}
+	if n := list("folder=/"); n != 1 {
+		t.Errorf("files in the root = %d, want 1", n)
+	}
+	if n := list("prefix=reports/"); n != 1 {
+		t.Errorf("files under reports/ = %d, want 1", n)
+	}
+
+	// anonymous uploads are only moved by file managers
+	testutil.AssertStatus(t, testutil.DoJSON(t, router, http.MethodPatch, "/files/"+f.ID, gin.H{"folder": ""}, testutil.WithToken(alice)),
+		http.StatusForbidden)
+	bob := testutil.Login(t, router, "/login", "bob", "adminpass")
+	testutil.AssertStatus(t, testutil.DoJSON(t, router, http.MethodPatch, "/files/"+f.ID, gin.H{"filename": "../x"}, testutil.WithToken(bob)),
+		http.StatusBadRequest)
+	testutil.AssertStatus(t, testutil.Do(t, router, http.MethodDelete, "/folders/reports/2024", nil, testutil.WithToken(alice)),
+		http.StatusConflict)
+	w = testutil.DoJSON(t, router, http.MethodPatch, "/files/"+f.ID, gin.H{"folder": "reports", "filename": "q3.txt"}, testutil.WithToken(bob))
+	testutil.AssertStatus(t, w, http.StatusOK)
+	if moved := testutil.Decode[File](t, w); filePath(moved) != "reports/q3.txt" {
+		t.Errorf("moved to %q, want reports/q3.txt", filePath(moved))
+	}
+	testutil.AssertStatus(t, testutil.Do(t, router, http.MethodDelete, "/folders/reports/2024", nil, testutil.WithToken(alice)),
+		http.StatusNoContent)
+}
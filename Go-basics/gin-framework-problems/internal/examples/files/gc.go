@@ -0,0 +1,99 @@
+package files
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// collectGarbage removes what uploads leave behind: readiness-probe and
+// upload temp files older than an hour (a probe or upload killed midway never
+// deletes its own), thumbnails of deleted files, blobs as old that no file
+// has (an upload that failed after storing it, or a delete that failed to
+// remove it), temp files among either, and resumable uploads idle for longer
+// than storage.upload_session_ttl.
+func (s *Server) collectGarbage(ctx context.Context) error {
+	cutoff := time.Now().Add(-time.Hour)
+	var errs []error
+	entries, err := os.ReadDir(s.uploadDir)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		errs = append(errs, err)
+	}
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasPrefix(e.Name(), ".healthcheck-") && !strings.HasPrefix(e.Name(), ".tmp-") {
+			continue
+		}
+		if info, err := e.Info(); err == nil && info.ModTime().Before(cutoff) {
+			errs = append(errs, os.Remove(filepath.Join(s.uploadDir, e.Name())))
+		}
+	}
+
+	// thumbnails/<size>/<id>.png, or thumbnails/<id>.png from before sizes
+	thumbs, err := s.blobs.List(ctx, "thumbnails/")
+	if err != nil && ctx.Err() == nil {
+		errs = append(errs, err)
+	}
+	for _, t := range thumbs {
+		if ctx.Err() != nil {
+			break
+		}
+		base := path.Base(t.Name)
+		if strings.HasPrefix(base, ".") {
+			// a thumbnail being written, unless the job died an hour ago
+			if t.ModTime.Before(cutoff) {
+				errs = append(errs, s.blobs.Delete(ctx, t.Name))
+			}
+			continue
+		}
+		id := strings.TrimSuffix(base, ".png")
+		if _, err := s.repo.Get(ctx, id); errors.Is(err, errFileNotFound) {
+			errs = append(errs, s.blobs.Delete(ctx, t.Name))
+		}
+	}
+
+	// sha256/<digest>, younger ones possibly an upload about to count itself
+	stored, err := s.blobs.List(ctx, "sha256/")
+	if err != nil && ctx.Err() == nil {
+		errs = append(errs, err)
+	}
+	for _, b := range stored {
+		if ctx.Err() != nil {
+			break
+		}
+		if !b.ModTime.Before(cutoff) {
+			continue
+		}
+		sum := path.Base(b.Name)
+		if strings.HasPrefix(sum, ".") {
+			errs = append(errs, s.blobs.Delete(ctx, b.Name))
+			continue
+		}
+		errs = append(errs, s.deleteUnused(ctx, sum))
+	}
+
+	partial, err := os.ReadDir(s.partialPath())
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		errs = append(errs, err)
+	}
+	idle := time.Now().Add(-s.sessionTTL)
+	for _, e := range partial {
+		if ctx.Err() != nil {
+			break
+		}
+		// a session is active while its data file is being written, however
+		// old its metadata
+		id, _, _ := strings.Cut(e.Name(), ".")
+		if info, err := os.Stat(s.sessionPath(id, ".data")); err == nil && info.ModTime().After(idle) {
+			continue
+		}
+		if info, err := e.Info(); err == nil && info.ModTime().Before(idle) {
+			errs = append(errs, os.Remove(filepath.Join(s.partialPath(), e.Name())))
+			s.endProgress(id, "aborted", nil)
+		}
+	}
+	return errors.Join(errs...)
+}
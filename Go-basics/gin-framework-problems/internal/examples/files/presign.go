@@ -0,0 +1,120 @@
+package files
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/apperror"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+)
+
+// A presigned link is
+//
+//	GET /files/<id>?expires=<unix seconds>&signature=<mac>
+//
+// which downloads the file, or with /thumb its thumbnails, without an
+// Authorization header until it expires. It can't be revoked before then,
+// short of changing auth.token_secret, which revokes every link at once.
+
+// defaultPresignTTL is how long a link lasts when the request doesn't say.
+const defaultPresignTTL = 15 * time.Minute
+
+func (s *Server) signDownload(id string, expires int64) string {
+	mac := hmac.New(sha256.New, []byte(s.presignKey))
+	mac.Write([]byte(id + "\n" + strconv.FormatInt(expires, 10)))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+type presignResponse struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// presignDownload makes a link to the file, which the caller can hand to a
+// browser or a third party instead of their token. The body is optional:
+// {"expires_in": <seconds>}, 15 minutes when left out, at most
+// files.presign_max_ttl.
+func (s *Server) presignDownload(c *gin.Context) {
+	var req struct {
+		ExpiresIn int64 `json:"expires_in" binding:"omitempty,min=1"`
+	}
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			middleware.BindError(c, err)
+			return
+		}
+	}
+	ttl := min(defaultPresignTTL, s.presignMaxTTL)
+	if req.ExpiresIn > 0 {
+		if req.ExpiresIn > int64(s.presignMaxTTL/time.Second) {
+			middleware.Fail(c, apperror.BadRequest("expires_in is longer than allowed").
+				WithDetails(map[string]int64{"max_seconds": int64(s.presignMaxTTL / time.Second)}))
+			return
+		}
+		ttl = time.Duration(req.ExpiresIn) * time.Second
+	}
+	f, err := s.getFile(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	expires := s.now().Add(ttl).Truncate(time.Second)
+	q := url.Values{}
+	q.Set("expires", strconv.FormatInt(expires.Unix(), 10))
+	q.Set("signature", s.signDownload(f.ID, expires.Unix()))
+	c.JSON(http.StatusOK, presignResponse{
+		URL:       s.downloadBase(c) + "/files/" + url.PathEscape(f.ID) + "?" + q.Encode(),
+		ExpiresAt: expires.UTC(),
+	})
+}
+
+// downloadBase is what links start with: files.base_url, or the host the
+// request came to.
+func (s *Server) downloadBase(c *gin.Context) string {
+	if s.filesBaseURL != "" {
+		return strings.TrimRight(s.filesBaseURL, "/")
+	}
+	scheme := "http"
+	if middleware.IsSecure(c) {
+		scheme = "https"
+	}
+	return scheme + "://" + c.Request.Host
+}
+
+// downloadAccess lets requests for /files/:id through with a valid
+// presigned link for the file, and the rest as login says: nil, when
+// files.private_downloads is off, lets everyone through.
+func (s *Server) downloadAccess(login gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		q := c.Request.URL.Query()
+		if !q.Has("expires") && !q.Has("signature") {
+			if login == nil {
+				c.Next()
+			} else {
+				login(c)
+			}
+			return
+		}
+		expires, err := strconv.ParseInt(q.Get("expires"), 10, 64)
+		if err != nil || !hmac.Equal([]byte(q.Get("signature")), []byte(s.signDownload(c.Param("id"), expires))) {
+			middleware.Fail(c, apperror.Forbidden("invalid download link"))
+			return
+		}
+		if s.now().Unix() >= expires {
+			middleware.Fail(c, apperror.Forbidden("download link has expired"))
+			return
+		}
+		// the link is as good as a token until it expires; keep it out of
+		// shared caches
+		c.Header("Cache-Control", "private")
+		c.Next()
+	}
+}
@@ -0,0 +1,62 @@
+package files
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/testutil"
+)
+
+func TestPresignDownload(t *testing.T) {
+	cfg := testutil.Config(t)
+	cfg.Files.PrivateDownloads = true
+	router, s := newRouter(t, cfg)
+	w := upload(t, router, "/upload", "file", "a.txt")
+	testutil.AssertStatus(t, w, http.StatusCreated)
+	id := testutil.Decode[File](t, w).ID
+	alice := testutil.Login(t, router, "/login", "alice", "password1")
+
+	testutil.AssertStatus(t, testutil.Do(t, router, http.MethodGet, "/files/"+id, nil), http.StatusUnauthorized)
+	testutil.AssertStatus(t, testutil.DoJSON(t, router, http.MethodPost, "/files/"+id+"/presign", nil), http.StatusUnauthorized)
+	testutil.AssertStatus(t, testutil.DoJSON(t, router, http.MethodPost, "/files/"+id+"/presign", gin.H{"expires_in": 2 * 24 * 3600},
+		testutil.WithToken(alice)), http.StatusBadRequest)
+	testutil.AssertStatus(t, testutil.DoJSON(t, router, http.MethodPost, "/files/missing/presign", nil, testutil.WithToken(alice)), http.StatusNotFound)
+
+	w = testutil.DoJSON(t, router, http.MethodPost, "/files/"+id+"/presign", gin.H{"expires_in": 60}, testutil.WithToken(alice))
+	testutil.AssertStatus(t, w, http.StatusOK)
+	link, err := url.Parse(testutil.Decode[presignResponse](t, w).URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w = testutil.Do(t, router, http.MethodGet, link.RequestURI(), nil)
+	testutil.AssertStatus(t, w, http.StatusOK)
+	if got := w.Body.String(); got != "hello, a.txt" {
+		t.Errorf("downloaded %q through the link, want the content", got)
+	}
+
+	q := link.Query()
+	tests := map[string]func(url.Values) string{
+		"other file": func(q url.Values) string { return "/files/other?" + q.Encode() },
+		"later expiry": func(q url.Values) string {
+			q.Set("expires", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+			return "/files/" + id + "?" + q.Encode()
+		},
+		"expired": func(q url.Values) string {
+			past := time.Now().Add(-time.Minute).Unix()
+			q.Set("expires", strconv.FormatInt(past, 10))
+			q.Set("signature", s.signDownload(id, past))
+			return "/files/" + id + "?" + q.Encode()
+		},
+	}
+	for name, target := range tests {
+		t.Run(name, func(t *testing.T) {
+			q := url.Values{"expires": {q.Get("expires")}, "signature": {q.Get("signature")}}
+			testutil.AssertStatus(t, testutil.Do(t, router, http.MethodGet, target(q), nil), http.StatusForbidden)
+		})
+	}
+}
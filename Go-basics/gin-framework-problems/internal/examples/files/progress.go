@@ -0,0 +1,109 @@
+package files
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/sse"
+)
+
+// progressInterval is how often a chunk being received reports progress,
+// at most.
+const progressInterval = 250 * time.Millisecond
+
+// progressEvent is the data of "progress" events: how much of the upload
+// has arrived.
+type progressEvent struct {
+	Offset int64 `json:"offset"`
+	Size   int64 `json:"size"`
+}
+
+// watch returns the broker of upload s, making one that starts with where s
+// is. Each keeps only its latest event, which is all a client that
+// (re)connects needs.
+func (s *Server) watch(sess uploadSession) *sse.Broker {
+	s.watchers.Lock()
+	defer s.watchers.Unlock()
+	if b, ok := s.watchers.brokers[sess.ID]; ok {
+		return b
+	}
+	b := sse.NewBroker(1, 15*time.Second)
+	b.Publish("progress", progressEvent{Offset: sess.Offset, Size: sess.Size})
+	if s.watchers.closed {
+		b.Close()
+		return b
+	}
+	s.watchers.brokers[sess.ID] = b
+	return b
+}
+
+// reportProgress tells whoever follows upload id how far it is.
+func (s *Server) reportProgress(id string, offset, size int64) {
+	s.watchers.Lock()
+	b := s.watchers.brokers[id]
+	s.watchers.Unlock()
+	if b != nil {
+		b.Publish("progress", progressEvent{Offset: offset, Size: size})
+	}
+}
+
+// endProgress sends whoever follows upload id a last event, such as
+// "complete" with the stored file, and ends their streams.
+func (s *Server) endProgress(id, name string, data any) {
+	s.watchers.Lock()
+	b := s.watchers.brokers[id]
+	delete(s.watchers.brokers, id)
+	s.watchers.Unlock()
+	if b != nil {
+		b.Publish(name, data)
+		b.Close()
+	}
+}
+
+// closeWatchers ends every progress stream; it is a shutdown hook.
+func (s *Server) closeWatchers(context.Context) error {
+	s.watchers.Lock()
+	defer s.watchers.Unlock()
+	s.watchers.closed = true
+	for id, b := range s.watchers.brokers {
+		b.Close()
+		delete(s.watchers.brokers, id)
+	}
+	return nil
+}
+
+// progressWriter reports the bytes of a chunk as they are written, every
+// progressInterval at most.
+type progressWriter struct {
+	s            *Server
+	w            io.Writer
+	id           string
+	offset, size int64
+	last         time.Time
+}
+
+func (p *progressWriter) Write(b []byte) (int, error) {
+	n, err := p.w.Write(b)
+	p.offset += int64(n)
+	if now := time.Now(); now.Sub(p.last) >= progressInterval {
+		p.last = now
+		p.s.reportProgress(p.id, p.offset, p.size)
+	}
+	return n, err
+}
+
+// uploadProgress streams an upload's progress as Server-Sent Events:
+// "progress" with offset and size, first where it is and then as chunks
+// arrive, and to end with "complete" with the stored file, or "aborted".
+func (s *Server) uploadProgress(c *gin.Context) {
+	sess, err := s.loadSession(c.Param("id"))
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	s.watch(sess).Handler()(c)
+}
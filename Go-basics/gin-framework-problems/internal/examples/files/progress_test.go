@@ -0,0 +1,59 @@
+package files
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/testutil"
+)
+
+func TestUploadProgress(t *testing.T) {
+	router, _ := newRouter(t, nil)
+	w := testutil.DoJSON(t, router, http.MethodPost, "/uploads", map[string]any{"filename": "big.txt", "size": 10})
+	testutil.AssertStatus(t, w, http.StatusCreated)
+	path := w.Header().Get("Location")
+	testutil.AssertStatus(t, testutil.Do(t, router, http.MethodPatch, path, strings.NewReader("hello"),
+		testutil.WithHeader("Content-Type", offsetContentType), testutil.WithHeader("Upload-Offset", "0")), http.StatusNoContent)
+	testutil.AssertStatus(t, testutil.Do(t, router, http.MethodGet, "/uploads/missing/progress", nil), http.StatusNotFound)
+
+	srv := httptest.NewServer(router)
+	defer srv.Close()
+	resp, err := http.Get(srv.URL + path + "/progress")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if got := resp.Header.Get("Content-Type"); got != "text/event-stream" {
+		t.Fatalf("Content-Type = %q, want text/event-stream", got)
+	}
+	events := bufio.NewScanner(resp.Body)
+	next := func() (name, data string) {
+		t.Helper()
+		for events.Scan() {
+			line := events.Text()
+			if v, ok := strings.CutPrefix(line, "event: "); ok {
+				name = v
+			} else if v, ok := strings.CutPrefix(line, "data: "); ok {
+				data = v
+			} else if line == "" && name != "" {
+				return name, data
+			}
+		}
+		t.Fatalf("stream ended: %v", events.Err())
+		return "", ""
+	}
+
+	if name, data := next(); name != "progress" || data != `{"offset":5,"size":10}` {
+		t.Errorf("first event = %s %s, want where the upload is", name, data)
+	}
+	testutil.AssertStatus(t, testutil.Do(t, router, http.MethodDelete, path, nil), http.StatusNoContent)
+	if name, _ := next(); name != "aborted" {
+		t.Errorf("event after DELETE = %s, want aborted", name)
+	}
+	if events.Scan() {
+		t.Errorf("stream goes on after aborted: %q", events.Text())
+	}
+}
@@ -0,0 +1,103 @@
+package files
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/apperror"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/auth"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+)
+
+// usage is what GET /files/usage answers. Quota and Remaining are null
+// without a quota.
+type usage struct {
+	User      string `json:"user"`
+	Used      int64  `json:"used_bytes"`
+	Quota     *int64 `json:"quota_bytes"`
+	Remaining *int64 `json:"remaining_bytes"`
+}
+
+// reservation is one user's reserved bytes. Its lock is held from reading
+// the user's usage to counting a new upload in, so two uploads checked at
+// once can't both fit into the same space.
+type reservation struct {
+	sync.Mutex
+	bytes int64
+	refs  int // under reserved's lock
+}
+
+// reserveQuota fails with 413 when size more bytes would take user past
+// files.quota_bytes, counting what uploads running at the same time have
+// reserved. Otherwise it reserves them until release is called, which the
+// caller does once the upload is stored or has failed; stored bytes are
+// counted twice for that moment, never not at all. Anonymous uploads have
+// no one to count against.
+func (s *Server) reserveQuota(ctx context.Context, user string, size int64) (release func(), err error) {
+	if s.quotaBytes == 0 || user == "" {
+		return func() {}, nil
+	}
+	s.reserved.Lock()
+	r := s.reserved.users[user]
+	if r == nil {
+		r = &reservation{}
+		s.reserved.users[user] = r
+	}
+	r.refs++
+	s.reserved.Unlock()
+
+	r.Lock()
+	used, err := s.repo.Usage(ctx, user)
+	if err == nil && used+r.bytes+size > s.quotaBytes {
+		err = apperror.New(http.StatusRequestEntityTooLarge, apperror.CodeTooLarge, "the upload would exceed your storage quota").
+			WithDetails(map[string]int64{
+				"quota_bytes":     s.quotaBytes,
+				"used_bytes":      used,
+				"reserved_bytes":  r.bytes,
+				"remaining_bytes": max(s.quotaBytes-used-r.bytes, 0),
+				"requested_bytes": size,
+			})
+	}
+	if err != nil {
+		r.Unlock()
+		s.unreserve(user, r)
+		return nil, err
+	}
+	r.bytes += size
+	r.Unlock()
+	return sync.OnceFunc(func() {
+		r.Lock()
+		r.bytes -= size
+		r.Unlock()
+		s.unreserve(user, r)
+	}), nil
+}
+
+// unreserve drops a hold on r, and r itself when it was the last.
+func (s *Server) unreserve(user string, r *reservation) {
+	s.reserved.Lock()
+	defer s.reserved.Unlock()
+	if r.refs--; r.refs == 0 {
+		delete(s.reserved.users, user)
+	}
+}
+
+// fileUsage answers with how much the signed-in user has stored, against
+// their quota.
+func (s *Server) fileUsage(c *gin.Context) {
+	user := auth.MustUser(c)
+	used, err := s.repo.Usage(c.Request.Context(), user.Username)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	out := usage{User: user.Username, Used: used}
+	if s.quotaBytes > 0 {
+		quota, remaining := s.quotaBytes, max(s.quotaBytes-used, 0)
+		out.Quota, out.Remaining = &quota, &remaining
+	}
+	c.JSON(http.StatusOK, out)
+}
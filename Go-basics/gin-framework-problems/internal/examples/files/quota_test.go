@@ -0,0 +1,62 @@
+package files
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/testutil"
+)
+
+func TestUploadQuota(t *testing.T) {
+	cfg := testutil.Config(t)
+	cfg.Files.QuotaBytes = 30 // two of upload's 12-byte files
+	router, _ := newRouter(t, cfg)
+	alice := testutil.Login(t, router, "/login", "alice", "password1")
+	post := func(path, field string, names ...string) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		for _, name := range names {
+			part, _ := mw.CreateFormFile(field, name)
+			part.Write([]byte("hello, " + name))
+		}
+		mw.Close()
+		return testutil.Do(t, router, http.MethodPost, path, &body,
+			testutil.WithHeader("Content-Type", mw.FormDataContentType()), testutil.WithToken(alice))
+	}
+	usageOf := func() usage {
+		t.Helper()
+		w := testutil.Do(t, router, http.MethodGet, "/files/usage", nil, testutil.WithToken(alice))
+		testutil.AssertStatus(t, w, http.StatusOK)
+		return testutil.Decode[usage](t, w)
+	}
+
+	testutil.AssertStatus(t, testutil.Do(t, router, http.MethodGet, "/files/usage", nil), http.StatusUnauthorized)
+	w := post("/upload", "file", "a.txt")
+	testutil.AssertStatus(t, w, http.StatusCreated)
+	first := testutil.Decode[File](t, w)
+	if u := usageOf(); u.User != "alice" || u.Used != 12 || u.Quota == nil || *u.Quota != 30 || *u.Remaining != 18 {
+		t.Errorf("usage = %+v, want 12 of 30 bytes used", u)
+	}
+
+	// together the two would pass the quota, so neither is stored
+	testutil.AssertStatus(t, post("/upload/multi", "files", "b.txt", "c.txt"), http.StatusRequestEntityTooLarge)
+	testutil.AssertStatus(t, post("/upload", "file", "b.txt"), http.StatusCreated)
+	w = post("/upload", "file", "c.txt")
+	testutil.AssertStatus(t, w, http.StatusRequestEntityTooLarge)
+	if body := w.Body.String(); !strings.Contains(body, `"remaining_bytes":6`) {
+		t.Errorf("body = %s, want remaining_bytes in details", body)
+	}
+	// anonymous uploads count against no one
+	testutil.AssertStatus(t, upload(t, router, "/upload", "file", "c.txt"), http.StatusCreated)
+
+	bob := testutil.Login(t, router, "/login", "bob", "adminpass")
+	testutil.AssertStatus(t, testutil.Do(t, router, http.MethodDelete, "/files/"+first.ID, nil, testutil.WithToken(bob)), http.StatusNoContent)
+	if u := usageOf(); u.Used != 12 {
+		t.Errorf("used after delete = %d, want 12", u.Used)
+	}
+	testutil.AssertStatus(t, post("/upload", "file", "c.txt"), http.StatusCreated)
+}
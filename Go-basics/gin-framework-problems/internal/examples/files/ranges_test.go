@@ -0,0 +1,91 @@
+package files
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/testutil"
+)
+
+func TestParseRange(t *testing.T) {
+	tests := []struct {
+		header string
+		want   []string
+		ok     bool
+	}{
+		{"bytes=0-9", []string{"0-9"}, true},
+		{"bytes=0-9, 20-", []string{"0-9", "20-"}, true},
+		{"bytes=-5", []string{"-5"}, true},
+		{"bytes=0-9,,", []string{"0-9"}, true},
+		{"bytes=0-9,500-", []string{"0-9"}, true},
+		{"bytes=500-600", nil, true},
+		{"bytes=-0", nil, true},
+		{"items=0-9", nil, false},
+		{"bytes=9-0", nil, false},
+		{"bytes=a-9", nil, false},
+		{"bytes=+1-9", nil, false},
+		{"bytes=", nil, false},
+		{"bytes 0-9", nil, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseRange(tt.header, 100)
+		if ok != tt.ok || !slices.Equal(got, tt.want) {
+			t.Errorf("parseRange(%q) = %q, %v; want %q, %v", tt.header, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestDownloadRanges(t *testing.T) {
+	router, _ := newRouter(t, nil)
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, _ := mw.CreateFormFile("file", "digits.txt")
+	part.Write([]byte("0123456789"))
+	mw.Close()
+	w := testutil.Do(t, router, http.MethodPost, "/upload", &body, testutil.WithHeader("Content-Type", mw.FormDataContentType()))
+	testutil.AssertStatus(t, w, http.StatusCreated)
+	f := testutil.Decode[File](t, w)
+	path := "/files/" + f.ID
+
+	tests := []struct {
+		name         string
+		headers      map[string]string
+		status       int
+		body         string // a prefix for multipart answers
+		contentRange string
+	}{
+		{"whole", nil, http.StatusOK, "0123456789", ""},
+		{"one range", map[string]string{"Range": "bytes=2-4"}, http.StatusPartialContent, "234", "bytes 2-4/10"},
+		{"suffix", map[string]string{"Range": "bytes=-3"}, http.StatusPartialContent, "789", "bytes 7-9/10"},
+		{"past the end dropped", map[string]string{"Range": "bytes=8-,20-"}, http.StatusPartialContent, "89", "bytes 8-9/10"},
+		{"unsatisfiable", map[string]string{"Range": "bytes=20-"}, http.StatusRequestedRangeNotSatisfiable, `{"error":"requested range is not satisfiable","code":"range_not_satisfiable"`, "bytes */10"},
+		{"malformed", map[string]string{"Range": "bytes=4-2"}, http.StatusOK, "0123456789", ""},
+		{"other unit", map[string]string{"Range": "lines=1-2"}, http.StatusOK, "0123456789", ""},
+		{"several", map[string]string{"Range": "bytes=0-1,5-6"}, http.StatusPartialContent, "--", ""},
+		{"If-Range matches", map[string]string{"Range": "bytes=0-1", "If-Range": `"` + f.ID + `"`}, http.StatusPartialContent, "01", "bytes 0-1/10"},
+		{"If-Range stale", map[string]string{"Range": "bytes=0-1", "If-Range": `"other"`}, http.StatusOK, "0123456789", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts []testutil.RequestOption
+			for k, v := range tt.headers {
+				opts = append(opts, testutil.WithHeader(k, v))
+			}
+			w := testutil.Do(t, router, http.MethodGet, path, nil, opts...)
+			testutil.AssertStatus(t, w, tt.status)
+			if got := w.Body.String(); !strings.HasPrefix(got, tt.body) {
+				t.Errorf("body = %q, want it to start with %q", got, tt.body)
+			}
+			if got := w.Header().Get("Content-Range"); got != tt.contentRange {
+				t.Errorf("Content-Range = %q, want %q", got, tt.contentRange)
+			}
+			if got := w.Header().Get("Accept-Ranges"); got != "bytes" {
+				t.Errorf("Accept-Ranges = %q, want bytes", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,455 @@
+package files
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/apperror"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/config"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/database"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/server"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/tenant"
+)
+
+// apperrors, so handlers can pass them to middleware.Fail
+var (
+	errFileNotFound   = apperror.NotFound("file not found")
+	errFolderNotFound = apperror.NotFound("folder not found")
+	errFolderExists   = apperror.Conflict("folder already exists")
+	errFolderNotEmpty = apperror.Conflict("folder is not empty")
+)
+
+// File is what the files example knows about an upload. Each has its own
+// ID, so two uploads of report.pdf are two files, but identical content is
+// stored once, under its digest.
+type File struct {
+	ID          string     `json:"id"`
+	Folder      string     `json:"folder"`   // path of the folder it is in; empty for the root
+	Filename    string     `json:"filename"` // as uploaded, or renamed to
+	Size        int64      `json:"size"`
+	ContentType string     `json:"content_type"`     // sniffed from the content
+	SHA256      string     `json:"sha256,omitempty"` // hex; empty for files stored before digests
+	Uploader    string     `json:"uploader,omitempty"`
+	UploadedAt  time.Time  `json:"uploaded_at"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"` // when file_expiry deletes it; nil keeps it
+	Tenant      string     `json:"tenant,omitempty"`
+}
+
+// Expired reports whether f's time was up before now.
+func (f File) Expired(now time.Time) bool {
+	return f.ExpiresAt != nil && !now.Before(*f.ExpiresAt)
+}
+
+// Folder is a folder files can be put in. Folders only exist in the
+// metadata: content is stored by digest wherever its file is.
+type Folder struct {
+	Path      string    `json:"path"` // such as reports/2024, below its parent's
+	Owner     string    `json:"owner,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// FileRepository keeps the metadata of uploads and the folders they are in.
+// Get and Delete fail with errFileNotFound for an ID that isn't stored.
+// Expired files are kept, and returned, until they are deleted. Folder paths
+// are checked by the caller, see folderPath; the repository makes sure the
+// folder a file or folder goes in exists when it does.
+type FileRepository interface {
+	// Create stores f, whose ID the caller picked, failing with
+	// errFolderNotFound when f.Folder doesn't exist.
+	Create(ctx context.Context, f File) error
+	Get(ctx context.Context, id string) (File, error)
+	// List returns every file, oldest first.
+	List(ctx context.Context) ([]File, error)
+	Delete(ctx context.Context, id string) error
+	// Refs counts the files whose content has the digest sha256, which
+	// share its blob.
+	Refs(ctx context.Context, sha256 string) (int, error)
+	// Usage adds up the sizes of the files uploader uploaded, counting
+	// each in full even when its blob is shared.
+	Usage(ctx context.Context, uploader string) (int64, error)
+	// Expired lists the files that expired before now.
+	Expired(ctx context.Context, now time.Time) ([]File, error)
+	// Move puts file id in folder under filename, failing with
+	// errFolderNotFound when folder doesn't exist.
+	Move(ctx context.Context, id, folder, filename string) (File, error)
+
+	// CreateFolder stores d, failing with errFolderExists when there is one
+	// at its path and errFolderNotFound when its parent doesn't exist.
+	CreateFolder(ctx context.Context, d Folder) error
+	GetFolder(ctx context.Context, path string) (Folder, error)
+	// Folders lists the folders right below parent, by path.
+	Folders(ctx context.Context, parent string) ([]Folder, error)
+	// DeleteFolder removes the folder at path, failing with
+	// errFolderNotEmpty while it has files or folders.
+	DeleteFolder(ctx context.Context, path string) error
+}
+
+// configureStore keeps metadata where files.store says: in memory, or in the
+// files table of the SQLite database at database.path.
+func (s *Server) configureStore(cfg *config.Config, hooks *server.Hooks) {
+	if cfg.Files.Store == "sqlite" {
+		s.repo = scoped(traced(NewSQLiteRepository(server.OpenDB(cfg.Database.Path, hooks))))
+	}
+}
+
+// memoryRepository keeps files in a map by ID. Everything is lost on
+// restart, while the content stays on disk.
+type memoryRepository struct {
+	mu    sync.RWMutex
+	files map[string]File
+	ids   []string         // in the order they were added
+	refs  map[string]int   // files by digest
+	usage map[string]int64 // bytes by uploader
+
+	folders map[string]Folder // by path
+}
+
+func NewMemoryRepository() FileRepository {
+	return &memoryRepository{files: map[string]File{}, refs: map[string]int{}, usage: map[string]int64{},
+		folders: map[string]Folder{}}
+}
+
+func (r *memoryRepository) Create(_ context.Context, f File) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.hasFolder(f.Folder) {
+		return errFolderNotFound
+	}
+	r.files[f.ID] = f
+	r.ids = append(r.ids, f.ID)
+	if f.SHA256 != "" {
+		r.refs[f.SHA256]++
+	}
+	r.usage[f.Uploader] += f.Size
+	return nil
+}
+
+func (r *memoryRepository) Get(_ context.Context, id string) (File, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	f, ok := r.files[id]
+	if !ok {
+		return File{}, errFileNotFound
+	}
+	return f, nil
+}
+
+func (r *memoryRepository) List(context.Context) ([]File, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]File, len(r.ids))
+	for i, id := range r.ids {
+		out[i] = r.files[id]
+	}
+	return out, nil
+}
+
+func (r *memoryRepository) Delete(_ context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	f, ok := r.files[id]
+	if !ok {
+		return errFileNotFound
+	}
+	if f.SHA256 != "" {
+		if r.refs[f.SHA256]--; r.refs[f.SHA256] == 0 {
+			delete(r.refs, f.SHA256)
+		}
+	}
+	if r.usage[f.Uploader] -= f.Size; r.usage[f.Uploader] == 0 {
+		delete(r.usage, f.Uploader)
+	}
+	delete(r.files, id)
+	r.ids = slices.DeleteFunc(r.ids, func(other string) bool { return other == id })
+	return nil
+}
+
+func (r *memoryRepository) Refs(_ context.Context, sha256 string) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.refs[sha256], nil
+}
+
+func (r *memoryRepository) Usage(_ context.Context, uploader string) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.usage[uploader], nil
+}
+
+func (r *memoryRepository) Expired(_ context.Context, now time.Time) ([]File, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var out []File
+	for _, id := range r.ids {
+		if f := r.files[id]; f.Expired(now) {
+			out = append(out, f)
+		}
+	}
+	return out, nil
+}
+
+func (r *memoryRepository) Move(_ context.Context, id, folder, filename string) (File, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	f, ok := r.files[id]
+	if !ok {
+		return File{}, errFileNotFound
+	}
+	if !r.hasFolder(folder) {
+		return File{}, errFolderNotFound
+	}
+	f.Folder, f.Filename = folder, filename
+	r.files[id] = f
+	return f, nil
+}
+
+// hasFolder reports whether there is a folder at path; the root always is.
+func (r *memoryRepository) hasFolder(path string) bool {
+	_, ok := r.folders[path]
+	return path == "" || ok
+}
+
+func (r *memoryRepository) CreateFolder(_ context.Context, d Folder) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.folders[d.Path]; ok {
+		return errFolderExists
+	}
+	if !r.hasFolder(parentFolder(d.Path)) {
+		return errFolderNotFound
+	}
+	r.folders[d.Path] = d
+	return nil
+}
+
+func (r *memoryRepository) GetFolder(_ context.Context, path string) (Folder, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	d, ok := r.folders[path]
+	if !ok {
+		return Folder{}, errFolderNotFound
+	}
+	return d, nil
+}
+
+func (r *memoryRepository) Folders(_ context.Context, parent string) ([]Folder, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := []Folder{}
+	for _, d := range r.folders {
+		if parentFolder(d.Path) == parent {
+			out = append(out, d)
+		}
+	}
+	slices.SortFunc(out, func(a, b Folder) int { return strings.Compare(a.Path, b.Path) })
+	return out, nil
+}
+
+func (r *memoryRepository) DeleteFolder(_ context.Context, path string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.folders[path]; !ok {
+		return errFolderNotFound
+	}
+	for _, f := range r.files {
+		if f.Folder == path {
+			return errFolderNotEmpty
+		}
+	}
+	for other := range r.folders {
+		if parentFolder(other) == path {
+			return errFolderNotEmpty
+		}
+	}
+	delete(r.folders, path)
+	return nil
+}
+
+// sqliteRepository keeps files in the files table.
+type sqliteRepository struct {
+	db *sql.DB
+}
+
+// NewSQLiteRepository keeps files in db, whose schema must be migrated.
+func NewSQLiteRepository(db *sql.DB) FileRepository {
+	return sqliteRepository{db: db}
+}
+
+const fileColumns = `id, folder, filename, size, content_type, sha256, uploader, uploaded_at, expires_at, tenant`
+
+func scanFile(row interface{ Scan(...any) error }) (File, error) {
+	var (
+		f       File
+		expires sql.NullTime
+	)
+	err := row.Scan(&f.ID, &f.Folder, &f.Filename, &f.Size, &f.ContentType, &f.SHA256, &f.Uploader, &f.UploadedAt, &expires, &f.Tenant)
+	if errors.Is(err, sql.ErrNoRows) {
+		return File{}, errFileNotFound
+	}
+	if expires.Valid {
+		f.ExpiresAt = &expires.Time
+	}
+	return f, err
+}
+
+// inFolder is true for a folder path of "" or one in the folders table, so
+// a file or folder only goes where there is a folder, whatever was checked
+// before.
+const inFolder = `(? = '' OR EXISTS (SELECT 1 FROM folders WHERE path = ?))`
+
+func (r sqliteRepository) Create(ctx context.Context, f File) error {
+	res, err := r.db.ExecContext(ctx, `INSERT INTO files (`+fileColumns+`)
+		SELECT ?, ?, ?, ?, ?, ?, ?, ?, ?, ? WHERE `+inFolder,
+		f.ID, f.Folder, f.Filename, f.Size, f.ContentType, f.SHA256, f.Uploader, f.UploadedAt, f.ExpiresAt, tenant.Owner(f.Tenant),
+		f.Folder, f.Folder)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return errFolderNotFound
+	}
+	return nil
+}
+
+func (r sqliteRepository) Get(ctx context.Context, id string) (File, error) {
+	return scanFile(r.db.QueryRowContext(ctx, `SELECT `+fileColumns+` FROM files WHERE id = ?`, id))
+}
+
+func (r sqliteRepository) List(ctx context.Context) ([]File, error) {
+	return r.query(ctx, `SELECT `+fileColumns+` FROM files ORDER BY uploaded_at, id`)
+}
+
+func (r sqliteRepository) Expired(ctx context.Context, now time.Time) ([]File, error) {
+	return r.query(ctx, `SELECT `+fileColumns+` FROM files
+		WHERE expires_at IS NOT NULL AND expires_at <= ? ORDER BY expires_at, id`, now)
+}
+
+func (r sqliteRepository) query(ctx context.Context, query string, args ...any) ([]File, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []File{}
+	for rows.Next() {
+		f, err := scanFile(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, f)
+	}
+	return out, rows.Err()
+}
+
+func (r sqliteRepository) Delete(ctx context.Context, id string) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM files WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return errFileNotFound
+	}
+	return nil
+}
+
+func (r sqliteRepository) Refs(ctx context.Context, sha256 string) (int, error) {
+	var n int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM files WHERE sha256 = ?`, sha256).Scan(&n)
+	return n, err
+}
+
+func (r sqliteRepository) Usage(ctx context.Context, uploader string) (int64, error) {
+	var n int64
+	err := r.db.QueryRowContext(ctx, `SELECT COALESCE(SUM(size), 0) FROM files WHERE uploader = ?`, uploader).Scan(&n)
+	return n, err
+}
+
+func (r sqliteRepository) Move(ctx context.Context, id, folder, filename string) (File, error) {
+	res, err := r.db.ExecContext(ctx, `UPDATE files SET folder = ?, filename = ? WHERE id = ? AND `+inFolder,
+		folder, filename, id, folder, folder)
+	if err != nil {
+		return File{}, err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		if _, err := r.Get(ctx, id); err != nil {
+			return File{}, err
+		}
+		return File{}, errFolderNotFound
+	}
+	return r.Get(ctx, id)
+}
+
+func (r sqliteRepository) CreateFolder(ctx context.Context, d Folder) error {
+	parent := parentFolder(d.Path)
+	res, err := r.db.ExecContext(ctx, `INSERT INTO folders (path, owner, created_at) SELECT ?, ?, ? WHERE `+inFolder,
+		d.Path, d.Owner, d.CreatedAt, parent, parent)
+	if database.IsUniqueViolation(err) {
+		return errFolderExists
+	}
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return errFolderNotFound
+	}
+	return nil
+}
+
+func scanFolder(row interface{ Scan(...any) error }) (Folder, error) {
+	var d Folder
+	err := row.Scan(&d.Path, &d.Owner, &d.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Folder{}, errFolderNotFound
+	}
+	return d, err
+}
+
+func (r sqliteRepository) GetFolder(ctx context.Context, path string) (Folder, error) {
+	return scanFolder(r.db.QueryRowContext(ctx, `SELECT path, owner, created_at FROM folders WHERE path = ?`, path))
+}
+
+func (r sqliteRepository) Folders(ctx context.Context, parent string) ([]Folder, error) {
+	// below parent, and not below any folder below it
+	prefix := parent
+	if prefix != "" {
+		prefix += "/"
+	}
+	rows, err := r.db.QueryContext(ctx, `SELECT path, owner, created_at FROM folders
+		WHERE substr(path, 1, length(?)) = ? AND instr(substr(path, length(?) + 1), '/') = 0
+		ORDER BY path`, prefix, prefix, prefix)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []Folder{}
+	for rows.Next() {
+		d, err := scanFolder(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
+func (r sqliteRepository) DeleteFolder(ctx context.Context, path string) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM folders WHERE path = ?
+		AND NOT EXISTS (SELECT 1 FROM files WHERE folder = ?)
+		AND NOT EXISTS (SELECT 1 FROM folders WHERE substr(path, 1, length(?) + 1) = ? || '/')`,
+		path, path, path, path)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		if _, err := r.GetFolder(ctx, path); err != nil {
+			return err
+		}
+		return errFolderNotEmpty
+	}
+	return nil
+}
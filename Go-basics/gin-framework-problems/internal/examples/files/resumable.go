@@ -0,0 +1,392 @@
+package files
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/apperror"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+)
+
+// Resumable uploads follow the tus protocol's core: the client announces a
+// file, sends it in chunks with PATCH, each at the offset the server has, and
+// asks for that offset with HEAD after a dropped connection. Received bytes
+// are on disk as soon as they arrive, so a restart loses nothing either.
+// Completing one copies it to storage.backend.
+//
+// A session is two files under .partial in the upload dir: <id>.json, what
+// POST /uploads was told, and <id>.data, the bytes so far. The data file's
+// size is the offset and its mtime the last activity, which file_gc compares
+// with storage.upload_session_ttl.
+
+// offsetContentType is the Content-Type tus requires on PATCH bodies.
+const offsetContentType = "application/offset+octet-stream"
+
+// sessionID matches the IDs rand.Text makes, so an ID from the URL can't
+// name a path outside .partial.
+var sessionID = regexp.MustCompile(`^[A-Z2-7]{26}$`)
+
+var (
+	errUploadNotFound   = apperror.NotFound("upload not found")
+	errUploadBusy       = apperror.Conflict("the upload is busy with another request")
+	errOffsetMismatch   = apperror.Conflict("upload offset does not match")
+	errUploadIncomplete = apperror.Conflict("the upload is incomplete")
+	errChunkTooLong     = apperror.New(http.StatusRequestEntityTooLarge, apperror.CodeTooLarge,
+		"the chunk runs past the end of the upload")
+	errChunkType = apperror.New(http.StatusUnsupportedMediaType, apperror.CodeUnsupported,
+		"chunks must be sent as "+offsetContentType)
+)
+
+// uploadSession is a resumable upload in progress.
+type uploadSession struct {
+	ID        string    `json:"id"`
+	Filename  string    `json:"filename"`
+	Size      int64     `json:"size"`
+	Offset    int64     `json:"offset"`
+	Uploader  string    `json:"uploader,omitempty"`
+	Folder    string    `json:"folder,omitempty"`
+	FileTTL   string    `json:"file_ttl,omitempty"` // ?ttl= or files.default_ttl, for the file it becomes
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func (s *Server) partialPath() string {
+	return filepath.Join(s.uploadDir, ".partial")
+}
+
+func (s *Server) sessionPath(id, ext string) string {
+	return filepath.Join(s.partialPath(), id+ext)
+}
+
+// claim marks id busy, or reports that it was already.
+func (s *Server) claim(id string) bool {
+	s.busy.Lock()
+	defer s.busy.Unlock()
+	if s.busy.ids[id] {
+		return false
+	}
+	s.busy.ids[id] = true
+	return true
+}
+
+func (s *Server) release(id string) {
+	s.busy.Lock()
+	defer s.busy.Unlock()
+	delete(s.busy.ids, id)
+}
+
+// loadSession reads the session id from disk, with its offset and expiry
+// taken from the data file.
+func (s *Server) loadSession(id string) (uploadSession, error) {
+	var sess uploadSession
+	if !sessionID.MatchString(id) {
+		return sess, errUploadNotFound
+	}
+	meta, err := os.ReadFile(s.sessionPath(id, ".json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return sess, errUploadNotFound
+	}
+	if err != nil {
+		return sess, err
+	}
+	if err := json.Unmarshal(meta, &sess); err != nil {
+		return sess, err
+	}
+	info, err := os.Stat(s.sessionPath(id, ".data"))
+	if errors.Is(err, os.ErrNotExist) {
+		return sess, errUploadNotFound
+	}
+	if err != nil {
+		return sess, err
+	}
+	sess.Offset, sess.ExpiresAt = info.Size(), info.ModTime().Add(s.sessionTTL).UTC()
+	return sess, nil
+}
+
+// claimSession loads the session named in the URL and marks it busy; the
+// caller releases it. It answers the request itself when it fails.
+func (s *Server) claimSession(c *gin.Context) (uploadSession, bool) {
+	id := c.Param("id")
+	if !s.claim(id) {
+		middleware.Fail(c, errUploadBusy)
+		return uploadSession{}, false
+	}
+	sess, err := s.loadSession(id)
+	if err != nil {
+		s.release(id)
+		middleware.Fail(c, err)
+		return uploadSession{}, false
+	}
+	return sess, true
+}
+
+// writeProgress sets the tus headers that tell the client where s stands.
+func writeProgress(c *gin.Context, sess uploadSession) {
+	c.Header("Upload-Offset", strconv.FormatInt(sess.Offset, 10))
+	c.Header("Upload-Length", strconv.FormatInt(sess.Size, 10))
+	c.Header("Upload-Expires", sess.ExpiresAt.Format(http.TimeFormat))
+	c.Header("Cache-Control", "no-store")
+}
+
+// createUpload starts a resumable upload of a file with the given name and
+// size, up to storage.max_resumable_bytes, to be kept in ?folder= and for
+// ?ttl= once it is complete. The Location header is where its chunks go.
+func (s *Server) createUpload(c *gin.Context) {
+	var req struct {
+		Filename string `json:"filename" binding:"required,notblank,max=255"`
+		Size     int64  `json:"size" binding:"required,min=1"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.BindError(c, err)
+		return
+	}
+	ttl, err := s.uploadTTL(c)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	folder, err := s.uploadFolder(c)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	name := filepath.Base(strings.TrimSpace(req.Filename))
+	if strings.HasPrefix(name, ".") || name == string(filepath.Separator) {
+		middleware.Fail(c, apperror.Validation("invalid request body",
+			map[string]string{"filename": "must be a file name not starting with a dot"}))
+		return
+	}
+	if req.Size > s.maxResumableBytes {
+		middleware.Fail(c, apperror.New(http.StatusRequestEntityTooLarge, apperror.CodeTooLarge,
+			"the upload is larger than allowed").WithDetails(map[string]int64{"limit_bytes": s.maxResumableBytes}))
+		return
+	}
+	sess := uploadSession{ID: rand.Text(), Filename: name, Size: req.Size, Uploader: uploader(c), Folder: folder,
+		CreatedAt: s.now().UTC()}
+	if ttl > 0 {
+		sess.FileTTL = ttl.String()
+	}
+	// only checked here: the space is reserved on completion, when the
+	// rest may have used it
+	releaseQuota, err := s.reserveQuota(c.Request.Context(), sess.Uploader, sess.Size)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	releaseQuota()
+	if err := os.MkdirAll(s.partialPath(), 0755); err != nil {
+		middleware.Error(c, http.StatusInternalServerError, "cannot create upload dir")
+		return
+	}
+
+	// the data file first: a crash before the metadata leaves only a file
+	// file_gc removes, never a session without its data
+	f, err := os.Create(s.sessionPath(sess.ID, ".data"))
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	f.Close()
+	meta, err := json.Marshal(sess)
+	if err == nil {
+		err = os.WriteFile(s.sessionPath(sess.ID, ".json"), meta, 0644)
+	}
+	if err != nil {
+		os.Remove(s.sessionPath(sess.ID, ".data"))
+		middleware.Fail(c, err)
+		return
+	}
+	sess.ExpiresAt = sess.CreatedAt.Add(s.sessionTTL)
+	writeProgress(c, sess)
+	c.Header("Location", "/uploads/"+sess.ID)
+	c.JSON(http.StatusCreated, sess)
+}
+
+// uploadStatus answers GET with the session and HEAD with just its
+// Upload-Offset and Upload-Length headers, which is how tus clients resume.
+func (s *Server) uploadStatus(c *gin.Context) {
+	sess, err := s.loadSession(c.Param("id"))
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	writeProgress(c, sess)
+	if c.Request.Method == http.MethodHead {
+		c.Status(http.StatusOK)
+		return
+	}
+	c.JSON(http.StatusOK, sess)
+}
+
+// uploadChunk appends the body to the upload. Upload-Offset must be the
+// offset the server has; any other answers 409 with the right one, so a
+// client that lost track can carry on from there. Whatever arrives before a
+// dropped connection is kept.
+func (s *Server) uploadChunk(c *gin.Context) {
+	if c.ContentType() != offsetContentType {
+		middleware.Fail(c, errChunkType)
+		return
+	}
+	offset, err := strconv.ParseInt(c.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		middleware.Error(c, http.StatusBadRequest, "invalid Upload-Offset header")
+		return
+	}
+	sess, ok := s.claimSession(c)
+	if !ok {
+		return
+	}
+	defer s.release(sess.ID)
+	if offset != sess.Offset {
+		writeProgress(c, sess)
+		middleware.Fail(c, errOffsetMismatch.WithDetails(map[string]int64{"offset": sess.Offset}))
+		return
+	}
+	remaining := sess.Size - sess.Offset
+	if c.Request.ContentLength > remaining {
+		middleware.Fail(c, errChunkTooLong)
+		return
+	}
+
+	f, err := os.OpenFile(s.sessionPath(sess.ID, ".data"), os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	defer f.Close()
+	n, copyErr := io.Copy(&progressWriter{s: s, w: f, id: sess.ID, offset: sess.Offset, size: sess.Size},
+		io.LimitReader(c.Request.Body, remaining))
+	if copyErr == nil && n == remaining {
+		// a body without a Content-Length can still be too long
+		if m, _ := c.Request.Body.Read(make([]byte, 1)); m > 0 {
+			f.Truncate(sess.Offset)
+			middleware.Fail(c, errChunkTooLong)
+			return
+		}
+	}
+	if err := f.Sync(); err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	sess.Offset += n
+	sess.ExpiresAt = s.now().Add(s.sessionTTL).UTC()
+	writeProgress(c, sess)
+	s.reportProgress(sess.ID, sess.Offset, sess.Size)
+	if copyErr != nil {
+		middleware.Fail(c, copyErr)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// completeUpload stores a fully received upload under a new file ID and
+// announces it like a form upload. One that is still short answers 409, one
+// of a type POST /upload wouldn't take 415 and one past the uploader's quota
+// 413; each stays for the client to finish or delete, and so does one that
+// doesn't match the checksum the client sends. One the virus scanner flags
+// is quarantined and its session removed. Sessions are assembled on local
+// disk whatever the backend, since S3 can't append, and copied to storage
+// here.
+func (s *Server) completeUpload(c *gin.Context) {
+	sess, ok := s.claimSession(c)
+	if !ok {
+		return
+	}
+	defer s.release(sess.ID)
+	if sess.Offset != sess.Size {
+		middleware.Fail(c, errUploadIncomplete.WithDetails(map[string]int64{"offset": sess.Offset, "size": sess.Size}))
+		return
+	}
+	typ, err := s.checkUploadType(sess)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	releaseQuota, err := s.reserveQuota(c.Request.Context(), sess.Uploader, sess.Size)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	defer releaseQuota()
+	f := s.newFile(c, sess.Filename, sess.Size, typ)
+	f.Uploader, f.Folder = sess.Uploader, sess.Folder
+	if ttl, err := time.ParseDuration(sess.FileTTL); err == nil {
+		f.expireAfter(ttl)
+	}
+	data := func() (io.ReadCloser, error) { return os.Open(s.sessionPath(sess.ID, ".data")) }
+	if f.SHA256, err = digest(data); err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	if err := checkChecksum(c, f); err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	infected, err := s.scanContent(c, f, data)
+	if infected {
+		// there is nothing left to finish
+		if err := errors.Join(os.Remove(s.sessionPath(sess.ID, ".data")), os.Remove(s.sessionPath(sess.ID, ".json"))); err != nil {
+			c.Error(err)
+		}
+		s.endProgress(sess.ID, "aborted", progressEvent{Offset: sess.Offset, Size: sess.Size})
+	}
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	// on failure the session is still there for the client to complete again
+	if err := s.commit(c.Request.Context(), f, data); err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	if err := errors.Join(os.Remove(s.sessionPath(sess.ID, ".data")), os.Remove(s.sessionPath(sess.ID, ".json"))); err != nil {
+		c.Error(err)
+	}
+	s.endProgress(sess.ID, "complete", f)
+	publishUploaded(c, f)
+	s.makeThumbnails(c, f)
+	c.Header("Location", "/files/"+f.ID)
+	c.JSON(http.StatusCreated, f)
+}
+
+// checkUploadType sniffs the received file and checks its type against
+// storage.uploads.single.
+func (s *Server) checkUploadType(sess uploadSession) (string, error) {
+	f, err := os.Open(s.sessionPath(sess.ID, ".data"))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	typ, err := sniff(f)
+	if err != nil {
+		return "", err
+	}
+	return typ, checkType(s.singleRule, sess.Filename, typ)
+}
+
+// abortUpload drops an upload and what it received.
+func (s *Server) abortUpload(c *gin.Context) {
+	sess, ok := s.claimSession(c)
+	if !ok {
+		return
+	}
+	defer s.release(sess.ID)
+	err := errors.Join(os.Remove(s.sessionPath(sess.ID, ".data")), os.Remove(s.sessionPath(sess.ID, ".json")))
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	s.endProgress(sess.ID, "aborted", progressEvent{Offset: sess.Offset, Size: sess.Size})
+	c.Status(http.StatusNoContent)
+}
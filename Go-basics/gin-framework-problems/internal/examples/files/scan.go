@@ -0,0 +1,110 @@
+package files
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/antivirus"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/apperror"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/audit"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/config"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/healthcheck"
+)
+
+// quarantined is what quarantine/<id>.json in storage says about a flagged
+// upload, whose content is kept as quarantine/<id> for someone to look at.
+type quarantined struct {
+	File
+	Signature     string    `json:"signature"`
+	QuarantinedAt time.Time `json:"quarantined_at"`
+}
+
+// configureScanner has uploads scanned by the clamd at files.scan.clamd.
+func (s *Server) configureScanner(cfg *config.Config) {
+	if cfg.Files.Scan.Clamd == "" {
+		return
+	}
+	clamd := antivirus.NewClamd(cfg.Files.Scan.Clamd, cfg.Files.Scan.Timeout)
+	s.scanner = clamd
+	s.failOpen = cfg.Files.Scan.OnUnavailable == "accept"
+	// failing open, a clamd that is down doesn't stop uploads, so it
+	// shouldn't take the instance out of rotation either
+	if !s.failOpen {
+		healthcheck.Default.Register("clamd", clamd.Ping)
+	}
+}
+
+// scanFiles scans the files of a form upload, about to be stored as files,
+// before any is, so a request is kept or refused whole.
+func (s *Server) scanFiles(c *gin.Context, files []File, form []formFile) error {
+	for i, ff := range form {
+		if _, err := s.scanContent(c, files[i], ff.open); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scanContent scans the content open returns, which is about to be stored as
+// f. Infected content is quarantined under f's ID and answered with 422. A
+// scanner that can't answer refuses the upload with 503, or lets it through
+// with the error logged when failing open.
+func (s *Server) scanContent(c *gin.Context, f File, open func() (io.ReadCloser, error)) (infected bool, err error) {
+	if s.scanner == nil {
+		return false, nil
+	}
+	r, err := open()
+	if err != nil {
+		return false, err
+	}
+	verdict, err := s.scanner.Scan(c.Request.Context(), r)
+	r.Close()
+	if errors.Is(err, antivirus.ErrUnavailable) {
+		if s.failOpen {
+			c.Error(err)
+			return false, nil
+		}
+		e := apperror.New(http.StatusServiceUnavailable, apperror.CodeUnavailable, "virus scanner is unavailable")
+		e.Err = err
+		return false, e
+	}
+	if err != nil || !verdict.Infected {
+		return false, err
+	}
+	if err := s.quarantine(c, f, verdict.Signature, open); err != nil {
+		// refused all the same, just not kept
+		c.Error(err)
+	}
+	return true, apperror.New(http.StatusUnprocessableEntity, apperror.CodeMalware, "file contains malware").
+		WithDetails(map[string]string{"file": f.Filename, "signature": verdict.Signature, "quarantine_id": f.ID})
+}
+
+// quarantine keeps flagged content and what is known about it in storage,
+// out of reach of the files routes, and records it in the audit log.
+func (s *Server) quarantine(c *gin.Context, f File, signature string, open func() (io.ReadCloser, error)) error {
+	ctx := c.Request.Context()
+	r, err := open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	if err := s.blobs.Save(ctx, "quarantine/"+f.ID, r, f.Size); err != nil {
+		return err
+	}
+	meta, err := json.Marshal(quarantined{File: f, Signature: signature, QuarantinedAt: s.now().UTC()})
+	if err != nil {
+		return err
+	}
+	if err := s.blobs.Save(ctx, "quarantine/"+f.ID+".json", bytes.NewReader(meta), int64(len(meta))); err != nil {
+		return err
+	}
+	audit.Record(c, audit.Event{Action: audit.FileQuarantine, Outcome: audit.Success, Target: f.ID,
+		Details: map[string]string{"filename": f.Filename, "signature": signature}})
+	return nil
+}
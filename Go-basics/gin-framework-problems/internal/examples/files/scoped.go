@@ -0,0 +1,56 @@
+package files
+
+import (
+	"context"
+	"slices"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/tenant"
+)
+
+// scopedRepository keeps each tenant's files from the others: a file is
+// stored for the tenant of the context, and to requests of any other tenant
+// it is not found. Folders are shared, as paths only, and blobs are still
+// stored once per digest whichever tenants upload them. Contexts without a
+// tenant, as with tenancy off or in file_expiry, see every file.
+type scopedRepository struct {
+	FileRepository
+}
+
+func scoped(r FileRepository) FileRepository {
+	return scopedRepository{r}
+}
+
+func (s scopedRepository) Create(ctx context.Context, f File) error {
+	f.Tenant = tenant.Assign(ctx)
+	return s.FileRepository.Create(ctx, f)
+}
+
+func (s scopedRepository) Get(ctx context.Context, id string) (File, error) {
+	f, err := s.FileRepository.Get(ctx, id)
+	if err == nil && !tenant.Visible(ctx, f.Tenant) {
+		return File{}, errFileNotFound
+	}
+	return f, err
+}
+
+func (s scopedRepository) List(ctx context.Context) ([]File, error) {
+	list, err := s.FileRepository.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(list, func(f File) bool { return !tenant.Visible(ctx, f.Tenant) }), nil
+}
+
+func (s scopedRepository) Delete(ctx context.Context, id string) error {
+	if _, err := s.Get(ctx, id); err != nil {
+		return err
+	}
+	return s.FileRepository.Delete(ctx, id)
+}
+
+func (s scopedRepository) Move(ctx context.Context, id, folder, filename string) (File, error) {
+	if _, err := s.Get(ctx, id); err != nil {
+		return File{}, err
+	}
+	return s.FileRepository.Move(ctx, id, folder, filename)
+}
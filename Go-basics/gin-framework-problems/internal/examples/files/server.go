@@ -0,0 +1,165 @@
+package files
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"sync"
+	"time"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/antivirus"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/cache"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/config"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/sse"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/storage"
+)
+
+// Options are what a Server depends on. Zero fields take the defaults noted.
+type Options struct {
+	Config     *config.Config    // config.Default(); its storage and files limits
+	Repository FileRepository    // a NewMemoryRepository
+	Blobs      storage.Storage   // on local disk, in Config's upload dir
+	Scanner    antivirus.Scanner // scans nothing
+	Now        func() time.Time  // time.Now
+}
+
+// Server is the file upload example: the uploads, their content and the
+// resumable uploads under way. Every Server has its own, so tests and
+// several instances in one process don't share state. Other examples keep
+// avatars and covers in a Dir of their own instead.
+type Server struct {
+	// keeps the metadata; NewUploads picks it from files.store
+	repo FileRepository
+
+	// where the routes keep temp files and resumable uploads
+	uploadDir string
+
+	// what uploads, folders, links and expiry are timed by
+	now func() time.Time
+
+	// keeps the content of uploads and their thumbnails by file ID;
+	// NewUploads picks it from storage.backend
+	blobs storage.Storage
+
+	// serialize storing and deleting blobs of the same digest, so a blob is
+	// never deleted between another upload finding it and counting itself.
+	// They only cover this process; instances sharing storage can race.
+	blobLocks [64]sync.Mutex
+
+	// holds GET /files answers when response_cache is enabled; nil caches
+	// nothing
+	listCache *cache.Responses
+
+	// from storage.uploads
+	singleRule, multiRule config.UploadRule
+
+	// from storage.max_resumable_bytes and storage.upload_session_ttl
+	maxResumableBytes int64
+	sessionTTL        time.Duration
+
+	// from files.archive_max_files and files.archive_max_bytes
+	archiveMaxFiles int
+	archiveMaxBytes int64
+
+	// from files.presign_max_ttl and files.base_url
+	presignMaxTTL time.Duration
+	filesBaseURL  string
+	// signs links; derived from auth.token_secret, so every instance
+	// sharing it accepts the others' links
+	presignKey string
+
+	// from files.quota_bytes; 0 is no quota
+	quotaBytes int64
+
+	// from server.read_timeout; 0 leaves the deadline alone
+	readIdle time.Duration
+
+	// from files.default_ttl and files.max_ttl; 0 keeps files for good, and
+	// lets ?ttl= be as long as it likes
+	defaultTTL, maxTTL time.Duration
+
+	// a nil scanner scans nothing, and failOpen stores uploads unscanned
+	// while it is unavailable
+	scanner  antivirus.Scanner
+	failOpen bool
+
+	// from files.thumbnail_sizes; queueThumbnails hands a file to the
+	// worker pool, which writes one thumbnail per size. Without it no
+	// thumbnails are made.
+	thumbnailSizes  []int
+	queueThumbnails func(ctx context.Context, f File) error
+
+	// an SSE broker for each resumable upload someone follows on
+	// GET /uploads/<id>/progress, by upload ID. Uploads nobody follows
+	// publish nothing. Brokers live in this process, so a client must
+	// follow the instance receiving the chunks.
+	watchers struct {
+		sync.Mutex
+		brokers map[string]*sse.Broker
+		closed  bool
+	}
+
+	// per user, the bytes of uploads that passed reserveQuota and aren't
+	// stored yet. Entries go once nothing holds or waits on them.
+	reserved struct {
+		sync.Mutex
+		users map[string]*reservation
+	}
+
+	// the IDs of uploads a request is working on, so two chunks are never
+	// appended to one file at once
+	busy struct {
+		sync.Mutex
+		ids map[string]bool
+	}
+}
+
+// NewServer returns a Server with opts' dependencies.
+func NewServer(opts Options) *Server {
+	cfg := opts.Config
+	if cfg == nil {
+		cfg = config.Default()
+	}
+	s := &Server{repo: opts.Repository, blobs: opts.Blobs, scanner: opts.Scanner, now: opts.Now}
+	if s.repo == nil {
+		s.repo = NewMemoryRepository()
+	}
+	if s.now == nil {
+		s.now = time.Now
+	}
+	s.repo = scoped(traced(s.repo))
+	s.configure(cfg)
+	if s.blobs == nil {
+		s.blobs = storage.NewLocal(s.uploadDir)
+	}
+	s.watchers.brokers = map[string]*sse.Broker{}
+	s.reserved.users = map[string]*reservation{}
+	s.busy.ids = map[string]bool{}
+	return s
+}
+
+// configure applies cfg's limits to s.
+func (s *Server) configure(cfg *config.Config) {
+	s.uploadDir = cfg.Storage.UploadDir
+	s.singleRule, s.multiRule = cfg.Storage.Uploads.Single, cfg.Storage.Uploads.Multi
+	s.maxResumableBytes, s.sessionTTL = cfg.Storage.MaxResumableBytes, cfg.Storage.UploadSessionTTL
+	s.archiveMaxFiles, s.archiveMaxBytes = cfg.Files.ArchiveMaxFiles, cfg.Files.ArchiveMaxBytes
+	s.presignMaxTTL, s.filesBaseURL = cfg.Files.PresignMaxTTL, cfg.Files.BaseURL
+	s.quotaBytes = cfg.Files.QuotaBytes
+	s.readIdle = cfg.Server.ReadTimeout
+	s.defaultTTL, s.maxTTL = cfg.Files.DefaultTTL, cfg.Files.MaxTTL
+	s.setPresignSecret(cfg.Auth.TokenSecret)
+}
+
+// setPresignSecret derives the key links are signed with from secret. An
+// empty one gets a random key, so links only work on the instance that
+// made them.
+func (s *Server) setPresignSecret(secret string) {
+	if secret == "" {
+		secret = rand.Text()
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("files presign"))
+	s.presignKey = string(mac.Sum(nil))
+}
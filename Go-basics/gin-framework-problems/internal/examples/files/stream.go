@@ -0,0 +1,169 @@
+package files
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/apperror"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/config"
+)
+
+// formFile is a file of a form upload, written to a temp file in the upload
+// dir as it arrived and hashed and sniffed on the way, so it is never held
+// in memory and is read again only to be scanned and stored.
+type formFile struct {
+	Filename string
+	Size     int64
+	Type     string // sniffed from the first 512 bytes
+	SHA256   string // hex
+	path     string
+}
+
+func (ff formFile) open() (io.ReadCloser, error) { return os.Open(ff.path) }
+
+// fileOf is the metadata ff is about to be stored with, under a new ID.
+func (s *Server) fileOf(c *gin.Context, ff formFile) File {
+	f := s.newFile(c, ff.Filename, ff.Size, ff.Type)
+	f.SHA256 = ff.SHA256
+	return f
+}
+
+// removeForm deletes the temp files of files. file_gc gets any a crash
+// leaves, by their .tmp- prefix.
+func removeForm(files []formFile) {
+	for _, ff := range files {
+		os.Remove(ff.path)
+	}
+}
+
+// readForm reads a multipart/form-data upload one part at a time, checking
+// each file against rule as it arrives: the number of files as each starts,
+// its type once its first 512 bytes are in, and its size as it grows, so a
+// refused upload is cut off there rather than received whole. The files in
+// field are kept, in order; ones in other fields are checked the same and
+// dropped, so none slips past the rule. Other fields are skipped. The caller
+// removes what it gets with removeForm; on error nothing is left. The read
+// deadline moves with the upload, as idleBody has it.
+func (s *Server) readForm(c *gin.Context, rule config.UploadRule, field string) ([]formFile, error) {
+	s.idleRead(c)
+	mr, err := c.Request.MultipartReader()
+	if err != nil {
+		return nil, apperror.BadRequest("bad multipart form")
+	}
+	var (
+		files []formFile
+		count int
+	)
+	for {
+		p, err := mr.NextPart()
+		if errors.Is(err, io.EOF) {
+			return files, nil
+		}
+		if err != nil {
+			removeForm(files)
+			return nil, formError(err)
+		}
+		if p.FileName() == "" {
+			// NextPart discards what is left of it
+			continue
+		}
+		if count++; count > rule.MaxFiles {
+			removeForm(files)
+			return nil, apperror.New(http.StatusRequestEntityTooLarge, apperror.CodeTooLarge, "too many files").
+				WithDetails(map[string]int{"limit": rule.MaxFiles})
+		}
+		ff, err := s.spool(p, rule, p.FormName() == field)
+		p.Close()
+		if err != nil {
+			removeForm(files)
+			return nil, err
+		}
+		if ff.path != "" {
+			files = append(files, ff)
+		}
+	}
+}
+
+// spool checks p against rule as it reads it, and writes it to a temp file
+// when keep is set. Otherwise it only reads it through.
+func (s *Server) spool(p *multipart.Part, rule config.UploadRule, keep bool) (formFile, error) {
+	ff := formFile{Filename: p.FileName()}
+	body := &partReader{r: p}
+	br := bufio.NewReaderSize(body, 512)
+	head, _ := br.Peek(512)
+	if body.err != nil {
+		return formFile{}, formError(body.err)
+	}
+	ff.Type = detect(head)
+	if err := checkType(rule, ff.Filename, ff.Type); err != nil {
+		return formFile{}, err
+	}
+
+	limited := io.LimitReader(br, rule.MaxFileBytes+1)
+	dst := io.Discard
+	var tmp *os.File
+	if keep {
+		var err error
+		if tmp, err = os.CreateTemp(s.uploadDir, ".tmp-upload-*"); err != nil {
+			return formFile{}, err
+		}
+		dst = tmp
+	}
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(dst, h), limited)
+	switch {
+	case body.err != nil:
+		err = formError(body.err)
+	case err == nil && n > rule.MaxFileBytes:
+		err = apperror.New(http.StatusRequestEntityTooLarge, apperror.CodeTooLarge, "file is too large").
+			WithDetails(map[string]any{"file": ff.Filename, "limit_bytes": rule.MaxFileBytes})
+	}
+	if tmp != nil {
+		if cerr := tmp.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			os.Remove(tmp.Name())
+		}
+		ff.path = tmp.Name()
+	}
+	if err != nil {
+		return formFile{}, err
+	}
+	ff.Size, ff.SHA256 = n, hex.EncodeToString(h.Sum(nil))
+	return ff, nil
+}
+
+// partReader remembers the error reading the request, which is the
+// client's, apart from one writing the temp file, which is ours.
+type partReader struct {
+	r   io.Reader
+	err error
+}
+
+func (p *partReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if err != nil && !errors.Is(err, io.EOF) {
+		p.err = err
+	}
+	return n, err
+}
+
+// formError is the answer to a body that can't be read as a form: a 413
+// when the BodyLimit cut it off, a 400 otherwise.
+func formError(err error) error {
+	if TooLarge(err) {
+		return err
+	}
+	e := apperror.BadRequest("bad multipart form")
+	e.Err = err
+	return e
+}
@@ -0,0 +1,86 @@
+package files
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/apperror"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/jobs"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/storage"
+)
+
+// thumbnailTypes are the images the thumbnail job can decode.
+var thumbnailTypes = []string{"image/gif", "image/jpeg", "image/png"}
+
+// hasThumbnails reports whether thumbnails are made of f.
+func (s *Server) hasThumbnails(f File) bool {
+	return s.queueThumbnails != nil && slices.Contains(thumbnailTypes, f.ContentType)
+}
+
+// makeThumbnails queues f's thumbnails. Files that aren't images are
+// skipped, and a queue that is full or closed doesn't fail the upload.
+func (s *Server) makeThumbnails(c *gin.Context, f File) {
+	if !s.hasThumbnails(f) {
+		return
+	}
+	if err := s.queueThumbnails(c.Request.Context(), f); err != nil {
+		c.Error(err)
+	}
+}
+
+// deleteThumbnails deletes f's thumbnails, of every size.
+func (s *Server) deleteThumbnails(ctx context.Context, f File) error {
+	var errs []error
+	for _, size := range s.thumbnailSizes {
+		errs = append(errs, s.blobs.Delete(ctx, jobs.ThumbnailName(f.ID, size)))
+	}
+	return errors.Join(errs...)
+}
+
+// getThumbnail serves a thumbnail of an image, ?size= pixels on its longest
+// side at most: one of files.thumbnail_sizes, the smallest by default. They
+// are made in the background, so right after the upload it may answer 404
+// with Retry-After.
+func (s *Server) getThumbnail(c *gin.Context) {
+	f, err := s.getFile(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	if !s.hasThumbnails(f) {
+		middleware.Fail(c, apperror.NotFound("file has no thumbnails"))
+		return
+	}
+	size := slices.Min(s.thumbnailSizes)
+	if raw := c.Query("size"); raw != "" {
+		size, err = strconv.Atoi(raw)
+		if err != nil || !slices.Contains(s.thumbnailSizes, size) {
+			sizes := make([]string, len(s.thumbnailSizes))
+			for i, n := range s.thumbnailSizes {
+				sizes[i] = strconv.Itoa(n)
+			}
+			middleware.Fail(c, apperror.Validation("invalid query parameters",
+				map[string]string{"size": "must be one of " + strings.Join(sizes, ", ")}))
+			return
+		}
+	}
+	thumb, err := s.blobs.Open(c.Request.Context(), jobs.ThumbnailName(f.ID, size))
+	if errors.Is(err, storage.ErrNotExist) {
+		c.Header("Retry-After", "2")
+		middleware.Fail(c, apperror.NotFound("thumbnail is not ready yet"))
+		return
+	}
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	defer thumb.Close()
+	c.Header("Content-Type", "image/png")
+	serveContent(c, "", f.UploadedAt, thumb)
+}
@@ -0,0 +1,92 @@
+package files
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/tracing"
+)
+
+// tracedRepository makes each call to a FileRepository a child span of the
+// request's, files.store.<operation>; blobs get theirs from storage.Traced.
+type tracedRepository struct {
+	FileRepository
+}
+
+func traced(r FileRepository) FileRepository {
+	return tracedRepository{r}
+}
+
+func (t tracedRepository) Create(ctx context.Context, f File) (err error) {
+	ctx, span := tracing.Start(ctx, "files.store.create", attribute.String("file.id", f.ID))
+	defer func() { tracing.End(span, err) }()
+	return t.FileRepository.Create(ctx, f)
+}
+
+func (t tracedRepository) Get(ctx context.Context, id string) (_ File, err error) {
+	ctx, span := tracing.Start(ctx, "files.store.get", attribute.String("file.id", id))
+	defer func() { tracing.End(span, err) }()
+	return t.FileRepository.Get(ctx, id)
+}
+
+func (t tracedRepository) List(ctx context.Context) (_ []File, err error) {
+	ctx, span := tracing.Start(ctx, "files.store.list")
+	defer func() { tracing.End(span, err) }()
+	return t.FileRepository.List(ctx)
+}
+
+func (t tracedRepository) Delete(ctx context.Context, id string) (err error) {
+	ctx, span := tracing.Start(ctx, "files.store.delete", attribute.String("file.id", id))
+	defer func() { tracing.End(span, err) }()
+	return t.FileRepository.Delete(ctx, id)
+}
+
+func (t tracedRepository) Refs(ctx context.Context, sha256 string) (_ int, err error) {
+	ctx, span := tracing.Start(ctx, "files.store.refs")
+	defer func() { tracing.End(span, err) }()
+	return t.FileRepository.Refs(ctx, sha256)
+}
+
+func (t tracedRepository) Usage(ctx context.Context, uploader string) (_ int64, err error) {
+	ctx, span := tracing.Start(ctx, "files.store.usage")
+	defer func() { tracing.End(span, err) }()
+	return t.FileRepository.Usage(ctx, uploader)
+}
+
+func (t tracedRepository) Expired(ctx context.Context, now time.Time) (_ []File, err error) {
+	ctx, span := tracing.Start(ctx, "files.store.expired")
+	defer func() { tracing.End(span, err) }()
+	return t.FileRepository.Expired(ctx, now)
+}
+
+func (t tracedRepository) Move(ctx context.Context, id, folder, filename string) (_ File, err error) {
+	ctx, span := tracing.Start(ctx, "files.store.move", attribute.String("file.id", id))
+	defer func() { tracing.End(span, err) }()
+	return t.FileRepository.Move(ctx, id, folder, filename)
+}
+
+func (t tracedRepository) CreateFolder(ctx context.Context, d Folder) (err error) {
+	ctx, span := tracing.Start(ctx, "files.store.create_folder")
+	defer func() { tracing.End(span, err) }()
+	return t.FileRepository.CreateFolder(ctx, d)
+}
+
+func (t tracedRepository) GetFolder(ctx context.Context, path string) (_ Folder, err error) {
+	ctx, span := tracing.Start(ctx, "files.store.get_folder")
+	defer func() { tracing.End(span, err) }()
+	return t.FileRepository.GetFolder(ctx, path)
+}
+
+func (t tracedRepository) Folders(ctx context.Context, parent string) (_ []Folder, err error) {
+	ctx, span := tracing.Start(ctx, "files.store.folders")
+	defer func() { tracing.End(span, err) }()
+	return t.FileRepository.Folders(ctx, parent)
+}
+
+func (t tracedRepository) DeleteFolder(ctx context.Context, path string) (err error) {
+	ctx, span := tracing.Start(ctx, "files.store.delete_folder")
+	defer func() { tracing.End(span, err) }()
+	return t.FileRepository.DeleteFolder(ctx, path)
+}
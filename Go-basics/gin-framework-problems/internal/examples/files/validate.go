@@ -0,0 +1,46 @@
+package files
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/apperror"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/config"
+)
+
+// checkType fails with 415 unless rule allows typ, the sniffed type of file.
+func checkType(rule config.UploadRule, file, typ string) error {
+	if len(rule.AllowedTypes) == 0 || slices.ContainsFunc(rule.AllowedTypes, func(a string) bool {
+		prefix, wild := strings.CutSuffix(a, "*")
+		return a == typ || wild && strings.HasPrefix(typ, prefix)
+	}) {
+		return nil
+	}
+	return apperror.New(http.StatusUnsupportedMediaType, apperror.CodeUnsupported, "file type is not allowed").
+		WithDetails(map[string]any{"file": file, "type": typ, "allowed": rule.AllowedTypes})
+}
+
+// sniff returns the media type of r's content, without parameters, as
+// http.DetectContentType finds it from the first 512 bytes. Anything it
+// doesn't know, executables included, is application/octet-stream.
+func sniff(r io.Reader) (string, error) {
+	buf := make([]byte, 512)
+	n, err := io.ReadFull(r, buf)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return "", err
+	}
+	return detect(buf[:n]), nil
+}
+
+// detect is sniff for content already read.
+func detect(head []byte) string {
+	typ, _, err := mime.ParseMediaType(http.DetectContentType(head))
+	if err != nil {
+		return "application/octet-stream"
+	}
+	return typ
+}
@@ -0,0 +1,97 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// principal is the user behind a token, as the users service describes it.
+type principal struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+	Role     string `json:"role"`
+}
+
+func (p principal) HasRole(role string) bool { return p.Role == role }
+func (p principal) Subject() string          { return p.ID }
+
+// introspector checks tokens against the users service's /api/profile and
+// remembers the answer for ttl, so each request doesn't cost an extra hop.
+type introspector struct {
+	users  *upstream
+	client *http.Client
+	ttl    time.Duration
+
+	mu    sync.Mutex
+	cache map[string]cachedPrincipal
+}
+
+type cachedPrincipal struct {
+	p       principal
+	expires time.Time
+}
+
+var errInvalidToken = errors.New("invalid or expired token")
+
+// authenticate has the middleware.Authenticator signature.
+func (in *introspector) authenticate(token string) (any, error) {
+	now := time.Now()
+	in.mu.Lock()
+	if c, ok := in.cache[token]; ok && now.Before(c.expires) {
+		in.mu.Unlock()
+		return c.p, nil
+	}
+	in.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), in.client.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, in.users.target.JoinPath("/api/profile").String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := in.client.Do(req)
+	if err != nil {
+		return nil, errors.New("cannot verify token")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errInvalidToken
+	}
+	var p principal
+	if err := json.NewDecoder(resp.Body).Decode(&p); err != nil {
+		return nil, errors.New("cannot verify token")
+	}
+
+	in.mu.Lock()
+	in.cache[token] = cachedPrincipal{p: p, expires: now.Add(in.ttl)}
+	in.mu.Unlock()
+	return p, nil
+}
+
+// purge drops expired cache entries; run periodically.
+func (in *introspector) purge(now time.Time) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	for token, c := range in.cache {
+		if now.After(c.expires) {
+			delete(in.cache, token)
+		}
+	}
+}
+
+// public reports whether a request may pass the edge without a token:
+// account creation and login on the users service, and reads everywhere
+// else. Upstreams still enforce their own rules on what gets through.
+func public(service, method, path string) bool {
+	if service == "users" {
+		return method == http.MethodPost &&
+			(path == "/api/register" || path == "/api/login" || strings.HasPrefix(path, "/api/password/"))
+	}
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
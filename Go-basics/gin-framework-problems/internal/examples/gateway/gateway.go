@@ -0,0 +1,200 @@
+// Package gateway is an API gateway in front of the other examples. The first
+// path segment picks the service and is stripped before forwarding:
+//
+//	GET  /books/books?limit=5  ->  books service  GET  /books?limit=5
+//	POST /users/api/login      ->  users service  POST /api/login
+//
+// Rate limiting and token checks happen once at the edge. Each upstream is
+// probed on /healthz, requests to one that is down fail fast with a 503, and
+// idempotent requests are retried when an upstream hiccups. Forwarding goes
+// through an internal/httpclient transport, whose circuit breaker also
+// answers 503 while an upstream keeps failing.
+//
+// Run the services and the gateway side by side:
+//
+//	go run ./cmd/users -addr :8081 & go run ./cmd/books -addr :8082 &
+//	go run ./cmd/files -addr :8083 & go run ./cmd/gateway
+package gateway
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httputil"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/httpclient"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/ipfilter"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/server"
+)
+
+// routeKey carries the routing decision from the gin handler to the proxy's
+// Rewrite and ErrorHandler.
+type routeKey struct{}
+
+type route struct {
+	up   *upstream
+	path string
+	user *principal
+	c    *gin.Context
+}
+
+func newProxy(transport http.RoundTripper) *httputil.ReverseProxy {
+	return &httputil.ReverseProxy{
+		Transport: transport,
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			rt := pr.In.Context().Value(routeKey{}).(*route)
+			pr.Out.URL.Path = rt.path
+			pr.Out.URL.RawPath = ""
+			pr.SetURL(rt.up.target)
+			pr.SetXForwarded()
+			// identity headers only ever come from the gateway
+			pr.Out.Header.Del("X-User-ID")
+			pr.Out.Header.Del("X-User-Role")
+			if rt.user != nil {
+				pr.Out.Header.Set("X-User-ID", rt.user.ID)
+				pr.Out.Header.Set("X-User-Role", rt.user.Role)
+			}
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			rt := r.Context().Value(routeKey{}).(*route)
+			var tooLarge *http.MaxBytesError
+			if errors.Is(err, context.DeadlineExceeded) || errors.As(err, &tooLarge) {
+				middleware.Fail(rt.c, err)
+				return
+			}
+			rt.c.Error(err)
+			if errors.Is(err, httpclient.ErrCircuitOpen) {
+				middleware.Error(rt.c, http.StatusServiceUnavailable, "upstream unavailable")
+				return
+			}
+			middleware.Error(rt.c, http.StatusBadGateway, "upstream unavailable")
+		},
+	}
+}
+
+type gateway struct {
+	upstreams map[string]*upstream
+	proxy     *httputil.ReverseProxy
+	auth      gin.HandlerFunc
+}
+
+// edgeAuth requires a valid token except on public routes.
+func (g *gateway) edgeAuth(service string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if public(service, c.Request.Method, c.Param("path")) {
+			c.Next()
+			return
+		}
+		g.auth(c)
+	}
+}
+
+func (g *gateway) forward(up *upstream) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !up.healthy.Load() {
+			middleware.Error(c, http.StatusServiceUnavailable, "upstream unavailable")
+			return
+		}
+		rt := &route{up: up, path: c.Param("path"), c: c}
+		if v, ok := c.Get(middleware.UserKey); ok {
+			p := v.(principal)
+			rt.user = &p
+		}
+		ctx := context.WithValue(c.Request.Context(), routeKey{}, rt)
+		g.proxy.ServeHTTP(c.Writer, c.Request.WithContext(ctx))
+	}
+}
+
+func (g *gateway) status(c *gin.Context) {
+	names := make([]string, 0, len(g.upstreams))
+	for name := range g.upstreams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	out := make([]gin.H, len(names))
+	for i, name := range names {
+		up := g.upstreams[name]
+		entry := gin.H{"name": name, "url": up.target.String(), "healthy": up.healthy.Load()}
+		if t := up.checked.Load(); t != nil {
+			entry["checked_at"] = *t
+		}
+		out[i] = entry
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+// NewRouter builds the gateway example router.
+func NewRouter(deps server.Deps) *gin.Engine {
+	cfg, hooks := deps.Config, deps.Hooks
+	// the proxied body can't be read twice, so only bodyless requests are
+	// retried
+	transport := httpclient.NewTransport("gateway",
+		httpclient.WithRetries(cfg.Gateway.Retries), httpclient.WithBackoff(100*time.Millisecond))
+
+	g := &gateway{upstreams: map[string]*upstream{}, proxy: newProxy(transport)}
+	for name, raw := range cfg.Gateway.Upstreams {
+		up, err := newUpstream(name, raw, transport, cfg.Health.CheckTimeout)
+		if err != nil {
+			panic(err)
+		}
+		g.upstreams[name] = up
+	}
+	users, ok := g.upstreams["users"]
+	if !ok {
+		panic("gateway: the users upstream is needed to check tokens")
+	}
+	in := &introspector{
+		users:  users,
+		client: &http.Client{Transport: transport, Timeout: cfg.Health.CheckTimeout},
+		ttl:    30 * time.Second,
+		cache:  map[string]cachedPrincipal{},
+	}
+	g.auth = middleware.Auth(in.authenticate)
+
+	probeAll := func(ctx context.Context) error {
+		var errs []error
+		for _, up := range g.upstreams {
+			if err := up.probe(ctx); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return errors.Join(errs...)
+	}
+	// the scheduler's first run is a full interval away
+	go probeAll(context.Background())
+
+	limiter := middleware.NewRateLimiter(cfg.RateLimit.RequestsPerMinute,
+		middleware.WithName("gateway"),
+		middleware.WithBurst(cfg.RateLimit.Burst),
+		middleware.WithOnLimitExceeded(server.LogLimitExceeded),
+		middleware.WithAlgorithm(middleware.Algorithm(cfg.RateLimit.Algorithm)),
+		middleware.WithCosts(cfg.RateLimit.Costs),
+		middleware.WithIPFilter(ipfilter.Default))
+	// every instance has limiter state of its own to clean up, scheduler or not
+	limiter.StartJanitor(time.Minute)
+	hooks.Add(limiter.Stop)
+	deps.Tasks().Register("upstream_health", cfg.Gateway.HealthInterval, probeAll)
+	deps.Tasks().Register("gateway_cleanup", time.Minute, func(context.Context) error {
+		in.purge(time.Now())
+		return nil
+	})
+
+	router := server.NewEngine(deps)
+	router.GET("/gateway/upstreams", g.status)
+
+	edge := router.Group("", limiter.Middleware())
+	for name, up := range g.upstreams {
+		// the files service takes uploads; the others only JSON
+		limit := cfg.Server.MaxBodyBytes
+		if name == "files" {
+			limit = cfg.Storage.MaxUploadBytes
+		}
+		edge.Any("/"+name+"/*path", middleware.BodyLimit(limit), g.edgeAuth(name), g.forward(up))
+	}
+	return router
+}
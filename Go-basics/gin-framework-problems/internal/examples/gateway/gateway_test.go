@@ -0,0 +1,124 @@
+package gateway
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/testutil"
+)
+
+func TestPublic(t *testing.T) {
+	tests := []struct {
+		service, method, path string
+		want                  bool
+	}{
+		{"users", http.MethodPost, "/api/login", true},
+		{"users", http.MethodPost, "/api/register", true},
+		{"users", http.MethodPost, "/api/password/forgot", true},
+		{"users", http.MethodGet, "/api/profile", false},
+		{"users", http.MethodPost, "/api/logout", false},
+		{"books", http.MethodGet, "/books", true},
+		{"books", http.MethodHead, "/books/1", true},
+		{"books", http.MethodPost, "/books", false},
+		{"files", http.MethodDelete, "/files/1", false},
+	}
+	for _, tt := range tests {
+		if got := public(tt.service, tt.method, tt.path); got != tt.want {
+			t.Errorf("public(%s, %s, %s) = %v, want %v", tt.service, tt.method, tt.path, got, tt.want)
+		}
+	}
+}
+
+// fakeService is an upstream that answers /healthz, takes "good-token" on
+// /api/profile and echoes anything else with the identity headers it got.
+func fakeService(t *testing.T) string {
+	router := testutil.Engine()
+	router.GET("/healthz", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/api/profile", func(c *gin.Context) {
+		if c.GetHeader("Authorization") != "Bearer good-token" {
+			c.Status(http.StatusUnauthorized)
+			return
+		}
+		c.JSON(http.StatusOK, principal{ID: "u1", Username: "alice", Role: "admin"})
+	})
+	router.NoRoute(func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"method": c.Request.Method,
+			"path":   c.Request.URL.RequestURI(),
+			"user":   c.GetHeader("X-User-ID"),
+			"role":   c.GetHeader("X-User-Role"),
+		})
+	})
+	return testutil.Server(t, router).URL
+}
+
+func TestForward(t *testing.T) {
+	cfg := testutil.Config(t)
+	cfg.RateLimit.RequestsPerMinute = 1000
+	cfg.Gateway.Upstreams = map[string]string{"users": fakeService(t), "books": fakeService(t)}
+	router := testutil.Router(t, NewRouter, cfg)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		opts   []testutil.RequestOption
+		status int
+		want   gin.H
+	}{
+		{"public read", http.MethodGet, "/books/books?limit=5", nil, http.StatusOK,
+			gin.H{"method": "GET", "path": "/books?limit=5", "user": "", "role": ""}},
+		{"login", http.MethodPost, "/users/api/login", nil, http.StatusOK,
+			gin.H{"method": "POST", "path": "/api/login", "user": "", "role": ""}},
+		{"write without a token", http.MethodPost, "/books/books", nil, http.StatusUnauthorized, nil},
+		{"write with a bad token", http.MethodPost, "/books/books", []testutil.RequestOption{testutil.WithToken("bad-token")},
+			http.StatusUnauthorized, nil},
+		{"write with a token", http.MethodPost, "/books/books", []testutil.RequestOption{testutil.WithToken("good-token")},
+			http.StatusOK, gin.H{"method": "POST", "path": "/books", "user": "u1", "role": "admin"}},
+		// identity headers only ever come from the gateway
+		{"forged identity", http.MethodGet, "/books/books", []testutil.RequestOption{testutil.WithHeader("X-User-ID", "root")},
+			http.StatusOK, gin.H{"method": "GET", "path": "/books", "user": "", "role": ""}},
+		{"unknown service", http.MethodGet, "/orders/orders", nil, http.StatusNotFound, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := testutil.Do(t, router, tt.method, tt.path, nil, tt.opts...)
+			if tt.want == nil {
+				testutil.AssertStatus(t, w, tt.status)
+				return
+			}
+			testutil.AssertJSON(t, w, tt.status, tt.want)
+		})
+	}
+}
+
+func TestUpstreamDown(t *testing.T) {
+	down := testutil.Server(t, testutil.Engine())
+	down.Close()
+	cfg := testutil.Config(t)
+	cfg.RateLimit.RequestsPerMinute = 1000
+	cfg.Gateway.Upstreams = map[string]string{"users": fakeService(t), "files": down.URL}
+	router := testutil.Router(t, NewRouter, cfg)
+
+	// NewRouter probes every upstream once in the background
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		w := testutil.Do(t, router, http.MethodGet, "/gateway/upstreams", nil)
+		status := testutil.Decode[[]struct {
+			Name    string
+			Healthy bool
+		}](t, w)
+		if len(status) == 2 && status[0].Name == "files" && !status[0].Healthy && status[1].Healthy {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("upstreams = %+v, want files down and users up", status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	testutil.AssertStatus(t, testutil.Do(t, router, http.MethodGet, "/files/files", nil), http.StatusServiceUnavailable)
+	testutil.AssertStatus(t, testutil.Do(t, router, http.MethodPost, "/users/api/login", nil), http.StatusOK)
+}
@@ -0,0 +1,69 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"time"
+)
+
+// upstream is one service behind the gateway.
+type upstream struct {
+	name    string
+	target  *url.URL
+	client  *http.Client // for health probes
+	healthy atomic.Bool
+	checked atomic.Pointer[time.Time]
+}
+
+func newUpstream(name, raw string, transport http.RoundTripper, timeout time.Duration) (*upstream, error) {
+	target, err := url.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	u := &upstream{
+		name:   name,
+		target: target,
+		client: &http.Client{Transport: transport, Timeout: timeout},
+	}
+	// assume it's up until the first probe says otherwise
+	u.healthy.Store(true)
+	return u, nil
+}
+
+// probe asks the upstream's /healthz and records the answer, logging when
+// it changes.
+func (u *upstream) probe(ctx context.Context) error {
+	err := u.check(ctx)
+	now := time.Now()
+	u.checked.Store(&now)
+	if was := u.healthy.Swap(err == nil); was != (err == nil) {
+		if err != nil {
+			slog.WarnContext(ctx, "upstream down", "upstream", u.name, "error", err)
+		} else {
+			slog.InfoContext(ctx, "upstream up", "upstream", u.name)
+		}
+	}
+	return err
+}
+
+func (u *upstream) check(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.target.JoinPath("/healthz").String(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("healthz: %s", resp.Status)
+	}
+	return nil
+}
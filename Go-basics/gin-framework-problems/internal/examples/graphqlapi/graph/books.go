@@ -0,0 +1,41 @@
+package graph
+
+import (
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/books"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/graphqlapi/graph/model"
+)
+
+// maxLimit caps the page size of the books query.
+const maxLimit = 100
+
+// validateNewBook checks the input of createBook and updateBook.
+func validateNewBook(input model.NewBook) error {
+	if strings.TrimSpace(input.Title) == "" || strings.TrimSpace(input.Author) == "" {
+		return errors.New("title and author are required")
+	}
+	if input.Year < 1000 || input.Year > 2100 {
+		return errors.New("year must be between 1000 and 2100")
+	}
+	return nil
+}
+
+// matches reports whether b passes every field set in f.
+func matches(f model.BookFilter, b books.Book) bool {
+	if f.Title != nil && !strings.Contains(strings.ToLower(b.Title), strings.ToLower(*f.Title)) {
+		return false
+	}
+	if f.Author != nil && !strings.EqualFold(b.Author, *f.Author) {
+		return false
+	}
+	if f.YearFrom != nil && b.Year < *f.YearFrom || f.YearTo != nil && b.Year > *f.YearTo {
+		return false
+	}
+	if f.Tag != nil && !slices.ContainsFunc(b.Tags, func(t string) bool { return strings.EqualFold(t, *f.Tag) }) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,125 @@
+package graph
+
+import (
+	"context"
+
+	"github.com/graph-gophers/dataloader/v7"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/books"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/users"
+)
+
+// Loaders batch the lookups made by nested fields. Without them a query like
+// { books { owner { username } } } would hit the user store once per book;
+// with them every owner requested in the same tick is fetched in one call.
+// Authors and reviews are batched the same way.
+type Loaders struct {
+	UserByID      *dataloader.Loader[string, *users.User]
+	BooksByOwner  *dataloader.Loader[string, []*books.Book]
+	AuthorByID    *dataloader.Loader[string, *books.Author]
+	ReviewsByBook *dataloader.Loader[string, []*books.Review]
+}
+
+func (r *Resolver) loadUsers(ctx context.Context, ids []string) []*dataloader.Result[*users.User] {
+	found, err := r.Accounts.GetMany(ctx, ids)
+	out := make([]*dataloader.Result[*users.User], len(ids))
+	for i, id := range ids {
+		if err != nil {
+			out[i] = &dataloader.Result[*users.User]{Error: err}
+		} else if u, ok := found[id]; ok {
+			out[i] = &dataloader.Result[*users.User]{Data: &u}
+		} else {
+			// a deleted owner is a null field, not an error
+			out[i] = &dataloader.Result[*users.User]{}
+		}
+	}
+	return out
+}
+
+func (r *Resolver) loadBooksByOwner(ctx context.Context, ownerIDs []string) []*dataloader.Result[[]*books.Book] {
+	byOwner, err := r.Catalog.ListByOwners(ctx, ownerIDs)
+	out := make([]*dataloader.Result[[]*books.Book], len(ownerIDs))
+	for i, id := range ownerIDs {
+		if err != nil {
+			out[i] = &dataloader.Result[[]*books.Book]{Error: err}
+			continue
+		}
+		list := make([]*books.Book, 0, len(byOwner[id]))
+		for j := range byOwner[id] {
+			list = append(list, &byOwner[id][j])
+		}
+		out[i] = &dataloader.Result[[]*books.Book]{Data: list}
+	}
+	return out
+}
+
+func (r *Resolver) loadAuthors(ctx context.Context, ids []string) []*dataloader.Result[*books.Author] {
+	found, err := r.Catalog.AuthorsByID(ctx, ids)
+	out := make([]*dataloader.Result[*books.Author], len(ids))
+	for i, id := range ids {
+		if err != nil {
+			out[i] = &dataloader.Result[*books.Author]{Error: err}
+		} else if a, ok := found[id]; ok {
+			out[i] = &dataloader.Result[*books.Author]{Data: &a}
+		} else {
+			out[i] = &dataloader.Result[*books.Author]{}
+		}
+	}
+	return out
+}
+
+func (r *Resolver) loadReviewsByBook(ctx context.Context, bookIDs []string) []*dataloader.Result[[]*books.Review] {
+	byBook, err := r.Catalog.ReviewsByBooks(ctx, bookIDs)
+	out := make([]*dataloader.Result[[]*books.Review], len(bookIDs))
+	if err != nil {
+		for i := range out {
+			out[i] = &dataloader.Result[[]*books.Review]{Error: err}
+		}
+		return out
+	}
+	for i, id := range bookIDs {
+		list := make([]*books.Review, 0, len(byBook[id]))
+		for j := range byBook[id] {
+			list = append(list, &byBook[id][j])
+		}
+		out[i] = &dataloader.Result[[]*books.Review]{Data: list}
+	}
+	return out
+}
+
+// NewLoaders returns fresh loaders over r's stores. They cache results, so
+// they must be created per request.
+func (r *Resolver) NewLoaders() *Loaders {
+	return &Loaders{
+		UserByID:      dataloader.NewBatchedLoader(r.loadUsers),
+		BooksByOwner:  dataloader.NewBatchedLoader(r.loadBooksByOwner),
+		AuthorByID:    dataloader.NewBatchedLoader(r.loadAuthors),
+		ReviewsByBook: dataloader.NewBatchedLoader(r.loadReviewsByBook),
+	}
+}
+
+type loadersKey struct{}
+
+// WithLoaders attaches a fresh set of r's loaders to ctx.
+func (r *Resolver) WithLoaders(ctx context.Context) context.Context {
+	return context.WithValue(ctx, loadersKey{}, r.NewLoaders())
+}
+
+func (r *Resolver) loadersFor(ctx context.Context) *Loaders {
+	if l, ok := ctx.Value(loadersKey{}).(*Loaders); ok {
+		return l
+	}
+	return r.NewLoaders()
+}
+
+type userKey struct{}
+
+// WithUser stores the authenticated user for the resolvers.
+func WithUser(ctx context.Context, u users.User) context.Context {
+	return context.WithValue(ctx, userKey{}, u)
+}
+
+func currentUser(ctx context.Context) (users.User, bool) {
+	u, ok := ctx.Value(userKey{}).(users.User)
+	return u, ok
+}
@@ -0,0 +1,13 @@
+package graph
+
+import (
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/books"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/users"
+)
+
+// Resolver is the root resolver. Data lives in the books and users example
+// servers it is given; per-request loaders live in the context.
+type Resolver struct {
+	Catalog  *books.Server
+	Accounts *users.Server
+}
@@ -0,0 +1,191 @@
+package graph
+
+// This file will be automatically regenerated based on the schema, any resolver
+// implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.91
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/books"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/graphqlapi/graph/model"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/users"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/rbac"
+)
+
+// Owner is the resolver for the owner field.
+func (r *bookResolver) Owner(ctx context.Context, obj *books.Book) (*users.User, error) {
+	if obj.OwnerID == "" {
+		return nil, nil
+	}
+	return r.loadersFor(ctx).UserByID.Load(ctx, obj.OwnerID)()
+}
+
+// AuthorDetails is the resolver for the authorDetails field.
+func (r *bookResolver) AuthorDetails(ctx context.Context, obj *books.Book) (*books.Author, error) {
+	if obj.AuthorID == "" {
+		return nil, nil
+	}
+	return r.loadersFor(ctx).AuthorByID.Load(ctx, obj.AuthorID)()
+}
+
+// Reviews is the resolver for the reviews field.
+func (r *bookResolver) Reviews(ctx context.Context, obj *books.Book) ([]*books.Review, error) {
+	return r.loadersFor(ctx).ReviewsByBook.Load(ctx, obj.ID)()
+}
+
+// CreateBook is the resolver for the createBook field.
+func (r *mutationResolver) CreateBook(ctx context.Context, input model.NewBook) (*books.Book, error) {
+	u, ok := currentUser(ctx)
+	if !ok {
+		return nil, errors.New("authentication required")
+	}
+	if err := validateNewBook(input); err != nil {
+		return nil, err
+	}
+
+	b, err := r.Catalog.Create(ctx, books.Book{
+		Title:   input.Title,
+		Author:  input.Author,
+		Year:    input.Year,
+		OwnerID: u.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// UpdateBook is the resolver for the updateBook field.
+func (r *mutationResolver) UpdateBook(ctx context.Context, id string, version int, input model.NewBook) (*books.Book, error) {
+	u, ok := currentUser(ctx)
+	if !ok {
+		return nil, errors.New("authentication required")
+	}
+	if err := validateNewBook(input); err != nil {
+		return nil, err
+	}
+	b, err := r.Catalog.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if b.OwnerID != u.ID && !u.Can(rbac.BooksManage) {
+		return nil, errors.New("missing permission " + rbac.BooksManage)
+	}
+
+	// the input only has some of the fields; the others are kept
+	if input.Author != b.Author {
+		// a new name means another author
+		b.AuthorID = ""
+	}
+	b.Title, b.Author, b.Year, b.Version = input.Title, input.Author, input.Year, version
+	b, err = r.Catalog.Update(ctx, id, b)
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// Books is the resolver for the books field.
+func (r *queryResolver) Books(ctx context.Context, filter *model.BookFilter, limit int, offset int) ([]*books.Book, error) {
+	if limit < 1 || limit > maxLimit {
+		return nil, fmt.Errorf("limit must be between 1 and %d", maxLimit)
+	}
+	if offset < 0 {
+		return nil, errors.New("offset must not be negative")
+	}
+	list, err := r.Catalog.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if filter != nil {
+		list = slices.DeleteFunc(list, func(b books.Book) bool { return !matches(*filter, b) })
+	}
+	list = list[min(offset, len(list)):]
+	list = list[:min(limit, len(list))]
+	out := make([]*books.Book, len(list))
+	for i := range list {
+		out[i] = &list[i]
+	}
+	return out, nil
+}
+
+// Book is the resolver for the book field.
+func (r *queryResolver) Book(ctx context.Context, id string) (*books.Book, error) {
+	b, err := r.Catalog.Get(ctx, id)
+	if errors.Is(err, books.ErrBookNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// Me is the resolver for the me field.
+func (r *queryResolver) Me(ctx context.Context) (*users.User, error) {
+	u, ok := currentUser(ctx)
+	if !ok {
+		return nil, nil
+	}
+	return &u, nil
+}
+
+// User is the resolver for the user field.
+func (r *queryResolver) User(ctx context.Context, id string) (*users.User, error) {
+	me, ok := currentUser(ctx)
+	if !ok || me.ID != id && !me.Can(rbac.UsersRead) {
+		return nil, errors.New("missing permission " + rbac.UsersRead)
+	}
+	u, err := r.Accounts.Get(ctx, id)
+	if errors.Is(err, users.ErrUserNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// Users is the resolver for the users field.
+func (r *queryResolver) Users(ctx context.Context) ([]*users.User, error) {
+	u, ok := currentUser(ctx)
+	if !ok || !u.Can(rbac.UsersRead) {
+		return nil, errors.New("missing permission " + rbac.UsersRead)
+	}
+	list, err := r.Accounts.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*users.User, len(list))
+	for i := range list {
+		out[i] = &list[i]
+	}
+	return out, nil
+}
+
+// Books is the resolver for the books field.
+func (r *userResolver) Books(ctx context.Context, obj *users.User) ([]*books.Book, error) {
+	return r.loadersFor(ctx).BooksByOwner.Load(ctx, obj.ID)()
+}
+
+// Book returns BookResolver implementation.
+func (r *Resolver) Book() BookResolver { return &bookResolver{r} }
+
+// Mutation returns MutationResolver implementation.
+func (r *Resolver) Mutation() MutationResolver { return &mutationResolver{r} }
+
+// Query returns QueryResolver implementation.
+func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
+
+// User returns UserResolver implementation.
+func (r *Resolver) User() UserResolver { return &userResolver{r} }
+
+type bookResolver struct{ *Resolver }
+type mutationResolver struct{ *Resolver }
+type queryResolver struct{ *Resolver }
+type userResolver struct{ *Resolver }
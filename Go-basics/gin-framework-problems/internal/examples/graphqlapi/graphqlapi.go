@@ -0,0 +1,84 @@
+// Package graphqlapi serves a gqlgen GraphQL API over the users and books
+// example servers, with dataloaders batching the nested lookups.
+//
+// Register and log in through the users REST routes, then open /playground
+// and send the token as {"Authorization": "Bearer <token>"}:
+//
+//	mutation { createBook(input: {title: "Go", author: "Pike", year: 2015}) { id } }
+//	{ me { username books { title owner { username } } } }
+//	{ books(filter: {yearFrom: 2000}, limit: 10) { title authorDetails { bio } reviews { rating text } } }
+//	mutation { updateBook(id: "1", version: 1, input: {title: "Go", author: "Pike", year: 2016}) { version } }
+package graphqlapi
+
+import (
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/99designs/gqlgen/graphql/playground"
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/books"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/graphqlapi/graph"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/users"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/server"
+)
+
+// withRequestState attaches per-request loaders and the user Auth found, if
+// any. Anonymous requests are allowed: resolvers decide what needs a user.
+func withRequestState(resolver *graph.Resolver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := resolver.WithLoaders(c.Request.Context())
+		if u, ok := middleware.Principal[users.User](c); ok {
+			ctx = graph.WithUser(ctx, u)
+		}
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+func newGraphQLHandler(resolver *graph.Resolver) *handler.Server {
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{Resolvers: resolver}))
+	srv.AddTransport(transport.GET{})
+	srv.AddTransport(transport.POST{})
+	srv.Use(extension.Introspection{})
+	return srv
+}
+
+// Deps are what NewRouter builds the example from.
+type Deps struct {
+	server.Deps
+	// the books served; books.NewCatalog's when nil
+	Books *books.Server
+	// the users served, who also sign in; users.NewAccounts' when nil, which
+	// seeds the admin the users query needs
+	Users *users.Server
+}
+
+// NewRouter builds the GraphQL example router.
+func NewRouter(deps Deps) *gin.Engine {
+	resolver := &graph.Resolver{Catalog: deps.Books, Accounts: deps.Users}
+	if resolver.Catalog == nil {
+		resolver.Catalog = books.NewCatalog(deps.Deps)
+	}
+	if resolver.Accounts == nil {
+		resolver.Accounts = users.NewAccounts(deps.Deps)
+	}
+
+	router := server.NewEngine(deps.Deps)
+
+	api := router.Group("/api")
+	{
+		api.POST("/register", resolver.Accounts.Register)
+		api.POST("/login", resolver.Accounts.Login)
+	}
+
+	// a token from another tenant is refused like on the REST routes
+	authOptional := middleware.Auth(resolver.Accounts.LookupToken, middleware.WithAnonymous())
+	state := withRequestState(resolver)
+	gql := gin.WrapH(newGraphQLHandler(resolver))
+	router.GET("/graphql", authOptional, state, gql)
+	router.POST("/graphql", authOptional, state, gql)
+	router.GET("/playground", gin.WrapH(playground.Handler("Tech Learning Hub", "/graphql")))
+	return router
+}
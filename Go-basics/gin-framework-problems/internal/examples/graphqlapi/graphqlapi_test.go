@@ -0,0 +1,148 @@
+package graphqlapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/testutil"
+)
+
+type response struct {
+	Data   json.RawMessage
+	Errors []struct{ Message string }
+}
+
+// query posts a GraphQL query as the holder of token, if any.
+func query(t *testing.T, h http.Handler, token, q string, vars map[string]any) response {
+	t.Helper()
+	var opts []testutil.RequestOption
+	if token != "" {
+		opts = append(opts, testutil.WithToken(token))
+	}
+	w := testutil.DoJSON(t, h, http.MethodPost, "/graphql", map[string]any{"query": q, "variables": vars}, opts...)
+	testutil.AssertStatus(t, w, http.StatusOK)
+	return testutil.Decode[response](t, w)
+}
+
+// data is query's data, failing the test on errors.
+func data[T any](t *testing.T, h http.Handler, token, q string, vars map[string]any) T {
+	t.Helper()
+	resp := query(t, h, token, q, vars)
+	if len(resp.Errors) > 0 {
+		t.Fatalf("%s: %v", q, resp.Errors)
+	}
+	var out T
+	if err := json.Unmarshal(resp.Data, &out); err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+// wantError fails the test unless the query errs with a message containing want.
+func wantError(t *testing.T, h http.Handler, token, q string, vars map[string]any, want string) {
+	t.Helper()
+	resp := query(t, h, token, q, vars)
+	if len(resp.Errors) == 0 || !strings.Contains(resp.Errors[0].Message, want) {
+		t.Errorf("%s: errors %v, want %q", q, resp.Errors, want)
+	}
+}
+
+const createBook = `mutation($title: String!) {
+	createBook(input: {title: $title, author: "Rob Pike", year: 2015}) { id title }
+}`
+
+type book struct {
+	ID, Title string
+	Year      int
+	Owner     *struct{ Username string }
+}
+
+func TestBooks(t *testing.T) {
+	router := NewRouter(Deps{Deps: testutil.Deps(t, nil)})
+	owner := testutil.RegisterUser(t, router, "gqlowner")
+
+	wantError(t, router, "", createBook, map[string]any{"title": "Anonymous"}, "authentication required")
+	created := data[struct{ CreateBook book }](t, router, owner, createBook, map[string]any{"title": "GraphQL Go"}).CreateBook
+
+	got := data[struct{ Me struct{ Books []book } }](t, router, owner, `{ me { books { title owner { username } } } }`, nil)
+	want := []book{{Title: "GraphQL Go", Owner: &struct{ Username string }{"gqlowner"}}}
+	if diff := cmp.Diff(want, got.Me.Books); diff != "" {
+		t.Errorf("me.books (-want +got):\n%s", diff)
+	}
+
+	wantError(t, router, owner, `mutation { createBook(input: {title: "Old", author: "Anon", year: 900}) { id } }`, nil, "year must be between")
+	list := data[struct{ Books []book }](t, router, "", `{ books { id title } }`, nil)
+	if !slices.Contains(list.Books, book{ID: created.ID, Title: "GraphQL Go"}) {
+		t.Errorf("books = %+v, want the created book among them", list.Books)
+	}
+	if got := data[struct{ Book *book }](t, router, "", `{ book(id: "missing") { title } }`, nil); got.Book != nil {
+		t.Errorf("book(missing) = %+v, want null", got.Book)
+	}
+}
+
+func TestUsers(t *testing.T) {
+	cfg := testutil.Config(t)
+	router := NewRouter(Deps{Deps: testutil.Deps(t, cfg)})
+	user := testutil.RegisterUser(t, router, "gqluser")
+	admin := testutil.Login(t, router, "/api/login", "admin", cfg.Auth.AdminPassword)
+
+	if got := data[struct{ Me *struct{ Username string } }](t, router, "", `{ me { username } }`, nil); got.Me != nil {
+		t.Errorf("anonymous me = %+v, want null", got.Me)
+	}
+	wantError(t, router, user, `{ users { username } }`, nil, "missing permission users:read")
+	list := data[struct{ Users []struct{ Username string } }](t, router, admin, `{ users { username } }`, nil)
+	var names []string
+	for _, u := range list.Users {
+		names = append(names, u.Username)
+	}
+	if !slices.Contains(names, "gqluser") {
+		t.Errorf("users = %v, want gqluser among them", names)
+	}
+}
+
+func TestBookQueries(t *testing.T) {
+	router := NewRouter(Deps{Deps: testutil.Deps(t, nil)})
+	owner := testutil.RegisterUser(t, router, "gqlpager")
+	other := testutil.RegisterUser(t, router, "gqlother")
+	for _, title := range []string{"Paging One", "Paging Two", "Paging Three"} {
+		data[struct{ CreateBook book }](t, router, owner, createBook, map[string]any{"title": title})
+	}
+
+	const page = `query($title: String, $limit: Int!, $offset: Int!) {
+		books(filter: {title: $title}, limit: $limit, offset: $offset) { title reviews { id } }
+	}`
+	got := data[struct{ Books []book }](t, router, "", page, map[string]any{"title": "paging", "limit": 1, "offset": 1})
+	if diff := cmp.Diff([]book{{Title: "Paging Two"}}, got.Books); diff != "" {
+		t.Errorf("second page of books matching paging (-want +got):\n%s", diff)
+	}
+	wantError(t, router, "", page, map[string]any{"limit": 0, "offset": 0}, "limit must be between")
+
+	const update = `mutation($id: ID!, $version: Int!) {
+		updateBook(id: $id, version: $version, input: {title: "Paging Two", author: "Rob Pike", year: 2021}) { year version }
+	}`
+	type versioned struct {
+		ID      string
+		Version int
+	}
+	target := data[struct{ Books []versioned }](t, router, "", `{ books(filter: {title: "paging two"}) { id version } }`, nil).Books[0]
+	vars := map[string]any{"id": target.ID, "version": target.Version}
+	wantError(t, router, other, update, vars, "missing permission books:manage")
+	updated := data[struct{ UpdateBook book }](t, router, owner, update, vars).UpdateBook
+	if updated.Year != 2021 {
+		t.Errorf("updated year = %d, want 2021", updated.Year)
+	}
+	// the version it was read at is stale now
+	wantError(t, router, owner, update, vars, "book has changed")
+
+	me := data[struct{ Me struct{ ID string } }](t, router, owner, `{ me { id } }`, nil).Me
+	const user = `query($id: ID!) { user(id: $id) { username } }`
+	if got := data[struct{ User struct{ Username string } }](t, router, owner, user, map[string]any{"id": me.ID}); got.User.Username != "gqlpager" {
+		t.Errorf("user(own id) = %+v", got.User)
+	}
+	wantError(t, router, other, user, map[string]any{"id": me.ID}, "missing permission users:read")
+}
@@ -0,0 +1,161 @@
+// Package grpcbasics serves a gRPC Greeter next to a tiny REST API. Both
+// accept the tokens issued by the auth example's POST /login, so a client can
+// log in over REST and then call gRPC with the same token. cmd/grpc-client
+// walks through every RPC.
+package grpcbasics
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/auth"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/grpcbasics/greeterpb"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/logging"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/server"
+)
+
+type greeter struct {
+	greeterpb.UnimplementedGreeterServer
+}
+
+func (greeter) SayHello(ctx context.Context, req *greeterpb.HelloRequest) (*greeterpb.HelloReply, error) {
+	name := req.GetName()
+	if name == "" {
+		if p, ok := principal(ctx); ok {
+			name = p.Subject()
+		}
+	}
+	return &greeterpb.HelloReply{Message: "hello, " + name}, nil
+}
+
+func (greeter) Countdown(req *greeterpb.CountdownRequest, stream greeterpb.Greeter_CountdownServer) error {
+	if req.GetFrom() < 0 || req.GetFrom() > 100 {
+		return status.Error(codes.InvalidArgument, "from must be between 0 and 100")
+	}
+	interval := time.Duration(req.GetIntervalMs()) * time.Millisecond
+	if interval <= 0 {
+		interval = 200 * time.Millisecond
+	}
+	for v := req.GetFrom(); v >= 0; v-- {
+		if err := stream.Send(&greeterpb.CountdownReply{Value: v}); err != nil {
+			return err
+		}
+		select {
+		case <-time.After(interval):
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		}
+	}
+	return nil
+}
+
+func (greeter) Sum(stream greeterpb.Greeter_SumServer) error {
+	var total int64
+	var count int32
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return stream.SendAndClose(&greeterpb.SumReply{Total: total, Count: count})
+		}
+		if err != nil {
+			return err
+		}
+		total += req.GetValue()
+		count++
+	}
+}
+
+func (greeter) Echo(stream greeterpb.Greeter_EchoServer) error {
+	user := ""
+	if p, ok := principal(stream.Context()); ok {
+		user = p.Subject()
+	}
+	for {
+		msg, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		reply := &greeterpb.EchoMessage{User: user, Text: msg.GetText()}
+		if err := stream.Send(reply); err != nil {
+			return err
+		}
+	}
+}
+
+// NewGRPCServer returns a Greeter server with logging and auth interceptors.
+func NewGRPCServer(logger *slog.Logger, lookup middleware.Authenticator) *grpc.Server {
+	srv := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(UnaryLogging(logger), unaryAuth(lookup)),
+		grpc.ChainStreamInterceptor(streamLogging(logger), streamAuth(lookup)),
+	)
+	greeterpb.RegisterGreeterServer(srv, greeter{})
+	// lets grpcurl discover the service without the .proto file
+	reflection.Register(srv)
+	return srv
+}
+
+// Deps are what NewRouter builds the example from.
+type Deps struct {
+	server.Deps
+	// issues the tokens /login hands out and both servers check;
+	// auth.NewSessions' when nil
+	Auth *auth.Server
+}
+
+// NewRouter starts the gRPC server on cfg.GRPC.Addr and returns the REST
+// router, which only has the auth example's /login.
+func NewRouter(deps Deps) *gin.Engine {
+	cfg, hooks := deps.Config, deps.Hooks
+	authn := deps.Auth
+	if authn == nil {
+		authn = auth.NewSessions(deps.Deps)
+	}
+	logger := logging.New(cfg.Log)
+	Serve(NewGRPCServer(logger, authn.LookupToken), cfg.GRPC.Addr, logger, hooks)
+
+	router := server.NewEngine(deps.Deps)
+	router.POST("/login", authn.Login)
+	return router
+}
+
+// Serve runs srv on addr in the background, panicking when addr can't be
+// listened on, and adds a hook that stops it gracefully on shutdown.
+func Serve(srv *grpc.Server, addr string, logger *slog.Logger, hooks *server.Hooks) {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		panic(fmt.Sprintf("grpc listen on %s: %v", addr, err))
+	}
+	go func() {
+		logger.Info("grpc listening", "addr", addr)
+		if err := srv.Serve(lis); err != nil {
+			logger.Error("grpc serve", "error", err)
+		}
+	}()
+	hooks.Add(func(ctx context.Context) error {
+		done := make(chan struct{})
+		go func() {
+			srv.GracefulStop()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-ctx.Done():
+			srv.Stop()
+		}
+		return nil
+	})
+}
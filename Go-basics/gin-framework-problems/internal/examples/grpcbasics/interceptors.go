@@ -0,0 +1,94 @@
+package grpcbasics
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+)
+
+type principalKey struct{}
+
+// principal returns the user the auth interceptor attached to ctx.
+func principal(ctx context.Context) (middleware.Subjecter, bool) {
+	p, ok := ctx.Value(principalKey{}).(middleware.Subjecter)
+	return p, ok
+}
+
+// authenticate checks the "authorization: Bearer <token>" metadata with the
+// same Authenticator the REST routes use.
+func authenticate(ctx context.Context, lookup middleware.Authenticator) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return nil, status.Error(codes.Unauthenticated, "missing authorization metadata")
+	}
+	token, ok := middleware.BearerToken(values[0])
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "invalid authorization format")
+	}
+	user, err := lookup(token)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	return context.WithValue(ctx, principalKey{}, user), nil
+}
+
+func unaryAuth(lookup middleware.Authenticator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := authenticate(ctx, lookup)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// authStream swaps in the authenticated context for a stream handler.
+type authStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s authStream) Context() context.Context { return s.ctx }
+
+func streamAuth(lookup middleware.Authenticator) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authenticate(ss.Context(), lookup)
+		if err != nil {
+			return err
+		}
+		return handler(srv, authStream{ss, ctx})
+	}
+}
+
+// UnaryLogging logs each call with its method, status code and latency.
+func UnaryLogging(logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		logger.InfoContext(ctx, "grpc",
+			"method", info.FullMethod,
+			"code", status.Code(err).String(),
+			"latency", time.Since(start))
+		return resp, err
+	}
+}
+
+func streamLogging(logger *slog.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		logger.InfoContext(ss.Context(), "grpc stream",
+			"method", info.FullMethod,
+			"code", status.Code(err).String(),
+			"duration", time.Since(start))
+		return err
+	}
+}
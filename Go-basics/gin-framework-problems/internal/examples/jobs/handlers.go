@@ -0,0 +1,164 @@
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif" // decoders for thumbnail sources
+	_ "image/jpeg"
+	"image/png"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/httpclient"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/jobs"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/storage"
+)
+
+// thumbnailSize is the thumbnail made when the payload names no sizes.
+const thumbnailSize = 128
+
+type thumbnailPayload struct {
+	File  string `json:"file"`            // ID of a file in storage
+	Blob  string `json:"blob,omitempty"`  // where its content is stored, if not under the ID
+	Sizes []int  `json:"sizes,omitempty"` // longest side of each thumbnail, in pixels
+}
+
+// ThumbnailName is what the thumbnail of file no larger than size on either
+// side is stored as.
+func ThumbnailName(file string, size int) string {
+	return path.Join("thumbnails", strconv.Itoa(size), path.Base(file)+".png")
+}
+
+// thumbnail stores a PNG of each size in the payload as ThumbnailName.
+// Files that aren't GIF, JPEG or PNG images fail without retrying.
+func thumbnail(store storage.Storage) jobs.Handler {
+	return func(ctx context.Context, payload json.RawMessage) error {
+		var p thumbnailPayload
+		if err := json.Unmarshal(payload, &p); err != nil || p.File == "" {
+			return jobs.Permanent(errors.New("payload needs a file name"))
+		}
+		if len(p.Sizes) == 0 {
+			p.Sizes = []int{thumbnailSize}
+		}
+		if slices.ContainsFunc(p.Sizes, func(size int) bool { return size <= 0 }) {
+			return jobs.Permanent(errors.New("thumbnail sizes must be positive"))
+		}
+		name, blob := path.Base(p.File), p.Blob
+		if blob == "" {
+			blob = name
+		}
+
+		f, err := store.Open(ctx, blob)
+		if err != nil {
+			if errors.Is(err, storage.ErrNotExist) {
+				return jobs.Permanent(err)
+			}
+			return err
+		}
+		defer f.Close()
+		src, _, err := image.Decode(f)
+		if err != nil {
+			return jobs.Permanent(fmt.Errorf("decode %s: %w", name, err))
+		}
+		for _, size := range p.Sizes {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			var buf bytes.Buffer
+			if err := png.Encode(&buf, scale(src, size)); err != nil {
+				return err
+			}
+			if err := store.Save(ctx, ThumbnailName(name, size), &buf, int64(buf.Len())); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// scale shrinks src to fit in a size x size box with nearest-neighbour
+// sampling. Images that already fit are returned as is.
+func scale(src image.Image, size int) image.Image {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= size && h <= size {
+		return src
+	}
+	tw, th := size, h*size/w
+	if h > w {
+		tw, th = w*size/h, size
+	}
+	tw, th = max(tw, 1), max(th, 1)
+
+	dst := image.NewRGBA(image.Rect(0, 0, tw, th))
+	for y := range th {
+		for x := range tw {
+			dst.Set(x, y, src.At(b.Min.X+x*w/tw, b.Min.Y+y*h/th))
+		}
+	}
+	return dst
+}
+
+type webhookPayload struct {
+	URL   string `json:"url"`
+	Event string `json:"event"`
+	Data  any    `json:"data"`
+}
+
+// webhookClient leaves retrying to the job queue.
+var webhookClient = httpclient.New("jobs_webhook", httpclient.WithTimeout(10*time.Second), httpclient.WithRetries(0))
+
+// webhook POSTs {"event", "data"} to the payload's URL, signed with an
+// X-Hub-Signature HMAC of the body so the receiver can verify the sender.
+// 5xx and network errors are retried; other non-2xx answers are not.
+func webhook(secret string) jobs.Handler {
+	return func(ctx context.Context, payload json.RawMessage) error {
+		var p webhookPayload
+		if err := json.Unmarshal(payload, &p); err != nil {
+			return jobs.Permanent(err)
+		}
+		u, err := url.Parse(p.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return jobs.Permanent(fmt.Errorf("invalid webhook url %q", p.URL))
+		}
+
+		body, err := json.Marshal(map[string]any{"event": p.Event, "data": p.Data})
+		if err != nil {
+			return jobs.Permanent(err)
+		}
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(body)
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), bytes.NewReader(body))
+		if err != nil {
+			return jobs.Permanent(err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Hub-Event", p.Event)
+		req.Header.Set("X-Hub-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+
+		resp, err := webhookClient.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		switch {
+		case resp.StatusCode >= 500:
+			return fmt.Errorf("webhook answered %s", resp.Status)
+		case resp.StatusCode >= 300:
+			return jobs.Permanent(fmt.Errorf("webhook answered %s", resp.Status))
+		}
+		return nil
+	}
+}
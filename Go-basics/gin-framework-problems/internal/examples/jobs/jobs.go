@@ -0,0 +1,130 @@
+// Package jobs shows background processing: requests enqueue work and return
+// 202 right away, a worker pool runs it with retries, and clients poll the
+// job's status.
+//
+//	curl -X POST localhost:8080/jobs -d '{"type":"thumbnail","payload":{"file":"<file id>","blob":"sha256/<digest>","sizes":[128,512]}}'
+//	curl -X POST localhost:8080/jobs -d '{"type":"webhook","payload":{"url":"http://localhost:9000/hook","event":"book.created","data":{"id":"1"}}}'
+//	curl -X POST localhost:8080/jobs -d '{"type":"send_mail","payload":{"From":"hub@localhost","To":["alice@example.com"],"Subject":"Hi","Text":"Hello"}}'
+//	curl localhost:8080/jobs/<id>
+//	curl -X DELETE localhost:8080/jobs/<id>
+//
+// Thumbnails are made from files uploaded through the files example, which
+// shares storage.backend and stores each file's content under its digest
+// (see GET /files/<id>/meta), or under its ID for files from before digests.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/config"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/healthcheck"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/jobs"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/logging"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/mail"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/server"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/storage"
+)
+
+type enqueueRequest struct {
+	Type    string          `json:"type" binding:"required"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+func enqueue(pool *jobs.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req enqueueRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			middleware.BindError(c, err)
+			return
+		}
+		job, err := pool.Enqueue(c.Request.Context(), req.Type, req.Payload)
+		switch {
+		case errors.Is(err, jobs.ErrUnknownType):
+			middleware.Error(c, http.StatusBadRequest, err.Error())
+		case errors.Is(err, jobs.ErrQueueFull), errors.Is(err, jobs.ErrClosed):
+			c.Header("Retry-After", "5")
+			middleware.Error(c, http.StatusServiceUnavailable, err.Error())
+		case err != nil:
+			middleware.Error(c, http.StatusInternalServerError, err.Error())
+		default:
+			c.Header("Location", "/jobs/"+job.ID)
+			c.JSON(http.StatusAccepted, job)
+		}
+	}
+}
+
+func status(pool *jobs.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		job, ok := pool.Get(c.Param("id"))
+		if !ok {
+			middleware.Error(c, http.StatusNotFound, "job not found")
+			return
+		}
+		c.JSON(http.StatusOK, job)
+	}
+}
+
+func cancel(pool *jobs.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		job, err := pool.Cancel(c.Param("id"))
+		switch {
+		case errors.Is(err, jobs.ErrNotFound):
+			middleware.Error(c, http.StatusNotFound, "job not found")
+		case errors.Is(err, jobs.ErrFinished):
+			middleware.Error(c, http.StatusConflict, "job already "+string(job.Status))
+		default:
+			c.JSON(http.StatusOK, job)
+		}
+	}
+}
+
+// NewPool builds a worker pool from cfg with the thumbnail, webhook and mail
+// handlers registered, and adds its shutdown to hooks. It is not started.
+// Every pool handles every type, as pools may share the Redis queue.
+func NewPool(cfg *config.Config, hooks *server.Hooks) *jobs.Pool {
+	opts := []jobs.Option{
+		jobs.WithWorkers(cfg.Jobs.Workers),
+		jobs.WithMaxAttempts(cfg.Jobs.MaxAttempts),
+		jobs.WithBackoff(cfg.Jobs.Backoff, cfg.Jobs.MaxBackoff),
+		jobs.WithLogger(logging.New(cfg.Log)),
+	}
+	if cfg.Jobs.Queue == "redis" {
+		client := redis.NewClient(&redis.Options{Addr: cfg.Redis.Addr})
+		hooks.Add(func(context.Context) error { return client.Close() })
+		q := jobs.NewRedisQueue(client, "hub:jobs")
+		healthcheck.Default.Register("redis", q.Ping)
+		opts = append(opts, jobs.WithQueue(q))
+	}
+
+	pool := jobs.NewPool(opts...)
+	store, err := storage.New(cfg.Storage)
+	if err != nil {
+		panic(err)
+	}
+	pool.Handle("thumbnail", thumbnail(store))
+	pool.Handle("webhook", webhook(cfg.Auth.TokenSecret))
+	pool.Handle(mail.JobType, mail.Handler(mail.NewSender(cfg.Mail)))
+	// added after the Redis client so it runs first on shutdown
+	hooks.Add(pool.Shutdown)
+	return pool
+}
+
+// NewRouter builds the background jobs example router.
+func NewRouter(deps server.Deps) *gin.Engine {
+	cfg, hooks := deps.Config, deps.Hooks
+	pool := NewPool(cfg, hooks)
+	pool.Start()
+
+	router := server.NewEngine(deps)
+	router.POST("/jobs", enqueue(pool))
+	router.GET("/jobs/:id", status(pool))
+	router.DELETE("/jobs/:id", cancel(pool))
+	return router
+}
@@ -0,0 +1,197 @@
+package jobs
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"image"
+	"image/png"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/config"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/jobs"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/testutil"
+)
+
+func TestScale(t *testing.T) {
+	tests := []struct {
+		w, h, size int
+		want       image.Point
+	}{
+		{10, 10, 20, image.Pt(10, 10)},
+		{10, 10, 10, image.Pt(10, 10)},
+		{100, 50, 10, image.Pt(10, 5)},
+		{50, 100, 10, image.Pt(5, 10)},
+		// never thinner than a pixel
+		{1000, 1, 10, image.Pt(10, 1)},
+	}
+	for _, tt := range tests {
+		src := image.NewRGBA(image.Rect(0, 0, tt.w, tt.h))
+		if got := scale(src, tt.size).Bounds().Size(); got != tt.want {
+			t.Errorf("scale(%dx%d, %d) = %v, want %v", tt.w, tt.h, tt.size, got, tt.want)
+		}
+	}
+}
+
+// fastRetries is the test config with quick retries, for tests that wait
+// for a job to fail.
+func fastRetries(t *testing.T) *config.Config {
+	cfg := testutil.Config(t)
+	cfg.Jobs.MaxAttempts = 3
+	cfg.Jobs.Backoff, cfg.Jobs.MaxBackoff = time.Millisecond, time.Millisecond
+	return cfg
+}
+
+// run enqueues a job and waits for it to finish.
+func run(t *testing.T, h http.Handler, typ string, payload any) jobs.Job {
+	t.Helper()
+	w := testutil.DoJSON(t, h, http.MethodPost, "/jobs", gin.H{"type": typ, "payload": payload})
+	testutil.AssertStatus(t, w, http.StatusAccepted)
+	path := w.Header().Get("Location")
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		job := testutil.Decode[jobs.Job](t, testutil.Do(t, h, http.MethodGet, path, nil))
+		if job.Status == jobs.StatusSucceeded || job.Status == jobs.StatusFailed {
+			return job
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job %s still %s", job.ID, job.Status)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestWebhookJob(t *testing.T) {
+	cfg := fastRetries(t)
+	router := testutil.Router(t, NewRouter, cfg)
+
+	var got struct {
+		sync.Mutex
+		event, body string
+		signed      bool
+	}
+	receiver := testutil.Engine()
+	receiver.POST("/hook", func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		mac := hmac.New(sha256.New, []byte(cfg.Auth.TokenSecret))
+		mac.Write(body)
+		got.Lock()
+		defer got.Unlock()
+		got.event, got.body = c.GetHeader("X-Hub-Event"), string(body)
+		got.signed = c.GetHeader("X-Hub-Signature") == "sha256="+hex.EncodeToString(mac.Sum(nil))
+		c.Status(http.StatusNoContent)
+	})
+	receiver.POST("/broken", func(c *gin.Context) { c.Status(http.StatusInternalServerError) })
+	receiver.POST("/gone", func(c *gin.Context) { c.Status(http.StatusGone) })
+	url := testutil.Server(t, receiver).URL
+
+	tests := []struct {
+		name     string
+		url      string
+		status   jobs.Status
+		attempts int
+	}{
+		{"delivered", url + "/hook", jobs.StatusSucceeded, 1},
+		{"server error, retried", url + "/broken", jobs.StatusFailed, 3},
+		{"client error, not retried", url + "/gone", jobs.StatusFailed, 1},
+		{"not a url", "ftp://example.com/hook", jobs.StatusFailed, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job := run(t, router, "webhook", gin.H{"url": tt.url, "event": "book.created", "data": gin.H{"id": "1"}})
+			if job.Status != tt.status || job.Attempts != tt.attempts {
+				t.Errorf("job %s after %d attempts, want %s after %d; last error %q",
+					job.Status, job.Attempts, tt.status, tt.attempts, job.LastError)
+			}
+		})
+	}
+	got.Lock()
+	defer got.Unlock()
+	if got.event != "book.created" || got.body != `{"data":{"id":"1"},"event":"book.created"}` || !got.signed {
+		t.Errorf("receiver got event %q, body %s, signed %v", got.event, got.body, got.signed)
+	}
+}
+
+func TestThumbnailJob(t *testing.T) {
+	cfg := fastRetries(t)
+	router := testutil.Router(t, NewRouter, cfg)
+	dir := cfg.Storage.UploadDir
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	var src bytes.Buffer
+	png.Encode(&src, image.NewRGBA(image.Rect(0, 0, 400, 200)))
+	if err := os.WriteFile(filepath.Join(dir, "wide.png"), src.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(dir, "notimage"), []byte("text"), 0644)
+
+	tests := []struct {
+		name    string
+		payload gin.H
+		status  jobs.Status
+	}{
+		{"image", gin.H{"file": "wide.png", "sizes": []int{128, 300}}, jobs.StatusSucceeded},
+		{"bad size", gin.H{"file": "wide.png", "sizes": []int{0}}, jobs.StatusFailed},
+		{"missing file", gin.H{"file": "missing"}, jobs.StatusFailed},
+		{"not an image", gin.H{"file": "notimage"}, jobs.StatusFailed},
+		{"no file", gin.H{}, jobs.StatusFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job := run(t, router, "thumbnail", tt.payload)
+			// none of the failures are worth retrying
+			if job.Status != tt.status || job.Attempts != 1 {
+				t.Errorf("job %s after %d attempts, want %s after 1; last error %q", job.Status, job.Attempts, tt.status, job.LastError)
+			}
+		})
+	}
+
+	for size, want := range map[int]image.Point{128: image.Pt(128, 64), 300: image.Pt(300, 150)} {
+		f, err := os.Open(filepath.Join(dir, filepath.FromSlash(ThumbnailName("wide.png", size))))
+		if err != nil {
+			t.Fatal(err)
+		}
+		img, err := png.Decode(f)
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := img.Bounds().Size(); got != want {
+			t.Errorf("%d thumbnail is %v, want %v", size, got, want)
+		}
+	}
+}
+
+func TestJobRoutes(t *testing.T) {
+	router := testutil.Router(t, NewRouter, fastRetries(t))
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   any
+		status int
+	}{
+		{"unknown type", http.MethodPost, "/jobs", gin.H{"type": "nope"}, http.StatusBadRequest},
+		{"no type", http.MethodPost, "/jobs", gin.H{"payload": gin.H{}}, http.StatusBadRequest},
+		{"unknown job", http.MethodGet, "/jobs/missing", nil, http.StatusNotFound},
+		{"cancel unknown job", http.MethodDelete, "/jobs/missing", nil, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testutil.AssertStatus(t, testutil.DoJSON(t, router, tt.method, tt.path, tt.body), tt.status)
+		})
+	}
+
+	job := run(t, router, "thumbnail", gin.H{})
+	testutil.AssertStatus(t, testutil.Do(t, router, http.MethodDelete, "/jobs/"+job.ID, nil), http.StatusConflict)
+}
@@ -0,0 +1,113 @@
+// Package notify streams server notifications over Server-Sent Events.
+//
+//	curl -N localhost:8080/events
+//	curl -X POST localhost:8080/events -d '{"event":"deploy","data":{"version":"1.2"}}'
+//
+// A client that reconnects with Last-Event-ID gets the events it missed.
+// Domain events from the bus (user.registered, file.uploaded, file.deleted,
+// book.updated) are streamed and numbered along with them.
+//
+// Signed-in users also get notifications meant for them over a WebSocket,
+// logging in through the auth example's /login:
+//
+//	ws://localhost:8080/ws?token=tok_alice_1
+//
+// Every user hears of book.updated, the uploader of file.uploaded and
+// file.deleted, and admins of user.registered.
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/events"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/auth"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/server"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/sse"
+)
+
+type publishRequest struct {
+	Event string `json:"event" binding:"required"`
+	Data  any    `json:"data"`
+}
+
+func publish(broker *sse.Broker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req publishRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			middleware.BindError(c, err)
+			return
+		}
+		ev := broker.Publish(req.Event, req.Data)
+		c.JSON(http.StatusAccepted, ev)
+	}
+}
+
+// forward relays every event on t to the SSE stream, named by its subject.
+// With a NATS bus this includes events from the other examples' processes.
+func forward[T any](broker *sse.Broker, t events.Topic[T]) error {
+	_, err := events.Subscribe(events.Default, t, func(_ context.Context, ev T) {
+		broker.Publish(t.Subject, ev)
+	})
+	return err
+}
+
+// push has hub notify users of the events on the bus meant for them.
+func push(hub *Hub) []error {
+	_, booksErr := events.Subscribe(events.Default, events.BookUpdated, func(_ context.Context, ev events.BookUpdatedEvent) {
+		hub.Broadcast(events.BookUpdated.Subject, ev)
+	})
+	_, filesErr := events.Subscribe(events.Default, events.FileUploaded, func(_ context.Context, ev events.FileUploadedEvent) {
+		if ev.Uploader != "" {
+			hub.Notify(ev.Uploader, events.FileUploaded.Subject, ev)
+		}
+	})
+	_, deletedErr := events.Subscribe(events.Default, events.FileDeleted, func(_ context.Context, ev events.FileDeletedEvent) {
+		if ev.Uploader != "" {
+			hub.Notify(ev.Uploader, events.FileDeleted.Subject, ev)
+		}
+	})
+	// it carries the email address, which only admins get to see
+	_, usersErr := events.Subscribe(events.Default, events.UserRegistered, func(_ context.Context, ev events.UserRegisteredEvent) {
+		hub.NotifyRole("admin", events.UserRegistered.Subject, ev)
+	})
+	return []error{booksErr, filesErr, deletedErr, usersErr}
+}
+
+// NewRouter builds the SSE and WebSocket notifications example router.
+func NewRouter(deps server.Deps) *gin.Engine {
+	hooks := deps.Hooks
+	authn := auth.NewSessions(deps)
+	broker := sse.NewBroker(100, 15*time.Second)
+	hub := NewHub()
+	hooks.Add(func(context.Context) error {
+		broker.Close()
+		hub.Close()
+		return nil
+	})
+
+	router := server.NewEngine(deps)
+	// after NewEngine, which picks the bus
+	errs := append([]error{
+		forward(broker, events.UserRegistered),
+		forward(broker, events.FileUploaded),
+		forward(broker, events.FileDeleted),
+		forward(broker, events.BookUpdated),
+	}, push(hub)...)
+	for _, err := range errs {
+		if err != nil {
+			panic(fmt.Sprintf("subscribe: %v", err))
+		}
+	}
+
+	router.POST("/login", authn.Login)
+	router.GET("/events", middleware.WithoutTimeout(), broker.Handler())
+	router.POST("/events", publish(broker))
+	router.GET("/ws", middleware.Auth(authn.LookupToken, middleware.WithQueryToken("token")), hub.Handler())
+	return router
+}
@@ -0,0 +1,91 @@
+package notify
+
+import (
+	"bufio"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/events"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/testutil"
+)
+
+// stream reads one server-sent event at a time.
+type stream struct {
+	r *bufio.Reader
+}
+
+func (s stream) next(t *testing.T) (id, event, data string) {
+	t.Helper()
+	for {
+		line, err := s.r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read event: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "" && event != "":
+			return id, event, data
+		case strings.HasPrefix(line, "id: "):
+			id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+// subscribe opens GET /events on srv, resuming after lastID when set.
+func subscribe(t *testing.T, url, lastID string) stream {
+	t.Helper()
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, url+"/events", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lastID != "" {
+		req.Header.Set("Last-Event-ID", lastID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q, want text/event-stream", ct)
+	}
+	return stream{bufio.NewReader(resp.Body)}
+}
+
+func TestEvents(t *testing.T) {
+	router := testutil.Router(t, NewRouter, nil)
+	srv := testutil.Server(t, router)
+	live := subscribe(t, srv.URL, "")
+
+	testutil.AssertStatus(t, testutil.DoJSON(t, router, http.MethodPost, "/events", gin.H{"data": 1}), http.StatusBadRequest)
+	w := testutil.DoJSON(t, router, http.MethodPost, "/events", gin.H{"event": "deploy", "data": gin.H{"version": "1.2"}})
+	testutil.AssertStatus(t, w, http.StatusAccepted)
+	first := testutil.Decode[struct{ ID int }](t, w).ID
+
+	if _, event, data := live.next(t); event != "deploy" || data != `{"version":"1.2"}` {
+		t.Errorf("got %s %s, want the published deploy", event, data)
+	}
+	// domain events are numbered along with them
+	err := events.Publish(t.Context(), events.Default, events.BookUpdated, events.BookUpdatedEvent{BookID: "1", Action: "updated"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, event, _ := live.next(t)
+	if event != "book.updated" {
+		t.Fatalf("got %s, want book.updated", event)
+	}
+
+	// a client coming back gets what it missed
+	resumed := subscribe(t, srv.URL, strconv.Itoa(first))
+	if got, event, _ := resumed.next(t); got != id || event != "book.updated" {
+		t.Errorf("resumed with event %s %s, want %s book.updated", got, event, id)
+	}
+}
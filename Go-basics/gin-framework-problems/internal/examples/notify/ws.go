@@ -0,0 +1,216 @@
+package notify
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/auth"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+)
+
+const (
+	writeWait       = 10 * time.Second    // time allowed to write a message
+	pongWait        = 60 * time.Second    // time allowed to read the next pong
+	pingPeriod      = (pongWait * 9) / 10 // must be less than pongWait
+	maxMessageSize  = 512                 // clients only send control frames
+	maxConnsPerUser = 5                   // tabs and devices of one user
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	// the example has no fixed front-end origin
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// Notification is one message pushed on /ws.
+type Notification struct {
+	Event string    `json:"event"`
+	Data  any       `json:"data"`
+	Time  time.Time `json:"time"`
+}
+
+// Hub holds the /ws connections of the signed-in users, by username, for
+// the server to push notifications to one user, the users with a role, or
+// everyone. A connection that can't keep up is dropped rather than block
+// the others; the client reconnects.
+type Hub struct {
+	mu     sync.Mutex
+	conns  map[string]map[*conn]struct{}
+	closed bool
+}
+
+// conn is one /ws connection of a user.
+type conn struct {
+	ws   *websocket.Conn
+	user auth.UserInfo
+	send chan Notification
+}
+
+// NewHub returns a Hub with no connections.
+func NewHub() *Hub {
+	return &Hub{conns: map[string]map[*conn]struct{}{}}
+}
+
+// Notify pushes event to every connection of user.
+func (h *Hub) Notify(user, event string, data any) {
+	h.deliver(newNotification(event, data), func(u auth.UserInfo) bool { return u.Username == user })
+}
+
+// NotifyRole pushes event to the connected users with role.
+func (h *Hub) NotifyRole(role, event string, data any) {
+	h.deliver(newNotification(event, data), func(u auth.UserInfo) bool { return u.HasRole(role) })
+}
+
+// Broadcast pushes event to every connected user.
+func (h *Hub) Broadcast(event string, data any) {
+	h.deliver(newNotification(event, data), func(auth.UserInfo) bool { return true })
+}
+
+func newNotification(event string, data any) Notification {
+	return Notification{Event: event, Data: data, Time: time.Now().UTC()}
+}
+
+func (h *Hub) deliver(n Notification, to func(auth.UserInfo) bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, conns := range h.conns {
+		for c := range conns {
+			if !to(c.user) {
+				continue
+			}
+			select {
+			case c.send <- n:
+			default:
+				h.remove(c)
+			}
+		}
+	}
+}
+
+// add registers c, unless its user has maxConnsPerUser already or the hub
+// is closed.
+func (h *Hub) add(c *conn) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	name := c.user.Username
+	if h.closed || len(h.conns[name]) >= maxConnsPerUser {
+		return false
+	}
+	if h.conns[name] == nil {
+		h.conns[name] = map[*conn]struct{}{}
+	}
+	h.conns[name][c] = struct{}{}
+	return true
+}
+
+// drop unregisters c, closing its send channel if it was still registered.
+func (h *Hub) drop(c *conn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.remove(c)
+}
+
+// remove is drop with h.mu held.
+func (h *Hub) remove(c *conn) {
+	name := c.user.Username
+	if _, ok := h.conns[name][c]; !ok {
+		return
+	}
+	delete(h.conns[name], c)
+	if len(h.conns[name]) == 0 {
+		delete(h.conns, name)
+	}
+	close(c.send)
+}
+
+// Close ends every connection and refuses new ones; use it as a shutdown
+// hook.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for _, conns := range h.conns {
+		for c := range conns {
+			h.remove(c)
+		}
+	}
+}
+
+// Handler upgrades the request to a WebSocket that receives the user's
+// notifications. Mount it behind Auth on an auth example server's
+// LookupToken.
+func (h *Hub) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cl := &conn{user: auth.MustUser(c), send: make(chan Notification, 32)}
+		// registered before upgrading, so a refusal can still be an HTTP answer
+		if !h.add(cl) {
+			middleware.Error(c, http.StatusTooManyRequests, "too many connections")
+			return
+		}
+		ws, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+		if err != nil {
+			// Upgrade already wrote the error response
+			h.drop(cl)
+			return
+		}
+		cl.ws = ws
+		go cl.writePump()
+		go cl.readPump(h)
+	}
+}
+
+// readPump reads until the connection drops, which it reports to the hub.
+// Clients send nothing but control frames; reading is what processes the
+// pongs that keep the connection alive.
+func (c *conn) readPump(h *Hub) {
+	defer func() {
+		h.drop(c)
+		c.ws.Close()
+	}()
+
+	c.ws.SetReadLimit(maxMessageSize)
+	c.ws.SetReadDeadline(time.Now().Add(pongWait))
+	c.ws.SetPongHandler(func(string) error {
+		return c.ws.SetReadDeadline(time.Now().Add(pongWait))
+	})
+	for {
+		if _, _, err := c.ws.NextReader(); err != nil {
+			return
+		}
+	}
+}
+
+// writePump is the only writer of the connection: it sends queued
+// notifications and pings the peer so dead connections are noticed.
+func (c *conn) writePump() {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		c.ws.Close()
+	}()
+
+	for {
+		select {
+		case n, ok := <-c.send:
+			c.ws.SetWriteDeadline(time.Now().Add(writeWait))
+			if !ok {
+				// the hub dropped the connection
+				c.ws.WriteMessage(websocket.CloseMessage, []byte{})
+				return
+			}
+			if err := c.ws.WriteJSON(n); err != nil {
+				return
+			}
+		case <-ticker.C:
+			c.ws.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.ws.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
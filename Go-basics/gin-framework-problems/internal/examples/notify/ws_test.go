@@ -0,0 +1,88 @@
+package notify
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/events"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/testutil"
+)
+
+// dial opens /ws on srv with token, and returns the response status when
+// the upgrade is refused.
+func dial(t *testing.T, url, token string) (*websocket.Conn, int) {
+	t.Helper()
+	ws, resp, err := websocket.DefaultDialer.DialContext(t.Context(), "ws"+strings.TrimPrefix(url, "http")+"/ws?token="+token, nil)
+	if err != nil {
+		if resp == nil {
+			t.Fatal(err)
+		}
+		return nil, resp.StatusCode
+	}
+	t.Cleanup(func() { ws.Close() })
+	return ws, http.StatusSwitchingProtocols
+}
+
+// receive reads the next notification on ws.
+func receive(t *testing.T, ws *websocket.Conn) Notification {
+	t.Helper()
+	ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var n Notification
+	if err := ws.ReadJSON(&n); err != nil {
+		t.Fatalf("read notification: %v", err)
+	}
+	return n
+}
+
+func TestWebSocket(t *testing.T) {
+	router := testutil.Router(t, NewRouter, nil)
+	srv := testutil.Server(t, router)
+	alice := testutil.Login(t, router, "/login", "alice", "password1")
+	bob := testutil.Login(t, router, "/login", "bob", "adminpass")
+
+	if _, status := dial(t, srv.URL, "nope"); status != http.StatusUnauthorized {
+		t.Fatalf("dial with a bad token: status %d, want 401", status)
+	}
+	user, _ := dial(t, srv.URL, alice)
+	admin, _ := dial(t, srv.URL, bob)
+
+	publish := func(topic string, err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("publish %s: %v", topic, err)
+		}
+	}
+	// only admins hear of registrations, and only the uploader of an upload
+	publish("user", events.Publish(t.Context(), events.Default, events.UserRegistered,
+		events.UserRegisteredEvent{Username: "carol", Email: "carol@example.com"}))
+	publish("file", events.Publish(t.Context(), events.Default, events.FileUploaded,
+		events.FileUploadedEvent{ID: "f1", Name: "notes.txt", Uploader: "alice"}))
+	publish("book", events.Publish(t.Context(), events.Default, events.BookUpdated,
+		events.BookUpdatedEvent{BookID: "1", Action: "updated"}))
+
+	// topics are delivered independently, so in no particular order
+	received := func(ws *websocket.Conn) []string {
+		got := []string{receive(t, ws).Event, receive(t, ws).Event}
+		slices.Sort(got)
+		return got
+	}
+	if got := received(user); !slices.Equal(got, []string{"book.updated", "file.uploaded"}) {
+		t.Errorf("alice got %v, want book.updated and file.uploaded", got)
+	}
+	if got := received(admin); !slices.Equal(got, []string{"book.updated", "user.registered"}) {
+		t.Errorf("bob got %v, want book.updated and user.registered", got)
+	}
+
+	// alice has one connection open already
+	for range maxConnsPerUser - 1 {
+		dial(t, srv.URL, alice)
+	}
+	if _, status := dial(t, srv.URL, alice); status != http.StatusTooManyRequests {
+		t.Errorf("dial past the limit: status %d, want 429", status)
+	}
+}
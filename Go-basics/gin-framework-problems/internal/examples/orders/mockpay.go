@@ -0,0 +1,214 @@
+package orders
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/apperror"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/httpclient"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/server"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/webhooks"
+)
+
+// Payment statuses, on the provider and mirrored on the order.
+const (
+	paymentRequiresConfirmation = "requires_confirmation"
+	paymentSucceeded            = "succeeded"
+	paymentFailed               = "failed"
+)
+
+// event is what the provider POSTs to the callback URL, signed like an
+// outgoing webhook. Redeliveries carry the same ID.
+type event struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"` // payment.succeeded or payment.failed
+	PaymentID string    `json:"payment_id"`
+	OrderID   string    `json:"order_id"`
+	Amount    int64     `json:"amount"`
+	Currency  string    `json:"currency"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type providerPayment struct {
+	ID          string    `json:"id"`
+	OrderID     string    `json:"order_id"`
+	Amount      int64     `json:"amount"`
+	Currency    string    `json:"currency"`
+	Status      string    `json:"status"`
+	CreatedAt   time.Time `json:"created_at"`
+	callbackURL string
+}
+
+// provider stands in for an external payment provider. The customer
+// confirms or declines a payment on its side, and it reports the outcome to
+// the merchant's callback URL.
+type provider struct {
+	secret string
+	client *http.Client
+	// callbacks in flight, for shutdown to wait for
+	background server.Background
+
+	mu       sync.Mutex
+	payments map[string]*providerPayment
+	events   map[string]event
+}
+
+func newProvider(secret string) *provider {
+	return &provider{
+		secret:   secret,
+		client:   httpclient.New("mockpay", httpclient.WithTimeout(10*time.Second)),
+		payments: map[string]*providerPayment{},
+		events:   map[string]event{},
+	}
+}
+
+// createPayment is the merchant's API call starting a payment.
+func (p *provider) createPayment(orderID string, amount int64, currency, callbackURL string) providerPayment {
+	pay := &providerPayment{
+		ID:          "pay_" + strings.ToLower(rand.Text()[:16]),
+		OrderID:     orderID,
+		Amount:      amount,
+		Currency:    currency,
+		Status:      paymentRequiresConfirmation,
+		CreatedAt:   time.Now().UTC(),
+		callbackURL: callbackURL,
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.payments[pay.ID] = pay
+	return *pay
+}
+
+// getPayment is GET /mockpay/payments/:id.
+func (p *provider) getPayment(c *gin.Context) {
+	p.mu.Lock()
+	pay, ok := p.payments[c.Param("id")]
+	var out providerPayment
+	if ok {
+		out = *pay
+	}
+	p.mu.Unlock()
+	if !ok {
+		middleware.Fail(c, apperror.NotFound("payment not found"))
+		return
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+// confirm is the customer finishing, or abandoning, the payment on the
+// provider's page. The callback goes out in the background.
+func (p *provider) confirm(c *gin.Context) {
+	var req struct {
+		Outcome string `json:"outcome" binding:"required,oneof=succeeded failed"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.BindError(c, err)
+		return
+	}
+
+	p.mu.Lock()
+	pay, ok := p.payments[c.Param("id")]
+	if !ok || pay.Status != paymentRequiresConfirmation {
+		p.mu.Unlock()
+		if !ok {
+			middleware.Fail(c, apperror.NotFound("payment not found"))
+		} else {
+			middleware.Fail(c, apperror.Conflict("payment is not awaiting confirmation"))
+		}
+		return
+	}
+	pay.Status = req.Outcome
+	ev := event{
+		ID:        "evt_" + strings.ToLower(rand.Text()[:16]),
+		Type:      "payment." + req.Outcome,
+		PaymentID: pay.ID,
+		OrderID:   pay.OrderID,
+		Amount:    pay.Amount,
+		Currency:  pay.Currency,
+		CreatedAt: time.Now().UTC(),
+	}
+	p.events[ev.ID] = ev
+	url := pay.callbackURL
+	p.mu.Unlock()
+
+	ctx := context.WithoutCancel(c.Request.Context())
+	p.background.Go(func() { p.deliver(ctx, url, ev) })
+	c.JSON(http.StatusAccepted, gin.H{"event_id": ev.ID, "status": req.Outcome})
+}
+
+// resend delivers an event again, as real providers do when they aren't
+// sure the first delivery arrived.
+func (p *provider) resend(c *gin.Context) {
+	p.mu.Lock()
+	ev, ok := p.events[c.Param("id")]
+	var url string
+	if ok {
+		url = p.payments[ev.PaymentID].callbackURL
+	}
+	p.mu.Unlock()
+	if !ok {
+		middleware.Fail(c, apperror.NotFound("event not found"))
+		return
+	}
+	ctx := context.WithoutCancel(c.Request.Context())
+	p.background.Go(func() { p.deliver(ctx, url, ev) })
+	c.JSON(http.StatusAccepted, gin.H{"event_id": ev.ID})
+}
+
+// deliver signs ev and POSTs it to url. The Idempotency-Key lets the client
+// retry it on a 5xx.
+func (p *provider) deliver(ctx context.Context, url string, ev event) {
+	if err := p.send(ctx, url, ev); err != nil {
+		slog.ErrorContext(ctx, "payment callback failed", "event_id", ev.ID, "order_id", ev.OrderID, "error", err)
+		return
+	}
+	slog.InfoContext(ctx, "payment callback delivered", "event_id", ev.ID, "order_id", ev.OrderID, "type", ev.Type)
+}
+
+func (p *provider) send(ctx context.Context, url string, ev event) error {
+	body, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", ev.ID)
+	req.Header.Set("X-Webhook-ID", ev.ID)
+	req.Header.Set("X-Webhook-Event", ev.Type)
+	req.Header.Set("X-Webhook-Timestamp", strconv.FormatInt(now.Unix(), 10))
+	req.Header.Set("X-Webhook-Signature", webhooks.Sign(p.secret, now, body))
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("callback answered %s", resp.Status)
+	}
+	return nil
+}
+
+// routes mounts the provider's own API, which in real life would live on
+// another host.
+func (p *provider) routes(rg *gin.RouterGroup) {
+	rg.GET("/payments/:id", p.getPayment)
+	rg.POST("/payments/:id/confirm", p.confirm)
+	rg.POST("/events/:id/resend", p.resend)
+}
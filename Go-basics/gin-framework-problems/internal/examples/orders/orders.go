@@ -0,0 +1,394 @@
+// Package orders walks an order through its lifecycle: it is created
+// pending, becomes paid when the payment provider calls back, and is
+// shipped by an admin; until it is paid its owner can cancel it. Any other
+// move is refused with 409.
+//
+// The provider is a mock served from the same process under /mockpay. It
+// signs its callbacks like internal/webhooks does and may deliver one more
+// than once, so POST /payments/callback checks the signature and applies
+// each event ID only once.
+//
+//	curl -X POST localhost:8080/login -d '{"username":"alice","password":"password1"}'
+//	curl -X POST localhost:8080/orders -H 'Authorization: Bearer <token>' \
+//	  -d '{"items":[{"sku":"go-book","quantity":2}]}'
+//	curl -X POST localhost:8080/orders/<id>/pay -H 'Authorization: Bearer <token>'
+//	curl -X POST localhost:8080/mockpay/payments/<payment id>/confirm -d '{"outcome":"succeeded"}'
+//	curl localhost:8080/orders/<id> -H 'Authorization: Bearer <token>'
+//	curl -X POST localhost:8080/orders/<id>/ship -H 'Authorization: Bearer <bob token>' \
+//	  -d '{"tracking_number":"1Z999AA10123456784"}'
+//
+// Users are the auth example's; bob is the admin.
+package orders
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/apperror"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/auth"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/idempotency"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/pagination"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/rbac"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/server"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/webhooks"
+)
+
+const (
+	currency = "usd"
+	// callbacks signed longer ago than this are refused as replays
+	callbackTolerance = 5 * time.Minute
+	// how long handled event IDs are remembered
+	eventRetention = 72 * time.Hour
+)
+
+type product struct {
+	SKU   string `json:"sku"`
+	Name  string `json:"name"`
+	Price int64  `json:"price"` // cents
+}
+
+// catalog is what can be ordered. Prices come from here, never from the
+// client.
+var catalog = []product{
+	{SKU: "go-book", Name: "The Go Programming Language", Price: 3999},
+	{SKU: "gin-course", Name: "Gin Web Services Course", Price: 4900},
+	{SKU: "gopher-sticker", Name: "Gopher Sticker", Price: 299},
+}
+
+type createRequest struct {
+	Items []struct {
+		SKU      string `json:"sku" binding:"required"`
+		Quantity int    `json:"quantity" binding:"required,min=1,max=99"`
+	} `json:"items" binding:"required,min=1,max=20,dive"`
+}
+
+type handlers struct {
+	store       *store
+	provider    *provider
+	secret      string
+	callbackURL string
+}
+
+func listProducts(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"data": catalog})
+}
+
+// create prices the items from the catalog. Repeated SKUs are merged.
+func (h *handlers) create(c *gin.Context) {
+	var req createRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.BindError(c, err)
+		return
+	}
+	now := time.Now().UTC()
+	o := Order{
+		ID:        "ord_" + strings.ToLower(rand.Text()[:16]),
+		Owner:     principal(c).Username,
+		Currency:  currency,
+		Status:    StatusPending,
+		History:   []Transition{},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	for _, it := range req.Items {
+		i := slices.IndexFunc(catalog, func(p product) bool { return p.SKU == it.SKU })
+		if i < 0 {
+			middleware.Fail(c, apperror.Validation("unknown product",
+				map[string]string{"items": "unknown sku " + it.SKU}))
+			return
+		}
+		if j := slices.IndexFunc(o.Items, func(x Item) bool { return x.SKU == it.SKU }); j >= 0 {
+			o.Items[j].Quantity += it.Quantity
+		} else {
+			o.Items = append(o.Items, Item{SKU: it.SKU, Name: catalog[i].Name, Quantity: it.Quantity, UnitPrice: catalog[i].Price})
+		}
+		o.Total += int64(it.Quantity) * catalog[i].Price
+	}
+	h.store.create(o)
+	c.Header("Location", "/orders/"+o.ID)
+	c.JSON(http.StatusCreated, o)
+}
+
+func (h *handlers) list(c *gin.Context) {
+	p, err := pagination.ParseParams(c)
+	if err != nil {
+		middleware.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	status := Status(c.Query("status"))
+	switch status {
+	case "", StatusPending, StatusPaid, StatusShipped, StatusCancelled:
+	default:
+		middleware.Fail(c, apperror.Validation("status must be pending, paid, shipped or cancelled",
+			map[string]string{"status": "must be pending, paid, shipped or cancelled"}))
+		return
+	}
+	owner := principal(c).Username
+	if principal(c).Can(rbac.OrdersRead) {
+		owner = c.Query("owner") // everyone's unless narrowed
+	}
+	pagination.Write(c, pagination.NewPage(h.store.list(owner, status), p))
+}
+
+func (h *handlers) get(c *gin.Context) {
+	o, ok := h.owned(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, o)
+}
+
+// pay starts a payment with the provider for a pending order. While one is
+// awaiting confirmation it is returned again instead of starting another.
+func (h *handlers) pay(c *gin.Context) {
+	o, ok := h.owned(c)
+	if !ok {
+		return
+	}
+	if o.Status != StatusPending {
+		middleware.Fail(c, transitionConflict(&transitionError{from: o.Status, to: StatusPaid}))
+		return
+	}
+	if o.Payment != nil && o.Payment.Status == paymentRequiresConfirmation {
+		c.JSON(http.StatusOK, paymentResponse(o))
+		return
+	}
+
+	pay := h.provider.createPayment(o.ID, o.Total, o.Currency, h.callback(c))
+	o, err := h.store.update(o.ID, func(o *Order) error {
+		if o.Status != StatusPending {
+			return &transitionError{from: o.Status, to: StatusPaid}
+		}
+		o.Payment = &Payment{ID: pay.ID, Status: pay.Status}
+		o.UpdatedAt = time.Now().UTC()
+		return nil
+	})
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, paymentResponse(o))
+}
+
+func paymentResponse(o Order) gin.H {
+	return gin.H{
+		"order_id":    o.ID,
+		"payment":     o.Payment,
+		"amount":      o.Total,
+		"currency":    o.Currency,
+		"confirm_url": "/mockpay/payments/" + o.Payment.ID + "/confirm",
+	}
+}
+
+func (h *handlers) cancel(c *gin.Context) {
+	o, ok := h.owned(c)
+	if !ok {
+		return
+	}
+	o, err := h.store.update(o.ID, func(o *Order) error {
+		return o.transition(StatusCancelled, "cancelled by "+principal(c).Username, time.Now().UTC())
+	})
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, o)
+}
+
+// ship needs orders:ship, which admins hold, and a paid order.
+func (h *handlers) ship(c *gin.Context) {
+	var req struct {
+		TrackingNumber string `json:"tracking_number" binding:"required,max=64"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.BindError(c, err)
+		return
+	}
+	o, err := h.store.update(c.Param("id"), func(o *Order) error {
+		if err := o.transition(StatusShipped, "tracking "+req.TrackingNumber, time.Now().UTC()); err != nil {
+			return err
+		}
+		o.TrackingNumber = req.TrackingNumber
+		return nil
+	})
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, o)
+}
+
+// ignoredEvent is a genuine provider event that doesn't apply to the order
+// as it stands. It is acknowledged, since redelivering it won't help.
+type ignoredEvent string
+
+func (e ignoredEvent) Error() string { return string(e) }
+
+// paymentCallback receives the provider's signed events. Anything but a
+// 2xx makes the provider send the event again, so only a bad signature or
+// body, or an unknown order, is refused.
+func (h *handlers) paymentCallback(c *gin.Context) {
+	body, err := c.GetRawData()
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	err = webhooks.Verify(h.secret, c.GetHeader("X-Webhook-Timestamp"), c.GetHeader("X-Webhook-Signature"),
+		body, callbackTolerance)
+	if err != nil {
+		middleware.Fail(c, apperror.Unauthorized("invalid signature"))
+		return
+	}
+	var ev event
+	if err := json.Unmarshal(body, &ev); err != nil || ev.ID == "" || ev.PaymentID == "" {
+		middleware.Fail(c, apperror.BadRequest("invalid event"))
+		return
+	}
+
+	ctx := c.Request.Context()
+	now := time.Now().UTC()
+	o, err := h.store.applyEvent(ev.ID, ev.OrderID, now, func(o *Order) error {
+		return applyPayment(o, ev, now)
+	})
+	var ignored ignoredEvent
+	switch {
+	case errors.Is(err, errDuplicate):
+		c.JSON(http.StatusOK, gin.H{"status": "duplicate"})
+	case errors.As(err, &ignored):
+		slog.WarnContext(ctx, "payment event ignored", "event_id", ev.ID, "order_id", ev.OrderID, "reason", ignored)
+		c.JSON(http.StatusOK, gin.H{"status": "ignored", "reason": string(ignored)})
+	case err != nil:
+		h.fail(c, err)
+	default:
+		slog.InfoContext(ctx, "payment event applied", "event_id", ev.ID, "order_id", o.ID, "order_status", o.Status)
+		c.JSON(http.StatusOK, gin.H{"status": "processed", "order_status": o.Status})
+	}
+}
+
+// applyPayment records the outcome of the order's current payment. A
+// failed payment leaves the order pending, so it can be paid again.
+func applyPayment(o *Order, ev event, now time.Time) error {
+	if o.Payment == nil || o.Payment.ID != ev.PaymentID {
+		return ignoredEvent("not the order's current payment")
+	}
+	switch ev.Type {
+	case "payment.failed":
+		if o.Status != StatusPending {
+			return ignoredEvent("order is " + string(o.Status))
+		}
+		o.Payment = &Payment{ID: ev.PaymentID, Status: paymentFailed}
+		o.UpdatedAt = now
+		return nil
+	case "payment.succeeded":
+		if ev.Amount != o.Total || ev.Currency != o.Currency {
+			return ignoredEvent("amount doesn't match the order")
+		}
+		if err := o.transition(StatusPaid, "payment "+ev.PaymentID, now); err != nil {
+			// e.g. cancelled while the customer was paying: a refund is due
+			return ignoredEvent(err.Error())
+		}
+		o.Payment = &Payment{ID: ev.PaymentID, Status: paymentSucceeded}
+		return nil
+	}
+	return ignoredEvent("unknown event type " + ev.Type)
+}
+
+// owned loads the :id order for its owner or anyone with orders:read. Others
+// get a 404, so IDs can't be probed for existence.
+func (h *handlers) owned(c *gin.Context) (Order, bool) {
+	o, err := h.store.get(c.Param("id"))
+	if err == nil && o.Owner != principal(c).Username && !principal(c).Can(rbac.OrdersRead) {
+		err = errNotFound
+	}
+	if err != nil {
+		h.fail(c, err)
+		return Order{}, false
+	}
+	return o, true
+}
+
+func (h *handlers) fail(c *gin.Context, err error) {
+	var te *transitionError
+	switch {
+	case errors.Is(err, errNotFound):
+		middleware.Fail(c, apperror.NotFound("order not found"))
+	case errors.As(err, &te):
+		middleware.Fail(c, transitionConflict(te))
+	default:
+		middleware.Fail(c, err)
+	}
+}
+
+func transitionConflict(te *transitionError) *apperror.Error {
+	return apperror.Conflict("order can't move to that status").WithDetails(gin.H{
+		"status":    te.from,
+		"requested": te.to,
+		"allowed":   append([]Status{}, transitions[te.from]...),
+	})
+}
+
+// callback is where the provider should send events for payments started
+// by this request.
+func (h *handlers) callback(c *gin.Context) string {
+	if h.callbackURL != "" {
+		return h.callbackURL
+	}
+	scheme := "http"
+	if middleware.IsSecure(c) {
+		scheme = "https"
+	}
+	return scheme + "://" + c.Request.Host + "/payments/callback"
+}
+
+// principal is the signed-in user, the zero UserInfo for anonymous
+// requests.
+func principal(c *gin.Context) auth.UserInfo {
+	u, _ := auth.CurrentUser(c)
+	return u
+}
+
+// NewRouter builds the orders example router.
+func NewRouter(deps server.Deps) *gin.Engine {
+	cfg, hooks := deps.Config, deps.Hooks
+	authn := auth.NewSessions(deps)
+	h := &handlers{
+		store:       newStore(),
+		provider:    newProvider(cfg.Payments.WebhookSecret),
+		secret:      cfg.Payments.WebhookSecret,
+		callbackURL: cfg.Payments.CallbackURL,
+	}
+	deps.Tasks().Register("payment_events", time.Hour, func(context.Context) error {
+		h.store.forgetEvents(time.Now().UTC().Add(-eventRetention))
+		return nil
+	})
+
+	router := server.NewEngine(deps)
+	hooks.Add(h.provider.background.Wait)
+	router.POST("/login", authn.Login)
+	router.GET("/products", listProducts)
+	router.POST("/payments/callback", h.paymentCallback)
+	h.provider.routes(router.Group("/mockpay"))
+
+	idem := idempotency.Middleware(idempotency.WithTTL(cfg.Idempotency.TTL))
+	private := router.Group("/orders")
+	private.Use(middleware.Auth(authn.LookupToken))
+	{
+		private.POST("", idem, h.create)
+		private.GET("", h.list)
+		private.GET("/:id", h.get)
+		private.POST("/:id/pay", h.pay)
+		private.POST("/:id/cancel", h.cancel)
+		private.POST("/:id/ship", middleware.RequirePermission(rbac.OrdersShip), h.ship)
+	}
+	return router
+}
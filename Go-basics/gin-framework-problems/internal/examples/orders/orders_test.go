@@ -0,0 +1,270 @@
+package orders
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/testutil"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/webhooks"
+)
+
+func TestTransition(t *testing.T) {
+	tests := []struct {
+		from, to Status
+		ok       bool
+	}{
+		{StatusPending, StatusPaid, true},
+		{StatusPending, StatusCancelled, true},
+		{StatusPending, StatusShipped, false},
+		{StatusPaid, StatusShipped, true},
+		{StatusPaid, StatusCancelled, false},
+		{StatusShipped, StatusCancelled, false},
+		{StatusCancelled, StatusPaid, false},
+	}
+	for _, tt := range tests {
+		o := Order{Status: tt.from}
+		err := o.transition(tt.to, "", time.Now())
+		if (err == nil) != tt.ok {
+			t.Errorf("%s -> %s: %v, want ok %v", tt.from, tt.to, err, tt.ok)
+		}
+		if want := map[bool]Status{true: tt.to, false: tt.from}[tt.ok]; o.Status != want {
+			t.Errorf("%s -> %s left the order %s, want %s", tt.from, tt.to, o.Status, want)
+		}
+		if tt.ok && len(o.History) != 1 {
+			t.Errorf("%s -> %s recorded %d steps, want 1", tt.from, tt.to, len(o.History))
+		}
+	}
+}
+
+// newTestServer serves the example on a live listener, which the mock
+// provider calls back, and returns it with alice's and bob's tokens.
+func newTestServer(t *testing.T) (router http.Handler, alice, bob string) {
+	var h http.Handler
+	srv := testutil.Server(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { h.ServeHTTP(w, r) }))
+	cfg := testutil.Config(t)
+	cfg.Payments.CallbackURL = srv.URL + "/payments/callback"
+	h = testutil.Router(t, NewRouter, cfg)
+	return h, testutil.Login(t, h, "/login", "alice", "password1"), testutil.Login(t, h, "/login", "bob", "adminpass")
+}
+
+func createOrder(t *testing.T, h http.Handler, token string) Order {
+	t.Helper()
+	w := testutil.DoJSON(t, h, http.MethodPost, "/orders", gin.H{"items": []gin.H{{"sku": "gopher-sticker", "quantity": 1}}},
+		testutil.WithToken(token))
+	testutil.AssertStatus(t, w, http.StatusCreated)
+	return testutil.Decode[Order](t, w)
+}
+
+func TestCreateOrder(t *testing.T) {
+	router, alice, _ := newTestServer(t)
+	item := func(sku string, quantity int) gin.H { return gin.H{"sku": sku, "quantity": quantity} }
+	tests := []struct {
+		name   string
+		items  []gin.H
+		status int
+		total  int64
+	}{
+		{"one", []gin.H{item("go-book", 2)}, http.StatusCreated, 7998},
+		{"repeated skus merge", []gin.H{item("gopher-sticker", 1), item("go-book", 1), item("gopher-sticker", 2)}, http.StatusCreated, 3999 + 3*299},
+		{"unknown sku", []gin.H{item("nope", 1)}, http.StatusBadRequest, 0},
+		{"no quantity", []gin.H{item("go-book", 0)}, http.StatusBadRequest, 0},
+		{"too many", []gin.H{item("go-book", 100)}, http.StatusBadRequest, 0},
+		{"no items", []gin.H{}, http.StatusBadRequest, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := testutil.DoJSON(t, router, http.MethodPost, "/orders", gin.H{"items": tt.items}, testutil.WithToken(alice))
+			testutil.AssertStatus(t, w, tt.status)
+			if tt.status != http.StatusCreated {
+				return
+			}
+			o := testutil.Decode[Order](t, w)
+			if o.Total != tt.total || o.Status != StatusPending || o.Owner != "alice" {
+				t.Errorf("order = %+v, want alice's pending order of %d", o, tt.total)
+			}
+		})
+	}
+	testutil.AssertStatus(t, testutil.DoJSON(t, router, http.MethodPost, "/orders", gin.H{"items": []gin.H{item("go-book", 1)}}),
+		http.StatusUnauthorized)
+}
+
+// waitFor polls the order until it has status.
+func waitFor(t *testing.T, h http.Handler, token, id string, status Status) Order {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		o := testutil.Decode[Order](t, testutil.Do(t, h, http.MethodGet, "/orders/"+id, nil, testutil.WithToken(token)))
+		if o.Status == status {
+			return o
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("order %s still %s, want %s", id, o.Status, status)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestOrderLifecycle(t *testing.T) {
+	router, alice, bob := newTestServer(t)
+	o := createOrder(t, router, alice)
+	path := "/orders/" + o.ID
+
+	w := testutil.Do(t, router, http.MethodPost, path+"/pay", nil, testutil.WithToken(alice))
+	testutil.AssertStatus(t, w, http.StatusCreated)
+	payment := testutil.Decode[struct {
+		Payment    Payment
+		ConfirmURL string `json:"confirm_url"`
+	}](t, w)
+	// paying again returns the payment awaiting confirmation
+	w = testutil.Do(t, router, http.MethodPost, path+"/pay", nil, testutil.WithToken(alice))
+	testutil.AssertStatus(t, w, http.StatusOK)
+	if got := testutil.Decode[struct{ Payment Payment }](t, w).Payment.ID; got != payment.Payment.ID {
+		t.Errorf("second pay started payment %s, want %s again", got, payment.Payment.ID)
+	}
+
+	w = testutil.DoJSON(t, router, http.MethodPost, payment.ConfirmURL, gin.H{"outcome": "succeeded"})
+	testutil.AssertStatus(t, w, http.StatusAccepted)
+	o = waitFor(t, router, alice, o.ID, StatusPaid)
+	if o.Payment.Status != paymentSucceeded {
+		t.Errorf("payment %s, want succeeded", o.Payment.Status)
+	}
+
+	tests := []struct {
+		name   string
+		token  string
+		path   string
+		body   any
+		status int
+	}{
+		{"cancel a paid order", alice, "/cancel", nil, http.StatusConflict},
+		{"ship without orders:ship", alice, "/ship", gin.H{"tracking_number": "1Z"}, http.StatusForbidden},
+		{"ship without tracking", bob, "/ship", gin.H{}, http.StatusBadRequest},
+		{"ship", bob, "/ship", gin.H{"tracking_number": "1Z999AA10123456784"}, http.StatusOK},
+		{"ship again", bob, "/ship", gin.H{"tracking_number": "1Z"}, http.StatusConflict},
+		{"pay a shipped order", alice, "/pay", nil, http.StatusConflict},
+	}
+	for _, tt := range tests {
+		w := testutil.DoJSON(t, router, http.MethodPost, path+tt.path, tt.body, testutil.WithToken(tt.token))
+		if w.Code != tt.status {
+			t.Fatalf("%s: status = %d, want %d; body: %s", tt.name, w.Code, tt.status, w.Body)
+		}
+	}
+	o = testutil.Decode[Order](t, testutil.Do(t, router, http.MethodGet, path, nil, testutil.WithToken(alice)))
+	var steps []Status
+	for _, tr := range o.History {
+		steps = append(steps, tr.To)
+	}
+	if len(steps) != 2 || steps[0] != StatusPaid || steps[1] != StatusShipped {
+		t.Errorf("history = %v, want paid then shipped", steps)
+	}
+}
+
+func TestFailedPayment(t *testing.T) {
+	router, alice, _ := newTestServer(t)
+	o := createOrder(t, router, alice)
+	path := "/orders/" + o.ID
+
+	w := testutil.Do(t, router, http.MethodPost, path+"/pay", nil, testutil.WithToken(alice))
+	confirm := testutil.Decode[struct {
+		ConfirmURL string `json:"confirm_url"`
+	}](t, w).ConfirmURL
+	testutil.AssertStatus(t, testutil.DoJSON(t, router, http.MethodPost, confirm, gin.H{"outcome": "failed"}), http.StatusAccepted)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		o = testutil.Decode[Order](t, testutil.Do(t, router, http.MethodGet, path, nil, testutil.WithToken(alice)))
+		if o.Payment.Status == paymentFailed {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("payment still %s", o.Payment.Status)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	// still pending, so it can be cancelled
+	if o.Status != StatusPending {
+		t.Errorf("order %s after a failed payment, want pending", o.Status)
+	}
+	testutil.AssertStatus(t, testutil.Do(t, router, http.MethodPost, path+"/cancel", nil, testutil.WithToken(alice)), http.StatusOK)
+	testutil.AssertStatus(t, testutil.Do(t, router, http.MethodPost, path+"/pay", nil, testutil.WithToken(alice)), http.StatusConflict)
+}
+
+func TestOrderVisibility(t *testing.T) {
+	router, alice, bob := newTestServer(t)
+	mine, theirs := createOrder(t, router, alice), createOrder(t, router, bob)
+
+	tests := []struct {
+		name   string
+		token  string
+		id     string
+		status int
+	}{
+		{"own order", alice, mine.ID, http.StatusOK},
+		{"someone else's", alice, theirs.ID, http.StatusNotFound},
+		{"with orders:read", bob, mine.ID, http.StatusOK},
+		{"missing", bob, "ord_missing", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testutil.AssertStatus(t, testutil.Do(t, router, http.MethodGet, "/orders/"+tt.id, nil, testutil.WithToken(tt.token)), tt.status)
+		})
+	}
+
+	w := testutil.Do(t, router, http.MethodGet, "/orders?status=bogus", nil, testutil.WithToken(alice))
+	testutil.AssertStatus(t, w, http.StatusBadRequest)
+}
+
+func TestPaymentCallback(t *testing.T) {
+	cfg := testutil.Config(t)
+	router := testutil.Router(t, NewRouter, cfg)
+	alice := testutil.Login(t, router, "/login", "alice", "password1")
+	o := createOrder(t, router, alice)
+	w := testutil.Do(t, router, http.MethodPost, "/orders/"+o.ID+"/pay", nil, testutil.WithToken(alice))
+	testutil.AssertStatus(t, w, http.StatusCreated)
+	pay := testutil.Decode[struct{ Payment Payment }](t, w).Payment.ID
+
+	paid := func(id string) event {
+		return event{ID: id, Type: "payment.succeeded", PaymentID: pay, OrderID: o.ID, Amount: o.Total, Currency: o.Currency}
+	}
+	wrongAmount := paid("evt_amount")
+	wrongAmount.Amount++
+	tests := []struct {
+		name   string
+		ev     event
+		secret string
+		status int
+		result string
+	}{
+		{"wrong secret", paid("evt_1"), "guess", http.StatusUnauthorized, ""},
+		{"no event id", paid(""), cfg.Payments.WebhookSecret, http.StatusBadRequest, ""},
+		{"unknown order", event{ID: "evt_2", PaymentID: pay, OrderID: "ord_missing"}, cfg.Payments.WebhookSecret, http.StatusNotFound, ""},
+		{"wrong amount", wrongAmount, cfg.Payments.WebhookSecret, http.StatusOK, "ignored"},
+		{"paid", paid("evt_1"), cfg.Payments.WebhookSecret, http.StatusOK, "processed"},
+		// providers deliver more than once
+		{"redelivered", paid("evt_1"), cfg.Payments.WebhookSecret, http.StatusOK, "duplicate"},
+		{"paid twice", paid("evt_3"), cfg.Payments.WebhookSecret, http.StatusOK, "ignored"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, _ := json.Marshal(tt.ev)
+			now := time.Now()
+			w := testutil.Do(t, router, http.MethodPost, "/payments/callback", bytes.NewReader(body),
+				testutil.WithHeader("X-Webhook-Timestamp", strconv.FormatInt(now.Unix(), 10)),
+				testutil.WithHeader("X-Webhook-Signature", webhooks.Sign(tt.secret, now, body)))
+			testutil.AssertStatus(t, w, tt.status)
+			if got := testutil.Decode[struct{ Status string }](t, w).Status; tt.result != "" && got != tt.result {
+				t.Errorf("callback %s, want %s", got, tt.result)
+			}
+		})
+	}
+	o = testutil.Decode[Order](t, testutil.Do(t, router, http.MethodGet, "/orders/"+o.ID, nil, testutil.WithToken(alice)))
+	if o.Status != StatusPaid || len(o.History) != 1 {
+		t.Errorf("order %s with %d steps, want paid once", o.Status, len(o.History))
+	}
+}
@@ -0,0 +1,182 @@
+package orders
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"time"
+)
+
+var (
+	errNotFound  = errors.New("order not found")
+	errDuplicate = errors.New("event already handled")
+)
+
+// Status is where an order is in its lifecycle.
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusPaid      Status = "paid"
+	StatusShipped   Status = "shipped"
+	StatusCancelled Status = "cancelled"
+)
+
+// transitions lists the statuses each one may move to. Shipped and
+// cancelled orders are final.
+var transitions = map[Status][]Status{
+	StatusPending: {StatusPaid, StatusCancelled},
+	StatusPaid:    {StatusShipped},
+}
+
+// transitionError is a move the state machine doesn't allow.
+type transitionError struct {
+	from, to Status
+}
+
+func (e *transitionError) Error() string {
+	return "order can't move from " + string(e.from) + " to " + string(e.to)
+}
+
+type Item struct {
+	SKU       string `json:"sku"`
+	Name      string `json:"name"`
+	Quantity  int    `json:"quantity"`
+	UnitPrice int64  `json:"unit_price"` // cents
+}
+
+// Payment is the provider payment an order is waiting on, or was paid with.
+type Payment struct {
+	ID     string `json:"id"`
+	Status string `json:"status"` // requires_confirmation, succeeded or failed
+}
+
+// Transition is one step in an order's history.
+type Transition struct {
+	From Status    `json:"from"`
+	To   Status    `json:"to"`
+	At   time.Time `json:"at"`
+	Note string    `json:"note,omitempty"`
+}
+
+type Order struct {
+	ID             string       `json:"id"`
+	Owner          string       `json:"owner"`
+	Items          []Item       `json:"items"`
+	Total          int64        `json:"total"` // cents
+	Currency       string       `json:"currency"`
+	Status         Status       `json:"status"`
+	Payment        *Payment     `json:"payment,omitempty"`
+	TrackingNumber string       `json:"tracking_number,omitempty"`
+	History        []Transition `json:"history"`
+	CreatedAt      time.Time    `json:"created_at"`
+	UpdatedAt      time.Time    `json:"updated_at"`
+}
+
+// transition moves o to status to and records it, or returns a
+// *transitionError leaving o as it was.
+func (o *Order) transition(to Status, note string, now time.Time) error {
+	if !slices.Contains(transitions[o.Status], to) {
+		return &transitionError{from: o.Status, to: to}
+	}
+	o.History = append(o.History, Transition{From: o.Status, To: to, At: now, Note: note})
+	o.Status = to
+	o.UpdatedAt = now
+	return nil
+}
+
+// store keeps orders, and the provider events already applied to them, in
+// memory.
+type store struct {
+	mu     sync.Mutex
+	orders map[string]Order
+	seen   map[string]time.Time // provider event ID -> when it was handled
+}
+
+func newStore() *store {
+	return &store{orders: map[string]Order{}, seen: map[string]time.Time{}}
+}
+
+func (s *store) create(o Order) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.orders[o.ID] = o
+}
+
+func (s *store) get(id string) (Order, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	o, ok := s.orders[id]
+	if !ok {
+		return Order{}, errNotFound
+	}
+	return o, nil
+}
+
+// list returns owner's orders, or everyone's for an empty owner, newest
+// first.
+func (s *store) list(owner string, status Status) []Order {
+	s.mu.Lock()
+	out := make([]Order, 0, len(s.orders))
+	for _, o := range s.orders {
+		if (owner == "" || o.Owner == owner) && (status == "" || o.Status == status) {
+			out = append(out, o)
+		}
+	}
+	s.mu.Unlock()
+
+	slices.SortFunc(out, func(a, b Order) int { return b.CreatedAt.Compare(a.CreatedAt) })
+	return out
+}
+
+// update runs fn on a copy of order id and saves it unless fn fails, so a
+// rejected change leaves nothing half done.
+func (s *store) update(id string, fn func(*Order) error) (Order, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.updateLocked(id, fn)
+}
+
+func (s *store) updateLocked(id string, fn func(*Order) error) (Order, error) {
+	o, ok := s.orders[id]
+	if !ok {
+		return Order{}, errNotFound
+	}
+	o.History = slices.Clip(o.History) // appends mustn't reach the saved order
+	if err := fn(&o); err != nil {
+		return Order{}, err
+	}
+	s.orders[id] = o
+	return o, nil
+}
+
+// applyEvent is update for provider event eventID, done at most once: an
+// event seen before fails with errDuplicate. One fn rejects is still
+// remembered, since sending it again won't change the answer.
+func (s *store) applyEvent(eventID, orderID string, now time.Time, fn func(*Order) error) (Order, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.seen[eventID]; ok {
+		return Order{}, errDuplicate
+	}
+	o, err := s.updateLocked(orderID, fn)
+	if !errors.Is(err, errNotFound) {
+		s.seen[eventID] = now
+	}
+	return o, err
+}
+
+// forgetEvents drops events handled before cutoff and returns how many. A
+// late redelivery of one is still refused by the state machine.
+func (s *store) forgetEvents(cutoff time.Time) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for id, at := range s.seen {
+		if at.Before(cutoff) {
+			delete(s.seen, id)
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,97 @@
+package ratelimit
+
+import (
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/auth"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/ipfilter"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/server"
+)
+
+// Deps are what NewRouter builds the example from. Nil limiters are made
+// from Config's rate_limit settings and read Clock.
+type Deps struct {
+	server.Deps
+	// issues and checks the tokens /me takes; auth.NewSessions' when nil
+	Auth *auth.Server
+	// limits anonymous routes per IP
+	Limiter *middleware.RateLimiter
+	// limits /me per user
+	UserLimiter *middleware.RateLimiter
+}
+
+// NewRouter builds the rate limiting example router. Anonymous routes are
+// limited per IP and /me, behind a login, per user, each group with a
+// limiter of its own. Routes in rate_limit.costs use more of the budget.
+func NewRouter(deps Deps) *gin.Engine {
+	cfg, hooks := deps.Config, deps.Hooks
+	authn := deps.Auth
+	if authn == nil {
+		authn = auth.NewSessions(deps.Deps)
+	}
+	router := server.NewEngine(deps.Deps)
+
+	opts := []middleware.RateLimiterOption{
+		middleware.WithAlgorithm(middleware.Algorithm(cfg.RateLimit.Algorithm)),
+		middleware.WithIPFilter(ipfilter.Default),
+		middleware.WithCosts(cfg.RateLimit.Costs),
+		middleware.WithOnLimitExceeded(server.LogLimitExceeded),
+	}
+	if deps.Clock != nil {
+		opts = append(opts, middleware.WithClock(deps.Clock))
+	}
+	// each limiter appends its own to a copy
+	opts = slices.Clip(opts)
+	limiter, userLimiter := deps.Limiter, deps.UserLimiter
+	if limiter == nil {
+		limiter = middleware.NewRateLimiter(cfg.RateLimit.RequestsPerMinute, append(opts,
+			middleware.WithName("ratelimit:ip"), middleware.WithBurst(cfg.RateLimit.Burst))...)
+	}
+	if userLimiter == nil {
+		userLimiter = middleware.NewRateLimiter(cfg.RateLimit.UserRequestsPerMinute, append(opts,
+			middleware.WithName("ratelimit:user"), middleware.WithKey(middleware.ByUser))...)
+	}
+	// the limiters' state is this instance's alone, so each cleans up its
+	// own rather than through the scheduler, which may run on one instance
+	for _, rl := range []*middleware.RateLimiter{limiter, userLimiter} {
+		rl.StartJanitor(5 * time.Minute)
+		hooks.Add(rl.Stop)
+	}
+
+	public := router.Group("/", limiter.Middleware())
+	public.POST("/login", authn.Login)
+	public.GET("/", func(c *gin.Context) {
+		c.JSON(200, gin.H{"message": "ok"})
+	})
+	// costs what rate_limit.costs says, 5 with the example config
+	public.GET("/search", func(c *gin.Context) {
+		c.JSON(200, gin.H{"query": c.Query("q"), "results": []string{}})
+	})
+	// the caller's budget; this request already counts against it
+	public.GET("/status", status(limiter))
+
+	me := router.Group("/me", middleware.Auth(authn.LookupToken), userLimiter.Middleware())
+	me.GET("", status(userLimiter))
+
+	return router
+}
+
+// status answers with what is left of the caller's budget with limiter.
+func status(limiter *middleware.RateLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key, now := limiter.Key(c), limiter.Now()
+		c.JSON(200, gin.H{
+			"key":            key,
+			"limit":          limiter.Limit(),
+			"remaining":      limiter.Remaining(key, now),
+			"reset_seconds":  int(limiter.Reset(key, now).Seconds()),
+			"window_seconds": int(limiter.Window().Seconds()),
+			"algorithm":      limiter.Algorithm(),
+			"clients":        limiter.Clients(),
+		})
+	}
+}
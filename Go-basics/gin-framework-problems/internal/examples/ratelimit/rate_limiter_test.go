@@ -0,0 +1,218 @@
+package ratelimit
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/config"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/auth"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/testutil"
+)
+
+// newRouter builds the example with sessions of its own and limiters that
+// read the clock it returns.
+func newRouter(t *testing.T, cfg *config.Config) (*gin.Engine, *testutil.Clock) {
+	t.Helper()
+	clock := testutil.NewClock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	deps := testutil.Deps(t, cfg)
+	deps.Clock = clock
+	return NewRouter(Deps{Deps: deps, Auth: auth.NewSessions(deps)}), clock
+}
+
+// sendAllAs is sendAll with token.
+func sendAllAs(t *testing.T, h http.Handler, path, token string, n int) map[int]int {
+	var mu sync.Mutex
+	codes := map[int]int{}
+	var wg sync.WaitGroup
+	for range n {
+		wg.Go(func() {
+			w := testutil.Do(t, h, http.MethodGet, path, nil, testutil.WithToken(token))
+			mu.Lock()
+			codes[w.Code]++
+			mu.Unlock()
+		})
+	}
+	wg.Wait()
+	return codes
+}
+
+// sendAll sends n requests for path at once and counts the statuses.
+func sendAll(t *testing.T, h http.Handler, path string, n int) map[int]int {
+	var mu sync.Mutex
+	codes := map[int]int{}
+	var wg sync.WaitGroup
+	for range n {
+		wg.Go(func() {
+			w := testutil.Do(t, h, http.MethodGet, path, nil)
+			mu.Lock()
+			codes[w.Code]++
+			mu.Unlock()
+		})
+	}
+	wg.Wait()
+	return codes
+}
+
+func TestConcurrentRequests(t *testing.T) {
+	cfg := testutil.Config(t)
+	cfg.RateLimit.RequestsPerMinute = 20
+	router, _ := newRouter(t, cfg)
+
+	codes := sendAll(t, router, "/", 100)
+	if codes[http.StatusOK] != 20 || codes[http.StatusTooManyRequests] != 80 {
+		t.Errorf("statuses = %v, want 20 200s and 80 429s", codes)
+	}
+	w := testutil.Do(t, router, http.MethodGet, "/", nil)
+	testutil.AssertStatus(t, w, http.StatusTooManyRequests)
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("429 without Retry-After")
+	}
+}
+
+func TestUserLimit(t *testing.T) {
+	cfg := testutil.Config(t)
+	cfg.RateLimit.RequestsPerMinute = 2
+	cfg.RateLimit.UserRequestsPerMinute = 3
+	router, _ := newRouter(t, cfg)
+	alice := testutil.Login(t, router, "/login", "alice", "password1")
+
+	testutil.AssertStatus(t, testutil.Do(t, router, http.MethodGet, "/me", nil), http.StatusUnauthorized)
+	// /me has a budget of its own, apart from the per-IP one login used
+	for range 3 {
+		testutil.AssertStatus(t, testutil.Do(t, router, http.MethodGet, "/me", nil, testutil.WithToken(alice)), http.StatusOK)
+	}
+	testutil.AssertStatus(t, testutil.Do(t, router, http.MethodGet, "/me", nil, testutil.WithToken(alice)), http.StatusTooManyRequests)
+
+	bob := testutil.Login(t, router, "/login", "bob", "adminpass")
+	w := testutil.Do(t, router, http.MethodGet, "/me", nil, testutil.WithToken(bob))
+	testutil.AssertStatus(t, w, http.StatusOK)
+	got := testutil.Decode[map[string]any](t, w)
+	if got["key"] != "user:bob" || got["remaining"] != float64(2) {
+		t.Errorf("status = %v, want bob's own budget", got)
+	}
+}
+
+func TestSearchCost(t *testing.T) {
+	cfg := testutil.Config(t)
+	cfg.RateLimit.RequestsPerMinute = 10
+	cfg.RateLimit.Costs = map[string]int{"GET /search": 5}
+	router, _ := newRouter(t, cfg)
+
+	for _, want := range []string{"5", "0"} {
+		w := testutil.Do(t, router, http.MethodGet, "/search?q=go", nil)
+		testutil.AssertStatus(t, w, http.StatusOK)
+		if got := w.Header().Get("X-RateLimit-Remaining"); got != want {
+			t.Errorf("X-RateLimit-Remaining = %s, want %s", got, want)
+		}
+	}
+	testutil.AssertStatus(t, testutil.Do(t, router, http.MethodGet, "/", nil), http.StatusTooManyRequests)
+}
+
+// TestConcurrentWindows checks that each algorithm lets exactly its budget
+// through a burst of concurrent requests, and all of it again once the
+// window has passed.
+func TestConcurrentWindows(t *testing.T) {
+	for _, algorithm := range []middleware.Algorithm{middleware.SlidingLog, middleware.TokenBucket, middleware.SlidingWindowCounter} {
+		t.Run(string(algorithm), func(t *testing.T) {
+			cfg := testutil.Config(t)
+			cfg.RateLimit.Algorithm = string(algorithm)
+			cfg.RateLimit.RequestsPerMinute = 15
+			router, clock := newRouter(t, cfg)
+
+			for round := range 3 {
+				codes := sendAll(t, router, "/", 60)
+				if codes[http.StatusOK] != 15 || codes[http.StatusTooManyRequests] != 45 {
+					t.Errorf("round %d: statuses = %v, want 15 200s and 45 429s", round, codes)
+				}
+				clock.Advance(2 * time.Minute)
+			}
+		})
+	}
+}
+
+// TestConcurrentUsers checks that users sending at once each get their own
+// budget, and that one user's burst doesn't spend another's.
+func TestConcurrentUsers(t *testing.T) {
+	cfg := testutil.Config(t)
+	cfg.RateLimit.RequestsPerMinute = 100
+	cfg.RateLimit.UserRequestsPerMinute = 10
+	router, _ := newRouter(t, cfg)
+	tokens := map[string]string{
+		"alice": testutil.Login(t, router, "/login", "alice", "password1"),
+		"bob":   testutil.Login(t, router, "/login", "bob", "adminpass"),
+	}
+
+	var mu sync.Mutex
+	got := map[string]map[int]int{}
+	var wg sync.WaitGroup
+	for name, token := range tokens {
+		wg.Go(func() {
+			codes := sendAllAs(t, router, "/me", token, 40)
+			mu.Lock()
+			got[name] = codes
+			mu.Unlock()
+		})
+	}
+	wg.Wait()
+	for name, codes := range got {
+		if codes[http.StatusOK] != 10 || codes[http.StatusTooManyRequests] != 30 {
+			t.Errorf("%s: statuses = %v, want 10 200s and 30 429s", name, codes)
+		}
+	}
+}
+
+// TestLimiterDeps checks that NewRouter serves the limiters it is given.
+func TestLimiterDeps(t *testing.T) {
+	clock := testutil.NewClock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	limiter := middleware.NewRateLimiter(3, middleware.WithName("test:ip"), middleware.WithClock(clock))
+	router := NewRouter(Deps{Deps: testutil.Deps(t, nil), Limiter: limiter})
+
+	codes := sendAll(t, router, "/", 10)
+	if codes[http.StatusOK] != 3 || codes[http.StatusTooManyRequests] != 7 {
+		t.Errorf("statuses = %v, want 3 200s and 7 429s", codes)
+	}
+	clock.Advance(time.Minute + time.Second)
+	w := testutil.Do(t, router, http.MethodGet, "/status", nil)
+	testutil.AssertStatus(t, w, http.StatusOK)
+	got := testutil.Decode[map[string]any](t, w)
+	if got["limit"] != float64(3) || got["remaining"] != float64(2) {
+		t.Errorf("status = %v, want the given limiter's budget, less this request", got)
+	}
+}
+
+func TestSpoofedForwardedFor(t *testing.T) {
+	cfg := testutil.Config(t)
+	cfg.RateLimit.RequestsPerMinute = 2
+	cfg.RateLimit.Allow = []string{"203.0.113.7"}
+	router, _ := newRouter(t, cfg)
+
+	// httptest requests come from 192.0.2.1, which is no trusted proxy:
+	// naming an allowlisted address or a new one each time doesn't help
+	for i, xff := range []string{"203.0.113.7", "198.51.100.1", "198.51.100.2"} {
+		w := testutil.Do(t, router, http.MethodGet, "/", nil, testutil.WithHeader("X-Forwarded-For", xff))
+		want := http.StatusOK
+		if i == 2 {
+			want = http.StatusTooManyRequests
+		}
+		testutil.AssertStatus(t, w, want)
+	}
+
+	// from a trusted proxy the header names the client
+	cfg = testutil.Config(t)
+	cfg.RateLimit.RequestsPerMinute = 2
+	cfg.RateLimit.Allow = []string{"203.0.113.7"}
+	cfg.Server.TrustedProxies = []string{"192.0.2.0/24"}
+	router, _ = newRouter(t, cfg)
+	for range 3 {
+		w := testutil.Do(t, router, http.MethodGet, "/", nil, testutil.WithHeader("X-Forwarded-For", "203.0.113.7"))
+		testutil.AssertStatus(t, w, http.StatusOK)
+	}
+	if codes := sendAll(t, router, "/", 3); codes[http.StatusTooManyRequests] != 1 {
+		t.Errorf("statuses from the proxy itself = %v, want one 429", codes)
+	}
+}
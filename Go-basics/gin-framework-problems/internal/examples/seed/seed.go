@@ -0,0 +1,166 @@
+// Package seed fills the users, books and files examples with fixtures, so
+// pagination, search, sorting and rate limiting have something to work on:
+// 48 users, 320 books in 10 categories and a handful of uploads, embedded
+// from fixtures/.
+//
+//	go run ./cmd/hub serve -seed web
+//
+// Loading is idempotent: users whose username is taken are skipped, and
+// books and files are only added while their store has none, so restarting
+// with -seed against a SQLite store adds nothing twice. The fixtures share
+// one password, seed.password, and are meant for development only.
+package seed
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/config"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/books"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/files"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/users"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/passhash"
+)
+
+//go:embed fixtures
+var fixtures embed.FS
+
+// Stores are the example servers Load fills. The nil ones are left alone.
+type Stores struct {
+	Users *users.Server
+	Books *books.Server
+	Files *files.Server
+}
+
+// loader adds one kind of fixture and returns how many it added.
+type loader struct {
+	name string
+	load func() (int, error)
+}
+
+// Load adds the fixtures to each of stores' servers. Build the servers
+// before, and serve the same ones after.
+func Load(ctx context.Context, cfg *config.Config, stores Stores) error {
+	var loaders []loader
+	if stores.Users != nil {
+		loaders = append(loaders, loader{"users", func() (int, error) { return loadUsers(ctx, cfg, stores.Users) }})
+	}
+	if stores.Books != nil {
+		loaders = append(loaders, loader{"books", func() (int, error) { return loadBooks(ctx, stores.Books) }})
+	}
+	if stores.Files != nil {
+		loaders = append(loaders, loader{"files", func() (int, error) { return loadFiles(ctx, stores.Files) }})
+	}
+	for _, l := range loaders {
+		n, err := l.load()
+		if err != nil {
+			return fmt.Errorf("seed %s: %w", l.name, err)
+		}
+		slog.InfoContext(ctx, "seeded fixtures", "kind", l.name, "added", n)
+	}
+	return nil
+}
+
+type userFixture struct {
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	Role     string `json:"role"`
+	Verified bool   `json:"verified"`
+	Disabled bool   `json:"disabled"`
+}
+
+func loadUsers(ctx context.Context, cfg *config.Config, accounts *users.Server) (int, error) {
+	var list []userFixture
+	if err := readJSON("fixtures/users.json", &list); err != nil {
+		return 0, err
+	}
+	// one hash for everyone: hashing each password would take seconds
+	hash, err := passhash.Hash(cfg.Seed.Password)
+	if err != nil {
+		return 0, err
+	}
+	added := 0
+	for _, f := range list {
+		ok, err := accounts.SeedUser(ctx, users.User{
+			Username: f.Username,
+			Email:    f.Email,
+			Role:     f.Role,
+			Password: hash,
+			Verified: f.Verified,
+			Disabled: f.Disabled,
+		})
+		if err != nil {
+			return added, err
+		}
+		if ok {
+			added++
+		}
+	}
+	return added, nil
+}
+
+type bookFixtures struct {
+	Categories []books.Category `json:"categories"`
+	Books      []books.Book     `json:"books"`
+}
+
+func loadBooks(ctx context.Context, catalog *books.Server) (int, error) {
+	existing, err := catalog.List(ctx)
+	if err != nil || len(existing) > 0 {
+		return 0, err
+	}
+	var fx bookFixtures
+	if err := readJSON("fixtures/books.json", &fx); err != nil {
+		return 0, err
+	}
+	for _, cat := range fx.Categories {
+		if err := catalog.CreateCategory(ctx, cat); err != nil {
+			return 0, err
+		}
+	}
+	for i, b := range fx.Books {
+		if _, err := catalog.Create(ctx, b); err != nil {
+			return i, err
+		}
+	}
+	return len(fx.Books), nil
+}
+
+// loadFiles uploads fixtures/files, each as one of the seeded users.
+func loadFiles(ctx context.Context, uploads *files.Server) (int, error) {
+	existing, err := uploads.List(ctx)
+	if err != nil || len(existing) > 0 {
+		return 0, err
+	}
+	var uploaders []userFixture
+	if err := readJSON("fixtures/users.json", &uploaders); err != nil {
+		return 0, err
+	}
+	entries, err := fs.ReadDir(fixtures, "fixtures/files")
+	if err != nil {
+		return 0, err
+	}
+	for i, e := range entries {
+		content, err := fixtures.ReadFile(path.Join("fixtures/files", e.Name()))
+		if err != nil {
+			return i, err
+		}
+		if _, err := uploads.Add(ctx, e.Name(), uploaders[i%len(uploaders)].Username, content); err != nil {
+			return i, err
+		}
+	}
+	return len(entries), nil
+}
+
+func readJSON(name string, v any) error {
+	data, err := fixtures.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
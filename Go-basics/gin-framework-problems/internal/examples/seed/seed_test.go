@@ -0,0 +1,45 @@
+package seed
+
+import (
+	"testing"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/books"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/files"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/users"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/testutil"
+)
+
+func TestLoad(t *testing.T) {
+	cfg := testutil.Config(t)
+	deps := testutil.Deps(t, cfg)
+	stores := Stores{
+		Users: users.NewAccounts(deps),
+		Books: books.NewCatalog(deps),
+		Files: files.NewUploads(deps),
+	}
+
+	count := func() (int, int) {
+		t.Helper()
+		b, err := stores.Books.List(t.Context())
+		if err != nil {
+			t.Fatal(err)
+		}
+		f, err := stores.Files.List(t.Context())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(b), len(f)
+	}
+	for range 2 {
+		if err := Load(t.Context(), cfg, stores); err != nil {
+			t.Fatal(err)
+		}
+		if b, f := count(); b != 320 || f != 10 {
+			t.Errorf("after Load: %d books and %d files, want 320 and 10", b, f)
+		}
+	}
+	// taken by the fixtures
+	if added, err := stores.Users.SeedUser(t.Context(), users.User{Username: "asharma"}); err != nil || added {
+		t.Errorf("SeedUser(asharma) = %v, %v; want false, nil", added, err)
+	}
+}
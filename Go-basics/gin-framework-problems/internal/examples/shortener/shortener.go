@@ -0,0 +1,312 @@
+// Package shortener is a URL shortener: signed-in users shorten URLs, with
+// an optional custom alias and expiry, and anyone following the short link
+// is redirected while its hits are counted.
+//
+//	curl -X POST localhost:8080/login -d '{"username":"alice","password":"password1"}'
+//	curl -X POST localhost:8080/shorten -H 'Authorization: Bearer <token>' \
+//	  -d '{"url":"https://go.dev/doc/","alias":"godoc","expires_in":"72h"}'
+//	curl -i localhost:8080/godoc
+//	curl localhost:8080/links -H 'Authorization: Bearer <token>'
+//
+// Links live in memory, or in SQLite with shortener.store: sqlite. Users are
+// the auth example's; admins can see and delete anyone's links.
+package shortener
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/apperror"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/config"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/database"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/auth"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/healthcheck"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/pagination"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/rbac"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/server"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/validation"
+)
+
+const (
+	codeLength   = 7
+	codeAlphabet = "abcdefghijkmnopqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789" // no 0/O, 1/l/I
+)
+
+func init() {
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterValidation("alias", validAlias)
+	}
+	validation.RegisterMessage("alias", "{{.Field}} may only contain letters, digits, - and _")
+}
+
+func validAlias(fl validator.FieldLevel) bool {
+	return strings.Trim(fl.Field().String(),
+		"abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_") == ""
+}
+
+type shortenRequest struct {
+	URL       string `json:"url" binding:"required,http_url,max=2048"`
+	Alias     string `json:"alias" binding:"omitempty,min=3,max=32,alias"`
+	ExpiresIn string `json:"expires_in"` // a duration such as 24h; empty never expires
+}
+
+// linkResponse adds the full short URL to a link.
+type linkResponse struct {
+	Link
+	ShortURL string `json:"short_url"`
+}
+
+type handlers struct {
+	store   Store
+	baseURL string
+	now     func() time.Time
+	// reserved holds the first path segments of the other routes, which
+	// an alias would shadow
+	reserved map[string]bool
+}
+
+func (h *handlers) shorten(c *gin.Context) {
+	var req shortenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.BindError(c, err)
+		return
+	}
+	now := h.now().UTC()
+	l := Link{URL: req.URL, Owner: principal(c).Username, CreatedAt: now}
+	if req.ExpiresIn != "" {
+		d, err := time.ParseDuration(req.ExpiresIn)
+		if err != nil || d <= 0 {
+			middleware.Fail(c, apperror.Validation("expires_in must be a positive duration such as 24h",
+				map[string]string{"expires_in": "must be a positive duration such as 24h"}))
+			return
+		}
+		expires := now.Add(d)
+		l.ExpiresAt = &expires
+	}
+
+	ctx := c.Request.Context()
+	var err error
+	if req.Alias != "" {
+		if h.reserved[strings.ToLower(req.Alias)] {
+			middleware.Fail(c, apperror.Conflict("alias is reserved"))
+			return
+		}
+		l.Code = req.Alias
+		err = h.store.Create(ctx, l)
+	} else {
+		// a generated code can collide, however rarely; draw again
+		for range 5 {
+			l.Code = newCode()
+			if err = h.store.Create(ctx, l); !errors.Is(err, errCodeTaken) {
+				break
+			}
+		}
+	}
+	switch {
+	case errors.Is(err, errCodeTaken):
+		middleware.Fail(c, apperror.Conflict("alias already taken"))
+	case err != nil:
+		middleware.Fail(c, err)
+	default:
+		c.Header("Location", "/links/"+l.Code)
+		c.JSON(http.StatusCreated, h.response(c, l))
+	}
+}
+
+// redirect sends the client on to the link's URL. 302 rather than 301, so
+// browsers come back each time and every hit is counted.
+func (h *handlers) redirect(c *gin.Context) {
+	ctx := c.Request.Context()
+	code := c.Param("code")
+	l, err := h.store.Get(ctx, code)
+	switch {
+	case errors.Is(err, errNotFound):
+		middleware.Fail(c, apperror.NotFound("link not found"))
+		return
+	case err != nil:
+		middleware.Fail(c, err)
+		return
+	}
+	now := h.now().UTC()
+	if l.Expired(now) {
+		middleware.Fail(c, apperror.New(http.StatusGone, apperror.CodeGone, "link has expired"))
+		return
+	}
+	if err := h.store.Hit(ctx, code, now); err != nil && !errors.Is(err, errNotFound) {
+		middleware.Fail(c, err)
+		return
+	}
+	c.Header("Cache-Control", "private, max-age=0")
+	c.Redirect(http.StatusFound, l.URL)
+}
+
+func (h *handlers) listLinks(c *gin.Context) {
+	p, err := pagination.ParseParams(c)
+	if err != nil {
+		middleware.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	owner := principal(c).Username
+	if o := c.Query("owner"); o != "" && managesLinks(c) {
+		owner = o
+	}
+	links, err := h.store.ListByOwner(c.Request.Context(), owner)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	out := make([]linkResponse, len(links))
+	for i, l := range links {
+		out[i] = h.response(c, l)
+	}
+	pagination.Write(c, pagination.NewPage(out, p))
+}
+
+func (h *handlers) getLink(c *gin.Context) {
+	l, ok := h.owned(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, h.response(c, l))
+}
+
+func (h *handlers) deleteLink(c *gin.Context) {
+	l, ok := h.owned(c)
+	if !ok {
+		return
+	}
+	if err := h.store.Delete(c.Request.Context(), l.Code); err != nil && !errors.Is(err, errNotFound) {
+		middleware.Fail(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// owned loads the :code link for its owner or an admin. Anyone else gets a
+// 404, so codes can't be probed for existence.
+func (h *handlers) owned(c *gin.Context) (Link, bool) {
+	l, err := h.store.Get(c.Request.Context(), c.Param("code"))
+	switch {
+	case errors.Is(err, errNotFound) || (err == nil && l.Owner != principal(c).Username && !managesLinks(c)):
+		middleware.Fail(c, apperror.NotFound("link not found"))
+		return Link{}, false
+	case err != nil:
+		middleware.Fail(c, err)
+		return Link{}, false
+	}
+	return l, true
+}
+
+func (h *handlers) response(c *gin.Context, l Link) linkResponse {
+	base := h.baseURL
+	if base == "" {
+		scheme := "http"
+		if middleware.IsSecure(c) {
+			scheme = "https"
+		}
+		base = scheme + "://" + c.Request.Host
+	}
+	return linkResponse{Link: l, ShortURL: strings.TrimRight(base, "/") + "/" + l.Code}
+}
+
+// principal is the signed-in user, the zero UserInfo for anonymous
+// requests.
+func principal(c *gin.Context) auth.UserInfo {
+	u, _ := auth.CurrentUser(c)
+	return u
+}
+
+func managesLinks(c *gin.Context) bool {
+	return principal(c).Can(rbac.LinksManage)
+}
+
+func newCode() string {
+	b := make([]byte, codeLength)
+	rand.Read(b)
+	for i := range b {
+		b[i] = codeAlphabet[int(b[i])%len(codeAlphabet)]
+	}
+	return string(b)
+}
+
+// newStore returns the store shortener.store asks for. The SQLite one is
+// migrated here, since the example has nothing to show without its table.
+func newStore(cfg *config.Config, hooks *server.Hooks) Store {
+	if cfg.Shortener.Store != "sqlite" {
+		return newMemoryStore()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	db, err := database.Open(ctx, cfg.Database.Path)
+	if err != nil {
+		panic(err)
+	}
+	m, err := database.NewMigrator(db)
+	if err != nil {
+		panic(err)
+	}
+	if _, err := m.Up(ctx); err != nil {
+		panic(err)
+	}
+	hooks.Add(func(context.Context) error { return db.Close() })
+	healthcheck.Default.Register("database", db.PingContext)
+	return sqliteStore{db: db}
+}
+
+// Deps are what NewRouter builds the example from.
+type Deps struct {
+	server.Deps
+	// keeps the links; the one shortener.store asks for when nil
+	Store Store
+	// issues and checks the tokens the private routes take;
+	// auth.NewSessions' when nil
+	Auth *auth.Server
+}
+
+// NewRouter builds the URL shortener example router. Links expire by
+// deps' clock.
+func NewRouter(deps Deps) *gin.Engine {
+	cfg, hooks := deps.Config, deps.Hooks
+	authn := deps.Auth
+	if authn == nil {
+		authn = auth.NewSessions(deps.Deps)
+	}
+	store := deps.Store
+	if store == nil {
+		store = newStore(cfg, hooks)
+	}
+	h := &handlers{store: store, baseURL: cfg.Shortener.BaseURL, now: deps.Now}
+	deps.Tasks().Register("expired_links", 10*time.Minute, func(ctx context.Context) error {
+		_, err := h.store.DeleteExpired(ctx, h.now().UTC())
+		return err
+	})
+
+	router := server.NewEngine(deps.Deps)
+	router.POST("/login", authn.Login)
+	private := router.Group("")
+	private.Use(middleware.Auth(authn.LookupToken))
+	{
+		private.POST("/shorten", h.shorten)
+		private.GET("/links", h.listLinks)
+		private.GET("/links/:code", h.getLink)
+		private.DELETE("/links/:code", h.deleteLink)
+	}
+
+	h.reserved = map[string]bool{}
+	for _, r := range router.Routes() {
+		seg, _, _ := strings.Cut(strings.TrimPrefix(r.Path, "/"), "/")
+		h.reserved[strings.ToLower(seg)] = true
+	}
+	// gin matches the static routes above before this one
+	router.GET("/:code", h.redirect)
+	return router
+}
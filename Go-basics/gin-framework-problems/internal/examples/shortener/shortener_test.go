@@ -0,0 +1,228 @@
+package shortener
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/auth"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/server"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/testutil"
+)
+
+// stores returns one of each Store, the SQLite one in a temp file.
+func stores(t *testing.T) map[string]Store {
+	cfg := testutil.Config(t)
+	cfg.Shortener.Store = "sqlite"
+	var hooks server.Hooks
+	db := newStore(cfg, &hooks)
+	t.Cleanup(func() {
+		for _, stop := range hooks {
+			stop(context.Background())
+		}
+	})
+	return map[string]Store{"memory": newMemoryStore(), "sqlite": db}
+}
+
+func TestStore(t *testing.T) {
+	t0 := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	expires := t0.Add(time.Hour)
+	for name, s := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := t.Context()
+			for i, l := range []Link{
+				{Code: "first", URL: "https://go.dev", Owner: "alice", CreatedAt: t0},
+				{Code: "second", URL: "https://pkg.go.dev", Owner: "alice", CreatedAt: t0.Add(time.Minute), ExpiresAt: &expires},
+				{Code: "other", URL: "https://gin-gonic.com", Owner: "bob", CreatedAt: t0},
+			} {
+				if err := s.Create(ctx, l); err != nil {
+					t.Fatalf("Create %d: %v", i, err)
+				}
+			}
+			if err := s.Create(ctx, Link{Code: "first", URL: "https://example.com", Owner: "bob"}); !errors.Is(err, errCodeTaken) {
+				t.Errorf("Create with a taken code: %v, want errCodeTaken", err)
+			}
+
+			for range 2 {
+				if err := s.Hit(ctx, "first", t0.Add(time.Second)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			l, err := s.Get(ctx, "first")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if l.Hits != 2 || l.LastHitAt == nil || !l.LastHitAt.Equal(t0.Add(time.Second)) {
+				t.Errorf("after 2 hits: %d hits, last at %v", l.Hits, l.LastHitAt)
+			}
+
+			links, err := s.ListByOwner(ctx, "alice")
+			if err != nil {
+				t.Fatal(err)
+			}
+			var codes []string
+			for _, l := range links {
+				codes = append(codes, l.Code)
+			}
+			if want := []string{"second", "first"}; !slices.Equal(codes, want) {
+				t.Errorf("alice's links = %v, want %v", codes, want)
+			}
+
+			if n, err := s.DeleteExpired(ctx, expires.Add(-time.Second)); err != nil || n != 0 {
+				t.Errorf("DeleteExpired before the expiry = %d, %v; want 0", n, err)
+			}
+			// a link stops redirecting at its expiry, as Expired says
+			if n, err := s.DeleteExpired(ctx, expires); err != nil || n != 1 {
+				t.Errorf("DeleteExpired at the expiry = %d, %v; want 1", n, err)
+			}
+			if err := s.Delete(ctx, "other"); err != nil {
+				t.Fatal(err)
+			}
+			for _, code := range []string{"second", "other"} {
+				if _, err := s.Get(ctx, code); !errors.Is(err, errNotFound) {
+					t.Errorf("Get(%s) after deleting: %v, want errNotFound", code, err)
+				}
+			}
+			if err := s.Delete(ctx, "other"); !errors.Is(err, errNotFound) {
+				t.Errorf("second Delete: %v, want errNotFound", err)
+			}
+			if err := s.Hit(ctx, "other", t0); !errors.Is(err, errNotFound) {
+				t.Errorf("Hit of a deleted link: %v, want errNotFound", err)
+			}
+		})
+	}
+}
+
+// newRouter builds the example on a memory store and sessions of its own,
+// its links expiring by the clock it returns.
+func newRouter(t *testing.T) (*gin.Engine, Store, *testutil.Clock) {
+	t.Helper()
+	clock := testutil.NewClock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	deps := testutil.Deps(t, nil)
+	deps.Clock = clock
+	store := newMemoryStore()
+	return NewRouter(Deps{Deps: deps, Store: store, Auth: auth.NewSessions(deps)}), store, clock
+}
+
+func TestShorten(t *testing.T) {
+	router, _, _ := newRouter(t)
+	alice := testutil.Login(t, router, "/login", "alice", "password1")
+	shorten := func(body gin.H) *httptest.ResponseRecorder {
+		return testutil.DoJSON(t, router, http.MethodPost, "/shorten", body, testutil.WithToken(alice))
+	}
+	testutil.AssertStatus(t, shorten(gin.H{"url": "https://go.dev", "alias": "taken"}), http.StatusCreated)
+
+	tests := []struct {
+		name   string
+		body   gin.H
+		status int
+	}{
+		{"generated code", gin.H{"url": "https://go.dev/doc/"}, http.StatusCreated},
+		{"alias", gin.H{"url": "https://go.dev/doc/", "alias": "go_doc-1"}, http.StatusCreated},
+		{"expiring", gin.H{"url": "https://go.dev/doc/", "expires_in": "72h"}, http.StatusCreated},
+		{"alias taken", gin.H{"url": "https://go.dev/doc/", "alias": "taken"}, http.StatusConflict},
+		// it would shadow GET /links
+		{"alias reserved", gin.H{"url": "https://go.dev/doc/", "alias": "Links"}, http.StatusConflict},
+		{"alias too short", gin.H{"url": "https://go.dev/doc/", "alias": "go"}, http.StatusBadRequest},
+		{"alias with a slash", gin.H{"url": "https://go.dev/doc/", "alias": "go/doc"}, http.StatusBadRequest},
+		{"not a url", gin.H{"url": "go.dev"}, http.StatusBadRequest},
+		{"not http", gin.H{"url": "javascript:alert(1)"}, http.StatusBadRequest},
+		{"bad expiry", gin.H{"url": "https://go.dev/doc/", "expires_in": "-1h"}, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := shorten(tt.body)
+			testutil.AssertStatus(t, w, tt.status)
+			if tt.status != http.StatusCreated {
+				return
+			}
+			l := testutil.Decode[linkResponse](t, w)
+			if l.Owner != "alice" || l.ShortURL != "http://example.com/"+l.Code || (tt.body["alias"] != nil && l.Code != tt.body["alias"]) {
+				t.Errorf("link = %+v", l)
+			}
+		})
+	}
+	testutil.AssertStatus(t, testutil.DoJSON(t, router, http.MethodPost, "/shorten", gin.H{"url": "https://go.dev"}), http.StatusUnauthorized)
+}
+
+func TestRedirect(t *testing.T) {
+	router, store, clock := newRouter(t)
+	alice := testutil.Login(t, router, "/login", "alice", "password1")
+	for _, body := range []gin.H{
+		{"url": "https://go.dev/doc/", "alias": "godoc"},
+		{"url": "https://go.dev/blog/", "alias": "gone", "expires_in": "30m"},
+	} {
+		testutil.AssertStatus(t, testutil.DoJSON(t, router, http.MethodPost, "/shorten", body, testutil.WithToken(alice)), http.StatusCreated)
+	}
+
+	for range 3 {
+		w := testutil.Do(t, router, http.MethodGet, "/godoc", nil)
+		testutil.AssertStatus(t, w, http.StatusFound)
+		if got := w.Header().Get("Location"); got != "https://go.dev/doc/" {
+			t.Fatalf("Location = %q", got)
+		}
+	}
+	testutil.AssertStatus(t, testutil.Do(t, router, http.MethodGet, "/gone", nil), http.StatusFound)
+	clock.Advance(30 * time.Minute)
+	testutil.AssertStatus(t, testutil.Do(t, router, http.MethodGet, "/gone", nil), http.StatusGone)
+	testutil.AssertStatus(t, testutil.Do(t, router, http.MethodGet, "/missing", nil), http.StatusNotFound)
+
+	w := testutil.Do(t, router, http.MethodGet, "/links/godoc", nil, testutil.WithToken(alice))
+	testutil.AssertStatus(t, w, http.StatusOK)
+	if hits := testutil.Decode[Link](t, w).Hits; hits != 3 {
+		t.Errorf("hits = %d, want 3", hits)
+	}
+	// an expired link's hit isn't counted
+	l, err := store.Get(t.Context(), "gone")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.Hits != 1 || !l.LastHitAt.Equal(clock.Now().Add(-30*time.Minute)) {
+		t.Errorf("gone: hits = %d, last hit %v; want 1, half an hour ago", l.Hits, l.LastHitAt)
+	}
+}
+
+func TestLinkAccess(t *testing.T) {
+	router, _, _ := newRouter(t)
+	alice := testutil.Login(t, router, "/login", "alice", "password1")
+	bob := testutil.Login(t, router, "/login", "bob", "adminpass")
+	for alias, token := range map[string]string{"alices": alice, "bobs": bob} {
+		w := testutil.DoJSON(t, router, http.MethodPost, "/shorten", gin.H{"url": "https://go.dev/", "alias": alias}, testutil.WithToken(token))
+		testutil.AssertStatus(t, w, http.StatusCreated)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		token  string
+		status int
+	}{
+		// someone else's link doesn't exist, as far as alice knows
+		{"get someone else's", http.MethodGet, "/links/bobs", alice, http.StatusNotFound},
+		{"delete someone else's", http.MethodDelete, "/links/bobs", alice, http.StatusNotFound},
+		{"admin gets anyone's", http.MethodGet, "/links/alices", bob, http.StatusOK},
+		{"delete own", http.MethodDelete, "/links/alices", alice, http.StatusNoContent},
+		{"get deleted", http.MethodGet, "/links/alices", alice, http.StatusNotFound},
+		{"anonymous", http.MethodGet, "/links/bobs", "", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testutil.AssertStatus(t, testutil.Do(t, router, tt.method, tt.path, nil, testutil.WithToken(tt.token)), tt.status)
+		})
+	}
+
+	w := testutil.Do(t, router, http.MethodGet, "/links?owner=bob", nil, testutil.WithToken(alice))
+	testutil.AssertStatus(t, w, http.StatusOK)
+	if got := len(testutil.Decode[struct{ Items []Link }](t, w).Items); got != 0 {
+		t.Errorf("alice listing bob's links got %d, want only her own, none", got)
+	}
+	w = testutil.Do(t, router, http.MethodGet, "/links?owner=alice", nil, testutil.WithToken(bob))
+	testutil.AssertStatus(t, w, http.StatusOK)
+}
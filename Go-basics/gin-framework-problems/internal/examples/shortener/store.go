@@ -0,0 +1,219 @@
+package shortener
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/database"
+)
+
+var (
+	errNotFound  = errors.New("link not found")
+	errCodeTaken = errors.New("code already taken")
+)
+
+// Link is a short code and where it redirects.
+type Link struct {
+	Code      string     `json:"code"`
+	URL       string     `json:"url"`
+	Owner     string     `json:"owner"`
+	Hits      int64      `json:"hits"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	LastHitAt *time.Time `json:"last_hit_at,omitempty"`
+}
+
+// Expired reports whether l stopped redirecting before now.
+func (l Link) Expired(now time.Time) bool {
+	return l.ExpiresAt != nil && !now.Before(*l.ExpiresAt)
+}
+
+// Store keeps links. Create fails with errCodeTaken for a code in use; Get,
+// Hit and Delete fail with errNotFound for one that isn't.
+type Store interface {
+	Create(ctx context.Context, l Link) error
+	Get(ctx context.Context, code string) (Link, error)
+	Hit(ctx context.Context, code string, at time.Time) error
+	// ListByOwner returns owner's links, newest first.
+	ListByOwner(ctx context.Context, owner string) ([]Link, error)
+	Delete(ctx context.Context, code string) error
+	// DeleteExpired removes links that expired before now.
+	DeleteExpired(ctx context.Context, now time.Time) (int, error)
+}
+
+type memoryStore struct {
+	mu    sync.RWMutex
+	links map[string]*Link
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{links: map[string]*Link{}}
+}
+
+func (s *memoryStore) Create(_ context.Context, l Link) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.links[l.Code]; ok {
+		return errCodeTaken
+	}
+	s.links[l.Code] = &l
+	return nil
+}
+
+func (s *memoryStore) Get(_ context.Context, code string) (Link, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	l, ok := s.links[code]
+	if !ok {
+		return Link{}, errNotFound
+	}
+	return *l, nil
+}
+
+func (s *memoryStore) Hit(_ context.Context, code string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	l, ok := s.links[code]
+	if !ok {
+		return errNotFound
+	}
+	l.Hits++
+	l.LastHitAt = &at
+	return nil
+}
+
+func (s *memoryStore) ListByOwner(_ context.Context, owner string) ([]Link, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	list := []Link{}
+	for _, l := range s.links {
+		if l.Owner == owner {
+			list = append(list, *l)
+		}
+	}
+	slices.SortFunc(list, func(a, b Link) int {
+		if c := b.CreatedAt.Compare(a.CreatedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(b.Code, a.Code)
+	})
+	return list, nil
+}
+
+func (s *memoryStore) Delete(_ context.Context, code string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.links[code]; !ok {
+		return errNotFound
+	}
+	delete(s.links, code)
+	return nil
+}
+
+func (s *memoryStore) DeleteExpired(_ context.Context, now time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for code, l := range s.links {
+		if l.Expired(now) {
+			delete(s.links, code)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// sqliteStore keeps links in the links table of database.path.
+type sqliteStore struct{ db *sql.DB }
+
+func (s sqliteStore) Create(ctx context.Context, l Link) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO links (code, url, owner, created_at, expires_at) VALUES (?, ?, ?, ?, ?)`,
+		l.Code, l.URL, l.Owner, l.CreatedAt, l.ExpiresAt)
+	if database.IsUniqueViolation(err) {
+		return errCodeTaken
+	}
+	return err
+}
+
+const linkColumns = `code, url, owner, hits, created_at, expires_at, last_hit_at`
+
+type scanner interface{ Scan(dest ...any) error }
+
+func scanLink(row scanner) (Link, error) {
+	var l Link
+	var expires, lastHit sql.NullTime
+	if err := row.Scan(&l.Code, &l.URL, &l.Owner, &l.Hits, &l.CreatedAt, &expires, &lastHit); err != nil {
+		return Link{}, err
+	}
+	if expires.Valid {
+		l.ExpiresAt = &expires.Time
+	}
+	if lastHit.Valid {
+		l.LastHitAt = &lastHit.Time
+	}
+	return l, nil
+}
+
+func (s sqliteStore) Get(ctx context.Context, code string) (Link, error) {
+	l, err := scanLink(s.db.QueryRowContext(ctx, `SELECT `+linkColumns+` FROM links WHERE code = ?`, code))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Link{}, errNotFound
+	}
+	return l, err
+}
+
+func (s sqliteStore) Hit(ctx context.Context, code string, at time.Time) error {
+	return s.exec(ctx, `UPDATE links SET hits = hits + 1, last_hit_at = ? WHERE code = ?`, at, code)
+}
+
+func (s sqliteStore) ListByOwner(ctx context.Context, owner string) ([]Link, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT `+linkColumns+` FROM links WHERE owner = ? ORDER BY created_at DESC, code DESC`, owner)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	list := []Link{}
+	for rows.Next() {
+		l, err := scanLink(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, l)
+	}
+	return list, rows.Err()
+}
+
+func (s sqliteStore) Delete(ctx context.Context, code string) error {
+	return s.exec(ctx, `DELETE FROM links WHERE code = ?`, code)
+}
+
+func (s sqliteStore) DeleteExpired(ctx context.Context, now time.Time) (int, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM links WHERE expires_at IS NOT NULL AND expires_at <= ?`, now)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+// exec runs a statement that targets one link, reporting errNotFound when
+// it matched none.
+func (s sqliteStore) exec(ctx context.Context, query string, args ...any) error {
+	res, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return errNotFound
+	}
+	return nil
+}
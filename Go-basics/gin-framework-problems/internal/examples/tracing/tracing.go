@@ -0,0 +1,104 @@
+// Package tracing is a small distributed tracing demo: /checkout does some
+// "store" work and calls /pricing over HTTP, and the trace context travels in
+// the traceparent header so both requests end up in the same trace.
+//
+// Start a collector (e.g. Jaeger with OTLP on :4318) and run:
+//
+//	HUB_TRACING=true OTEL_EXPORTER_OTLP_ENDPOINT=http://localhost:4318 go run ./cmd/tracing
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/httpclient"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/server"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/tracing"
+)
+
+// client carries both the trace context and the request ID to the callee.
+var client = httpclient.New("pricing", httpclient.WithTimeout(5*time.Second))
+
+// baseURL is where /checkout reaches /pricing; set from the config.
+var baseURL = "http://localhost:8080"
+
+// sleep stands in for slow work that gives up when ctx is done, as a real
+// database call would.
+func sleep(ctx context.Context, d time.Duration) error {
+	select {
+	case <-time.After(d):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func reserveStock(c *gin.Context, item string) error {
+	ctx, span := tracing.Start(c.Request.Context(), "inventory.reserve", attribute.String("item", item))
+	defer span.End()
+	return sleep(ctx, 20*time.Millisecond) // pretend to hit a database
+}
+
+func checkout(c *gin.Context) {
+	item := c.DefaultQuery("item", "book")
+	if err := reserveStock(c, item); err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+
+	req, err := http.NewRequestWithContext(c.Request.Context(), http.MethodGet, baseURL+"/pricing?item="+item, nil)
+	if err != nil {
+		middleware.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		if ctxErr := c.Request.Context().Err(); ctxErr != nil {
+			// our deadline, not the pricing service's fault
+			middleware.Fail(c, ctxErr)
+			return
+		}
+		middleware.Error(c, http.StatusBadGateway, "pricing unavailable")
+		return
+	}
+	defer resp.Body.Close()
+
+	var price gin.H
+	if err := json.NewDecoder(resp.Body).Decode(&price); err != nil {
+		middleware.Error(c, http.StatusBadGateway, "bad pricing response")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"item": item, "pricing": price})
+}
+
+func pricing(c *gin.Context) {
+	ctx, span := tracing.Start(c.Request.Context(), "pricing.lookup")
+	defer span.End()
+	if err := sleep(ctx, 10*time.Millisecond); err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"item": c.Query("item"), "price": 12.5})
+}
+
+// NewRouter builds the tracing example router.
+func NewRouter(deps server.Deps) *gin.Engine {
+	cfg := deps.Config
+	addr := cfg.Server.Addr
+	if strings.HasPrefix(addr, ":") {
+		addr = "localhost" + addr
+	}
+	baseURL = "http://" + addr
+
+	router := server.NewEngine(deps)
+	router.GET("/checkout", checkout)
+	router.GET("/pricing", pricing)
+	return router
+}
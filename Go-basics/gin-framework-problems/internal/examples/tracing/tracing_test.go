@@ -0,0 +1,106 @@
+package tracing
+
+import (
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/requestid"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/testutil"
+)
+
+// pricingCall is what /pricing was sent by /checkout.
+type pricingCall struct {
+	sync.Mutex
+	header http.Header
+}
+
+// newTestServer serves the router on a live server that /checkout reaches
+// /pricing through, recording the headers of each /pricing request.
+func newTestServer(t *testing.T) (*gin.Engine, *pricingCall) {
+	t.Helper()
+	var (
+		router *gin.Engine
+		call   pricingCall
+	)
+	srv := testutil.Server(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/pricing" {
+			call.Lock()
+			call.header = r.Header.Clone()
+			call.Unlock()
+		}
+		router.ServeHTTP(w, r)
+	}))
+	cfg := testutil.Config(t)
+	cfg.Server.Addr = srv.Listener.Addr().String()
+	router = testutil.Router(t, NewRouter, cfg)
+	return router, &call
+}
+
+func TestCheckout(t *testing.T) {
+	router, _ := newTestServer(t)
+	tests := []struct {
+		name string
+		path string
+		want gin.H
+	}{
+		{"item", "/checkout?item=pen", gin.H{"item": "pen", "pricing": gin.H{"item": "pen", "price": 12.5}}},
+		{"default item", "/checkout", gin.H{"item": "book", "pricing": gin.H{"item": "book", "price": 12.5}}},
+		{"pricing", "/pricing?item=pen", gin.H{"item": "pen", "price": 12.5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testutil.AssertJSON(t, testutil.Do(t, router, http.MethodGet, tt.path, nil), http.StatusOK, tt.want)
+		})
+	}
+}
+
+func TestPricingDown(t *testing.T) {
+	router, _ := newTestServer(t)
+	srv := testutil.Server(t, testutil.Engine())
+	srv.Close()
+	baseURL = srv.URL
+	testutil.AssertStatus(t, testutil.Do(t, router, http.MethodGet, "/checkout", nil), http.StatusBadGateway)
+}
+
+func TestPropagation(t *testing.T) {
+	rec := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+	prevTP, prevProp := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		tp.Shutdown(t.Context())
+		otel.SetTracerProvider(prevTP)
+		otel.SetTextMapPropagator(prevProp)
+	})
+
+	router, call := newTestServer(t)
+	w := testutil.Do(t, router, http.MethodGet, "/checkout?item=pen", nil, testutil.WithHeader(requestid.Header, "checkout-1"))
+	testutil.AssertStatus(t, w, http.StatusOK)
+
+	call.Lock()
+	defer call.Unlock()
+	if got := call.header.Get(requestid.Header); got != "checkout-1" {
+		t.Errorf("pricing got request ID %q, want checkout-1", got)
+	}
+	if call.header.Get("traceparent") == "" {
+		t.Error("pricing got no traceparent")
+	}
+	var names []string
+	for _, s := range rec.Ended() {
+		names = append(names, s.Name())
+	}
+	for _, want := range []string{"inventory.reserve", "pricing.lookup"} {
+		if !slices.Contains(names, want) {
+			t.Errorf("spans %v, want %s among them", names, want)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package transactions
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/database"
+)
+
+type Account struct {
+	ID        string    `json:"id"`
+	Username  string    `json:"username"`
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type AuditEntry struct {
+	ID        int64     `json:"id"`
+	Action    string    `json:"action"`
+	Subject   string    `json:"subject"`
+	Detail    string    `json:"detail,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// UserStore reads and writes the users table through whatever Querier it
+// was given: the database itself, or a transaction.
+type UserStore struct{ q database.Querier }
+
+// Create inserts the account with passwordHash, from passhash.Hash.
+func (s UserStore) Create(ctx context.Context, a Account, passwordHash string) error {
+	_, err := s.q.ExecContext(ctx,
+		`INSERT INTO users (id, username, email, password, role, created_at) VALUES (?, ?, ?, ?, ?, ?)`,
+		a.ID, a.Username, a.Email, passwordHash, a.Role, a.CreatedAt)
+	return err
+}
+
+// Delete removes the account and reports whether it existed.
+func (s UserStore) Delete(ctx context.Context, id string) (bool, error) {
+	res, err := s.q.ExecContext(ctx, `DELETE FROM users WHERE id = ?`, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (s UserStore) List(ctx context.Context) ([]Account, error) {
+	rows, err := s.q.QueryContext(ctx, `SELECT id, username, email, role, created_at FROM users ORDER BY created_at, id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	accounts := []Account{}
+	for rows.Next() {
+		var a Account
+		if err := rows.Scan(&a.ID, &a.Username, &a.Email, &a.Role, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, a)
+	}
+	return accounts, rows.Err()
+}
+
+// AuditStore appends to and reads the audit_log table.
+type AuditStore struct{ q database.Querier }
+
+func (s AuditStore) Record(ctx context.Context, action, subject, detail string) error {
+	_, err := s.q.ExecContext(ctx,
+		`INSERT INTO audit_log (action, subject, detail, created_at) VALUES (?, ?, ?, ?)`,
+		action, subject, detail, time.Now().UTC())
+	return err
+}
+
+// List returns the entries for subject, or all of them when it is empty,
+// newest first.
+func (s AuditStore) List(ctx context.Context, subject string) ([]AuditEntry, error) {
+	rows, err := s.q.QueryContext(ctx,
+		`SELECT id, action, subject, detail, created_at FROM audit_log
+		 WHERE ? = '' OR subject = ? ORDER BY id DESC`, subject, subject)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	entries := []AuditEntry{}
+	for rows.Next() {
+		var e AuditEntry
+		if err := rows.Scan(&e.ID, &e.Action, &e.Subject, &e.Detail, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// Stores is every store bound to the same Querier.
+type Stores struct {
+	Users UserStore
+	Audit AuditStore
+}
+
+func storesOn(q database.Querier) Stores {
+	return Stores{Users: UserStore{q}, Audit: AuditStore{q}}
+}
+
+// UnitOfWork groups store calls into one transaction: either all of their
+// writes land or none do.
+type UnitOfWork struct{ db *sql.DB }
+
+// Do runs fn with stores bound to a new transaction, committing when fn
+// returns nil and rolling back otherwise.
+func (u UnitOfWork) Do(ctx context.Context, fn func(Stores) error) error {
+	return database.WithTx(ctx, u.db, func(tx *sql.Tx) error {
+		return fn(storesOn(tx))
+	})
+}
@@ -0,0 +1,148 @@
+// Package transactions shows multi-statement SQLite transactions: creating or
+// deleting an account writes the users row and its audit_log entry in one
+// unit of work, so a failure in either leaves neither behind.
+package transactions
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/apperror"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/database"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/healthcheck"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/passhash"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/server"
+)
+
+// errSimulated is returned after the users insert when the client asks for
+// ?fail=audit, to show the insert being rolled back.
+var errSimulated = errors.New("simulated audit failure")
+
+type handlers struct {
+	db  *sql.DB
+	uow UnitOfWork
+}
+
+func (h handlers) createAccount(c *gin.Context) {
+	var req struct {
+		Username string `json:"username" binding:"required,min=3"`
+		Email    string `json:"email" binding:"required,email"`
+		Password string `json:"password" binding:"required,password"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.BindError(c, err)
+		return
+	}
+	hash, err := passhash.Hash(req.Password)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	a := Account{
+		ID:        newID(),
+		Username:  req.Username,
+		Email:     req.Email,
+		Role:      "user",
+		CreatedAt: time.Now().UTC(),
+	}
+	err = h.uow.Do(c.Request.Context(), func(s Stores) error {
+		if err := s.Users.Create(c.Request.Context(), a, hash); err != nil {
+			return err
+		}
+		if c.Query("fail") == "audit" {
+			return errSimulated
+		}
+		return s.Audit.Record(c.Request.Context(), "user.created", a.ID, a.Username)
+	})
+	switch {
+	case database.IsUniqueViolation(err):
+		middleware.Fail(c, apperror.Conflict("username already exists"))
+	case err != nil:
+		middleware.Fail(c, err)
+	default:
+		c.JSON(http.StatusCreated, a)
+	}
+}
+
+func (h handlers) deleteAccount(c *gin.Context) {
+	id := c.Param("id")
+	var found bool
+	err := h.uow.Do(c.Request.Context(), func(s Stores) error {
+		var err error
+		if found, err = s.Users.Delete(c.Request.Context(), id); err != nil || !found {
+			return err
+		}
+		return s.Audit.Record(c.Request.Context(), "user.deleted", id, "")
+	})
+	switch {
+	case err != nil:
+		middleware.Fail(c, err)
+	case !found:
+		middleware.Fail(c, apperror.NotFound("user not found"))
+	default:
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// reads don't need a transaction, so they use stores over the database
+func (h handlers) listAccounts(c *gin.Context) {
+	accounts, err := storesOn(h.db).Users.List(c.Request.Context())
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, accounts)
+}
+
+func (h handlers) listAudit(c *gin.Context) {
+	entries, err := storesOn(h.db).Audit.List(c.Request.Context(), c.Query("subject"))
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, entries)
+}
+
+func newID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// NewRouter builds the transactions example router. It opens the database at
+// database.path and applies the migrations itself, since it has nothing to
+// show without the schema.
+func NewRouter(deps server.Deps) *gin.Engine {
+	cfg, hooks := deps.Config, deps.Hooks
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	db, err := database.Open(ctx, cfg.Database.Path)
+	if err != nil {
+		panic(err)
+	}
+	m, err := database.NewMigrator(db)
+	if err != nil {
+		panic(err)
+	}
+	if _, err := m.Up(ctx); err != nil {
+		panic(err)
+	}
+	hooks.Add(func(context.Context) error { return db.Close() })
+	healthcheck.Default.Register("database", db.PingContext)
+
+	router := server.NewEngine(deps)
+	h := handlers{db: db, uow: UnitOfWork{db: db}}
+	router.GET("/accounts", h.listAccounts)
+	router.POST("/accounts", h.createAccount)
+	router.DELETE("/accounts/:id", h.deleteAccount)
+	router.GET("/audit", h.listAudit)
+	return router
+}
@@ -0,0 +1,124 @@
+package transactions
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/database"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/testutil"
+)
+
+// openDB opens a migrated SQLite database in a temp file.
+func openDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := database.Open(t.Context(), filepath.Join(t.TempDir(), "hub.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	m, err := database.NewMigrator(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestUnitOfWork(t *testing.T) {
+	errAudit := errors.New("audit failed")
+	tests := []struct {
+		name     string
+		fail     error // returned after both writes
+		accounts int   // left behind
+		entries  int
+	}{
+		{"commit", nil, 1, 1},
+		{"rollback", errAudit, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := openDB(t)
+			ctx := t.Context()
+			a := Account{ID: newID(), Username: "dana", Email: "dana@example.com", Role: "user", CreatedAt: time.Now().UTC()}
+			err := UnitOfWork{db: db}.Do(ctx, func(s Stores) error {
+				if err := s.Users.Create(ctx, a, "hash"); err != nil {
+					return err
+				}
+				if err := s.Audit.Record(ctx, "user.created", a.ID, a.Username); err != nil {
+					return err
+				}
+				return tt.fail
+			})
+			if !errors.Is(err, tt.fail) {
+				t.Fatalf("Do = %v, want %v", err, tt.fail)
+			}
+
+			s := storesOn(db)
+			accounts, err := s.Users.List(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			entries, err := s.Audit.List(ctx, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(accounts) != tt.accounts || len(entries) != tt.entries {
+				t.Errorf("%d accounts and %d audit entries left, want %d and %d", len(accounts), len(entries), tt.accounts, tt.entries)
+			}
+		})
+	}
+}
+
+func TestAccounts(t *testing.T) {
+	router := testutil.Router(t, NewRouter, nil)
+	body := gin.H{"username": "erin", "email": "erin@example.com", "password": "password123"}
+	count := func(path string) int {
+		w := testutil.Do(t, router, http.MethodGet, path, nil)
+		testutil.AssertStatus(t, w, http.StatusOK)
+		return len(testutil.Decode[[]any](t, w))
+	}
+
+	// the users row was written before the audit failed, and went with it
+	testutil.AssertStatus(t, testutil.DoJSON(t, router, http.MethodPost, "/accounts?fail=audit", body), http.StatusInternalServerError)
+	if n, m := count("/accounts"), count("/audit"); n != 0 || m != 0 {
+		t.Fatalf("after a failed audit: %d accounts, %d audit entries; want none", n, m)
+	}
+
+	w := testutil.DoJSON(t, router, http.MethodPost, "/accounts", body)
+	testutil.AssertStatus(t, w, http.StatusCreated)
+	id := testutil.Decode[Account](t, w).ID
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		body     any
+		status   int
+		accounts int
+		entries  int
+	}{
+		{"username taken", http.MethodPost, "/accounts", body, http.StatusConflict, 1, 1},
+		{"invalid", http.MethodPost, "/accounts", gin.H{"username": "fo"}, http.StatusBadRequest, 1, 1},
+		{"delete", http.MethodDelete, "/accounts/" + id, nil, http.StatusNoContent, 0, 2},
+		{"delete again", http.MethodDelete, "/accounts/" + id, nil, http.StatusNotFound, 0, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testutil.AssertStatus(t, testutil.DoJSON(t, router, tt.method, tt.path, tt.body), tt.status)
+			if n, m := count("/accounts"), count("/audit"); n != tt.accounts || m != tt.entries {
+				t.Errorf("%d accounts, %d audit entries; want %d and %d", n, m, tt.accounts, tt.entries)
+			}
+		})
+	}
+	if n := count("/audit?subject=" + id); n != 2 {
+		t.Errorf("%d audit entries for the account, want its creation and deletion", n)
+	}
+}
@@ -0,0 +1,172 @@
+package users
+
+import (
+	"crypto/rand"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/apperror"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/audit"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+)
+
+// maxAPIKeys caps the keys one user can hold.
+const maxAPIKeys = 20
+
+// apiKey is a named credential for scripts and other services, sent as
+// X-API-Key. It acts as its user until revoked, without expiring.
+type apiKey struct {
+	id        string
+	userID    string
+	name      string
+	prefix    string // the key's first characters, to tell keys apart
+	createdAt time.Time
+	lastUsed  time.Time
+}
+
+// APIKey is one of GET /api/keys.
+type APIKey struct {
+	ID         string    `json:"id"`
+	Name       string    `json:"name"`
+	Prefix     string    `json:"prefix"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at,omitzero"`
+}
+
+func (k *apiKey) view() APIKey {
+	return APIKey{ID: k.id, Name: k.name, Prefix: k.prefix, CreatedAt: k.createdAt, LastUsedAt: k.lastUsed}
+}
+
+// LookupAPIKey resolves an X-API-Key to its stored user, with the same
+// account checks as LookupToken, and the key's ID.
+func (s *Server) LookupAPIKey(key string) (any, string, error) {
+	s.tokensMu.Lock()
+	k, ok := s.apiKeys[hashToken(key)]
+	var userID, id string
+	if ok {
+		k.lastUsed = s.now().UTC()
+		userID, id = k.userID, k.id
+	}
+	s.tokensMu.Unlock()
+	if !ok {
+		return nil, "", errors.New("invalid API key")
+	}
+	u, err := s.activeUser(userID)
+	if err != nil {
+		return nil, "", err
+	}
+	return u, id, nil
+}
+
+// fromAPIKey refuses key management to requests made with a key, so a leaked
+// key can't mint more of them or revoke its owner's other keys.
+func fromAPIKey(c *gin.Context) bool {
+	if _, ok := c.Get(middleware.APIKeyIDKey); ok {
+		middleware.Error(c, http.StatusForbidden, "API keys can't manage API keys")
+		return true
+	}
+	return false
+}
+
+// listAPIKeys answers with the caller's keys, newest first.
+func (s *Server) listAPIKeys(c *gin.Context) {
+	if fromAPIKey(c) {
+		return
+	}
+	u := MustUser(c)
+	out := []APIKey{}
+	s.tokensMu.Lock()
+	for _, k := range s.apiKeys {
+		if k.userID == u.ID {
+			out = append(out, k.view())
+		}
+	}
+	s.tokensMu.Unlock()
+	slices.SortFunc(out, func(a, b APIKey) int { return b.CreatedAt.Compare(a.CreatedAt) })
+	c.JSON(http.StatusOK, gin.H{"keys": out})
+}
+
+// createAPIKey issues a key named {name}. The response is the only time the
+// key is shown.
+func (s *Server) createAPIKey(c *gin.Context) {
+	if fromAPIKey(c) {
+		return
+	}
+	u := MustUser(c)
+	var req struct {
+		Name string `json:"name" binding:"required,max=64"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.BindError(c, err)
+		return
+	}
+
+	key := "hub_" + rand.Text()
+	k := &apiKey{
+		id:        strings.ToLower(rand.Text()[:12]),
+		userID:    u.ID,
+		name:      req.Name,
+		prefix:    key[:8],
+		createdAt: s.now().UTC(),
+	}
+	s.tokensMu.Lock()
+	held, taken := 0, false
+	for _, other := range s.apiKeys {
+		if other.userID == u.ID {
+			held++
+			taken = taken || other.name == req.Name
+		}
+	}
+	if !taken && held < maxAPIKeys {
+		s.apiKeys[hashToken(key)] = k
+	}
+	s.tokensMu.Unlock()
+	switch {
+	case taken:
+		middleware.Fail(c, apperror.Conflict("an API key with this name already exists"))
+		return
+	case held >= maxAPIKeys:
+		middleware.Fail(c, apperror.Conflict("too many API keys"))
+		return
+	}
+
+	audit.Record(c, audit.Event{Action: audit.APIKeyCreate, Outcome: audit.Success, Actor: u.Username, Target: k.id,
+		Details: map[string]string{"name": k.name}})
+	c.JSON(http.StatusCreated, gin.H{
+		"id":         k.id,
+		"name":       k.name,
+		"prefix":     k.prefix,
+		"created_at": k.createdAt,
+		"key":        key,
+	})
+}
+
+// revokeAPIKey deletes one of the caller's keys. Requests made with it fail
+// from then on.
+func (s *Server) revokeAPIKey(c *gin.Context) {
+	if fromAPIKey(c) {
+		return
+	}
+	u := MustUser(c)
+	id := c.Param("id")
+	found := false
+	s.tokensMu.Lock()
+	for h, k := range s.apiKeys {
+		if k.id == id && k.userID == u.ID {
+			delete(s.apiKeys, h)
+			found = true
+		}
+	}
+	s.tokensMu.Unlock()
+	if !found {
+		middleware.Error(c, http.StatusNotFound, "API key not found")
+		return
+	}
+	audit.Record(c, audit.Event{Action: audit.APIKeyRevoke, Outcome: audit.Success, Actor: u.Username, Target: id})
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,122 @@
+package users
+
+import (
+	"crypto/rand"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/apperror"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/audit"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/files"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+)
+
+// avatarDir is where avatars go under storage.upload_dir.
+const avatarDir = "avatars"
+
+// avatar file extensions by the content type sniffed from the upload; the
+// client's filename and Content-Type aren't trusted
+var avatarTypes = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// avatarURL is where GET /api/avatars/:name serves u's avatar, empty without one.
+func avatarURL(u User) string {
+	if u.Avatar == "" {
+		return ""
+	}
+	return "/api/avatars/" + path.Base(u.Avatar)
+}
+
+// sniffAvatar returns the extension for file's content, or false if it isn't
+// an image type avatars may have.
+func sniffAvatar(file io.Reader) (string, bool) {
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return "", false
+	}
+	ext, ok := avatarTypes[http.DetectContentType(head[:n])]
+	return ext, ok
+}
+
+// uploadAvatar stores the image in the "avatar" form field as the caller's
+// avatar and deletes the one it replaces. Each upload gets a new name, so the
+// URL changes with the picture and caches never serve the old one.
+func (s *Server) uploadAvatar(c *gin.Context) {
+	u := MustUser(c)
+	file, err := c.FormFile("avatar")
+	if files.TooLarge(err) {
+		middleware.Fail(c, err)
+		return
+	}
+	if err != nil {
+		middleware.Error(c, http.StatusBadRequest, "file is required")
+		return
+	}
+	if file.Size > s.maxAvatarBytes {
+		middleware.Fail(c, apperror.TooLarge(s.maxAvatarBytes))
+		return
+	}
+	f, err := file.Open()
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	ext, ok := sniffAvatar(f)
+	f.Close()
+	if !ok {
+		middleware.Error(c, http.StatusBadRequest, "avatar must be a PNG, JPEG, GIF or WebP image")
+		return
+	}
+
+	name := avatarDir + "/" + u.ID + "-" + strings.ToLower(rand.Text()[:8]) + ext
+	if err := s.avatars.Save(c, file, name); err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	var old string
+	stored, err := s.repo.Update(c.Request.Context(), u.ID, func(u *User) error {
+		old, u.Avatar = u.Avatar, name
+		return nil
+	})
+	if err != nil {
+		s.removeAvatar(c, name)
+		middleware.Fail(c, err)
+		return
+	}
+	if old != "" {
+		s.removeAvatar(c, old)
+	}
+	audit.Record(c, audit.Event{Action: audit.FileUpload, Outcome: audit.Success, Actor: u.Username, Target: name})
+	c.JSON(http.StatusCreated, gin.H{"avatar_url": avatarURL(stored)})
+}
+
+// removeAvatar deletes a stored avatar. A failure only leaves a stray file
+// behind, so it is logged rather than returned.
+func (s *Server) removeAvatar(c *gin.Context, name string) {
+	if err := s.avatars.Remove(name); err != nil {
+		slog.ErrorContext(c.Request.Context(), "remove avatar", "file", name, "error", err)
+	}
+}
+
+// getAvatar serves an avatar by the name in its URL. Avatars are public, like
+// the profile pictures of most sites.
+func (s *Server) getAvatar(c *gin.Context) {
+	p := s.avatars.Path(avatarDir + "/" + path.Base(c.Param("name")))
+	if info, err := os.Stat(p); err != nil || !info.Mode().IsRegular() {
+		middleware.Error(c, http.StatusNotFound, "file not found")
+		return
+	}
+	c.Header("Cache-Control", "public, max-age=86400, immutable")
+	c.File(p)
+}
@@ -0,0 +1,341 @@
+package users
+
+import (
+	"cmp"
+	"crypto/rand"
+	"encoding/csv"
+	"errors"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/apperror"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/audit"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/files"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/passhash"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/rbac"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/validation"
+)
+
+// maxImportRows caps one import; every created row costs a bcrypt hash.
+const maxImportRows = 1000
+
+// exportColumns are the columns of GET /api/admin/users/export. An import
+// takes the same file back: it ignores id and created_at.
+var exportColumns = []string{"id", "username", "email", "role", "verified", "enabled", "created_at"}
+
+// importColumns are the columns an import understands, in any order; only
+// username and email are required.
+var importColumns = []string{"id", "username", "email", "password", "role", "verified", "enabled", "created_at"}
+
+// importRow is one line of an import after its columns are matched up.
+type importRow struct {
+	Username string `form:"username" binding:"required,min=3"`
+	Email    string `form:"email" binding:"required,email"`
+	Password string `form:"password" binding:"omitempty,password"`
+	Role     string `form:"role"`
+	Verified string `form:"verified" binding:"omitempty,boolean"`
+	Enabled  string `form:"enabled" binding:"omitempty,boolean"`
+}
+
+// ImportResult reports what became of one line of an import.
+type ImportResult struct {
+	Row      int    `json:"row"` // line in the file, the header being 1
+	Username string `json:"username,omitempty"`
+	Status   string `json:"status"` // created, skipped or error
+	Error    string `json:"error,omitempty"`
+	ID       string `json:"id,omitempty"` // of the created user, unless a dry run
+}
+
+// import statuses
+const (
+	importCreated = "created"
+	importSkipped = "skipped"
+	importError   = "error"
+)
+
+// exportUsers streams every live user as CSV, ordered by username.
+func (s *Server) exportUsers(c *gin.Context) {
+	list, err := s.List(c.Request.Context())
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", `attachment; filename="users.csv"`)
+	c.Status(http.StatusOK)
+	w := csv.NewWriter(c.Writer)
+	w.Write(exportColumns)
+	for _, u := range list {
+		w.Write([]string{
+			u.ID,
+			u.Username,
+			u.Email,
+			u.Role,
+			strconv.FormatBool(u.Verified),
+			strconv.FormatBool(!u.Disabled),
+			u.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		// the status is out already; the client sees a cut-off file
+		c.Error(err)
+	}
+}
+
+// importUsers creates users from the CSV in the "file" form field and
+// reports on every row. A row that fails validation is an error, one whose
+// username exists already, or appears earlier in the file, is skipped; the
+// rest are created. With ?dry_run=true nothing is stored, but the report is
+// the same. Rows without a password get a random one, to be replaced
+// through POST /api/password/forgot.
+func (s *Server) importUsers(c *gin.Context) {
+	dryRun, err := strconv.ParseBool(c.DefaultQuery("dry_run", "false"))
+	if err != nil {
+		middleware.Fail(c, apperror.Validation("invalid query parameters",
+			map[string]string{"dry_run": "must be true or false"}))
+		return
+	}
+	file, err := c.FormFile("file")
+	if files.TooLarge(err) {
+		middleware.Fail(c, err)
+		return
+	}
+	if err != nil {
+		middleware.Error(c, http.StatusBadRequest, "file is required")
+		return
+	}
+	f, err := file.Open()
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1 // checked per row, so one bad row doesn't end the import
+	r.TrimLeadingSpace = true
+	header, err := r.Read()
+	if err != nil {
+		middleware.Error(c, http.StatusBadRequest, "the CSV file has no header row")
+		return
+	}
+	index, err := importHeader(header)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+
+	// read it all first, so an oversized file is refused before anything
+	// is stored
+	var lines []csvLine
+	for {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if len(lines) == maxImportRows {
+			middleware.Fail(c, apperror.Validation("too many rows",
+				map[string]string{"file": "at most " + strconv.Itoa(maxImportRows) + " rows per import"}))
+			return
+		}
+		l := csvLine{record: record, err: err}
+		var perr *csv.ParseError
+		switch {
+		case errors.As(err, &perr):
+			l.row = perr.StartLine
+		case err != nil:
+			// the upload itself can't be read; there's no next row
+			middleware.Fail(c, err)
+			return
+		default:
+			l.row, _ = r.FieldPos(0)
+		}
+		lines = append(lines, l)
+	}
+
+	ctx := c.Request.Context()
+	results := []ImportResult{}
+	seen := map[string]bool{}
+	for _, l := range lines {
+		record := l.record
+		res := ImportResult{Row: l.row, Status: importError}
+		if l.err != nil {
+			res.Error = l.err.Error()
+			results = append(results, res)
+			continue
+		}
+		if len(record) != len(header) {
+			res.Error = "has " + strconv.Itoa(len(record)) + " fields, the header " + strconv.Itoa(len(header))
+			results = append(results, res)
+			continue
+		}
+
+		row := importRow{
+			Username: field(record, index, "username"),
+			Email:    field(record, index, "email"),
+			Password: field(record, index, "password"),
+			Role:     field(record, index, "role"),
+			Verified: field(record, index, "verified"),
+			Enabled:  field(record, index, "enabled"),
+		}
+		res.Username = row.Username
+		if row.Role == "" {
+			row.Role = "user"
+		}
+		if msg := validateImportRow(c, row); msg != "" {
+			res.Error = msg
+			results = append(results, res)
+			continue
+		}
+		if seen[row.Username] {
+			res.Status, res.Error = importSkipped, "username appears earlier in the file"
+			results = append(results, res)
+			continue
+		}
+		seen[row.Username] = true
+
+		if dryRun {
+			_, err := s.repo.GetByUsername(ctx, row.Username)
+			switch {
+			case err == nil:
+				res.Status, res.Error = importSkipped, errUsernameTaken.Error()
+			case errors.Is(err, ErrUserNotFound):
+				res.Status = importCreated
+			default:
+				middleware.Fail(c, err)
+				return
+			}
+			results = append(results, res)
+			continue
+		}
+
+		u, err := s.createImported(c, row)
+		switch {
+		case errors.Is(err, errUsernameTaken):
+			res.Status, res.Error = importSkipped, err.Error()
+		case err != nil:
+			middleware.Fail(c, err)
+			return
+		default:
+			res.Status, res.ID = importCreated, u.ID
+			publishCreated(c, u, "import")
+		}
+		results = append(results, res)
+	}
+
+	counts := map[string]int{importCreated: 0, importSkipped: 0, importError: 0}
+	for _, res := range results {
+		counts[res.Status]++
+	}
+	if !dryRun {
+		recordAdmin(c, audit.UserImport, "", map[string]string{
+			"file":    file.Filename,
+			"created": strconv.Itoa(counts[importCreated]),
+			"skipped": strconv.Itoa(counts[importSkipped]),
+			"errored": strconv.Itoa(counts[importError]),
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"dry_run": dryRun,
+		"created": counts[importCreated],
+		"skipped": counts[importSkipped],
+		"errored": counts[importError],
+		"rows":    results,
+	})
+}
+
+// csvLine is a record of an import as read, or why it couldn't be.
+type csvLine struct {
+	record []string
+	row    int
+	err    error
+}
+
+// importHeader maps the lower-cased column names to their positions. It
+// refuses unknown or repeated columns, since a typo would otherwise drop a
+// column silently.
+func importHeader(header []string) (map[string]int, error) {
+	index := make(map[string]int, len(header))
+	details := map[string]string{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch _, dup := index[name]; {
+		case !slices.Contains(importColumns, name):
+			details[name] = "is not a column; use " + strings.Join(importColumns, ", ")
+		case dup:
+			details[name] = "appears more than once"
+		}
+		index[name] = i
+	}
+	for _, name := range []string{"username", "email"} {
+		if _, ok := index[name]; !ok {
+			details[name] = "column is required"
+		}
+	}
+	if len(details) > 0 {
+		return nil, apperror.Validation("invalid CSV header", details)
+	}
+	return index, nil
+}
+
+// field returns the value of column name in record, empty for a column the
+// file doesn't have.
+func field(record []string, index map[string]int, name string) string {
+	i, ok := index[name]
+	if !ok {
+		return ""
+	}
+	return strings.TrimSpace(record[i])
+}
+
+// validateImportRow checks row with the same rules as registration and
+// returns what's wrong, localized, or "".
+func validateImportRow(c *gin.Context, row importRow) string {
+	if err := binding.Validator.ValidateStruct(&row); err != nil {
+		fields, ok := validation.Translate(c, err)
+		if !ok {
+			return err.Error()
+		}
+		msgs := slices.Collect(maps.Values(fields))
+		slices.Sort(msgs)
+		return strings.Join(msgs, "; ")
+	}
+	if _, ok := rbac.Default.Get(row.Role); !ok {
+		return "role " + row.Role + " is not a role in /admin/roles"
+	}
+	return ""
+}
+
+// createImported stores row as a new user.
+func (s *Server) createImported(c *gin.Context, row importRow) (User, error) {
+	password := row.Password
+	if password == "" {
+		password = rand.Text()
+	}
+	hash, err := passhash.Hash(password)
+	if err != nil {
+		return User{}, err
+	}
+	// validated already, so these parse
+	verified, _ := strconv.ParseBool(cmp.Or(row.Verified, "false"))
+	enabled, _ := strconv.ParseBool(cmp.Or(row.Enabled, "true"))
+	return s.repo.Create(c.Request.Context(), User{
+		Username:  row.Username,
+		Email:     row.Email,
+		Role:      row.Role,
+		Password:  hash,
+		Verified:  verified,
+		Disabled:  !enabled,
+		CreatedAt: s.now().UTC(),
+	})
+}
@@ -0,0 +1,38 @@
+package users
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/apidocs"
+)
+
+// tokenPair is what signing in and refreshing answer.
+var tokenPair = gin.H{"token": "", "expires_in": 0, "refresh_token": "", "refresh_expires_in": 0}
+
+// docs documents the users API at /openapi.json: the /api routes as v1
+// serves them, and the profile as v2 has it.
+var docs = []apidocs.Operation{
+	{Method: http.MethodPost, Path: "/api/register", Tag: "users", Summary: "Create a regular user, unverified until the mailed link is followed",
+		Request: RegisterRequest{}, Status: http.StatusCreated,
+		Response: gin.H{"id": "", "username": "", "email": "", "role": "", "verified": false}},
+	{Method: http.MethodPost, Path: "/api/login", Tag: "users", Summary: "Sign in for an access token and a refresh token",
+		Request: LoginRequest{}, Response: tokenPair},
+	{Method: http.MethodPost, Path: "/api/refresh", Tag: "users", Summary: "Trade a refresh token for new tokens",
+		Request: gin.H{"refresh_token": ""}, Response: tokenPair},
+	{Method: http.MethodPost, Path: "/api/password/forgot", Tag: "users", Summary: "Mail a password reset link",
+		Request: gin.H{"email": ""}, Status: http.StatusAccepted, Response: gin.H{"message": ""}},
+	{Method: http.MethodPost, Path: "/api/password/reset", Tag: "users", Summary: "Set a new password with a reset token",
+		Request: gin.H{"token": "", "password": ""}, Response: gin.H{"message": ""}},
+	{Method: http.MethodGet, Path: "/api/profile", Tag: "users", Summary: "The signed-in user", Auth: true,
+		Response: gin.H{"id": "", "username": "", "email": "", "role": "", "verified": false, "avatar_url": "", "flags": map[string]bool{}}},
+	{Method: http.MethodPut, Path: "/api/profile", Tag: "users", Summary: "Change the email address, to be verified again", Auth: true,
+		Request: gin.H{"email": ""}, Response: gin.H{"message": ""}},
+	{Method: http.MethodGet, Path: "/api/v2/profile", Tag: "users", Summary: "The signed-in user, as v2 has it", Auth: true,
+		Response: ProfileV2{}},
+	{Method: http.MethodPut, Path: "/api/v2/profile", Tag: "users", Summary: "Change the email address, to be verified again", Auth: true,
+		Request: gin.H{"email": gin.H{"address": ""}}, Response: ProfileV2{}},
+	{Method: http.MethodPost, Path: "/api/logout", Tag: "users", Summary: "Sign out this session", Auth: true,
+		Status: http.StatusNoContent},
+}
@@ -0,0 +1,262 @@
+package users
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/apperror"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/audit"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/events"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/passhash"
+)
+
+// ResetSender delivers a password reset link to u. The link stops working
+// after validFor, or once used.
+type ResetSender func(ctx context.Context, u User, link string, validFor time.Duration) error
+
+// SetResetSender replaces how reset links reach s's users; by default they
+// are mailed. Call it before serving.
+func (s *Server) SetResetSender(send ResetSender) {
+	s.sendResetMu.Lock()
+	defer s.sendResetMu.Unlock()
+	s.sendReset = send
+}
+
+// findUserByEmail returns the live user with email, ignoring case. A lookup
+// that fails is logged and treated as no match, since its callers answer the
+// same either way.
+func (s *Server) findUserByEmail(ctx context.Context, email string) (User, bool) {
+	all, err := s.repo.List(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "find user by email", "error", err)
+		return User{}, false
+	}
+	for _, u := range all {
+		if !u.Deleted() && strings.EqualFold(u.Email, email) {
+			return u, true
+		}
+	}
+	return User{}, false
+}
+
+// forgotPassword mails a single-use reset link. It answers the same whether
+// or not the address is registered, and sends in the background so the
+// response time doesn't tell either.
+func (s *Server) forgotPassword(c *gin.Context) {
+	var req struct {
+		Email string `json:"email" binding:"required,email"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.BindError(c, err)
+		return
+	}
+
+	if u, ok := s.findUserByEmail(c.Request.Context(), req.Email); ok {
+		token, ttl := s.resets.issue(u.ID, s.now())
+		link := s.baseURL + "/reset-password?token=" + url.QueryEscape(token)
+		s.sendResetMu.Lock()
+		send := s.sendReset
+		s.sendResetMu.Unlock()
+
+		ctx := context.WithoutCancel(c.Request.Context())
+		s.background.Go(func() {
+			if err := send(ctx, u, link, ttl); err != nil {
+				slog.ErrorContext(ctx, "send password reset link", "user_id", u.ID, "error", err)
+			}
+		})
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "if the address is registered, a reset link is on its way"})
+}
+
+// resetPassword sets a new password and signs the user out everywhere.
+func (s *Server) resetPassword(c *gin.Context) {
+	var req struct {
+		Token    string `json:"token" binding:"required"`
+		Password string `json:"password" binding:"required,password"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.BindError(c, err)
+		return
+	}
+
+	userID, ok := s.resets.consume(req.Token, s.now())
+	if !ok {
+		audit.Record(c, audit.Event{Action: audit.PasswordReset, Outcome: audit.Failure,
+			Details: map[string]string{"reason": "invalid or expired token"}})
+		middleware.Error(c, http.StatusBadRequest, "invalid or expired reset token")
+		return
+	}
+	s.tokensMu.Lock()
+	s.signedOut[userID] = s.now()
+	s.revokeUserLocked(userID)
+	s.tokensMu.Unlock()
+
+	hash, err := passhash.Hash(req.Password)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	u, err := s.repo.Update(c.Request.Context(), userID, func(u *User) error {
+		u.Password = hash
+		return nil
+	})
+	if errors.Is(err, ErrUserNotFound) {
+		middleware.Error(c, http.StatusBadRequest, "invalid or expired reset token")
+		return
+	}
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	audit.Record(c, audit.Event{Action: audit.PasswordReset, Outcome: audit.Success, ActorID: u.ID, Actor: u.Username})
+	c.JSON(http.StatusOK, gin.H{"message": "password updated"})
+}
+
+var errWrongPassword = errors.New("current password is incorrect")
+
+// changePassword replaces the caller's password once the current one checks
+// out. Every other session and API key is signed out, and so is every access
+// token issued before now, the one the request carried included; the caller
+// gets fresh tokens in its own session instead.
+func (s *Server) changePassword(c *gin.Context) {
+	if _, ok := c.Get(middleware.APIKeyIDKey); ok {
+		middleware.Error(c, http.StatusForbidden, "API keys can't change the password")
+		return
+	}
+	u := MustUser(c)
+	var req struct {
+		CurrentPassword string `json:"current_password" binding:"required"`
+		NewPassword     string `json:"new_password" binding:"required,password"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.BindError(c, err)
+		return
+	}
+	if req.NewPassword == req.CurrentPassword {
+		middleware.Fail(c, apperror.Validation("new_password must differ from current_password",
+			map[string]string{"new_password": "new_password must differ from current_password"}))
+		return
+	}
+	claims, err := s.accessClaims(c)
+	if err != nil {
+		middleware.Error(c, http.StatusUnauthorized, "invalid token")
+		return
+	}
+
+	hash, err := passhash.Hash(req.NewPassword)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	if match, _ := passhash.Verify(u.Password, req.CurrentPassword); match {
+		_, err = s.repo.Update(c.Request.Context(), u.ID, func(stored *User) error {
+			// changed since Auth loaded it, so what was checked isn't current
+			if stored.Password != u.Password {
+				return errWrongPassword
+			}
+			stored.Password = hash
+			return nil
+		})
+	} else {
+		err = errWrongPassword
+	}
+	if errors.Is(err, errWrongPassword) {
+		audit.Record(c, audit.Event{Action: audit.PasswordChange, Outcome: audit.Failure, Actor: u.Username,
+			Details: map[string]string{"reason": err.Error()}})
+		middleware.Error(c, http.StatusForbidden, err.Error())
+		return
+	}
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+
+	s.tokensMu.Lock()
+	s.signedOut[u.ID] = s.now()
+	current := s.currentFamilyLocked(claims.ID)
+	for h, rs := range s.refreshes {
+		// the current session's too: they were issued with the old password
+		if rs.userID == u.ID {
+			delete(s.refreshes, h)
+		}
+	}
+	for family, l := range s.logins {
+		if l.userID == u.ID && family != current {
+			delete(s.logins, family)
+		}
+	}
+	for h, k := range s.apiKeys {
+		if k.userID == u.ID {
+			delete(s.apiKeys, h)
+		}
+	}
+	s.tokensMu.Unlock()
+
+	audit.Record(c, audit.Event{Action: audit.PasswordChange, Outcome: audit.Success, Actor: u.Username})
+	if current == "" {
+		// no refresh token ties the access token to a session any more
+		current = rand.Text()
+	}
+	s.issueTokens(c, u, current)
+}
+
+// mailResetLink is the default ResetSender.
+func (s *Server) mailResetLink(ctx context.Context, u User, link string, validFor time.Duration) error {
+	return s.mailer.Send(ctx, "password_reset", u.Email, resetMail{
+		Username: u.Username,
+		Link:     link,
+		ValidFor: humanDuration(validFor),
+	})
+}
+
+// humanDuration writes d as "1 hour" or "30 minutes" for emails.
+func humanDuration(d time.Duration) string {
+	n, unit := int(d/time.Minute), "minute"
+	if d >= time.Hour && d%time.Hour == 0 {
+		n, unit = int(d/time.Hour), "hour"
+	}
+	if n != 1 {
+		unit += "s"
+	}
+	return fmt.Sprintf("%d %s", n, unit)
+}
+
+// upgradePassword replaces the stored password of id, still equal to old,
+// with a fresh hash of password: plaintext from before hashing, or a hash
+// made at a lower cost. A failure only postpones it to the next login.
+func (s *Server) upgradePassword(ctx context.Context, id, old, password string) {
+	hash, err := passhash.Hash(password)
+	if err != nil {
+		slog.ErrorContext(ctx, "rehash password", "user_id", id, "error", err)
+		return
+	}
+	_, err = s.repo.Update(ctx, id, func(u *User) error {
+		// unless it changed while hashing
+		if u.Password == old {
+			u.Password = hash
+		}
+		return nil
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "rehash password", "user_id", id, "error", err)
+	}
+}
+
+// sendWelcome mails every newly registered user. It listens on the event
+// bus, so registration never waits for SMTP.
+func (s *Server) sendWelcome(ctx context.Context, ev events.UserRegisteredEvent) {
+	if err := s.mailer.Send(ctx, "welcome", ev.Email, ev); err != nil {
+		slog.ErrorContext(ctx, "send welcome mail", "user_id", ev.UserID, "error", err)
+	}
+}
@@ -0,0 +1,174 @@
+package users
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/audit"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/jwt"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+)
+
+// refreshSession is one refresh token. Each login starts a family; every
+// refresh replaces the family's token with a new one and keeps the old one,
+// marked used, until it expires. A used token coming back means it was
+// copied, so the whole family is revoked.
+type refreshSession struct {
+	userID        string
+	family        string
+	accessID      string    // jti of the access token issued alongside
+	accessExpires time.Time // when that access token expires anyway
+	expires       time.Time
+	used          bool
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// issueTokens answers with a new access token and a refresh token in
+// family.
+func (s *Server) issueTokens(c *gin.Context, u User, family string) {
+	now := s.now()
+	claims := jwt.Claims{ID: rand.Text(), Subject: u.ID, Username: u.Username, Role: u.Role}
+	access, err := s.signer.Issue(claims)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	refresh := rand.Text()
+	s.tokensMu.Lock()
+	s.refreshes[hashToken(refresh)] = &refreshSession{
+		userID:        u.ID,
+		family:        family,
+		accessID:      claims.ID,
+		accessExpires: now.Add(s.signer.TTL()),
+		expires:       now.Add(s.refreshTTL),
+	}
+	s.trackLoginLocked(c, family, u.ID, now)
+	s.tokensMu.Unlock()
+
+	c.JSON(http.StatusOK, gin.H{
+		"token":              access,
+		"expires_in":         int(s.signer.TTL().Seconds()),
+		"refresh_token":      refresh,
+		"refresh_expires_in": int(s.refreshTTL.Seconds()),
+	})
+}
+
+// refresh trades a refresh token for a new access token and a new
+// refresh token. The one sent can't be used again.
+func (s *Server) refresh(c *gin.Context) {
+	var req struct {
+		RefreshToken string `json:"refresh_token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.BindError(c, err)
+		return
+	}
+
+	s.tokensMu.Lock()
+	rs, ok := s.refreshes[hashToken(req.RefreshToken)]
+	switch {
+	case !ok || s.now().After(rs.expires):
+		s.tokensMu.Unlock()
+		middleware.Error(c, http.StatusUnauthorized, "invalid or expired refresh token")
+		return
+	case rs.used:
+		s.revokeFamilyLocked(rs.family)
+		s.tokensMu.Unlock()
+		slog.WarnContext(c.Request.Context(), "refresh token reused, family revoked", "user_id", rs.userID)
+		audit.Record(c, audit.Event{Action: audit.RefreshReuse, Outcome: audit.Failure, ActorID: rs.userID,
+			Details: map[string]string{"session": rs.family}})
+		middleware.Error(c, http.StatusUnauthorized, "invalid or expired refresh token")
+		return
+	}
+	rs.used = true
+	userID, family := rs.userID, rs.family
+	s.tokensMu.Unlock()
+
+	u, err := s.Get(c.Request.Context(), userID)
+	if err != nil && !errors.Is(err, ErrUserNotFound) {
+		middleware.Fail(c, err)
+		return
+	}
+	if err != nil || u.Disabled {
+		middleware.Error(c, http.StatusUnauthorized, "invalid or expired refresh token")
+		return
+	}
+	s.issueTokens(c, u, family)
+}
+
+// logout revokes the access token it was called with and the refresh
+// tokens of its login. A refresh_token in the body is revoked too, for
+// clients whose access token came from an earlier refresh.
+func (s *Server) logout(c *gin.Context) {
+	var req struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			middleware.BindError(c, err)
+			return
+		}
+	}
+	u := MustUser(c)
+	claims, err := s.accessClaims(c)
+	if err != nil {
+		middleware.Error(c, http.StatusUnauthorized, "invalid token")
+		return
+	}
+
+	s.tokensMu.Lock()
+	s.revoked[claims.ID] = claims.Expires()
+	if family := s.currentFamilyLocked(claims.ID); family != "" {
+		s.revokeFamilyLocked(family)
+	}
+	if req.RefreshToken != "" {
+		if rs, ok := s.refreshes[hashToken(req.RefreshToken)]; ok && rs.userID == u.ID {
+			s.revokeFamilyLocked(rs.family)
+		}
+	}
+	s.tokensMu.Unlock()
+	c.Status(http.StatusNoContent)
+}
+
+// revokeFamilyLocked drops every refresh token of family and revokes the
+// access tokens issued with them. The caller holds tokensMu.
+func (s *Server) revokeFamilyLocked(family string) {
+	for h, rs := range s.refreshes {
+		if rs.family == family {
+			s.revoked[rs.accessID] = rs.accessExpires
+			delete(s.refreshes, h)
+		}
+	}
+	delete(s.logins, family)
+}
+
+// revokeUserLocked drops all of a user's refresh tokens and API keys. The
+// caller holds tokensMu.
+func (s *Server) revokeUserLocked(userID string) {
+	for h, rs := range s.refreshes {
+		if rs.userID == userID {
+			delete(s.refreshes, h)
+		}
+	}
+	for h, k := range s.apiKeys {
+		if k.userID == userID {
+			delete(s.apiKeys, h)
+		}
+	}
+	for family, l := range s.logins {
+		if l.userID == userID {
+			delete(s.logins, family)
+		}
+	}
+}
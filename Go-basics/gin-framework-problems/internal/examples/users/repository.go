@@ -0,0 +1,242 @@
+package users
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"maps"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/apperror"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/config"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/database"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/server"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/tenant"
+)
+
+var (
+	// an apperror, so handlers can pass it to middleware.Fail for a 404
+	ErrUserNotFound  = apperror.NotFound("user not found")
+	errUsernameTaken = errors.New("username already exists")
+)
+
+// UserRepository keeps users, deleted ones included: what deletion means is
+// up to the callers. Get, GetByUsername, Update and Delete fail with
+// ErrUserNotFound for a user that isn't stored.
+type UserRepository interface {
+	// Create stores u under a new ID and returns it with the ID set. It fails
+	// with errUsernameTaken when another user, even a deleted one, has u's
+	// username.
+	Create(ctx context.Context, u User) (User, error)
+	Get(ctx context.Context, id string) (User, error)
+	GetByUsername(ctx context.Context, username string) (User, error)
+	// List returns every user, in no particular order.
+	List(ctx context.Context) ([]User, error)
+	// Update applies fn to the stored user and saves the result, with no
+	// other update in between. An error from fn is returned and leaves the
+	// user as it was.
+	Update(ctx context.Context, id string, fn func(*User) error) (User, error)
+	Delete(ctx context.Context, id string) error
+}
+
+// SetRepository replaces where s's users are kept, e.g. with a fresh
+// NewMemoryRepository. Call it before serving.
+func (s *Server) SetRepository(r UserRepository) {
+	s.repo = scoped(traced(r))
+}
+
+// newRepository keeps users where users.store says: in memory, or in the
+// SQLite database at database.path, where they survive restarts.
+func newRepository(cfg *config.Config, hooks *server.Hooks) UserRepository {
+	if cfg.Users.Store == "sqlite" {
+		return NewSQLiteRepository(server.OpenDB(cfg.Database.Path, hooks))
+	}
+	return NewMemoryRepository()
+}
+
+// memoryRepository keeps users in a map, numbering them from 1. Everything
+// is lost on restart.
+type memoryRepository struct {
+	mu    sync.Mutex
+	users map[string]User
+	seq   int
+}
+
+func NewMemoryRepository() UserRepository {
+	return &memoryRepository{users: map[string]User{}}
+}
+
+func (r *memoryRepository) Create(_ context.Context, u User) (User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, other := range r.users {
+		if other.Username == u.Username {
+			return User{}, errUsernameTaken
+		}
+	}
+	r.seq++
+	u.ID = strconv.Itoa(r.seq)
+	r.users[u.ID] = u
+	return u, nil
+}
+
+func (r *memoryRepository) Get(_ context.Context, id string) (User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.users[id]
+	if !ok {
+		return User{}, ErrUserNotFound
+	}
+	return u, nil
+}
+
+func (r *memoryRepository) GetByUsername(_ context.Context, username string) (User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, u := range r.users {
+		if u.Username == username {
+			return u, nil
+		}
+	}
+	return User{}, ErrUserNotFound
+}
+
+func (r *memoryRepository) List(context.Context) ([]User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Collect(maps.Values(r.users)), nil
+}
+
+func (r *memoryRepository) Update(_ context.Context, id string, fn func(*User) error) (User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.users[id]
+	if !ok {
+		return User{}, ErrUserNotFound
+	}
+	if err := fn(&u); err != nil {
+		return User{}, err
+	}
+	r.users[id] = u
+	return u, nil
+}
+
+func (r *memoryRepository) Delete(_ context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.users[id]; !ok {
+		return ErrUserNotFound
+	}
+	delete(r.users, id)
+	return nil
+}
+
+// sqliteRepository keeps users in the users table, which the transactions
+// example writes to as well. IDs are random hex.
+type sqliteRepository struct {
+	db *sql.DB
+}
+
+// NewSQLiteRepository keeps users in db, whose schema must be migrated.
+func NewSQLiteRepository(db *sql.DB) UserRepository {
+	return sqliteRepository{db: db}
+}
+
+const userColumns = `id, username, email, password, role, verified, disabled, avatar, deleted_at, created_at, tenant`
+
+func scanUser(row interface{ Scan(...any) error }) (User, error) {
+	var u User
+	var deletedAt sql.NullTime
+	err := row.Scan(&u.ID, &u.Username, &u.Email, &u.Password, &u.Role, &u.Verified, &u.Disabled, &u.Avatar, &deletedAt, &u.CreatedAt, &u.Tenant)
+	if errors.Is(err, sql.ErrNoRows) {
+		return User{}, ErrUserNotFound
+	}
+	u.DeletedAt = deletedAt.Time
+	return u, err
+}
+
+// nullTime stores the zero time as NULL.
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
+
+func (r sqliteRepository) Create(ctx context.Context, u User) (User, error) {
+	b := make([]byte, 8)
+	rand.Read(b)
+	u.ID = hex.EncodeToString(b)
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO users (`+userColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		u.ID, u.Username, u.Email, u.Password, u.Role, u.Verified, u.Disabled, u.Avatar, nullTime(u.DeletedAt), u.CreatedAt, tenant.Owner(u.Tenant))
+	if database.IsUniqueViolation(err) {
+		return User{}, errUsernameTaken
+	}
+	if err != nil {
+		return User{}, err
+	}
+	return u, nil
+}
+
+func (r sqliteRepository) Get(ctx context.Context, id string) (User, error) {
+	return scanUser(r.db.QueryRowContext(ctx, `SELECT `+userColumns+` FROM users WHERE id = ?`, id))
+}
+
+func (r sqliteRepository) GetByUsername(ctx context.Context, username string) (User, error) {
+	return scanUser(r.db.QueryRowContext(ctx, `SELECT `+userColumns+` FROM users WHERE username = ?`, username))
+}
+
+func (r sqliteRepository) List(ctx context.Context) ([]User, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+userColumns+` FROM users`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []User
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, u)
+	}
+	return out, rows.Err()
+}
+
+func (r sqliteRepository) Update(ctx context.Context, id string, fn func(*User) error) (User, error) {
+	var u User
+	err := database.WithTx(ctx, r.db, func(tx *sql.Tx) error {
+		var err error
+		u, err = scanUser(tx.QueryRowContext(ctx, `SELECT `+userColumns+` FROM users WHERE id = ?`, id))
+		if err != nil {
+			return err
+		}
+		if err := fn(&u); err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx,
+			`UPDATE users SET email = ?, password = ?, role = ?, verified = ?, disabled = ?, avatar = ?, deleted_at = ? WHERE id = ?`,
+			u.Email, u.Password, u.Role, u.Verified, u.Disabled, u.Avatar, nullTime(u.DeletedAt), id)
+		return err
+	})
+	if err != nil {
+		return User{}, err
+	}
+	return u, nil
+}
+
+func (r sqliteRepository) Delete(ctx context.Context, id string) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM users WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package users
+
+import (
+	"context"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/tenant"
+)
+
+// scopedRepository keeps each tenant's users from the others: a user is
+// created for the tenant of the context, and to requests of any other
+// tenant it is not found. Usernames stay unique across tenants, so a taken
+// one is taken everywhere. Contexts without a tenant, as with tenancy off
+// or in background work, see every user.
+type scopedRepository struct {
+	UserRepository
+}
+
+func scoped(r UserRepository) UserRepository {
+	return scopedRepository{r}
+}
+
+func (s scopedRepository) Create(ctx context.Context, u User) (User, error) {
+	u.Tenant = tenant.Assign(ctx)
+	return s.UserRepository.Create(ctx, u)
+}
+
+func (s scopedRepository) Get(ctx context.Context, id string) (User, error) {
+	return visible(ctx)(s.UserRepository.Get(ctx, id))
+}
+
+func (s scopedRepository) GetByUsername(ctx context.Context, username string) (User, error) {
+	return visible(ctx)(s.UserRepository.GetByUsername(ctx, username))
+}
+
+func (s scopedRepository) List(ctx context.Context) ([]User, error) {
+	all, err := s.UserRepository.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var out []User
+	for _, u := range all {
+		if tenant.Visible(ctx, u.Tenant) {
+			out = append(out, u)
+		}
+	}
+	return out, nil
+}
+
+func (s scopedRepository) Update(ctx context.Context, id string, fn func(*User) error) (User, error) {
+	return s.UserRepository.Update(ctx, id, func(u *User) error {
+		if !tenant.Visible(ctx, u.Tenant) {
+			return ErrUserNotFound
+		}
+		owner := u.Tenant
+		err := fn(u)
+		u.Tenant = owner
+		return err
+	})
+}
+
+func (s scopedRepository) Delete(ctx context.Context, id string) error {
+	if _, err := s.Get(ctx, id); err != nil {
+		return err
+	}
+	return s.UserRepository.Delete(ctx, id)
+}
+
+// visible returns a filter of a lookup result that passes on the user
+// unless ctx's tenant may not see it.
+func visible(ctx context.Context) func(User, error) (User, error) {
+	return func(u User, err error) (User, error) {
+		if err == nil && !tenant.Visible(ctx, u.Tenant) {
+			return User{}, ErrUserNotFound
+		}
+		return u, err
+	}
+}
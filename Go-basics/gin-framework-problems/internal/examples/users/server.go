@@ -0,0 +1,120 @@
+package users
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/config"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/files"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/jwt"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/mail"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/server"
+)
+
+// Options are what a Server depends on. Zero fields take the defaults noted.
+type Options struct {
+	Repository     UserRepository    // a NewMemoryRepository
+	Auth           config.AuthConfig // config.Default's
+	Mailer         *mail.Mailer      // none: verification mail isn't sent
+	BaseURL        string            // of the links in mail; config.Default's
+	MaxAvatarBytes int64             // 2 MiB
+	SendReset      ResetSender       // mails the link
+	UploadDir      string            // config.Default's storage.upload_dir
+	Now            func() time.Time  // time.Now
+}
+
+// Server is the users API example: its users and every token and API key
+// issued to them. Every Server has its own, so tests and several instances
+// in one process don't share state. Other examples that share these users
+// are handed the Server, or build one with NewAccounts.
+type Server struct {
+	// keeps the users; NewAccounts picks it from users.store
+	repo UserRepository
+
+	// what tokens, sessions, API keys and accounts are timed by
+	now func() time.Time
+
+	// set by ConfigureTokens: what issues and checks login tokens, how
+	// long refresh tokens last and whether unverified accounts may sign in
+	signer          *jwt.Signer
+	refreshTTL      time.Duration
+	requireVerified bool
+
+	// password reset and email verification tokens, whose lifetimes
+	// ConfigureTokens sets
+	resets        *oneTimeTokens
+	verifications *oneTimeTokens
+
+	tokensMu sync.Mutex
+	// user ID -> when their password was last reset; tokens issued before
+	// then are refused
+	signedOut map[string]time.Time
+	// sha256 of the refresh token -> session
+	refreshes map[string]*refreshSession
+	// jti -> expiry of access tokens revoked before their time
+	revoked map[string]time.Time
+	// sha256 of the key -> key; the key itself is only shown when it's
+	// created
+	apiKeys map[string]*apiKey
+	// family -> login; it goes when the family's refresh tokens do
+	logins map[string]*login
+
+	mailer  *mail.Mailer
+	baseURL string
+	// mail sent after the response, which shutdown waits for
+	background server.Background
+
+	// how reset links reach users
+	sendResetMu sync.Mutex
+	sendReset   ResetSender
+
+	maxAvatarBytes int64
+	// keeps the avatars, under avatarDir
+	avatars files.Dir
+}
+
+// NewServer returns a Server with opts' dependencies.
+func NewServer(opts Options) *Server {
+	defaults := config.Default()
+	s := &Server{
+		mailer:         opts.Mailer,
+		baseURL:        opts.BaseURL,
+		maxAvatarBytes: opts.MaxAvatarBytes,
+		sendReset:      opts.SendReset,
+		avatars:        files.Dir(opts.UploadDir),
+		now:            opts.Now,
+		resets:         newOneTimeTokens(0),
+		verifications:  newOneTimeTokens(0),
+		signedOut:      map[string]time.Time{},
+		refreshes:      map[string]*refreshSession{},
+		revoked:        map[string]time.Time{},
+		apiKeys:        map[string]*apiKey{},
+		logins:         map[string]*login{},
+	}
+	if s.now == nil {
+		s.now = time.Now
+	}
+	if s.avatars == "" {
+		s.avatars = files.Dir(defaults.Storage.UploadDir)
+	}
+	repo := opts.Repository
+	if repo == nil {
+		repo = NewMemoryRepository()
+	}
+	s.SetRepository(repo)
+	auth := opts.Auth
+	if auth == (config.AuthConfig{}) {
+		auth = defaults.Auth
+	}
+	s.ConfigureTokens(auth)
+	if s.baseURL == "" {
+		s.baseURL = defaults.Mail.BaseURL
+	}
+	if s.maxAvatarBytes == 0 {
+		s.maxAvatarBytes = 2 << 20
+	}
+	if s.sendReset == nil {
+		s.sendReset = s.mailResetLink
+	}
+	return s
+}
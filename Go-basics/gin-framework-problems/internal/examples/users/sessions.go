@@ -0,0 +1,145 @@
+package users
+
+import (
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/jwt"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+)
+
+// login describes a family of refresh tokens: one sign-in on one device.
+type login struct {
+	userID    string
+	createdAt time.Time
+	lastUsed  time.Time // the sign-in or the latest refresh
+	ip        string    // as of lastUsed
+	userAgent string
+}
+
+// Session is one of GET /api/sessions.
+type Session struct {
+	ID         string    `json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"` // of its refresh token
+	IP         string    `json:"ip"`
+	UserAgent  string    `json:"user_agent"`
+	Current    bool      `json:"current"` // the one the request was made with
+}
+
+// trackLoginLocked records that family was used from this request. The
+// caller holds tokensMu.
+func (s *Server) trackLoginLocked(c *gin.Context, family, userID string, now time.Time) {
+	l, ok := s.logins[family]
+	if !ok {
+		l = &login{userID: userID, createdAt: now}
+		s.logins[family] = l
+	}
+	l.lastUsed = now
+	l.ip = c.ClientIP()
+	l.userAgent = c.Request.UserAgent()
+}
+
+// accessClaims recovers the claims of the access token the request was
+// authenticated with. Auth has already checked it.
+func (s *Server) accessClaims(c *gin.Context) (jwt.Claims, error) {
+	return middleware.JWTClaims(c, s.signer)
+}
+
+// currentFamilyLocked returns the family the access token with jti was
+// issued in. The caller holds tokensMu.
+func (s *Server) currentFamilyLocked(jti string) string {
+	for _, rs := range s.refreshes {
+		if rs.accessID == jti {
+			return rs.family
+		}
+	}
+	return ""
+}
+
+// listSessions answers with the caller's signed-in devices, newest first: the
+// families with a refresh token that can still be used.
+func (s *Server) listSessions(c *gin.Context) {
+	u := MustUser(c)
+	claims, err := s.accessClaims(c)
+	if err != nil {
+		middleware.Error(c, http.StatusUnauthorized, "invalid token")
+		return
+	}
+	now := s.now()
+
+	s.tokensMu.Lock()
+	current := s.currentFamilyLocked(claims.ID)
+	expires := map[string]time.Time{}
+	for _, rs := range s.refreshes {
+		if rs.userID == u.ID && !rs.used && now.Before(rs.expires) {
+			if rs.expires.After(expires[rs.family]) {
+				expires[rs.family] = rs.expires
+			}
+		}
+	}
+	out := []Session{}
+	for family, exp := range expires {
+		l, ok := s.logins[family]
+		if !ok {
+			continue
+		}
+		out = append(out, Session{
+			ID:         family,
+			CreatedAt:  l.createdAt,
+			LastUsedAt: l.lastUsed,
+			ExpiresAt:  exp,
+			IP:         l.ip,
+			UserAgent:  l.userAgent,
+			Current:    family == current,
+		})
+	}
+	s.tokensMu.Unlock()
+
+	slices.SortFunc(out, func(a, b Session) int { return b.CreatedAt.Compare(a.CreatedAt) })
+	c.JSON(http.StatusOK, gin.H{"sessions": out})
+}
+
+// revokeSession signs one of the caller's devices out: its refresh tokens
+// stop working and so does the access token issued with the latest one.
+// Revoking the current session is the same as logging out.
+func (s *Server) revokeSession(c *gin.Context) {
+	u := MustUser(c)
+	family := c.Param("id")
+	s.tokensMu.Lock()
+	l, ok := s.logins[family]
+	if ok && l.userID == u.ID {
+		s.revokeFamilyLocked(family)
+	}
+	s.tokensMu.Unlock()
+	if !ok || l.userID != u.ID {
+		middleware.Error(c, http.StatusNotFound, "session not found")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// revokeOtherSessions signs out every device but the one making the request.
+func (s *Server) revokeOtherSessions(c *gin.Context) {
+	u := MustUser(c)
+	claims, err := s.accessClaims(c)
+	if err != nil {
+		middleware.Error(c, http.StatusUnauthorized, "invalid token")
+		return
+	}
+	s.tokensMu.Lock()
+	current := s.currentFamilyLocked(claims.ID)
+	n := 0
+	for family, l := range s.logins {
+		if l.userID == u.ID && family != current {
+			s.revokeFamilyLocked(family)
+			n++
+		}
+	}
+	s.tokensMu.Unlock()
+	c.JSON(http.StatusOK, gin.H{"revoked": n})
+}
@@ -0,0 +1,266 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/passhash"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/query"
+)
+
+// Exported access to the user store, so other examples (GraphQL) share the
+// users the REST handlers manage.
+
+// These leave out deleted users, who can still be restored.
+
+// Get returns the user with the given ID, or ErrUserNotFound.
+func (s *Server) Get(ctx context.Context, id string) (User, error) {
+	u, err := s.repo.Get(ctx, id)
+	if err == nil && u.Deleted() {
+		return User{}, ErrUserNotFound
+	}
+	return u, err
+}
+
+// GetMany returns the users that exist among ids, for batch loaders.
+func (s *Server) GetMany(ctx context.Context, ids []string) (map[string]User, error) {
+	out := make(map[string]User, len(ids))
+	for _, id := range ids {
+		u, err := s.Get(ctx, id)
+		if errors.Is(err, ErrUserNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		out[id] = u
+	}
+	return out, nil
+}
+
+// List returns every user ordered by username.
+func (s *Server) List(ctx context.Context) ([]User, error) {
+	all, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := slices.DeleteFunc(all, User.Deleted)
+	slices.SortFunc(out, func(a, b User) int { return strings.Compare(a.Username, b.Username) })
+	return out, nil
+}
+
+// userFilters selects users for the admin list.
+type userFilters struct {
+	Deleted bool   `form:"deleted"`                                   // only deleted users, rather than only live ones
+	Role    string `form:"role" binding:"omitempty,oneof=user admin"` // empty for both
+	Search  string `form:"q"`                                         // case-insensitive part of the username or email
+}
+
+var userSorts = query.Sorts[User]{
+	"username":   func(a, b User) int { return strings.Compare(a.Username, b.Username) },
+	"email":      func(a, b User) int { return strings.Compare(strings.ToLower(a.Email), strings.ToLower(b.Email)) },
+	"created_at": func(a, b User) int { return a.CreatedAt.Compare(b.CreatedAt) },
+}
+
+// listUsers returns the users matching f in the order s asks for. Usernames
+// are unique and break ties, so the order is the same on every call and
+// pages don't overlap.
+func (s *Server) listUsers(ctx context.Context, f userFilters, order query.Sort) ([]User, error) {
+	all, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	search := strings.ToLower(f.Search)
+	out := make([]User, 0, len(all))
+	for _, u := range all {
+		if u.Deleted() != f.Deleted {
+			continue
+		}
+		if f.Role != "" && u.Role != f.Role {
+			continue
+		}
+		if search != "" && !strings.Contains(strings.ToLower(u.Username), search) &&
+			!strings.Contains(strings.ToLower(u.Email), search) {
+			continue
+		}
+		out = append(out, u)
+	}
+	userSorts.Apply(out, order, func(a, b User) int { return strings.Compare(a.Username, b.Username) })
+	return out, nil
+}
+
+// search match modes: a prefix of the username or email, or any part of it
+const (
+	matchPrefix    = "prefix"
+	matchSubstring = "substring"
+)
+
+// searchRank scores how well u matches term, which is lower case: 0 for the
+// whole username or email, then a prefix of the username, a prefix of the
+// email, part of the username and part of the email. It is -1 for no match.
+func searchRank(u User, term, match string) int {
+	name, email := strings.ToLower(u.Username), strings.ToLower(u.Email)
+	switch {
+	case name == term || email == term:
+		return 0
+	case strings.HasPrefix(name, term):
+		return 1
+	case strings.HasPrefix(email, term):
+		return 2
+	case match == matchPrefix:
+		return -1
+	case strings.Contains(name, term):
+		return 3
+	case strings.Contains(email, term):
+		return 4
+	}
+	return -1
+}
+
+// searchUsers returns the live users matching term, ignoring case, best
+// match first and then by username.
+func (s *Server) searchUsers(ctx context.Context, term, match string) ([]User, error) {
+	all, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	term = strings.ToLower(term)
+	type ranked struct {
+		u    User
+		rank int
+	}
+	var found []ranked
+	for _, u := range all {
+		if u.Deleted() {
+			continue
+		}
+		if r := searchRank(u, term, match); r >= 0 {
+			found = append(found, ranked{u, r})
+		}
+	}
+
+	slices.SortFunc(found, func(a, b ranked) int {
+		if a.rank != b.rank {
+			return a.rank - b.rank
+		}
+		return strings.Compare(a.u.Username, b.u.Username)
+	})
+	out := make([]User, len(found))
+	for i, f := range found {
+		out[i] = f.u
+	}
+	return out, nil
+}
+
+// SeedUser stores u, whose Password must be a passhash hash already, unless
+// a user with its username exists, deleted or not, and reports whether it
+// did. Fixtures use it, so loading them twice adds nobody twice.
+func (s *Server) SeedUser(ctx context.Context, u User) (bool, error) {
+	if u.CreatedAt.IsZero() {
+		u.CreatedAt = s.now().UTC()
+	}
+	_, err := s.repo.Create(ctx, u)
+	if errors.Is(err, errUsernameTaken) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// SeedAdmin creates the default "admin" account unless it already exists.
+// An existing admin whose password is still plaintext gets it hashed.
+func (s *Server) SeedAdmin(password string) {
+	ctx := context.Background()
+	u, err := s.repo.GetByUsername(ctx, "admin")
+	if err == nil {
+		if !passhash.IsHash(u.Password) {
+			s.upgradePassword(ctx, u.ID, u.Password, u.Password)
+		}
+		return
+	}
+	if !errors.Is(err, ErrUserNotFound) {
+		panic(fmt.Sprintf("seed admin: %v", err))
+	}
+	hash, err := passhash.Hash(password)
+	if err != nil {
+		// only a password over passhash.MaxLength gets here
+		panic(fmt.Sprintf("seed admin: %v", err))
+	}
+	_, err = s.repo.Create(ctx, User{
+		Username:  "admin",
+		Email:     "admin@example.com",
+		Role:      "admin",
+		Password:  hash,
+		Verified:  true,
+		CreatedAt: s.now().UTC(),
+	})
+	if err != nil {
+		panic(fmt.Sprintf("seed admin: %v", err))
+	}
+}
+
+// PurgeExpiredTokens drops reset, verification and refresh tokens that
+// expired before now or whose user was deleted, and returns how many were
+// removed. It also forgets revocations of access tokens that have expired by
+// now.
+func (s *Server) PurgeExpiredTokens(ctx context.Context, now time.Time) (int, error) {
+	all, err := s.repo.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+	live := make(map[string]bool, len(all))
+	for _, u := range all {
+		live[u.ID] = !u.Deleted()
+	}
+	s.tokensMu.Lock()
+	defer s.tokensMu.Unlock()
+	n := 0
+	n += s.resets.purge(now, live)
+	n += s.verifications.purge(now, live)
+	families := map[string]bool{}
+	for h, rs := range s.refreshes {
+		if now.After(rs.expires) || !live[rs.userID] {
+			delete(s.refreshes, h)
+			n++
+		} else {
+			families[rs.family] = true
+		}
+	}
+	for family := range s.logins {
+		if !families[family] {
+			delete(s.logins, family)
+		}
+	}
+	for id, expires := range s.revoked {
+		// past the signer's leeway too, or the token would work again
+		if now.After(expires.Add(time.Minute)) {
+			delete(s.revoked, id)
+		}
+	}
+	for id, at := range s.signedOut {
+		if now.Sub(at) > s.signer.TTL() || !live[id] {
+			delete(s.signedOut, id)
+		}
+	}
+	return n, nil
+}
+
+// UpdateEmail changes a user's email and returns the updated user. A
+// different address is unverified until POST /api/verify/resend sends it a
+// link; the users example sends one itself.
+func (s *Server) UpdateEmail(ctx context.Context, id, email string) (User, error) {
+	return s.repo.Update(ctx, id, func(u *User) error {
+		if u.Deleted() {
+			return ErrUserNotFound
+		}
+		if u.Email != email {
+			u.Email = email
+			u.Verified = false
+			s.verifications.drop(id)
+		}
+		return nil
+	})
+}
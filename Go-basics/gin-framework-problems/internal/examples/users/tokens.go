@@ -0,0 +1,86 @@
+package users
+
+import (
+	"crypto/rand"
+	"sync"
+	"time"
+)
+
+// oneTimeTokens keeps the outstanding tokens of one kind, such as password
+// resets. Only their SHA-256 is stored, a user has at most one (issuing
+// another replaces it) and a token is gone once presented, whether or not it
+// had expired.
+type oneTimeTokens struct {
+	mu     sync.Mutex
+	ttl    time.Duration
+	byHash map[string]session
+}
+
+func newOneTimeTokens(ttl time.Duration) *oneTimeTokens {
+	return &oneTimeTokens{ttl: ttl, byHash: map[string]session{}}
+}
+
+func (t *oneTimeTokens) setTTL(ttl time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.ttl = ttl
+}
+
+// drop forgets userID's outstanding token, if any.
+func (t *oneTimeTokens) drop(userID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.dropLocked(userID)
+}
+
+func (t *oneTimeTokens) dropLocked(userID string) {
+	for h, s := range t.byHash {
+		if s.userID == userID {
+			delete(t.byHash, h)
+		}
+	}
+}
+
+// issue returns a new token for userID and how long it is valid.
+func (t *oneTimeTokens) issue(userID string, now time.Time) (string, time.Duration) {
+	token := rand.Text()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.dropLocked(userID)
+	t.byHash[hashToken(token)] = session{userID: userID, expires: now.Add(t.ttl)}
+	return token, t.ttl
+}
+
+// consume returns the user a live token was issued to, and forgets it.
+func (t *oneTimeTokens) consume(token string, now time.Time) (string, bool) {
+	h := hashToken(token)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s, ok := t.byHash[h]
+	delete(t.byHash, h)
+	if !ok || !now.Before(s.expires) {
+		return "", false
+	}
+	return s.userID, true
+}
+
+// purge drops tokens that expired before now or whose user isn't live, and
+// returns how many.
+func (t *oneTimeTokens) purge(now time.Time, live map[string]bool) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	n := 0
+	for h, s := range t.byHash {
+		if now.After(s.expires) || !live[s.userID] {
+			delete(t.byHash, h)
+			n++
+		}
+	}
+	return n
+}
+
+type resetMail struct {
+	Username string
+	Link     string
+	ValidFor string
+}
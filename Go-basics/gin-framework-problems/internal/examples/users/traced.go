@@ -0,0 +1,55 @@
+package users
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/tracing"
+)
+
+// tracedRepository makes each call to a UserRepository a child span of the
+// request's, users.store.<operation>, as books does around its store.
+type tracedRepository struct {
+	UserRepository
+}
+
+func traced(r UserRepository) UserRepository {
+	return tracedRepository{r}
+}
+
+func (t tracedRepository) Create(ctx context.Context, u User) (_ User, err error) {
+	ctx, span := tracing.Start(ctx, "users.store.create")
+	defer func() { tracing.End(span, err) }()
+	return t.UserRepository.Create(ctx, u)
+}
+
+func (t tracedRepository) Get(ctx context.Context, id string) (_ User, err error) {
+	ctx, span := tracing.Start(ctx, "users.store.get", attribute.String("user.id", id))
+	defer func() { tracing.End(span, err) }()
+	return t.UserRepository.Get(ctx, id)
+}
+
+func (t tracedRepository) GetByUsername(ctx context.Context, username string) (_ User, err error) {
+	ctx, span := tracing.Start(ctx, "users.store.get_by_username")
+	defer func() { tracing.End(span, err) }()
+	return t.UserRepository.GetByUsername(ctx, username)
+}
+
+func (t tracedRepository) List(ctx context.Context) (_ []User, err error) {
+	ctx, span := tracing.Start(ctx, "users.store.list")
+	defer func() { tracing.End(span, err) }()
+	return t.UserRepository.List(ctx)
+}
+
+func (t tracedRepository) Update(ctx context.Context, id string, fn func(*User) error) (_ User, err error) {
+	ctx, span := tracing.Start(ctx, "users.store.update", attribute.String("user.id", id))
+	defer func() { tracing.End(span, err) }()
+	return t.UserRepository.Update(ctx, id, fn)
+}
+
+func (t tracedRepository) Delete(ctx context.Context, id string) (err error) {
+	ctx, span := tracing.Start(ctx, "users.store.delete", attribute.String("user.id", id))
+	defer func() { tracing.End(span, err) }()
+	return t.UserRepository.Delete(ctx, id)
+}
@@ -0,0 +1,729 @@
+package users
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/apiversion"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/apperror"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/audit"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/config"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/events"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/jobs"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/featureflags"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/idempotency"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/ipfilter"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/jwt"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/loginguard"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/mail"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/metrics"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/pagination"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/passhash"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/query"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/rbac"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/server"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/tenant"
+)
+
+type User struct {
+	ID        string    `json:"id"`
+	Username  string    `json:"username" binding:"required,min=3"`
+	Email     string    `json:"email" binding:"required,email"`
+	Role      string    `json:"role"`     // a role in rbac.Default
+	Password  string    `json:"-"`        // bcrypt hash; plaintext until the next login for old accounts
+	Verified  bool      `json:"verified"` // the email address has been confirmed
+	Disabled  bool      `json:"disabled"` // by an admin; the account can't sign in
+	Avatar    string    `json:"-"`        // stored under storage.upload_dir; served at avatarURL
+	DeletedAt time.Time `json:"deleted_at,omitzero"`
+	CreatedAt time.Time `json:"created_at"`
+	Tenant    string    `json:"tenant,omitempty"` // set by the store; see internal/tenant
+}
+
+// TenantID lets middleware.Auth refuse the user on other tenants' requests.
+func (u User) TenantID() string {
+	return tenant.Owner(u.Tenant)
+}
+
+// HasRole lets middleware.RequireRole inspect the user.
+func (u User) HasRole(role string) bool {
+	return u.Role == role
+}
+
+// Deleted reports whether the user was deleted. Deleted users keep their ID
+// and username until they are purged, and can be restored.
+func (u User) Deleted() bool {
+	return !u.DeletedAt.IsZero()
+}
+
+// Can lets middleware.RequirePermission check what the user's role grants.
+func (u User) Can(permission string) bool {
+	return rbac.Default.Allowed(u.Role, permission)
+}
+
+// Subject identifies the user in request logs.
+func (u User) Subject() string {
+	return u.ID
+}
+
+// RegisterRequest is the body of POST /api/register.
+type RegisterRequest struct {
+	Username string `json:"username" binding:"required,min=3"`
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required,password"`
+}
+
+type LoginRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// ConfigureTokens sets the key, issuer and lifetimes of s's login, refresh,
+// password reset and email verification tokens, and whether accounts must
+// be verified to sign in. Call it before serving.
+func (s *Server) ConfigureTokens(cfg config.AuthConfig) {
+	s.signer = jwt.NewHS256([]byte(cfg.TokenSecret), cfg.TokenIssuer, cfg.TokenTTL, jwt.WithClock(s.now))
+	s.refreshTTL = cfg.RefreshTTL
+	s.resets.setTTL(cfg.ResetTTL)
+	s.verifications.setTTL(cfg.VerifyTTL)
+	s.requireVerified = cfg.RequireVerified
+}
+
+type session struct {
+	userID  string
+	expires time.Time
+}
+
+// Register creates a regular user account, unverified until the link mailed
+// to its address is followed.
+func (s *Server) Register(c *gin.Context) {
+	u, ok := s.register(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"id":       u.ID,
+		"username": u.Username,
+		"email":    u.Email,
+		"role":     u.Role,
+		"verified": u.Verified,
+	})
+}
+
+// register creates the account a registration asks for, or answers why it
+// can't and returns false.
+func (s *Server) register(c *gin.Context) (User, bool) {
+	var raw RegisterRequest
+	if err := c.ShouldBindJSON(&raw); err != nil {
+		middleware.BindError(c, err)
+		return User{}, false
+	}
+	hash, err := passhash.Hash(raw.Password)
+	if err != nil {
+		middleware.Fail(c, err)
+		return User{}, false
+	}
+	u, err := s.repo.Create(c.Request.Context(), User{
+		Username:  raw.Username,
+		Email:     raw.Email,
+		Role:      "user",
+		Password:  hash,
+		CreatedAt: s.now().UTC(),
+	})
+	if errors.Is(err, errUsernameTaken) {
+		audit.Record(c, audit.Event{Action: audit.Register, Outcome: audit.Failure, Actor: raw.Username,
+			Details: map[string]string{"reason": "username taken"}})
+		middleware.Error(c, http.StatusBadRequest, "username already exists")
+		return User{}, false
+	}
+	if err != nil {
+		middleware.Fail(c, err)
+		return User{}, false
+	}
+	audit.Record(c, audit.Event{Action: audit.Register, Outcome: audit.Success, ActorID: u.ID, Actor: u.Username})
+	s.sendVerification(c.Request.Context(), u)
+
+	err = events.Publish(c.Request.Context(), events.Default, events.UserRegistered,
+		events.UserRegisteredEvent{UserID: u.ID, Username: u.Username, Email: u.Email})
+	if err != nil {
+		c.Error(err)
+	}
+	publishCreated(c, u, "register")
+	return u, true
+}
+
+// publishCreated announces a new account on events.UserCreated. A failed
+// publish is recorded on the request but doesn't undo the account.
+func publishCreated(c *gin.Context, u User, source string) {
+	err := events.Publish(c.Request.Context(), events.Default, events.UserCreated,
+		events.UserCreatedEvent{UserID: u.ID, Username: u.Username, Role: u.Role, Source: source})
+	if err != nil {
+		c.Error(err)
+	}
+}
+
+// Login issues a signed JWT and a refresh token for a valid
+// username/password.
+func (s *Server) Login(c *gin.Context) {
+	var req LoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.BindError(c, err)
+		return
+	}
+	u, err := s.Authenticate(c, req.Username, req.Password)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	// each login starts its own family of refresh tokens
+	s.issueTokens(c, u, rand.Text())
+}
+
+// Authenticate checks a username and password as POST /api/login does,
+// recording the attempt, and returns the user they belong to. Wrong
+// credentials are a 401 and a disabled or, with auth.require_verified,
+// unverified account a 403, as *apperror.Error. loginguard.Default may
+// refuse the attempt first, with a 429 or a 401 captcha_required. Examples
+// with their own sign-in, like the web admin pages, issue their own session
+// for the user.
+func (s *Server) Authenticate(c *gin.Context, username, password string) (User, error) {
+	if err := loginguard.Default.Check(c, username); err != nil {
+		return User{}, err
+	}
+	u, err := s.repo.GetByUsername(c.Request.Context(), username)
+	if err != nil && !errors.Is(err, ErrUserNotFound) {
+		loginguard.Default.Abandoned(c, username)
+		return User{}, err
+	}
+	if err != nil || u.Deleted() {
+		passhash.VerifyNone(password)
+		loginguard.Default.Failed(c, username)
+		loginFailed(c, User{Username: username}, "unknown user")
+		return User{}, apperror.Unauthorized("invalid credentials")
+	}
+	match, rehash := passhash.Verify(u.Password, password)
+	if !match {
+		loginguard.Default.Failed(c, username)
+		loginFailed(c, u, "wrong password")
+		return User{}, apperror.Unauthorized("invalid credentials")
+	}
+	loginguard.Default.Succeeded(c, username)
+	if rehash {
+		s.upgradePassword(c.Request.Context(), u.ID, u.Password, password)
+	}
+	// only after the password, so this doesn't reveal which accounts exist
+	if u.Disabled {
+		loginFailed(c, u, "account disabled")
+		return User{}, apperror.Forbidden("account disabled")
+	}
+	if s.requireVerified && !u.Verified {
+		loginFailed(c, u, "email address not verified")
+		return User{}, apperror.Forbidden("email address not verified")
+	}
+
+	audit.Record(c, audit.Event{Action: audit.Login, Outcome: audit.Success, ActorID: u.ID, Actor: u.Username})
+	metrics.Login(true)
+	return u, nil
+}
+
+// loginFailed records a refused sign-in as u, which has only a username when
+// there's no such user.
+func loginFailed(c *gin.Context, u User, reason string) {
+	audit.Record(c, audit.Event{Action: audit.Login, Outcome: audit.Failure, ActorID: u.ID, Actor: u.Username,
+		Details: map[string]string{"reason": reason}})
+	metrics.Login(false)
+}
+
+// recordAdmin records a change an admin made to the user with id.
+func recordAdmin(c *gin.Context, action, id string, details map[string]string) {
+	admin := MustUser(c)
+	audit.Record(c, audit.Event{Action: action, Outcome: audit.Success, Actor: admin.Username, Target: id, Details: details})
+}
+
+// LookupToken checks a bearer token's signature, issuer and expiry and
+// resolves it to the stored user. The user is loaded rather than rebuilt
+// from the claims, so a deleted or disabled account, a changed role or, with
+// auth.require_verified, a changed email address takes effect before the
+// token expires.
+func (s *Server) LookupToken(token string) (any, error) {
+	return middleware.JWT(s.signer, s.claimsUser)(token)
+}
+
+// claimsUser is the user of verified claims, unless they were revoked.
+func (s *Server) claimsUser(claims jwt.Claims) (any, error) {
+	s.tokensMu.Lock()
+	cutoff, reset := s.signedOut[claims.Subject]
+	_, loggedOut := s.revoked[claims.ID]
+	s.tokensMu.Unlock()
+	if loggedOut {
+		return nil, errors.New("invalid token")
+	}
+	// iat has whole seconds, so a token issued in the same second as the
+	// reset survives it
+	if reset && claims.Issued().Before(cutoff.Truncate(time.Second)) {
+		return nil, errors.New("invalid token")
+	}
+
+	return s.activeUser(claims.Subject)
+}
+
+// activeUser loads the user a credential belongs to, failing when the
+// account can't be used.
+func (s *Server) activeUser(id string) (User, error) {
+	user, err := s.repo.Get(context.Background(), id)
+	if err != nil && !errors.Is(err, ErrUserNotFound) {
+		// the message goes to the client, so it doesn't carry err
+		slog.Error("load user", "user_id", id, "error", err)
+		return User{}, errors.New("user lookup failed")
+	}
+	if err != nil || user.Deleted() {
+		return User{}, errors.New("user not found")
+	}
+	if user.Disabled {
+		return User{}, errors.New("account disabled")
+	}
+	if s.requireVerified && !user.Verified {
+		return User{}, errors.New("email address not verified")
+	}
+	return user, nil
+}
+
+// ---- Handlers ----
+
+// CurrentUser is the user Auth(LookupToken) let in, by token or API key. ok
+// is false without one, as on a public route.
+func CurrentUser(c *gin.Context) (User, bool) {
+	return middleware.Principal[User](c)
+}
+
+// MustUser is CurrentUser for handlers behind Auth(LookupToken), which
+// always have a user. It panics, a 500, when they are mounted without it.
+func MustUser(c *gin.Context) User {
+	u, ok := CurrentUser(c)
+	if !ok {
+		panic("users: no User in the context; mount the handler behind Auth(LookupToken)")
+	}
+	return u
+}
+
+func getProfile(c *gin.Context) {
+	u := MustUser(c)
+	// hide password
+	c.JSON(http.StatusOK, gin.H{
+		"id":         u.ID,
+		"username":   u.Username,
+		"email":      u.Email,
+		"role":       u.Role,
+		"verified":   u.Verified,
+		"avatar_url": avatarURL(u),
+		// which features the client should show this user
+		"flags": featureflags.Default.States(c),
+	})
+}
+
+// updateProfile changes the email address. A new address has to be verified
+// again.
+func (s *Server) updateProfile(c *gin.Context) {
+	u := MustUser(c)
+	var req struct {
+		Email string `json:"email" binding:"omitempty,email"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.BindError(c, err)
+		return
+	}
+	if _, err := s.changeEmail(c, u, req.Email); err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "updated"})
+}
+
+// changeEmail gives u the address email, unless it is empty or already
+// theirs, and mails a link to verify it. It returns u as stored.
+func (s *Server) changeEmail(c *gin.Context, u User, email string) (User, error) {
+	if email == "" || email == u.Email {
+		return u, nil
+	}
+	stored, err := s.UpdateEmail(c.Request.Context(), u.ID, email)
+	if err != nil {
+		return User{}, err
+	}
+	s.sendVerification(c.Request.Context(), stored)
+	return stored, nil
+}
+
+// adminListUsers pages through the users matching ?role= and ?q=, ordered by
+// ?sort=username|email|created_at, with a leading "-" for descending.
+func (s *Server) adminListUsers(c *gin.Context) {
+	var f userFilters
+	req, err := query.Parse(c, userSorts, "username", &f)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	list, err := s.listUsers(c.Request.Context(), f, req.Sort)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	out := []gin.H{}
+	for _, u := range list {
+		out = append(out, adminView(u))
+	}
+	pagination.Write(c, pagination.NewPage(out, req.Page))
+}
+
+// adminUpdateUser changes a user's role, email address or whether the
+// account is enabled; fields left out stay as they are. A new address has to
+// be verified again, and disabling an account signs out all its sessions.
+// Tokens carry the role in their claims, but LookupToken loads the stored
+// user, so every change applies to the user's next request.
+func (s *Server) adminUpdateUser(c *gin.Context) {
+	var req struct {
+		Role    *string `json:"role"`
+		Email   *string `json:"email" binding:"omitempty,email"`
+		Enabled *bool   `json:"enabled"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.BindError(c, err)
+		return
+	}
+	if req.Role != nil {
+		if _, ok := rbac.Default.Get(*req.Role); !ok {
+			middleware.Fail(c, apperror.Validation("unknown role", map[string]string{"role": "is not a role in /admin/roles"}))
+			return
+		}
+	}
+	id := c.Param("id")
+	// an admin locking themselves out would need a restart to undo
+	if self := MustUser(c); id == self.ID &&
+		(req.Role != nil && *req.Role != self.Role || req.Enabled != nil && !*req.Enabled) {
+		middleware.Fail(c, apperror.Conflict("you can't change your own role or disable your own account"))
+		return
+	}
+
+	u, err := s.repo.Update(c.Request.Context(), id, func(u *User) error {
+		if u.Deleted() {
+			return ErrUserNotFound
+		}
+		if req.Role != nil {
+			u.Role = *req.Role
+		}
+		if req.Enabled != nil {
+			u.Disabled = !*req.Enabled
+		}
+		return nil
+	})
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	if u.Disabled {
+		s.tokensMu.Lock()
+		s.revokeUserLocked(id)
+		s.tokensMu.Unlock()
+	}
+	changed := map[string]string{}
+	if req.Role != nil {
+		changed["role"] = *req.Role
+	}
+	if req.Enabled != nil {
+		changed["enabled"] = strconv.FormatBool(*req.Enabled)
+	}
+	if req.Email != nil && *req.Email != u.Email {
+		changed["email"] = *req.Email
+		if u, err = s.UpdateEmail(c.Request.Context(), id, *req.Email); err != nil {
+			middleware.Fail(c, err)
+			return
+		}
+		s.sendVerification(c.Request.Context(), u)
+	}
+	recordAdmin(c, audit.UserUpdate, id, changed)
+	c.JSON(http.StatusOK, adminView(u))
+}
+
+// adminSearchUsers pages through the users whose username or email matches
+// ?q=, ignoring case, best match first (see searchRank). ?match=prefix only
+// matches the start of either; the default, substring, matches any part.
+// ?fields= picks the fields of each user, e.g. fields=id,username.
+func (s *Server) adminSearchUsers(c *gin.Context) {
+	// ranked, so there's no ?sort=
+	f := struct {
+		Term   string   `form:"q" binding:"required"`
+		Match  string   `form:"match" binding:"oneof=prefix substring"`
+		Fields []string `form:"fields"`
+	}{Match: matchSubstring}
+	req, err := query.Parse[User](c, nil, "", &f)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	term, fields := strings.TrimSpace(f.Term), f.Fields
+	details := map[string]string{}
+	if term == "" {
+		details["q"] = "is required"
+	}
+	for _, name := range fields {
+		if _, ok := adminView(User{})[name]; !ok {
+			details["fields"] = "must be a comma-separated list of id, username, email, role, verified, enabled and created_at"
+		}
+	}
+	if len(details) > 0 {
+		middleware.Fail(c, apperror.Validation("invalid query parameters", details))
+		return
+	}
+
+	found, err := s.searchUsers(c.Request.Context(), term, f.Match)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	out := []gin.H{}
+	for _, u := range found {
+		view := adminView(u)
+		if fields != nil {
+			view = pick(view, fields)
+		}
+		out = append(out, view)
+	}
+	pagination.Write(c, pagination.NewPage(out, req.Page))
+}
+
+// pick returns the named fields of view.
+func pick(view gin.H, fields []string) gin.H {
+	out := gin.H{}
+	for _, f := range fields {
+		out[f] = view[f]
+	}
+	return out
+}
+
+// adminView is how the admin endpoints show a user.
+func adminView(u User) gin.H {
+	view := gin.H{
+		"id":         u.ID,
+		"username":   u.Username,
+		"email":      u.Email,
+		"role":       u.Role,
+		"verified":   u.Verified,
+		"enabled":    !u.Disabled,
+		"created_at": u.CreatedAt,
+	}
+	if u.Deleted() {
+		view["deleted_at"] = u.DeletedAt
+	}
+	return view
+}
+
+// adminDeleteUser marks a user deleted: they drop out of listings and can't
+// sign in, and their sessions and emailed links stop working. Everything
+// tied to their ID stays, so POST .../restore can bring them back.
+func (s *Server) adminDeleteUser(c *gin.Context) {
+	id := c.Param("id")
+	if id == MustUser(c).ID {
+		middleware.Fail(c, apperror.Conflict("you can't delete your own account"))
+		return
+	}
+	_, err := s.repo.Update(c.Request.Context(), id, func(u *User) error {
+		if u.Deleted() {
+			return ErrUserNotFound
+		}
+		u.DeletedAt = s.now().UTC()
+		return nil
+	})
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	s.tokensMu.Lock()
+	s.revokeUserLocked(id)
+	s.tokensMu.Unlock()
+	s.resets.drop(id)
+	s.verifications.drop(id)
+	recordAdmin(c, audit.UserDelete, id, nil)
+	c.Status(http.StatusNoContent)
+}
+
+// adminRestoreUser undoes adminDeleteUser. The user signs in again from
+// scratch.
+func (s *Server) adminRestoreUser(c *gin.Context) {
+	id := c.Param("id")
+	u, err := s.repo.Update(c.Request.Context(), id, func(u *User) error {
+		if !u.Deleted() {
+			return apperror.Conflict("user is not deleted")
+		}
+		u.DeletedAt = time.Time{}
+		return nil
+	})
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	recordAdmin(c, audit.UserRestore, id, nil)
+	c.JSON(http.StatusOK, adminView(u))
+}
+
+// adminPurgeUser removes a deleted user for good. Data other examples keep
+// under the ID is left as it is.
+func (s *Server) adminPurgeUser(c *gin.Context) {
+	id := c.Param("id")
+	u, err := s.repo.Get(c.Request.Context(), id)
+	if err == nil && !u.Deleted() {
+		err = apperror.Conflict("only deleted users can be purged")
+	}
+	if err == nil {
+		err = s.repo.Delete(c.Request.Context(), id)
+	}
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	if u.Avatar != "" {
+		s.removeAvatar(c, u.Avatar)
+	}
+	recordAdmin(c, audit.UserPurge, id, map[string]string{"username": u.Username})
+	c.Status(http.StatusNoContent)
+}
+
+// NewAccounts returns a Server set up from deps.Config: users kept where
+// users.store says, with the admin account seeded, tokens as auth says,
+// mail queued on a worker pool and avatars under storage.upload_dir, timed
+// by deps' clock. Shutdown waits for the mail still being queued.
+func NewAccounts(deps server.Deps) *Server {
+	cfg, hooks := deps.Config, deps.Hooks
+	repo := newRepository(cfg, hooks)
+	mailer := mail.New(cfg.Mail)
+	// stopped after the mail still being handed to it, below
+	mailPool := jobs.NewPool(cfg, hooks)
+	mailer.UseQueue(mailPool)
+	mailPool.Start()
+	s := NewServer(Options{
+		Repository:     repo,
+		Auth:           cfg.Auth,
+		Mailer:         mailer,
+		BaseURL:        cfg.Mail.BaseURL,
+		MaxAvatarBytes: cfg.Storage.MaxAvatarBytes,
+		UploadDir:      cfg.Storage.UploadDir,
+		Now:            deps.Now,
+	})
+	s.SeedAdmin(cfg.Auth.AdminPassword)
+	// mail still being queued is queued before the stores close
+	hooks.Add(s.background.Wait)
+	return s
+}
+
+// Deps are what NewRouter builds the example from.
+type Deps struct {
+	server.Deps
+	// the users served; NewAccounts' when nil
+	Users *Server
+}
+
+// NewRouter builds the users API example router, serving deps.Users.
+func NewRouter(deps Deps) *gin.Engine {
+	cfg, hooks := deps.Config, deps.Hooks
+	s := deps.Users
+	if s == nil {
+		s = NewAccounts(deps.Deps)
+	}
+	deps.Tasks().Register("token_janitor", 10*time.Minute, func(ctx context.Context) error {
+		_, err := s.PurgeExpiredTokens(ctx, s.now())
+		return err
+	})
+
+	// structured logging and recovery
+	router := server.NewEngine(deps.Deps)
+	router.MaxMultipartMemory = cfg.Storage.MaxMultipartMemory
+
+	if _, err := events.Subscribe(events.Default, events.UserRegistered, s.sendWelcome); err != nil {
+		panic(fmt.Sprintf("subscribe: %v", err))
+	}
+	deps.APIDocs().Add(docs...)
+	s.Routes(router, cfg, hooks)
+
+	// make sure uploads dir exists for potential file endpoints
+	_ = os.MkdirAll(cfg.Storage.UploadDir, 0755)
+
+	return router
+}
+
+// Routes mounts s's API on router under /api, /api/v1 and /api/v2, with the
+// limits cfg sets. The API key limiter stops with hooks.
+func (s *Server) Routes(router gin.IRouter, cfg *config.Config, hooks *server.Hooks) {
+	// a retried registration must not create the account twice
+	idem := idempotency.Middleware(idempotency.WithTTL(cfg.Idempotency.TTL))
+
+	// scripts and services send an X-API-Key instead of signing in, each key
+	// with its own budget
+	keyLimiter := middleware.NewRateLimiter(cfg.RateLimit.APIKeyRequestsPerMinute,
+		middleware.WithName("users:api_key"),
+		middleware.WithOnLimitExceeded(server.LogLimitExceeded),
+		middleware.WithAlgorithm(middleware.Algorithm(cfg.RateLimit.Algorithm)),
+		middleware.WithCosts(cfg.RateLimit.Costs),
+		middleware.WithIPFilter(ipfilter.Default))
+	keyLimiter.StartJanitor(5 * time.Minute)
+	hooks.Add(keyLimiter.Stop)
+	auth := middleware.Auth(s.LookupToken, middleware.WithAPIKey(s.LookupAPIKey, keyLimiter),
+		middleware.WithAuthError(middleware.BearerChallenge))
+
+	// the API is served as /api/v1 and /api/v2, and as /api for the version
+	// Accept asks for, v1 by default; v2 changed the shape of a profile
+	apiversion.Mount(router, "/api", 1, []int{1, 2}, func(api *gin.RouterGroup) {
+		// Public
+		public := api.Group("")
+		{
+			public.POST("/register", idem, apiversion.Handlers(map[int]gin.HandlerFunc{1: s.Register, 2: s.registerV2}))
+			public.POST("/login", s.Login)
+			public.POST("/refresh", s.refresh)
+			public.POST("/password/forgot", s.forgotPassword)
+			public.POST("/password/reset", s.resetPassword)
+			public.GET("/verify", s.verifyEmail)
+			public.GET("/avatars/:name", s.getAvatar)
+			public.POST("/verify/resend", s.resendVerification)
+		}
+
+		// Authenticated
+		private := api.Group("")
+		private.Use(auth)
+		{
+			private.GET("/profile", apiversion.Handlers(map[int]gin.HandlerFunc{1: getProfile, 2: getProfileV2}))
+			private.PUT("/profile", apiversion.Handlers(map[int]gin.HandlerFunc{1: s.updateProfile, 2: s.updateProfileV2}))
+			private.PUT("/profile/password", s.changePassword)
+			private.POST("/profile/avatar", middleware.BodyLimit(cfg.Storage.MaxUploadBytes), s.uploadAvatar)
+			private.POST("/logout", s.logout)
+			private.GET("/sessions", s.listSessions)
+			private.DELETE("/sessions", s.revokeOtherSessions)
+			private.DELETE("/sessions/:id", s.revokeSession)
+			private.GET("/keys", s.listAPIKeys)
+			private.POST("/keys", s.createAPIKey)
+			private.DELETE("/keys/:id", s.revokeAPIKey)
+		}
+
+		// Admin
+		adminRoutes := api.Group("/admin")
+		adminRoutes.Use(auth)
+		{
+			adminRoutes.GET("/users", middleware.RequirePermission(rbac.UsersRead), s.adminListUsers)
+			adminRoutes.GET("/users/search", middleware.RequirePermission(rbac.UsersRead), s.adminSearchUsers)
+			adminRoutes.GET("/users/export", middleware.RequirePermission(rbac.UsersRead), s.exportUsers)
+			adminRoutes.POST("/users/import", middleware.RequirePermission(rbac.UsersWrite),
+				middleware.BodyLimit(cfg.Storage.MaxUploadBytes), s.importUsers)
+			adminRoutes.PUT("/users/:id", middleware.RequirePermission(rbac.UsersWrite), s.adminUpdateUser)
+			adminRoutes.DELETE("/users/:id", middleware.RequirePermission(rbac.UsersDelete), s.adminDeleteUser)
+			adminRoutes.POST("/users/:id/restore", middleware.RequirePermission(rbac.UsersDelete), s.adminRestoreUser)
+			adminRoutes.DELETE("/users/:id/purge", middleware.RequirePermission(rbac.UsersDelete), s.adminPurgeUser)
+			adminRoutes.GET("/audit", middleware.RequirePermission(rbac.AuditRead), audit.Handler())
+		}
+	})
+}
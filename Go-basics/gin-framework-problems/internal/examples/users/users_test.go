@@ -0,0 +1,246 @@
+package users
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/config"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/testutil"
+)
+
+// newRouter builds the example on a Server of its own, configured by cfg
+// (testutil.Config when nil), and returns that Server too.
+func newRouter(t testing.TB, cfg *config.Config) (*gin.Engine, *Server) {
+	t.Helper()
+	deps := testutil.Deps(t, cfg)
+	s := NewAccounts(deps)
+	return NewRouter(Deps{Deps: deps, Users: s}), s
+}
+
+// issuedTokens is what login and refresh answer with.
+type issuedTokens struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+func TestRegister(t *testing.T) {
+	router, _ := newRouter(t, nil)
+	testutil.RegisterUser(t, router, "taken")
+	tests := []struct {
+		name   string
+		body   any
+		status int
+	}{
+		{"ok", gin.H{"username": "carol", "email": "carol@example.com", "password": "password123"}, http.StatusCreated},
+		{"username taken", gin.H{"username": "taken", "email": "other@example.com", "password": "password123"}, http.StatusBadRequest},
+		{"short username", gin.H{"username": "ab", "email": "ab@example.com", "password": "password123"}, http.StatusBadRequest},
+		{"bad email", gin.H{"username": "dave", "email": "dave", "password": "password123"}, http.StatusBadRequest},
+		{"missing fields", gin.H{}, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := testutil.DoJSON(t, router, http.MethodPost, "/api/register", tt.body)
+			testutil.AssertStatus(t, w, tt.status)
+		})
+	}
+}
+
+func TestRegisteredProfile(t *testing.T) {
+	router, _ := newRouter(t, nil)
+	token := testutil.RegisterUser(t, router, "frank")
+	w := testutil.Do(t, router, http.MethodGet, "/api/profile", nil, testutil.WithToken(token))
+	testutil.AssertJSON(t, w, http.StatusOK, gin.H{
+		"username":   "frank",
+		"email":      "frank@example.com",
+		"role":       "user",
+		"verified":   false,
+		"avatar_url": "",
+		// on by default, see config.Default
+		"flags": gin.H{"books_v2": true},
+	}, "id")
+
+	w = testutil.DoJSON(t, router, http.MethodPost, "/api/login", LoginRequest{Username: "frank", Password: "password124"})
+	testutil.AssertStatus(t, w, http.StatusUnauthorized)
+}
+
+func TestAPIVersions(t *testing.T) {
+	router, _ := newRouter(t, nil)
+	token := testutil.RegisterUser(t, router, "grace")
+
+	tests := []struct {
+		name, path, accept string
+		status             int
+		version            string
+		v2                 bool // the email is an object
+	}{
+		{"default", "/api/profile", "", http.StatusOK, "1", false},
+		{"v1 path", "/api/v1/profile", "", http.StatusOK, "1", false},
+		{"v2 path", "/api/v2/profile", "", http.StatusOK, "2", true},
+		{"vendor type", "/api/profile", "application/vnd.hub.v2+json", http.StatusOK, "2", true},
+		{"version parameter", "/api/profile", "application/json; version=2", http.StatusOK, "2", true},
+		// the path wins
+		{"path over Accept", "/api/v1/profile", "application/vnd.hub.v2+json", http.StatusOK, "1", false},
+		{"unknown version", "/api/profile", "application/vnd.hub.v9+json", http.StatusNotAcceptable, "", false},
+		{"unknown path version", "/api/v9/profile", "", http.StatusNotFound, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := []testutil.RequestOption{testutil.WithToken(token)}
+			if tt.accept != "" {
+				opts = append(opts, testutil.WithHeader("Accept", tt.accept))
+			}
+			w := testutil.Do(t, router, http.MethodGet, tt.path, nil, opts...)
+			testutil.AssertStatus(t, w, tt.status)
+			if got := w.Header().Get("API-Version"); got != tt.version {
+				t.Errorf("API-Version = %q, want %q", got, tt.version)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			body := testutil.Decode[map[string]any](t, w)
+			if _, v2 := body["email"].(map[string]any); v2 != tt.v2 {
+				t.Errorf("email = %v, want the v2 shape: %v", body["email"], tt.v2)
+			}
+		})
+	}
+
+	w := testutil.DoJSON(t, router, http.MethodPut, "/api/v2/profile",
+		gin.H{"email": gin.H{"address": "grace@example.org"}}, testutil.WithToken(token))
+	testutil.AssertStatus(t, w, http.StatusOK)
+	if got := testutil.Decode[ProfileV2](t, w).Email; got != (EmailV2{Address: "grace@example.org"}) {
+		t.Errorf("email after the update = %+v", got)
+	}
+}
+
+func TestAdminRoutes(t *testing.T) {
+	cfg := testutil.Config(t)
+	router, _ := newRouter(t, cfg)
+	admin := testutil.Login(t, router, "/api/login", "admin", cfg.Auth.AdminPassword)
+	user := testutil.RegisterUser(t, router, "mallory")
+
+	tests := []struct {
+		name   string
+		opts   []testutil.RequestOption
+		status int
+	}{
+		{"admin", []testutil.RequestOption{testutil.WithToken(admin)}, http.StatusOK},
+		{"user", []testutil.RequestOption{testutil.WithToken(user)}, http.StatusForbidden},
+		{"anonymous", nil, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := testutil.Do(t, router, http.MethodGet, "/api/admin/users", nil, tt.opts...)
+			testutil.AssertStatus(t, w, tt.status)
+		})
+	}
+}
+
+// TestTokenFlow follows a user's tokens from login through refreshes, a
+// stolen refresh token coming back, and logout.
+func TestTokenFlow(t *testing.T) {
+	router, _ := newRouter(t, nil)
+	testutil.RegisterUser(t, router, "heidi")
+	login := func() issuedTokens {
+		t.Helper()
+		w := testutil.DoJSON(t, router, http.MethodPost, "/api/login", LoginRequest{Username: "heidi", Password: "password123"})
+		testutil.AssertStatus(t, w, http.StatusOK)
+		return testutil.Decode[issuedTokens](t, w)
+	}
+	refresh := func(token string) *httptest.ResponseRecorder {
+		return testutil.DoJSON(t, router, http.MethodPost, "/api/refresh", gin.H{"refresh_token": token})
+	}
+	profile := func(token string) int {
+		return testutil.Do(t, router, http.MethodGet, "/api/profile", nil, testutil.WithToken(token)).Code
+	}
+
+	first := login()
+	w := refresh(first.RefreshToken)
+	testutil.AssertStatus(t, w, http.StatusOK)
+	second := testutil.Decode[issuedTokens](t, w)
+	if second.RefreshToken == first.RefreshToken {
+		t.Fatal("refresh answered with the same refresh token")
+	}
+	if got := profile(second.Token); got != http.StatusOK {
+		t.Fatalf("profile with the refreshed token = %d", got)
+	}
+
+	// the first refresh token coming back means it was copied: the login's
+	// tokens all stop working, the newest too
+	testutil.AssertStatus(t, refresh(first.RefreshToken), http.StatusUnauthorized)
+	testutil.AssertStatus(t, refresh(second.RefreshToken), http.StatusUnauthorized)
+	if got := profile(second.Token); got != http.StatusUnauthorized {
+		t.Errorf("profile after the reuse = %d, want 401", got)
+	}
+
+	// another login is unaffected by the first's revocation until it logs out
+	third := login()
+	other := login()
+	if got := profile(third.Token); got != http.StatusOK {
+		t.Fatalf("profile after logging in again = %d", got)
+	}
+	testutil.AssertStatus(t, testutil.Do(t, router, http.MethodPost, "/api/logout", nil, testutil.WithToken(third.Token)), http.StatusNoContent)
+	tests := []struct {
+		name string
+		code int
+		want int
+	}{
+		{"logged out access token", profile(third.Token), http.StatusUnauthorized},
+		{"logged out refresh token", refresh(third.RefreshToken).Code, http.StatusUnauthorized},
+		{"other login's access token", profile(other.Token), http.StatusOK},
+		{"other login's refresh token", refresh(other.RefreshToken).Code, http.StatusOK},
+	}
+	for _, tt := range tests {
+		if tt.code != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, tt.code, tt.want)
+		}
+	}
+}
+
+// TestTokenClock checks that access tokens expire by the deps' clock.
+func TestTokenClock(t *testing.T) {
+	deps := testutil.Deps(t, nil)
+	clock := testutil.NewClock(time.Now())
+	deps.Clock = clock
+	router := NewRouter(Deps{Deps: deps})
+	token := testutil.RegisterUser(t, router, "judy")
+	profile := func() int {
+		return testutil.Do(t, router, http.MethodGet, "/api/profile", nil, testutil.WithToken(token)).Code
+	}
+	if got := profile(); got != http.StatusOK {
+		t.Fatalf("profile = %d", got)
+	}
+	clock.Advance(deps.Config.Auth.TokenTTL + time.Minute)
+	if got := profile(); got != http.StatusUnauthorized {
+		t.Errorf("profile after auth.token_ttl = %d, want 401", got)
+	}
+}
+
+// TestServers checks that each Server has its own users.
+func TestServers(t *testing.T) {
+	a, _ := newRouter(t, nil)
+	b, _ := newRouter(t, nil)
+	token := testutil.RegisterUser(t, a, "ivan")
+
+	w := testutil.DoJSON(t, b, http.MethodPost, "/api/login", LoginRequest{Username: "ivan", Password: "password123"})
+	testutil.AssertStatus(t, w, http.StatusUnauthorized)
+	testutil.AssertStatus(t, testutil.Do(t, b, http.MethodGet, "/api/profile", nil, testutil.WithToken(token)), http.StatusUnauthorized)
+	// the name is free there
+	testutil.RegisterUser(t, b, "ivan")
+}
+
+// BenchmarkLookupToken resolves a signed-in user's access token, which every
+// authenticated request does.
+func BenchmarkLookupToken(b *testing.B) {
+	router, s := newRouter(b, nil)
+	token := testutil.RegisterUser(b, router, "bench")
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := s.LookupToken(token); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
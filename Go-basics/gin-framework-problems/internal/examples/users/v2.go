@@ -0,0 +1,79 @@
+package users
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/featureflags"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+)
+
+// ProfileV2 is the v2 shape of a user, from /api/v2 or with Accept:
+// application/vnd.hub.v2+json. The address and whether it is verified go
+// together, the account's age is shown, and the avatar is one of its links.
+// Registering and updating the profile answer with it too; only GET has
+// the flags, each feature flag on or off for the user.
+type ProfileV2 struct {
+	ID        string            `json:"id"`
+	Username  string            `json:"username"`
+	Role      string            `json:"role"`
+	Email     EmailV2           `json:"email"`
+	CreatedAt time.Time         `json:"created_at"`
+	Links     map[string]string `json:"links"`
+	Flags     map[string]bool   `json:"flags,omitempty"`
+}
+
+// EmailV2 is an address and whether its owner confirmed it.
+type EmailV2 struct {
+	Address  string `json:"address"`
+	Verified bool   `json:"verified"`
+}
+
+func toV2(u User) ProfileV2 {
+	links := map[string]string{"self": "/api/v2/profile"}
+	if url := avatarURL(u); url != "" {
+		links["avatar"] = url
+	}
+	return ProfileV2{
+		ID:        u.ID,
+		Username:  u.Username,
+		Role:      u.Role,
+		Email:     EmailV2{Address: u.Email, Verified: u.Verified},
+		CreatedAt: u.CreatedAt,
+		Links:     links,
+	}
+}
+
+func (s *Server) registerV2(c *gin.Context) {
+	if u, ok := s.register(c); ok {
+		c.JSON(http.StatusCreated, toV2(u))
+	}
+}
+
+func getProfileV2(c *gin.Context) {
+	p := toV2(MustUser(c))
+	p.Flags = featureflags.Default.States(c)
+	c.JSON(http.StatusOK, p)
+}
+
+// updateProfileV2 takes the address as the profile has it, {"email":
+// {"address": ...}}, and answers with the updated profile.
+func (s *Server) updateProfileV2(c *gin.Context) {
+	var req struct {
+		Email struct {
+			Address string `json:"address" binding:"omitempty,email"`
+		} `json:"email"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.BindError(c, err)
+		return
+	}
+	u, err := s.changeEmail(c, MustUser(c), req.Email.Address)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, toV2(u))
+}
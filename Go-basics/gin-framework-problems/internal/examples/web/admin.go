@@ -0,0 +1,211 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/apperror"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/config"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/books"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/files"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/users"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/i18n"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/loginguard"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/pagination"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/query"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/rbac"
+)
+
+type loginForm struct {
+	Username string `form:"username" binding:"required"`
+	Password string `form:"password" binding:"required"`
+}
+
+// admin serves the /admin pages: users, books and uploads, for accounts
+// with users:read. Deleting books takes books:manage and browsing uploads
+// files:manage as well, as in the JSON APIs.
+type admin struct {
+	sessions *sessions
+	captcha  *loginguard.Widget // nil without a CAPTCHA to show
+	accounts *users.Server
+	catalog  *books.Server
+	uploads  *files.Server
+}
+
+// captchaWidget is the CAPTCHA the sign-in page shows when the login guard
+// challenges, or nil when it never does or there is no site key.
+func captchaWidget(cfg *config.Config) *loginguard.Widget {
+	guard := cfg.Auth.LoginGuard
+	if guard.CaptchaVerifyURL == "" || guard.CaptchaSiteKey == "" {
+		return nil
+	}
+	w := loginguard.NewWidget(guard.CaptchaVerifyURL, guard.CaptchaSiteKey)
+	return &w
+}
+
+func (a admin) loginPage(c *gin.Context) {
+	if _, ok := a.sessions.user(c); ok {
+		c.Redirect(http.StatusFound, nextPage(c.Query("next")))
+		return
+	}
+	a.renderLogin(c, http.StatusOK, loginForm{}, "", false)
+}
+
+// renderLogin shows the sign-in form, with the CAPTCHA when challenged.
+func (a admin) renderLogin(c *gin.Context, status int, form loginForm, msg string, challenged bool) {
+	var captcha *loginguard.Widget
+	if challenged {
+		captcha = a.captcha
+	}
+	c.HTML(status, "admin/login", gin.H{
+		"Form":      form,
+		"Error":     msg,
+		"Next":      c.Query("next"),
+		"Flash":     takeFlash(c),
+		"CSRFToken": middleware.CSRFToken(c),
+		"Captcha":   captcha,
+	})
+}
+
+func (a admin) login(c *gin.Context) {
+	var form loginForm
+	if err := c.ShouldBind(&form); err != nil {
+		a.renderLogin(c, http.StatusUnprocessableEntity, form, i18n.Localize(c, "invalid credentials", nil), false)
+		return
+	}
+	u, err := a.accounts.Authenticate(c, form.Username, form.Password)
+	if err != nil {
+		e := apperror.From(err)
+		if e.Status >= http.StatusInternalServerError {
+			middleware.Fail(c, err)
+			return
+		}
+		a.renderLogin(c, e.Status, loginForm{Username: form.Username}, i18n.Localize(c, e.Message, nil),
+			e.Code == apperror.CodeCaptchaRequired)
+		return
+	}
+	if !u.Can(rbac.UsersRead) {
+		a.renderLogin(c, http.StatusForbidden, loginForm{Username: form.Username},
+			i18n.Localize(c, "this account can't use the admin pages", nil), false)
+		return
+	}
+	a.sessions.start(c, u)
+	c.Redirect(http.StatusSeeOther, nextPage(c.Query("next")))
+}
+
+func (a admin) logout(c *gin.Context) {
+	a.sessions.end(c)
+	setFlash(c, "Signed out.")
+	c.Redirect(http.StatusSeeOther, "/admin/login")
+}
+
+// page renders an admin page with the signed-in admin and any flash
+// message.
+func (a admin) page(c *gin.Context, name string, data gin.H) {
+	data["Admin"] = currentAdmin(c)
+	data["Flash"] = takeFlash(c)
+	data["CSRFToken"] = middleware.CSRFToken(c)
+	c.HTML(http.StatusOK, name, data)
+}
+
+// allowed renders the forbidden page unless the admin has permission.
+func (a admin) allowed(c *gin.Context, permission string) bool {
+	u := currentAdmin(c)
+	if u.Can(permission) {
+		return true
+	}
+	c.HTML(http.StatusForbidden, "admin/forbidden", gin.H{
+		"Admin":     u,
+		"Message":   i18n.Localize(c, "you don't have permission to do that", nil),
+		"CSRFToken": middleware.CSRFToken(c),
+	})
+	return false
+}
+
+func (a admin) users(c *gin.Context) {
+	req, err := query.Parse[users.User](c, nil, "", nil)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	list, err := a.accounts.List(c.Request.Context())
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	a.page(c, "admin/users", gin.H{"Page": pagination.NewPage(list, req.Page)})
+}
+
+func (a admin) books(c *gin.Context) {
+	req, err := query.Parse[books.Book](c, nil, "", nil)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	list, err := a.catalog.List(c.Request.Context())
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	a.page(c, "admin/books", gin.H{
+		"Page":      pagination.NewPage(list, req.Page),
+		"CanDelete": currentAdmin(c).Can(rbac.BooksManage),
+	})
+}
+
+func (a admin) deleteBook(c *gin.Context) {
+	if !a.allowed(c, rbac.BooksManage) {
+		return
+	}
+	ctx := c.Request.Context()
+	b, err := a.catalog.Get(ctx, c.Param("id"))
+	if err == nil {
+		err = a.catalog.Delete(ctx, b.ID)
+	}
+	if err != nil {
+		if e := apperror.From(err); e.Status == http.StatusNotFound {
+			// deleted in another tab, say; the list shows it's gone
+			c.Redirect(http.StatusSeeOther, "/admin/books")
+			return
+		}
+		middleware.Fail(c, err)
+		return
+	}
+	setFlash(c, fmt.Sprintf("Deleted %q.", b.Title))
+	c.Redirect(http.StatusSeeOther, "/admin/books")
+}
+
+func (a admin) files(c *gin.Context) {
+	if !a.allowed(c, rbac.FilesManage) {
+		return
+	}
+	req, err := query.Parse[files.File](c, nil, "", nil)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	list, err := a.uploads.List(c.Request.Context())
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	a.page(c, "admin/files", gin.H{"Page": pagination.NewPage(list, req.Page)})
+}
+
+// routes mounts the admin pages under /admin.
+func (a admin) routes(router *gin.Engine) {
+	g := router.Group("/admin")
+	g.GET("/login", a.loginPage)
+	g.POST("/login", a.login)
+
+	g.Use(a.sessions.require)
+	g.GET("", func(c *gin.Context) { c.Redirect(http.StatusFound, "/admin/users") })
+	g.POST("/logout", a.logout)
+	g.GET("/users", a.users)
+	g.GET("/books", a.books)
+	g.POST("/books/:id/delete", a.deleteBook)
+	g.GET("/files", a.files)
+}
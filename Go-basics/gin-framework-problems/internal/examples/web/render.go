@@ -0,0 +1,69 @@
+package web
+
+import (
+	"fmt"
+	"html/template"
+	"io/fs"
+	"time"
+
+	"github.com/gin-gonic/gin/render"
+)
+
+// pages maps each page name to its file under templates/. Every page is
+// parsed together with the layout and partials into its own template set, so
+// each can define "title" and "content" without clashing with the others.
+var pages = map[string]string{
+	"books/index": "templates/books/index.html",
+	"books/new":   "templates/books/new.html",
+
+	"admin/login":     "templates/admin/login.html",
+	"admin/users":     "templates/admin/users.html",
+	"admin/books":     "templates/admin/books.html",
+	"admin/files":     "templates/admin/files.html",
+	"admin/forbidden": "templates/admin/forbidden.html",
+}
+
+// funcs are the functions every template can call.
+var funcs = template.FuncMap{
+	"add": func(a, b int) int { return a + b },
+	// bytes is a size for people: 512 B, 1.5 KiB, 20.0 MiB
+	"bytes": func(n int64) string {
+		const unit = 1024
+		if n < unit {
+			return fmt.Sprintf("%d B", n)
+		}
+		div, exp := int64(unit), 0
+		for m := n / unit; m >= unit; m /= unit {
+			div *= unit
+			exp++
+		}
+		return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+	},
+	"date": func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format("2 Jan 2006 15:04")
+	},
+}
+
+// renderer is a gin render.HTMLRender that executes a page inside the layout.
+// gin's own SetHTMLTemplate uses one shared set, where the pages' "content"
+// blocks would overwrite each other.
+type renderer map[string]*template.Template
+
+func newRenderer(files fs.FS) (renderer, error) {
+	r := renderer{}
+	for name, page := range pages {
+		t, err := template.New("").Funcs(funcs).ParseFS(files, "templates/layout.html", "templates/partials/*.html", page)
+		if err != nil {
+			return nil, err
+		}
+		r[name] = t
+	}
+	return r, nil
+}
+
+func (r renderer) Instance(name string, data any) render.Render {
+	return render.HTML{Template: r[name], Name: "layout", Data: data}
+}
@@ -0,0 +1,140 @@
+package web
+
+import (
+	"crypto/rand"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/users"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/rbac"
+)
+
+const (
+	sessionCookie = "hub_admin"
+	flashCookie   = "hub_flash"
+	adminKey      = "web.admin"
+)
+
+// sessions keeps who each admin session cookie signed in as, in this
+// process: a restart, or another instance, signs everyone out. Only the
+// user ID is kept, so the account is loaded again on every request and a
+// disabled account or a lost role ends the session at once.
+type sessions struct {
+	ttl      time.Duration
+	accounts *users.Server
+	now      func() time.Time
+
+	mu   sync.Mutex
+	byID map[string]session
+}
+
+type session struct {
+	userID  string
+	expires time.Time
+}
+
+func newSessions(ttl time.Duration, accounts *users.Server, now func() time.Time) *sessions {
+	return &sessions{ttl: ttl, accounts: accounts, now: now, byID: map[string]session{}}
+}
+
+// start signs u in, setting the session cookie.
+func (s *sessions) start(c *gin.Context, u users.User) {
+	id := rand.Text()
+	now := s.now()
+	s.mu.Lock()
+	for k, v := range s.byID {
+		if now.After(v.expires) {
+			delete(s.byID, k)
+		}
+	}
+	s.byID[id] = session{userID: u.ID, expires: now.Add(s.ttl)}
+	s.mu.Unlock()
+
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(sessionCookie, id, int(s.ttl.Seconds()), "/admin", "", middleware.IsSecure(c), true)
+}
+
+// end signs out the session the request came with and clears its cookie.
+func (s *sessions) end(c *gin.Context) {
+	if id, err := c.Cookie(sessionCookie); err == nil {
+		s.mu.Lock()
+		delete(s.byID, id)
+		s.mu.Unlock()
+	}
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(sessionCookie, "", -1, "/admin", "", middleware.IsSecure(c), true)
+}
+
+// user is the account the request's session belongs to, while it still
+// may use the admin pages.
+func (s *sessions) user(c *gin.Context) (users.User, bool) {
+	id, err := c.Cookie(sessionCookie)
+	if err != nil {
+		return users.User{}, false
+	}
+	s.mu.Lock()
+	sess, ok := s.byID[id]
+	if ok && s.now().After(sess.expires) {
+		delete(s.byID, id)
+		ok = false
+	}
+	s.mu.Unlock()
+	if !ok {
+		return users.User{}, false
+	}
+	u, err := s.accounts.Get(c.Request.Context(), sess.userID)
+	if err != nil || u.Disabled || !u.Can(rbac.UsersRead) {
+		return users.User{}, false
+	}
+	return u, true
+}
+
+// require lets signed-in admins through and sends everyone else to the
+// login page, which brings them back here afterwards.
+func (s *sessions) require(c *gin.Context) {
+	u, ok := s.user(c)
+	if !ok {
+		c.Redirect(http.StatusFound, "/admin/login?next="+url.QueryEscape(c.Request.URL.RequestURI()))
+		c.Abort()
+		return
+	}
+	c.Set(adminKey, u)
+}
+
+// currentAdmin is the user require let in.
+func currentAdmin(c *gin.Context) users.User {
+	return c.MustGet(adminKey).(users.User)
+}
+
+// nextPage is where to go after signing in: next when it is one of the
+// admin pages, so the login form can't be made to redirect off the site.
+func nextPage(next string) string {
+	if strings.HasPrefix(next, "/admin/") {
+		return next
+	}
+	return "/admin/users"
+}
+
+// setFlash keeps msg for the next page rendered, which is usually the one a
+// post/redirect/get lands on.
+func setFlash(c *gin.Context, msg string) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(flashCookie, msg, 60, "/", "", middleware.IsSecure(c), true)
+}
+
+// takeFlash returns the message setFlash kept, once.
+func takeFlash(c *gin.Context) string {
+	msg, err := c.Cookie(flashCookie)
+	if err != nil || msg == "" {
+		return ""
+	}
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(flashCookie, "", -1, "/", "", middleware.IsSecure(c), true)
+	return msg
+}
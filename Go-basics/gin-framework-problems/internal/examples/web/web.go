@@ -0,0 +1,186 @@
+// Package web is a server-rendered UI over the books store: html/template
+// pages with a shared layout and partials, forms protected against CSRF by
+// middleware.CSRF, and validation errors shown next to the fields.
+// Templates and static files are embedded, so the binary runs from any
+// directory.
+//
+// Under /admin, staff sign in with a users example account, admin with
+// auth.admin_password to start with, to page through users, delete books
+// and browse uploads. The session is a cookie, and messages after a
+// redirect travel in a flash cookie.
+//
+//	open http://localhost:8080/books
+//	open http://localhost:8080/admin
+package web
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/books"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/files"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/users"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/i18n"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/server"
+)
+
+//go:embed templates static
+var assets embed.FS
+
+// bookForm keeps what the user typed, Year included, so a rejected form
+// comes back exactly as submitted.
+type bookForm struct {
+	Title  string `form:"title" binding:"required,max=200"`
+	Author string `form:"author" binding:"required,max=200"`
+	Year   string `form:"year" binding:"required,numeric"`
+}
+
+// sameSite is the cookie attribute each web.csrf_same_site value sets.
+var sameSite = map[string]http.SameSite{
+	"strict": http.SameSiteStrictMode,
+	"lax":    http.SameSiteLaxMode,
+	"none":   http.SameSiteNoneMode,
+}
+
+var messages = map[string]string{
+	"required": "is required",
+	"max":      "is too long",
+	"numeric":  "must be a number",
+}
+
+// fieldErrors turns a binding error into localized messages keyed by form
+// field; anything that isn't a field error goes under "form".
+func fieldErrors(c *gin.Context, err error) map[string]string {
+	out := map[string]string{}
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		out["form"] = err.Error()
+		return out
+	}
+	for _, fe := range verrs {
+		msg, ok := messages[fe.Tag()]
+		if !ok {
+			msg = "is invalid"
+		}
+		out[fe.Field()] = i18n.Localize(c, msg, nil)
+	}
+	return out
+}
+
+// shelf serves the public books pages over a catalog.
+type shelf struct {
+	catalog *books.Server
+}
+
+func (sh shelf) index(c *gin.Context) {
+	list, err := sh.catalog.List(c.Request.Context())
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	c.HTML(http.StatusOK, "books/index", gin.H{
+		"Books": list,
+		"Flash": takeFlash(c),
+	})
+}
+
+func newBook(c *gin.Context) {
+	c.HTML(http.StatusOK, "books/new", gin.H{
+		"Form":      bookForm{},
+		"Errors":    map[string]string{},
+		"CSRFToken": middleware.CSRFToken(c),
+	})
+}
+
+func (sh shelf) createBook(c *gin.Context) {
+	var form bookForm
+	errs := map[string]string{}
+	if err := c.ShouldBind(&form); err != nil {
+		errs = fieldErrors(c, err)
+	}
+	year, err := strconv.Atoi(form.Year)
+	if _, bad := errs["year"]; !bad && (err != nil || year < 1000 || year > 2100) {
+		errs["year"] = i18n.Localize(c, "must be between 1000 and 2100", nil)
+	}
+	if len(errs) > 0 {
+		c.HTML(http.StatusUnprocessableEntity, "books/new", gin.H{
+			"Form":      form,
+			"Errors":    errs,
+			"CSRFToken": middleware.CSRFToken(c),
+		})
+		return
+	}
+
+	b, err := sh.catalog.Create(c.Request.Context(), books.Book{Title: form.Title, Author: form.Author, Year: year})
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	// post/redirect/get, so a refresh doesn't submit the form again
+	setFlash(c, fmt.Sprintf("Added %q.", b.Title))
+	c.Redirect(http.StatusSeeOther, "/books")
+}
+
+// Deps are what NewRouter builds the example from.
+type Deps struct {
+	server.Deps
+	// the books the pages list and add to; books.NewCatalog's when nil
+	Books *books.Server
+	// the accounts staff sign in with; users.NewAccounts' when nil
+	Users *users.Server
+	// the uploads the admin pages browse; files.NewUploads' when nil
+	Files *files.Server
+}
+
+// NewRouter builds the server-rendered books UI router, with the admin pages.
+func NewRouter(deps Deps) *gin.Engine {
+	cfg := deps.Config
+	r, err := newRenderer(assets)
+	if err != nil {
+		panic(fmt.Sprintf("parse templates: %v", err))
+	}
+	static, _ := fs.Sub(assets, "static")
+
+	catalog := deps.Books
+	if catalog == nil {
+		catalog = books.NewCatalog(deps.Deps)
+	}
+	accounts := deps.Users
+	if accounts == nil {
+		accounts = users.NewAccounts(deps.Deps)
+	}
+	uploads := deps.Files
+	if uploads == nil {
+		uploads = files.NewUploads(deps.Deps)
+	}
+
+	router := server.NewEngine(deps.Deps)
+	// checked by cfg.Validate
+	router.Use(middleware.CSRF(middleware.WithSameSite(sameSite[cfg.Web.CSRFSameSite])))
+	router.HTMLRender = r
+	router.StaticFS("/static", http.FS(static))
+
+	router.GET("/", func(c *gin.Context) { c.Redirect(http.StatusFound, "/books") })
+	// the token for scripts, which send it back as X-CSRF-Token
+	router.GET("/csrf", middleware.CSRFTokenHandler)
+	sh := shelf{catalog: catalog}
+	router.GET("/books", sh.index)
+	router.GET("/books/new", newBook)
+	router.POST("/books", sh.createBook)
+	admin{
+		sessions: newSessions(cfg.Auth.SessionTTL, accounts, deps.Now),
+		captcha:  captchaWidget(cfg),
+		accounts: accounts,
+		catalog:  catalog,
+		uploads:  uploads,
+	}.routes(router)
+	return router
+}
@@ -0,0 +1,189 @@
+package web
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/books"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/users"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/testutil"
+)
+
+// browser keeps cookies between requests and doesn't follow redirects, so
+// tests can check where a page sends it.
+type browser struct {
+	t      *testing.T
+	url    string
+	client *http.Client
+}
+
+func newBrowser(t *testing.T, router *gin.Engine) *browser {
+	t.Helper()
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &browser{t: t, url: testutil.Server(t, router).URL, client: &http.Client{
+		Jar:           jar,
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}}
+}
+
+// do sends the request and returns the response with its body read.
+func (b *browser) do(req *http.Request) (*http.Response, string) {
+	b.t.Helper()
+	resp, err := b.client.Do(req)
+	if err != nil {
+		b.t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		b.t.Fatal(err)
+	}
+	return resp, string(body)
+}
+
+func (b *browser) get(path string) (*http.Response, string) {
+	b.t.Helper()
+	req, err := http.NewRequestWithContext(b.t.Context(), http.MethodGet, b.url+path, nil)
+	if err != nil {
+		b.t.Fatal(err)
+	}
+	return b.do(req)
+}
+
+// post submits form to path with the CSRF token from the cookie, as the
+// pages' forms do, unless form sets csrf_token itself.
+func (b *browser) post(path string, form url.Values) (*http.Response, string) {
+	b.t.Helper()
+	if !form.Has("csrf_token") {
+		u, _ := url.Parse(b.url)
+		for _, c := range b.client.Jar.Cookies(u) {
+			if c.Name == "csrf_token" {
+				form.Set("csrf_token", c.Value)
+			}
+		}
+	}
+	req, err := http.NewRequestWithContext(b.t.Context(), http.MethodPost, b.url+path, strings.NewReader(form.Encode()))
+	if err != nil {
+		b.t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return b.do(req)
+}
+
+func wantPage(t *testing.T, resp *http.Response, body string, status int, text string) {
+	t.Helper()
+	if resp.StatusCode != status {
+		t.Fatalf("status = %d, want %d; body:\n%s", resp.StatusCode, status, body)
+	}
+	if !strings.Contains(body, text) {
+		t.Errorf("page doesn't say %q:\n%s", text, body)
+	}
+}
+
+func wantRedirect(t *testing.T, resp *http.Response, location string) {
+	t.Helper()
+	if resp.StatusCode != http.StatusSeeOther && resp.StatusCode != http.StatusFound {
+		t.Fatalf("status = %d, want a redirect to %s", resp.StatusCode, location)
+	}
+	if got := resp.Header.Get("Location"); got != location {
+		t.Errorf("redirected to %s, want %s", got, location)
+	}
+}
+
+func TestAddBook(t *testing.T) {
+	b := newBrowser(t, NewRouter(Deps{Deps: testutil.Deps(t, nil)}))
+	resp, body := b.get("/books/new")
+	wantPage(t, resp, body, http.StatusOK, `name="csrf_token"`)
+
+	tests := []struct {
+		name   string
+		form   url.Values
+		status int
+		text   string
+	}{
+		{"forged token", url.Values{"csrf_token": {"forged"}, "title": {"Forged"}, "author": {"Mallory"}, "year": {"2020"}}, http.StatusForbidden, ""},
+		{"missing title", url.Values{"author": {"Ann Leckie"}, "year": {"2013"}}, http.StatusUnprocessableEntity, "is required"},
+		{"year not a number", url.Values{"title": {"Ancillary Justice"}, "author": {"Ann Leckie"}, "year": {"soon"}}, http.StatusUnprocessableEntity, "must be a number"},
+		// the form comes back as typed
+		{"year out of range", url.Values{"title": {"Ancillary Justice"}, "author": {"Ann Leckie"}, "year": {"20130"}}, http.StatusUnprocessableEntity, `value="20130"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, body := b.post("/books", tt.form)
+			wantPage(t, resp, body, tt.status, tt.text)
+		})
+	}
+
+	resp, _ = b.post("/books", url.Values{"title": {"Ancillary Justice"}, "author": {"Ann Leckie"}, "year": {"2013"}})
+	wantRedirect(t, resp, "/books")
+	resp, body = b.get("/books")
+	wantPage(t, resp, body, http.StatusOK, "Added &#34;Ancillary Justice&#34;.")
+	// the flash is shown once
+	if _, body = b.get("/books"); strings.Contains(body, "Added") {
+		t.Errorf("flash shown again:\n%s", body)
+	}
+}
+
+func TestAdmin(t *testing.T) {
+	cfg := testutil.Config(t)
+	deps := testutil.Deps(t, cfg)
+	accounts, catalog := users.NewAccounts(deps), books.NewCatalog(deps)
+	b := newBrowser(t, NewRouter(Deps{Deps: deps, Users: accounts, Books: catalog}))
+	// the users example shares its accounts
+	testutil.RegisterUser(t, users.NewRouter(users.Deps{Deps: testutil.Deps(t, cfg), Users: accounts}), "webreader")
+	ctx := t.Context()
+	dune, err := catalog.Create(ctx, books.Book{Title: "Dune", Author: "Frank Herbert", Year: 1965})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, _ := b.get("/admin/books")
+	wantRedirect(t, resp, "/admin/login?next=%2Fadmin%2Fbooks")
+	tests := []struct {
+		name               string
+		username, password string
+		status             int
+		text               string
+	}{
+		{"wrong password", "admin", "wrong", http.StatusUnauthorized, "invalid credentials"},
+		{"no users:read", "webreader", "password123", http.StatusForbidden, "can&#39;t use the admin pages"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, body := b.post("/admin/login?next=/admin/books", url.Values{"username": {tt.username}, "password": {tt.password}})
+			wantPage(t, resp, body, tt.status, tt.text)
+		})
+	}
+	// next only ever leads to an admin page
+	resp, _ = b.post("/admin/login?next=https://evil.example", url.Values{"username": {"admin"}, "password": {cfg.Auth.AdminPassword}})
+	wantRedirect(t, resp, "/admin/users")
+
+	resp, body := b.get("/admin/users")
+	wantPage(t, resp, body, http.StatusOK, "webreader")
+	resp, body = b.get("/admin/books")
+	wantPage(t, resp, body, http.StatusOK, "Dune")
+	resp, _ = b.post("/admin/books/"+dune.ID+"/delete", url.Values{})
+	wantRedirect(t, resp, "/admin/books")
+	resp, body = b.get("/admin/books")
+	wantPage(t, resp, body, http.StatusOK, "Deleted &#34;Dune&#34;.")
+	if _, err := catalog.Get(ctx, dune.ID); !errors.Is(err, books.ErrBookNotFound) {
+		t.Errorf("Get after the admin deleted it: %v, want ErrBookNotFound", err)
+	}
+	resp, body = b.get("/admin/files")
+	wantPage(t, resp, body, http.StatusOK, "")
+
+	resp, _ = b.post("/admin/logout", url.Values{})
+	wantRedirect(t, resp, "/admin/login")
+	resp, _ = b.get("/admin/users")
+	wantRedirect(t, resp, "/admin/login?next=%2Fadmin%2Fusers")
+}
@@ -0,0 +1,128 @@
+// Package webhooks delivers domain events to registered HTTP endpoints,
+// signed with each endpoint's secret and retried until they succeed or land
+// in the dead letters.
+//
+// The operator API under /webhooks takes basic auth with the admin password,
+// and isn't mounted without one:
+//
+//	curl -u admin:admin123 -X POST localhost:8080/webhooks/endpoints -d '{"url":"https://example.com/hook","events":["user.registered"]}'
+//	curl -u admin:admin123 -X POST localhost:8080/webhooks/endpoints/<id>/ping
+//	curl -u admin:admin123 'localhost:8080/webhooks/deliveries?status=retrying'
+//	curl -u admin:admin123 localhost:8080/webhooks/dead-letters
+//	curl -u admin:admin123 -X POST localhost:8080/webhooks/deliveries/<id>/redeliver
+//
+// API consumers register and inspect their own endpoints under
+// /api/webhooks, with a token from the users example's /api/login. They get
+// book.updated and book.deleted for the books they own, and nothing else:
+//
+//	curl -X POST localhost:8080/api/webhooks -H "Authorization: Bearer <token>" \
+//	  -d '{"url":"https://example.com/hook","events":["book.updated"]}'
+//	curl localhost:8080/api/webhooks/<id>/deliveries -H "Authorization: Bearer <token>"
+//
+// user.registered, book.updated, file.uploaded and file.deleted come from
+// the users, books and files examples; they reach this process when
+// events.nats_url connects the bus. Endpoints on localhost and private
+// networks are refused unless webhooks.allow_private_networks is set.
+package webhooks
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/config"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/events"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/examples/users"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/logging"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/server"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/webhooks"
+)
+
+// forward publishes every event on t to the endpoints subscribed to its
+// subject: the operator's, and those of the consumer owner names, if any.
+func forward[T any](d *webhooks.Dispatcher, t events.Topic[T], owner func(T) string) error {
+	_, err := events.Subscribe(events.Default, t, func(ctx context.Context, ev T) {
+		var id string
+		if owner != nil {
+			id = owner(ev)
+		}
+		d.PublishOwned(ctx, t.Subject, id, ev)
+	})
+	return err
+}
+
+// NewDispatcher builds a dispatcher from cfg and adds its shutdown to hooks.
+// It is not started.
+func NewDispatcher(cfg *config.Config, hooks *server.Hooks) *webhooks.Dispatcher {
+	opts := []webhooks.Option{
+		webhooks.WithWorkers(cfg.Webhooks.Workers),
+		webhooks.WithMaxAttempts(cfg.Webhooks.MaxAttempts),
+		webhooks.WithBackoff(cfg.Webhooks.Backoff, cfg.Webhooks.MaxBackoff),
+		webhooks.WithHistory(cfg.Webhooks.History),
+		webhooks.WithClient(webhooks.NewClient(cfg.Webhooks.Timeout, cfg.Webhooks.AllowPrivateNetworks)),
+		webhooks.WithLogger(logging.New(cfg.Log)),
+		webhooks.WithEvents(events.UserRegistered.Subject, events.UserCreated.Subject,
+			events.BookUpdated.Subject, events.BookDeleted.Subject,
+			events.FileUploaded.Subject, events.FileDeleted.Subject),
+		// user events carry emails, and files are owned by usernames of the
+		// auth example rather than by consumers
+		webhooks.WithConsumerEvents(events.BookUpdated.Subject, events.BookDeleted.Subject),
+	}
+	if cfg.Webhooks.AllowPrivateNetworks {
+		opts = append(opts, webhooks.WithPrivateNetworks())
+	}
+	d := webhooks.NewDispatcher(opts...)
+	hooks.Add(d.Shutdown)
+	return d
+}
+
+// Deps are what NewRouter builds the example from.
+type Deps struct {
+	server.Deps
+	// the accounts consumers register and sign in with;
+	// users.NewAccounts' when nil
+	Users *users.Server
+}
+
+// NewRouter builds the webhooks example router.
+func NewRouter(deps Deps) *gin.Engine {
+	cfg, hooks := deps.Config, deps.Hooks
+	accounts := deps.Users
+	if accounts == nil {
+		accounts = users.NewAccounts(deps.Deps)
+	}
+	d := NewDispatcher(cfg, hooks)
+	d.Start()
+
+	router := server.NewEngine(deps.Deps)
+	// after NewEngine, which picks the bus
+	for _, err := range []error{
+		forward(d, events.UserRegistered, nil),
+		forward(d, events.UserCreated, nil),
+		forward(d, events.BookUpdated, func(ev events.BookUpdatedEvent) string { return ev.OwnerID }),
+		forward(d, events.BookDeleted, func(ev events.BookDeletedEvent) string { return ev.OwnerID }),
+		forward(d, events.FileUploaded, nil),
+		forward(d, events.FileDeleted, nil),
+	} {
+		if err != nil {
+			panic(fmt.Sprintf("subscribe: %v", err))
+		}
+	}
+
+	// the operator's API sees every endpoint and delivery, user emails
+	// included
+	if cfg.Auth.AdminPassword != "" {
+		d.Routes(router.Group("/webhooks", gin.BasicAuth(gin.Accounts{"admin": cfg.Auth.AdminPassword})))
+	}
+
+	api := router.Group("/api")
+	{
+		api.POST("/register", accounts.Register)
+		api.POST("/login", accounts.Login)
+	}
+	consumer := router.Group("/api/webhooks", middleware.Auth(accounts.LookupToken))
+	d.ConsumerRoutes(consumer, func(c *gin.Context) string { return users.MustUser(c).ID })
+	return router
+}
@@ -0,0 +1,224 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/config"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/events"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/testutil"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/webhooks"
+)
+
+const secret = "whsec_0123456789abcdef"
+
+// received is one delivery as the receiver saw it.
+type received struct {
+	event    string
+	verified error
+}
+
+// receiver serves /hook, passing each delivery it gets on the returned
+// channel, and /broken, which always fails.
+func receiver(t *testing.T) (string, <-chan received) {
+	t.Helper()
+	got := make(chan received, 10)
+	r := testutil.Engine()
+	r.POST("/hook", func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		var msg struct {
+			Event string `json:"event"`
+		}
+		json.Unmarshal(body, &msg)
+		err := webhooks.Verify(secret, c.GetHeader("X-Webhook-Timestamp"), c.GetHeader("X-Webhook-Signature"), body, time.Minute)
+		got <- received{event: msg.Event, verified: err}
+		c.Status(http.StatusNoContent)
+	})
+	r.POST("/broken", func(c *gin.Context) { c.Status(http.StatusInternalServerError) })
+	return testutil.Server(t, r).URL, got
+}
+
+// localConfig lets endpoints be on the receivers' 127.0.0.1.
+func localConfig(t *testing.T) *config.Config {
+	t.Helper()
+	cfg := testutil.Config(t)
+	cfg.Webhooks.AllowPrivateNetworks = true
+	return cfg
+}
+
+// admin signs in to the operator API.
+func admin(cfg *config.Config) testutil.RequestOption {
+	return testutil.WithBasicAuth("admin", cfg.Auth.AdminPassword)
+}
+
+func next(t *testing.T, got <-chan received) received {
+	t.Helper()
+	select {
+	case r := <-got:
+		return r
+	case <-time.After(5 * time.Second):
+		t.Fatal("no delivery")
+		return received{}
+	}
+}
+
+func TestForward(t *testing.T) {
+	cfg := localConfig(t)
+	router := NewRouter(Deps{Deps: testutil.Deps(t, cfg)})
+	url, got := receiver(t)
+	w := testutil.DoJSON(t, router, http.MethodPost, "/webhooks/endpoints", gin.H{
+		"url": url + "/hook", "events": []string{"user.registered"}, "secret": secret,
+	}, admin(cfg))
+	testutil.AssertStatus(t, w, http.StatusCreated)
+
+	// not subscribed to, so only the registration arrives
+	ctx := t.Context()
+	events.Publish(ctx, events.Default, events.FileUploaded, events.FileUploadedEvent{Name: "a.txt"})
+	events.Publish(ctx, events.Default, events.UserRegistered, events.UserRegisteredEvent{UserID: "1", Username: "hooked"})
+	if r := next(t, got); r.event != "user.registered" || r.verified != nil {
+		t.Errorf("got %s, verified: %v; want user.registered, verified", r.event, r.verified)
+	}
+}
+
+func TestDeadLetters(t *testing.T) {
+	cfg := localConfig(t)
+	cfg.Webhooks.MaxAttempts = 2
+	cfg.Webhooks.Backoff, cfg.Webhooks.MaxBackoff = time.Millisecond, time.Millisecond
+	router := NewRouter(Deps{Deps: testutil.Deps(t, cfg)})
+	url, _ := receiver(t)
+
+	w := testutil.DoJSON(t, router, http.MethodPost, "/webhooks/endpoints", gin.H{"url": url + "/broken", "secret": secret}, admin(cfg))
+	testutil.AssertStatus(t, w, http.StatusCreated)
+	ep := testutil.Decode[webhooks.Endpoint](t, w)
+	w = testutil.Do(t, router, http.MethodPost, "/webhooks/endpoints/"+ep.ID+"/ping", nil, admin(cfg))
+	testutil.AssertStatus(t, w, http.StatusAccepted)
+	id := testutil.Decode[webhooks.Delivery](t, w).ID
+	path := "/webhooks/deliveries/" + id
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		dl := testutil.Decode[webhooks.Delivery](t, testutil.Do(t, router, http.MethodGet, path, nil, admin(cfg)))
+		if dl.Status == webhooks.StatusDead {
+			if len(dl.Attempts) != 2 {
+				t.Errorf("dead after %d attempts, want 2", len(dl.Attempts))
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("delivery still %s", dl.Status)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		status int
+	}{
+		{"unknown endpoint", http.MethodPost, "/webhooks/endpoints/ep_missing/ping", http.StatusNotFound},
+		{"unknown delivery", http.MethodPost, "/webhooks/deliveries/missing/redeliver", http.StatusNotFound},
+		{"discard", http.MethodDelete, "/webhooks/dead-letters/" + id, http.StatusNoContent},
+		{"discarded", http.MethodGet, path, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testutil.AssertStatus(t, testutil.Do(t, router, tt.method, tt.path, nil, admin(cfg)), tt.status)
+		})
+	}
+}
+
+func TestOperatorAPI(t *testing.T) {
+	cfg := localConfig(t)
+	router := NewRouter(Deps{Deps: testutil.Deps(t, cfg)})
+	testutil.AssertStatus(t, testutil.Do(t, router, http.MethodGet, "/webhooks/endpoints", nil), http.StatusUnauthorized)
+	token := testutil.RegisterUser(t, router, "notadmin")
+	testutil.AssertStatus(t, testutil.Do(t, router, http.MethodGet, "/webhooks/deliveries", nil, testutil.WithToken(token)), http.StatusUnauthorized)
+	testutil.AssertStatus(t, testutil.Do(t, router, http.MethodGet, "/webhooks/endpoints", nil, admin(cfg)), http.StatusOK)
+
+	cfg = localConfig(t)
+	cfg.Auth.AdminPassword = ""
+	router = NewRouter(Deps{Deps: testutil.Deps(t, cfg)})
+	testutil.AssertStatus(t, testutil.Do(t, router, http.MethodGet, "/webhooks/endpoints", nil), http.StatusNotFound)
+}
+
+func TestPrivateNetworks(t *testing.T) {
+	cfg := testutil.Config(t)
+	router := NewRouter(Deps{Deps: testutil.Deps(t, cfg)})
+	token := testutil.RegisterUser(t, router, "ssrf")
+	for _, u := range []string{"http://127.0.0.1:9000/hook", "http://169.254.169.254/latest/meta-data/", "http://10.0.0.5/hook", "http://localhost/hook"} {
+		w := testutil.DoJSON(t, router, http.MethodPost, "/api/webhooks", gin.H{"url": u, "events": []string{"book.updated"}}, testutil.WithToken(token))
+		testutil.AssertStatus(t, w, http.StatusBadRequest)
+		w = testutil.DoJSON(t, router, http.MethodPost, "/webhooks/endpoints", gin.H{"url": u}, admin(cfg))
+		testutil.AssertStatus(t, w, http.StatusBadRequest)
+	}
+}
+
+func TestConsumerRoutes(t *testing.T) {
+	router := NewRouter(Deps{Deps: testutil.Deps(t, localConfig(t))})
+	url, got := receiver(t)
+	w := testutil.DoJSON(t, router, http.MethodPost, "/api/register",
+		gin.H{"username": "hookowner", "email": "hookowner@example.com", "password": "password123"})
+	testutil.AssertStatus(t, w, http.StatusCreated)
+	ownerID := testutil.Decode[struct{ ID string }](t, w).ID
+	owner := testutil.Login(t, router, "/api/login", "hookowner", "password123")
+	other := testutil.RegisterUser(t, router, "hookother")
+
+	testutil.AssertStatus(t, testutil.DoJSON(t, router, http.MethodPost, "/api/webhooks", gin.H{"url": url + "/hook"}), http.StatusUnauthorized)
+	// misspelt, or not one a consumer may have
+	for _, ev := range []string{"book.udpated", "user.registered", "file.uploaded"} {
+		w = testutil.DoJSON(t, router, http.MethodPost, "/api/webhooks", gin.H{"url": url + "/hook", "events": []string{ev}}, testutil.WithToken(owner))
+		testutil.AssertStatus(t, w, http.StatusBadRequest)
+	}
+	w = testutil.DoJSON(t, router, http.MethodPost, "/api/webhooks",
+		gin.H{"url": url + "/hook", "events": []string{"book.updated"}, "secret": secret}, testutil.WithToken(owner))
+	testutil.AssertStatus(t, w, http.StatusCreated)
+	ep := testutil.Decode[webhooks.Endpoint](t, w)
+
+	// only the books the consumer owns
+	ctx := t.Context()
+	events.Publish(ctx, events.Default, events.BookUpdated, events.BookUpdatedEvent{BookID: "1", Action: "created", Title: "Emma"})
+	events.Publish(ctx, events.Default, events.BookUpdated, events.BookUpdatedEvent{BookID: "2", Action: "created", Title: "Ulysses", OwnerID: "someone else"})
+	events.Publish(ctx, events.Default, events.BookUpdated, events.BookUpdatedEvent{BookID: "3", Action: "created", Title: "Dune", OwnerID: ownerID})
+	if r := next(t, got); r.event != "book.updated" || r.verified != nil {
+		t.Errorf("got %s, verified: %v; want book.updated, verified", r.event, r.verified)
+	}
+	w = testutil.Do(t, router, http.MethodGet, "/api/webhooks/"+ep.ID+"/deliveries", nil, testutil.WithToken(owner))
+	if n := len(testutil.Decode[struct{ Items []webhooks.Delivery }](t, w).Items); n != 1 {
+		t.Errorf("%d deliveries, want the owned book's only", n)
+	}
+
+	// another consumer doesn't see the endpoint, or that it exists
+	tests := []struct {
+		name, token, method, path string
+		status                    int
+	}{
+		{"own", owner, http.MethodGet, "/api/webhooks/" + ep.ID, http.StatusOK},
+		{"own deliveries", owner, http.MethodGet, "/api/webhooks/" + ep.ID + "/deliveries", http.StatusOK},
+		{"other's", other, http.MethodGet, "/api/webhooks/" + ep.ID, http.StatusNotFound},
+		{"other's deliveries", other, http.MethodGet, "/api/webhooks/" + ep.ID + "/deliveries", http.StatusNotFound},
+		{"other's ping", other, http.MethodPost, "/api/webhooks/" + ep.ID + "/ping", http.StatusNotFound},
+		{"other's delete", other, http.MethodDelete, "/api/webhooks/" + ep.ID, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testutil.AssertStatus(t, testutil.Do(t, router, tt.method, tt.path, nil, testutil.WithToken(tt.token)), tt.status)
+		})
+	}
+	list := func(token string) []webhooks.Endpoint {
+		w := testutil.Do(t, router, http.MethodGet, "/api/webhooks", nil, testutil.WithToken(token))
+		return testutil.Decode[struct{ Endpoints []webhooks.Endpoint }](t, w).Endpoints
+	}
+	if got := list(owner); len(got) != 1 || got[0].ID != ep.ID {
+		t.Errorf("owner's endpoints = %+v", got)
+	}
+	if got := list(other); len(got) != 0 {
+		t.Errorf("other's endpoints = %+v, want none", got)
+	}
+	testutil.AssertStatus(t, testutil.Do(t, router, http.MethodDelete, "/api/webhooks/"+ep.ID, nil, testutil.WithToken(owner)), http.StatusNoContent)
+}
@@ -0,0 +1,239 @@
+// Package featureflags turns features on per request. A flag is on for the
+// users and roles it lists, and for a percentage of everyone else: each
+// caller falls in a stable bucket from 0 to 99, keyed by user ID or, for
+// anonymous callers, by a random ID kept in a cookie.
+//
+// Flags start from the flags section of the config and can be changed at
+// runtime through the admin API NewEngine mounts at /admin/flags. With a
+// Store, such as the file flags_file names, changes survive restarts.
+package featureflags
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"hash/fnv"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+)
+
+// BucketCookie holds an anonymous caller's bucket key.
+const BucketCookie = "hub_bucket"
+
+const bucketKey = "featureflags.bucket"
+
+var ErrInvalidRollout = errors.New("rollout must be between 0 and 100")
+
+// Flag is one feature switch. Enabled is the master switch: when it is off
+// nobody gets the feature, whatever the targeting says.
+type Flag struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	Enabled     bool      `json:"enabled"`
+	Rollout     int       `json:"rollout"` // percent of buckets the flag is on for
+	Users       []string  `json:"users"`   // user IDs that always get it
+	Roles       []string  `json:"roles"`   // roles that always get it
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Subject is who a flag is evaluated for.
+type Subject struct {
+	User    string // empty when anonymous
+	Bucket  string // key for the percentage rollout
+	HasRole func(role string) bool
+}
+
+// On reports whether f is on for s.
+func (f Flag) On(s Subject) bool {
+	if !f.Enabled {
+		return false
+	}
+	if s.User != "" && slices.Contains(f.Users, s.User) {
+		return true
+	}
+	if s.HasRole != nil && slices.ContainsFunc(f.Roles, s.HasRole) {
+		return true
+	}
+	return bucket(f.Name, s.Bucket) < f.Rollout
+}
+
+// bucket places key in 0-99 for flag. Hashing the flag name in too means a
+// caller in the first 10% of one rollout isn't in the first 10% of all.
+func bucket(flag, key string) int {
+	h := fnv.New32a()
+	h.Write([]byte(flag + ":" + key))
+	return int(h.Sum32() % 100)
+}
+
+// Registry holds the flags. It is safe for concurrent use.
+type Registry struct {
+	mu    sync.RWMutex
+	flags map[string]Flag
+	store Store // nil keeps them in memory only
+}
+
+func New() *Registry {
+	return &Registry{flags: map[string]Flag{}}
+}
+
+// Default is the registry the examples and the admin API share.
+var Default = New()
+
+// UseStore loads the flags s keeps, which replace those of the same name,
+// and saves every change to s from then on.
+func (r *Registry) UseStore(s Store) error {
+	stored, err := s.Load()
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, f := range stored {
+		r.flags[f.Name] = f
+	}
+	r.store = s
+	return r.save()
+}
+
+// save writes the flags to the store, if there is one. r.mu must be held.
+func (r *Registry) save() error {
+	if r.store == nil {
+		return nil
+	}
+	list := make([]Flag, 0, len(r.flags))
+	for _, f := range r.flags {
+		list = append(list, f)
+	}
+	slices.SortFunc(list, func(a, b Flag) int { return strings.Compare(a.Name, b.Name) })
+	return r.store.Save(list)
+}
+
+// Set adds or replaces a flag. An error saving it leaves it changed in
+// memory.
+func (r *Registry) Set(f Flag) (Flag, error) {
+	if f.Rollout < 0 || f.Rollout > 100 {
+		return Flag{}, ErrInvalidRollout
+	}
+	if f.Users == nil {
+		f.Users = []string{}
+	}
+	if f.Roles == nil {
+		f.Roles = []string{}
+	}
+	f.UpdatedAt = time.Now().UTC()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.flags[f.Name] = f
+	return f, r.save()
+}
+
+func (r *Registry) Get(name string) (Flag, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	f, ok := r.flags[name]
+	return f, ok
+}
+
+// List returns every flag, sorted by name.
+func (r *Registry) List() []Flag {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	list := make([]Flag, 0, len(r.flags))
+	for _, f := range r.flags {
+		list = append(list, f)
+	}
+	slices.SortFunc(list, func(a, b Flag) int { return strings.Compare(a.Name, b.Name) })
+	return list
+}
+
+// Delete removes a flag and reports whether it existed.
+func (r *Registry) Delete(name string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.flags[name]; !ok {
+		return false, nil
+	}
+	delete(r.flags, name)
+	return true, r.save()
+}
+
+// Enabled reports whether the flag is on for this request. Unknown flags
+// are off. Routes that use it should run after Auth, so signed-in users are
+// bucketed by their ID rather than by cookie.
+func (r *Registry) Enabled(c *gin.Context, name string) bool {
+	f, ok := r.Get(name)
+	return ok && f.On(SubjectOf(c))
+}
+
+// States reports every flag as on or off for this request, for clients
+// that want to know ahead of time which features they get.
+func (r *Registry) States(c *gin.Context) map[string]bool {
+	s := SubjectOf(c)
+	states := map[string]bool{}
+	for _, f := range r.List() {
+		states[f.Name] = f.On(s)
+	}
+	return states
+}
+
+// Enabled is Default.Enabled, for handlers that branch on a flag:
+//
+//	if featureflags.Enabled(c, "new-search") { ... }
+func Enabled(c *gin.Context, name string) bool {
+	return Default.Enabled(c, name)
+}
+
+// Require answers 404 when the flag is off, so a feature that isn't rolled
+// out to the caller looks like it doesn't exist.
+func (r *Registry) Require(name string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !r.Enabled(c, name) {
+			middleware.AbortError(c, http.StatusNotFound, "not found")
+			return
+		}
+		c.Next()
+	}
+}
+
+// SubjectOf builds the subject for c from the principal Auth stored, or
+// from the bucket cookie, which it sets on first use.
+func SubjectOf(c *gin.Context) Subject {
+	var s Subject
+	if v, ok := c.Get(middleware.UserKey); ok {
+		if p, ok := v.(middleware.Subjecter); ok {
+			s.User = p.Subject()
+		}
+		if p, ok := v.(middleware.RoleChecker); ok {
+			s.HasRole = p.HasRole
+		}
+	}
+	if s.User != "" {
+		s.Bucket = s.User
+		return s
+	}
+	s.Bucket = anonymousBucket(c)
+	return s
+}
+
+func anonymousBucket(c *gin.Context) string {
+	if key := c.GetString(bucketKey); key != "" {
+		return key
+	}
+	key, err := c.Cookie(BucketCookie)
+	if err != nil || len(key) != 32 {
+		b := make([]byte, 16)
+		rand.Read(b)
+		key = hex.EncodeToString(b)
+		c.SetSameSite(http.SameSiteLaxMode)
+		c.SetCookie(BucketCookie, key, int((365 * 24 * time.Hour).Seconds()), "/", "", middleware.IsSecure(c), true)
+	}
+	c.Set(bucketKey, key)
+	return key
+}
@@ -0,0 +1,140 @@
+package featureflags
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/apperror"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+)
+
+// Routes mounts the admin API on r:
+//
+//	GET    /            every flag
+//	GET    /:name
+//	PUT    /:name       create or replace {description, enabled, rollout, users, roles}
+//	PATCH  /:name       change some fields, e.g. {"enabled": false}
+//	DELETE /:name
+func (r *Registry) Routes(g gin.IRouter) {
+	g.GET("", r.list)
+	g.GET("/:name", middleware.Handle(r.get))
+	g.PUT("/:name", middleware.Handle(r.put))
+	g.PATCH("/:name", middleware.Handle(r.patch))
+	g.DELETE("/:name", middleware.Handle(r.remove))
+}
+
+var errFlagNotFound = apperror.NotFound("flag not found")
+
+type putRequest struct {
+	Description string   `json:"description"`
+	Enabled     bool     `json:"enabled"`
+	Rollout     *int     `json:"rollout" binding:"omitempty,min=0,max=100"` // default 100
+	Users       []string `json:"users"`
+	Roles       []string `json:"roles"`
+}
+
+// patchRequest leaves out what isn't sent.
+type patchRequest struct {
+	Description *string   `json:"description"`
+	Enabled     *bool     `json:"enabled"`
+	Rollout     *int      `json:"rollout" binding:"omitempty,min=0,max=100"`
+	Users       *[]string `json:"users"`
+	Roles       *[]string `json:"roles"`
+}
+
+// setError is the answer to a failed Set: 400 for a bad rollout, else a
+// 500 for a store that couldn't save.
+func setError(err error) error {
+	if errors.Is(err, ErrInvalidRollout) {
+		return apperror.BadRequest(err.Error())
+	}
+	return err
+}
+
+func (r *Registry) list(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"flags": r.List()})
+}
+
+func (r *Registry) get(c *gin.Context) error {
+	f, ok := r.Get(c.Param("name"))
+	if !ok {
+		return errFlagNotFound
+	}
+	c.JSON(http.StatusOK, f)
+	return nil
+}
+
+func (r *Registry) put(c *gin.Context) error {
+	var req putRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return middleware.BadInput(c, err)
+	}
+	f := Flag{
+		Name:        c.Param("name"),
+		Description: req.Description,
+		Enabled:     req.Enabled,
+		Rollout:     100,
+		Users:       req.Users,
+		Roles:       req.Roles,
+	}
+	if req.Rollout != nil {
+		f.Rollout = *req.Rollout
+	}
+	_, existed := r.Get(f.Name)
+	f, err := r.Set(f)
+	if err != nil {
+		return setError(err)
+	}
+	status := http.StatusOK
+	if !existed {
+		status = http.StatusCreated
+	}
+	c.JSON(status, f)
+	return nil
+}
+
+func (r *Registry) patch(c *gin.Context) error {
+	var req patchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return middleware.BadInput(c, err)
+	}
+	f, ok := r.Get(c.Param("name"))
+	if !ok {
+		return errFlagNotFound
+	}
+	if req.Description != nil {
+		f.Description = *req.Description
+	}
+	if req.Enabled != nil {
+		f.Enabled = *req.Enabled
+	}
+	if req.Rollout != nil {
+		f.Rollout = *req.Rollout
+	}
+	if req.Users != nil {
+		f.Users = *req.Users
+	}
+	if req.Roles != nil {
+		f.Roles = *req.Roles
+	}
+	f, err := r.Set(f)
+	if err != nil {
+		return setError(err)
+	}
+	c.JSON(http.StatusOK, f)
+	return nil
+}
+
+func (r *Registry) remove(c *gin.Context) error {
+	ok, err := r.Delete(c.Param("name"))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errFlagNotFound
+	}
+	c.Status(http.StatusNoContent)
+	return nil
+}
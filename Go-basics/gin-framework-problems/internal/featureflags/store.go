@@ -0,0 +1,56 @@
+package featureflags
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// Store keeps the flags somewhere they survive a restart. Without one, a
+// Registry holds them in memory only.
+type Store interface {
+	// Load returns the stored flags, none when nothing was saved yet.
+	Load() ([]Flag, error)
+	// Save replaces the stored flags with flags.
+	Save(flags []Flag) error
+}
+
+// FileStore keeps the flags as a JSON array in a file.
+type FileStore struct {
+	path string
+}
+
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+func (s *FileStore) Load() ([]Flag, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var flags []Flag
+	if err := json.Unmarshal(data, &flags); err != nil {
+		return nil, err
+	}
+	return flags, nil
+}
+
+func (s *FileStore) Save(flags []Flag) error {
+	data, err := json.MarshalIndent(flags, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	// write then rename, so a crash never leaves a truncated file
+	if err := os.WriteFile(s.path+".tmp", data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(s.path+".tmp", s.path)
+}
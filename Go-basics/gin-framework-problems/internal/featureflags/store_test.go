@@ -0,0 +1,33 @@
+package featureflags
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "flags", "flags.json")
+	r := New()
+	if err := r.UseStore(NewFileStore(path)); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range []Flag{{Name: "new-search", Rollout: 25}, {Name: "dark-mode", Enabled: true}} {
+		if _, err := r.Set(f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if ok, err := r.Delete("dark-mode"); !ok || err != nil {
+		t.Fatalf("Delete = %v, %v; want true, nil", ok, err)
+	}
+
+	// a restart: the file's flags replace those set up before UseStore
+	restarted := New()
+	restarted.Set(Flag{Name: "new-search", Enabled: true})
+	if err := restarted.UseStore(NewFileStore(path)); err != nil {
+		t.Fatal(err)
+	}
+	got := restarted.List()
+	if len(got) != 1 || got[0].Name != "new-search" || got[0].Enabled || got[0].Rollout != 25 {
+		t.Errorf("after restart = %+v, want only new-search at 25%%", got)
+	}
+}
@@ -0,0 +1,161 @@
+// Package healthcheck lets components register checks that back the
+// /healthz (liveness) and /readyz (readiness) endpoints.
+package healthcheck
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Check reports whether a component is healthy. It must honor ctx.
+type Check func(ctx context.Context) error
+
+// Result is the outcome of one check.
+type Result struct {
+	Status   string `json:"status"` // "ok" or "fail"
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// Report is the JSON body of /healthz and /readyz.
+type Report struct {
+	Status string            `json:"status"` // "ok", "fail" or "draining"
+	Checks map[string]Result `json:"checks"`
+}
+
+// Registry holds named liveness and readiness checks.
+type Registry struct {
+	mu        sync.RWMutex
+	timeout   time.Duration
+	liveness  map[string]Check
+	readiness map[string]Check
+	draining  atomic.Bool
+}
+
+// New returns an empty registry that gives each check timeout to finish.
+func New(timeout time.Duration) *Registry {
+	return &Registry{
+		timeout:   timeout,
+		liveness:  map[string]Check{},
+		readiness: map[string]Check{},
+	}
+}
+
+// Default is the registry the examples register into and NewEngine serves.
+var Default = New(2 * time.Second)
+
+// SetTimeout changes the per-check timeout.
+func (r *Registry) SetTimeout(d time.Duration) {
+	r.mu.Lock()
+	r.timeout = d
+	r.mu.Unlock()
+}
+
+// Register adds a readiness check, e.g. a store or storage backend. A failing
+// readiness check takes the instance out of rotation without restarting it.
+func (r *Registry) Register(name string, fn Check) {
+	r.mu.Lock()
+	r.readiness[name] = fn
+	r.mu.Unlock()
+}
+
+// RegisterLiveness adds a liveness check, e.g. a janitor goroutine that must
+// keep running. A failing liveness check means the process should restart.
+func (r *Registry) RegisterLiveness(name string, fn Check) {
+	r.mu.Lock()
+	r.liveness[name] = fn
+	r.mu.Unlock()
+}
+
+// Drain makes /readyz answer 503 "draining" from now on, without running
+// its checks, so load balancers stop sending requests to an instance that
+// is shutting down. Liveness is unaffected.
+func (r *Registry) Drain() {
+	r.draining.Store(true)
+}
+
+// run executes checks concurrently, each under the registry timeout.
+func (r *Registry) run(ctx context.Context, checks map[string]Check) Report {
+	r.mu.RLock()
+	timeout := r.timeout
+	todo := make(map[string]Check, len(checks))
+	for name, fn := range checks {
+		todo[name] = fn
+	}
+	r.mu.RUnlock()
+
+	report := Report{Status: "ok", Checks: make(map[string]Result, len(todo))}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, fn := range todo {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res := runOne(ctx, fn, timeout)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[name] = res
+			if res.Status != "ok" {
+				report.Status = "fail"
+			}
+		}()
+	}
+	wg.Wait()
+	return report
+}
+
+func runOne(ctx context.Context, fn Check, timeout time.Duration) Result {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	errc := make(chan error, 1)
+	go func() { errc <- fn(ctx) }()
+
+	var err error
+	select {
+	case err = <-errc:
+	case <-ctx.Done():
+		// don't wait for a check that ignores its context
+		err = ctx.Err()
+	}
+
+	res := Result{Status: "ok", Duration: time.Since(start).String()}
+	if err != nil {
+		res.Status = "fail"
+		res.Error = err.Error()
+	}
+	return res
+}
+
+func serve(c *gin.Context, report Report) {
+	status := http.StatusOK
+	if report.Status != "ok" {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, report)
+}
+
+// Liveness serves /healthz.
+func (r *Registry) Liveness() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		serve(c, r.run(c.Request.Context(), r.liveness))
+	}
+}
+
+// Readiness serves /readyz.
+func (r *Registry) Readiness() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if r.draining.Load() {
+			serve(c, Report{Status: "draining", Checks: map[string]Result{}})
+			return
+		}
+		serve(c, r.run(c.Request.Context(), r.readiness))
+	}
+}
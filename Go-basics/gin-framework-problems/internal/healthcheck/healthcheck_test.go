@@ -0,0 +1,56 @@
+package healthcheck_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/healthcheck"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/testutil"
+)
+
+func TestReadiness(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	reg := healthcheck.New(20 * time.Millisecond)
+	r := gin.New()
+	r.GET("/healthz", reg.Liveness())
+	r.GET("/readyz", reg.Readiness())
+
+	reg.Register("database", func(context.Context) error { return nil })
+	w := testutil.Do(t, r, http.MethodGet, "/readyz", nil)
+	testutil.AssertStatus(t, w, http.StatusOK)
+
+	reg.Register("redis", func(context.Context) error { return errors.New("connection refused") })
+	// a check that ignores its context is cut off
+	stuck := make(chan struct{})
+	defer close(stuck)
+	reg.Register("stuck", func(context.Context) error { <-stuck; return nil })
+	w = testutil.Do(t, r, http.MethodGet, "/readyz", nil)
+	testutil.AssertStatus(t, w, http.StatusServiceUnavailable)
+	report := testutil.Decode[healthcheck.Report](t, w)
+	want := map[string]string{
+		"database": "",
+		"redis":    "connection refused",
+		"stuck":    context.DeadlineExceeded.Error(),
+	}
+	if report.Status != "fail" || len(report.Checks) != len(want) {
+		t.Fatalf("report = %+v", report)
+	}
+	for name, msg := range want {
+		if got := report.Checks[name].Error; got != msg {
+			t.Errorf("%s: error %q, want %q", name, got, msg)
+		}
+	}
+
+	reg.Drain()
+	w = testutil.Do(t, r, http.MethodGet, "/readyz", nil)
+	testutil.AssertStatus(t, w, http.StatusServiceUnavailable)
+	if got := testutil.Decode[healthcheck.Report](t, w).Status; got != "draining" {
+		t.Errorf("status while draining = %q", got)
+	}
+	testutil.AssertStatus(t, testutil.Do(t, r, http.MethodGet, "/healthz", nil), http.StatusOK)
+}
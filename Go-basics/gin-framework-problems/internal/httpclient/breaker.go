@@ -0,0 +1,67 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"sync"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/breaker"
+)
+
+// ErrCircuitOpen is returned without sending the request while the host has
+// been failing.
+var ErrCircuitOpen = breaker.ErrOpen
+
+// hostBreaker keeps one circuit per host, named client/host. A failure is a
+// network error or a 5xx answer; the caller canceling is neither a failure
+// nor a success.
+type hostBreaker struct {
+	name string
+	base http.RoundTripper
+	opts []breaker.Option
+
+	mu    sync.Mutex
+	hosts map[string]*breaker.Breaker
+}
+
+func newHostBreaker(name string, base http.RoundTripper, opts []breaker.Option) *hostBreaker {
+	return &hostBreaker{name: name, base: base, opts: opts, hosts: map[string]*breaker.Breaker{}}
+}
+
+func (b *hostBreaker) RoundTrip(req *http.Request) (*http.Response, error) {
+	done, err := b.circuit(req.URL.Host).Allow()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := b.base.RoundTrip(req)
+	switch {
+	case err != nil && (errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)):
+		done(breaker.Ignored)
+	case err != nil || resp.StatusCode >= http.StatusInternalServerError:
+		done(breaker.Failure)
+	default:
+		done(breaker.Success)
+	}
+	return resp, err
+}
+
+// circuit is host's breaker, new the first time host is called.
+func (b *hostBreaker) circuit(host string) *breaker.Breaker {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c, ok := b.hosts[host]
+	if !ok {
+		opts := append(slices.Clip(b.opts), breaker.WithOnStateChange(func(_, to breaker.State) {
+			open := 0.0
+			if to == breaker.Open {
+				open = 1
+			}
+			breakerOpen.WithLabelValues(b.name, host).Set(open)
+		}))
+		c = breaker.New(b.name+"/"+host, opts...)
+		b.hosts[host] = c
+	}
+	return c
+}
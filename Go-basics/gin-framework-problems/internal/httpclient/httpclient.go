@@ -0,0 +1,240 @@
+// Package httpclient builds the http.Clients examples use to call other
+// services. Every request gets a client span and the caller's request ID,
+// is retried with backoff when that is safe, is refused fast while its host
+// keeps failing, and is counted in Prometheus metrics labeled by client name.
+//
+// A request is retried on a network error or a 502, 503 or 504 answer when
+// its method is idempotent, or it carries an Idempotency-Key, and its body
+// can be sent again (no body, or one http.NewRequest knows how to rewind).
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/breaker"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/requestid"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/tracing"
+)
+
+var (
+	requestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_client_requests_total",
+		Help: "Outbound HTTP attempts by client, method and status class (error when none came back).",
+	}, []string{"client", "method", "status"})
+
+	requestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_client_request_duration_seconds",
+		Help:    "Outbound HTTP attempt latency by client and method.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"client", "method"})
+
+	retriesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_client_retries_total",
+		Help: "Outbound HTTP requests sent again, by client.",
+	}, []string{"client"})
+
+	breakerOpen = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "http_client_circuit_open",
+		Help: "1 while the circuit to a host is open, by client and host.",
+	}, []string{"client", "host"})
+)
+
+type options struct {
+	base      http.RoundTripper
+	timeout   time.Duration
+	retries   int
+	backoff   time.Duration
+	failures  int
+	cooldown  time.Duration
+	rate      float64
+	minCalls  int
+	redirects bool
+}
+
+type Option func(*options)
+
+// WithTimeout bounds a whole call, retries included (default 10s).
+func WithTimeout(d time.Duration) Option {
+	return func(o *options) { o.timeout = d }
+}
+
+// WithRetries sets how many times a failed request is sent again (default
+// 2). Callers with their own retry loop, such as job handlers, pass 0.
+func WithRetries(n int) Option {
+	return func(o *options) { o.retries = n }
+}
+
+// WithBackoff sets the delay before the first retry (default 100ms). It
+// doubles on each one, plus up to half again of jitter.
+func WithBackoff(d time.Duration) Option {
+	return func(o *options) { o.backoff = d }
+}
+
+// WithBreaker opens the circuit to a host after failures failed attempts in
+// a row, refusing requests with ErrCircuitOpen for cooldown before letting
+// one through to try again (default 5 and 30s). A failures of 0 turns the
+// breaker off.
+func WithBreaker(failures int, cooldown time.Duration) Option {
+	return func(o *options) { o.failures, o.cooldown = failures, cooldown }
+}
+
+// WithFailureRate also opens the circuit to a host once rate (0 to 1) of its
+// last 20 attempts failed, after at least minCalls, for a host that fails
+// often but not in a row.
+func WithFailureRate(rate float64, minCalls int) Option {
+	return func(o *options) { o.rate, o.minCalls = rate, minCalls }
+}
+
+// WithoutRedirects returns 3xx answers to the caller instead of following
+// them.
+func WithoutRedirects() Option {
+	return func(o *options) { o.redirects = false }
+}
+
+// WithBase sends requests through rt instead of http.DefaultTransport.
+func WithBase(rt http.RoundTripper) Option {
+	return func(o *options) { o.base = rt }
+}
+
+func newOptions(opts []Option) options {
+	o := options{
+		base:      http.DefaultTransport,
+		timeout:   10 * time.Second,
+		retries:   2,
+		backoff:   100 * time.Millisecond,
+		failures:  5,
+		cooldown:  30 * time.Second,
+		redirects: true,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// New returns a client whose metrics are labeled name.
+func New(name string, opts ...Option) *http.Client {
+	o := newOptions(opts)
+	c := &http.Client{Transport: transport(name, o), Timeout: o.timeout}
+	if !o.redirects {
+		// a redirect is an answer, not an instruction to send it elsewhere
+		c.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+	}
+	return c
+}
+
+// NewTransport returns New's transport on its own, for callers that bring
+// their own client or use an httputil.ReverseProxy. WithTimeout and
+// WithoutRedirects don't apply to it.
+func NewTransport(name string, opts ...Option) http.RoundTripper {
+	return transport(name, newOptions(opts))
+}
+
+// transport stacks, outermost first: one span and request ID per call,
+// retries, the breaker, then metrics for each attempt.
+func transport(name string, o options) http.RoundTripper {
+	var rt http.RoundTripper = &instrumented{name: name, base: o.base}
+	if o.failures > 0 {
+		opts := []breaker.Option{breaker.WithConsecutiveFailures(o.failures), breaker.WithCooldown(o.cooldown)}
+		if o.rate > 0 {
+			opts = append(opts, breaker.WithFailureRate(o.rate, o.minCalls, 20))
+		}
+		rt = newHostBreaker(name, rt, opts)
+	}
+	if o.retries > 0 {
+		rt = &retrying{name: name, base: rt, retries: o.retries, backoff: o.backoff}
+	}
+	return tracing.Transport(&requestid.Transport{Base: rt})
+}
+
+// instrumented records each attempt.
+type instrumented struct {
+	name string
+	base http.RoundTripper
+}
+
+func (t *instrumented) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	status := "error"
+	if err == nil {
+		status = strconv.Itoa(resp.StatusCode/100) + "xx"
+	}
+	requestsTotal.WithLabelValues(t.name, req.Method, status).Inc()
+	requestDuration.WithLabelValues(t.name, req.Method).Observe(time.Since(start).Seconds())
+	return resp, err
+}
+
+type retrying struct {
+	name    string
+	base    http.RoundTripper
+	retries int
+	backoff time.Duration
+}
+
+func (t *retrying) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !repeatable(req) {
+		return t.base.RoundTrip(req)
+	}
+	for attempt := 0; ; attempt++ {
+		resp, err := t.base.RoundTrip(req)
+		if attempt == t.retries || !retryable(resp, err) {
+			return resp, err
+		}
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		delay := t.backoff << attempt
+		delay += rand.N(delay/2 + 1)
+		select {
+		case <-time.After(delay):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			// RoundTrippers must not modify the caller's request
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+		retriesTotal.WithLabelValues(t.name).Inc()
+	}
+}
+
+// repeatable reports whether req may be sent more than once.
+func repeatable(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+	default:
+		if req.Header.Get("Idempotency-Key") == "" {
+			return false
+		}
+	}
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		// the caller giving up, or the breaker refusing, isn't worth a retry
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) &&
+			!errors.Is(err, ErrCircuitOpen)
+	}
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
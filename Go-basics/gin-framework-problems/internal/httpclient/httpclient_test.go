@@ -0,0 +1,147 @@
+package httpclient
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// server answers each request with the next of statuses, repeating the last
+// one, and records the bodies it was sent.
+type server struct {
+	mu       sync.Mutex
+	statuses []int
+	bodies   []string
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.bodies = append(s.bodies, string(body))
+	code := s.statuses[0]
+	if len(s.statuses) > 1 {
+		s.statuses = s.statuses[1:]
+	}
+	if code == http.StatusFound {
+		w.Header().Set("Location", "/elsewhere")
+	}
+	w.WriteHeader(code)
+}
+
+func (s *server) attempts() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.bodies)
+}
+
+func start(t *testing.T, statuses ...int) (*server, string) {
+	t.Helper()
+	s := &server{statuses: statuses}
+	srv := httptest.NewServer(s)
+	t.Cleanup(srv.Close)
+	return s, srv.URL
+}
+
+func TestRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		key      string
+		statuses []int
+		status   int
+		attempts int
+	}{
+		{"ok", http.MethodGet, "", []int{200}, 200, 1},
+		{"recovers", http.MethodGet, "", []int{503, 502, 200}, 200, 3},
+		{"gives up", http.MethodGet, "", []int{504}, 504, 3},
+		{"not retryable", http.MethodGet, "", []int{500}, 500, 1},
+		{"client error", http.MethodPut, "", []int{404}, 404, 1},
+		{"post", http.MethodPost, "", []int{503, 200}, 503, 1},
+		{"post with a key", http.MethodPost, "k1", []int{503, 200}, 200, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, url := start(t, tt.statuses...)
+			c := New("test", WithBackoff(time.Millisecond), WithBreaker(0, 0))
+			req, _ := http.NewRequestWithContext(t.Context(), tt.method, url, strings.NewReader("payload"))
+			if tt.key != "" {
+				req.Header.Set("Idempotency-Key", tt.key)
+			}
+			resp, err := c.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.status || s.attempts() != tt.attempts {
+				t.Errorf("%d after %d attempts, want %d after %d", resp.StatusCode, s.attempts(), tt.status, tt.attempts)
+			}
+			for i, body := range s.bodies {
+				if body != "payload" {
+					t.Errorf("attempt %d sent %q, want the whole body", i+1, body)
+				}
+			}
+		})
+	}
+}
+
+func TestUnrewindableBody(t *testing.T) {
+	s, url := start(t, 503, 200)
+	c := New("test", WithBackoff(time.Millisecond), WithBreaker(0, 0))
+	// a reader NewRequest can't rewind
+	req, _ := http.NewRequestWithContext(t.Context(), http.MethodPut, url, io.MultiReader(strings.NewReader("payload")))
+	resp, err := c.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != 503 || s.attempts() != 1 {
+		t.Errorf("%d after %d attempts, want 503 after 1", resp.StatusCode, s.attempts())
+	}
+}
+
+func TestBreaker(t *testing.T) {
+	s, url := start(t, 500, 500, 200)
+	c := New("test", WithRetries(0), WithBreaker(2, 50*time.Millisecond))
+	get := func() error {
+		resp, err := c.Get(url)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+
+	get()
+	get()
+	if err := get(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("third call = %v, want ErrCircuitOpen", err)
+	}
+	if n := s.attempts(); n != 2 {
+		t.Errorf("%d requests reached the server, want 2", n)
+	}
+
+	// after the cooldown one probe goes through, and its success closes the circuit
+	time.Sleep(60 * time.Millisecond)
+	for i := range 2 {
+		if err := get(); err != nil {
+			t.Fatalf("call %d after the cooldown: %v", i+1, err)
+		}
+	}
+}
+
+func TestWithoutRedirects(t *testing.T) {
+	_, url := start(t, http.StatusFound)
+	resp, err := New("test", WithoutRedirects()).Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound || resp.Header.Get("Location") != "/elsewhere" {
+		t.Errorf("got %d to %q, want the 302 itself", resp.StatusCode, resp.Header.Get("Location"))
+	}
+}
@@ -0,0 +1,5 @@
+package hubclient
+
+// client.gen.go is generated from openapi.yaml; edit the spec and regenerate
+// instead of editing it.
+//go:generate go run github.com/oapi-codegen/oapi-codegen/v2/cmd/oapi-codegen -config oapi-codegen.yaml openapi.yaml
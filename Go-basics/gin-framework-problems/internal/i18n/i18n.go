@@ -0,0 +1,130 @@
+// Package i18n picks a locale per request and translates the messages the
+// examples send to clients.
+//
+// Catalogs are keyed by the English text itself (the gettext convention), so
+// call sites keep plain English and a missing translation falls back to it.
+// Messages may use text/template fields, e.g. "{{.Field}} is required".
+// locales/ has en, es and ta.
+package i18n
+
+import (
+	"embed"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	goi18n "github.com/nicksnyder/go-i18n/v2/i18n"
+	"golang.org/x/text/language"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed locales/*.yaml
+var locales embed.FS
+
+// chainKey is where Middleware stores the request's fallback chain.
+const chainKey = "i18n.chain"
+
+// catalog is a language with a catalog and the localizer reading it.
+type catalog struct {
+	tag       language.Tag
+	localizer *goi18n.Localizer
+}
+
+var (
+	bundle = load()
+	// by base language, so en-GB and es-MX find en and es
+	catalogs = make(map[language.Base]catalog)
+	english  catalog
+)
+
+func init() {
+	for _, t := range bundle.LanguageTags() {
+		base, _ := t.Base()
+		catalogs[base] = catalog{tag: t, localizer: goi18n.NewLocalizer(bundle, t.String())}
+	}
+	english = catalogs[language.MustParseBase("en")]
+}
+
+func load() *goi18n.Bundle {
+	b := goi18n.NewBundle(language.English)
+	b.RegisterUnmarshalFunc("yaml", yaml.Unmarshal)
+	files, err := locales.ReadDir("locales")
+	if err != nil {
+		panic(err)
+	}
+	for _, f := range files {
+		if _, err := b.LoadMessageFileFS(locales, "locales/"+f.Name()); err != nil {
+			panic(fmt.Sprintf("i18n: %s: %v", f.Name(), err))
+		}
+	}
+	return b
+}
+
+// Middleware negotiates the locale from ?lang, then Accept-Language, and
+// reports the choice in Content-Language. A message missing from that
+// locale's catalog comes from the next accepted language that has one, then
+// from English: "es-MX, ta;q=0.8" tries es, then ta, then en.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var tags []language.Tag
+		for _, p := range []string{c.Query("lang"), c.GetHeader("Accept-Language")} {
+			// malformed values are skipped, not rejected; each is sorted by q
+			t, _, _ := language.ParseAcceptLanguage(p)
+			tags = append(tags, t...)
+		}
+		chain := fallbackChain(tags)
+		base, _ := chain[0].tag.Base()
+
+		c.Set(chainKey, chain)
+		c.Header("Content-Language", base.String())
+		c.Next()
+	}
+}
+
+// fallbackChain is the catalogs of tags in order, each once, ending with
+// English.
+func fallbackChain(tags []language.Tag) []catalog {
+	var chain []catalog
+	seen := map[language.Base]bool{}
+	for _, t := range append(tags, english.tag) {
+		base, _ := t.Base()
+		if cat, ok := catalogs[base]; ok && !seen[base] {
+			seen[base] = true
+			chain = append(chain, cat)
+		}
+	}
+	return chain
+}
+
+// Supported reports whether lang, a language tag like "ta" or "en-GB", has
+// a catalog.
+func Supported(lang string) bool {
+	t, err := language.Parse(lang)
+	if err != nil {
+		return false
+	}
+	base, _ := t.Base()
+	_, ok := catalogs[base]
+	return ok
+}
+
+// Localize translates msg into the first language of the request's chain
+// whose catalog has it, filling in data. It returns msg (rendered with data)
+// when no catalog has it or Middleware didn't run.
+func Localize(c *gin.Context, msg string, data map[string]any) string {
+	chain, _ := c.Value(chainKey).([]catalog)
+	for _, cat := range chain {
+		// a localizer falls back to English itself; the tag tells
+		out, tag, err := cat.localizer.LocalizeWithTag(&goi18n.LocalizeConfig{MessageID: msg, TemplateData: data})
+		if err == nil && tag == cat.tag {
+			return out
+		}
+	}
+	out, err := english.localizer.Localize(&goi18n.LocalizeConfig{
+		DefaultMessage: &goi18n.Message{ID: msg, Other: msg},
+		TemplateData:   data,
+	})
+	if err != nil && out == "" {
+		return msg
+	}
+	return out
+}
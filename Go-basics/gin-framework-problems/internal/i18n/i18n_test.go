@@ -0,0 +1,104 @@
+package i18n
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/text/language"
+	"gopkg.in/yaml.v3"
+)
+
+// TestCatalogsComplete keeps every catalog in step with en.yaml, so no
+// language silently falls back to English.
+func TestCatalogsComplete(t *testing.T) {
+	read := func(name string) map[string]string {
+		t.Helper()
+		data, err := locales.ReadFile("locales/" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var m map[string]string
+		if err := yaml.Unmarshal(data, &m); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		return m
+	}
+	en := read("en.yaml")
+	entries, err := locales.ReadDir("locales")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		cat := read(e.Name())
+		for k := range en {
+			if cat[k] == "" {
+				t.Errorf("%s has no %q", e.Name(), k)
+			}
+		}
+		for k := range cat {
+			if _, ok := en[k]; !ok {
+				t.Errorf("%s has %q, which en.yaml doesn't", e.Name(), k)
+			}
+		}
+	}
+}
+
+func TestFallbackChain(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   []string
+	}{
+		{"", []string{"en"}},
+		{"es-MX, ta;q=0.8", []string{"es", "ta", "en"}},
+		// sorted by q; unknown and repeated languages are skipped
+		{"fr, ta;q=0.5, es-ES;q=0.9, es;q=0.8", []string{"es", "ta", "en"}},
+		{"en-GB, es;q=0.5", []string{"en", "es"}},
+		{"not a language!", []string{"en"}},
+	}
+	for _, tt := range tests {
+		tags, _, _ := language.ParseAcceptLanguage(tt.accept)
+		var got []string
+		for _, cat := range fallbackChain(tags) {
+			got = append(got, cat.tag.String())
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("fallbackChain(%q) = %v, want %v", tt.accept, got, tt.want)
+		}
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Middleware())
+	r.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, Localize(c, "{{.Field}} is required", map[string]any{"Field": "title"}))
+	})
+	r.GET("/missing", func(c *gin.Context) {
+		c.String(http.StatusOK, Localize(c, "no catalog has {{.Field}}", map[string]any{"Field": "this"}))
+	})
+
+	tests := []struct {
+		path, accept, lang, body string
+	}{
+		{"/", "es-MX", "es", "title es obligatorio"},
+		{"/?lang=ta", "es", "ta", "title தேவை"},
+		{"/", "fr", "en", "title is required"},
+		{"/missing", "es", "es", "no catalog has this"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		req.Header.Set("Accept-Language", tt.accept)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if got := w.Header().Get("Content-Language"); got != tt.lang {
+			t.Errorf("%s with %q: Content-Language = %s, want %s", tt.path, tt.accept, got, tt.lang)
+		}
+		if w.Body.String() != tt.body {
+			t.Errorf("%s with %q: %q, want %q", tt.path, tt.accept, w.Body, tt.body)
+		}
+	}
+}
@@ -0,0 +1,310 @@
+// Package idempotency makes POST endpoints safe to retry. A client sends an
+// Idempotency-Key header; the first request with that key runs and its
+// response is stored, and a retry with the same key gets the stored response
+// back (marked Idempotent-Replayed: true) instead of running again.
+//
+// Keys are scoped to the route and the caller: the principal Auth stored,
+// or the client IP for anonymous routes. Reusing a key for a different
+// request body is refused with 422, and a retry that arrives while the first
+// request is still running gets 409.
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/apperror"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+)
+
+const (
+	// Header is the request header carrying the key.
+	Header = "Idempotency-Key"
+	// ReplayedHeader is set on responses served from the store.
+	ReplayedHeader = "Idempotent-Replayed"
+	// CodeKeyReused is the error code for a key sent with a different request.
+	CodeKeyReused = "idempotency_key_reused"
+
+	maxKeyLength = 255
+)
+
+// Record is what the store keeps for a key: a marker while the first request
+// runs, then its response.
+type Record struct {
+	Done        bool        `json:"done"`
+	Fingerprint string      `json:"fingerprint,omitempty"` // hash of method, URL, content type and body
+	Status      int         `json:"status,omitempty"`
+	Header      http.Header `json:"header,omitempty"`
+	Body        []byte      `json:"body,omitempty"`
+}
+
+// Store keeps records with a TTL.
+type Store interface {
+	// Reserve claims key for a new request, holding it for lockTTL. If key
+	// is already claimed or done it returns that record and false instead.
+	Reserve(ctx context.Context, key string, lockTTL time.Duration) (Record, bool, error)
+	// Complete replaces the claim with the finished request's record.
+	Complete(ctx context.Context, key string, rec Record, ttl time.Duration) error
+	// Release drops the claim so the request can be retried.
+	Release(ctx context.Context, key string) error
+}
+
+// Default is the store the middleware uses unless given one. NewEngine
+// replaces it with a Redis store when redis.addr is set, so that keys hold
+// across every instance.
+var Default Store = NewMemoryStore()
+
+type options struct {
+	store    Store
+	ttl      time.Duration
+	lockTTL  time.Duration
+	maxBody  int
+	required bool
+}
+
+type Option func(*options)
+
+// WithStore uses s instead of Default.
+func WithStore(s Store) Option {
+	return func(o *options) { o.store = s }
+}
+
+// WithTTL sets how long a response is replayed for (default 24h).
+func WithTTL(d time.Duration) Option {
+	return func(o *options) { o.ttl = d }
+}
+
+// WithLockTTL sets how long a request may hold its key before another one
+// can claim it (default 1m), in case the instance running it dies.
+func WithLockTTL(d time.Duration) Option {
+	return func(o *options) { o.lockTTL = d }
+}
+
+// WithMaxBody sets the largest response that is stored (default 1 MiB);
+// bigger ones are sent but not kept, so a retry runs again.
+func WithMaxBody(n int) Option {
+	return func(o *options) { o.maxBody = n }
+}
+
+// Required rejects requests without a key with 400.
+func Required() Option {
+	return func(o *options) { o.required = true }
+}
+
+// Middleware stores and replays responses for requests carrying an
+// Idempotency-Key. Mount it after Auth on the routes it protects.
+//
+// Only responses the handler writes itself are stored, and not 5xx, 408, 409
+// or 429, since a retry of those may succeed. Errors reported with
+// middleware.Fail are rendered later by ErrorHandler, so they release the key
+// too.
+func Middleware(opts ...Option) gin.HandlerFunc {
+	o := options{ttl: 24 * time.Hour, lockTTL: time.Minute, maxBody: 1 << 20}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return func(c *gin.Context) {
+		key := c.GetHeader(Header)
+		switch {
+		case key == "" && o.required:
+			middleware.Fail(c, apperror.BadRequest("Idempotency-Key header is required"))
+			return
+		case key == "":
+			c.Next()
+			return
+		case len(key) > maxKeyLength:
+			middleware.Fail(c, apperror.BadRequest("Idempotency-Key must be at most 255 characters"))
+			return
+		}
+
+		store := o.store
+		if store == nil {
+			store = Default
+		}
+		ctx := c.Request.Context()
+		k := storeKey(c, key)
+		rec, fresh, err := store.Reserve(ctx, k, o.lockTTL)
+		if err != nil {
+			e := apperror.New(http.StatusServiceUnavailable, apperror.CodeUnavailable, "idempotency store unavailable")
+			e.Err = err
+			middleware.Fail(c, e)
+			return
+		}
+		if !fresh {
+			replay(c, rec)
+			return
+		}
+
+		h := newFingerprint(c)
+		body := c.Request.Body
+		c.Request.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.TeeReader(body, h), body}
+		w := &recorder{ResponseWriter: c.Writer, max: o.maxBody}
+		c.Writer = w
+
+		stored := false
+		defer func() {
+			c.Writer = w.ResponseWriter
+			if !stored {
+				// the handler failed, panicked or timed out: let a retry run
+				if err := store.Release(context.WithoutCancel(ctx), k); err != nil {
+					slog.WarnContext(ctx, "idempotency release failed", "error", err)
+				}
+			}
+		}()
+		c.Next()
+
+		if !w.Written() || w.overflow || !storable(w.Status()) {
+			return
+		}
+		// whatever the handler left unread still belongs to the request
+		io.Copy(h, body)
+		rec = Record{
+			Done:        true,
+			Fingerprint: h.Sum(),
+			Status:      w.Status(),
+			Header:      replayHeader(w.Header()),
+			Body:        w.body,
+		}
+		if err := store.Complete(context.WithoutCancel(ctx), k, rec, o.ttl); err != nil {
+			slog.WarnContext(ctx, "idempotency store failed", "error", err)
+			return
+		}
+		stored = true
+	}
+}
+
+// replay answers from rec, or explains why it can't.
+func replay(c *gin.Context, rec Record) {
+	if !rec.Done {
+		c.Header("Retry-After", "1")
+		middleware.Fail(c, apperror.Conflict("a request with this Idempotency-Key is in progress"))
+		return
+	}
+	h := newFingerprint(c)
+	io.Copy(h, c.Request.Body)
+	if h.Sum() != rec.Fingerprint {
+		middleware.Fail(c, apperror.New(http.StatusUnprocessableEntity, CodeKeyReused,
+			"Idempotency-Key was already used for a different request"))
+		return
+	}
+	for name, values := range rec.Header {
+		c.Writer.Header()[name] = values
+	}
+	c.Header(ReplayedHeader, "true")
+	c.Status(rec.Status)
+	c.Writer.Write(rec.Body)
+	c.Abort()
+}
+
+// storeKey scopes key to the route and the caller.
+func storeKey(c *gin.Context, key string) string {
+	scope := "ip:" + c.ClientIP()
+	if v, ok := c.Get(middleware.UserKey); ok {
+		if s, ok := v.(middleware.Subjecter); ok {
+			scope = "user:" + s.Subject()
+		}
+	}
+	sum := sha256.Sum256([]byte(c.Request.Method + "\x00" + c.FullPath() + "\x00" + scope + "\x00" + key))
+	return hex.EncodeToString(sum[:])
+}
+
+// fingerprint hashes a request; the body is written to it as it is read.
+// The boundary of a multipart body is left out: clients pick a random one
+// for each request, so a retry of the same upload rarely sends the same
+// bytes, but its parts are the same.
+type fingerprint struct {
+	h        hash.Hash
+	boundary []byte
+	tail     []byte // may hold the start of a boundary
+}
+
+func newFingerprint(c *gin.Context) *fingerprint {
+	f := &fingerprint{h: sha256.New()}
+	io.WriteString(f.h, c.Request.Method+" "+c.Request.URL.RequestURI()+"\n"+c.ContentType()+"\n")
+	if _, params, err := mime.ParseMediaType(c.GetHeader("Content-Type")); err == nil && params["boundary"] != "" {
+		f.boundary = []byte(params["boundary"])
+	}
+	return f
+}
+
+func (f *fingerprint) Write(p []byte) (int, error) {
+	if f.boundary == nil {
+		return f.h.Write(p)
+	}
+	f.tail = append(f.tail, p...)
+	for {
+		i := bytes.Index(f.tail, f.boundary)
+		if i < 0 {
+			break
+		}
+		f.h.Write(f.tail[:i])
+		f.tail = f.tail[i+len(f.boundary):]
+	}
+	if keep := len(f.boundary) - 1; len(f.tail) > keep {
+		f.h.Write(f.tail[:len(f.tail)-keep])
+		f.tail = append(f.tail[:0], f.tail[len(f.tail)-keep:]...)
+	}
+	return len(p), nil
+}
+
+// Sum is the fingerprint of everything written, in hex.
+func (f *fingerprint) Sum() string {
+	f.h.Write(f.tail)
+	f.tail = nil
+	return hex.EncodeToString(f.h.Sum(nil))
+}
+
+func storable(status int) bool {
+	switch status {
+	case http.StatusRequestTimeout, http.StatusConflict, http.StatusTooManyRequests:
+		return false
+	}
+	return status < http.StatusInternalServerError
+}
+
+// replayHeader keeps the response headers a replay should repeat. Each
+// response gets its own request ID, date and cookies.
+func replayHeader(h http.Header) http.Header {
+	out := h.Clone()
+	for _, name := range []string{"Date", "Content-Length", "Set-Cookie", "X-Request-Id"} {
+		out.Del(name)
+	}
+	return out
+}
+
+// recorder copies the response body while passing it through.
+type recorder struct {
+	gin.ResponseWriter
+	max      int
+	body     []byte
+	overflow bool
+}
+
+func (w *recorder) Write(p []byte) (int, error) {
+	if !w.overflow {
+		if len(w.body)+len(p) > w.max {
+			w.overflow = true
+			w.body = nil
+		} else {
+			w.body = append(w.body, p...)
+		}
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *recorder) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
@@ -0,0 +1,74 @@
+package idempotency_test
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/idempotency"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+)
+
+// upload is a multipart body holding one file, with boundary.
+func upload(t *testing.T, boundary, content string) (*bytes.Buffer, string) {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	if err := mw.SetBoundary(boundary); err != nil {
+		t.Fatal(err)
+	}
+	part, _ := mw.CreateFormFile("file", "notes.txt")
+	io.WriteString(part, content)
+	mw.Close()
+	return &body, mw.FormDataContentType()
+}
+
+func TestMultipartFingerprint(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.ErrorHandler(slog.New(slog.DiscardHandler)))
+	runs := 0
+	r.POST("/upload", idempotency.Middleware(idempotency.WithStore(idempotency.NewMemoryStore())), func(c *gin.Context) {
+		if _, err := c.FormFile("file"); err != nil {
+			c.Status(http.StatusBadRequest)
+			return
+		}
+		runs++
+		c.String(http.StatusCreated, strconv.Itoa(runs))
+	})
+
+	tests := []struct {
+		name, boundary, content string
+		status                  int
+		replayed                bool
+	}{
+		{"first", "boundary-one", "hello", http.StatusCreated, false},
+		// a retry of the same upload picks a new boundary
+		{"retry", "boundary-two", "hello", http.StatusCreated, true},
+		{"other file", "boundary-three", "goodbye", http.StatusUnprocessableEntity, false},
+	}
+	for _, tt := range tests {
+		body, contentType := upload(t, tt.boundary, tt.content)
+		req := httptest.NewRequest(http.MethodPost, "/upload", body)
+		req.Header.Set("Content-Type", contentType)
+		req.Header.Set(idempotency.Header, "upload-1")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tt.status {
+			t.Fatalf("%s: status = %d, want %d: %s", tt.name, w.Code, tt.status, w.Body)
+		}
+		if replayed := w.Header().Get(idempotency.ReplayedHeader) == "true"; replayed != tt.replayed {
+			t.Errorf("%s: replayed = %v, want %v", tt.name, replayed, tt.replayed)
+		}
+	}
+	if runs != 1 {
+		t.Errorf("handler ran %d times, want once", runs)
+	}
+}
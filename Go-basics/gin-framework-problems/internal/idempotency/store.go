@@ -0,0 +1,116 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisStore keeps records in Redis, claiming keys with SET NX so that only
+// one instance runs a request.
+type RedisStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisStore keeps records under prefix+key.
+func NewRedisStore(client *redis.Client, prefix string) *RedisStore {
+	return &RedisStore{client: client, prefix: prefix}
+}
+
+// Ping reports whether Redis is reachable, for the readiness check.
+func (s *RedisStore) Ping(ctx context.Context) error {
+	return s.client.Ping(ctx).Err()
+}
+
+func (s *RedisStore) Reserve(ctx context.Context, key string, lockTTL time.Duration) (Record, bool, error) {
+	pending, _ := json.Marshal(Record{})
+	// the claim can expire between SET NX and GET; try again then
+	for range 3 {
+		ok, err := s.client.SetNX(ctx, s.prefix+key, pending, lockTTL).Result()
+		if err != nil {
+			return Record{}, false, err
+		}
+		if ok {
+			return Record{}, true, nil
+		}
+		data, err := s.client.Get(ctx, s.prefix+key).Bytes()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			return Record{}, false, err
+		}
+		var rec Record
+		if err := json.Unmarshal(data, &rec); err != nil {
+			return Record{}, false, err
+		}
+		return rec, false, nil
+	}
+	return Record{}, false, errors.New("idempotency: key keeps expiring")
+}
+
+func (s *RedisStore) Complete(ctx context.Context, key string, rec Record, ttl time.Duration) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	return s.client.Set(ctx, s.prefix+key, data, ttl).Err()
+}
+
+func (s *RedisStore) Release(ctx context.Context, key string) error {
+	return s.client.Del(ctx, s.prefix+key).Err()
+}
+
+type memoryEntry struct {
+	rec     Record
+	expires time.Time
+}
+
+// MemoryStore is a process-local Store for running without Redis. Expired
+// records are swept every thousand reservations.
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+	ops     int
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: map[string]memoryEntry{}}
+}
+
+func (s *MemoryStore) Reserve(_ context.Context, key string, lockTTL time.Duration) (Record, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	if s.ops++; s.ops%1000 == 0 {
+		for k, e := range s.entries {
+			if now.After(e.expires) {
+				delete(s.entries, k)
+			}
+		}
+	}
+	if e, ok := s.entries[key]; ok && now.Before(e.expires) {
+		return e.rec, false, nil
+	}
+	s.entries[key] = memoryEntry{expires: now.Add(lockTTL)}
+	return Record{}, true, nil
+}
+
+func (s *MemoryStore) Complete(_ context.Context, key string, rec Record, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[key] = memoryEntry{rec: rec, expires: time.Now().Add(ttl)}
+	return nil
+}
+
+func (s *MemoryStore) Release(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, key)
+	return nil
+}
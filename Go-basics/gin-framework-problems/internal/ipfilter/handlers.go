@@ -0,0 +1,100 @@
+package ipfilter
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/apperror"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/audit"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+)
+
+// Routes mounts the admin API on g:
+//
+//	GET    /                   both lists
+//	PUT    /:list              replace {networks}
+//	POST   /:list              add {network}
+//	DELETE /:list?network=     remove
+//
+// where :list is allow or deny, and a network is CIDR, like 10.0.0.0/8, or
+// one address.
+func (f *Filter) Routes(g gin.IRouter) {
+	g.GET("", f.list)
+	g.PUT("/:list", middleware.Handle(f.put))
+	g.POST("/:list", middleware.Handle(f.add))
+	g.DELETE("/:list", middleware.Handle(f.remove))
+}
+
+func (f *Filter) list(c *gin.Context) {
+	c.JSON(http.StatusOK, f.Lists())
+}
+
+func (f *Filter) put(c *gin.Context) error {
+	var req struct {
+		Networks []string `json:"networks" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return middleware.BadInput(c, err)
+	}
+	networks, err := f.Set(c.Param("list"), req.Networks)
+	if err != nil {
+		return changeError(err)
+	}
+	recordChange(c, c.Param("list"), "set", networks...)
+	c.JSON(http.StatusOK, gin.H{"list": c.Param("list"), "networks": networks})
+	return nil
+}
+
+func (f *Filter) add(c *gin.Context) error {
+	var req struct {
+		Network string `json:"network" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return middleware.BadInput(c, err)
+	}
+	networks, err := f.Add(c.Param("list"), req.Network)
+	if err != nil {
+		return changeError(err)
+	}
+	recordChange(c, c.Param("list"), "add", req.Network)
+	c.JSON(http.StatusOK, gin.H{"list": c.Param("list"), "networks": networks})
+	return nil
+}
+
+func (f *Filter) remove(c *gin.Context) error {
+	network := c.Query("network")
+	if network == "" {
+		return apperror.BadRequest("network query parameter is required")
+	}
+	removed, err := f.Remove(c.Param("list"), network)
+	if err != nil {
+		return changeError(err)
+	}
+	if !removed {
+		return apperror.NotFound("network not on the list")
+	}
+	recordChange(c, c.Param("list"), "remove", network)
+	c.Status(http.StatusNoContent)
+	return nil
+}
+
+// changeError is the answer to an error changing a list: 404 for a list
+// other than allow and deny, 400 for anything else.
+func changeError(err error) error {
+	if errors.Is(err, ErrUnknownList) {
+		return apperror.NotFound(err.Error())
+	}
+	return apperror.BadRequest(err.Error())
+}
+
+// recordChange writes a successful change to list to the audit log.
+func recordChange(c *gin.Context, list, change string, networks ...string) {
+	details := map[string]string{"change": change}
+	if len(networks) > 0 {
+		details["networks"] = strings.Join(networks, ",")
+	}
+	audit.Record(c, audit.Event{Action: audit.IPListUpdate, Outcome: audit.Success, Target: list, Details: details})
+}
@@ -0,0 +1,151 @@
+// Package ipfilter keeps the networks rate limiters treat specially: the
+// allow list, whose clients are never limited, such as health checkers and
+// internal networks, and the deny list, whose clients are refused with a
+// 403 before they are counted. A client on both is denied.
+//
+// The lists start from rate_limit.allow and rate_limit.deny in the config
+// and can be changed at runtime through the admin API NewEngine mounts at
+// /admin/ips. Limiters consult them when built with
+// middleware.WithIPFilter(ipfilter.Default).
+package ipfilter
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// The lists.
+const (
+	Allow = "allow"
+	Deny  = "deny"
+)
+
+var ErrUnknownList = errors.New("list must be allow or deny")
+
+// Filter holds the lists. It is safe for concurrent use.
+type Filter struct {
+	mu    sync.RWMutex
+	lists map[string][]netip.Prefix
+}
+
+func New() *Filter {
+	return &Filter{lists: map[string][]netip.Prefix{Allow: nil, Deny: nil}}
+}
+
+// Default is the filter the examples and the admin API share.
+var Default = New()
+
+// ParsePrefix reads a network in CIDR notation, or a single address as the
+// network of just it.
+func ParsePrefix(s string) (netip.Prefix, error) {
+	s = strings.TrimSpace(s)
+	if !strings.Contains(s, "/") {
+		addr, err := netip.ParseAddr(s)
+		if err != nil {
+			return netip.Prefix{}, fmt.Errorf("%q is not an address or CIDR network", s)
+		}
+		addr = addr.Unmap()
+		return netip.PrefixFrom(addr, addr.BitLen()), nil
+	}
+	p, err := netip.ParsePrefix(s)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("%q is not an address or CIDR network", s)
+	}
+	if p.Addr().Is4In6() {
+		p = netip.PrefixFrom(p.Addr().Unmap(), p.Bits()-96)
+	}
+	return p.Masked(), nil
+}
+
+// Check reports whether ip is on the allow list and whether it is on the
+// deny list. An address that doesn't parse is on neither.
+func (f *Filter) Check(ip string) (allowed, denied bool) {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false, false
+	}
+	addr = addr.Unmap()
+	contains := func(p netip.Prefix) bool { return p.Contains(addr) }
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return slices.ContainsFunc(f.lists[Allow], contains), slices.ContainsFunc(f.lists[Deny], contains)
+}
+
+// Set replaces list with networks.
+func (f *Filter) Set(list string, networks []string) ([]string, error) {
+	prefixes := make([]netip.Prefix, 0, len(networks))
+	for _, n := range networks {
+		p, err := ParsePrefix(n)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(prefixes, p) {
+			prefixes = append(prefixes, p)
+		}
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.lists[list]; !ok {
+		return nil, ErrUnknownList
+	}
+	f.lists[list] = prefixes
+	return format(prefixes), nil
+}
+
+// Add puts network on list, if it isn't there already.
+func (f *Filter) Add(list, network string) ([]string, error) {
+	p, err := ParsePrefix(network)
+	if err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	prefixes, ok := f.lists[list]
+	if !ok {
+		return nil, ErrUnknownList
+	}
+	if !slices.Contains(prefixes, p) {
+		// a copy, so a List taken earlier doesn't change under its caller
+		f.lists[list] = append(slices.Clip(prefixes), p)
+	}
+	return format(f.lists[list]), nil
+}
+
+// Remove takes network off list. It reports false when it wasn't on it.
+func (f *Filter) Remove(list, network string) (bool, error) {
+	p, err := ParsePrefix(network)
+	if err != nil {
+		return false, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	prefixes, ok := f.lists[list]
+	if !ok {
+		return false, ErrUnknownList
+	}
+	i := slices.Index(prefixes, p)
+	if i < 0 {
+		return false, nil
+	}
+	f.lists[list] = slices.Delete(slices.Clone(prefixes), i, i+1)
+	return true, nil
+}
+
+// Lists returns both lists, by name.
+func (f *Filter) Lists() map[string][]string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return map[string][]string{Allow: format(f.lists[Allow]), Deny: format(f.lists[Deny])}
+}
+
+func format(prefixes []netip.Prefix) []string {
+	out := make([]string, len(prefixes))
+	for i, p := range prefixes {
+		out[i] = p.String()
+	}
+	return out
+}
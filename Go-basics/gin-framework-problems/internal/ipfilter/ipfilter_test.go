@@ -0,0 +1,114 @@
+package ipfilter
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+)
+
+func TestParsePrefix(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"10.0.0.0/8", "10.0.0.0/8"},
+		{"10.1.2.3/8", "10.0.0.0/8"},
+		{" 192.0.2.10 ", "192.0.2.10/32"},
+		{"::ffff:192.0.2.10", "192.0.2.10/32"},
+		{"::ffff:10.0.0.0/104", "10.0.0.0/8"},
+		{"2001:db8::/32", "2001:db8::/32"},
+		{"2001:db8::1", "2001:db8::1/128"},
+	}
+	for _, tt := range tests {
+		got, err := ParsePrefix(tt.in)
+		if err != nil || got.String() != tt.want {
+			t.Errorf("ParsePrefix(%q) = %v, %v; want %s", tt.in, got, err, tt.want)
+		}
+	}
+	for _, bad := range []string{"", "10.0.0", "10.0.0.0/33", "example.com"} {
+		if _, err := ParsePrefix(bad); err == nil {
+			t.Errorf("ParsePrefix(%q): no error", bad)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	f := New()
+	if _, err := f.Set(Allow, []string{"10.0.0.0/8", "192.0.2.10"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Add(Deny, "10.9.0.0/16"); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		ip              string
+		allowed, denied bool
+	}{
+		{"10.1.2.3", true, false},
+		{"::ffff:10.1.2.3", true, false},
+		{"192.0.2.10", true, false},
+		{"192.0.2.11", false, false},
+		{"10.9.1.1", true, true}, // the caller lets deny win
+		{"not an ip", false, false},
+	}
+	for _, tt := range tests {
+		if allowed, denied := f.Check(tt.ip); allowed != tt.allowed || denied != tt.denied {
+			t.Errorf("Check(%q) = %v, %v; want %v, %v", tt.ip, allowed, denied, tt.allowed, tt.denied)
+		}
+	}
+
+	lists := f.Lists()
+	if removed, err := f.Remove(Allow, "192.0.2.10"); !removed || err != nil {
+		t.Errorf("Remove = %v, %v; want it removed", removed, err)
+	}
+	if removed, _ := f.Remove(Allow, "192.0.2.10"); removed {
+		t.Error("Remove of a network not on the list reported true")
+	}
+	if !slices.Equal(lists[Allow], []string{"10.0.0.0/8", "192.0.2.10/32"}) {
+		t.Errorf("Lists taken before Remove = %v, changed under the caller", lists[Allow])
+	}
+	if _, err := f.Add("maybe", "10.0.0.1"); err != ErrUnknownList {
+		t.Errorf("Add to an unknown list: err = %v, want ErrUnknownList", err)
+	}
+}
+
+func TestRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	f := New()
+	r := gin.New()
+	r.Use(middleware.ErrorHandler(slog.New(slog.DiscardHandler)))
+	f.Routes(r.Group("/ips"))
+
+	tests := []struct {
+		method, path, body string
+		status             int
+	}{
+		{http.MethodGet, "/ips", "", http.StatusOK},
+		{http.MethodPost, "/ips/deny", `{"network":"203.0.113.0/24"}`, http.StatusOK},
+		{http.MethodPost, "/ips/deny", `{"network":"203.0.113"}`, http.StatusBadRequest},
+		{http.MethodPost, "/ips/maybe", `{"network":"203.0.113.0/24"}`, http.StatusNotFound},
+		{http.MethodPut, "/ips/allow", `{"networks":["10.0.0.0/8"]}`, http.StatusOK},
+		{http.MethodPut, "/ips/allow", `{}`, http.StatusBadRequest},
+		{http.MethodDelete, "/ips/deny?network=203.0.113.0/24", "", http.StatusNoContent},
+		{http.MethodDelete, "/ips/deny?network=203.0.113.0/24", "", http.StatusNotFound},
+		{http.MethodDelete, "/ips/deny", "", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tt.status {
+			t.Errorf("%s %s %s: status = %d, want %d; body: %s", tt.method, tt.path, tt.body, w.Code, tt.status, w.Body)
+		}
+	}
+	if allowed, _ := f.Check("10.1.2.3"); !allowed {
+		t.Error("PUT didn't replace the allow list")
+	}
+}
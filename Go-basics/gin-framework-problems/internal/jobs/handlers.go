@@ -0,0 +1,118 @@
+package jobs
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/apperror"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/pagination"
+)
+
+// Routes mounts the admin API of the process's pools on g, as one queue:
+//
+//	GET    /                 workers and jobs by status
+//	GET    /list             jobs, newest first; ?status=, ?type=
+//	GET    /dead-letters     failed jobs, out of attempts
+//	GET    /:id
+//	DELETE /:id              cancel
+//	POST   /:id/retry        queue a failed job again
+func Routes(g gin.IRouter) {
+	g.GET("", stats)
+	g.GET("/list", list(""))
+	g.GET("/dead-letters", list(StatusFailed))
+	g.GET("/:id", middleware.Handle(get))
+	g.DELETE("/:id", middleware.Handle(cancel))
+	g.POST("/:id/retry", middleware.Handle(retry))
+}
+
+var errJobNotFound = apperror.NotFound("job not found")
+
+func stats(c *gin.Context) {
+	total := Stats{ByStatus: map[Status]int{}}
+	for _, p := range allPools() {
+		st := p.Stats()
+		total.Workers += st.Workers
+		for status, n := range st.ByStatus {
+			total.ByStatus[status] += n
+		}
+	}
+	c.JSON(http.StatusOK, total)
+}
+
+func list(status Status) gin.HandlerFunc {
+	return middleware.Handle(func(c *gin.Context) error {
+		p, err := pagination.ParseParams(c)
+		if err != nil {
+			return apperror.BadRequest(err.Error())
+		}
+		want := status
+		if want == "" {
+			want = Status(c.Query("status"))
+		}
+		var all []Job
+		for _, pool := range allPools() {
+			all = append(all, pool.List(want)...)
+		}
+		if typ := c.Query("type"); typ != "" {
+			all = slices.DeleteFunc(all, func(j Job) bool { return j.Type != typ })
+		}
+		slices.SortStableFunc(all, func(a, b Job) int { return b.CreatedAt.Compare(a.CreatedAt) })
+		pagination.Write(c, pagination.NewPage(all, p))
+		return nil
+	})
+}
+
+// find is the pool tracking the job with id.
+func find(id string) (*Pool, Job, bool) {
+	for _, p := range allPools() {
+		if job, ok := p.Get(id); ok {
+			return p, job, true
+		}
+	}
+	return nil, Job{}, false
+}
+
+func get(c *gin.Context) error {
+	_, job, ok := find(c.Param("id"))
+	if !ok {
+		return errJobNotFound
+	}
+	c.JSON(http.StatusOK, job)
+	return nil
+}
+
+func cancel(c *gin.Context) error {
+	p, _, ok := find(c.Param("id"))
+	if !ok {
+		return errJobNotFound
+	}
+	job, err := p.Cancel(c.Param("id"))
+	if errors.Is(err, ErrFinished) {
+		return apperror.Conflict("job already " + string(job.Status))
+	}
+	c.JSON(http.StatusOK, job)
+	return nil
+}
+
+func retry(c *gin.Context) error {
+	p, _, ok := find(c.Param("id"))
+	if !ok {
+		return errJobNotFound
+	}
+	job, err := p.Retry(c.Request.Context(), c.Param("id"))
+	switch {
+	case errors.Is(err, ErrNotFailed):
+		return apperror.Conflict("job is " + string(job.Status) + ", not failed")
+	case errors.Is(err, ErrQueueFull), errors.Is(err, ErrClosed):
+		c.Header("Retry-After", "5")
+		return apperror.New(http.StatusServiceUnavailable, apperror.CodeUnavailable, err.Error())
+	case err != nil:
+		return err
+	}
+	c.JSON(http.StatusAccepted, job)
+	return nil
+}
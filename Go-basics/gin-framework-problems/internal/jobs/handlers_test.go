@@ -0,0 +1,94 @@
+package jobs_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/jobs"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/pagination"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/testutil"
+)
+
+// wait polls the pool until the job with id is done.
+func wait(t *testing.T, p *jobs.Pool, id string) jobs.Job {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		job, _ := p.Get(id)
+		if job.Status.Done() {
+			return job
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job %s still %s", id, job.Status)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestAdminRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := slog.New(slog.DiscardHandler)
+	p := jobs.NewPool(jobs.WithWorkers(1), jobs.WithMaxAttempts(1), jobs.WithLogger(logger))
+	// fails until fixed, reporting how far it got
+	var fixed atomic.Bool
+	p.Handle("flaky", func(ctx context.Context, _ json.RawMessage) error {
+		jobs.SetResult(ctx, gin.H{"fixed": fixed.Load()})
+		if !fixed.Load() {
+			return errors.New("not yet")
+		}
+		return nil
+	})
+	p.Start()
+	t.Cleanup(func() { p.Shutdown(context.Background()) })
+	r := gin.New()
+	r.Use(middleware.ErrorHandler(logger))
+	jobs.Routes(r.Group("/admin/jobs"))
+
+	job, err := p.Enqueue(t.Context(), "flaky", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job = wait(t, p, job.ID); job.Status != jobs.StatusFailed {
+		t.Fatalf("job is %s, want failed after its one attempt", job.Status)
+	}
+	w := testutil.Do(t, r, http.MethodGet, "/admin/jobs/dead-letters", nil)
+	testutil.AssertStatus(t, w, http.StatusOK)
+	if page := testutil.Decode[pagination.Page[jobs.Job]](t, w); page.Total != 1 || page.Items[0].ID != job.ID {
+		t.Fatalf("dead letters = %+v", page)
+	}
+	if got := string(job.Result); got != `{"fixed":false}` {
+		t.Errorf("result of the failed attempt = %s", got)
+	}
+
+	fixed.Store(true)
+	testutil.AssertStatus(t, testutil.Do(t, r, http.MethodPost, "/admin/jobs/"+job.ID+"/retry", nil), http.StatusAccepted)
+	if job = wait(t, p, job.ID); job.Status != jobs.StatusSucceeded || job.Attempts != 1 {
+		t.Fatalf("retried job is %s after %d attempts", job.Status, job.Attempts)
+	}
+	if got := string(job.Result); got != `{"fixed":true}` {
+		t.Errorf("result = %s", got)
+	}
+
+	tests := []struct {
+		method, path string
+		status       int
+	}{
+		{http.MethodPost, "/admin/jobs/" + job.ID + "/retry", http.StatusConflict}, // not failed
+		{http.MethodDelete, "/admin/jobs/" + job.ID, http.StatusConflict},          // finished
+		{http.MethodGet, "/admin/jobs/nope", http.StatusNotFound},
+		{http.MethodGet, "/admin/jobs/list?status=succeeded", http.StatusOK},
+		{http.MethodGet, "/admin/jobs", http.StatusOK},
+	}
+	for _, tt := range tests {
+		testutil.AssertStatus(t, testutil.Do(t, r, tt.method, tt.path, nil), tt.status)
+	}
+}
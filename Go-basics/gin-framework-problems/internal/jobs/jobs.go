@@ -0,0 +1,88 @@
+// Package jobs runs background work outside the request that asked for it:
+// a queue (in memory or in Redis), a worker pool with retries and
+// exponential backoff, and status tracking for each job. Jobs out of
+// attempts stay failed, as dead letters, until retried; Routes serves an
+// admin API over every pool.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Status is where a job is in its lifecycle.
+type Status string
+
+const (
+	StatusQueued    Status = "queued"
+	StatusRunning   Status = "running"
+	StatusRetrying  Status = "retrying" // failed, waiting for its backoff
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed" // out of attempts
+	StatusCanceled  Status = "canceled"
+)
+
+// Done reports whether the job will not run again.
+func (s Status) Done() bool {
+	return s == StatusSucceeded || s == StatusFailed || s == StatusCanceled
+}
+
+// Job is one unit of work and its progress.
+type Job struct {
+	ID          string          `json:"id"`
+	Type        string          `json:"type"`
+	Payload     json.RawMessage `json:"payload,omitempty"`
+	Status      Status          `json:"status"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"max_attempts"`
+	LastError   string          `json:"last_error,omitempty"`
+	Result      json.RawMessage `json:"result,omitempty"` // what the handler passed to SetResult
+	NextRunAt   *time.Time      `json:"next_run_at,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// Handler does the work for one job type. The context is canceled when the
+// job is canceled or the pool shuts down.
+type Handler func(ctx context.Context, payload json.RawMessage) error
+
+var (
+	ErrNotFound    = errors.New("jobs: job not found")
+	ErrUnknownType = errors.New("jobs: no handler for job type")
+	ErrFinished    = errors.New("jobs: job already finished")
+	ErrNotFailed   = errors.New("jobs: only failed jobs can be retried")
+	ErrQueueFull   = errors.New("jobs: queue is full")
+	ErrClosed      = errors.New("jobs: pool is shut down")
+)
+
+// permanentError marks a failure that retrying cannot fix.
+type permanentError struct{ err error }
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so the pool fails the job without retrying, e.g. for a
+// payload that will never parse.
+func Permanent(err error) error {
+	return permanentError{err}
+}
+
+type resultKey struct{}
+
+// SetResult records v, as JSON, as the result of the job running with ctx,
+// for whoever polls it; a later call replaces it. The result of a failed
+// attempt is kept too, so a handler can report how far it got.
+func SetResult(ctx context.Context, v any) error {
+	slot, ok := ctx.Value(resultKey{}).(*json.RawMessage)
+	if !ok {
+		return errors.New("jobs: SetResult outside a job")
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	*slot = data
+	return nil
+}
@@ -0,0 +1,396 @@
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Pool runs queued jobs on a fixed number of workers and tracks their status.
+// Failed jobs are retried after base*2^(attempt-1), capped at maxBackoff,
+// until they run out of attempts.
+type Pool struct {
+	queue       Queue
+	workers     int
+	maxAttempts int
+	backoff     time.Duration
+	maxBackoff  time.Duration
+	logger      *slog.Logger
+
+	mu       sync.Mutex
+	handlers map[string]Handler
+	jobs     map[string]*Job
+	cancels  map[string]context.CancelFunc // jobs that are running right now
+
+	ctx  context.Context // canceled by Shutdown
+	stop context.CancelFunc
+	wg   sync.WaitGroup
+}
+
+type Option func(*Pool)
+
+// WithQueue replaces the default in-memory queue of 1000 jobs.
+func WithQueue(q Queue) Option {
+	return func(p *Pool) { p.queue = q }
+}
+
+func WithWorkers(n int) Option {
+	return func(p *Pool) { p.workers = n }
+}
+
+func WithMaxAttempts(n int) Option {
+	return func(p *Pool) { p.maxAttempts = n }
+}
+
+func WithBackoff(base, max time.Duration) Option {
+	return func(p *Pool) {
+		p.backoff = base
+		p.maxBackoff = max
+	}
+}
+
+func WithLogger(logger *slog.Logger) Option {
+	return func(p *Pool) { p.logger = logger }
+}
+
+// NewPool returns a pool with no handlers. Register them with Handle, then
+// call Start.
+func NewPool(opts ...Option) *Pool {
+	p := &Pool{
+		workers:     4,
+		maxAttempts: 5,
+		backoff:     time.Second,
+		maxBackoff:  time.Minute,
+		logger:      slog.Default(),
+		handlers:    map[string]Handler{},
+		jobs:        map[string]*Job{},
+		cancels:     map[string]context.CancelFunc{},
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	if p.queue == nil {
+		p.queue = NewMemoryQueue(1000)
+	}
+	p.ctx, p.stop = context.WithCancel(context.Background())
+	registry.mu.Lock()
+	registry.pools = append(registry.pools, p)
+	registry.mu.Unlock()
+	return p
+}
+
+// registry holds every pool NewPool made, for Routes.
+var registry struct {
+	mu    sync.Mutex
+	pools []*Pool
+}
+
+// allPools is every pool NewPool made.
+func allPools() []*Pool {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	return slices.Clone(registry.pools)
+}
+
+// Handle registers the handler for jobs of type typ.
+func (p *Pool) Handle(typ string, h Handler) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.handlers[typ] = h
+}
+
+// Start launches the workers.
+func (p *Pool) Start() {
+	for range p.workers {
+		p.wg.Add(1)
+		go p.work()
+	}
+}
+
+// Shutdown stops taking jobs, cancels the running ones and waits for the
+// workers to return or ctx to expire. Jobs waiting for a retry stay in the
+// retrying state.
+func (p *Pool) Shutdown(ctx context.Context) error {
+	p.stop()
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("jobs: workers still running: %w", ctx.Err())
+	}
+}
+
+// Enqueue queues a job of type typ. payload is marshaled to JSON.
+func (p *Pool) Enqueue(ctx context.Context, typ string, payload any) (Job, error) {
+	if p.ctx.Err() != nil {
+		return Job{}, ErrClosed
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return Job{}, err
+	}
+
+	p.mu.Lock()
+	if _, ok := p.handlers[typ]; !ok {
+		p.mu.Unlock()
+		return Job{}, fmt.Errorf("%w %q", ErrUnknownType, typ)
+	}
+	now := time.Now()
+	job := &Job{
+		ID:          rand.Text(),
+		Type:        typ,
+		Payload:     data,
+		Status:      StatusQueued,
+		MaxAttempts: p.maxAttempts,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	p.jobs[job.ID] = job
+	snapshot := *job
+	p.mu.Unlock()
+
+	if err := p.queue.Push(ctx, snapshot); err != nil {
+		p.mu.Lock()
+		delete(p.jobs, job.ID)
+		p.mu.Unlock()
+		return Job{}, err
+	}
+	return snapshot, nil
+}
+
+// Get returns a copy of the job with the given ID.
+func (p *Pool) Get(id string) (Job, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	job, ok := p.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	return *job, true
+}
+
+// List returns copies of the jobs with status, or of every job if status
+// is empty, newest first.
+func (p *Pool) List(status Status) []Job {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var out []Job
+	for _, job := range p.jobs {
+		if status == "" || job.Status == status {
+			out = append(out, *job)
+		}
+	}
+	slices.SortFunc(out, func(a, b Job) int { return b.CreatedAt.Compare(a.CreatedAt) })
+	return out
+}
+
+// Stats is a pool at a glance.
+type Stats struct {
+	Workers  int            `json:"workers"`
+	ByStatus map[Status]int `json:"by_status"` // jobs tracked, by status
+}
+
+// Stats counts the pool's jobs by status.
+func (p *Pool) Stats() Stats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	st := Stats{Workers: p.workers, ByStatus: map[Status]int{}}
+	for _, job := range p.jobs {
+		st.ByStatus[job.Status]++
+	}
+	return st
+}
+
+// Retry queues a failed job again, with its attempts back to zero, for
+// once whatever failed it has been fixed.
+func (p *Pool) Retry(ctx context.Context, id string) (Job, error) {
+	p.mu.Lock()
+	job, ok := p.jobs[id]
+	switch {
+	case !ok:
+		p.mu.Unlock()
+		return Job{}, ErrNotFound
+	case job.Status != StatusFailed:
+		p.mu.Unlock()
+		return *job, ErrNotFailed
+	}
+	job.Status = StatusQueued
+	job.Attempts = 0
+	job.UpdatedAt = time.Now()
+	snapshot := *job
+	p.mu.Unlock()
+
+	if err := p.queue.Push(ctx, snapshot); err != nil {
+		p.mu.Lock()
+		job.Status = StatusFailed
+		job.UpdatedAt = time.Now()
+		p.mu.Unlock()
+		return Job{}, err
+	}
+	return snapshot, nil
+}
+
+// Cancel stops a job. A queued or retrying job is skipped when a worker
+// reaches it; a running job has its context canceled.
+func (p *Pool) Cancel(id string) (Job, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	job, ok := p.jobs[id]
+	switch {
+	case !ok:
+		return Job{}, ErrNotFound
+	case job.Status.Done():
+		return *job, ErrFinished
+	}
+	job.Status = StatusCanceled
+	job.NextRunAt = nil
+	job.UpdatedAt = time.Now()
+	if cancel, ok := p.cancels[id]; ok {
+		cancel()
+	}
+	return *job, nil
+}
+
+func (p *Pool) work() {
+	defer p.wg.Done()
+	for {
+		job, err := p.queue.Pop(p.ctx)
+		if err != nil {
+			if p.ctx.Err() != nil {
+				return
+			}
+			p.logger.Error("jobs: pop", "error", err)
+			select {
+			case <-time.After(time.Second):
+			case <-p.ctx.Done():
+				return
+			}
+			continue
+		}
+		p.run(job)
+	}
+}
+
+func (p *Pool) run(queued Job) {
+	p.mu.Lock()
+	job, ok := p.jobs[queued.ID]
+	if !ok {
+		// enqueued by another process sharing the Redis queue
+		job = &queued
+		p.jobs[job.ID] = job
+	}
+	if job.Status == StatusCanceled {
+		p.mu.Unlock()
+		return
+	}
+	ctx, cancel := context.WithCancel(p.ctx)
+	defer cancel()
+	result := job.Result
+	ctx = context.WithValue(ctx, resultKey{}, &result)
+	p.cancels[job.ID] = cancel
+	job.Status = StatusRunning
+	job.Attempts++
+	job.NextRunAt = nil
+	job.UpdatedAt = time.Now()
+	h := p.handlers[job.Type]
+	payload, attempt := job.Payload, job.Attempts
+	p.mu.Unlock()
+
+	start := time.Now()
+	err := p.call(ctx, h, job.Type, payload)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.cancels, job.ID)
+	job.Result = result
+	job.UpdatedAt = time.Now()
+	log := p.logger.With("job_id", job.ID, "job_type", job.Type, "attempt", attempt, "duration", time.Since(start))
+
+	var permanent permanentError
+	switch {
+	case job.Status == StatusCanceled:
+		log.Info("job canceled")
+	case err == nil:
+		job.Status = StatusSucceeded
+		job.LastError = ""
+		log.Info("job succeeded")
+	case p.ctx.Err() != nil:
+		// shutting down: the handler was interrupted, not broken
+		job.Status = StatusRetrying
+		job.LastError = err.Error()
+		log.Warn("job interrupted by shutdown", "error", err)
+	case errors.As(err, &permanent) || attempt >= job.MaxAttempts:
+		job.Status = StatusFailed
+		job.LastError = err.Error()
+		log.Error("job failed", "error", err)
+	default:
+		delay := p.delay(attempt)
+		next := time.Now().Add(delay)
+		job.Status = StatusRetrying
+		job.LastError = err.Error()
+		job.NextRunAt = &next
+		log.Warn("job will be retried", "error", err, "retry_in", delay)
+		p.wg.Add(1)
+		go p.retry(*job, delay)
+	}
+}
+
+// call runs h, turning a panic into an error so one bad job can't take a
+// worker down.
+func (p *Pool) call(ctx context.Context, h Handler, typ string, payload json.RawMessage) (err error) {
+	if h == nil {
+		return Permanent(fmt.Errorf("%w %q", ErrUnknownType, typ))
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return h(ctx, payload)
+}
+
+func (p *Pool) delay(attempt int) time.Duration {
+	d := p.backoff
+	for i := 1; i < attempt && d < p.maxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, p.maxBackoff)
+}
+
+func (p *Pool) retry(job Job, delay time.Duration) {
+	defer p.wg.Done()
+	t := time.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-t.C:
+	case <-p.ctx.Done():
+		return
+	}
+
+	p.mu.Lock()
+	canceled := p.jobs[job.ID].Status == StatusCanceled
+	p.mu.Unlock()
+	if canceled {
+		return
+	}
+	if err := p.queue.Push(p.ctx, job); err != nil {
+		p.mu.Lock()
+		j := p.jobs[job.ID]
+		j.Status = StatusFailed
+		j.LastError = fmt.Sprintf("requeue: %v", err)
+		j.NextRunAt = nil
+		j.UpdatedAt = time.Now()
+		p.mu.Unlock()
+	}
+}
@@ -0,0 +1,40 @@
+package jobs
+
+import "context"
+
+// Queue hands jobs from Enqueue to the workers. Job state lives in the Pool;
+// the queue only needs to carry the job until a worker picks it up.
+type Queue interface {
+	Push(ctx context.Context, job Job) error
+	// Pop blocks until a job is available or ctx is done.
+	Pop(ctx context.Context) (Job, error)
+}
+
+// MemoryQueue is a bounded in-process queue. Jobs still queued when the
+// process exits are lost.
+type MemoryQueue struct {
+	ch chan Job
+}
+
+// NewMemoryQueue returns a queue that holds up to size jobs.
+func NewMemoryQueue(size int) *MemoryQueue {
+	return &MemoryQueue{ch: make(chan Job, size)}
+}
+
+func (q *MemoryQueue) Push(ctx context.Context, job Job) error {
+	select {
+	case q.ch <- job:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+func (q *MemoryQueue) Pop(ctx context.Context) (Job, error) {
+	select {
+	case job := <-q.ch:
+		return job, nil
+	case <-ctx.Done():
+		return Job{}, ctx.Err()
+	}
+}
@@ -0,0 +1,60 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisQueue keeps queued jobs in a Redis list, so they survive a restart
+// and several processes can share the work.
+type RedisQueue struct {
+	client *redis.Client
+	key    string
+}
+
+// NewRedisQueue stores jobs in the list named key.
+func NewRedisQueue(client *redis.Client, key string) *RedisQueue {
+	return &RedisQueue{client: client, key: key}
+}
+
+func (q *RedisQueue) Push(ctx context.Context, job Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	return q.client.LPush(ctx, q.key, data).Err()
+}
+
+func (q *RedisQueue) Pop(ctx context.Context) (Job, error) {
+	for {
+		// a short block so shutdown is noticed even when the list stays empty
+		res, err := q.client.BRPop(ctx, time.Second, q.key).Result()
+		switch {
+		case errors.Is(err, redis.Nil):
+			if ctx.Err() != nil {
+				return Job{}, ctx.Err()
+			}
+			continue
+		case err != nil:
+			if ctx.Err() != nil {
+				return Job{}, ctx.Err()
+			}
+			return Job{}, err
+		}
+
+		var job Job
+		if err := json.Unmarshal([]byte(res[1]), &job); err != nil {
+			return Job{}, err
+		}
+		return job, nil
+	}
+}
+
+// Ping is a readiness check for the Redis connection.
+func (q *RedisQueue) Ping(ctx context.Context) error {
+	return q.client.Ping(ctx).Err()
+}
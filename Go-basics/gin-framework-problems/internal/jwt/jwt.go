@@ -0,0 +1,181 @@
+// Package jwt issues and verifies JSON Web Tokens signed with HMAC-SHA256
+// (HS256). Tokens carry the standard issuer, subject, ID, issued-at and
+// expiry claims plus the user's name and role.
+//
+// Only HS256 is accepted when parsing; a token claiming any other algorithm,
+// "none" included, is rejected before its signature is looked at.
+package jwt
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	ErrMalformed = errors.New("jwt: malformed token")
+	ErrAlgorithm = errors.New("jwt: unexpected signing algorithm")
+	ErrSignature = errors.New("jwt: signature mismatch")
+	ErrExpired   = errors.New("jwt: token has expired")
+	ErrNotYet    = errors.New("jwt: token used before issued")
+	ErrIssuer    = errors.New("jwt: unexpected issuer")
+	ErrNoSecret  = errors.New("jwt: empty signing secret")
+)
+
+// Claims is the token payload. Times are Unix seconds, as RFC 7519 has them.
+type Claims struct {
+	Issuer    string `json:"iss,omitempty"`
+	Subject   string `json:"sub,omitempty"` // user ID
+	ID        string `json:"jti,omitempty"`
+	IssuedAt  int64  `json:"iat,omitempty"`
+	ExpiresAt int64  `json:"exp,omitempty"`
+	Username  string `json:"username,omitempty"`
+	Role      string `json:"role,omitempty"`
+}
+
+// Issued returns the iat claim as a time.
+func (c Claims) Issued() time.Time {
+	return time.Unix(c.IssuedAt, 0)
+}
+
+// Expires returns the exp claim as a time.
+func (c Claims) Expires() time.Time {
+	return time.Unix(c.ExpiresAt, 0)
+}
+
+type header struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ,omitempty"`
+}
+
+// hs256Header is the encoded header of every token Signer issues.
+var hs256Header = encode([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// Signer issues and verifies HS256 tokens for one issuer.
+type Signer struct {
+	key    []byte
+	issuer string
+	ttl    time.Duration
+	leeway time.Duration
+	now    func() time.Time
+}
+
+type Option func(*Signer)
+
+// WithLeeway tolerates clock skew of up to d when checking exp and iat
+// (default 30s), for tokens checked on another machine than the issuer.
+func WithLeeway(d time.Duration) Option {
+	return func(s *Signer) { s.leeway = d }
+}
+
+// WithClock replaces time.Now.
+func WithClock(now func() time.Time) Option {
+	return func(s *Signer) { s.now = now }
+}
+
+// NewHS256 returns a signer whose tokens are valid for ttl.
+func NewHS256(secret []byte, issuer string, ttl time.Duration, opts ...Option) *Signer {
+	s := &Signer{key: secret, issuer: issuer, ttl: ttl, leeway: 30 * time.Second, now: time.Now}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// TTL is how long issued tokens stay valid.
+func (s *Signer) TTL() time.Duration {
+	return s.ttl
+}
+
+// Issue signs c, filling in the issuer, the issued-at and expiry times and,
+// unless c.ID is set, a random ID.
+func (s *Signer) Issue(c Claims) (string, error) {
+	if len(s.key) == 0 {
+		return "", ErrNoSecret
+	}
+	now := s.now()
+	c.Issuer = s.issuer
+	if c.ID == "" {
+		c.ID = rand.Text()
+	}
+	c.IssuedAt = now.Unix()
+	c.ExpiresAt = now.Add(s.ttl).Unix()
+	payload, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	unsigned := hs256Header + "." + encode(payload)
+	return unsigned + "." + s.sign(unsigned), nil
+}
+
+// Parse verifies token's algorithm, signature, issuer and times, and
+// returns its claims.
+func (s *Signer) Parse(token string) (Claims, error) {
+	if len(s.key) == 0 {
+		return Claims{}, ErrNoSecret
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Claims{}, ErrMalformed
+	}
+	var h header
+	if err := decodeJSON(parts[0], &h); err != nil {
+		return Claims{}, err
+	}
+	if h.Alg != "HS256" {
+		return Claims{}, ErrAlgorithm
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Claims{}, ErrMalformed
+	}
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(sig, mac.Sum(nil)) {
+		return Claims{}, ErrSignature
+	}
+
+	var c Claims
+	if err := decodeJSON(parts[1], &c); err != nil {
+		return Claims{}, err
+	}
+	now := s.now()
+	switch {
+	case c.Issuer != s.issuer:
+		return Claims{}, ErrIssuer
+	case c.ExpiresAt == 0:
+		// a token that never expires isn't one this package issued
+		return Claims{}, ErrMalformed
+	case !now.Before(c.Expires().Add(s.leeway)):
+		return Claims{}, ErrExpired
+	case c.Issued().After(now.Add(s.leeway)):
+		return Claims{}, ErrNotYet
+	}
+	return c, nil
+}
+
+func (s *Signer) sign(unsigned string) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(unsigned))
+	return encode(mac.Sum(nil))
+}
+
+func encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeJSON(part string, v any) error {
+	b, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return ErrMalformed
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return ErrMalformed
+	}
+	return nil
+}
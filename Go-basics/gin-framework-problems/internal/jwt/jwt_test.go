@@ -0,0 +1,107 @@
+package jwt
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+var start = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// clock returns a signer clock and a way to move it.
+func clock() (func() time.Time, func(time.Duration)) {
+	now := start
+	return func() time.Time { return now }, func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestIssueAndParse(t *testing.T) {
+	now, _ := clock()
+	s := NewHS256([]byte("secret"), "hub", time.Hour, WithClock(now))
+	token, err := s.Issue(Claims{Subject: "42", Username: "alice", Role: "admin", Issuer: "forged"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := s.Parse(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Subject != "42" || c.Username != "alice" || c.Role != "admin" || c.Issuer != "hub" || c.ID == "" {
+		t.Errorf("claims = %+v, want alice's with the signer's issuer and an ID", c)
+	}
+	if !c.Issued().Equal(start) || !c.Expires().Equal(start.Add(time.Hour)) {
+		t.Errorf("issued %v, expires %v; want %v and an hour later", c.Issued(), c.Expires(), start)
+	}
+
+	other, _ := s.Issue(Claims{Subject: "42"})
+	if c2, _ := s.Parse(other); c2.ID == c.ID {
+		t.Error("two tokens share an ID")
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	now, _ := clock()
+	s := NewHS256([]byte("secret"), "hub", time.Hour, WithClock(now))
+	token, _ := s.Issue(Claims{Subject: "42", Role: "user"})
+	parts := strings.Split(token, ".")
+	// the same claims with the role raised, under the original signature
+	forged := encode([]byte(`{"iss":"hub","sub":"42","role":"admin","iat":1767225600,"exp":1767229200}`))
+
+	tests := []struct {
+		name  string
+		token string
+		s     *Signer
+		want  error
+	}{
+		{"other secret", token, NewHS256([]byte("other"), "hub", time.Hour, WithClock(now)), ErrSignature},
+		{"other issuer", token, NewHS256([]byte("secret"), "elsewhere", time.Hour, WithClock(now)), ErrIssuer},
+		{"tampered claims", parts[0] + "." + forged + "." + parts[2], s, ErrSignature},
+		{"alg none", encode([]byte(`{"alg":"none"}`)) + "." + parts[1] + ".", s, ErrAlgorithm},
+		{"alg HS512", encode([]byte(`{"alg":"HS512"}`)) + "." + parts[1] + "." + parts[2], s, ErrAlgorithm},
+		{"two parts", parts[0] + "." + parts[1], s, ErrMalformed},
+		{"not base64", "!!." + parts[1] + "." + parts[2], s, ErrMalformed},
+		{"no secret", token, NewHS256(nil, "hub", time.Hour, WithClock(now)), ErrNoSecret},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.s.Parse(tt.token); err != tt.want {
+				t.Errorf("Parse = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTimes(t *testing.T) {
+	tests := []struct {
+		name  string
+		issue time.Duration // issuer's clock, from start
+		parse time.Duration // verifier's clock, from start
+		want  error
+	}{
+		{"fresh", 0, 0, nil},
+		{"just before expiry", 0, time.Hour - time.Second, nil},
+		{"expired, within leeway", 0, time.Hour + 29*time.Second, nil},
+		{"expired", 0, time.Hour + 30*time.Second, ErrExpired},
+		{"issuer a little ahead", 20 * time.Second, 0, nil},
+		{"issuer too far ahead", time.Minute, 0, ErrNotYet},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issuerNow, _ := clock()
+			verifierNow, advance := clock()
+			issuer := NewHS256([]byte("secret"), "hub", time.Hour, WithClock(func() time.Time { return issuerNow().Add(tt.issue) }))
+			verifier := NewHS256([]byte("secret"), "hub", time.Hour, WithClock(verifierNow))
+			token, err := issuer.Issue(Claims{Subject: "42"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			advance(tt.parse)
+			if _, err := verifier.Parse(token); err != tt.want {
+				t.Errorf("Parse = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	if _, err := NewHS256(nil, "hub", time.Hour).Issue(Claims{}); err != ErrNoSecret {
+		t.Errorf("Issue without a secret = %v, want ErrNoSecret", err)
+	}
+}
@@ -0,0 +1,30 @@
+// Package logging builds the slog logger shared by the examples.
+package logging
+
+import (
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/config"
+)
+
+// New returns a JSON (or text) logger writing to stdout at the configured
+// level. Lines logged with a request context include its request ID.
+func New(cfg config.LogConfig) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: ParseLevel(cfg.Level)}
+	var h slog.Handler = slog.NewJSONHandler(os.Stdout, opts)
+	if strings.EqualFold(cfg.Format, "text") {
+		h = slog.NewTextHandler(os.Stdout, opts)
+	}
+	return slog.New(contextHandler{h})
+}
+
+// ParseLevel maps debug/info/warn/error to a slog level, defaulting to info.
+func ParseLevel(s string) slog.Level {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(s)); err != nil {
+		return slog.LevelInfo
+	}
+	return level
+}
@@ -0,0 +1,73 @@
+package loginguard
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/httpclient"
+)
+
+var captchaClient = httpclient.New("captcha", httpclient.WithTimeout(5*time.Second))
+
+// Widget is what a sign-in page needs to show the CAPTCHA: the script to
+// load and the class of the element it renders into, with SiteKey in the
+// element's data-sitekey. The widget puts the token in its field of
+// CaptchaFields.
+type Widget struct {
+	Script  string
+	Class   string
+	SiteKey string
+}
+
+// NewWidget returns the widget of the service verifyURL belongs to, as
+// SiteVerify takes it: hCaptcha's, Turnstile's, or else reCAPTCHA's.
+func NewWidget(verifyURL, siteKey string) Widget {
+	w := Widget{Script: "https://www.google.com/recaptcha/api.js", Class: "g-recaptcha", SiteKey: siteKey}
+	u, err := url.Parse(verifyURL)
+	if err != nil {
+		return w
+	}
+	switch host := u.Hostname(); {
+	case host == "hcaptcha.com" || strings.HasSuffix(host, ".hcaptcha.com"):
+		w.Script, w.Class = "https://js.hcaptcha.com/1/api.js", "h-captcha"
+	case host == "challenges.cloudflare.com":
+		w.Script, w.Class = "https://challenges.cloudflare.com/turnstile/v0/api.js", "cf-turnstile"
+	}
+	return w
+}
+
+// SiteVerify returns a Verifier that posts tokens to verifyURL with secret,
+// as reCAPTCHA (https://www.google.com/recaptcha/api/siteverify), hCaptcha
+// (https://api.hcaptcha.com/siteverify) and Cloudflare Turnstile
+// (https://challenges.cloudflare.com/turnstile/v0/siteverify) all take
+// them, and reads "success" from the answer.
+func SiteVerify(verifyURL, secret string) Verifier {
+	return func(ctx context.Context, token, remoteIP string) (bool, error) {
+		form := url.Values{"secret": {secret}, "response": {token}, "remoteip": {remoteIP}}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, verifyURL, strings.NewReader(form.Encode()))
+		if err != nil {
+			return false, err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		resp, err := captchaClient.Do(req)
+		if err != nil {
+			return false, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return false, fmt.Errorf("loginguard: CAPTCHA verification answered %s", resp.Status)
+		}
+		var answer struct {
+			Success bool `json:"success"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&answer); err != nil {
+			return false, err
+		}
+		return answer.Success, nil
+	}
+}
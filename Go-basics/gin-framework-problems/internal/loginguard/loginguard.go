@@ -0,0 +1,295 @@
+// Package loginguard slows down password guessing at sign-in, which the
+// per-IP rate limits can't stop on their own: an attacker who rotates
+// addresses gets a fresh budget from each, and can spend all of them on one
+// username.
+//
+// Failures are counted per username and client IP. After FreeAttempts of
+// them, that pair has to wait before trying again, twice as long after each
+// further failure, from BaseDelay up to MaxDelay. Failures on a username are
+// counted across every IP too, and past CaptchaAfter of them signing in to
+// it takes a solved CAPTCHA. An attacker rotating addresses meets a
+// challenge rather than a fresh budget, and the account's owner can still
+// get in.
+//
+// An attempt Check lets through counts as a failure until Failed, Succeeded
+// or Abandoned settles it, so attempts sent at once can't all have the free
+// ones, or all slip in under the CAPTCHA.
+//
+// Counts are kept in memory, per instance. They are forgotten ResetAfter
+// after the last failure, and a pair's at a successful sign-in.
+package loginguard
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/apperror"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/audit"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/metrics"
+)
+
+// CaptchaHeader carries the token a client got for solving the CAPTCHA.
+const CaptchaHeader = "X-Captcha-Token"
+
+// CaptchaFields are the form fields a sign-in form may send the token in
+// instead: the ones the reCAPTCHA, hCaptcha and Turnstile widgets add, and
+// captcha_token for a form that sets it itself.
+var CaptchaFields = []string{"g-recaptcha-response", "h-captcha-response", "cf-turnstile-response", "captcha_token"}
+
+// Options are a Guard's thresholds.
+type Options struct {
+	FreeAttempts int           // failures of a username from an IP before it has to wait
+	BaseDelay    time.Duration // the first wait, doubled by each failure after it
+	MaxDelay     time.Duration
+	CaptchaAfter int           // failures of a username from any IP before it needs a CAPTCHA; 0 never
+	ResetAfter   time.Duration // failures are forgotten this long after the last
+}
+
+// pendingTTL is how long an attempt Check let through counts before it is
+// settled. One never settled, as when its handler panicked, stops counting
+// then.
+const pendingTTL = time.Minute
+
+// Verifier checks the token of a solved CAPTCHA, sent from remoteIP.
+type Verifier func(ctx context.Context, token, remoteIP string) (bool, error)
+
+// Guard counts failed sign-ins. It is safe for concurrent use.
+type Guard struct {
+	opts   Options
+	verify Verifier // nil never challenges
+	now    func() time.Time
+
+	mu    sync.Mutex
+	pairs map[string]*record // by username and IP
+	users map[string]*record // by username
+	swept time.Time
+}
+
+// record is the failures of a username, or of a username from an IP, and
+// its attempts in flight.
+type record struct {
+	failures int
+	last     time.Time
+	until    time.Time // no attempt before then
+	pending  int       // let through by Check and not settled yet
+	reserved time.Time // when the last of those was
+}
+
+// New returns a Guard with opts that challenges with verify, or never when
+// verify is nil.
+func New(opts Options, verify Verifier) *Guard {
+	return &Guard{
+		opts:   opts,
+		verify: verify,
+		now:    time.Now,
+		pairs:  map[string]*record{},
+		users:  map[string]*record{},
+	}
+}
+
+// Default is the guard the sign-in handlers share. NewEngine replaces it
+// with one from the auth.login_guard section of the config.
+var Default = New(Options{
+	FreeAttempts: 3,
+	BaseDelay:    time.Second,
+	MaxDelay:     15 * time.Minute,
+	CaptchaAfter: 10,
+	ResetAfter:   time.Hour,
+}, nil)
+
+var (
+	errCaptchaRequired = apperror.New(http.StatusUnauthorized, apperror.CodeCaptchaRequired, "solve the CAPTCHA to sign in")
+	errCaptchaFailed   = apperror.New(http.StatusUnauthorized, apperror.CodeCaptchaRequired, "the CAPTCHA wasn't solved")
+)
+
+// Check is called before the credentials of username are checked. It
+// answers 429, with Retry-After, while the request's IP has to wait for
+// username or has as many attempts in flight as it may fail, and 401
+// captcha_required when username needs a CAPTCHA and the request has no
+// solved one. An attempt it lets through must be settled with Failed,
+// Succeeded or Abandoned.
+func (g *Guard) Check(c *gin.Context, username string) error {
+	user, ip := key(username), c.ClientIP()
+	now := g.now()
+
+	g.mu.Lock()
+	g.sweep(now)
+	pair, u := g.entry(g.pairs, user+"\x00"+ip, now), g.entry(g.users, user, now)
+	wait := pair.until.Sub(now)
+	if wait <= 0 && pair.pending > 0 && pair.failures+pair.pending >= g.opts.FreeAttempts {
+		// the next failure would make it wait, and one in flight may be it
+		wait = time.Second
+	}
+	challenge := g.verify != nil && g.opts.CaptchaAfter > 0 && u.failures+u.pending >= g.opts.CaptchaAfter
+	if wait <= 0 {
+		pair.pending++
+		u.pending++
+		pair.reserved, u.reserved = now, now
+	}
+	g.mu.Unlock()
+
+	if wait > 0 {
+		secs := int((wait + time.Second - 1) / time.Second)
+		c.Header("Retry-After", strconv.Itoa(secs))
+		metrics.LoginGuard("blocked")
+		return apperror.New(http.StatusTooManyRequests, apperror.CodeRateLimited, "too many failed sign-ins; try again later").
+			WithDetails(map[string]int{"retry_after_seconds": secs})
+	}
+	if !challenge {
+		return nil
+	}
+	token := captchaToken(c)
+	if token == "" {
+		g.Abandoned(c, username)
+		metrics.LoginGuard("challenged")
+		return errCaptchaRequired
+	}
+	ok, err := g.verify(c.Request.Context(), token, ip)
+	if err != nil {
+		g.Abandoned(c, username)
+		e := apperror.New(http.StatusServiceUnavailable, apperror.CodeUnavailable, "the CAPTCHA couldn't be checked")
+		e.Err = err
+		return e
+	}
+	if !ok {
+		g.Abandoned(c, username)
+		metrics.LoginGuard("captcha_failed")
+		return errCaptchaFailed
+	}
+	return nil
+}
+
+// captchaToken is the token c sends in CaptchaHeader or, from a form, in
+// one of CaptchaFields.
+func captchaToken(c *gin.Context) string {
+	if token := c.GetHeader(CaptchaHeader); token != "" {
+		return token
+	}
+	for _, f := range CaptchaFields {
+		if token := c.PostForm(f); token != "" {
+			return token
+		}
+	}
+	return ""
+}
+
+// Failed records wrong credentials for username. A failure that makes the
+// IP wait is a lockout, recorded in the audit log.
+func (g *Guard) Failed(c *gin.Context, username string) {
+	user, ip := key(username), c.ClientIP()
+	now := g.now()
+
+	g.mu.Lock()
+	pair := g.fail(g.pairs, user+"\x00"+ip, now)
+	u := g.fail(g.users, user, now)
+	var delay time.Duration
+	if n := pair.failures - g.opts.FreeAttempts; n > 0 {
+		delay = g.opts.MaxDelay
+		// past 30 doublings the shift would overflow, and any sane max is hit
+		if n <= 30 {
+			delay = min(g.opts.BaseDelay<<(n-1), g.opts.MaxDelay)
+		}
+		pair.until = now.Add(delay)
+	}
+	failures, challenged := pair.failures, g.verify != nil && u.failures == g.opts.CaptchaAfter
+	g.mu.Unlock()
+
+	if challenged {
+		metrics.LoginGuard("captcha_started")
+	}
+	if delay > 0 {
+		metrics.LoginGuard("lockout")
+		audit.Record(c, audit.Event{Action: audit.LoginLockout, Outcome: audit.Failure, Actor: username,
+			Details: map[string]string{"failures": strconv.Itoa(failures), "delay": delay.String()}})
+	}
+}
+
+// Succeeded forgets the failures of username from the request's IP. Those
+// from anywhere are kept, so a username under attack stays challenged.
+func (g *Guard) Succeeded(c *gin.Context, username string) {
+	user, ip := key(username), c.ClientIP()
+	now := g.now()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	pair := g.settle(g.pairs, user+"\x00"+ip, now)
+	pair.failures, pair.until = 0, time.Time{}
+	g.settle(g.users, user, now)
+}
+
+// Abandoned settles an attempt of username that ended before the
+// credentials could be told right or wrong, such as on a storage error,
+// counting it neither way.
+func (g *Guard) Abandoned(c *gin.Context, username string) {
+	user, ip := key(username), c.ClientIP()
+	now := g.now()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.settle(g.pairs, user+"\x00"+ip, now)
+	g.settle(g.users, user, now)
+}
+
+// entry is m[k] at now, new if there is none. Failures that are forgotten
+// are cleared, and so are attempts in flight past pendingTTL. g.mu must be
+// held.
+func (g *Guard) entry(m map[string]*record, k string, now time.Time) *record {
+	r := m[k]
+	if r == nil {
+		r = &record{}
+		m[k] = r
+	}
+	if r.failures > 0 && g.forgotten(r, now) {
+		r.failures, r.until = 0, time.Time{}
+	}
+	if r.pending > 0 && now.Sub(r.reserved) >= pendingTTL {
+		r.pending = 0
+	}
+	return r
+}
+
+// settle ends an attempt in flight in m[k]. g.mu must be held.
+func (g *Guard) settle(m map[string]*record, k string, now time.Time) *record {
+	r := g.entry(m, k, now)
+	r.pending = max(r.pending-1, 0)
+	return r
+}
+
+// fail settles an attempt in m[k] as a failure at now. g.mu must be held.
+func (g *Guard) fail(m map[string]*record, k string, now time.Time) *record {
+	r := g.settle(m, k, now)
+	r.failures++
+	r.last = now
+	return r
+}
+
+func (g *Guard) forgotten(r *record, now time.Time) bool {
+	return now.Sub(r.last) >= g.opts.ResetAfter && !now.Before(r.until)
+}
+
+// sweep drops forgotten records, at most once per ResetAfter, so the maps
+// don't grow with every username ever tried. g.mu must be held.
+func (g *Guard) sweep(now time.Time) {
+	if now.Sub(g.swept) < g.opts.ResetAfter {
+		return
+	}
+	g.swept = now
+	for _, m := range []map[string]*record{g.pairs, g.users} {
+		for k, r := range m {
+			if g.forgotten(r, now) && (r.pending == 0 || now.Sub(r.reserved) >= pendingTTL) {
+				delete(m, k)
+			}
+		}
+	}
+}
+
+// key folds the case of username, so Alice and alice share their counts.
+func key(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
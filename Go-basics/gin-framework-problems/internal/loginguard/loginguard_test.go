@@ -0,0 +1,117 @@
+package loginguard
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/apperror"
+)
+
+// request is a sign-in request from ip, with a CAPTCHA token unless it is
+// empty.
+func request(ip, token string) *gin.Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/login", nil)
+	c.Request.RemoteAddr = ip + ":1234"
+	if token != "" {
+		c.Request.Header.Set(CaptchaHeader, token)
+	}
+	return c
+}
+
+// attempt signs in as username from ip, right when ok, and returns the
+// error Check refused it with.
+func attempt(g *Guard, ip, username, token string, ok bool) error {
+	c := request(ip, token)
+	if err := g.Check(c, username); err != nil {
+		return err
+	}
+	if ok {
+		g.Succeeded(c, username)
+	} else {
+		g.Failed(c, username)
+	}
+	return nil
+}
+
+func status(err error) int {
+	if e, ok := err.(*apperror.Error); ok {
+		return e.Status
+	}
+	return 0
+}
+
+func TestBackoff(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	now := time.Date(2024, time.May, 15, 10, 0, 0, 0, time.UTC)
+	g := New(Options{FreeAttempts: 2, BaseDelay: time.Second, MaxDelay: 4 * time.Second, ResetAfter: time.Hour}, nil)
+	g.now = func() time.Time { return now }
+
+	for i, want := range []time.Duration{0, 0, time.Second, 2 * time.Second, 4 * time.Second, 4 * time.Second} {
+		if err := attempt(g, "10.0.0.1", "alice", "", false); err != nil {
+			t.Fatalf("failure %d: %v", i+1, err)
+		}
+		if want > 0 {
+			c := request("10.0.0.1", "")
+			if err := g.Check(c, "Alice"); status(err) != http.StatusTooManyRequests {
+				t.Fatalf("after failure %d: retry = %v, want a 429", i+1, err)
+			}
+			if got, want := c.Writer.Header().Get("Retry-After"), strconv.Itoa(int(want/time.Second)); got != want {
+				t.Errorf("after failure %d: Retry-After = %s, want %s", i+1, got, want)
+			}
+			// another IP isn't held back
+			if err := attempt(g, "10.0.0.2", "alice", "", true); err != nil {
+				t.Fatalf("after failure %d, from another IP: %v", i+1, err)
+			}
+		}
+		now = now.Add(want)
+	}
+	if err := attempt(g, "10.0.0.1", "alice", "", true); err != nil {
+		t.Fatal(err)
+	}
+	// a success starts the pair over
+	for range 2 {
+		if err := attempt(g, "10.0.0.1", "alice", "", false); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestCaptcha(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	now := time.Date(2024, time.May, 15, 10, 0, 0, 0, time.UTC)
+	verify := func(_ context.Context, token, _ string) (bool, error) { return token == "solved", nil }
+	g := New(Options{FreeAttempts: 10, BaseDelay: time.Second, MaxDelay: time.Minute, CaptchaAfter: 3, ResetAfter: time.Hour}, verify)
+	g.now = func() time.Time { return now }
+
+	// an attacker rotating addresses
+	for _, ip := range []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"} {
+		if err := attempt(g, ip, "bob", "", false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := attempt(g, "10.0.0.4", "bob", "", true)
+	if e, ok := err.(*apperror.Error); !ok || e.Code != apperror.CodeCaptchaRequired {
+		t.Fatalf("without a token = %v, want captcha_required", err)
+	}
+	if err := attempt(g, "10.0.0.4", "bob", "wrong", true); status(err) != http.StatusUnauthorized {
+		t.Fatalf("with a wrong token = %v, want a 401", err)
+	}
+	if err := attempt(g, "10.0.0.4", "bob", "solved", true); err != nil {
+		t.Fatalf("with a solved token = %v", err)
+	}
+	// other usernames aren't challenged
+	if err := attempt(g, "10.0.0.4", "carol", "", true); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(time.Hour)
+	if err := attempt(g, "10.0.0.4", "bob", "", true); err != nil {
+		t.Fatalf("after ResetAfter = %v", err)
+	}
+}
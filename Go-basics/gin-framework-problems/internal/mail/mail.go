@@ -0,0 +1,159 @@
+// Package mail renders templated emails (text and HTML alternatives) and
+// sends them over SMTP, or in development writes them to disk as .eml files
+// or only logs them. Sending can go through a job queue; see UseQueue.
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/textproto"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/config"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/jobs"
+)
+
+//go:embed templates
+var templates embed.FS
+
+// Message is a rendered email.
+type Message struct {
+	From    string
+	To      []string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Sender delivers messages: SMTPSender, FileSink or LogSender, or a fake in
+// tests.
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Mailer renders named templates from templates/ and hands the result to a
+// Sender. Template <name> needs <name>.txt.tmpl, which also defines the
+// "subject", and <name>.html.tmpl, which defines the "content" of the HTML
+// layout.
+type Mailer struct {
+	from   string
+	sender Sender
+	pool   *jobs.Pool // set by UseQueue
+}
+
+func NewMailer(from string, sender Sender) *Mailer {
+	return &Mailer{from: from, sender: sender}
+}
+
+// Render builds the message for template name without sending it.
+func (m *Mailer) Render(name string, to string, data any) (Message, error) {
+	txt, err := texttemplate.ParseFS(templates, "templates/"+name+".txt.tmpl")
+	if err != nil {
+		return Message{}, fmt.Errorf("mail: %w", err)
+	}
+	var subject, text bytes.Buffer
+	if err := txt.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return Message{}, fmt.Errorf("mail: %s subject: %w", name, err)
+	}
+	if err := txt.Execute(&text, data); err != nil {
+		return Message{}, fmt.Errorf("mail: %s text: %w", name, err)
+	}
+
+	html, err := htmltemplate.ParseFS(templates, "templates/layout.html.tmpl", "templates/"+name+".html.tmpl")
+	if err != nil {
+		return Message{}, fmt.Errorf("mail: %w", err)
+	}
+	var body bytes.Buffer
+	if err := html.ExecuteTemplate(&body, "layout", data); err != nil {
+		return Message{}, fmt.Errorf("mail: %s html: %w", name, err)
+	}
+
+	return Message{
+		From:    m.from,
+		To:      []string{to},
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    text.String(),
+		HTML:    body.String(),
+	}, nil
+}
+
+// Send renders template name with data and sends it to one recipient, or
+// queues it for sending after UseQueue.
+func (m *Mailer) Send(ctx context.Context, name string, to string, data any) error {
+	msg, err := m.Render(name, to, data)
+	if err != nil {
+		return err
+	}
+	if m.pool != nil {
+		_, err := m.pool.Enqueue(ctx, JobType, msg)
+		return err
+	}
+	return m.sender.Send(ctx, msg)
+}
+
+// Bytes encodes msg as a MIME multipart/alternative message (RFC 5322).
+func (msg Message) Bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+
+	h := func(k, v string) { fmt.Fprintf(&buf, "%s: %s\r\n", k, v) }
+	h("From", msg.From)
+	h("To", strings.Join(msg.To, ", "))
+	h("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	h("Date", time.Now().Format(time.RFC1123Z))
+	h("Message-ID", "<"+rand.Text()+"@tech-learning-hub>")
+	h("MIME-Version", "1.0")
+	h("Content-Type", "multipart/alternative; boundary="+mw.Boundary())
+	buf.WriteString("\r\n")
+
+	// plain text first: clients show the last alternative they understand
+	for _, part := range []struct{ typ, body string }{
+		{"text/plain; charset=utf-8", msg.Text},
+		{"text/html; charset=utf-8", msg.HTML},
+	} {
+		pw, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.typ},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(pw)
+		if _, err := qp.Write([]byte(part.body)); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// New returns a Mailer that sends with NewSender(cfg).
+func New(cfg config.MailConfig) *Mailer {
+	return NewMailer(cfg.From, NewSender(cfg))
+}
+
+// NewSender returns an SMTPSender when cfg.SMTPAddr is set, a FileSink
+// writing to cfg.SinkDir otherwise, and a LogSender when neither is.
+func NewSender(cfg config.MailConfig) Sender {
+	switch {
+	case cfg.SMTPAddr != "":
+		return NewSMTPSender(cfg.SMTPAddr, cfg.SMTPUsername, cfg.SMTPPassword, cfg.Timeout, cfg.Retries)
+	case cfg.SinkDir != "":
+		return NewFileSink(cfg.SinkDir)
+	}
+	return LogSender{}
+}
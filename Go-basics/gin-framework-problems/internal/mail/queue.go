@@ -0,0 +1,36 @@
+package mail
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/jobs"
+)
+
+// JobType is the type of the jobs that send queued mail.
+const JobType = "send_mail"
+
+// UseQueue makes Send queue each rendered message as a job on pool instead
+// of sending it while the caller waits. pool, or whichever pool shares its
+// queue, must handle JobType with Handler.
+func (m *Mailer) UseQueue(pool *jobs.Pool) {
+	m.pool = pool
+}
+
+// Handler sends the message of a JobType job with sender. A message the
+// server rejected fails the job for good; other failures are retried with
+// the pool's backoff, so mail outlives an SMTP outage longer than the
+// sender's own retries do.
+func Handler(sender Sender) jobs.Handler {
+	return func(ctx context.Context, payload json.RawMessage) error {
+		var msg Message
+		if err := json.Unmarshal(payload, &msg); err != nil {
+			return jobs.Permanent(err)
+		}
+		err := sender.Send(ctx, msg)
+		if rejected(err) {
+			return jobs.Permanent(err)
+		}
+		return err
+	}
+}
@@ -0,0 +1,53 @@
+package mail
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileSink is the development Sender: every message is written to dir as an
+// .eml file that any mail client can open. Nothing leaves the machine.
+type FileSink struct {
+	dir string
+}
+
+func NewFileSink(dir string) *FileSink {
+	return &FileSink{dir: dir}
+}
+
+func (s *FileSink) Send(ctx context.Context, msg Message) error {
+	data, err := msg.Bytes()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(s.dir, time.Now().UTC().Format("20060102T150405Z")+"-*.eml")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	slog.InfoContext(ctx, "mail written", "to", msg.To, "subject", msg.Subject, "file", filepath.Base(f.Name()))
+	return nil
+}
+
+// LogSender is the Sender that sends nothing: it logs whom each message was
+// for and its subject, and its text at debug level, for reading links out
+// of without a mail client.
+type LogSender struct{}
+
+func (LogSender) Send(ctx context.Context, msg Message) error {
+	slog.InfoContext(ctx, "mail not sent", "to", msg.To, "subject", msg.Subject)
+	slog.DebugContext(ctx, "mail text", "to", msg.To, "text", msg.Text)
+	return nil
+}
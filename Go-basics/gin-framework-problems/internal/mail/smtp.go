@@ -0,0 +1,128 @@
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"time"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/breaker"
+)
+
+// SMTPSender delivers over SMTP, upgrading to TLS when the server offers
+// STARTTLS. Each attempt is bounded by timeout; temporary failures (network
+// errors, 4xx replies) are retried with doubling delays, 5xx replies are not.
+// After 5 failed attempts in a row the server is given 30s to recover,
+// with sends failing with breaker.ErrOpen meanwhile.
+type SMTPSender struct {
+	addr    string // host:port
+	auth    smtp.Auth
+	timeout time.Duration
+	retries int
+	breaker *breaker.Breaker
+}
+
+// NewSMTPSender logs in with PLAIN auth when username is set.
+func NewSMTPSender(addr, username, password string, timeout time.Duration, retries int) *SMTPSender {
+	s := &SMTPSender{addr: addr, timeout: timeout, retries: retries, breaker: breaker.New("smtp")}
+	if username != "" {
+		host, _, _ := net.SplitHostPort(addr)
+		s.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return s
+}
+
+func (s *SMTPSender) Send(ctx context.Context, msg Message) error {
+	data, err := msg.Bytes()
+	if err != nil {
+		return err
+	}
+	delay := time.Second
+	for attempt := 0; ; attempt++ {
+		done, err := s.breaker.Allow()
+		if err != nil {
+			return err
+		}
+		err = s.send(ctx, msg.From, msg.To, data)
+		switch {
+		case err == nil || rejected(err):
+			// the server answered; a rejected message says nothing of its health
+			done(breaker.Success)
+		case ctx.Err() != nil:
+			done(breaker.Ignored)
+		default:
+			done(breaker.Failure)
+		}
+		if err == nil || attempt >= s.retries || rejected(err) {
+			return err
+		}
+		select {
+		case <-time.After(delay):
+			delay *= 2
+		case <-ctx.Done():
+			return fmt.Errorf("mail: %w (last error: %v)", ctx.Err(), err)
+		}
+	}
+}
+
+// rejected reports whether err is the server refusing the message with a
+// 5xx reply, which sending it again won't change.
+func rejected(err error) bool {
+	var reply *textproto.Error
+	return errors.As(err, &reply) && reply.Code >= 500
+}
+
+func (s *SMTPSender) send(ctx context.Context, from string, to []string, data []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+
+	host, _, _ := net.SplitHostPort(s.addr)
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if s.auth != nil {
+		if err := c.Auth(s.auth); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(from); err != nil {
+		return err
+	}
+	for _, rcpt := range to {
+		if err := c.Rcpt(rcpt); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
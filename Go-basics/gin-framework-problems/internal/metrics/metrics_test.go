@@ -0,0 +1,59 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestDomainCounters(t *testing.T) {
+	success, failure := testutil.ToFloat64(loginsTotal.WithLabelValues("success")), testutil.ToFloat64(loginsTotal.WithLabelValues("failure"))
+	uploads, bytes := testutil.ToFloat64(uploadsTotal), testutil.ToFloat64(uploadBytes)
+	unnamed := testutil.ToFloat64(rateLimitedTotal.WithLabelValues("unnamed"))
+
+	Login(true)
+	Login(false)
+	Login(false)
+	Upload(1000)
+	Upload(24)
+	RateLimited("")
+
+	tests := []struct {
+		name      string
+		got, want float64
+	}{
+		{"successful logins", testutil.ToFloat64(loginsTotal.WithLabelValues("success")), success + 1},
+		{"failed logins", testutil.ToFloat64(loginsTotal.WithLabelValues("failure")), failure + 2},
+		{"uploads", testutil.ToFloat64(uploadsTotal), uploads + 2},
+		{"upload bytes", testutil.ToFloat64(uploadBytes), bytes + 1024},
+		{"unnamed rejections", testutil.ToFloat64(rateLimitedTotal.WithLabelValues("unnamed")), unnamed + 1},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestMiddlewareResponseSize(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Middleware())
+	r.GET("/size/:id", func(c *gin.Context) { c.String(http.StatusOK, "hello") })
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/size/1", nil))
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/size/2", nil))
+
+	// both IDs land in the route template's series
+	var m dto.Metric
+	if err := responseSize.WithLabelValues(http.MethodGet, "/size/:id", "2xx").(prometheus.Metric).Write(&m); err != nil {
+		t.Fatal(err)
+	}
+	if h := m.GetHistogram(); h.GetSampleCount() != 2 || h.GetSampleSum() != 10 {
+		t.Errorf("%d responses, %v bytes, want 2 responses, 10 bytes", h.GetSampleCount(), h.GetSampleSum())
+	}
+}
@@ -0,0 +1,247 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UserKey is the context key the authenticated principal is stored under.
+const UserKey = "user"
+
+// TokenKey is the context key the bearer token a request was authenticated
+// with is stored under, for handlers that revoke or inspect it. It is unset
+// for API keys.
+const TokenKey = "token"
+
+// APIKeyIDKey is the context key the ID of the API key a request was
+// authenticated with is stored under. It is unset for bearer tokens.
+const APIKeyIDKey = "api_key_id"
+
+// TenantKey is the context key internal/tenant stores the ID of a request's
+// tenant under. It is unset while tenancy is off.
+const TenantKey = "tenant"
+
+// tenantMember is implemented by principals that belong to one tenant.
+// Auth refuses them on another tenant's requests.
+type tenantMember interface {
+	TenantID() string
+}
+
+var errOtherTenant = errors.New("these credentials belong to another tenant")
+
+// otherTenant reports whether principal belongs to a tenant other than the
+// request's.
+func otherTenant(c *gin.Context, principal any) bool {
+	m, ok := principal.(tenantMember)
+	tenant := c.GetString(TenantKey)
+	return ok && tenant != "" && m.TenantID() != tenant
+}
+
+// Authenticator resolves a bearer token to a principal. The returned error
+// message is sent back to the client with a 401.
+type Authenticator func(token string) (any, error)
+
+// KeyAuthenticator resolves an API key to a principal and the key's ID, which
+// is safe to log and to rate limit by. The returned error message is sent
+// back to the client with a 401.
+type KeyAuthenticator func(key string) (principal any, keyID string, err error)
+
+// RoleChecker is implemented by principals that RequireRole can inspect.
+type RoleChecker interface {
+	HasRole(role string) bool
+}
+
+// PermissionChecker is implemented by principals that RequirePermission can
+// inspect.
+type PermissionChecker interface {
+	Can(permission string) bool
+}
+
+type authConfig struct {
+	queryParam string
+	apiKey     KeyAuthenticator
+	keyLimiter *RateLimiter
+	fail       func(c *gin.Context, status int, message string)
+	anonymous  bool
+}
+
+// AuthOption customizes the Auth middleware.
+type AuthOption func(*authConfig)
+
+// WithQueryToken also accepts the token from the given query parameter when
+// no Authorization header is sent. Browsers can't set headers on WebSocket or
+// EventSource requests, so those routes need it.
+func WithQueryToken(param string) AuthOption {
+	return func(cfg *authConfig) { cfg.queryParam = param }
+}
+
+// WithAPIKey also accepts an "X-API-Key: <key>" header, resolved with
+// authenticate, which scripts and other services can send instead of signing
+// in. Requests with a key are rate limited by limiter, one bucket per key,
+// apart from any per-IP limit; a nil limiter leaves them unlimited.
+func WithAPIKey(authenticate KeyAuthenticator, limiter *RateLimiter) AuthOption {
+	return func(cfg *authConfig) {
+		cfg.apiKey = authenticate
+		cfg.keyLimiter = limiter
+	}
+}
+
+// WithAuthError has fail answer the requests Auth refuses, with the status
+// and message it would send, instead of AbortError. fail must abort.
+func WithAuthError(fail func(c *gin.Context, status int, message string)) AuthOption {
+	return func(cfg *authConfig) { cfg.fail = fail }
+}
+
+// WithAnonymous lets requests that send no credentials through without a
+// principal, for routes that serve everyone and more to a signed-in user.
+// Credentials that are sent must still be good, and of the request's
+// tenant.
+func WithAnonymous() AuthOption {
+	return func(cfg *authConfig) { cfg.anonymous = true }
+}
+
+// Auth reads "Authorization: Bearer <token>", resolves it with authenticate
+// and stores the principal under UserKey and the token under TokenKey. With
+// WithAPIKey, an X-API-Key header is used instead when sent. A LimitRoutes
+// limit keyed by the principal counts the request here.
+func Auth(authenticate Authenticator, opts ...AuthOption) gin.HandlerFunc {
+	cfg := authConfig{fail: AbortError}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	return func(c *gin.Context) {
+		if key := c.GetHeader("X-API-Key"); key != "" && cfg.apiKey != nil {
+			apiKeyAuth(c, cfg, key)
+			return
+		}
+		if cfg.anonymous && c.GetHeader("Authorization") == "" && (cfg.queryParam == "" || c.Query(cfg.queryParam) == "") {
+			c.Next()
+			return
+		}
+		token, ok := bearerToken(c, cfg)
+		if !ok {
+			return
+		}
+
+		user, err := authenticate(token)
+		if err != nil {
+			cfg.fail(c, http.StatusUnauthorized, err.Error())
+			return
+		}
+		if otherTenant(c, user) {
+			cfg.fail(c, http.StatusForbidden, errOtherTenant.Error())
+			return
+		}
+
+		c.Set(UserKey, user)
+		c.Set(TokenKey, token)
+		if limitAuthenticated(c) {
+			c.Next()
+		}
+	}
+}
+
+func apiKeyAuth(c *gin.Context, cfg authConfig, key string) {
+	user, id, err := cfg.apiKey(key)
+	if err != nil {
+		cfg.fail(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+	if otherTenant(c, user) {
+		cfg.fail(c, http.StatusForbidden, errOtherTenant.Error())
+		return
+	}
+	if cfg.keyLimiter != nil && !cfg.keyLimiter.allow(c, "key:"+id) {
+		return
+	}
+	c.Set(UserKey, user)
+	c.Set(APIKeyIDKey, id)
+	if limitAuthenticated(c) {
+		c.Next()
+	}
+}
+
+// bearerToken extracts the token or aborts with a 401.
+func bearerToken(c *gin.Context, cfg authConfig) (string, bool) {
+	h := c.GetHeader("Authorization")
+	if h == "" && cfg.queryParam != "" {
+		if token := c.Query(cfg.queryParam); token != "" {
+			return token, true
+		}
+	}
+	if h == "" {
+		cfg.fail(c, http.StatusUnauthorized, "missing Authorization header")
+		return "", false
+	}
+	token, ok := BearerToken(h)
+	if !ok {
+		cfg.fail(c, http.StatusUnauthorized, "invalid Authorization format")
+		return "", false
+	}
+	return token, true
+}
+
+// BearerToken is the token of an Authorization header value such as
+// "Bearer <token>", the scheme in any case. ok is false for other schemes
+// and for a missing token.
+func BearerToken(header string) (token string, ok bool) {
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", false
+	}
+	return token, true
+}
+
+// Principal is the principal Auth stored, as a T. ok is false when Auth
+// hasn't run or stored something else, so a handler mounted without it or
+// behind another example's Auth gets no zero value mistaken for a user.
+func Principal[T any](c *gin.Context) (p T, ok bool) {
+	v, _ := c.Get(UserKey)
+	p, ok = v.(T)
+	return p, ok
+}
+
+// RequireRole lets the request through only if the principal set by Auth
+// has the given role.
+func RequireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		v, exists := c.Get(UserKey)
+		if !exists {
+			AbortError(c, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		user, ok := v.(RoleChecker)
+		if !ok || !user.HasRole(role) {
+			AbortError(c, http.StatusForbidden, role+" only")
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequirePermission lets the request through only if the principal set by
+// Auth holds permission, whichever role grants it.
+func RequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		v, exists := c.Get(UserKey)
+		if !exists {
+			AbortError(c, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		user, ok := v.(PermissionChecker)
+		if !ok || !user.Can(permission) {
+			AbortError(c, http.StatusForbidden, "missing permission "+permission)
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireAdmin is RequireRole("admin").
+func RequireAdmin() gin.HandlerFunc {
+	return RequireRole("admin")
+}
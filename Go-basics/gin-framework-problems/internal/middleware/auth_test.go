@@ -0,0 +1,139 @@
+package middleware_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+)
+
+func engine(mw ...gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(mw...)
+	return r
+}
+
+// serve sends a request with headers to h and returns the response.
+func serve(h http.Handler, method, path string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+// principal is a user as the examples' Authenticators return them.
+type principal struct {
+	name, role string
+}
+
+func (p principal) HasRole(role string) bool { return p.role == role }
+
+var principals = map[string]principal{
+	"alice-token": {"alice", "user"},
+	"bob-token":   {"bob", "admin"},
+}
+
+func authenticate(token string) (any, error) {
+	if p, ok := principals[token]; ok {
+		return p, nil
+	}
+	return nil, errors.New("invalid or expired token")
+}
+
+// whoami answers with the principal's name.
+func whoami(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"user": c.MustGet(middleware.UserKey).(principal).name})
+}
+
+func TestAuth(t *testing.T) {
+	r := engine()
+	r.GET("/me", middleware.Auth(authenticate), whoami)
+	tests := []struct {
+		name   string
+		header string
+		status int
+		want   string // the user, or the error
+	}{
+		{"bearer", "Bearer alice-token", http.StatusOK, "alice"},
+		{"scheme in any case", "bearer bob-token", http.StatusOK, "bob"},
+		{"no credentials", "", http.StatusUnauthorized, "missing Authorization header"},
+		{"basic", "Basic YWxpY2U6cHc=", http.StatusUnauthorized, "invalid Authorization format"},
+		{"no scheme", "alice-token", http.StatusUnauthorized, "invalid Authorization format"},
+		{"bad token", "Bearer nope", http.StatusUnauthorized, "invalid or expired token"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(r, http.MethodGet, "/me", map[string]string{"Authorization": tt.header})
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d", w.Code, tt.status)
+			}
+			var body map[string]string
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if got := body["user"] + body["error"]; got != tt.want {
+				t.Errorf("body = %v, want %q", body, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequire(t *testing.T) {
+	r := engine()
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	// a principal that can't be asked about roles
+	r.GET("/opaque", func(c *gin.Context) { c.Set(middleware.UserKey, "someone") }, middleware.RequireAdmin(), ok)
+	r.GET("/anonymous", middleware.RequireAdmin(), ok)
+	auth := middleware.Auth(authenticate)
+	r.GET("/admin", auth, middleware.RequireAdmin(), ok)
+	r.GET("/role", auth, middleware.RequireRole("user"), ok)
+
+	tests := []struct {
+		path   string
+		token  string
+		status int
+	}{
+		{"/admin", "bob-token", http.StatusOK},
+		{"/admin", "alice-token", http.StatusForbidden},
+		{"/role", "alice-token", http.StatusOK},
+		{"/role", "bob-token", http.StatusForbidden},
+		{"/opaque", "", http.StatusForbidden},
+		{"/anonymous", "", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path+" "+tt.token, func(t *testing.T) {
+			w := serve(r, http.MethodGet, tt.path, map[string]string{"Authorization": "Bearer " + tt.token})
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d", w.Code, tt.status)
+			}
+		})
+	}
+}
+
+func TestPrincipal(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	if _, ok := middleware.Principal[principal](c); ok {
+		t.Error("Principal before Auth ran: ok")
+	}
+	c.Set(middleware.UserKey, "someone")
+	if _, ok := middleware.Principal[principal](c); ok {
+		t.Error("Principal of another type: ok")
+	}
+	c.Set(middleware.UserKey, principals["bob-token"])
+	if p, ok := middleware.Principal[principal](c); !ok || p.name != "bob" {
+		t.Errorf("Principal = %v, %v, want bob", p, ok)
+	}
+	// an interface the principal implements works too
+	if _, ok := middleware.Principal[interface{ HasRole(string) bool }](c); !ok {
+		t.Error("Principal as a role checker: not ok")
+	}
+}
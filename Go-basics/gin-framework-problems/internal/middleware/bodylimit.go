@@ -0,0 +1,119 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+)
+
+// routeBodyLimit is the body cap of the routes a pattern matches.
+type routeBodyLimit struct {
+	routePattern
+	limit int64
+}
+
+type bodyLimitConfig struct {
+	routes []routeBodyLimit
+}
+
+// BodyLimitOption customizes the body limit middleware.
+type BodyLimitOption func(*bodyLimitConfig)
+
+// WithRouteLimits caps the bodies of some routes at other sizes than n, by
+// "[METHOD ]path" pattern as LimitRoutes takes them, such as
+// {"POST /register": 16 << 10}. The most specific pattern a request matches
+// applies, and it is final: a BodyLimit the route mounts itself doesn't
+// replace it, so operators can tighten or loosen any route.
+func WithRouteLimits(limits map[string]int64) BodyLimitOption {
+	return func(cfg *bodyLimitConfig) {
+		for pattern, n := range limits {
+			cfg.routes = append(cfg.routes, routeBodyLimit{parseRoutePattern(pattern), n})
+		}
+		slices.SortStableFunc(cfg.routes, func(a, b routeBodyLimit) int { return moreSpecific(a.routePattern, b.routePattern) })
+	}
+}
+
+// BodyLimit caps the request body at n bytes with http.MaxBytesReader.
+// NewEngine mounts it with server.max_body_bytes for every route, and
+// server.body_limits through WithRouteLimits; mounting it again on a route or
+// group replaces that limit there, so upload routes can allow more than JSON
+// ones. A non-positive n lifts the limit.
+//
+// A body declaring a Content-Length over the limit fails on the first read,
+// before any of it is received. Reading past the limit fails with
+// *http.MaxBytesError, which BindError and ErrorHandler turn into a 413 that
+// reports the limit.
+func BodyLimit(n int64, opts ...BodyLimitOption) gin.HandlerFunc {
+	var cfg bodyLimitConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return func(c *gin.Context) {
+		if c.Request.Body == nil || c.Request.Body == http.NoBody {
+			c.Next()
+			return
+		}
+		limit, fixed := cfg.limit(c.Request.Method, c.FullPath())
+		if !fixed {
+			limit = n
+		}
+		if b, ok := c.Request.Body.(*limitedBody); ok {
+			// the route's own limit wins over the engine's, unless
+			// WithRouteLimits set that one; nothing has been read yet
+			if !b.fixed {
+				b.limit, b.fixed = limit, fixed
+			}
+			c.Next()
+			return
+		}
+		c.Request.Body = &limitedBody{
+			orig:   c.Request.Body,
+			w:      c.Writer,
+			limit:  limit,
+			fixed:  fixed,
+			length: c.Request.ContentLength,
+		}
+		c.Next()
+	}
+}
+
+// limit is the cap WithRouteLimits gives route, if any.
+func (cfg bodyLimitConfig) limit(method, route string) (int64, bool) {
+	for _, r := range cfg.routes {
+		if r.matches(method, route) {
+			return r.limit, true
+		}
+	}
+	return 0, false
+}
+
+// limitedBody applies the limit on the first read, once every BodyLimit on
+// the route has had its say.
+type limitedBody struct {
+	orig   io.ReadCloser
+	w      http.ResponseWriter
+	limit  int64
+	fixed  bool  // set by WithRouteLimits, so later BodyLimits keep it
+	length int64 // Content-Length, -1 when unknown
+
+	r io.ReadCloser
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.r == nil {
+		if b.limit > 0 && b.length > b.limit {
+			return 0, &http.MaxBytesError{Limit: b.limit}
+		}
+		b.r = b.orig
+		if b.limit > 0 {
+			b.r = http.MaxBytesReader(b.w, b.orig, b.limit)
+		}
+	}
+	return b.r.Read(p)
+}
+
+func (b *limitedBody) Close() error {
+	return b.orig.Close()
+}
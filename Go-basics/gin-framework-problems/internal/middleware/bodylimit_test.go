@@ -0,0 +1,62 @@
+package middleware_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+)
+
+func TestBodyLimitRoutes(t *testing.T) {
+	r := engine(middleware.BodyLimit(100, middleware.WithRouteLimits(map[string]int64{
+		"POST /register": 10,
+		"/import":        0,
+		"/covers/*":      20,
+	})))
+	// read answers with the limit a read ran into, or "ok"
+	read := func(c *gin.Context) {
+		_, err := io.ReadAll(c.Request.Body)
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.String(http.StatusRequestEntityTooLarge, strconv.FormatInt(tooLarge.Limit, 10))
+			return
+		}
+		c.String(http.StatusOK, "ok")
+	}
+	r.POST("/register", read)
+	r.PUT("/register", read)
+	r.POST("/import", read)
+	// the route's own limit gives way to the configured one
+	r.PUT("/covers/:id", middleware.BodyLimit(1000), read)
+	r.PUT("/files/:id", middleware.BodyLimit(1000), read)
+
+	tests := []struct {
+		method, path string
+		size         int
+		want         string
+	}{
+		{http.MethodPost, "/register", 10, "ok"},
+		{http.MethodPost, "/register", 11, "10"},
+		{http.MethodPut, "/register", 11, "ok"},
+		{http.MethodPut, "/register", 101, "100"},
+		{http.MethodPost, "/import", 5000, "ok"},
+		{http.MethodPut, "/covers/1", 21, "20"},
+		{http.MethodPut, "/files/1", 500, "ok"},
+		{http.MethodPut, "/files/1", 1001, "1000"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(strings.Repeat("x", tt.size)))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if got := w.Body.String(); got != tt.want {
+			t.Errorf("%s %s with %d bytes: %s, want %s", tt.method, tt.path, tt.size, got, tt.want)
+		}
+	}
+}
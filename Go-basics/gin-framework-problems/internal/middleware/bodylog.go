@@ -0,0 +1,244 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// redacted replaces the values BodyLogger must not log.
+const redacted = "[REDACTED]"
+
+type bodyLogConfig struct {
+	maxBytes int
+	fields   map[string]bool
+	headers  []string
+	routes   []routeSwitch
+}
+
+// routeSwitch turns body logging on or off for the routes a pattern matches.
+type routeSwitch struct {
+	routePattern
+	on bool
+}
+
+// BodyLogOption customizes the body logging middleware.
+type BodyLogOption func(*bodyLogConfig)
+
+// WithMaxBodyBytes sets how much of each body is kept for the log, 4 KiB by
+// default. A longer body is logged by size alone, since a cut-off document
+// can't be redacted reliably.
+func WithMaxBodyBytes(n int) BodyLogOption {
+	return func(cfg *bodyLogConfig) { cfg.maxBytes = n }
+}
+
+// WithRedactedFields adds to the JSON and form fields whose values are
+// replaced by [REDACTED], at any depth and whatever their case. password,
+// token, secret and the like always are.
+func WithRedactedFields(fields ...string) BodyLogOption {
+	return func(cfg *bodyLogConfig) {
+		for _, f := range fields {
+			cfg.fields[strings.ToLower(f)] = true
+		}
+	}
+}
+
+// WithBodyLogRoute logs the bodies of the routes pattern matches, "[METHOD
+// ]path" as LimitRoutes takes them, or not when on is false, such as
+// uploads. The most specific pattern a request matches applies; routes no
+// pattern matches are logged.
+func WithBodyLogRoute(pattern string, on bool) BodyLogOption {
+	return func(cfg *bodyLogConfig) {
+		cfg.routes = append(cfg.routes, routeSwitch{parseRoutePattern(pattern), on})
+		slices.SortStableFunc(cfg.routes, func(a, b routeSwitch) int { return moreSpecific(a.routePattern, b.routePattern) })
+	}
+}
+
+var defaultRedactedFields = []string{
+	"password", "new_password", "current_password", "token", "access_token",
+	"refresh_token", "secret", "api_key", "authorization", "csrf_token",
+}
+
+// BodyLogger logs each request's headers and body and the response body at
+// debug level, for debugging a client. Values that give access are
+// redacted: the Authorization, Cookie, Set-Cookie, X-API-Key and
+// X-CSRF-Token headers, and the fields WithRedactedFields lists in JSON and
+// form bodies. Other bodies, such as uploads and compressed responses, are
+// logged by size and type only. The request body is captured as the handler
+// reads it, so a body it doesn't read isn't logged.
+//
+// It costs nothing while logger is above debug. Mount it after Logger and
+// before ErrorHandler, so error responses are captured too.
+func BodyLogger(logger *slog.Logger, opts ...BodyLogOption) gin.HandlerFunc {
+	cfg := bodyLogConfig{
+		maxBytes: 4 << 10,
+		fields:   map[string]bool{},
+		headers:  []string{"Authorization", "Cookie", "Set-Cookie", "X-API-Key", "X-CSRF-Token"},
+	}
+	WithRedactedFields(defaultRedactedFields...)(&cfg)
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		if !logger.Enabled(ctx, slog.LevelDebug) || !cfg.on(c.Request.Method, c.FullPath()) {
+			c.Next()
+			return
+		}
+
+		req := &captureBody{ReadCloser: c.Request.Body, capture: capture{max: cfg.maxBytes}}
+		if c.Request.Body != nil && c.Request.Body != http.NoBody {
+			c.Request.Body = req
+		}
+		orig := c.Writer
+		resp := &captureWriter{ResponseWriter: orig, capture: capture{max: cfg.maxBytes}}
+		c.Writer = resp
+		defer func() { c.Writer = orig }()
+
+		c.Next()
+
+		logger.LogAttrs(ctx, slog.LevelDebug, "request body",
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.Int("status", resp.Status()),
+			slog.Any("request_headers", cfg.redactHeaders(c.Request.Header)),
+			slog.Any("request_body", cfg.body(c.Request.Header, req.buf.Bytes(), req.n)),
+			slog.Any("response_body", cfg.body(resp.Header(), resp.buf.Bytes(), resp.n)),
+		)
+	}
+}
+
+func (cfg bodyLogConfig) on(method, route string) bool {
+	for _, r := range cfg.routes {
+		if r.matches(method, route) {
+			return r.on
+		}
+	}
+	return true
+}
+
+func (cfg bodyLogConfig) redactHeaders(h http.Header) map[string]string {
+	out := make(map[string]string, len(h))
+	for name, values := range h {
+		out[name] = strings.Join(values, ", ")
+	}
+	for _, name := range cfg.headers {
+		if _, ok := out[name]; ok {
+			out[name] = redacted
+		}
+	}
+	return out
+}
+
+// body is what to log of a body that was n bytes long and began with data:
+// JSON and form bodies redacted, anything else, which can't be, by size and
+// type.
+func (cfg bodyLogConfig) body(h http.Header, data []byte, n int64) any {
+	if n == 0 {
+		return nil
+	}
+	mediaType, _, _ := mime.ParseMediaType(h.Get("Content-Type"))
+	summary := strconv.FormatInt(n, 10) + " bytes"
+	if mediaType != "" {
+		summary += " of " + mediaType
+	}
+	if n > int64(len(data)) || h.Get("Content-Encoding") != "" {
+		return summary
+	}
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		var v any
+		if json.Unmarshal(data, &v) != nil {
+			return summary
+		}
+		return cfg.redact(v)
+	case mediaType == "application/x-www-form-urlencoded":
+		form, err := url.ParseQuery(string(data))
+		if err != nil {
+			return summary
+		}
+		for name := range form {
+			if cfg.fields[strings.ToLower(name)] {
+				form[name] = []string{redacted}
+			}
+		}
+		return form
+	}
+	return summary
+}
+
+// redact replaces the values of sensitive fields in a decoded JSON value.
+func (cfg bodyLogConfig) redact(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, field := range v {
+			if cfg.fields[strings.ToLower(k)] {
+				v[k] = redacted
+			} else {
+				v[k] = cfg.redact(field)
+			}
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = cfg.redact(item)
+		}
+	}
+	return v
+}
+
+// capture keeps the first max bytes of a body and counts the rest.
+type capture struct {
+	max int
+	buf bytes.Buffer
+	n   int64
+}
+
+func (c *capture) keep(p []byte) {
+	c.n += int64(len(p))
+	if room := c.max - c.buf.Len(); room > 0 {
+		c.buf.Write(p[:min(room, len(p))])
+	}
+}
+
+// captureBody captures a request body as the handler reads it.
+type captureBody struct {
+	io.ReadCloser
+	capture
+}
+
+func (b *captureBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.keep(p[:n])
+	return n, err
+}
+
+// captureWriter captures a response body as it is written.
+type captureWriter struct {
+	gin.ResponseWriter
+	capture
+}
+
+func (w *captureWriter) Write(p []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(p)
+	w.keep(p[:n])
+	return n, err
+}
+
+func (w *captureWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Unwrap lets http.ResponseController reach the connection.
+func (w *captureWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
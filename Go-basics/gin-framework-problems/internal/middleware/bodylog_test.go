@@ -0,0 +1,122 @@
+package middleware_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+)
+
+func TestBodyLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	r := engine(middleware.BodyLogger(logger,
+		middleware.WithMaxBodyBytes(128),
+		middleware.WithRedactedFields("SSN"),
+		middleware.WithBodyLogRoute("POST /upload", false)))
+	echo := func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		c.Data(http.StatusOK, c.ContentType(), body)
+	}
+	r.POST("/echo", echo)
+	r.POST("/upload", echo)
+
+	tests := []struct {
+		name, path, contentType, body string
+		want                          map[string]any // request_body logged, nil for no line
+	}{
+		{"json", "/echo", "application/json",
+			`{"user":"alice","password":"p","nested":{"ssn":"1","access_token":"t"}}`,
+			map[string]any{"user": "alice", "password": "[REDACTED]",
+				"nested": map[string]any{"ssn": "[REDACTED]", "access_token": "[REDACTED]"}}},
+		{"form", "/echo", "application/x-www-form-urlencoded", "user=alice&Token=t",
+			map[string]any{"user": []any{"alice"}, "Token": []any{"[REDACTED]"}}},
+		{"switched off", "/upload", "application/json", `{"a":1}`, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf.Reset()
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			req.Header.Set("Authorization", "Bearer secret")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Body.String() != tt.body {
+				t.Fatalf("handler got %q, want %q", w.Body, tt.body)
+			}
+			if tt.want == nil {
+				if buf.Len() != 0 {
+					t.Fatalf("logged %s, want nothing", buf.String())
+				}
+				return
+			}
+			var line struct {
+				RequestHeaders map[string]string `json:"request_headers"`
+				RequestBody    map[string]any    `json:"request_body"`
+				ResponseBody   any               `json:"response_body"`
+			}
+			if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+				t.Fatalf("%v in %s", err, buf.String())
+			}
+			if got := line.RequestHeaders["Authorization"]; got != "[REDACTED]" {
+				t.Errorf("Authorization logged as %q", got)
+			}
+			got, _ := json.Marshal(line.RequestBody)
+			want, _ := json.Marshal(tt.want)
+			if !bytes.Equal(got, want) {
+				t.Errorf("request body logged as %s, want %s", got, want)
+			}
+			if strings.Contains(buf.String(), `"p"`) {
+				t.Errorf("password leaked in %s", buf.String())
+			}
+		})
+	}
+}
+
+func TestBodyLoggerMaxBytes(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	r := engine(middleware.BodyLogger(logger, middleware.WithMaxBodyBytes(8)))
+	r.POST("/echo", func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		c.Data(http.StatusOK, "application/json", body)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader(`{"password":"hunter2"}`))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	var line struct {
+		RequestBody  string `json:"request_body"`
+		ResponseBody string `json:"response_body"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatalf("%v in %s", err, buf.String())
+	}
+	const want = "22 bytes of application/json"
+	if line.RequestBody != want || line.ResponseBody != want {
+		t.Errorf("bodies logged as %q and %q, want %q", line.RequestBody, line.ResponseBody, want)
+	}
+}
+
+func TestBodyLoggerAboveDebug(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo}))
+	r := engine(middleware.BodyLogger(logger))
+	r.GET("/", func(c *gin.Context) { c.String(http.StatusOK, "hi") })
+
+	if w := serve(r, http.MethodGet, "/", nil); w.Body.String() != "hi" {
+		t.Fatalf("body = %q", w.Body)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("logged %s above debug", buf.String())
+	}
+}
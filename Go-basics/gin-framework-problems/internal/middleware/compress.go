@@ -0,0 +1,273 @@
+package middleware
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+)
+
+type compressConfig struct {
+	minSize      int
+	contentTypes map[string]bool
+}
+
+type CompressOption func(*compressConfig)
+
+// WithMinSize sets how many bytes a response needs before it is compressed
+// (default 1 KiB); smaller ones gain little and cost CPU.
+func WithMinSize(n int) CompressOption {
+	return func(cfg *compressConfig) { cfg.minSize = n }
+}
+
+// WithContentTypes replaces the media types that get compressed. The default
+// is JSON, text/*-style and other textual types.
+func WithContentTypes(types ...string) CompressOption {
+	return func(cfg *compressConfig) {
+		cfg.contentTypes = make(map[string]bool, len(types))
+		for _, t := range types {
+			cfg.contentTypes[t] = true
+		}
+	}
+}
+
+var defaultCompressTypes = []string{
+	"application/json",
+	"application/problem+json",
+	"application/javascript",
+	"application/xml",
+	"image/svg+xml",
+	"text/html",
+	"text/plain",
+	"text/css",
+	"text/csv",
+	"text/xml",
+}
+
+var (
+	gzipPool   = sync.Pool{New: func() any { w, _ := gzip.NewWriterLevel(nil, gzip.DefaultCompression); return w }}
+	flatePool  = sync.Pool{New: func() any { w, _ := flate.NewWriter(nil, flate.DefaultCompression); return w }}
+	brotliPool = sync.Pool{New: func() any { return brotli.NewWriterLevel(nil, 4) }}
+)
+
+// Compress encodes responses with Brotli, gzip or deflate, whichever the
+// client prefers in Accept-Encoding. Only the configured content types at or
+// above the minimum size are compressed; responses that already have a
+// Content-Encoding, and bodyless statuses, pass through. Vary:
+// Accept-Encoding is always set, since caches must key on it either way.
+// WebSocket upgrades and routes behind WithoutCompression are left alone.
+//
+// The first bytes are held until the size is known. A handler that flushes
+// (a stream) gets the decision made at that point and its data flushed
+// through the encoder on every Flush.
+func Compress(opts ...CompressOption) gin.HandlerFunc {
+	cfg := &compressConfig{minSize: 1024}
+	WithContentTypes(defaultCompressTypes...)(cfg)
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return func(c *gin.Context) {
+		addVary(c.Writer.Header(), "Accept-Encoding")
+		encoding := negotiateEncoding(c.GetHeader("Accept-Encoding"))
+		if encoding == "" || c.Request.Method == http.MethodHead || c.IsWebsocket() {
+			c.Next()
+			return
+		}
+
+		orig := c.Writer
+		w := &compressWriter{ResponseWriter: orig, cfg: cfg, encoding: encoding}
+		c.Writer = w
+		defer func() {
+			w.finish()
+			c.Writer = orig
+		}()
+		c.Next()
+	}
+}
+
+// WithoutCompression keeps Compress from encoding one route's responses,
+// such as file downloads: content that is mostly compressed already, served
+// in byte ranges that must count the bytes as stored. Put it first in the
+// route's handlers.
+func WithoutCompression() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if w, ok := c.Writer.(*compressWriter); ok {
+			// nothing is buffered yet; Compress restores its writer after
+			c.Writer = w.ResponseWriter
+		}
+		c.Next()
+	}
+}
+
+// negotiateEncoding picks br, gzip or deflate from an Accept-Encoding value,
+// by q value with br winning ties, then gzip. "" means send it uncompressed.
+func negotiateEncoding(header string) string {
+	q := map[string]float64{}
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		weight := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				weight = f
+			}
+		}
+		q[name] = weight
+	}
+	best, bestQ := "", 0.0
+	for _, enc := range []string{"br", "gzip", "deflate"} {
+		w, ok := q[enc]
+		if !ok {
+			w, ok = q["*"]
+		}
+		if ok && w > bestQ {
+			best, bestQ = enc, w
+		}
+	}
+	return best
+}
+
+func addVary(h http.Header, value string) {
+	for _, v := range h.Values("Vary") {
+		for _, f := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(f), value) {
+				return
+			}
+		}
+	}
+	h.Add("Vary", value)
+}
+
+// compressWriter buffers the start of the body until it can decide whether
+// to compress, then writes through an encoder or straight to the client.
+type compressWriter struct {
+	gin.ResponseWriter
+	cfg      *compressConfig
+	encoding string
+	buf      []byte
+	decided  bool
+	enc      io.WriteCloser
+}
+
+func (w *compressWriter) Write(p []byte) (int, error) {
+	if w.decided {
+		if w.enc != nil {
+			return w.enc.Write(p)
+		}
+		return w.ResponseWriter.Write(p)
+	}
+	w.buf = append(w.buf, p...)
+	if len(w.buf) >= w.cfg.minSize {
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Unwrap lets http.ResponseController reach the connection, for handlers
+// that extend their write deadline.
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// WriteHeaderNow is deferred until the decision, since compressing changes
+// the headers.
+func (w *compressWriter) WriteHeaderNow() {}
+
+// Written counts buffered bytes, so error handlers don't write a second body
+// over a response that is only waiting for its size to be known.
+func (w *compressWriter) Written() bool {
+	return len(w.buf) > 0 || w.ResponseWriter.Written()
+}
+
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		w.decide(true)
+	}
+	if f, ok := w.enc.(interface{ Flush() error }); ok {
+		f.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// decide settles on compressing or not, sends the headers and whatever was
+// buffered. big says the body is large enough to be worth compressing.
+func (w *compressWriter) decide(big bool) error {
+	w.decided = true
+	h := w.Header()
+	status := w.Status()
+	if big && status >= http.StatusOK && status != http.StatusNoContent && status != http.StatusNotModified &&
+		h.Get("Content-Encoding") == "" && w.compressible(h) {
+		h.Set("Content-Encoding", w.encoding)
+		h.Del("Content-Length")
+		switch w.encoding {
+		case "br":
+			bw := brotliPool.Get().(*brotli.Writer)
+			bw.Reset(w.ResponseWriter)
+			w.enc = bw
+		case "gzip":
+			gw := gzipPool.Get().(*gzip.Writer)
+			gw.Reset(w.ResponseWriter)
+			w.enc = gw
+		case "deflate":
+			fw := flatePool.Get().(*flate.Writer)
+			fw.Reset(w.ResponseWriter)
+			w.enc = fw
+		}
+	}
+	w.ResponseWriter.WriteHeaderNow()
+	if len(w.buf) == 0 {
+		return nil
+	}
+	buf := w.buf
+	w.buf = nil
+	_, err := w.Write(buf)
+	return err
+}
+
+func (w *compressWriter) compressible(h http.Header) bool {
+	ct := h.Get("Content-Type")
+	if ct == "" && len(w.buf) > 0 {
+		// net/http would sniff the compressed bytes; sniff the real ones
+		ct = http.DetectContentType(w.buf)
+		h.Set("Content-Type", ct)
+	}
+	mt, _, err := mime.ParseMediaType(ct)
+	return err == nil && w.cfg.contentTypes[mt]
+}
+
+// finish runs after the handler: small bodies go out as they are, and the
+// encoder is closed and returned to its pool.
+func (w *compressWriter) finish() {
+	if !w.decided {
+		w.decide(len(w.buf) >= w.cfg.minSize)
+	}
+	if w.enc == nil {
+		return
+	}
+	w.enc.Close()
+	switch enc := w.enc.(type) {
+	case *brotli.Writer:
+		brotliPool.Put(enc)
+	case *gzip.Writer:
+		gzipPool.Put(enc)
+	case *flate.Writer:
+		flatePool.Put(enc)
+	}
+	w.enc = nil
+}
@@ -0,0 +1,64 @@
+package middleware_test
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+)
+
+func TestCompress(t *testing.T) {
+	big := strings.Repeat(`{"title":"Dune"},`, 200)
+	r := engine(middleware.Compress())
+	r.GET("/books", func(c *gin.Context) { c.Data(http.StatusOK, "application/json", []byte(big)) })
+	r.GET("/small", func(c *gin.Context) { c.Data(http.StatusOK, "application/json", []byte("{}")) })
+	r.GET("/files/:id", middleware.WithoutCompression(), func(c *gin.Context) {
+		c.Data(http.StatusOK, "text/plain", []byte(big))
+	})
+
+	tests := []struct {
+		path, accept string
+		want         string // the Content-Encoding
+	}{
+		{"/books", "gzip, deflate", "gzip"},
+		{"/books", "deflate", "deflate"},
+		{"/books", "gzip;q=0.5, deflate", "deflate"},
+		{"/books", "identity", ""},
+		{"/small", "gzip", ""},
+		{"/files/1", "gzip", ""},
+	}
+	for _, tt := range tests {
+		w := serve(r, http.MethodGet, tt.path, map[string]string{"Accept-Encoding": tt.accept})
+		if got := w.Header().Get("Content-Encoding"); got != tt.want {
+			t.Errorf("%s with %q: Content-Encoding %q, want %q", tt.path, tt.accept, got, tt.want)
+			continue
+		}
+		if vary := w.Header().Get("Vary"); vary != "Accept-Encoding" {
+			t.Errorf("%s with %q: Vary %q", tt.path, tt.accept, vary)
+		}
+		var body io.Reader = w.Body
+		switch tt.want {
+		case "gzip":
+			zr, err := gzip.NewReader(body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body = zr
+		case "deflate":
+			body = flate.NewReader(body)
+		}
+		got, err := io.ReadAll(body)
+		if err != nil {
+			t.Fatalf("%s with %q: %v", tt.path, tt.accept, err)
+		}
+		if tt.path != "/small" && string(got) != big {
+			t.Errorf("%s with %q: body of %d bytes doesn't round-trip", tt.path, tt.accept, len(got))
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package middleware
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type corsConfig struct {
+	origins     []string
+	methods     []string
+	headers     []string
+	expose      []string
+	maxAge      time.Duration
+	credentials bool
+}
+
+// CORSOption customizes the CORS middleware.
+type CORSOption func(*corsConfig)
+
+// WithAllowOrigins sets the origins allowed to call the API: exact ones such
+// as https://app.example.com, subdomains of one such as
+// https://*.example.com (which leaves out example.com itself), or "*" for
+// any. By default no cross-origin request is allowed.
+func WithAllowOrigins(origins ...string) CORSOption {
+	return func(cfg *corsConfig) { cfg.origins = origins }
+}
+
+// WithAllowMethods overrides the methods advertised in preflight responses.
+func WithAllowMethods(methods ...string) CORSOption {
+	return func(cfg *corsConfig) { cfg.methods = methods }
+}
+
+// WithAllowHeaders overrides the request headers clients may send.
+func WithAllowHeaders(headers ...string) CORSOption {
+	return func(cfg *corsConfig) { cfg.headers = headers }
+}
+
+// WithExposeHeaders overrides the response headers scripts may read besides
+// the CORS-safelisted ones.
+func WithExposeHeaders(headers ...string) CORSOption {
+	return func(cfg *corsConfig) { cfg.expose = headers }
+}
+
+// WithMaxAge sets how long browsers may cache a preflight answer, 10 minutes
+// by default. Browsers cap it, Chrome at 2 hours.
+func WithMaxAge(d time.Duration) CORSOption {
+	return func(cfg *corsConfig) { cfg.maxAge = d }
+}
+
+// WithCredentials lets browsers send cookies and HTTP authentication along
+// and show the response to the script. Allowed origins are then always
+// echoed, never "*", which browsers refuse with credentials. CORS panics
+// when it is combined with the origin "*", which would let every site read
+// responses with the user's cookies.
+func WithCredentials(allow bool) CORSOption {
+	return func(cfg *corsConfig) { cfg.credentials = allow }
+}
+
+// CORS lets the allowed origins call the API from a browser. It answers
+// preflight requests itself, 204 for an allowed origin and 403 for any
+// other, and adds the CORS headers to the actual requests of allowed
+// origins; requests from other origins go on without them, so the browser
+// hides the response. Responses vary by Origin, for caches.
+func CORS(opts ...CORSOption) gin.HandlerFunc {
+	cfg := corsConfig{
+		methods: []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		headers: []string{"Authorization", "Content-Type", "X-API-Key", "X-CSRF-Token", "X-Captcha-Token", "X-Request-ID", "If-Match", "If-None-Match"},
+		expose:  []string{"X-Request-ID", "ETag", "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset", "Retry-After"},
+		maxAge:  10 * time.Minute,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	methods := strings.Join(cfg.methods, ", ")
+	headers := strings.Join(cfg.headers, ", ")
+	expose := strings.Join(cfg.expose, ", ")
+	maxAge := strconv.Itoa(int(cfg.maxAge.Seconds()))
+	anyOrigin := slices.Contains(cfg.origins, "*")
+	if anyOrigin && cfg.credentials {
+		panic(`middleware: CORS with credentials needs explicit origins, not "*"`)
+	}
+	origins := parseOrigins(cfg.origins)
+
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" {
+			c.Next()
+			return
+		}
+		h := c.Writer.Header()
+		addVary(h, "Origin")
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+		if !anyOrigin && !allowedOrigin(origins, origin) {
+			if preflight {
+				AbortError(c, http.StatusForbidden, "origin not allowed")
+				return
+			}
+			c.Next()
+			return
+		}
+
+		if anyOrigin {
+			h.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			h.Set("Access-Control-Allow-Origin", origin)
+		}
+		if cfg.credentials {
+			h.Set("Access-Control-Allow-Credentials", "true")
+		}
+		if !preflight {
+			if expose != "" {
+				h.Set("Access-Control-Expose-Headers", expose)
+			}
+			c.Next()
+			return
+		}
+		addVary(h, "Access-Control-Request-Method")
+		addVary(h, "Access-Control-Request-Headers")
+		h.Set("Access-Control-Allow-Methods", methods)
+		h.Set("Access-Control-Allow-Headers", headers)
+		if cfg.maxAge > 0 {
+			h.Set("Access-Control-Max-Age", maxAge)
+		}
+		c.AbortWithStatus(http.StatusNoContent)
+	}
+}
+
+// corsOrigin is a parsed allowed origin; wildcard matches the subdomains
+// of host rather than host.
+type corsOrigin struct {
+	scheme, host, port string
+	wildcard           bool
+}
+
+// parseOrigins parses WithAllowOrigins' origins, skipping "*" and any
+// without a scheme and host.
+func parseOrigins(origins []string) []corsOrigin {
+	var parsed []corsOrigin
+	for _, pattern := range origins {
+		rest, wildcard := pattern, false
+		if scheme, host, ok := strings.Cut(pattern, "://*."); ok {
+			rest, wildcard = scheme+"://"+host, true
+		}
+		u, err := url.Parse(rest)
+		if err != nil || u.Host == "" {
+			continue
+		}
+		parsed = append(parsed, corsOrigin{strings.ToLower(u.Scheme), strings.ToLower(u.Hostname()), u.Port(), wildcard})
+	}
+	return parsed
+}
+
+// allowedOrigin reports whether origin is one of origins or a subdomain of
+// a wildcard one, comparing schemes, hosts and ports.
+func allowedOrigin(origins []corsOrigin, origin string) bool {
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	scheme, host, port := strings.ToLower(u.Scheme), strings.ToLower(u.Hostname()), u.Port()
+	for _, o := range origins {
+		if o.scheme != scheme || o.port != port {
+			continue
+		}
+		if o.wildcard {
+			if sub, ok := strings.CutSuffix(host, "."+o.host); ok && sub != "" {
+				return true
+			}
+		} else if host == o.host {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,145 @@
+package middleware_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+)
+
+func corsRouter(opts ...middleware.CORSOption) *gin.Engine {
+	r := engine(middleware.CORS(opts...))
+	r.GET("/books", func(c *gin.Context) { c.Status(http.StatusOK) })
+	return r
+}
+
+func TestCORSHeaders(t *testing.T) {
+	allowed := middleware.WithAllowOrigins("https://app.example.com", "https://*.example.org")
+	tests := []struct {
+		name      string
+		opts      []middleware.CORSOption
+		origin    string
+		preflight bool
+		status    int
+		want      map[string]string // "" for a header that must be absent
+	}{
+		{
+			name:   "exact origin",
+			opts:   []middleware.CORSOption{allowed},
+			origin: "https://app.example.com",
+			status: http.StatusOK,
+			want: map[string]string{
+				"Access-Control-Allow-Origin":   "https://app.example.com",
+				"Access-Control-Expose-Headers": "X-Request-ID, ETag, X-RateLimit-Limit, X-RateLimit-Remaining, X-RateLimit-Reset, Retry-After",
+				"Access-Control-Allow-Methods":  "",
+				"Vary":                          "Origin",
+			},
+		},
+		{
+			name:   "wildcard subdomain",
+			opts:   []middleware.CORSOption{allowed},
+			origin: "https://a.b.example.org",
+			status: http.StatusOK,
+			want:   map[string]string{"Access-Control-Allow-Origin": "https://a.b.example.org"},
+		},
+		{
+			// the wildcard leaves out the domain itself
+			name:   "wildcard parent",
+			opts:   []middleware.CORSOption{allowed},
+			origin: "https://example.org",
+			status: http.StatusOK,
+			want:   map[string]string{"Access-Control-Allow-Origin": ""},
+		},
+		{
+			name:   "other scheme",
+			opts:   []middleware.CORSOption{allowed},
+			origin: "http://app.example.com",
+			status: http.StatusOK,
+			want:   map[string]string{"Access-Control-Allow-Origin": ""},
+		},
+		{
+			name:   "no origins",
+			origin: "https://app.example.com",
+			status: http.StatusOK,
+			want:   map[string]string{"Access-Control-Allow-Origin": ""},
+		},
+		{
+			// answered by the middleware, without a route for OPTIONS
+			name:      "preflight",
+			opts:      []middleware.CORSOption{allowed, middleware.WithMaxAge(time.Hour)},
+			origin:    "https://app.example.com",
+			preflight: true,
+			status:    http.StatusNoContent,
+			want: map[string]string{
+				"Access-Control-Allow-Origin":  "https://app.example.com",
+				"Access-Control-Allow-Methods": "GET, POST, PUT, PATCH, DELETE, OPTIONS",
+				"Access-Control-Allow-Headers": "Authorization, Content-Type, X-API-Key, X-CSRF-Token, X-Captcha-Token, X-Request-ID, If-Match, If-None-Match",
+				"Access-Control-Max-Age":       "3600",
+			},
+		},
+		{
+			name:      "preflight refused",
+			opts:      []middleware.CORSOption{allowed},
+			origin:    "https://evil.example.net",
+			preflight: true,
+			status:    http.StatusForbidden,
+			want:      map[string]string{"Access-Control-Allow-Origin": ""},
+		},
+		{
+			name: "options",
+			opts: []middleware.CORSOption{
+				middleware.WithAllowOrigins("*"),
+				middleware.WithAllowMethods("GET", "PUT"),
+				middleware.WithAllowHeaders("Authorization"),
+			},
+			origin:    "https://app.example.com",
+			preflight: true,
+			status:    http.StatusNoContent,
+			want: map[string]string{
+				"Access-Control-Allow-Origin":  "*",
+				"Access-Control-Allow-Methods": "GET, PUT",
+				"Access-Control-Allow-Headers": "Authorization",
+			},
+		},
+		{
+			name:   "credentials",
+			opts:   []middleware.CORSOption{allowed, middleware.WithCredentials(true)},
+			origin: "https://app.example.com",
+			status: http.StatusOK,
+			want: map[string]string{
+				"Access-Control-Allow-Origin":      "https://app.example.com",
+				"Access-Control-Allow-Credentials": "true",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method, headers := http.MethodGet, map[string]string{"Origin": tt.origin}
+			if tt.preflight {
+				method = http.MethodOptions
+				headers["Access-Control-Request-Method"] = http.MethodPut
+			}
+			w := serve(corsRouter(tt.opts...), method, "/books", headers)
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d", w.Code, tt.status)
+			}
+			for k, want := range tt.want {
+				if got := w.Header().Get(k); got != want {
+					t.Errorf("%s = %q, want %q", k, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCORSAnyOriginWithCredentials(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error(`CORS with "*" and credentials didn't panic`)
+		}
+	}()
+	middleware.CORS(middleware.WithAllowOrigins("*"), middleware.WithCredentials(true))
+}
@@ -0,0 +1,88 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+)
+
+func TestCSRF(t *testing.T) {
+	r := engine(middleware.CSRF(middleware.WithSameSite(http.SameSiteLaxMode), middleware.WithCSRFExempt("POST /hooks/*")))
+	ok := func(c *gin.Context) { c.Status(http.StatusNoContent) }
+	r.GET("/csrf", middleware.CSRFTokenHandler)
+	r.POST("/form", ok)
+	r.DELETE("/form", ok)
+	r.POST("/hooks/:name", ok)
+
+	// a first visit sets the cookie
+	w := serve(r, http.MethodGet, "/csrf", nil)
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != "csrf_token" || !cookies[0].HttpOnly || cookies[0].SameSite != http.SameSiteLaxMode {
+		t.Fatalf("cookies = %v, want an HttpOnly, SameSite=Lax csrf_token", cookies)
+	}
+	token := cookies[0].Value
+	if got := w.Body.String(); !strings.Contains(got, token) {
+		t.Errorf("GET /csrf = %s, want the cookie's token", got)
+	}
+	cookie := "csrf_token=" + token
+
+	tests := []struct {
+		name, method, path string
+		headers            map[string]string
+		form               url.Values
+		want               int
+	}{
+		{"header", http.MethodPost, "/form", map[string]string{"Cookie": cookie, "X-CSRF-Token": token}, nil, 204},
+		{"form field", http.MethodPost, "/form", map[string]string{"Cookie": cookie}, url.Values{"csrf_token": {token}}, 204},
+		{"other unsafe methods", http.MethodDelete, "/form", map[string]string{"Cookie": cookie, "X-CSRF-Token": token}, nil, 204},
+		{"no token", http.MethodPost, "/form", map[string]string{"Cookie": cookie}, nil, 403},
+		{"wrong token", http.MethodPost, "/form", map[string]string{"Cookie": cookie, "X-CSRF-Token": "forged"}, nil, 403},
+		{"no cookie", http.MethodPost, "/form", map[string]string{"X-CSRF-Token": token}, nil, 403},
+		{"bearer token", http.MethodPost, "/form", map[string]string{"Authorization": "Bearer abc"}, nil, 204},
+		{"api key", http.MethodPost, "/form", map[string]string{"X-API-Key": "abc"}, nil, 204},
+		{"exempt route", http.MethodPost, "/hooks/payments", nil, nil, 204},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.form.Encode()))
+			if tt.form != nil {
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			}
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d; body: %s", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+}
+
+func TestCSRFSecureCookie(t *testing.T) {
+	tests := []struct {
+		name   string
+		mw     []gin.HandlerFunc
+		secure bool
+	}{
+		{"plain HTTP", nil, false},
+		{"behind a TLS-ending proxy", []gin.HandlerFunc{middleware.AssumeTLS()}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := engine(append(tt.mw, middleware.CSRF())...)
+			r.GET("/csrf", middleware.CSRFTokenHandler)
+			cookies := serve(r, http.MethodGet, "/csrf", nil).Result().Cookies()
+			if len(cookies) != 1 || cookies[0].Secure != tt.secure {
+				t.Errorf("cookies = %v, want one with Secure %v", cookies, tt.secure)
+			}
+		})
+	}
+}
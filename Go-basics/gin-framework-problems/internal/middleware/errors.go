@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/apperror"
+)
+
+// ErrorHandler replaces gin.Recovery. A panic becomes a 500, and when a
+// handler reports an error with c.Error, or returns one through Handle,
+// without writing a response, the last one is rendered as an
+// apperror.APIError. 5xx errors are logged with their stack; the client
+// only gets the code and a generic message.
+func ErrorHandler(logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if r := recover(); r != nil {
+				if err, ok := r.(error); ok && err == http.ErrAbortHandler {
+					// the handler wants the connection dropped, not a response
+					panic(r)
+				}
+				e := apperror.Internal(fmt.Errorf("panic: %v", r))
+				e.Stack = debug.Stack()
+				c.Error(e)
+				respond(c, logger, e)
+				c.Abort()
+			}
+		}()
+
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		respond(c, logger, apperror.From(c.Errors.Last().Err))
+	}
+}
+
+func respond(c *gin.Context, logger *slog.Logger, e *apperror.Error) {
+	if e.Status >= http.StatusInternalServerError {
+		logger.ErrorContext(c.Request.Context(), "request failed",
+			"code", e.Code,
+			"error", e.Error(),
+			"stack", string(e.Stack),
+		)
+	}
+	if c.Writer.Written() {
+		return
+	}
+	c.AbortWithStatusJSON(e.Status, appErrorBody(c, e))
+}
+
+// appErrorBody is errorBody with e's own code and any details.
+func appErrorBody(c *gin.Context, e *apperror.Error) apperror.APIError {
+	body := errorBody(c, e.Status, e.Message)
+	body.Code = e.Code
+	body.Details = e.Details
+	return body
+}
+
+// Fail reports err and stops the chain; ErrorHandler writes the response.
+func Fail(c *gin.Context, err error) {
+	c.Error(err)
+	c.Abort()
+}
+
+// Handle adapts a handler that returns its error rather than writing it:
+// a non-nil error goes to Fail, so ErrorHandler answers with it, an
+// *apperror.Error as it says and anything else as apperror.From makes it.
+func Handle(fn func(*gin.Context) error) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := fn(c); err != nil {
+			Fail(c, err)
+		}
+	}
+}
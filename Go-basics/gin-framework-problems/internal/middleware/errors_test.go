@@ -0,0 +1,59 @@
+package middleware_test
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/apperror"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+)
+
+func TestHandleErrors(t *testing.T) {
+	r := engine(middleware.RequestID(), middleware.ErrorHandler(slog.New(slog.DiscardHandler)))
+	r.GET("/missing", middleware.Handle(func(c *gin.Context) error {
+		return apperror.NotFound("book not found")
+	}))
+	r.GET("/broken", middleware.Handle(func(c *gin.Context) error {
+		return errors.New("disk on fire")
+	}))
+	r.GET("/panic", func(c *gin.Context) { panic("oops") })
+	r.GET("/ok", middleware.Handle(func(c *gin.Context) error {
+		c.String(http.StatusOK, "fine")
+		return nil
+	}))
+
+	tests := []struct {
+		path    string
+		status  int
+		code    string
+		message string
+	}{
+		{"/missing", http.StatusNotFound, apperror.CodeNotFound, "book not found"},
+		// the client doesn't see what went wrong inside
+		{"/broken", http.StatusInternalServerError, apperror.CodeInternal, "internal server error"},
+		{"/panic", http.StatusInternalServerError, apperror.CodeInternal, "internal server error"},
+	}
+	for _, tt := range tests {
+		w := serve(r, http.MethodGet, tt.path, map[string]string{"X-Request-ID": "req-1"})
+		if w.Code != tt.status {
+			t.Fatalf("%s: status = %d, want %d", tt.path, w.Code, tt.status)
+		}
+		var body apperror.APIError
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("%s: %v: %s", tt.path, err, w.Body)
+		}
+		want := apperror.APIError{Message: tt.message, Code: tt.code, RequestID: "req-1"}
+		if body != want {
+			t.Errorf("%s: body = %+v, want %+v", tt.path, body, want)
+		}
+	}
+
+	if w := serve(r, http.MethodGet, "/ok", nil); w.Code != http.StatusOK || w.Body.String() != "fine" {
+		t.Errorf("/ok = %d %s", w.Code, w.Body)
+	}
+}
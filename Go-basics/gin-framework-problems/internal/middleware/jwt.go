@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/jwt"
+)
+
+var (
+	errInvalidToken = errors.New("invalid token")
+	errTokenExpired = errors.New("token has expired")
+)
+
+// JWT is an Authenticator for the tokens signer issues: it checks their
+// signature, issuer and times, then has resolve turn the claims into a
+// principal, say by loading the user the subject names. An expired token is
+// refused as "token has expired" and any other bad one as "invalid token";
+// errors from resolve are sent as they are.
+func JWT(signer *jwt.Signer, resolve func(jwt.Claims) (any, error)) Authenticator {
+	return func(token string) (any, error) {
+		claims, err := signer.Parse(token)
+		switch {
+		case errors.Is(err, jwt.ErrExpired):
+			return nil, errTokenExpired
+		case err != nil:
+			return nil, errInvalidToken
+		}
+		return resolve(claims)
+	}
+}
+
+// JWTClaims are the claims of the bearer token Auth accepted, for handlers
+// that need more of the token than the principal, such as its ID to revoke
+// it. It fails for requests Auth didn't pass with a token from signer.
+func JWTClaims(c *gin.Context, signer *jwt.Signer) (jwt.Claims, error) {
+	token := c.GetString(TokenKey)
+	if token == "" {
+		return jwt.Claims{}, errInvalidToken
+	}
+	return signer.Parse(token)
+}
+
+// BearerChallenge answers like AbortError, adding the WWW-Authenticate
+// header RFC 6750 asks of a 401 from an API that takes bearer tokens. Pass
+// it to WithAuthError.
+func BearerChallenge(c *gin.Context, status int, message string) {
+	if status == http.StatusUnauthorized {
+		c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+	}
+	AbortError(c, status, message)
+}
@@ -0,0 +1,104 @@
+package middleware_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/jwt"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+)
+
+func TestBearerToken(t *testing.T) {
+	tests := []struct {
+		header, token string
+		ok            bool
+	}{
+		{"Bearer abc", "abc", true},
+		{"BEARER abc", "abc", true},
+		{"Bearer ", "", false},
+		{"Bearer", "", false},
+		{"Basic abc", "", false},
+		{"abc", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		if token, ok := middleware.BearerToken(tt.header); token != tt.token || ok != tt.ok {
+			t.Errorf("BearerToken(%q) = %q, %v, want %q, %v", tt.header, token, ok, tt.token, tt.ok)
+		}
+	}
+}
+
+func TestJWT(t *testing.T) {
+	signer := jwt.NewHS256([]byte("secret"), "test", time.Hour)
+	past := jwt.NewHS256([]byte("secret"), "test", time.Hour,
+		jwt.WithClock(func() time.Time { return time.Now().Add(-2 * time.Hour) }))
+	other := jwt.NewHS256([]byte("other"), "test", time.Hour)
+	issue := func(s *jwt.Signer, subject string) string {
+		token, err := s.Issue(jwt.Claims{Subject: subject})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	resolve := func(c jwt.Claims) (any, error) {
+		if c.Subject == "gone" {
+			return nil, errors.New("user not found")
+		}
+		return principal{name: c.Subject}, nil
+	}
+
+	r := engine()
+	r.GET("/me", middleware.Auth(middleware.JWT(signer, resolve), middleware.WithAuthError(middleware.BearerChallenge)),
+		func(c *gin.Context) {
+			claims, err := middleware.JWTClaims(c, signer)
+			if err != nil {
+				t.Errorf("JWTClaims: %v", err)
+			}
+			c.JSON(http.StatusOK, gin.H{"user": c.MustGet(middleware.UserKey).(principal).name, "sub": claims.Subject})
+		})
+
+	tests := []struct {
+		name, token string
+		status      int
+		want        string // the user, or the error
+	}{
+		{"valid", issue(signer, "alice"), http.StatusOK, "alice"},
+		{"expired", issue(past, "alice"), http.StatusUnauthorized, "token has expired"},
+		{"other key", issue(other, "alice"), http.StatusUnauthorized, "invalid token"},
+		{"garbage", "nope", http.StatusUnauthorized, "invalid token"},
+		{"unresolved", issue(signer, "gone"), http.StatusUnauthorized, "user not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(r, http.MethodGet, "/me", map[string]string{"Authorization": "Bearer " + tt.token})
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d", w.Code, tt.status)
+			}
+			var body map[string]string
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if got := body["user"] + body["error"]; got != tt.want {
+				t.Errorf("body = %v, want %q", body, tt.want)
+			}
+			if w.Code == http.StatusOK && body["sub"] != tt.want {
+				t.Errorf("JWTClaims subject = %q, want %q", body["sub"], tt.want)
+			}
+			challenge := w.Header().Get("WWW-Authenticate")
+			if (challenge != "") != (w.Code == http.StatusUnauthorized) {
+				t.Errorf("WWW-Authenticate = %q on a %d", challenge, w.Code)
+			}
+		})
+	}
+
+	// without a token, as after an API key
+	c, _ := gin.CreateTestContext(nil)
+	if _, err := middleware.JWTClaims(c, signer); err == nil {
+		t.Error("JWTClaims without a token succeeded")
+	}
+}
@@ -0,0 +1,126 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Subjecter is implemented by principals that can be identified in logs.
+type Subjecter interface {
+	Subject() string
+}
+
+type loggerConfig struct {
+	sample map[string]int
+	levels []routeLevel
+}
+
+// routeLevel is the level successful requests to the routes a pattern
+// matches log at.
+type routeLevel struct {
+	routePattern
+	level slog.Level
+}
+
+// LoggerOption customizes the logging middleware.
+type LoggerOption func(*loggerConfig)
+
+// WithSampling logs only one in every n successful responses for route (the
+// gin route template, e.g. "/books/:id"). Errors are always logged.
+func WithSampling(route string, n int) LoggerOption {
+	return func(cfg *loggerConfig) {
+		if n > 1 {
+			cfg.sample[route] = n
+		}
+	}
+}
+
+// WithRouteLevel logs successful responses of the routes pattern matches,
+// "[METHOD ]path" as LimitRoutes takes them, at level instead of info:
+// debug keeps a chatty route such as /healthz out of the log unless the
+// logger is at debug. 4xx and 5xx responses keep warn and error. The most
+// specific pattern a request matches applies.
+func WithRouteLevel(pattern string, level slog.Level) LoggerOption {
+	return func(cfg *loggerConfig) {
+		cfg.levels = append(cfg.levels, routeLevel{parseRoutePattern(pattern), level})
+		slices.SortStableFunc(cfg.levels, func(a, b routeLevel) int { return moreSpecific(a.routePattern, b.routePattern) })
+	}
+}
+
+// level is what a successful request to route logs at.
+func (cfg loggerConfig) level(method, route string) slog.Level {
+	for _, rl := range cfg.levels {
+		if rl.matches(method, route) {
+			return rl.level
+		}
+	}
+	return slog.LevelInfo
+}
+
+// Logger writes one structured line per request, replacing gin.Logger: the
+// method, path, route, status, latency, size, client IP and, once Auth has
+// run, the user. The request ID comes from the context, through the handler
+// logging.New builds. 5xx responses log at error level and 4xx at warn.
+func Logger(logger *slog.Logger, opts ...LoggerOption) gin.HandlerFunc {
+	cfg := loggerConfig{sample: map[string]int{}}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	var mu sync.Mutex
+	seen := map[string]int{}
+
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		route := c.FullPath()
+		if n, ok := cfg.sample[route]; ok && status < http.StatusMultipleChoices {
+			mu.Lock()
+			seen[route]++
+			skip := seen[route]%n != 1
+			mu.Unlock()
+			if skip {
+				return
+			}
+		}
+
+		level := cfg.level(c.Request.Method, route)
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+		ctx := c.Request.Context()
+		if !logger.Enabled(ctx, level) {
+			return
+		}
+
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.String("route", route),
+			slog.Int("status", status),
+			slog.Duration("latency", time.Since(start)),
+			slog.Int("bytes", max(c.Writer.Size(), 0)),
+			slog.String("client_ip", c.ClientIP()),
+		}
+		if v, ok := c.Get(UserKey); ok {
+			if s, ok := v.(Subjecter); ok {
+				attrs = append(attrs, slog.String("user_id", s.Subject()))
+			}
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.String("errors", c.Errors.String()))
+		}
+
+		logger.LogAttrs(ctx, level, "request", attrs...)
+	}
+}
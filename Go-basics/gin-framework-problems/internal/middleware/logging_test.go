@@ -0,0 +1,59 @@
+package middleware_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+)
+
+func TestLoggerRouteLevels(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo}))
+	r := engine(middleware.Logger(logger,
+		middleware.WithRouteLevel("/healthz", slog.LevelDebug),
+		middleware.WithRouteLevel("/books/*", slog.LevelDebug),
+		middleware.WithRouteLevel("POST /books/:id", slog.LevelWarn)))
+	r.GET("/healthz", func(c *gin.Context) {
+		if c.Query("fail") != "" {
+			c.Status(http.StatusServiceUnavailable)
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+	r.GET("/books/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.POST("/books/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/other", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	tests := []struct {
+		method, path string
+		want         string // the level logged, "" for none
+	}{
+		{http.MethodGet, "/healthz", ""},
+		// errors keep their levels
+		{http.MethodGet, "/healthz?fail=1", "ERROR"},
+		{http.MethodGet, "/books/1", ""},
+		// the pattern with the method is as long, so it wins
+		{http.MethodPost, "/books/1", "WARN"},
+		{http.MethodGet, "/other", "INFO"},
+	}
+	for _, tt := range tests {
+		buf.Reset()
+		serve(r, tt.method, tt.path, nil)
+		var line struct{ Level string }
+		if out := strings.TrimSpace(buf.String()); out != "" {
+			if err := json.Unmarshal([]byte(out), &line); err != nil {
+				t.Fatalf("%s %s: %v in %s", tt.method, tt.path, err, out)
+			}
+		}
+		if line.Level != tt.want {
+			t.Errorf("%s %s logged at %q, want %q", tt.method, tt.path, line.Level, tt.want)
+		}
+	}
+}
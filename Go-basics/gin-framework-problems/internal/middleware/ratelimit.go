@@ -0,0 +1,663 @@
+package middleware
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/metrics"
+)
+
+// Algorithm is how a RateLimiter counts a client's requests.
+type Algorithm string
+
+const (
+	// SlidingLog keeps the time of each request in the window, up to the
+	// limit, and is exact: no window ever holds more than the limit. It
+	// costs a timestamp per allowed request per client.
+	SlidingLog Algorithm = "sliding_log"
+	// TokenBucket gives each client a bucket of limit tokens that refills
+	// at limit per window. A request takes a token, so a client can burst
+	// the whole limit and then goes at the refill rate. It costs the same
+	// few words per client whatever the limit.
+	TokenBucket Algorithm = "token_bucket"
+	// SlidingWindowCounter counts requests in fixed windows and weighs the
+	// previous window by how much of it still overlaps the sliding one. It
+	// is an estimate that assumes the previous window's requests were
+	// spread evenly, for the same few words per client.
+	SlidingWindowCounter Algorithm = "sliding_window_counter"
+)
+
+// counter is one client's state under an algorithm.
+type counter interface {
+	// take records a request costing n at now if it fits and reports
+	// whether it did. n is between 1 and the limit, or the burst.
+	take(now time.Time, n int) bool
+	// remaining is how many more requests of cost 1 fit at now.
+	remaining(now time.Time) int
+	// retry is how long from now until a request costing n fits again, 0 if
+	// one does.
+	retry(now time.Time, n int) time.Duration
+	// reset is how long from now until the whole budget is back.
+	reset(now time.Time) time.Duration
+	// idle reports whether the client is back where a new one starts, so
+	// forgetting it changes nothing.
+	idle(now time.Time) bool
+}
+
+// ring is a fixed-size circular buffer of request timestamps for one client.
+// It never holds more than the limit, so it is allocated once per client and
+// pruning only touches the entries that actually fell out of the window.
+type ring struct {
+	times  []time.Time
+	start  int // index of the oldest timestamp
+	count  int
+	window time.Duration
+}
+
+func newRing(size int, window time.Duration) *ring {
+	return &ring{times: make([]time.Time, size), window: window}
+}
+
+// prune drops timestamps older than cutoff, oldest first.
+func (r *ring) prune(cutoff time.Time) {
+	for r.count > 0 && r.times[r.start].Before(cutoff) {
+		r.start = (r.start + 1) % len(r.times)
+		r.count--
+	}
+}
+
+// fits reports whether n more timestamps fit.
+func (r *ring) fits(n int) bool {
+	return r.count+n <= len(r.times)
+}
+
+func (r *ring) push(t time.Time) {
+	r.times[(r.start+r.count)%len(r.times)] = t
+	r.count++
+}
+
+// take records a request costing n as n timestamps.
+func (r *ring) take(now time.Time, n int) bool {
+	// prune older than window
+	r.prune(now.Add(-r.window))
+	if !r.fits(n) {
+		// exceeded
+		return false
+	}
+	// allow and record
+	for range n {
+		r.push(now)
+	}
+	return true
+}
+
+func (r *ring) remaining(now time.Time) int {
+	r.prune(now.Add(-r.window))
+	return len(r.times) - r.count
+}
+
+// retry waits for the oldest requests to leave the window, as many as it
+// takes to make room for n.
+func (r *ring) retry(now time.Time, n int) time.Duration {
+	r.prune(now.Add(-r.window))
+	if r.fits(n) {
+		return 0
+	}
+	last := (r.start + r.count + n - len(r.times) - 1) % len(r.times)
+	return r.times[last].Add(r.window).Sub(now)
+}
+
+// reset waits for the newest request to leave the window.
+func (r *ring) reset(now time.Time) time.Duration {
+	r.prune(now.Add(-r.window))
+	if r.count == 0 {
+		return 0
+	}
+	return r.times[(r.start+r.count-1)%len(r.times)].Add(r.window).Sub(now)
+}
+
+func (r *ring) idle(now time.Time) bool {
+	r.prune(now.Add(-r.window))
+	return r.count == 0
+}
+
+// bucket is a token bucket of size tokens that earns limit tokens per
+// window, refilled lazily from the time it was last touched rather than by a
+// timer.
+type bucket struct {
+	tokens float64
+	last   time.Time
+	limit  int
+	size   int
+	window time.Duration
+}
+
+func newBucket(limit, size int, window time.Duration, now time.Time) *bucket {
+	return &bucket{tokens: float64(size), last: now, limit: limit, size: size, window: window}
+}
+
+// refill adds the tokens earned since last, up to the size.
+func (b *bucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = min(float64(b.size), b.tokens+float64(b.limit)*elapsed.Seconds()/b.window.Seconds())
+		b.last = now
+	}
+}
+
+func (b *bucket) take(now time.Time, n int) bool {
+	b.refill(now)
+	if b.tokens < float64(n) {
+		return false
+	}
+	b.tokens -= float64(n)
+	return true
+}
+
+func (b *bucket) remaining(now time.Time) int {
+	b.refill(now)
+	return int(b.tokens)
+}
+
+// refillTime is how long the bucket takes to earn tokens.
+func (b *bucket) refillTime(tokens float64) time.Duration {
+	return time.Duration(tokens * float64(b.window) / float64(b.limit))
+}
+
+func (b *bucket) retry(now time.Time, n int) time.Duration {
+	b.refill(now)
+	if b.tokens >= float64(n) {
+		return 0
+	}
+	return b.refillTime(float64(n) - b.tokens)
+}
+
+func (b *bucket) reset(now time.Time) time.Duration {
+	b.refill(now)
+	return b.refillTime(float64(b.size) - b.tokens)
+}
+
+func (b *bucket) idle(now time.Time) bool {
+	b.refill(now)
+	return b.tokens == float64(b.size)
+}
+
+// windowCounter counts requests in the current fixed window, which starts at
+// a multiple of the window length, and keeps the previous window's count.
+type windowCounter struct {
+	start      time.Time
+	curr, prev int
+	limit      int
+	window     time.Duration
+}
+
+// roll moves the counts along when now is past the current window.
+func (w *windowCounter) roll(now time.Time) {
+	start := now.Truncate(w.window)
+	if !start.After(w.start) {
+		return
+	}
+	if start.Sub(w.start) == w.window {
+		w.prev = w.curr
+	} else {
+		w.prev = 0
+	}
+	w.curr, w.start = 0, start
+}
+
+// estimate is the requests in the window ending at now: all of the current
+// fixed window's and the share of the previous one's the sliding window
+// still covers.
+func (w *windowCounter) estimate(now time.Time) float64 {
+	w.roll(now)
+	overlap := 1 - float64(now.Sub(w.start))/float64(w.window)
+	return float64(w.prev)*overlap + float64(w.curr)
+}
+
+func (w *windowCounter) take(now time.Time, n int) bool {
+	if w.estimate(now)+float64(n) > float64(w.limit) {
+		return false
+	}
+	w.curr += n
+	return true
+}
+
+func (w *windowCounter) remaining(now time.Time) int {
+	return max(w.limit-int(math.Ceil(w.estimate(now))), 0)
+}
+
+// retry waits for the previous window's weight to drop enough, or, when the
+// current window alone is full, for it to become the previous one and drop
+// in turn.
+func (w *windowCounter) retry(now time.Time, n int) time.Duration {
+	w.roll(now)
+	elapsed := now.Sub(w.start)
+	room := float64(w.limit - n - w.curr)
+	if room < 0 {
+		return w.window - elapsed + time.Duration((1-float64(w.limit-n)/float64(w.curr))*float64(w.window))
+	}
+	if float64(w.prev)*(1-float64(elapsed)/float64(w.window)) <= room {
+		return 0
+	}
+	return time.Duration((1-room/float64(w.prev))*float64(w.window)) - elapsed
+}
+
+// reset waits for every counted request's window to be fully behind the
+// sliding one.
+func (w *windowCounter) reset(now time.Time) time.Duration {
+	w.roll(now)
+	switch {
+	case w.curr > 0:
+		return w.start.Add(2 * w.window).Sub(now)
+	case w.prev > 0:
+		return w.start.Add(w.window).Sub(now)
+	}
+	return 0
+}
+
+func (w *windowCounter) idle(now time.Time) bool {
+	w.roll(now)
+	return w.curr == 0 && w.prev == 0
+}
+
+// KeyFunc is what the middleware counts a request against: clients with
+// the same key share a budget.
+type KeyFunc func(c *gin.Context) string
+
+// ByIP keys by client IP, the default.
+func ByIP(c *gin.Context) string { return c.ClientIP() }
+
+// ByUser keys by the principal Auth stored, through its Subject, and by
+// client IP for anonymous requests. Mount the limiter after Auth.
+func ByUser(c *gin.Context) string {
+	if u, ok := c.Get(UserKey); ok {
+		if s, ok := u.(Subjecter); ok && s.Subject() != "" {
+			return "user:" + s.Subject()
+		}
+	}
+	return ByIP(c)
+}
+
+// ByAPIKey keys by the ID of the API key Auth accepted, and by client IP for
+// requests without one. Mount the limiter after Auth: the key a client sends
+// isn't trusted before it has been looked up.
+func ByAPIKey(c *gin.Context) string {
+	if id := c.GetString(APIKeyIDKey); id != "" {
+		return "key:" + id
+	}
+	return ByIP(c)
+}
+
+// ByRouteAndIP keys by route template and client IP, so each route of a
+// group gets a budget of its own per client.
+func ByRouteAndIP(c *gin.Context) string {
+	return c.FullPath() + " " + c.ClientIP()
+}
+
+// RateLimiter allows limit requests per client in a sliding window of a
+// minute, counted with SlidingLog and keyed ByIP unless its options say
+// otherwise. Limits that differ by route group are limiters of their own,
+// each mounted on its group or bound to its routes with LimitRoutes.
+type RateLimiter struct {
+	name       string
+	limit      int
+	burst      int
+	algorithm  Algorithm
+	key        KeyFunc
+	ips        IPFilter
+	clock      Clock
+	costs      []routeCost // most specific first
+	onExceeded func(*gin.Context, LimitExceeded)
+	mu         sync.Mutex
+	clients    map[string]counter
+	window     time.Duration
+
+	// what it decided, in total and by key, for Stats
+	allowed, denied int64
+	stats           map[string]*KeyStats
+
+	// the janitor, once StartJanitor runs it
+	stop chan struct{}
+	done chan struct{}
+}
+
+type RateLimiterOption func(*RateLimiter)
+
+// WithAlgorithm counts requests with a instead of SlidingLog. The empty
+// Algorithm is SlidingLog.
+func WithAlgorithm(a Algorithm) RateLimiterOption {
+	return func(rl *RateLimiter) {
+		if a != "" {
+			rl.algorithm = a
+		}
+	}
+}
+
+// WithKey counts requests against key(c) instead of the client IP. A nil
+// key keeps ByIP.
+func WithKey(key KeyFunc) RateLimiterOption {
+	return func(rl *RateLimiter) {
+		if key != nil {
+			rl.key = key
+		}
+	}
+}
+
+// WithWindow counts limit requests per d instead of per minute. A d of
+// zero keeps the minute.
+func WithWindow(d time.Duration) RateLimiterOption {
+	return func(rl *RateLimiter) {
+		if d > 0 {
+			rl.window = d
+		}
+	}
+}
+
+// WithBurst lets a TokenBucket client spend n requests at once, rather than
+// limit, while it still earns limit per window. Other algorithms ignore it.
+func WithBurst(n int) RateLimiterOption {
+	return func(rl *RateLimiter) { rl.burst = n }
+}
+
+// Clock tells a RateLimiter the time, so tests can move it on rather than
+// sleep through windows.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+// WithClock reads the time from clock instead of time.Now. The janitor
+// still ticks in real time, but cleans up as of clock.Now.
+func WithClock(clock Clock) RateLimiterOption {
+	return func(rl *RateLimiter) { rl.clock = clock }
+}
+
+// IPFilter says which client IPs a RateLimiter lets through uncounted and
+// which it refuses outright.
+type IPFilter interface {
+	Check(ip string) (allowed, denied bool)
+}
+
+// WithIPFilter has the middleware consult f before counting a request: a
+// denied client IP gets a 403, and an allowed one goes through without
+// being counted or seeing rate limit headers. Denied wins over allowed.
+func WithIPFilter(f IPFilter) RateLimiterOption {
+	return func(rl *RateLimiter) { rl.ips = f }
+}
+
+func NewRateLimiter(limit int, opts ...RateLimiterOption) *RateLimiter {
+	rl := &RateLimiter{
+		limit:     limit,
+		algorithm: SlidingLog,
+		key:       ByIP,
+		clock:     systemClock{},
+		clients:   make(map[string]counter),
+		stats:     make(map[string]*KeyStats),
+		window:    time.Minute,
+	}
+	for _, opt := range opts {
+		opt(rl)
+	}
+	switch rl.algorithm {
+	case SlidingLog, TokenBucket, SlidingWindowCounter:
+	default:
+		panic("middleware: unknown rate limit algorithm " + strconv.Quote(string(rl.algorithm)))
+	}
+	if rl.name != "" {
+		namedLimiters.Lock()
+		namedLimiters.byName[rl.name] = rl
+		namedLimiters.Unlock()
+	}
+	return rl
+}
+
+// newCounter is the state of a client first seen at now.
+func (rl *RateLimiter) newCounter(now time.Time) counter {
+	switch rl.algorithm {
+	case TokenBucket:
+		return newBucket(rl.limit, rl.capacity(), rl.window, now)
+	case SlidingWindowCounter:
+		return &windowCounter{start: now.Truncate(rl.window), limit: rl.limit, window: rl.window}
+	default:
+		return newRing(rl.limit, rl.window)
+	}
+}
+
+// capacity is how many requests a new client may make at once.
+func (rl *RateLimiter) capacity() int {
+	if rl.algorithm == TokenBucket && rl.burst > 0 {
+		return rl.burst
+	}
+	return rl.limit
+}
+
+// Allow records a request for key at time now and reports whether it fits
+// in the window. The middleware is a thin wrapper around it, so the limiting
+// logic can be exercised directly without going through HTTP.
+func (rl *RateLimiter) Allow(key string, now time.Time) bool {
+	return rl.AllowN(key, now, 1)
+}
+
+// AllowN is Allow for a request that costs n units of the budget. A cost
+// over what a client may spend at once is taken as that much, so the
+// request can still fit in a full budget.
+func (rl *RateLimiter) AllowN(key string, now time.Time, n int) bool {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	allowed := rl.client(key, now).take(now, rl.clamp(n))
+	rl.record(key, allowed)
+	return allowed
+}
+
+// clamp is cost n between 1 and the capacity.
+func (rl *RateLimiter) clamp(n int) int {
+	return min(max(n, 1), rl.capacity())
+}
+
+// client is key's state, new if key hasn't been seen. rl.mu must be held.
+func (rl *RateLimiter) client(key string, now time.Time) counter {
+	r, ok := rl.clients[key]
+	if !ok {
+		r = rl.newCounter(now)
+		rl.clients[key] = r
+	}
+	return r
+}
+
+// Remaining reports how many more requests key may make in the window
+// ending at now, without recording one.
+func (rl *RateLimiter) Remaining(key string, now time.Time) int {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	r, ok := rl.clients[key]
+	if !ok {
+		return rl.capacity()
+	}
+	return r.remaining(now)
+}
+
+// RetryAfter reports how long from now until key may make a request again,
+// 0 if it may now.
+func (rl *RateLimiter) RetryAfter(key string, now time.Time) time.Duration {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	if r, ok := rl.clients[key]; ok {
+		return r.retry(now, 1)
+	}
+	return 0
+}
+
+// Reset reports how long from now until key has its whole budget again.
+func (rl *RateLimiter) Reset(key string, now time.Time) time.Duration {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	if r, ok := rl.clients[key]; ok {
+		return r.reset(now)
+	}
+	return 0
+}
+
+// Limit is the number of requests allowed per window.
+func (rl *RateLimiter) Limit() int { return rl.limit }
+
+// Window is the length of the sliding window, or for TokenBucket how long it
+// takes to earn limit tokens.
+func (rl *RateLimiter) Window() time.Duration { return rl.window }
+
+// Algorithm is how requests are counted.
+func (rl *RateLimiter) Algorithm() Algorithm { return rl.algorithm }
+
+// Key is what the middleware counts c against.
+func (rl *RateLimiter) Key(c *gin.Context) string { return rl.key(c) }
+
+// Now is the time by the limiter's clock, the one to pass to Remaining and
+// the like.
+func (rl *RateLimiter) Now() time.Time { return rl.clock.Now() }
+
+// Cleanup forgets clients that are back to a full budget at now, and their
+// stats, and returns how many were dropped. Without it the map keeps one
+// entry per IP ever seen.
+func (rl *RateLimiter) Cleanup(now time.Time) int {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	n := 0
+	for key, r := range rl.clients {
+		if r.idle(now) {
+			delete(rl.clients, key)
+			delete(rl.stats, key)
+			n++
+		}
+	}
+	return n
+}
+
+// Clients is how many clients the limiter tracks, idle ones Cleanup would
+// drop included.
+func (rl *RateLimiter) Clients() int {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	return len(rl.clients)
+}
+
+// StartJanitor runs Cleanup every interval in a goroutine until Stop, so the
+// limiter forgets clients without a scheduler task doing it. Calling it
+// again is a no-op.
+func (rl *RateLimiter) StartJanitor(every time.Duration) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	if rl.stop != nil {
+		return
+	}
+	rl.stop, rl.done = make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(rl.done)
+		ticker := time.NewTicker(every)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-rl.stop:
+				return
+			case <-ticker.C:
+				rl.Cleanup(rl.clock.Now())
+			}
+		}
+	}()
+}
+
+// Stop stops the janitor and waits for it until ctx expires. It fits
+// server.Hooks, and does nothing for a limiter without a janitor.
+func (rl *RateLimiter) Stop(ctx context.Context) error {
+	rl.mu.Lock()
+	stop, done := rl.stop, rl.done
+	if stop != nil {
+		select {
+		case <-stop:
+		default:
+			close(stop)
+		}
+	}
+	rl.mu.Unlock()
+	if done == nil {
+		return nil
+	}
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Middleware limits by the limiter's key, the client IP by default, and
+// reports the budget on every response in the X-RateLimit-Limit,
+// X-RateLimit-Remaining and X-RateLimit-Reset headers, the last in seconds
+// until the whole budget is back.
+func (rl *RateLimiter) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if rl.allow(c, rl.key(c)) {
+			c.Next()
+		}
+	}
+}
+
+// allow counts the request's cost against key's budget, sets the rate limit
+// headers and, once the budget is spent, aborts with a 429 whose Retry-After
+// says when the request would fit, and tells OnLimitExceeded. The IP filter
+// comes first.
+func (rl *RateLimiter) allow(c *gin.Context, key string) bool {
+	if decided, ok := rl.screen(c); decided {
+		return ok
+	}
+	now, cost := rl.clock.Now(), rl.clamp(rl.cost(c))
+	rl.mu.Lock()
+	r := rl.client(key, now)
+	allowed := r.take(now, cost)
+	rl.record(key, allowed)
+	remaining, reset, retry := r.remaining(now), r.reset(now), r.retry(now, cost)
+	rl.mu.Unlock()
+
+	c.Header("X-RateLimit-Limit", strconv.Itoa(rl.limit))
+	c.Header("X-RateLimit-Remaining", strconv.Itoa(remaining))
+	c.Header("X-RateLimit-Reset", seconds(reset))
+	if !allowed {
+		c.Header("Retry-After", seconds(max(retry, time.Second)))
+		AbortError(c, http.StatusTooManyRequests, "rate limit exceeded")
+		metrics.RateLimited(rl.name)
+		if rl.onExceeded != nil {
+			rl.onExceeded(c, LimitExceeded{Limiter: rl.name, Key: key, Cost: cost, RetryAfter: retry})
+		}
+	}
+	return allowed
+}
+
+// screen decides a request by the IP filter alone, aborting with a 403 for
+// a denied client and letting an allowed one through. decided is false for
+// the rest, which are counted.
+func (rl *RateLimiter) screen(c *gin.Context) (decided, ok bool) {
+	if rl.ips == nil {
+		return false, false
+	}
+	switch allowed, denied := rl.ips.Check(c.ClientIP()); {
+	case denied:
+		AbortError(c, http.StatusForbidden, "your address is blocked")
+		return true, false
+	case allowed:
+		return true, true
+	}
+	return false, false
+}
+
+// seconds is d in whole seconds, rounded up so a client that waits that
+// long isn't early.
+func seconds(d time.Duration) string {
+	return strconv.FormatInt(int64((d+time.Second-1)/time.Second), 10)
+}
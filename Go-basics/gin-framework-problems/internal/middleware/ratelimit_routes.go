@@ -0,0 +1,165 @@
+package middleware
+
+import (
+	"cmp"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// routePattern is a parsed "[METHOD ]path" pattern: path is a route
+// template such as /books/:id, or a prefix of them ending in "*", such as
+// /files/*. Without a method it matches them all. A path without an API
+// version covers every version: /api/login matches /api/v2/login too.
+type routePattern struct {
+	method, path string
+	prefix       bool
+}
+
+func parseRoutePattern(pattern string) routePattern {
+	p := routePattern{path: pattern}
+	if method, path, ok := strings.Cut(pattern, " "); ok {
+		p.method, p.path = strings.ToUpper(method), strings.TrimSpace(path)
+	}
+	p.path, p.prefix = strings.CutSuffix(p.path, "*")
+	return p
+}
+
+func (p routePattern) matches(method, route string) bool {
+	if p.method != "" && p.method != method {
+		return false
+	}
+	if p.matchesPath(route) {
+		return true
+	}
+	bare := versionSegment.ReplaceAllString(route, "$1")
+	return bare != route && p.matchesPath(bare)
+}
+
+func (p routePattern) matchesPath(route string) bool {
+	return route == p.path || p.prefix && strings.HasPrefix(route, p.path)
+}
+
+// versionSegment is the version of a route under an apiversion group, such
+// as the /v2 of /api/v2/login.
+var versionSegment = regexp.MustCompile(`^(/api)/v[0-9]+\b`)
+
+// moreSpecific orders patterns most specific first: the longest path, then
+// one with a method.
+func moreSpecific(a, b routePattern) int {
+	if c := cmp.Compare(len(b.path), len(a.path)); c != 0 {
+		return c
+	}
+	return cmp.Compare(len(b.method), len(a.method))
+}
+
+// routeCost is what requests to the routes a pattern matches cost.
+type routeCost struct {
+	routePattern
+	cost int
+}
+
+// WithCosts has requests to some routes cost more of the budget than 1,
+// by "[METHOD ]path" pattern as RouteLimit takes them, such as
+// {"GET /search": 5}, so the limit is in units rather than requests. The
+// most specific pattern a request matches applies.
+func WithCosts(costs map[string]int) RateLimiterOption {
+	return func(rl *RateLimiter) {
+		rl.costs = rl.costs[:0]
+		for pattern, cost := range costs {
+			rl.costs = append(rl.costs, routeCost{parseRoutePattern(pattern), cost})
+		}
+		slices.SortStableFunc(rl.costs, func(a, b routeCost) int { return moreSpecific(a.routePattern, b.routePattern) })
+	}
+}
+
+// cost is what c costs: what WithCosts gives its route, 1 otherwise.
+func (rl *RateLimiter) cost(c *gin.Context) int {
+	route := c.FullPath()
+	for _, rc := range rl.costs {
+		if rc.matches(c.Request.Method, route) {
+			return rc.cost
+		}
+	}
+	return 1
+}
+
+// pendingLimitKey is the context key of the limiter a route's policy left
+// for Auth, because it counts by the principal.
+const pendingLimitKey = "ratelimit.pending"
+
+// RouteLimit binds a limiter to the routes Pattern matches: "[METHOD ]path",
+// where path is a route template such as /books/:id, or a prefix of them
+// ending in "*", such as /files/*. Without a method it matches them all.
+// The limiter's costs apply as usual.
+type RouteLimit struct {
+	Pattern string
+	Limiter *RateLimiter
+	// AfterAuth is set when Limiter keys by what Auth stores, ByUser or
+	// ByAPIKey: requests with credentials are then counted by Auth, once it
+	// has accepted them.
+	AfterAuth bool
+
+	routePattern
+}
+
+// LimitRoutes limits each request with the limit whose pattern matches its
+// method and route template most closely: the longest path, then one with a
+// method. Requests no pattern matches, and ones for no route, aren't limited.
+//
+// An AfterAuth limit has Auth count requests that carry an Authorization or
+// X-API-Key header, against the principal. A route without Auth counts them
+// once they are done, against the IP, and so does a failed login.
+func LimitRoutes(limits []RouteLimit) gin.HandlerFunc {
+	limits = slices.Clone(limits)
+	for i := range limits {
+		limits[i].routePattern = parseRoutePattern(limits[i].Pattern)
+	}
+	slices.SortStableFunc(limits, func(a, b RouteLimit) int { return moreSpecific(a.routePattern, b.routePattern) })
+
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if route == "" {
+			c.Next()
+			return
+		}
+		i := slices.IndexFunc(limits, func(l RouteLimit) bool { return l.matches(c.Request.Method, route) })
+		if i < 0 {
+			c.Next()
+			return
+		}
+		rl := limits[i].Limiter
+		if !limits[i].AfterAuth || c.GetHeader("Authorization") == "" && c.GetHeader("X-API-Key") == "" {
+			if rl.allow(c, rl.key(c)) {
+				c.Next()
+			}
+			return
+		}
+		if decided, ok := rl.screen(c); decided {
+			if ok {
+				c.Next()
+			}
+			return
+		}
+		c.Set(pendingLimitKey, rl)
+		c.Next()
+		if pending, _ := c.Get(pendingLimitKey); pending != nil {
+			rl.AllowN(ByIP(c), rl.Now(), rl.cost(c))
+		}
+	}
+}
+
+// limitAuthenticated counts the request against the limit LimitRoutes left
+// for Auth, now that the principal is known, and reports whether it may go
+// on.
+func limitAuthenticated(c *gin.Context) bool {
+	v, ok := c.Get(pendingLimitKey)
+	rl, _ := v.(*RateLimiter)
+	if !ok || rl == nil {
+		return true
+	}
+	c.Set(pendingLimitKey, nil)
+	return rl.allow(c, rl.key(c))
+}
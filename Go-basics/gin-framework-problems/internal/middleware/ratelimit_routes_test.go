@@ -0,0 +1,150 @@
+package middleware_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+)
+
+func TestLimitRoutes(t *testing.T) {
+	limits := []middleware.RouteLimit{
+		{Pattern: "/files/*", Limiter: middleware.NewRateLimiter(3)},
+		{Pattern: "POST /files/:id", Limiter: middleware.NewRateLimiter(1)},
+	}
+	router := engine(middleware.LimitRoutes(limits))
+	ok := func(c *gin.Context) { c.Status(http.StatusNoContent) }
+	router.GET("/files/:id", ok)
+	router.POST("/files/:id", ok)
+	router.GET("/other", ok)
+
+	tests := []struct {
+		method, path string
+		want         int
+	}{
+		// the POST pattern is as long and has a method, so it wins
+		{http.MethodPost, "/files/a", 204},
+		{http.MethodPost, "/files/b", 429},
+		// GETs fall to the prefix, with a budget of their own
+		{http.MethodGet, "/files/a", 204},
+		{http.MethodGet, "/files/b", 204},
+		{http.MethodGet, "/files/c", 204},
+		{http.MethodGet, "/files/a", 429},
+		{http.MethodGet, "/other", 204},
+		{http.MethodGet, "/missing", 404},
+	}
+	for i, tt := range tests {
+		w := serve(router, tt.method, tt.path, nil)
+		if w.Code != tt.want {
+			t.Errorf("request %d, %s %s: status = %d, want %d", i, tt.method, tt.path, w.Code, tt.want)
+		}
+		if tt.path == "/other" && w.Header().Get("X-RateLimit-Limit") != "" {
+			t.Error("rate limit headers on a route no pattern matches")
+		}
+	}
+}
+
+func TestLimitRoutesVersions(t *testing.T) {
+	limits := []middleware.RouteLimit{{Pattern: "POST /api/login", Limiter: middleware.NewRateLimiter(1)}}
+	router := engine(middleware.LimitRoutes(limits))
+	ok := func(c *gin.Context) { c.Status(http.StatusNoContent) }
+	router.POST("/api/login", ok)
+	router.POST("/api/v2/login", ok)
+	router.POST("/api/v2login", ok)
+
+	tests := []struct {
+		path string
+		want int
+	}{
+		{"/api/v2/login", 204},
+		// one budget for every version
+		{"/api/login", 429},
+		{"/api/v2login", 204},
+		{"/api/v2login", 204},
+	}
+	for i, tt := range tests {
+		if w := serve(router, http.MethodPost, tt.path, nil); w.Code != tt.want {
+			t.Errorf("request %d, POST %s: status = %d, want %d", i, tt.path, w.Code, tt.want)
+		}
+	}
+}
+
+func TestLimitRoutesAfterAuth(t *testing.T) {
+	byUser := middleware.NewRateLimiter(1, middleware.WithKey(middleware.ByUser))
+	router := engine(middleware.LimitRoutes([]middleware.RouteLimit{{Pattern: "/me", Limiter: byUser, AfterAuth: true}}))
+	router.GET("/me", middleware.Auth(func(token string) (any, error) {
+		if token == "nope" {
+			return nil, errors.New("invalid or expired token")
+		}
+		return subject(token), nil
+	}), func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	tests := []struct {
+		token string
+		want  int
+	}{
+		{"alice", 204},
+		{"bob", 204}, // a budget per user, not per IP
+		{"alice", 429},
+		// a failed login is counted against the IP afterwards
+		{"nope", 401},
+		{"", 429},
+	}
+	for i, tt := range tests {
+		headers := map[string]string{}
+		if tt.token != "" {
+			headers["Authorization"] = "Bearer " + tt.token
+		}
+		if w := serve(router, http.MethodGet, "/me", headers); w.Code != tt.want {
+			t.Errorf("request %d as %q: status = %d, want %d", i, tt.token, w.Code, tt.want)
+		}
+	}
+}
+
+func TestRateLimiterCosts(t *testing.T) {
+	for _, alg := range algorithms {
+		t.Run(string(alg), func(t *testing.T) {
+			rl := middleware.NewRateLimiter(10, middleware.WithAlgorithm(alg),
+				middleware.WithCosts(map[string]int{"GET /search": 4}))
+			router := engine(rl.Middleware())
+			ok := func(c *gin.Context) { c.Status(http.StatusNoContent) }
+			router.GET("/search", ok)
+			router.POST("/search", ok)
+
+			tests := []struct {
+				method, path string
+				want         int
+				remaining    string
+			}{
+				{http.MethodGet, "/search", 204, "6"},
+				{http.MethodPost, "/search", 204, "5"}, // the pattern names GET
+				{http.MethodGet, "/search", 204, "1"},
+				{http.MethodGet, "/search", 429, "1"},
+				{http.MethodPost, "/search", 204, "0"},
+			}
+			for i, tt := range tests {
+				w := serve(router, tt.method, tt.path, nil)
+				if w.Code != tt.want || w.Header().Get("X-RateLimit-Remaining") != tt.remaining {
+					t.Errorf("request %d, %s %s: status = %d, remaining %s, want %d, %s", i, tt.method, tt.path,
+						w.Code, w.Header().Get("X-RateLimit-Remaining"), tt.want, tt.remaining)
+				}
+			}
+
+			// a cost over the budget is capped at it, so a full budget lets one in
+			big := engine(middleware.NewRateLimiter(10, middleware.WithAlgorithm(alg),
+				middleware.WithCosts(map[string]int{"/big": 50})).Middleware())
+			big.GET("/big", ok)
+			for i, want := range []int{204, 429} {
+				if w := serve(big, http.MethodGet, "/big", nil); w.Code != want {
+					t.Errorf("/big request %d: status = %d, want %d", i, w.Code, want)
+				}
+			}
+			if !rl.AllowN("n", start, 7) || rl.AllowN("n", start, 4) || !rl.AllowN("n", start, 3) {
+				t.Error("AllowN did not count costs against the budget")
+			}
+		})
+	}
+}
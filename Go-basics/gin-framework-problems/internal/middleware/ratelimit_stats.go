@@ -0,0 +1,133 @@
+package middleware
+
+import (
+	"cmp"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// topOffenders is how many keys RateLimitStats lists, most denied first.
+const topOffenders = 10
+
+// KeyStats is what a limiter decided for one key since it started tracking
+// it, in requests rather than units.
+type KeyStats struct {
+	Key     string `json:"key"`
+	Allowed int64  `json:"allowed"`
+	Denied  int64  `json:"denied"`
+}
+
+// RateLimitStats is a snapshot of a limiter: its settings, what it decided
+// since it was made, how many clients it tracks and the keys denied most.
+// Keys are forgotten with their clients, so TopOffenders covers the clients
+// Cleanup hasn't dropped.
+type RateLimitStats struct {
+	Name          string     `json:"name,omitempty"`
+	Algorithm     Algorithm  `json:"algorithm"`
+	Limit         int        `json:"limit"`
+	WindowSeconds int        `json:"window_seconds"`
+	Clients       int        `json:"clients"`
+	Allowed       int64      `json:"allowed"`
+	Denied        int64      `json:"denied"`
+	TopOffenders  []KeyStats `json:"top_offenders"`
+}
+
+// LimitExceeded is what a limiter tells its OnLimitExceeded callback about
+// a request it refused.
+type LimitExceeded struct {
+	Limiter    string // its name, if it has one
+	Key        string
+	Cost       int
+	RetryAfter time.Duration
+}
+
+// WithName names the limiter in its stats and lists it on the handler of
+// RateLimitStatsHandler. A limiter without a name isn't listed.
+func WithName(name string) RateLimiterOption {
+	return func(rl *RateLimiter) { rl.name = name }
+}
+
+// WithOnLimitExceeded has the middleware call fn for each request it
+// refuses with a 429, after the response is written and on the request's
+// goroutine, so fn should be quick: log, count or hand off to an alerter.
+func WithOnLimitExceeded(fn func(c *gin.Context, e LimitExceeded)) RateLimiterOption {
+	return func(rl *RateLimiter) { rl.onExceeded = fn }
+}
+
+// record counts a decision for key. rl.mu must be held.
+func (rl *RateLimiter) record(key string, allowed bool) {
+	s := rl.stats[key]
+	if s == nil {
+		s = &KeyStats{Key: key}
+		rl.stats[key] = s
+	}
+	if allowed {
+		rl.allowed++
+		s.Allowed++
+	} else {
+		rl.denied++
+		s.Denied++
+	}
+}
+
+// Stats is a snapshot of the limiter.
+func (rl *RateLimiter) Stats() RateLimitStats {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	s := RateLimitStats{
+		Name:          rl.name,
+		Algorithm:     rl.algorithm,
+		Limit:         rl.limit,
+		WindowSeconds: int(rl.window.Seconds()),
+		Clients:       len(rl.clients),
+		Allowed:       rl.allowed,
+		Denied:        rl.denied,
+		TopOffenders:  []KeyStats{},
+	}
+	for _, k := range rl.stats {
+		if k.Denied > 0 {
+			s.TopOffenders = append(s.TopOffenders, *k)
+		}
+	}
+	slices.SortFunc(s.TopOffenders, func(a, b KeyStats) int {
+		if c := cmp.Compare(b.Denied, a.Denied); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Key, b.Key)
+	})
+	if len(s.TopOffenders) > topOffenders {
+		s.TopOffenders = s.TopOffenders[:topOffenders]
+	}
+	return s
+}
+
+// namedLimiters are the limiters made WithName, by name. A later one of the
+// same name replaces the earlier.
+var namedLimiters = struct {
+	sync.Mutex
+	byName map[string]*RateLimiter
+}{byName: map[string]*RateLimiter{}}
+
+// RateLimitStatsHandler answers with the stats of every named limiter, by
+// name. They show who is being limited, so mount it behind authentication.
+func RateLimitStatsHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		namedLimiters.Lock()
+		limiters := make([]*RateLimiter, 0, len(namedLimiters.byName))
+		for _, rl := range namedLimiters.byName {
+			limiters = append(limiters, rl)
+		}
+		namedLimiters.Unlock()
+
+		stats := make([]RateLimitStats, 0, len(limiters))
+		for _, rl := range limiters {
+			stats = append(stats, rl.Stats())
+		}
+		slices.SortFunc(stats, func(a, b RateLimitStats) int { return cmp.Compare(a.Name, b.Name) })
+		c.JSON(http.StatusOK, gin.H{"limiters": stats})
+	}
+}
@@ -0,0 +1,73 @@
+package middleware_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+)
+
+func TestRateLimiterStats(t *testing.T) {
+	rl := middleware.NewRateLimiter(2, middleware.WithName("test:stats"))
+	allowAll(rl, "a", start, 5)
+	allowAll(rl, "b", start, 3)
+	allowAll(rl, "c", start, 1)
+
+	s := rl.Stats()
+	if s.Name != "test:stats" || s.Limit != 2 || s.Clients != 3 || s.Allowed != 5 || s.Denied != 4 {
+		t.Errorf("Stats = %+v, want 3 clients, 5 allowed and 4 denied", s)
+	}
+	want := []middleware.KeyStats{{Key: "a", Allowed: 2, Denied: 3}, {Key: "b", Allowed: 2, Denied: 1}}
+	if len(s.TopOffenders) != len(want) || s.TopOffenders[0] != want[0] || s.TopOffenders[1] != want[1] {
+		t.Errorf("TopOffenders = %+v, want %+v", s.TopOffenders, want)
+	}
+
+	// offenders are forgotten with their clients, totals are not
+	rl.Cleanup(start.Add(2 * rl.Window()))
+	if s := rl.Stats(); s.Clients != 0 || len(s.TopOffenders) != 0 || s.Denied != 4 {
+		t.Errorf("Stats after Cleanup = %+v", s)
+	}
+
+	router := engine()
+	router.GET("/debug/ratelimit", middleware.RateLimitStatsHandler())
+	w := serve(router, http.MethodGet, "/debug/ratelimit", nil)
+	var got struct {
+		Limiters []middleware.RateLimitStats `json:"limiters"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, s := range got.Limiters {
+		found = found || s.Name == "test:stats" && s.Allowed == 5
+	}
+	if !found {
+		t.Errorf("%s lists no test:stats limiter", w.Body)
+	}
+}
+
+func TestRateLimiterOnLimitExceeded(t *testing.T) {
+	var got []middleware.LimitExceeded
+	rl := middleware.NewRateLimiter(1, middleware.WithName("test:exceeded"),
+		middleware.WithOnLimitExceeded(func(c *gin.Context, e middleware.LimitExceeded) {
+			if c.Writer.Status() != http.StatusTooManyRequests {
+				t.Errorf("callback before the 429: status %d", c.Writer.Status())
+			}
+			got = append(got, e)
+		}))
+	router := engine(rl.Middleware())
+	router.GET("/", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	for range 3 {
+		serve(router, http.MethodGet, "/", nil)
+	}
+	if len(got) != 2 {
+		t.Fatalf("callback ran %d times, want 2", len(got))
+	}
+	if e := got[0]; e.Limiter != "test:exceeded" || e.Key == "" || e.Cost != 1 || e.RetryAfter <= 0 {
+		t.Errorf("LimitExceeded = %+v", e)
+	}
+}
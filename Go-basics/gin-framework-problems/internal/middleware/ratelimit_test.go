@@ -0,0 +1,641 @@
+package middleware_test
+
+import (
+	"cmp"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/testutil"
+)
+
+var start = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+var algorithms = []middleware.Algorithm{middleware.SlidingLog, middleware.TokenBucket, middleware.SlidingWindowCounter}
+
+// allowAll sends n requests for key at now and returns how many were allowed.
+func allowAll(rl *middleware.RateLimiter, key string, now time.Time, n int) int {
+	allowed := 0
+	for range n {
+		if rl.Allow(key, now) {
+			allowed++
+		}
+	}
+	return allowed
+}
+
+func TestRateLimiterConcurrentOneKey(t *testing.T) {
+	rl := middleware.NewRateLimiter(100)
+	var allowed atomic.Int64
+	var wg sync.WaitGroup
+	for range 500 {
+		wg.Go(func() {
+			if rl.Allow("10.0.0.1", start) {
+				allowed.Add(1)
+			}
+		})
+	}
+	wg.Wait()
+	if got := allowed.Load(); got != 100 {
+		t.Errorf("allowed %d of 500, want 100", got)
+	}
+}
+
+func TestRateLimiterConcurrentKeys(t *testing.T) {
+	const keys, workers, each, limit = 50, 20, 10, 50
+	rl := middleware.NewRateLimiter(limit)
+	allowed := make([]atomic.Int64, keys)
+	var wg sync.WaitGroup
+	for k := range keys {
+		key := "10.0.0." + strconv.Itoa(k)
+		for range workers {
+			wg.Go(func() {
+				allowed[k].Add(int64(allowAll(rl, key, start, each)))
+			})
+		}
+	}
+	wg.Wait()
+	for k := range keys {
+		if got := allowed[k].Load(); got != limit {
+			t.Errorf("key %d: allowed %d of %d, want %d", k, got, workers*each, limit)
+		}
+	}
+}
+
+func TestRateLimiterMiddlewareConcurrent(t *testing.T) {
+	router := engine(middleware.NewRateLimiter(100).Middleware())
+	router.GET("/", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	var mu sync.Mutex
+	codes := map[int]int{}
+	var wg sync.WaitGroup
+	for range 300 {
+		wg.Go(func() {
+			w := serve(router, http.MethodGet, "/", nil)
+			mu.Lock()
+			codes[w.Code]++
+			mu.Unlock()
+		})
+	}
+	wg.Wait()
+	if codes[http.StatusNoContent] != 100 || codes[http.StatusTooManyRequests] != 200 || len(codes) != 2 {
+		t.Errorf("statuses = %v, want 100 204s and 200 429s", codes)
+	}
+}
+
+func TestRateLimiterWindowBoundary(t *testing.T) {
+	tests := []struct {
+		at          time.Duration // after start
+		tries, want int
+	}{
+		{0, 5, 3},
+		{time.Minute - time.Nanosecond, 1, 0},
+		// a request exactly a window old still counts
+		{time.Minute, 1, 0},
+		{time.Minute + time.Nanosecond, 5, 3},
+	}
+	rl := middleware.NewRateLimiter(3)
+	for _, tt := range tests {
+		if got := allowAll(rl, "k", start.Add(tt.at), tt.tries); got != tt.want {
+			t.Errorf("at +%s: allowed %d of %d, want %d", tt.at, got, tt.tries, tt.want)
+		}
+	}
+}
+
+func TestRateLimiterPruning(t *testing.T) {
+	rl := middleware.NewRateLimiter(3)
+	// spread out, so they leave the window one at a time
+	for i := range 3 {
+		if !rl.Allow("k", start.Add(time.Duration(i)*10*time.Second)) {
+			t.Fatalf("request %d denied", i)
+		}
+	}
+	tests := []struct {
+		at   time.Duration
+		want bool
+	}{
+		{time.Minute, false},
+		{time.Minute + time.Nanosecond, true}, // the first has left
+		{time.Minute + 5*time.Second, false},
+		{time.Minute + 10*time.Second + time.Nanosecond, true}, // and the second
+		{time.Minute + 20*time.Second + time.Nanosecond, true}, // and the third
+		{time.Minute + 20*time.Second + time.Nanosecond, false},
+	}
+	for _, tt := range tests {
+		if got := rl.Allow("k", start.Add(tt.at)); got != tt.want {
+			t.Errorf("at +%s: Allow = %v, want %v", tt.at, got, tt.want)
+		}
+	}
+	// other clients keep their own budgets
+	if got := allowAll(rl, "other", start.Add(time.Minute+5*time.Second), 4); got != 3 {
+		t.Errorf("another client: allowed %d of 4, want 3", got)
+	}
+}
+
+func TestRateLimiterBurst(t *testing.T) {
+	type step struct {
+		at          time.Duration // after start
+		tries, want int
+	}
+	tests := []struct {
+		name  string
+		opts  []middleware.RateLimiterOption
+		limit int
+		steps []step
+	}{
+		// earns a token a second, but holds only ten
+		{"spike", []middleware.RateLimiterOption{middleware.WithAlgorithm(middleware.TokenBucket), middleware.WithBurst(10)}, 60, []step{
+			{0, 15, 10},
+			{time.Second - time.Millisecond, 1, 0},
+			{time.Second, 2, 1},
+			{11 * time.Second, 20, 10},
+			// however long the client waits
+			{10 * time.Minute, 20, 10},
+		}},
+		// the sustained rate is still a token a second
+		{"sustained", []middleware.RateLimiterOption{middleware.WithAlgorithm(middleware.TokenBucket), middleware.WithBurst(100)}, 60, []step{
+			{0, 150, 100},
+			{30 * time.Second, 50, 30},
+			{time.Minute, 50, 30},
+		}},
+		{"no burst", []middleware.RateLimiterOption{middleware.WithAlgorithm(middleware.TokenBucket), middleware.WithBurst(0)}, 60, []step{
+			{0, 100, 60},
+		}},
+		{"ignored by sliding log", []middleware.RateLimiterOption{middleware.WithBurst(10)}, 3, []step{
+			{0, 5, 3},
+		}},
+		{"ignored by sliding window counter", []middleware.RateLimiterOption{middleware.WithAlgorithm(middleware.SlidingWindowCounter), middleware.WithBurst(10)}, 3, []step{
+			{0, 5, 3},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rl := middleware.NewRateLimiter(tt.limit, tt.opts...)
+			for _, s := range tt.steps {
+				if got := allowAll(rl, "k", start.Add(s.at), s.tries); got != s.want {
+					t.Errorf("at +%s: allowed %d of %d, want %d", s.at, got, s.tries, s.want)
+				}
+			}
+		})
+	}
+
+	// a request costing more than the burst is taken as the whole burst
+	rl := middleware.NewRateLimiter(60, middleware.WithAlgorithm(middleware.TokenBucket), middleware.WithBurst(10))
+	if !rl.AllowN("k", start, 50) {
+		t.Error("AllowN(50) with a burst of 10 refused on a full bucket")
+	}
+	if rl.Allow("k", start) {
+		t.Error("Allow after AllowN(50) allowed, want the burst spent")
+	}
+}
+
+func TestRateLimiterWindow(t *testing.T) {
+	type step struct {
+		at          time.Duration // after start
+		tries, want int
+	}
+	tests := []struct {
+		alg    middleware.Algorithm
+		window time.Duration
+		limit  int
+		steps  []step
+	}{
+		{middleware.SlidingLog, 10 * time.Second, 5, []step{
+			{0, 7, 5},
+			{10 * time.Second, 1, 0},
+			{10*time.Second + time.Nanosecond, 7, 5},
+		}},
+		// earns a token every two seconds
+		{middleware.TokenBucket, 10 * time.Second, 5, []step{
+			{0, 6, 5},
+			{2*time.Second - time.Millisecond, 1, 0},
+			{2 * time.Second, 2, 1},
+			{time.Minute, 6, 5},
+		}},
+		{middleware.SlidingWindowCounter, 10 * time.Second, 10, []step{
+			{0, 12, 10},
+			{10 * time.Second, 1, 0},
+			{15 * time.Second, 10, 5},
+			{40 * time.Second, 12, 10},
+		}},
+		// zero keeps the minute
+		{middleware.SlidingLog, 0, 2, []step{
+			{0, 3, 2},
+			{time.Minute, 1, 0},
+			{time.Minute + time.Nanosecond, 3, 2},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.alg)+" "+tt.window.String(), func(t *testing.T) {
+			rl := middleware.NewRateLimiter(tt.limit, middleware.WithAlgorithm(tt.alg), middleware.WithWindow(tt.window))
+			if want := cmp.Or(tt.window, time.Minute); rl.Window() != want {
+				t.Errorf("Window() = %s, want %s", rl.Window(), want)
+			}
+			for _, s := range tt.steps {
+				if got := allowAll(rl, "k", start.Add(s.at), s.tries); got != s.want {
+					t.Errorf("at +%s: allowed %d of %d, want %d", s.at, got, s.tries, s.want)
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimiterClock(t *testing.T) {
+	// each step moves the clock on, sends requests as client and checks
+	// what it has left and how many clients a cleanup then leaves
+	type step struct {
+		advance          time.Duration
+		client           string
+		requests, want   int
+		remaining, after int
+	}
+	tests := []struct {
+		alg   middleware.Algorithm
+		limit int
+		steps []step
+	}{
+		{middleware.SlidingLog, 3, []step{
+			{0, "a", 2, 2, 1, 1},
+			{30 * time.Second, "b", 1, 1, 2, 2},
+			// a's first two are exactly a window old, and still count
+			{30 * time.Second, "a", 2, 1, 0, 2},
+			{time.Nanosecond, "a", 3, 2, 0, 2},
+			// b's request rolls out, so b is pruned
+			{30 * time.Second, "a", 0, 0, 0, 1},
+			{time.Minute, "a", 0, 0, 3, 0},
+		}},
+		// earns a token a second
+		{middleware.TokenBucket, 60, []step{
+			{0, "a", 61, 60, 0, 1},
+			{0, "b", 1, 1, 59, 2},
+			// b's bucket is full again long before
+			{30 * time.Second, "a", 40, 30, 0, 1},
+			{59 * time.Second, "a", 0, 0, 59, 1},
+			{time.Second, "a", 0, 0, 60, 0},
+		}},
+		{middleware.SlidingWindowCounter, 10, []step{
+			{0, "a", 12, 10, 0, 1},
+			{0, "b", 1, 1, 9, 2},
+			// half of the previous window still weighs in
+			{90 * time.Second, "a", 10, 5, 0, 2},
+			// b's window is two behind
+			{30 * time.Second, "a", 0, 0, 5, 1},
+			{time.Minute, "a", 0, 0, 10, 0},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.alg), func(t *testing.T) {
+			clock := testutil.NewClock(start)
+			rl := middleware.NewRateLimiter(tt.limit, middleware.WithAlgorithm(tt.alg), middleware.WithClock(clock),
+				middleware.WithKey(func(c *gin.Context) string { return c.GetHeader("X-Client") }))
+			r := testutil.Engine(rl.Middleware())
+			r.GET("/", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+			for i, s := range tt.steps {
+				now := clock.Advance(s.advance)
+				allowed := 0
+				for range s.requests {
+					w := testutil.Do(t, r, http.MethodGet, "/", nil, testutil.WithHeader("X-Client", s.client))
+					if w.Code == http.StatusNoContent {
+						allowed++
+					}
+				}
+				if allowed != s.want {
+					t.Errorf("step %d, +%s: %s allowed %d of %d, want %d", i+1, now.Sub(start), s.client, allowed, s.requests, s.want)
+				}
+				if got := rl.Remaining(s.client, now); got != s.remaining {
+					t.Errorf("step %d, +%s: %s has %d left, want %d", i+1, now.Sub(start), s.client, got, s.remaining)
+				}
+				rl.Cleanup(now)
+				if got := rl.Clients(); got != s.after {
+					t.Errorf("step %d, +%s: %d clients after cleanup, want %d", i+1, now.Sub(start), got, s.after)
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimiterJanitor(t *testing.T) {
+	rl := middleware.NewRateLimiter(3, middleware.WithWindow(10*time.Millisecond))
+	now := time.Now()
+	for _, key := range []string{"a", "b", "c"} {
+		rl.Allow(key, now)
+	}
+	if n := rl.Clients(); n != 3 {
+		t.Fatalf("Clients = %d, want 3", n)
+	}
+	rl.StartJanitor(5 * time.Millisecond)
+	rl.StartJanitor(5 * time.Millisecond) // a second janitor is not started
+	deadline := time.Now().Add(time.Second)
+	for rl.Clients() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("janitor left %d idle clients", rl.Clients())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if err := rl.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	// stopping twice, or a limiter that never had a janitor, is fine
+	if err := rl.Stop(context.Background()); err != nil {
+		t.Errorf("second Stop: %v", err)
+	}
+	if err := middleware.NewRateLimiter(1).Stop(context.Background()); err != nil {
+		t.Errorf("Stop without a janitor: %v", err)
+	}
+	// a stopped janitor cleans up no more
+	rl.Allow("d", time.Now())
+	time.Sleep(30 * time.Millisecond)
+	if n := rl.Clients(); n != 1 {
+		t.Errorf("Clients after Stop = %d, want 1", n)
+	}
+}
+
+func TestRateLimiterAlgorithms(t *testing.T) {
+	for _, a := range algorithms {
+		t.Run(string(a), func(t *testing.T) {
+			rl := middleware.NewRateLimiter(3, middleware.WithAlgorithm(a))
+			if got := rl.Algorithm(); got != a {
+				t.Errorf("Algorithm = %q, want %q", got, a)
+			}
+			if got := allowAll(rl, "k", start, 5); got != 3 {
+				t.Errorf("allowed %d of 5 at once, want the limit", got)
+			}
+			if got := rl.Remaining("k", start); got != 0 {
+				t.Errorf("Remaining = %d, want 0", got)
+			}
+			if n := rl.Cleanup(start); n != 0 {
+				t.Errorf("Cleanup dropped %d busy clients", n)
+			}
+			// two windows on, every algorithm has forgotten the burst
+			later := start.Add(2 * time.Minute)
+			if got := rl.Remaining("k", later); got != 3 {
+				t.Errorf("Remaining two windows later = %d, want 3", got)
+			}
+			if n := rl.Cleanup(later); n != 1 {
+				t.Errorf("Cleanup dropped %d idle clients, want 1", n)
+			}
+		})
+	}
+}
+
+func TestTokenBucketRefill(t *testing.T) {
+	rl := middleware.NewRateLimiter(60, middleware.WithAlgorithm(middleware.TokenBucket))
+	if got := allowAll(rl, "k", start, 61); got != 60 {
+		t.Fatalf("allowed %d of 61, want the whole bucket", got)
+	}
+	// a token a second
+	if got := allowAll(rl, "k", start.Add(500*time.Millisecond), 1); got != 0 {
+		t.Errorf("allowed %d half a second on, want 0", got)
+	}
+	if got := allowAll(rl, "k", start.Add(time.Second), 2); got != 1 {
+		t.Errorf("allowed %d a second on, want 1", got)
+	}
+	if got := allowAll(rl, "k", start.Add(11*time.Second), 20); got != 10 {
+		t.Errorf("allowed %d eleven seconds on, want 10", got)
+	}
+}
+
+func TestSlidingWindowCounterWeight(t *testing.T) {
+	rl := middleware.NewRateLimiter(10, middleware.WithAlgorithm(middleware.SlidingWindowCounter))
+	if got := allowAll(rl, "k", start, 10); got != 10 {
+		t.Fatalf("allowed %d of 10", got)
+	}
+	// a quarter into the next minute, three quarters of the previous one's
+	// 10 still count
+	at := start.Add(time.Minute + 15*time.Second)
+	if got := rl.Remaining("k", at); got != 2 {
+		t.Errorf("Remaining = %d, want 2", got)
+	}
+	if got := allowAll(rl, "k", at, 5); got != 2 {
+		t.Errorf("allowed %d of 5, want 2", got)
+	}
+}
+
+func TestNewRateLimiterUnknownAlgorithm(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("no panic for an unknown algorithm")
+		}
+	}()
+	middleware.NewRateLimiter(1, middleware.WithAlgorithm("leaky"))
+}
+
+func TestRateLimiterRetryAfter(t *testing.T) {
+	// three requests at start, the whole budget, asked about 10s on
+	tests := []struct {
+		algorithm    middleware.Algorithm
+		retry, reset time.Duration
+	}{
+		{middleware.SlidingLog, 50 * time.Second, 50 * time.Second},
+		// a token every 20s, half of one earned
+		{middleware.TokenBucket, 10 * time.Second, 50 * time.Second},
+		// the current minute alone is full: into the next, until a third of
+		// its weight is gone
+		{middleware.SlidingWindowCounter, 70 * time.Second, 110 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.algorithm), func(t *testing.T) {
+			rl := middleware.NewRateLimiter(3, middleware.WithAlgorithm(tt.algorithm))
+			allowAll(rl, "k", start, 3)
+			now := start.Add(10 * time.Second)
+			if got := rl.RetryAfter("k", now); got != tt.retry {
+				t.Errorf("RetryAfter = %s, want %s", got, tt.retry)
+			}
+			if got := rl.Reset("k", now); got != tt.reset {
+				t.Errorf("Reset = %s, want %s", got, tt.reset)
+			}
+			// a request exactly a window old still counts, and Retry-After
+			// is rounded up to whole seconds
+			if !rl.Allow("k", now.Add(tt.retry+time.Nanosecond)) {
+				t.Error("denied once RetryAfter is up")
+			}
+			if got := rl.RetryAfter("new", now); got != 0 {
+				t.Errorf("RetryAfter of a new client = %s, want 0", got)
+			}
+		})
+	}
+}
+
+func TestRateLimiterHeaders(t *testing.T) {
+	router := engine(middleware.NewRateLimiter(2).Middleware())
+	router.GET("/", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	w := serve(router, http.MethodGet, "/", nil)
+	if got := w.Header().Get("X-RateLimit-Remaining"); got != "1" {
+		t.Errorf("X-RateLimit-Remaining = %q, want 1", got)
+	}
+	if got := w.Header().Get("X-RateLimit-Reset"); got != "60" {
+		t.Errorf("X-RateLimit-Reset = %q, want 60", got)
+	}
+	if w.Header().Get("Retry-After") != "" {
+		t.Error("Retry-After on an allowed request")
+	}
+	serve(router, http.MethodGet, "/", nil)
+	w = serve(router, http.MethodGet, "/", nil)
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want 429", w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "60" {
+		t.Errorf("Retry-After = %q, want 60 for requests just made", got)
+	}
+}
+
+// ipLists is an IPFilter of exact addresses.
+type ipLists struct{ allow, deny []string }
+
+func (l ipLists) Check(ip string) (allowed, denied bool) {
+	return slices.Contains(l.allow, ip), slices.Contains(l.deny, ip)
+}
+
+func TestRateLimiterIPFilter(t *testing.T) {
+	ips := ipLists{allow: []string{"10.0.0.1", "10.0.0.3"}, deny: []string{"10.0.0.2", "10.0.0.3"}}
+	router := engine(middleware.NewRateLimiter(1, middleware.WithIPFilter(ips)).Middleware())
+	router.GET("/", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	send := func(ip string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = ip + ":1234"
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	tests := []struct {
+		ip   string
+		want int
+	}{
+		{"10.0.0.1", 204},
+		{"10.0.0.1", 204}, // allowed addresses aren't counted
+		{"10.0.0.2", 403},
+		{"10.0.0.3", 403}, // deny wins
+		{"10.0.0.4", 204},
+		{"10.0.0.4", 429},
+	}
+	for i, tt := range tests {
+		if w := send(tt.ip); w.Code != tt.want {
+			t.Errorf("request %d from %s: status = %d, want %d", i, tt.ip, w.Code, tt.want)
+		}
+	}
+	if w := send("10.0.0.1"); w.Header().Get("X-RateLimit-Limit") != "" {
+		t.Error("rate limit headers for an allowed address")
+	}
+}
+
+// subject is a principal as Auth would store one.
+type subject string
+
+func (s subject) Subject() string { return string(s) }
+
+func TestRateLimiterKeys(t *testing.T) {
+	user := func(c *gin.Context) {
+		if name := c.GetHeader("X-User"); name != "" {
+			c.Set(middleware.UserKey, subject(name))
+		}
+	}
+	tests := []struct {
+		name  string
+		key   middleware.KeyFunc
+		paths []string
+		users []string // one per request, "" for anonymous
+		want  []int
+	}{
+		{"ip", nil, []string{"/a", "/b", "/a"}, []string{"alice", "bob", ""}, []int{204, 429, 429}},
+		{"user", middleware.ByUser, []string{"/a", "/b", "/a", "/a"}, []string{"alice", "bob", "alice", ""}, []int{204, 204, 429, 204}},
+		{"route_ip", middleware.ByRouteAndIP, []string{"/a", "/b", "/a"}, []string{"", "", ""}, []int{204, 204, 429}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := engine(user, middleware.NewRateLimiter(1, middleware.WithKey(tt.key)).Middleware())
+			for _, path := range []string{"/a", "/b"} {
+				router.GET(path, func(c *gin.Context) { c.Status(http.StatusNoContent) })
+			}
+			for i, path := range tt.paths {
+				w := serve(router, http.MethodGet, path, map[string]string{"X-User": tt.users[i]})
+				if w.Code != tt.want[i] {
+					t.Errorf("request %d, %s as %q: status = %d, want %d", i, path, tt.users[i], w.Code, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+// sliceLog is the limiter as it was before the ring buffer: each request
+// prunes a client's timestamps into a new slice and appends to it.
+type sliceLog struct {
+	limit   int
+	window  time.Duration
+	mu      sync.Mutex
+	clients map[string][]time.Time
+}
+
+func (l *sliceLog) Allow(key string, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	pruned := make([]time.Time, 0, len(l.clients[key]))
+	for _, t := range l.clients[key] {
+		if now.Sub(t) <= l.window {
+			pruned = append(pruned, t)
+		}
+	}
+	if len(pruned) >= l.limit {
+		l.clients[key] = pruned
+		return false
+	}
+	l.clients[key] = append(pruned, now)
+	return true
+}
+
+// BenchmarkAllow has a thousand clients each over its limit most of the
+// time, so both the allow and the deny path are taken. Compare the
+// algorithms, and the allocations per request of the old slice log and the
+// sliding log's ring buffer.
+func BenchmarkAllow(b *testing.B) {
+	const limit = 10
+	keys := make([]string, 1000)
+	for i := range keys {
+		keys[i] = "10.0." + strconv.Itoa(i/256) + "." + strconv.Itoa(i%256)
+	}
+	type limiter struct {
+		name string
+		rl   interface{ Allow(string, time.Time) bool }
+	}
+	limiters := []limiter{{"slice_log", &sliceLog{limit: limit, window: time.Minute, clients: map[string][]time.Time{}}}}
+	for _, a := range algorithms {
+		limiters = append(limiters, limiter{string(a), middleware.NewRateLimiter(limit, middleware.WithAlgorithm(a))})
+	}
+	for _, l := range limiters {
+		b.Run(l.name, func(b *testing.B) {
+			b.ReportAllocs()
+			i := 0
+			for b.Loop() {
+				l.rl.Allow(keys[i%len(keys)], start.Add(time.Duration(i)*time.Millisecond))
+				i++
+			}
+		})
+	}
+}
+
+// BenchmarkNewClient sends the first request of a new client each time, at
+// the default API key limit, so its memory is what the limiter keeps per
+// client, plus the key and the map's share.
+func BenchmarkNewClient(b *testing.B) {
+	for _, a := range algorithms {
+		b.Run(string(a), func(b *testing.B) {
+			rl := middleware.NewRateLimiter(600, middleware.WithAlgorithm(a))
+			b.ReportAllocs()
+			i := 0
+			for b.Loop() {
+				rl.Allow(strconv.Itoa(i), start)
+				i++
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/apperror"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/i18n"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/requestid"
+)
+
+// RequestIDKey is the gin context key holding the current request ID.
+const RequestIDKey = "request_id"
+
+// maxRequestIDLen caps client supplied IDs so they can't bloat logs.
+const maxRequestIDLen = 128
+
+// RequestID reuses the caller's X-Request-ID (or generates one), echoes it on
+// the response and stores it in both the gin and the request context.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestid.Header)
+		if id == "" || len(id) > maxRequestIDLen {
+			id = requestid.New()
+		}
+
+		c.Set(RequestIDKey, id)
+		c.Request = c.Request.WithContext(requestid.NewContext(c.Request.Context(), id))
+		c.Header(requestid.Header, id)
+		c.Next()
+	}
+}
+
+// Error writes the JSON error body used across the examples, tagged with the
+// request ID so a client report can be matched to the server logs. msg is
+// translated into the request's locale when the catalog has it.
+func Error(c *gin.Context, status int, msg string) {
+	c.JSON(status, errorBody(c, status, msg))
+}
+
+// AbortError is Error that also stops the handler chain.
+func AbortError(c *gin.Context, status int, msg string) {
+	c.AbortWithStatusJSON(status, errorBody(c, status, msg))
+}
+
+// errorBody is the error envelope: the localized message, a code derived
+// from status, and the request ID.
+func errorBody(c *gin.Context, status int, msg string) apperror.APIError {
+	return apperror.APIError{
+		Message:   i18n.Localize(c, msg, nil),
+		Code:      apperror.CodeForStatus(status),
+		RequestID: c.GetString(RequestIDKey),
+	}
+}
@@ -0,0 +1,23 @@
+package middleware
+
+import "github.com/gin-gonic/gin"
+
+// assumeTLSKey marks a request that reached a TLS-ending proxy over HTTPS.
+const assumeTLSKey = "assume_tls"
+
+// AssumeTLS treats every request as sent over HTTPS, for a server behind a
+// proxy that ends TLS and forwards plain HTTP. Only the proxy should be able
+// to reach such a server.
+func AssumeTLS() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(assumeTLSKey, true)
+		c.Next()
+	}
+}
+
+// IsSecure reports whether the client reached the server over HTTPS, directly
+// or through a proxy behind AssumeTLS. Cookies set on such requests should be
+// Secure, so the browser never sends them back over plain HTTP.
+func IsSecure(c *gin.Context) bool {
+	return c.Request.TLS != nil || c.GetBool(assumeTLSKey)
+}
@@ -0,0 +1,173 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// timeoutParentKey holds the request context from before Timeout added its
+// deadline, for WithoutTimeout and RouteTimeout to restore.
+const timeoutParentKey = "timeout.parent"
+
+// routeTimeout is the deadline of the routes a pattern matches.
+type routeTimeout struct {
+	routePattern
+	d time.Duration
+}
+
+type timeoutConfig struct {
+	routes []routeTimeout
+}
+
+// TimeoutOption customizes the timeout middleware.
+type TimeoutOption func(*timeoutConfig)
+
+// WithRouteTimeouts gives some routes other deadlines than d, by "[METHOD
+// ]path" pattern as LimitRoutes takes them, such as {"POST /login": 3s,
+// "POST /upload*": 5m}. The most specific pattern a request matches
+// applies; zero lifts the deadline.
+func WithRouteTimeouts(timeouts map[string]time.Duration) TimeoutOption {
+	return func(cfg *timeoutConfig) {
+		for pattern, d := range timeouts {
+			cfg.routes = append(cfg.routes, routeTimeout{parseRoutePattern(pattern), d})
+		}
+		slices.SortStableFunc(cfg.routes, func(a, b routeTimeout) int { return moreSpecific(a.routePattern, b.routePattern) })
+	}
+}
+
+// Timeout gives each request a context that expires after d, so database
+// queries and outbound calls made with c.Request.Context() give up. If the
+// deadline passed before the handler wrote anything, the client gets a 504
+// and whatever the handler writes late is dropped, so the response is never
+// half a 504 and half its own.
+//
+// Handlers run to completion on the request goroutine; a handler that ignores
+// its context finishes late rather than racing a response written from
+// another goroutine. WebSocket upgrades, text/event-stream requests and routes
+// behind WithoutTimeout are left alone, and RouteTimeout changes the deadline
+// of a group. A d of zero disables it.
+func Timeout(d time.Duration, opts ...TimeoutOption) gin.HandlerFunc {
+	var cfg timeoutConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return func(c *gin.Context) {
+		limit := cfg.timeout(c.Request.Method, c.FullPath(), d)
+		if limit <= 0 || c.IsWebsocket() || strings.Contains(c.GetHeader("Accept"), "text/event-stream") {
+			c.Next()
+			return
+		}
+		parent := c.Request.Context()
+		ctx, cancel := context.WithTimeout(parent, limit)
+		defer cancel()
+		c.Set(timeoutParentKey, parent)
+		c.Request = c.Request.WithContext(ctx)
+
+		orig := c.Writer
+		c.Writer = &timeoutWriter{ResponseWriter: orig, c: c}
+		c.Next()
+		c.Writer = orig
+
+		if timedOut(c) && !orig.Written() {
+			// the dropped response may have described its body
+			h := c.Writer.Header()
+			for _, name := range []string{"Content-Encoding", "Content-Length", "Content-Disposition", "ETag", "Last-Modified"} {
+				h.Del(name)
+			}
+			AbortError(c, http.StatusGatewayTimeout, "request timed out")
+		}
+	}
+}
+
+// timeout is the deadline of route: what WithRouteTimeouts gives it, or d.
+func (cfg timeoutConfig) timeout(method, route string, d time.Duration) time.Duration {
+	for _, r := range cfg.routes {
+		if r.matches(method, route) {
+			return r.d
+		}
+	}
+	return d
+}
+
+// timedOut reports whether the request's current deadline has passed, the
+// one RouteTimeout set if it did.
+func timedOut(c *gin.Context) bool {
+	return errors.Is(c.Request.Context().Err(), context.DeadlineExceeded)
+}
+
+// WithoutTimeout lifts the Timeout deadline for one route, for long-lived
+// responses such as streams. Put it first in the route's handlers.
+func WithoutTimeout() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if parent, ok := c.Get(timeoutParentKey); ok {
+			c.Request = c.Request.WithContext(parent.(context.Context))
+		}
+		c.Next()
+	}
+}
+
+// RouteTimeout replaces the Timeout deadline of a route or group with d
+// from the start of the request, longer or shorter: short for sign-in,
+// long for uploads. Put it first in the handlers. Without Timeout in front
+// it does nothing.
+func RouteTimeout(d time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		parent, ok := c.Get(timeoutParentKey)
+		if !ok {
+			c.Next()
+			return
+		}
+		ctx, cancel := context.WithTimeout(parent.(context.Context), d)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// timeoutWriter drops what a handler writes once its deadline has passed,
+// unless it had already started the response, leaving the 504 to Timeout.
+type timeoutWriter struct {
+	gin.ResponseWriter
+	c *gin.Context
+}
+
+// late reports whether a write now comes too late to be sent.
+func (w *timeoutWriter) late() bool {
+	return !w.ResponseWriter.Written() && timedOut(w.c)
+}
+
+func (w *timeoutWriter) WriteHeader(code int) {
+	if !w.late() {
+		w.ResponseWriter.WriteHeader(code)
+	}
+}
+
+func (w *timeoutWriter) WriteHeaderNow() {
+	if !w.late() {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
+
+func (w *timeoutWriter) Write(p []byte) (int, error) {
+	if w.late() {
+		return 0, http.ErrHandlerTimeout
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *timeoutWriter) WriteString(s string) (int, error) {
+	if w.late() {
+		return 0, http.ErrHandlerTimeout
+	}
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *timeoutWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,62 @@
+package middleware_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+)
+
+func TestTimeoutRoutes(t *testing.T) {
+	r := engine(middleware.Timeout(time.Hour, middleware.WithRouteTimeouts(map[string]time.Duration{
+		"POST /slow": 10 * time.Millisecond,
+		"/free":      0,
+	})))
+	// wait answers once the deadline has passed, too late to be sent
+	wait := func(c *gin.Context) {
+		<-c.Request.Context().Done()
+		c.Header("ETag", `"late"`)
+		c.String(http.StatusOK, "late")
+	}
+	// deadline answers with the deadline left, or "none"
+	deadline := func(c *gin.Context) {
+		at, ok := c.Request.Context().Deadline()
+		if !ok {
+			c.String(http.StatusOK, "none")
+			return
+		}
+		c.String(http.StatusOK, time.Until(at).Round(time.Hour).String())
+	}
+	r.POST("/slow", wait)
+	r.GET("/slow", deadline)
+	r.GET("/free", deadline)
+	uploads := r.Group("/uploads", middleware.RouteTimeout(10*time.Millisecond))
+	uploads.POST("", wait)
+
+	for _, path := range []string{"/slow", "/uploads"} {
+		w := serve(r, http.MethodPost, path, nil)
+		if w.Code != http.StatusGatewayTimeout {
+			t.Errorf("POST %s: status = %d, want 504", path, w.Code)
+		}
+		if body := w.Body.String(); strings.Contains(body, "late") {
+			t.Errorf("POST %s: late write sent: %s", path, body)
+		}
+		if etag := w.Header().Get("ETag"); etag != "" {
+			t.Errorf("POST %s: late ETag %s sent", path, etag)
+		}
+	}
+
+	tests := []struct{ path, want string }{
+		{"/slow", "1h0m0s"}, // only POST has the short deadline
+		{"/free", "none"},
+	}
+	for _, tt := range tests {
+		if got := serve(r, http.MethodGet, tt.path, nil).Body.String(); got != tt.want {
+			t.Errorf("GET %s: deadline %s, want %s", tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/apperror"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/validation"
+)
+
+// BindError answers 400 for an error from c.ShouldBind*, with the error
+// BadInput makes of it.
+func BindError(c *gin.Context, err error) {
+	e := BadInput(c, err)
+	c.JSON(e.Status, appErrorBody(c, e))
+}
+
+// BadInput is the error to answer an error from c.ShouldBind* with, for
+// handlers that return it. Validation failures become one readable,
+// localized sentence per field instead of the validator's "Key:
+// 'Book.Title' Error:Field validation..." text, and are also listed by
+// field under "details". See internal/validation. A body over the
+// BodyLimit is a 413 instead.
+func BadInput(c *gin.Context, err error) *apperror.Error {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return apperror.TooLarge(tooLarge.Limit)
+	}
+	fields, ok := validation.Translate(c, err)
+	if !ok {
+		return apperror.BadRequest(err.Error())
+	}
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	msgs := make([]string, len(names))
+	for i, name := range names {
+		msgs[i] = fields[name]
+	}
+	// already localized; errorBody's lookup just passes it through
+	return apperror.Validation(strings.Join(msgs, "; "), fields)
+}
@@ -0,0 +1,134 @@
+// Package negotiate lets routes answer in JSON, XML or YAML, whichever the
+// client asks for, and read request bodies in any of them.
+//
+// Routes opt in with Middleware; their handlers then write with Render (and
+// pagination.Write, which uses it) instead of c.JSON, and read with Bind
+// instead of c.ShouldBindJSON. Without the middleware Render writes JSON, so
+// shared handlers behave as before on routes that haven't opted in. Errors
+// are always JSON.
+//
+// YAML mirrors the JSON: goccy/go-yaml, which gin renders with, honors
+// json tags. So does XML output, which is translated from the JSON: keys
+// become elements, and array items repeat their key's element. Reading XML
+// goes through encoding/xml, so types bound from it need xml tags that
+// match their json ones.
+package negotiate
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/apperror"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+)
+
+// The formats, as ?format= names them.
+const (
+	JSON = "json"
+	XML  = "xml"
+	YAML = "yaml"
+)
+
+// offered are the media types Accept can pick, by format; JSON comes first,
+// so it's the answer to */* or no Accept at all.
+var offered = []struct {
+	mime, format string
+}{
+	{binding.MIMEJSON, JSON},
+	{binding.MIMEXML, XML},
+	{binding.MIMEXML2, XML},
+	{binding.MIMEYAML2, YAML},
+	{binding.MIMEYAML, YAML},
+}
+
+// enabledKey marks a request whose route has the middleware.
+const enabledKey = "negotiate.enabled"
+
+// Middleware turns negotiation on. ?format=json|xml|yaml overrides the
+// Accept header, which is handy in a browser; any other ?format= is a 400.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if f := c.Query("format"); f != "" {
+			mime := ""
+			for _, o := range offered {
+				if o.format == f {
+					mime = o.mime
+					break
+				}
+			}
+			if mime == "" {
+				middleware.Fail(c, apperror.Validation("invalid query parameters",
+					map[string]string{"format": "must be json, xml or yaml"}))
+				return
+			}
+			c.SetAccepted(mime)
+		}
+		// caches must keep each format apart
+		c.Writer.Header().Add("Vary", "Accept")
+		c.Set(enabledKey, true)
+		c.Next()
+	}
+}
+
+// Format is the format Render writes for c: JSON unless the middleware is on
+// and the client asked for another. An Accept that names none of the three
+// gets JSON too, rather than a 406.
+func Format(c *gin.Context) string {
+	if !c.GetBool(enabledKey) {
+		return JSON
+	}
+	mimes := make([]string, len(offered))
+	for i, o := range offered {
+		mimes[i] = o.mime
+	}
+	mime := c.NegotiateFormat(mimes...)
+	for _, o := range offered {
+		if o.mime == mime {
+			return o.format
+		}
+	}
+	return JSON
+}
+
+// Render writes v with status in the format the client asked for.
+func Render(c *gin.Context, status int, v any) {
+	switch Format(c) {
+	case XML:
+		c.Render(status, xmlRender{v})
+	case YAML:
+		c.YAML(status, v)
+	default:
+		c.JSON(status, v)
+	}
+}
+
+// Bind reads the body into v by its Content-Type, XML or YAML, and
+// validates it like c.ShouldBindJSON. Any other type is read as JSON, as
+// before, since curl -d sends JSON as a form.
+func Bind(c *gin.Context, v any) error {
+	switch c.ContentType() {
+	case binding.MIMEXML, binding.MIMEXML2:
+		return c.ShouldBindWith(v, binding.XML)
+	case binding.MIMEYAML, binding.MIMEYAML2:
+		return c.ShouldBindWith(v, binding.YAML)
+	}
+	return c.ShouldBindJSON(v)
+}
+
+// xmlRender is a gin render.Render for toXML.
+type xmlRender struct {
+	data any
+}
+
+func (r xmlRender) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+	return toXML(w, r.data)
+}
+
+func (r xmlRender) WriteContentType(w http.ResponseWriter) {
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	}
+}
@@ -0,0 +1,141 @@
+package negotiate
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+)
+
+type shelfItem struct {
+	Title string   `json:"title" xml:"title" binding:"required"`
+	Tags  []string `json:"tags,omitempty" xml:"tags"`
+	ISBN  string   `json:"isbn,omitempty" xml:"isbn"`
+}
+
+type shelf struct {
+	Name  string      `json:"name"`
+	Items []shelfItem `json:"items"`
+	Owner *string     `json:"owner"`
+}
+
+func newRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.ErrorHandler(nil))
+	data := shelf{Name: "SF & fantasy", Items: []shelfItem{{Title: "Dune", Tags: []string{"desert", "spice"}}, {Title: "Emma", ISBN: "9780141439587"}}}
+	r.GET("/plain", func(c *gin.Context) { Render(c, http.StatusOK, data) })
+	g := r.Group("", Middleware())
+	g.GET("/shelf", func(c *gin.Context) { Render(c, http.StatusOK, data) })
+	g.GET("/list", func(c *gin.Context) { Render(c, http.StatusOK, []string{"a", "b"}) })
+	g.POST("/items", func(c *gin.Context) {
+		var item shelfItem
+		if err := Bind(c, &item); err != nil {
+			middleware.BindError(c, err)
+			return
+		}
+		Render(c, http.StatusCreated, item)
+	})
+	return r
+}
+
+func TestRender(t *testing.T) {
+	const (
+		xmlShelf  = xmlHeader + `<shelf><name>SF &amp; fantasy</name><items><title>Dune</title><tags>desert</tags><tags>spice</tags></items><items><title>Emma</title><isbn>9780141439587</isbn></items></shelf>`
+		yamlShelf = "name: SF & fantasy\nitems:\n- title: Dune\n  tags:\n  - desert\n  - spice\n- title: Emma\n  isbn: \"9780141439587\"\nowner: null\n"
+		jsonShelf = `{"name":"SF \u0026 fantasy","items":[{"title":"Dune","tags":["desert","spice"]},{"title":"Emma","isbn":"9780141439587"}],"owner":null}`
+	)
+	tests := []struct {
+		name   string
+		path   string
+		accept string
+		status int
+		mime   string
+		body   string
+	}{
+		{"no Accept", "/shelf", "", http.StatusOK, "application/json", jsonShelf},
+		{"any", "/shelf", "*/*", http.StatusOK, "application/json", jsonShelf},
+		{"xml", "/shelf", "application/xml", http.StatusOK, "application/xml", xmlShelf},
+		{"text/xml", "/shelf", "text/html, text/xml", http.StatusOK, "application/xml", xmlShelf},
+		{"yaml", "/shelf", "application/yaml", http.StatusOK, "application/yaml", yamlShelf},
+		{"none offered", "/shelf", "text/html", http.StatusOK, "application/json", jsonShelf},
+		{"format wins", "/shelf?format=yaml", "application/xml", http.StatusOK, "application/yaml", yamlShelf},
+		{"unknown format", "/shelf?format=csv", "", http.StatusBadRequest, "application/json", ""},
+		{"not opted in", "/plain", "application/xml", http.StatusOK, "application/json", jsonShelf},
+		{"top-level array", "/list?format=xml", "", http.StatusOK, "application/xml", xmlHeader + `<response><item>a</item><item>b</item></response>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			newRouter().ServeHTTP(w, req)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d; body: %s", w.Code, tt.status, w.Body)
+			}
+			if got := w.Header().Get("Content-Type"); !strings.HasPrefix(got, tt.mime) {
+				t.Errorf("Content-Type = %q, want %s", got, tt.mime)
+			}
+			if tt.body != "" && w.Body.String() != tt.body {
+				t.Errorf("body =\n%s\nwant\n%s", w.Body, tt.body)
+			}
+		})
+	}
+}
+
+func TestBind(t *testing.T) {
+	tests := []struct {
+		name   string
+		mime   string
+		body   string
+		status int
+	}{
+		{"json", "application/json", `{"title":"Dune","tags":["desert"]}`, http.StatusCreated},
+		{"no type", "", `{"title":"Dune","tags":["desert"]}`, http.StatusCreated},
+		{"xml", "application/xml", `<item><title>Dune</title><tags>desert</tags></item>`, http.StatusCreated},
+		{"yaml", "application/yaml", "title: Dune\ntags: [desert]\n", http.StatusCreated},
+		{"invalid xml", "text/xml", `<item><tags>desert</tags></item>`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/items", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.mime)
+			w := httptest.NewRecorder()
+			newRouter().ServeHTTP(w, req)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d; body: %s", w.Code, tt.status, w.Body)
+			}
+			if tt.status == http.StatusCreated && w.Body.String() != `{"title":"Dune","tags":["desert"]}` {
+				t.Errorf("bound %s", w.Body)
+			}
+		})
+	}
+}
+
+func TestRootName(t *testing.T) {
+	type BookV2 struct{}
+	type Page[T any] struct{}
+	tests := []struct {
+		v    any
+		want string
+	}{
+		{shelf{}, "shelf"},
+		{&BookV2{}, "book_v2"},
+		{Page[shelf]{}, "page"},
+		{gin.H{}, "response"},
+		{struct{ A int }{}, "response"},
+	}
+	for _, tt := range tests {
+		if got := rootName(tt.v); got != tt.want {
+			t.Errorf("rootName(%T) = %q, want %q", tt.v, got, tt.want)
+		}
+	}
+}
+
+const xmlHeader = `<?xml version="1.0" encoding="UTF-8"?>` + "\n"
@@ -0,0 +1,119 @@
+package negotiate
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// toXML writes v's JSON as an XML document. Objects become elements, each
+// key a child; an array repeats its key's element once per item, the way
+// encoding/xml reads a slice; null is left out. The root is named after v's
+// type (see rootName), and a top-level array's items are its <item>s.
+func toXML(w io.Writer, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	root := rootName(v)
+	if tok == json.Delim('[') {
+		start := xml.StartElement{Name: xml.Name{Local: root}}
+		if err := enc.EncodeToken(start); err != nil {
+			return err
+		}
+		if err := writeElement(enc, dec, "item", tok); err != nil {
+			return err
+		}
+		if err := enc.EncodeToken(start.End()); err != nil {
+			return err
+		}
+	} else if err := writeElement(enc, dec, root, tok); err != nil {
+		return err
+	}
+	return enc.Flush()
+}
+
+// writeElement writes the JSON value that starts with tok, read from dec,
+// as the element name.
+func writeElement(enc *xml.Encoder, dec *json.Decoder, name string, tok json.Token) error {
+	switch tok := tok.(type) {
+	case json.Delim:
+		if tok == '[' {
+			for dec.More() {
+				item, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				if err := writeElement(enc, dec, name, item); err != nil {
+					return err
+				}
+			}
+			_, err := dec.Token() // ]
+			return err
+		}
+		start := xml.StartElement{Name: xml.Name{Local: name}}
+		if err := enc.EncodeToken(start); err != nil {
+			return err
+		}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			value, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			if err := writeElement(enc, dec, key.(string), value); err != nil {
+				return err
+			}
+		}
+		if _, err := dec.Token(); err != nil { // }
+			return err
+		}
+		return enc.EncodeToken(start.End())
+	case nil:
+		return nil
+	default:
+		return enc.EncodeElement(fmt.Sprint(tok), xml.StartElement{Name: xml.Name{Local: name}})
+	}
+}
+
+// rootName is v's type name in snake case without type parameters, so a
+// Book is <book>, a pagination.Page[Book] <page> and a BookV2 <book_v2>.
+// Maps, gin.H among them, and unnamed types are <response>.
+func rootName(v any) string {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() == reflect.Map || t.Name() == "" {
+		return "response"
+	}
+	name, _, _ := strings.Cut(t.Name(), "[")
+	var b strings.Builder
+	prev := ' '
+	for _, r := range name {
+		if unicode.IsUpper(r) && (unicode.IsLower(prev) || unicode.IsDigit(prev)) {
+			b.WriteByte('_')
+		}
+		b.WriteRune(unicode.ToLower(r))
+		prev = r
+	}
+	return b.String()
+}
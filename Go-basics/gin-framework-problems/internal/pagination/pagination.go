@@ -0,0 +1,273 @@
+// Package pagination gives every list endpoint the same query parameters,
+// response envelope and Link header.
+//
+//	GET /books?limit=20             first page
+//	GET /books?limit=20&offset=40   third page
+//	GET /books?limit=20&page=3      third page too
+//	GET /books?cursor=<next_cursor> the page after the one that returned it
+//
+// Offsets shift when items are added or removed before the page, so a
+// client paging through a changing list can skip or repeat items. Lists
+// ordered by ID can use NewKeysetPage instead: its cursors resume after the
+// last item sent, wherever that is by then.
+package pagination
+
+import (
+	"cmp"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/negotiate"
+)
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+var (
+	ErrInvalidLimit  = errors.New("limit must be a positive integer")
+	ErrInvalidOffset = errors.New("offset must be a non-negative integer")
+	ErrInvalidPage   = errors.New("page must be a positive integer")
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+// Params is a parsed page request.
+type Params struct {
+	Limit  int
+	Offset int
+	// After is the ID of the last item of the previous page, from a keyset
+	// cursor; NewKeysetPage starts after it instead of at Offset.
+	After string
+}
+
+// cursor is what an opaque cursor string carries. Clients must not build
+// cursors themselves, which leaves room to change what's inside; they're
+// signed, so a client can't make one up either.
+type cursor struct {
+	Offset int    `json:"o"`
+	Limit  int    `json:"l"`
+	After  string `json:"a,omitempty"`
+}
+
+// cursorKey signs cursors. It's random until SetCursorKey, so cursors only
+// work on the instance that made them.
+var cursorKey = func() []byte {
+	key := make([]byte, 32)
+	rand.Read(key)
+	return key
+}()
+
+// SetCursorKey derives the key cursors are signed with from secret, so
+// every instance sharing it accepts the others' cursors. server.NewEngine
+// calls it with auth.token_secret.
+func SetCursorKey(secret string) {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("pagination cursor"))
+	cursorKey = mac.Sum(nil)
+}
+
+// sign is the MAC of a cursor's payload.
+func sign(payload string) []byte {
+	mac := hmac.New(sha256.New, cursorKey)
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
+
+// EncodeCursor returns the opaque cursor for the page starting at p.
+func EncodeCursor(p Params) string {
+	data, _ := json.Marshal(cursor{Offset: p.Offset, Limit: p.Limit, After: p.After})
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	return payload + "." + base64.RawURLEncoding.EncodeToString(sign(payload))
+}
+
+// DecodeCursor parses a cursor made by EncodeCursor with the current key.
+func DecodeCursor(s string) (Params, error) {
+	payload, sig, ok := strings.Cut(s, ".")
+	if !ok {
+		return Params{}, ErrInvalidCursor
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(mac, sign(payload)) {
+		return Params{}, ErrInvalidCursor
+	}
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return Params{}, ErrInvalidCursor
+	}
+	var cur cursor
+	if err := json.Unmarshal(data, &cur); err != nil || cur.Offset < 0 || cur.Limit < 1 {
+		return Params{}, ErrInvalidCursor
+	}
+	return Params{Limit: min(cur.Limit, MaxLimit), Offset: cur.Offset, After: cur.After}, nil
+}
+
+// ParseParams reads ?limit and ?offset, or ?cursor, which wins over offset
+// and page when both are sent; ?limit next to a cursor changes the page
+// size. ?page counts pages of limit from 1 and stands in for offset when
+// that's missing. A limit above MaxLimit is clamped rather than rejected.
+func ParseParams(c *gin.Context) (Params, error) {
+	p := Params{Limit: DefaultLimit}
+	if s := c.Query("cursor"); s != "" {
+		var err error
+		if p, err = DecodeCursor(s); err != nil {
+			return Params{}, err
+		}
+	}
+	if s := c.Query("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			return Params{}, ErrInvalidLimit
+		}
+		p.Limit = min(n, MaxLimit)
+	}
+	if c.Query("cursor") != "" {
+		return p, nil
+	}
+	if s := c.Query("offset"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			return Params{}, ErrInvalidOffset
+		}
+		p.Offset = n
+	} else if s := c.Query("page"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n-1 > math.MaxInt/p.Limit {
+			return Params{}, ErrInvalidPage
+		}
+		p.Offset = (n - 1) * p.Limit
+	}
+	return p, nil
+}
+
+// Page is the JSON envelope of a list response.
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	Total      int    `json:"total"`
+	Limit      int    `json:"limit"`
+	Offset     int    `json:"offset"`
+	Page       int    `json:"page"`        // from 1; the page offset falls in
+	TotalPages int    `json:"total_pages"` // of limit items
+	NextCursor string `json:"next_cursor,omitempty"`
+
+	// keyset is set by NewKeysetPage: NextCursor resumes after the last
+	// item rather than at an offset.
+	keyset bool
+}
+
+// NewPage cuts the page p out of all, which must be in a stable order.
+func NewPage[T any](all []T, p Params) Page[T] {
+	page := cut(all, p.Offset, p.Limit)
+	if end := p.Offset + len(page.Items); end < len(all) {
+		page.NextCursor = EncodeCursor(Params{Limit: p.Limit, Offset: end})
+	}
+	return page
+}
+
+// NewKeysetPage cuts out of all, which must be ordered by ID, the page of
+// p.Limit items after the one whose ID is p.After, or starting at p.Offset
+// without one. id returns an item's ID. The item named need not be there
+// any more: the page starts at the first greater ID. NextCursor carries the
+// page's last ID, so adding or removing items before it doesn't move the
+// next page. Offset reports where the page starts now.
+func NewKeysetPage[T any](all []T, p Params, id func(T) string) Page[T] {
+	start := p.Offset
+	if p.After != "" {
+		start = sort.Search(len(all), func(i int) bool { return compareIDs(id(all[i]), p.After) > 0 })
+	}
+	page := cut(all, start, p.Limit)
+	page.keyset = true
+	if n := len(page.Items); n > 0 && start+n < len(all) {
+		page.NextCursor = EncodeCursor(Params{Limit: p.Limit, After: id(page.Items[n-1])})
+	}
+	return page
+}
+
+// compareIDs orders IDs as numbers when both are, as the examples' row IDs
+// are, and as strings otherwise.
+func compareIDs(a, b string) int {
+	x, errA := strconv.ParseInt(a, 10, 64)
+	y, errB := strconv.ParseInt(b, 10, 64)
+	if errA == nil && errB == nil {
+		return cmp.Compare(x, y)
+	}
+	return strings.Compare(a, b)
+}
+
+// cut is the page of limit items of all starting at offset, without a
+// NextCursor.
+func cut[T any](all []T, offset, limit int) Page[T] {
+	start := min(offset, len(all))
+	end := min(start+limit, len(all))
+	page := Page[T]{
+		Items:  all[start:end],
+		Total:  len(all),
+		Limit:  limit,
+		Offset: offset,
+	}
+	if limit > 0 {
+		page.Page = offset/limit + 1
+		page.TotalPages = (len(all) + limit - 1) / limit
+	}
+	if page.Items == nil {
+		page.Items = []T{}
+	}
+	return page
+}
+
+// Write sets the Link and X-Total-Count headers for page and sends it with
+// status 200: as JSON, or on routes with negotiate.Middleware in the format
+// the client asked for. A keyset page's next link carries its cursor; the
+// others go by offset.
+func Write[T any](c *gin.Context, page Page[T]) {
+	links := []string{link(c, Params{Limit: page.Limit}, "first")}
+	if page.Offset > 0 {
+		links = append(links, link(c, Params{Limit: page.Limit, Offset: max(page.Offset-page.Limit, 0)}, "prev"))
+	}
+	switch {
+	case page.NextCursor != "" && page.keyset:
+		links = append(links, cursorLink(c, page.NextCursor, page.Limit, "next"))
+	case page.NextCursor != "":
+		links = append(links, link(c, Params{Limit: page.Limit, Offset: page.Offset + page.Limit}, "next"))
+	}
+	c.Header("Link", strings.Join(links, ", "))
+	c.Header("X-Total-Count", strconv.Itoa(page.Total))
+	negotiate.Render(c, http.StatusOK, page)
+}
+
+// link builds an RFC 8288 link to page p of the current URL, keeping its
+// other query parameters.
+func link(c *gin.Context, p Params, rel string) string {
+	u := url.URL{Path: c.Request.URL.Path}
+	q := c.Request.URL.Query()
+	q.Del("cursor")
+	q.Set("limit", strconv.Itoa(p.Limit))
+	q.Set("offset", strconv.Itoa(p.Offset))
+	u.RawQuery = q.Encode()
+	return fmt.Sprintf("<%s>; rel=%q", u.String(), rel)
+}
+
+// cursorLink is like link, to the page cur starts.
+func cursorLink(c *gin.Context, cur string, limit int, rel string) string {
+	u := url.URL{Path: c.Request.URL.Path}
+	q := c.Request.URL.Query()
+	q.Del("offset")
+	q.Del("page")
+	q.Set("cursor", cur)
+	q.Set("limit", strconv.Itoa(limit))
+	u.RawQuery = q.Encode()
+	return fmt.Sprintf("<%s>; rel=%q", u.String(), rel)
+}
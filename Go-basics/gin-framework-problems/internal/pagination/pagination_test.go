@@ -0,0 +1,138 @@
+package pagination
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newContext is a gin context for a GET of target.
+func newContext(target string) (*gin.Context, *httptest.ResponseRecorder) {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, target, nil)
+	return c, w
+}
+
+// tamper is cur with its payload pointed at another offset, keeping the
+// signature.
+func tamper(cur string) string {
+	_, sig, _ := strings.Cut(cur, ".")
+	payload, _, _ := strings.Cut(EncodeCursor(Params{Limit: 3, Offset: 60}), ".")
+	return payload + "." + sig
+}
+
+func TestParseParams(t *testing.T) {
+	tests := []struct {
+		query string
+		want  Params
+		err   error
+	}{
+		{"", Params{Limit: DefaultLimit}, nil},
+		{"?limit=5&offset=10", Params{Limit: 5, Offset: 10}, nil},
+		{"?limit=1000", Params{Limit: MaxLimit}, nil},
+		{"?cursor=" + EncodeCursor(Params{Limit: 3, Offset: 6}) + "&offset=1", Params{Limit: 3, Offset: 6}, nil},
+		{"?cursor=" + EncodeCursor(Params{Limit: 3, After: "7"}) + "&limit=5", Params{Limit: 5, After: "7"}, nil},
+		{"?cursor=" + tamper(EncodeCursor(Params{Limit: 3, Offset: 6})), Params{}, ErrInvalidCursor},
+		{"?limit=0", Params{}, ErrInvalidLimit},
+		{"?limit=ten", Params{}, ErrInvalidLimit},
+		{"?offset=-1", Params{}, ErrInvalidOffset},
+		{"?cursor=not-base64!", Params{}, ErrInvalidCursor},
+		{"?cursor=" + EncodeCursor(Params{Limit: 0}), Params{}, ErrInvalidCursor},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			c, _ := newContext("/books" + tt.query)
+			got, err := ParseParams(c)
+			if err != tt.err || got != tt.want {
+				t.Errorf("ParseParams = %+v, %v; want %+v, %v", got, err, tt.want, tt.err)
+			}
+		})
+	}
+}
+
+func TestNewPage(t *testing.T) {
+	all := []int{1, 2, 3, 4, 5}
+	tests := []struct {
+		name  string
+		p     Params
+		items []int
+		next  bool
+	}{
+		{"first", Params{Limit: 2}, []int{1, 2}, true},
+		{"last", Params{Limit: 2, Offset: 4}, []int{5}, false},
+		{"exact end", Params{Limit: 5}, []int{1, 2, 3, 4, 5}, false},
+		{"past the end", Params{Limit: 2, Offset: 9}, []int{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := NewPage(all, tt.p)
+			if !slices.Equal(page.Items, tt.items) || page.Total != len(all) {
+				t.Errorf("items %v of %d, want %v of %d", page.Items, page.Total, tt.items, len(all))
+			}
+			if (page.NextCursor != "") != tt.next {
+				t.Fatalf("next cursor %q, want one: %v", page.NextCursor, tt.next)
+			}
+			if tt.next {
+				next, err := DecodeCursor(page.NextCursor)
+				if want := (Params{Limit: tt.p.Limit, Offset: tt.p.Offset + tt.p.Limit}); err != nil || next != want {
+					t.Errorf("next cursor decodes to %+v, %v; want %+v", next, err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestNewKeysetPage(t *testing.T) {
+	all := []string{"1", "2", "3", "9", "10", "11"}
+	id := func(s string) string { return s }
+	tests := []struct {
+		name  string
+		p     Params
+		items []string
+		next  string
+	}{
+		{"first", Params{Limit: 2}, []string{"1", "2"}, "2"},
+		{"after", Params{Limit: 2, After: "2"}, []string{"3", "9"}, "9"},
+		{"after a gone ID", Params{Limit: 2, After: "5"}, []string{"9", "10"}, "10"},
+		{"numeric order", Params{Limit: 3, After: "9"}, []string{"10", "11"}, ""},
+		{"past the end", Params{Limit: 2, After: "11"}, []string{}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := NewKeysetPage(all, tt.p, id)
+			if !slices.Equal(page.Items, tt.items) {
+				t.Errorf("items %v, want %v", page.Items, tt.items)
+			}
+			if page.NextCursor == "" {
+				if tt.next != "" {
+					t.Errorf("no next cursor, want one after %s", tt.next)
+				}
+				return
+			}
+			next, err := DecodeCursor(page.NextCursor)
+			if want := (Params{Limit: tt.p.Limit, After: tt.next}); err != nil || next != want {
+				t.Errorf("next cursor decodes to %+v, %v; want %+v", next, err, want)
+			}
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	c, w := newContext("/books?author=Austen&limit=2&offset=2")
+	Write(c, NewPage([]int{1, 2, 3, 4, 5}, Params{Limit: 2, Offset: 2}))
+
+	want := `</books?author=Austen&limit=2&offset=0>; rel="first", ` +
+		`</books?author=Austen&limit=2&offset=0>; rel="prev", ` +
+		`</books?author=Austen&limit=2&offset=4>; rel="next"`
+	if got := w.Header().Get("Link"); got != want {
+		t.Errorf("Link = %s\nwant %s", got, want)
+	}
+	if got := w.Header().Get("X-Total-Count"); got != "5" {
+		t.Errorf("X-Total-Count = %s, want 5", got)
+	}
+}
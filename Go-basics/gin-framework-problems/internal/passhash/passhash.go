@@ -0,0 +1,57 @@
+// Package passhash hashes passwords with bcrypt.
+//
+// Verify also accepts a password stored in plaintext before hashing was
+// introduced, comparing it in constant time and asking the caller to
+// replace it with a hash. Stores migrate that way, one login at a time.
+package passhash
+
+import (
+	"crypto/subtle"
+	"sync"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Cost is the bcrypt work factor of new hashes. Hashes made at a lower cost
+// are upgraded on the next successful Verify.
+const Cost = bcrypt.DefaultCost
+
+// MaxLength is the longest password bcrypt accepts, in bytes.
+const MaxLength = 72
+
+// Hash returns the bcrypt hash of password.
+func Hash(password string) (string, error) {
+	b, err := bcrypt.GenerateFromPassword([]byte(password), Cost)
+	return string(b), err
+}
+
+// IsHash reports whether stored is a bcrypt hash rather than plaintext.
+func IsHash(stored string) bool {
+	_, err := bcrypt.Cost([]byte(stored))
+	return err == nil
+}
+
+// Verify reports whether password matches stored, and whether stored should
+// be replaced with a fresh Hash because it is plaintext or too cheap.
+func Verify(stored, password string) (ok, rehash bool) {
+	cost, err := bcrypt.Cost([]byte(stored))
+	if err != nil {
+		return subtle.ConstantTimeCompare([]byte(stored), []byte(password)) == 1, true
+	}
+	if bcrypt.CompareHashAndPassword([]byte(stored), []byte(password)) != nil {
+		return false, false
+	}
+	return true, cost < Cost
+}
+
+var dummy = sync.OnceValue(func() []byte {
+	b, _ := bcrypt.GenerateFromPassword([]byte("dummy password"), Cost)
+	return b
+})
+
+// VerifyNone takes as long as Verify against a real hash, and fails. Call
+// it when the username is unknown, so response times don't tell which
+// usernames exist.
+func VerifyNone(password string) {
+	bcrypt.CompareHashAndPassword(dummy(), []byte(password))
+}
@@ -0,0 +1,58 @@
+package passhash
+
+import (
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestVerify(t *testing.T) {
+	hash, err := Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cheap, err := bcrypt.GenerateFromPassword([]byte("correct horse"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		stored     string
+		password   string
+		ok, rehash bool
+	}{
+		{"hash", hash, "correct horse", true, false},
+		{"hash, wrong password", hash, "wrong horse", false, false},
+		{"cheap hash", string(cheap), "correct horse", true, true},
+		{"cheap hash, wrong password", string(cheap), "wrong horse", false, false},
+		{"plaintext", "correct horse", "correct horse", true, true},
+		{"plaintext, wrong password", "correct horse", "wrong horse", false, true},
+		{"empty", "", "", true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, rehash := Verify(tt.stored, tt.password)
+			if ok != tt.ok || rehash != tt.rehash {
+				t.Errorf("Verify = %v, %v; want %v, %v", ok, rehash, tt.ok, tt.rehash)
+			}
+		})
+	}
+}
+
+func TestHash(t *testing.T) {
+	a, _ := Hash("secret")
+	b, _ := Hash("secret")
+	if a == b {
+		t.Error("two hashes of one password are equal, want different salts")
+	}
+	if !IsHash(a) || IsHash("secret") {
+		t.Errorf("IsHash(hash) = %v, IsHash(plaintext) = %v", IsHash(a), IsHash("secret"))
+	}
+	if cost, _ := bcrypt.Cost([]byte(a)); cost != Cost {
+		t.Errorf("cost %d, want %d", cost, Cost)
+	}
+	if _, err := Hash(string(make([]byte, MaxLength+1))); err == nil {
+		t.Errorf("hashed a password of %d bytes, want an error", MaxLength+1)
+	}
+}
@@ -0,0 +1,130 @@
+// Package profiling serves the runtime's own diagnostics: net/http/pprof
+// profiles, expvar variables, GC and memory statistics and a goroutine dump.
+// Profiles expose a lot about the process, so mount Routes behind
+// authentication, and only when someone is profiling.
+//
+//	go tool pprof -http=: 'http://admin:<password>@localhost:8080/debug/pprof/profile?seconds=20'
+//	curl -u admin:<password> localhost:8080/debug/gc
+package profiling
+
+import (
+	"context"
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"runtime/debug"
+	rpprof "runtime/pprof"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+)
+
+var (
+	started = time.Now()
+
+	requests = expvar.NewMap("http_requests") // by status class
+	inFlight = expvar.NewInt("http_requests_in_flight")
+)
+
+func init() {
+	expvar.Publish("goroutines", expvar.Func(func() any { return runtime.NumGoroutine() }))
+	expvar.Publish("uptime_seconds", expvar.Func(func() any { return time.Since(started).Seconds() }))
+}
+
+// Middleware counts requests in the expvar variables http_requests and
+// http_requests_in_flight.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		inFlight.Add(1)
+		defer inFlight.Add(-1)
+		c.Next()
+		requests.Add(strconv.Itoa(c.Writer.Status()/100)+"xx", 1)
+	}
+}
+
+// Routes mounts the endpoints on rg, which must be the /debug group, since
+// pprof finds profiles by their path under /debug/pprof/:
+//
+//	GET /debug/pprof/            index of profiles; /debug/pprof/heap etc.
+//	GET /debug/pprof/profile     CPU profile over ?seconds= (default 30)
+//	GET /debug/pprof/trace       execution trace over ?seconds= (default 1)
+//	GET /debug/vars              expvar variables as JSON
+//	GET /debug/gc                GC and heap statistics
+//	GET /debug/goroutines        every goroutine's stack; ?debug=1 groups them
+func Routes(rg *gin.RouterGroup) {
+	rg.GET("/pprof/", gin.WrapF(pprof.Index))
+	rg.GET("/pprof/cmdline", gin.WrapF(pprof.Cmdline))
+	rg.GET("/pprof/profile", middleware.WithoutTimeout(), recording(pprof.Profile, 30))
+	rg.GET("/pprof/trace", middleware.WithoutTimeout(), recording(pprof.Trace, 1))
+	rg.GET("/pprof/symbol", gin.WrapF(pprof.Symbol))
+	rg.POST("/pprof/symbol", gin.WrapF(pprof.Symbol))
+	rg.GET("/pprof/:name", gin.WrapF(pprof.Index))
+	rg.GET("/vars", gin.WrapH(expvar.Handler()))
+	rg.GET("/gc", gcStats)
+	rg.GET("/goroutines", goroutines)
+}
+
+// recording serves a profile that records for ?seconds= before answering.
+// pprof refuses a duration past the server's write timeout, so this pushes
+// the deadline out for the one response and hides the server's setting.
+func recording(h http.HandlerFunc, defaultSeconds float64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sec, err := strconv.ParseFloat(c.Query("seconds"), 64)
+		if err != nil || sec <= 0 {
+			sec = defaultSeconds // pprof itself reports a malformed value
+		}
+		d := time.Duration(sec*float64(time.Second)) + 10*time.Second
+		if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Now().Add(d)); err == nil {
+			ctx := context.WithValue(c.Request.Context(), http.ServerContextKey, nil)
+			c.Request = c.Request.WithContext(ctx)
+		}
+		h(c.Writer, c.Request)
+	}
+}
+
+func gcStats(c *gin.Context) {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	gc := debug.GCStats{PauseQuantiles: make([]time.Duration, 5)}
+	debug.ReadGCStats(&gc)
+
+	quantiles := make([]string, len(gc.PauseQuantiles))
+	for i, q := range gc.PauseQuantiles {
+		quantiles[i] = q.String()
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"num_gc":             gc.NumGC,
+		"last_gc":            gc.LastGC,
+		"pause_total":        gc.PauseTotal.String(),
+		"pause_quantiles":    quantiles, // min, 25%, 50%, 75%, max
+		"gc_cpu_fraction":    ms.GCCPUFraction,
+		"next_gc_bytes":      ms.NextGC,
+		"heap_alloc_bytes":   ms.HeapAlloc,
+		"heap_sys_bytes":     ms.HeapSys,
+		"heap_objects":       ms.HeapObjects,
+		"total_alloc_bytes":  ms.TotalAlloc,
+		"sys_bytes":          ms.Sys,
+		"goroutines":         runtime.NumGoroutine(),
+		"gomaxprocs":         runtime.GOMAXPROCS(0),
+		"memory_limit_bytes": debug.SetMemoryLimit(-1), // -1 reads it without changing it
+		"stack_inuse_bytes":  ms.StackInuse,
+		"go_version":         runtime.Version(),
+		"uptime_seconds":     time.Since(started).Seconds(),
+	})
+}
+
+// goroutines dumps every goroutine's stack as text, or with ?debug=1 groups
+// identical stacks with a count.
+func goroutines(c *gin.Context) {
+	level := 2
+	if c.Query("debug") == "1" {
+		level = 1
+	}
+	c.Header("Content-Type", "text/plain; charset=utf-8")
+	c.Status(http.StatusOK)
+	rpprof.Lookup("goroutine").WriteTo(c.Writer, level)
+}
@@ -0,0 +1,190 @@
+// Package query reads the query string of list routes into typed values:
+// the page, an order out of the fields the route allows, and filters bound
+// into a struct. The list routes of the users, books and files examples all
+// use it, so they take the same parameters and reject bad ones the same way.
+//
+//	type bookFilters struct {
+//		Author  string `form:"author"`
+//		YearGTE int    `form:"year_gte" binding:"omitempty,min=0"`
+//	}
+//
+//	var f bookFilters
+//	req, err := query.Parse(c, bookSorts, "title", &f)
+//	...
+//	bookSorts.Apply(list, req.Sort, compareIDs)
+//	pagination.Write(c, pagination.NewPage(list, req.Page))
+package query
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/apperror"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/pagination"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/validation"
+)
+
+// Request is what a list route was asked for, besides its filters.
+type Request struct {
+	Page pagination.Params
+	Sort Sort
+}
+
+// Sort orders a listing by Field, descending when Desc. The zero Sort keeps
+// the order the store lists things in.
+type Sort struct {
+	Field string
+	Desc  bool
+}
+
+// String is the ?sort= value for s.
+func (s Sort) String() string {
+	if s.Desc {
+		return "-" + s.Field
+	}
+	return s.Field
+}
+
+// Sorts are the fields a listing may be ordered by, with how each compares
+// two items. A nil Sorts allows no ?sort= at all.
+type Sorts[T any] map[string]func(a, b T) int
+
+// Apply sorts items by s in place, breaking ties with tiebreak, which must
+// tell any two items apart, so the order is the same on every call and
+// pages don't overlap. The zero Sort leaves items as they are.
+func (sorts Sorts[T]) Apply(items []T, s Sort, tiebreak func(a, b T) int) {
+	compare, ok := sorts[s.Field]
+	if !ok {
+		return
+	}
+	slices.SortFunc(items, func(a, b T) int {
+		n := compare(a, b)
+		if s.Desc {
+			n = -n
+		}
+		if n == 0 {
+			n = tiebreak(a, b)
+		}
+		return n
+	})
+}
+
+// Parse reads a list request:
+//
+//   - ?limit, ?offset, ?page and ?cursor, as pagination.ParseParams does;
+//   - ?sort=field, or -field for descending, where field is one of sorts,
+//     else defaultSort, which may be empty for the store's order;
+//   - filters, unless nil: a pointer to a struct whose fields' form tags
+//     name their parameters. String, int, bool and []string fields (a
+//     comma-separated list) are filled from parameters that are set and
+//     keep their value otherwise, so set defaults before calling. Pointers
+//     to those stay nil unless their parameter is set. binding
+//     tags are checked afterwards, as for a request body.
+//
+// A bad page is a 400 with pagination's message. Bad sort or filter values
+// are a 400 "invalid query parameters" listing each under details.
+func Parse[T any](c *gin.Context, sorts Sorts[T], defaultSort string, filters any) (Request, error) {
+	p, err := pagination.ParseParams(c)
+	if err != nil {
+		return Request{}, apperror.BadRequest(err.Error())
+	}
+	req := Request{Page: p}
+
+	details := map[string]string{}
+	s := c.DefaultQuery("sort", defaultSort)
+	if s != "" {
+		field, desc := strings.CutPrefix(s, "-")
+		if _, ok := sorts[field]; ok {
+			req.Sort = Sort{Field: field, Desc: desc}
+		} else {
+			details["sort"] = sortHint(sorts)
+		}
+	}
+	if filters != nil {
+		bind(c, filters, details)
+		if len(details) == 0 {
+			if fields, ok := validation.Translate(c, binding.Validator.ValidateStruct(filters)); ok {
+				maps.Copy(details, fields)
+			}
+		}
+	}
+	if len(details) > 0 {
+		return Request{}, apperror.Validation("invalid query parameters", details)
+	}
+	return req, nil
+}
+
+// sortHint lists the fields sorts allows, as the detail for a bad ?sort=.
+func sortHint[T any](sorts Sorts[T]) string {
+	fields := slices.Sorted(maps.Keys(sorts))
+	switch len(fields) {
+	case 0:
+		return "is not supported here"
+	case 1:
+		return "must be " + fields[0] + ", optionally prefixed with -"
+	}
+	return "must be " + strings.Join(fields[:len(fields)-1], ", ") + " or " + fields[len(fields)-1] +
+		", optionally prefixed with -"
+}
+
+// bind fills the fields of the struct dst points to from the parameters
+// their form tags name, noting values that don't parse in details.
+func bind(c *gin.Context, dst any, details map[string]string) {
+	v := reflect.ValueOf(dst).Elem()
+	t := v.Type()
+	for i := range t.NumField() {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("form"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		s := c.Query(name)
+		if s == "" {
+			continue
+		}
+		field := v.Field(i)
+		if field.Kind() == reflect.Pointer {
+			ptr := reflect.New(f.Type.Elem())
+			if set(ptr.Elem(), s, name, details) {
+				field.Set(ptr)
+			}
+			continue
+		}
+		set(field, s, name, details)
+	}
+}
+
+// set parses s into field, reporting whether it could. A value that doesn't
+// parse is noted in details under name.
+func set(field reflect.Value, s, name string, details map[string]string) bool {
+	switch {
+	case field.Kind() == reflect.String:
+		field.SetString(s)
+	case field.Kind() == reflect.Int:
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			details[name] = "must be an integer"
+			return false
+		}
+		field.SetInt(int64(n))
+	case field.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			details[name] = "must be true or false"
+			return false
+		}
+		field.SetBool(b)
+	case field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.String:
+		field.Set(reflect.ValueOf(strings.Split(s, ",")))
+	default:
+		panic(fmt.Sprintf("query: filter %s: unsupported type %s", name, field.Type()))
+	}
+	return true
+}
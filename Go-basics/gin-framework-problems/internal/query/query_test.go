@@ -0,0 +1,143 @@
+package query
+
+import (
+	"errors"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/apperror"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/pagination"
+)
+
+type item struct {
+	ID   string
+	Name string
+	Size int
+}
+
+var itemSorts = Sorts[item]{
+	"name": func(a, b item) int { return strings.Compare(a.Name, b.Name) },
+	"size": func(a, b item) int { return a.Size - b.Size },
+}
+
+type itemFilters struct {
+	Name    string   `form:"name"`
+	MinSize int      `form:"min_size" binding:"omitempty,min=1"`
+	Kind    string   `form:"kind" binding:"omitempty,oneof=doc image"`
+	Shared  bool     `form:"shared"`
+	Tags    []string `form:"tags"`
+	Overdue *bool    `form:"overdue"`
+}
+
+// newContext is a gin context for a GET of target.
+func newContext(target string) *gin.Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, target, nil)
+	return c
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		target  string
+		want    Request
+		details []string // the parameters refused, if any
+	}{
+		{"/", Request{Page: pagination.Params{Limit: pagination.DefaultLimit}, Sort: Sort{Field: "name"}}, nil},
+		{"/?sort=-size&limit=5&page=2", Request{Page: pagination.Params{Limit: 5, Offset: 5}, Sort: Sort{Field: "size", Desc: true}}, nil},
+		{"/?limit=1000", Request{Page: pagination.Params{Limit: pagination.MaxLimit}, Sort: Sort{Field: "name"}}, nil},
+		{"/?sort=id", Request{}, []string{"sort"}},
+		{"/?min_size=big&shared=maybe&sort=-", Request{}, []string{"min_size", "shared", "sort"}},
+		{"/?min_size=-1", Request{}, []string{"min_size"}},
+		{"/?kind=video", Request{}, []string{"kind"}},
+		{"/?overdue=soon", Request{}, []string{"overdue"}},
+	}
+	for _, tt := range tests {
+		var f itemFilters
+		got, err := Parse(newContext(tt.target), itemSorts, "name", &f)
+		if tt.details == nil {
+			if err != nil || got != tt.want {
+				t.Errorf("%s: %+v, %v; want %+v", tt.target, got, err, tt.want)
+			}
+			continue
+		}
+		var e *apperror.Error
+		if !errors.As(err, &e) || e.Status != http.StatusBadRequest {
+			t.Errorf("%s: %v, want a 400", tt.target, err)
+			continue
+		}
+		details, _ := e.Details.(map[string]string)
+		if keys := slices.Sorted(maps.Keys(details)); !slices.Equal(keys, tt.details) {
+			t.Errorf("%s: details %v, want ones for %v", tt.target, details, tt.details)
+		}
+	}
+}
+
+func TestParseBadPage(t *testing.T) {
+	_, err := Parse[item](newContext("/?limit=-1"), nil, "", nil)
+	var e *apperror.Error
+	if !errors.As(err, &e) || e.Status != http.StatusBadRequest || e.Details != nil {
+		t.Errorf("bad limit: %v, want a plain 400", err)
+	}
+}
+
+func TestParseFilters(t *testing.T) {
+	f := itemFilters{Name: "default", MinSize: 3}
+	_, err := Parse(newContext("/?name=report&shared=true&tags=a,b&overdue=false"), itemSorts, "", &f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Name != "report" || f.MinSize != 3 || !f.Shared || !slices.Equal(f.Tags, []string{"a", "b"}) {
+		t.Errorf("filters = %+v", f)
+	}
+	if f.Overdue == nil || *f.Overdue {
+		t.Errorf("overdue = %v, want false", f.Overdue)
+	}
+
+	f = itemFilters{}
+	if _, err := Parse(newContext("/"), itemSorts, "", &f); err != nil || f.Overdue != nil {
+		t.Errorf("without ?overdue=: %v, %v; want nil", f.Overdue, err)
+	}
+}
+
+func TestNoSorts(t *testing.T) {
+	_, err := Parse[item](newContext("/?sort=name"), nil, "", nil)
+	var e *apperror.Error
+	if !errors.As(err, &e) || e.Details.(map[string]string)["sort"] != "is not supported here" {
+		t.Errorf("sort on an unsortable route: %v", err)
+	}
+}
+
+func TestApply(t *testing.T) {
+	items := []item{{"3", "b", 2}, {"1", "a", 2}, {"2", "c", 1}}
+	byID := func(a, b item) int { return strings.Compare(a.ID, b.ID) }
+	ids := func() string {
+		var s []string
+		for _, it := range items {
+			s = append(s, it.ID)
+		}
+		return strings.Join(s, ",")
+	}
+
+	itemSorts.Apply(items, Sort{}, byID)
+	if got := ids(); got != "3,1,2" {
+		t.Errorf("zero Sort: %s, want the order unchanged", got)
+	}
+	itemSorts.Apply(items, Sort{Field: "size"}, byID)
+	if got := ids(); got != "2,1,3" {
+		t.Errorf("size: %s, want 2,1,3", got)
+	}
+	// ties are broken by ID either way
+	itemSorts.Apply(items, Sort{Field: "size", Desc: true}, byID)
+	if got := ids(); got != "1,3,2" {
+		t.Errorf("-size: %s, want 1,3,2", got)
+	}
+	if s := (Sort{Field: "size", Desc: true}).String(); s != "-size" {
+		t.Errorf("String() = %s", s)
+	}
+}
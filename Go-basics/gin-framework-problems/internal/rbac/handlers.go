@@ -0,0 +1,119 @@
+package rbac
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/apperror"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/audit"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+)
+
+// Routes mounts the admin API on g:
+//
+//	GET    /                               every role, and the permissions the examples check
+//	GET    /:role
+//	PUT    /:role                          create or replace {permissions}
+//	DELETE /:role
+//	POST   /:role/permissions              grant {permission}
+//	DELETE /:role/permissions/:permission  revoke
+func (p *Policy) Routes(g gin.IRouter) {
+	g.GET("", p.list)
+	g.GET("/:role", middleware.Handle(p.get))
+	g.PUT("/:role", middleware.Handle(p.put))
+	g.DELETE("/:role", middleware.Handle(p.remove))
+	g.POST("/:role/permissions", middleware.Handle(p.grant))
+	g.DELETE("/:role/permissions/:permission", middleware.Handle(p.revoke))
+}
+
+var errRoleNotFound = apperror.NotFound("role not found")
+
+func (p *Policy) list(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"roles": p.List(), "permissions": Permissions})
+}
+
+func (p *Policy) get(c *gin.Context) error {
+	r, ok := p.Get(c.Param("role"))
+	if !ok {
+		return errRoleNotFound
+	}
+	c.JSON(http.StatusOK, r)
+	return nil
+}
+
+func (p *Policy) put(c *gin.Context) error {
+	var req struct {
+		Permissions []string `json:"permissions" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return middleware.BadInput(c, err)
+	}
+	_, existed := p.Get(c.Param("role"))
+	r, err := p.Set(c.Param("role"), req.Permissions)
+	if err != nil {
+		return apperror.BadRequest(err.Error())
+	}
+	recordChange(c, r.Name, "set", strings.Join(r.Permissions, ","))
+	status := http.StatusOK
+	if !existed {
+		status = http.StatusCreated
+	}
+	c.JSON(status, r)
+	return nil
+}
+
+func (p *Policy) remove(c *gin.Context) error {
+	if !p.Delete(c.Param("role")) {
+		return errRoleNotFound
+	}
+	recordChange(c, c.Param("role"), "delete", "")
+	c.Status(http.StatusNoContent)
+	return nil
+}
+
+func (p *Policy) grant(c *gin.Context) error {
+	var req struct {
+		Permission string `json:"permission" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return middleware.BadInput(c, err)
+	}
+	r, err := p.Grant(c.Param("role"), req.Permission)
+	if err != nil {
+		return changeError(err)
+	}
+	recordChange(c, r.Name, "grant", req.Permission)
+	c.JSON(http.StatusOK, r)
+	return nil
+}
+
+func (p *Policy) revoke(c *gin.Context) error {
+	r, err := p.Revoke(c.Param("role"), c.Param("permission"))
+	if err != nil {
+		return changeError(err)
+	}
+	recordChange(c, r.Name, "revoke", c.Param("permission"))
+	c.JSON(http.StatusOK, r)
+	return nil
+}
+
+// changeError is the answer to an error granting or revoking: 404 for a
+// role that doesn't exist, 400 for anything else.
+func changeError(err error) error {
+	if errors.Is(err, ErrUnknownRole) {
+		return apperror.NotFound(err.Error())
+	}
+	return apperror.BadRequest(err.Error())
+}
+
+// recordChange writes a successful change to role to the audit log.
+func recordChange(c *gin.Context, role, change, permissions string) {
+	details := map[string]string{"change": change}
+	if permissions != "" {
+		details["permissions"] = permissions
+	}
+	audit.Record(c, audit.Event{Action: audit.RoleUpdate, Outcome: audit.Success, Target: role, Details: details})
+}
@@ -0,0 +1,188 @@
+// Package rbac maps roles to the permissions they grant. Routes ask for a
+// permission with middleware.RequirePermission rather than for a role, so
+// what a role may do can change without touching the routes.
+//
+// A permission is "resource:action", like "users:delete". "users:*" grants
+// every action on users and "*" grants everything.
+//
+// Roles start from the roles section of the config and can be changed at
+// runtime through the admin API NewEngine mounts at /admin/roles.
+package rbac
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The permissions the examples check.
+const (
+	UsersRead       = "users:read"
+	UsersWrite      = "users:write"
+	UsersDelete     = "users:delete"
+	OrdersRead      = "orders:read"
+	OrdersShip      = "orders:ship"
+	LinksManage     = "links:manage"
+	AuditRead       = "audit:read"
+	ReviewsModerate = "reviews:moderate"
+	BooksManage     = "books:manage"
+	LoansManage     = "loans:manage"
+	FilesManage     = "files:manage"
+)
+
+// Permissions describes each permission the examples check, for the admin
+// API. Roles may hold others too.
+var Permissions = map[string]string{
+	UsersRead:       "list every account",
+	UsersWrite:      "change an account's role",
+	UsersDelete:     "delete, restore and purge accounts",
+	OrdersRead:      "see every customer's orders",
+	OrdersShip:      "mark paid orders shipped",
+	LinksManage:     "see and change every user's short links",
+	AuditRead:       "read the audit log",
+	ReviewsModerate: "delete other users' book reviews",
+	BooksManage:     "list deleted books",
+	LoansManage:     "see every loan and return any book",
+	FilesManage:     "delete anyone's uploads",
+}
+
+var (
+	ErrInvalidRole       = errors.New("role name must not be empty or contain spaces or /")
+	ErrInvalidPermission = errors.New(`permission must be "resource:action", "resource:*" or "*"`)
+	ErrUnknownRole       = errors.New("role not found")
+)
+
+// Role is a named set of permissions.
+type Role struct {
+	Name        string    `json:"name"`
+	Permissions []string  `json:"permissions"` // sorted, without duplicates
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Policy holds the roles. It is safe for concurrent use.
+type Policy struct {
+	mu    sync.RWMutex
+	roles map[string]Role
+}
+
+func New() *Policy {
+	return &Policy{roles: map[string]Role{}}
+}
+
+// Default is the policy the examples and the admin API share.
+var Default = New()
+
+// ValidPermission reports whether p is "*" or "resource:action", where
+// action may be "*".
+func ValidPermission(p string) bool {
+	if p == "*" {
+		return true
+	}
+	resource, action, ok := strings.Cut(p, ":")
+	return ok && resource != "" && action != "" &&
+		!strings.ContainsAny(resource, "*/ ") && !strings.ContainsAny(action, ":/ ")
+}
+
+func validRole(name string) bool {
+	return name != "" && !strings.ContainsAny(name, "/ ")
+}
+
+// Set creates or replaces a role.
+func (p *Policy) Set(name string, perms []string) (Role, error) {
+	if !validRole(name) {
+		return Role{}, ErrInvalidRole
+	}
+	for _, perm := range perms {
+		if !ValidPermission(perm) {
+			return Role{}, ErrInvalidPermission
+		}
+	}
+	r := Role{Name: name, Permissions: normalize(perms), UpdatedAt: time.Now().UTC()}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.roles[name] = r
+	return r, nil
+}
+
+// Grant adds perm to an existing role.
+func (p *Policy) Grant(name, perm string) (Role, error) {
+	if !ValidPermission(perm) {
+		return Role{}, ErrInvalidPermission
+	}
+	return p.update(name, func(perms []string) []string { return append(perms, perm) })
+}
+
+// Revoke takes perm away from an existing role. Revoking a permission the
+// role doesn't hold is not an error.
+func (p *Policy) Revoke(name, perm string) (Role, error) {
+	return p.update(name, func(perms []string) []string {
+		return slices.DeleteFunc(perms, func(s string) bool { return s == perm })
+	})
+}
+
+func (p *Policy) update(name string, fn func([]string) []string) (Role, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	r, ok := p.roles[name]
+	if !ok {
+		return Role{}, ErrUnknownRole
+	}
+	r.Permissions = normalize(fn(slices.Clone(r.Permissions)))
+	r.UpdatedAt = time.Now().UTC()
+	p.roles[name] = r
+	return r, nil
+}
+
+func (p *Policy) Get(name string) (Role, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	r, ok := p.roles[name]
+	return r, ok
+}
+
+// List returns every role, sorted by name.
+func (p *Policy) List() []Role {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	list := make([]Role, 0, len(p.roles))
+	for _, r := range p.roles {
+		list = append(list, r)
+	}
+	slices.SortFunc(list, func(a, b Role) int { return strings.Compare(a.Name, b.Name) })
+	return list
+}
+
+// Delete removes a role and reports whether it existed. Users left with it
+// keep the name but get no permissions.
+func (p *Policy) Delete(name string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, ok := p.roles[name]
+	delete(p.roles, name)
+	return ok
+}
+
+// Allowed reports whether role grants perm, directly or through a wildcard.
+// Unknown roles grant nothing.
+func (p *Policy) Allowed(role, perm string) bool {
+	resource, _, _ := strings.Cut(perm, ":")
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	for _, held := range p.roles[role].Permissions {
+		if held == perm || held == "*" || held == resource+":*" {
+			return true
+		}
+	}
+	return false
+}
+
+func normalize(perms []string) []string {
+	out := slices.Clone(perms)
+	if out == nil {
+		out = []string{}
+	}
+	slices.Sort(out)
+	return slices.Compact(out)
+}
@@ -0,0 +1,157 @@
+package rbac
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+)
+
+func TestValidPermission(t *testing.T) {
+	tests := []struct {
+		perm string
+		want bool
+	}{
+		{"*", true},
+		{"users:read", true},
+		{"users:*", true},
+		{"", false},
+		{"users", false},
+		{"users:", false},
+		{":read", false},
+		{"*:read", false},
+		{"users:read:all", false},
+		{"users/admin:read", false},
+		{"users: read", false},
+	}
+	for _, tt := range tests {
+		if got := ValidPermission(tt.perm); got != tt.want {
+			t.Errorf("ValidPermission(%q) = %v, want %v", tt.perm, got, tt.want)
+		}
+	}
+}
+
+func TestAllowed(t *testing.T) {
+	p := New()
+	p.Set("admin", []string{"*"})
+	p.Set("support", []string{"users:*", "orders:read"})
+	p.Set("user", nil)
+	tests := []struct {
+		role, perm string
+		want       bool
+	}{
+		{"admin", UsersDelete, true},
+		{"admin", "anything:at_all", true},
+		{"support", UsersDelete, true},
+		{"support", OrdersRead, true},
+		{"support", OrdersShip, false},
+		{"user", UsersRead, false},
+		{"nobody", UsersRead, false},
+	}
+	for _, tt := range tests {
+		if got := p.Allowed(tt.role, tt.perm); got != tt.want {
+			t.Errorf("Allowed(%q, %q) = %v, want %v", tt.role, tt.perm, got, tt.want)
+		}
+	}
+}
+
+func TestChanges(t *testing.T) {
+	p := New()
+	if _, err := p.Set("has space", nil); err != ErrInvalidRole {
+		t.Errorf("Set with an invalid name: err = %v, want %v", err, ErrInvalidRole)
+	}
+	if _, err := p.Set("support", []string{"users"}); err != ErrInvalidPermission {
+		t.Errorf("Set with an invalid permission: err = %v, want %v", err, ErrInvalidPermission)
+	}
+
+	r, err := p.Set("support", []string{"users:read", "orders:read", "users:read"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"orders:read", "users:read"}; !slices.Equal(r.Permissions, want) {
+		t.Errorf("Set: permissions = %v, want %v", r.Permissions, want)
+	}
+	r, err = p.Grant("support", OrdersShip)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Contains(r.Permissions, OrdersShip) || !p.Allowed("support", OrdersShip) {
+		t.Errorf("Grant: permissions = %v, want %s among them", r.Permissions, OrdersShip)
+	}
+	r, err = p.Revoke("support", "orders:read")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"orders:ship", "users:read"}; !slices.Equal(r.Permissions, want) {
+		t.Errorf("Revoke: permissions = %v, want %v", r.Permissions, want)
+	}
+	if _, err := p.Revoke("support", "orders:read"); err != nil {
+		t.Errorf("revoking a permission the role lacks: %v", err)
+	}
+
+	if _, err := p.Grant("nobody", UsersRead); err != ErrUnknownRole {
+		t.Errorf("Grant to an unknown role: err = %v, want %v", err, ErrUnknownRole)
+	}
+	if _, err := p.Grant("support", "users"); err != ErrInvalidPermission {
+		t.Errorf("Grant of an invalid permission: err = %v, want %v", err, ErrInvalidPermission)
+	}
+
+	p.Set("admin", []string{"*"})
+	var names []string
+	for _, r := range p.List() {
+		names = append(names, r.Name)
+	}
+	if want := []string{"admin", "support"}; !slices.Equal(names, want) {
+		t.Errorf("List = %v, want %v", names, want)
+	}
+	if !p.Delete("support") || p.Delete("support") {
+		t.Error("Delete should report true once, then false")
+	}
+	if p.Allowed("support", UsersRead) {
+		t.Error("a deleted role still grants its permissions")
+	}
+}
+
+func TestRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	p := New()
+	p.Set("admin", []string{"*"})
+	r := gin.New()
+	r.Use(middleware.ErrorHandler(slog.New(slog.DiscardHandler)))
+	p.Routes(r.Group("/roles"))
+
+	tests := []struct {
+		method, path, body string
+		status             int
+	}{
+		{http.MethodGet, "/roles", "", http.StatusOK},
+		{http.MethodGet, "/roles/admin", "", http.StatusOK},
+		{http.MethodGet, "/roles/support", "", http.StatusNotFound},
+		{http.MethodPut, "/roles/support", `{"permissions":["users:read"]}`, http.StatusCreated},
+		{http.MethodPut, "/roles/support", `{"permissions":["users:read","orders:read"]}`, http.StatusOK},
+		{http.MethodPut, "/roles/support", `{"permissions":["users"]}`, http.StatusBadRequest},
+		{http.MethodPut, "/roles/support", `{}`, http.StatusBadRequest},
+		{http.MethodPost, "/roles/support/permissions", `{"permission":"orders:ship"}`, http.StatusOK},
+		{http.MethodPost, "/roles/support/permissions", `{"permission":"orders"}`, http.StatusBadRequest},
+		{http.MethodPost, "/roles/nobody/permissions", `{"permission":"orders:ship"}`, http.StatusNotFound},
+		{http.MethodDelete, "/roles/support/permissions/orders:read", "", http.StatusOK},
+		{http.MethodDelete, "/roles/nobody/permissions/orders:read", "", http.StatusNotFound},
+		{http.MethodDelete, "/roles/support", "", http.StatusNoContent},
+		{http.MethodDelete, "/roles/support", "", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tt.status {
+			t.Errorf("%s %s %s: status = %d, want %d; body: %s", tt.method, tt.path, tt.body, w.Code, tt.status, w.Body)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule says when a task runs.
+type Schedule interface {
+	// Next is the first time after t the task runs.
+	Next(t time.Time) time.Time
+	// String is the schedule as ParseSchedule reads it.
+	String() string
+}
+
+// Every runs a task every d, counted from when its last tick came.
+func Every(d time.Duration) Schedule {
+	return interval(d)
+}
+
+type interval time.Duration
+
+func (d interval) Next(t time.Time) time.Time { return t.Add(time.Duration(d)) }
+func (d interval) String() string             { return time.Duration(d).String() }
+
+// descriptors are the cron shorthands ParseSchedule knows.
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// ParseSchedule reads when a task should run, in UTC:
+//
+//   - a duration such as 10m, or @every 10m, for an interval;
+//   - a cron expression of five fields, minute (0-59), hour (0-23), day of
+//     the month (1-31), month (1-12) and day of the week (0-6, or 7, from
+//     Sunday), each *, a number, a range such as 1-5 or a list of those,
+//     any of them with a /step, as in "*/15 * * * *" or "30 2 * * 1-5";
+//   - @hourly, @daily (or @midnight), @weekly, @monthly or @yearly.
+//
+// As in cron, a day of the month and a day of the week that are both
+// restricted match either, so "0 0 1 * 1" runs on the 1st and on Mondays.
+func ParseSchedule(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if rest, ok := strings.CutPrefix(spec, "@every "); ok {
+		return parseInterval(spec, rest)
+	}
+	if d, err := time.ParseDuration(spec); err == nil {
+		return parseInterval(spec, d.String())
+	}
+	expr := spec
+	if strings.HasPrefix(spec, "@") {
+		var ok bool
+		if expr, ok = descriptors[spec]; !ok {
+			return nil, fmt.Errorf("scheduler: unknown schedule %q", spec)
+		}
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("scheduler: %q: want 5 fields (minute hour day month weekday), got %d", spec, len(fields))
+	}
+	c := cron{spec: spec}
+	for i, f := range []struct {
+		name     string
+		min, max int
+		set      *uint64
+	}{
+		{"minute", 0, 59, &c.minute},
+		{"hour", 0, 23, &c.hour},
+		{"day of month", 1, 31, &c.dom},
+		{"month", 1, 12, &c.month},
+		{"day of week", 0, 7, &c.dow},
+	} {
+		set, err := parseField(fields[i], f.min, f.max)
+		if err != nil {
+			return nil, fmt.Errorf("scheduler: %q: %s: %w", spec, f.name, err)
+		}
+		*f.set = set
+	}
+	// 7 is Sunday too
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1
+	}
+	c.domAny, c.dowAny = fields[2] == "*", fields[4] == "*"
+	if c.Next(time.Now()).IsZero() {
+		return nil, fmt.Errorf("scheduler: %q never runs", spec)
+	}
+	return c, nil
+}
+
+func parseInterval(spec, value string) (Schedule, error) {
+	d, err := time.ParseDuration(strings.TrimSpace(value))
+	if err != nil || d <= 0 {
+		return nil, fmt.Errorf("scheduler: %q: interval must be a positive duration", spec)
+	}
+	return Every(d), nil
+}
+
+// parseField reads one cron field into a set of the values it allows, bit
+// n standing for n.
+func parseField(field string, min, max int) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(field, ",") {
+		rng, stepText, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepText)
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("bad step %q", stepText)
+			}
+			step = n
+		}
+		lo, hi := min, max
+		switch {
+		case rng == "*":
+		case strings.Contains(rng, "-"):
+			a, b, _ := strings.Cut(rng, "-")
+			var err error
+			if lo, err = fieldValue(a, min, max); err != nil {
+				return 0, err
+			}
+			if hi, err = fieldValue(b, min, max); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("range %q runs backwards", rng)
+			}
+		default:
+			n, err := fieldValue(rng, min, max)
+			if err != nil {
+				return 0, err
+			}
+			lo, hi = n, n
+			if hasStep {
+				// 5/15 is 5-max/15, as in cron
+				hi = max
+			}
+		}
+		for n := lo; n <= hi; n += step {
+			set |= 1 << n
+		}
+	}
+	return set, nil
+}
+
+func fieldValue(s string, min, max int) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a number", s)
+	}
+	if n < min || n > max {
+		return 0, fmt.Errorf("%d is not between %d and %d", n, min, max)
+	}
+	return n, nil
+}
+
+// cron is a parsed cron expression.
+type cron struct {
+	spec                          string
+	minute, hour, dom, month, dow uint64
+	domAny, dowAny                bool
+}
+
+func (c cron) String() string { return c.spec }
+
+// Next finds the next matching minute by skipping whole months, days and
+// hours that don't match. It gives up after five years, which only an
+// expression like "0 0 30 2 *" needs, and returns the zero time.
+func (c cron) Next(t time.Time) time.Time {
+	t = t.UTC().Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case c.month&(1<<int(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+		case !c.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+		case c.hour&(1<<t.Hour()) == 0:
+			t = t.Truncate(time.Hour).Add(time.Hour)
+		case c.minute&(1<<t.Minute()) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func (c cron) dayMatches(t time.Time) bool {
+	dom := c.dom&(1<<t.Day()) != 0
+	dow := c.dow&(1<<int(t.Weekday())) != 0
+	if c.domAny || c.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// MustParse is ParseSchedule for schedules written in code, panicking if
+// spec doesn't parse.
+func MustParse(spec string) Schedule {
+	s, err := ParseSchedule(spec)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
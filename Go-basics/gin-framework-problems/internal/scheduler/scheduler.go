@@ -0,0 +1,277 @@
+// Package scheduler runs periodic maintenance tasks from one registry, so
+// every example's janitors show up in the same /admin/tasks listing.
+//
+// Tasks run on an interval or a cron expression (see ParseSchedule), which
+// the scheduler.tasks config can override per task. A task never overlaps
+// itself: if it is still running when its next tick comes, that tick is
+// skipped and counted.
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Task does one run of periodic work. ctx is canceled on Stop.
+type Task func(ctx context.Context) error
+
+// Result is what /admin/tasks reports for one task.
+type Result struct {
+	Name         string     `json:"name"`
+	Schedule     string     `json:"schedule"`
+	Running      bool       `json:"running"`
+	Runs         int        `json:"runs"`
+	Failures     int        `json:"failures"`
+	Skipped      int        `json:"skipped"` // ticks dropped because the last run hadn't finished
+	LastStart    *time.Time `json:"last_start,omitempty"`
+	LastDuration string     `json:"last_duration,omitempty"`
+	LastStatus   string     `json:"last_status,omitempty"` // "ok" or "failed"
+	LastError    string     `json:"last_error,omitempty"`
+	NextRun      *time.Time `json:"next_run,omitempty"`
+}
+
+type entry struct {
+	fn       Task
+	schedule Schedule
+	jitter   time.Duration
+	stop     context.CancelFunc // ends the entry's loop; nil until started
+	result   Result
+}
+
+type TaskOption func(*entry)
+
+// WithJitter delays each run by a random amount up to d, so instances
+// started together don't all run the task at the same moment.
+func WithJitter(d time.Duration) TaskOption {
+	return func(e *entry) { e.jitter = d }
+}
+
+// Scheduler holds named tasks and, once started, runs each on its schedule.
+type Scheduler struct {
+	mu        sync.Mutex
+	tasks     map[string]*entry
+	overrides map[string]Schedule // from Configure, by task name
+	ctx       context.Context     // nil until Start
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+	logger    *slog.Logger
+	stopped   bool
+}
+
+// New returns an empty, stopped scheduler.
+func New() *Scheduler {
+	return &Scheduler{tasks: map[string]*entry{}, overrides: map[string]Schedule{}, logger: slog.Default()}
+}
+
+// Default is the scheduler the examples register into and NewEngine starts.
+var Default = New()
+
+// SetLogger changes where task failures are logged.
+func (s *Scheduler) SetLogger(logger *slog.Logger) {
+	s.mu.Lock()
+	s.logger = logger
+	s.mu.Unlock()
+}
+
+// Register adds a task that runs every interval, replacing any task with the
+// same name. Tasks registered after Start begin right away.
+func (s *Scheduler) Register(name string, every time.Duration, fn Task, opts ...TaskOption) {
+	s.RegisterSchedule(name, Every(every), fn, opts...)
+}
+
+// RegisterSchedule is Register for a cron expression or any other Schedule.
+// A schedule set for name by Configure wins over sched.
+func (s *Scheduler) RegisterSchedule(name string, sched Schedule, fn Task, opts ...TaskOption) {
+	e := &entry{fn: fn, schedule: sched, result: Result{Name: name}}
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if override, ok := s.overrides[name]; ok {
+		e.schedule = override
+	}
+	e.result.Schedule = e.schedule.String()
+	if old, ok := s.tasks[name]; ok && old.stop != nil {
+		old.stop()
+	}
+	s.tasks[name] = e
+	if s.ctx != nil && !s.stopped {
+		s.start(e)
+	}
+}
+
+// Configure sets the schedules of tasks by name, as ParseSchedule reads
+// them, for tasks registered now and later; names no example registers are
+// kept but never run. Nothing changes if any schedule doesn't parse. Call it
+// before Start: a running task that is rescheduled has its current run
+// canceled.
+func (s *Scheduler) Configure(specs map[string]string) error {
+	parsed := make(map[string]Schedule, len(specs))
+	for name, spec := range specs {
+		sched, err := ParseSchedule(spec)
+		if err != nil {
+			return fmt.Errorf("task %s: %w", name, err)
+		}
+		parsed[name] = sched
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for name, sched := range parsed {
+		s.overrides[name] = sched
+		e, ok := s.tasks[name]
+		if !ok {
+			continue
+		}
+		e.schedule = sched
+		e.result.Schedule = sched.String()
+		if e.stop != nil && !s.stopped {
+			e.stop()
+			s.start(e)
+		}
+	}
+	return nil
+}
+
+// Start runs the registered tasks. Calling it again is a no-op.
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ctx != nil {
+		return
+	}
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	for _, e := range s.tasks {
+		s.start(e)
+	}
+}
+
+// Stop cancels running tasks and waits for them until ctx expires.
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	if s.ctx == nil || s.stopped {
+		s.mu.Unlock()
+		return nil
+	}
+	s.stopped = true
+	s.cancel()
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("scheduler: tasks still running: %w", ctx.Err())
+	}
+}
+
+// start launches e's loop. s.mu must be held.
+func (s *Scheduler) start(e *entry) {
+	ctx, stop := context.WithCancel(s.ctx)
+	e.stop = stop
+	s.wg.Add(1)
+	go s.loop(ctx, e)
+}
+
+func (s *Scheduler) loop(ctx context.Context, e *entry) {
+	defer s.wg.Done()
+	for {
+		s.mu.Lock()
+		next := e.schedule.Next(time.Now())
+		if e.jitter > 0 {
+			next = next.Add(rand.N(e.jitter))
+		}
+		e.result.NextRun = &next
+		s.mu.Unlock()
+
+		wait := time.Until(next)
+
+		t := time.NewTimer(wait)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return
+		}
+
+		s.mu.Lock()
+		if e.result.Running {
+			e.result.Skipped++
+			s.mu.Unlock()
+			continue
+		}
+		e.result.Running = true
+		s.mu.Unlock()
+
+		s.wg.Add(1)
+		go s.run(ctx, e)
+	}
+}
+
+func (s *Scheduler) run(ctx context.Context, e *entry) {
+	defer s.wg.Done()
+	start := time.Now()
+	err := call(ctx, e.fn)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r := &e.result
+	r.Running = false
+	r.Runs++
+	r.LastStart = &start
+	r.LastDuration = time.Since(start).String()
+	r.LastStatus = "ok"
+	r.LastError = ""
+	if err != nil {
+		r.Failures++
+		r.LastStatus = "failed"
+		r.LastError = err.Error()
+		s.logger.Error("scheduled task failed", "task", r.Name, "error", err)
+	}
+}
+
+// call runs fn, turning a panic into an error so a broken task keeps its
+// schedule instead of crashing the process.
+func call(ctx context.Context, fn Task) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return fn(ctx)
+}
+
+// Results returns the state of every task ordered by name.
+func (s *Scheduler) Results() []Result {
+	s.mu.Lock()
+	out := make([]Result, 0, len(s.tasks))
+	for _, e := range s.tasks {
+		out = append(out, e.result)
+	}
+	s.mu.Unlock()
+
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Handler serves the task listing.
+func (s *Scheduler) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"tasks": s.Results()})
+	}
+}
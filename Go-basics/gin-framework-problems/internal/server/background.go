@@ -0,0 +1,39 @@
+package server
+
+import (
+	"context"
+	"sync"
+)
+
+// Background runs work a handler starts and doesn't wait for, such as mail
+// sent after the response, so that shutdown can wait for it instead of
+// cutting it off. The zero value is ready to use.
+type Background struct {
+	wg sync.WaitGroup
+}
+
+// Go runs fn in a goroutine. The caller should pass fn a context that
+// outlives the request, such as context.WithoutCancel of its context.
+func (b *Background) Go(fn func()) {
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		fn()
+	}()
+}
+
+// Wait waits for the work started so far until ctx expires. It fits
+// server.Hooks; register it after what the work uses, so it runs first.
+func (b *Background) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		b.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,38 @@
+package server_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/server"
+)
+
+func TestBackgroundWait(t *testing.T) {
+	var b server.Background
+	var done atomic.Int32
+	for range 3 {
+		b.Go(func() {
+			time.Sleep(10 * time.Millisecond)
+			done.Add(1)
+		})
+	}
+	if err := b.Wait(context.Background()); err != nil {
+		t.Fatalf("Wait = %v", err)
+	}
+	if n := done.Load(); n != 3 {
+		t.Errorf("%d of 3 done after Wait", n)
+	}
+
+	// work that doesn't finish in time is left behind
+	release := make(chan struct{})
+	defer close(release)
+	b.Go(func() { <-release })
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := b.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Wait past the deadline = %v, want DeadlineExceeded", err)
+	}
+}
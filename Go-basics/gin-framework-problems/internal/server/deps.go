@@ -0,0 +1,54 @@
+package server
+
+import (
+	"time"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/apidocs"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/config"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/scheduler"
+)
+
+// Deps are what an example's NewRouter builds its router from. Examples with
+// stores of their own take a Deps that embeds this one and adds them, so a
+// test can hand each router fresh ones; NewRouter builds what it isn't
+// given from these.
+type Deps struct {
+	Config *config.Config
+	// collects what the example needs closed on shutdown
+	Hooks *Hooks
+	// what rate limiters, token expiry and the stores' timestamps read the
+	// time from; the system clock when nil
+	Clock middleware.Clock
+	// where the example registers its periodic tasks, which NewEngine
+	// starts; scheduler.Default when nil
+	Scheduler *scheduler.Scheduler
+	// what the example describes its routes in for /openapi.json;
+	// apidocs.Default when nil
+	Docs *apidocs.Registry
+}
+
+// Now is the time by d.Clock, or time.Now when it has none. Its method value
+// fits the Now options of the stores and signers that take one.
+func (d Deps) Now() time.Time {
+	if d.Clock == nil {
+		return time.Now()
+	}
+	return d.Clock.Now()
+}
+
+// Tasks is d.Scheduler, or scheduler.Default when it has none.
+func (d Deps) Tasks() *scheduler.Scheduler {
+	if d.Scheduler == nil {
+		return scheduler.Default
+	}
+	return d.Scheduler
+}
+
+// APIDocs is d.Docs, or apidocs.Default when it has none.
+func (d Deps) APIDocs() *apidocs.Registry {
+	if d.Docs == nil {
+		return apidocs.Default
+	}
+	return d.Docs
+}
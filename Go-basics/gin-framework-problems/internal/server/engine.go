@@ -0,0 +1,337 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/apidocs"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/audit"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/config"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/database"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/events"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/featureflags"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/healthcheck"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/i18n"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/idempotency"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/ipfilter"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/jobs"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/logging"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/loginguard"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/metrics"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/pagination"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/profiling"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/rbac"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/scheduler"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/tenant"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/tracing"
+)
+
+// NewEngine returns a gin engine with the middleware every example shares:
+// request IDs, locale negotiation, structured request logging, error handling
+// and panic recovery, a per-request deadline, a request body cap, the rate
+// limits rate_limit.routes binds, Prometheus metrics, the /healthz and /readyz
+// endpoints, /debug/conn (the protocol and TLS details of the request), the
+// OpenAPI document at /openapi.json with Swagger UI at /docs, the /admin/tasks
+// listing and the /admin/flags, /admin/roles, /admin/ips and /admin/jobs APIs,
+// plus a span per request when tracing is enabled and the /debug profiling and
+// /debug/ratelimit endpoints when debug.enabled is set. It also starts the
+// task scheduler, loads the feature flags, roles and IP lists from cfg, keeps
+// idempotency keys in Redis when redis.addr is set, points the audit log at
+// the sink audit.sink names and, with database.auto_migrate, brings the
+// database schema up to date first. The scheduler and the OpenAPI document
+// are deps' Tasks and APIDocs.
+func NewEngine(deps Deps) *gin.Engine {
+	cfg, hooks := deps.Config, deps.Hooks
+	tasks := deps.Tasks()
+	logger := logging.New(cfg.Log)
+	// so that code without the engine at hand, such as Run and the log
+	// package, writes through it too
+	slog.SetDefault(logger)
+
+	var opts []middleware.LoggerOption
+	for route, n := range cfg.Log.Sample {
+		opts = append(opts, middleware.WithSampling(route, n))
+	}
+	for pattern, level := range cfg.Log.Levels {
+		opts = append(opts, middleware.WithRouteLevel(pattern, logging.ParseLevel(level)))
+	}
+
+	if cfg.Database.AutoMigrate {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		results, err := database.Migrate(ctx, cfg.Database.Path)
+		cancel()
+		if err != nil {
+			// serving against a half-migrated schema is worse than not starting
+			panic(err)
+		}
+		for _, r := range results {
+			logger.Info("migration applied", "version", r.Source.Version, "file", r.Source.Path, "duration", r.Duration)
+		}
+	}
+
+	// before any example subscribes, so every subscription lands on the
+	// bus that will actually carry events
+	if bus, err := ConnectBus(cfg.Events, cfg.Tracing.ServiceName); err != nil {
+		logger.Error("events stay in-process", "error", err)
+	} else if bus != nil {
+		events.Default = bus
+	}
+	hooks.Add(events.Default.Close)
+
+	// idempotency keys must be shared, or a retry that lands on another
+	// instance runs again
+	if cfg.Redis.Addr != "" {
+		client := redis.NewClient(&redis.Options{Addr: cfg.Redis.Addr})
+		hooks.Add(func(context.Context) error { return client.Close() })
+		store := idempotency.NewRedisStore(client, "idempotency:")
+		idempotency.Default = store
+		healthcheck.Default.Register("redis", store.Ping)
+	}
+
+	if cfg.FlagsFile != "" {
+		if err := featureflags.Default.UseStore(featureflags.NewFileStore(cfg.FlagsFile)); err != nil {
+			panic(fmt.Sprintf("flags_file: %v", err))
+		}
+	}
+	for name, f := range cfg.Flags {
+		// the file's flags win, as do those another example in the same
+		// process set up
+		if _, ok := featureflags.Default.Get(name); ok {
+			continue
+		}
+		// rollouts were checked by cfg.Validate
+		_, err := featureflags.Default.Set(featureflags.Flag{
+			Name:        name,
+			Description: f.Description,
+			Enabled:     f.Enabled,
+			Rollout:     f.Rollout,
+			Users:       f.Users,
+			Roles:       f.Roles,
+		})
+		if err != nil {
+			panic(fmt.Sprintf("flags.%s: %v", name, err))
+		}
+	}
+
+	switch cfg.Audit.Sink {
+	case "file":
+		sink, err := audit.NewFileSink(cfg.Audit.Path)
+		if err != nil {
+			panic(err)
+		}
+		hooks.Add(sink.Close)
+		audit.Default = sink
+	case "sqlite":
+		audit.Default = audit.NewSQLSink(OpenDB(cfg.Database.Path, hooks))
+	}
+
+	// every instance must accept the cursors the others hand out
+	pagination.SetCursorKey(cfg.Auth.TokenSecret)
+
+	guard := cfg.Auth.LoginGuard
+	var verify loginguard.Verifier
+	if guard.CaptchaVerifyURL != "" {
+		verify = loginguard.SiteVerify(guard.CaptchaVerifyURL, guard.CaptchaSecret)
+	}
+	loginguard.Default = loginguard.New(loginguard.Options{
+		FreeAttempts: guard.FreeAttempts,
+		BaseDelay:    guard.BaseDelay,
+		MaxDelay:     guard.MaxDelay,
+		CaptchaAfter: guard.CaptchaAfter,
+		ResetAfter:   guard.ResetAfter,
+	}, verify)
+
+	for name, perms := range cfg.Roles {
+		if _, err := rbac.Default.Set(name, perms); err != nil {
+			panic(fmt.Sprintf("roles.%s: %v", name, err))
+		}
+	}
+	for id, name := range cfg.Tenancy.Tenants {
+		// another example in the same process may have added it
+		if _, err := tenant.Default.Create(id, name); err != nil && !errors.Is(err, tenant.ErrTenantExists) {
+			panic(fmt.Sprintf("tenancy.tenants.%s: %v", id, err))
+		}
+	}
+	// checked by cfg.Validate
+	ipfilter.Default.Set(ipfilter.Allow, cfg.RateLimit.Allow)
+	ipfilter.Default.Set(ipfilter.Deny, cfg.RateLimit.Deny)
+
+	if cfg.Server.Mode != "" {
+		// checked by cfg.Validate
+		gin.SetMode(cfg.Server.Mode)
+	}
+	router := gin.New()
+	// checked by cfg.Validate; none trusted leaves ClientIP the peer
+	// address, so X-Forwarded-For can't pick the rate limit or allowlist
+	// entry a request is counted under
+	if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		panic(fmt.Sprintf("server.trusted_proxies: %v", err))
+	}
+	if cfg.Tracing.Enabled {
+		shutdown, err := tracing.Setup(context.Background(), cfg.Tracing.ServiceName)
+		if err != nil {
+			logger.Error("tracing disabled", "error", err)
+		} else {
+			hooks.Add(shutdown)
+			router.Use(otelgin.Middleware(cfg.Tracing.ServiceName))
+		}
+	}
+	if cfg.Server.TLS.Proxied {
+		router.Use(middleware.AssumeTLS())
+	}
+	router.Use(middleware.RequestID())
+	router.Use(i18n.Middleware())
+	router.Use(middleware.Logger(logger, opts...))
+	if cfg.Log.Bodies.Enabled {
+		router.Use(middleware.BodyLogger(logger, bodyLogOptions(cfg.Log.Bodies)...))
+	}
+	router.Use(middleware.ErrorHandler(logger))
+	if len(cfg.CORS.AllowedOrigins) > 0 {
+		router.Use(middleware.CORS(corsOptions(cfg.CORS)...))
+	}
+	router.Use(middleware.Timeout(cfg.Server.RequestTimeout, middleware.WithRouteTimeouts(cfg.Server.RouteTimeouts)))
+	router.Use(middleware.BodyLimit(cfg.Server.MaxBodyBytes, middleware.WithRouteLimits(cfg.Server.BodyLimits)))
+	if cfg.Server.Compress.Enabled {
+		router.Use(middleware.Compress(compressOptions(cfg.Server.Compress)...))
+	}
+	if len(cfg.RateLimit.Routes) > 0 {
+		router.Use(rateLimits(cfg.RateLimit, hooks))
+	}
+
+	healthcheck.Default.SetTimeout(cfg.Health.CheckTimeout)
+	router.GET("/healthz", healthcheck.Default.Liveness())
+	router.GET("/readyz", healthcheck.Default.Readiness())
+	router.GET("/debug/conn", connInfo)
+	router.GET("/openapi.json", deps.APIDocs().Handler("Tech Learning Hub examples", "1.0.0"))
+	router.GET("/docs", apidocs.UI("/openapi.json"))
+
+	if err := tasks.Configure(cfg.Scheduler.Tasks); err != nil {
+		panic(fmt.Sprintf("scheduler.tasks: %v", err))
+	}
+	if cfg.Scheduler.Enabled {
+		tasks.SetLogger(logger)
+		// just after midnight UTC, once the day is complete
+		tasks.RegisterSchedule("audit_summary", scheduler.MustParse("5 0 * * *"), audit.DailySummary(logger))
+		tasks.Start()
+		hooks.Add(tasks.Stop)
+	}
+	// basic auth with the admin password, since not every example has a user
+	// store to check a token against
+	if cfg.Auth.AdminPassword != "" {
+		admin := gin.BasicAuth(gin.Accounts{"admin": cfg.Auth.AdminPassword})
+		router.GET("/admin/tasks", admin, tasks.Handler())
+		featureflags.Default.Routes(router.Group("/admin/flags", admin))
+		rbac.Default.Routes(router.Group("/admin/roles", admin))
+		ipfilter.Default.Routes(router.Group("/admin/ips", admin))
+		jobs.Routes(router.Group("/admin/jobs", admin))
+		tenant.Default.Routes(router.Group("/admin/tenants", admin))
+		// cfg.Validate makes sure there is a password to guard these
+		if cfg.Debug.Enabled {
+			router.Use(profiling.Middleware())
+			profiling.Routes(router.Group("/debug", admin))
+			router.GET("/debug/ratelimit", admin, middleware.RateLimitStatsHandler())
+		}
+	}
+
+	if cfg.Metrics.Path != "" {
+		router.Use(metrics.Middleware())
+		router.GET(cfg.Metrics.Path, metrics.Handler())
+	}
+	// last, so it scopes the examples' routes but not the health checks and
+	// the admin API above, which serve the operator rather than a tenant
+	if cfg.Tenancy.Enabled {
+		router.Use(tenant.Default.Middleware(cfg.Tenancy.Header, cfg.Tenancy.BaseDomain))
+	}
+	return router
+}
+
+// OpenDB opens the SQLite database at path with its schema migrated, adds it
+// to the readiness checks and closes it on shutdown. It panics when the
+// database can't be used, since the caller can't serve without it.
+func OpenDB(path string, hooks *Hooks) *sql.DB {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	db, err := database.Open(ctx, path)
+	if err != nil {
+		panic(err)
+	}
+	m, err := database.NewMigrator(db)
+	if err != nil {
+		panic(err)
+	}
+	if _, err := m.Up(ctx); err != nil {
+		panic(err)
+	}
+	hooks.Add(func(context.Context) error { return db.Close() })
+	healthcheck.Default.Register("database", db.PingContext)
+	return db
+}
+
+// ConnectBus connects to the broker the events section names, NATS or AMQP,
+// and adds it to the readiness checks. The bus is nil when events stay
+// in-process.
+func ConnectBus(cfg config.EventsConfig, name string) (events.Bus, error) {
+	switch {
+	case cfg.NATSURL != "":
+		bus, err := events.ConnectNATS(cfg.NATSURL, name)
+		if err != nil {
+			return nil, err
+		}
+		healthcheck.Default.Register("nats", bus.Ping)
+		return bus, nil
+	case cfg.AMQPURL != "":
+		bus, err := events.ConnectAMQP(cfg.AMQPURL, cfg.Exchange, name)
+		if err != nil {
+			return nil, err
+		}
+		healthcheck.Default.Register("amqp", bus.Ping)
+		return bus, nil
+	}
+	return nil, nil
+}
+
+// corsOptions are the CORS options the cors section sets.
+func corsOptions(cfg config.CORSConfig) []middleware.CORSOption {
+	opts := []middleware.CORSOption{
+		middleware.WithAllowOrigins(cfg.AllowedOrigins...),
+		middleware.WithCredentials(cfg.AllowCredentials),
+		middleware.WithMaxAge(cfg.MaxAge),
+	}
+	if len(cfg.AllowedHeaders) > 0 {
+		opts = append(opts, middleware.WithAllowHeaders(cfg.AllowedHeaders...))
+	}
+	if len(cfg.ExposedHeaders) > 0 {
+		opts = append(opts, middleware.WithExposeHeaders(cfg.ExposedHeaders...))
+	}
+	return opts
+}
+
+// compressOptions are the Compress options server.compress sets.
+func compressOptions(cfg config.CompressConfig) []middleware.CompressOption {
+	opts := []middleware.CompressOption{middleware.WithMinSize(cfg.MinBytes)}
+	if len(cfg.ContentTypes) > 0 {
+		opts = append(opts, middleware.WithContentTypes(cfg.ContentTypes...))
+	}
+	return opts
+}
+
+// bodyLogOptions are the BodyLogger options log.bodies sets.
+func bodyLogOptions(cfg config.BodyLogConfig) []middleware.BodyLogOption {
+	opts := []middleware.BodyLogOption{
+		middleware.WithMaxBodyBytes(cfg.MaxBytes),
+		middleware.WithRedactedFields(cfg.Redact...),
+	}
+	for pattern, on := range cfg.Routes {
+		opts = append(opts, middleware.WithBodyLogRoute(pattern, on))
+	}
+	return opts
+}
@@ -0,0 +1,63 @@
+package server
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/config"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/ipfilter"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+)
+
+// rateLimitKeys are the keys a policy can name.
+var rateLimitKeys = map[string]middleware.KeyFunc{
+	"":         middleware.ByIP,
+	"ip":       middleware.ByIP,
+	"user":     middleware.ByUser,
+	"api_key":  middleware.ByAPIKey,
+	"route_ip": middleware.ByRouteAndIP,
+}
+
+// rateLimits binds the policies of cfg to their routes, one limiter per
+// policy, so routes sharing a policy share a budget. cfg was validated.
+func rateLimits(cfg config.RateLimitConfig, hooks *Hooks) gin.HandlerFunc {
+	limiters := make(map[string]*middleware.RateLimiter, len(cfg.Policies))
+	for name, p := range cfg.Policies {
+		algorithm := p.Algorithm
+		if algorithm == "" {
+			algorithm = cfg.Algorithm
+		}
+		rl := middleware.NewRateLimiter(p.Requests,
+			middleware.WithName("policy:"+name),
+			middleware.WithWindow(p.Window),
+			middleware.WithBurst(p.Burst),
+			middleware.WithKey(rateLimitKeys[p.Key]),
+			middleware.WithAlgorithm(middleware.Algorithm(algorithm)),
+			middleware.WithCosts(cfg.Costs),
+			middleware.WithIPFilter(ipfilter.Default),
+			middleware.WithOnLimitExceeded(LogLimitExceeded))
+		rl.StartJanitor(5 * time.Minute)
+		hooks.Add(rl.Stop)
+		limiters[name] = rl
+	}
+	var limits []middleware.RouteLimit
+	for pattern, name := range cfg.Routes {
+		key := cfg.Policies[name].Key
+		limits = append(limits, middleware.RouteLimit{
+			Pattern:   pattern,
+			Limiter:   limiters[name],
+			AfterAuth: key == "user" || key == "api_key",
+		})
+	}
+	return middleware.LimitRoutes(limits)
+}
+
+// LogLimitExceeded logs a request a limiter refused, at warn, for limiters
+// made with middleware.WithOnLimitExceeded.
+func LogLimitExceeded(c *gin.Context, e middleware.LimitExceeded) {
+	slog.WarnContext(c.Request.Context(), "rate limit exceeded",
+		"limiter", e.Limiter, "key", e.Key, "route", c.FullPath(), "cost", e.Cost,
+		"retry_after", e.RetryAfter)
+}
@@ -0,0 +1,165 @@
+// Package server runs an example's router with timeouts, optional TLS and a
+// graceful shutdown on SIGINT/SIGTERM.
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/config"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/healthcheck"
+)
+
+// Hook is cleanup work run once the server has drained, e.g. closing a store
+// or stopping a janitor goroutine.
+type Hook func(ctx context.Context) error
+
+// Hooks collects the shutdown hooks an example registers while it builds its
+// routes. They run in reverse order of registration.
+type Hooks []Hook
+
+// Add registers fn to run on shutdown.
+func (h *Hooks) Add(fn Hook) {
+	*h = append(*h, fn)
+}
+
+type options struct {
+	readHeaderTimeout time.Duration
+	readTimeout       time.Duration
+	writeTimeout      time.Duration
+	shutdownTimeout   time.Duration
+	drainDelay        time.Duration
+	tls               config.TLSConfig
+	hooks             Hooks
+	logger            *slog.Logger
+}
+
+// Option customizes Run.
+type Option func(*options)
+
+// WithConfig takes the timeouts and TLS settings from the server config.
+func WithConfig(cfg config.ServerConfig) Option {
+	return func(o *options) {
+		o.readHeaderTimeout = cfg.ReadHeaderTimeout
+		o.readTimeout = cfg.ReadTimeout
+		o.writeTimeout = cfg.WriteTimeout
+		o.shutdownTimeout = cfg.ShutdownTimeout
+		o.drainDelay = cfg.DrainDelay
+		o.tls = cfg.TLS
+	}
+}
+
+// WithLogger logs the server's startup and shutdown to logger rather than
+// to slog.Default, which NewEngine sets to the configured logger.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) { o.logger = logger }
+}
+
+// WithHooks runs hooks after the server has stopped serving.
+func WithHooks(hooks Hooks) Option {
+	return func(o *options) { o.hooks = append(o.hooks, hooks...) }
+}
+
+// Run serves handler on addr until the process receives SIGINT or SIGTERM,
+// then stops accepting connections, waits for in-flight requests to finish
+// and runs the shutdown hooks, all within the shutdown timeout. Before that,
+// /readyz turns to "draining" and requests are still served for the drain
+// delay, for load balancers to notice.
+//
+// When the TLS config has a certificate or domains, addr serves HTTPS and
+// HTTP/2, and the redirect address, if any, answers plain HTTP with a
+// redirect to it (and ACME challenges, for domains).
+func Run(handler http.Handler, addr string, opts ...Option) error {
+	o := options{
+		readHeaderTimeout: 5 * time.Second,
+		readTimeout:       10 * time.Second,
+		writeTimeout:      30 * time.Second,
+		shutdownTimeout:   15 * time.Second,
+		logger:            slog.Default(),
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	log := o.logger
+
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: o.readHeaderTimeout,
+		ReadTimeout:       o.readTimeout,
+		WriteTimeout:      o.writeTimeout,
+	}
+	servers := []*http.Server{srv}
+	serve := srv.ListenAndServe
+	if o.tls.Enabled() {
+		tc, m := newTLSConfig(o.tls)
+		srv.TLSConfig = tc
+		srv.Handler = hsts(handler)
+		serve = func() error { return srv.ListenAndServeTLS(o.tls.CertFile, o.tls.KeyFile) }
+
+		if o.tls.RedirectAddr != "" {
+			var redirect http.Handler = redirectHandler(addr)
+			if m != nil {
+				redirect = m.HTTPHandler(redirect)
+			}
+			servers = append(servers, &http.Server{
+				Addr:              o.tls.RedirectAddr,
+				Handler:           redirect,
+				ReadHeaderTimeout: o.readHeaderTimeout,
+				ReadTimeout:       o.readTimeout,
+				WriteTimeout:      o.writeTimeout,
+			})
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errc := make(chan error, len(servers))
+	go func() {
+		log.Info("listening", "addr", addr, "https", o.tls.Enabled())
+		errc <- serve()
+	}()
+	for _, r := range servers[1:] {
+		go func() {
+			log.Info("redirecting http to https", "addr", r.Addr)
+			errc <- r.ListenAndServe()
+		}()
+	}
+
+	select {
+	case err := <-errc:
+		// a listener failed before any signal arrived
+		for _, s := range servers {
+			s.Close()
+		}
+		return err
+	case <-ctx.Done():
+	}
+	// restore default signal handling so a second Ctrl-C exits immediately
+	stop()
+
+	healthcheck.Default.Drain()
+	if o.drainDelay > 0 {
+		log.Info("not ready, still serving", "drain_delay", o.drainDelay)
+		time.Sleep(o.drainDelay)
+	}
+	log.Info("shutting down", "shutdown_timeout", o.shutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), o.shutdownTimeout)
+	defer cancel()
+
+	var errs []error
+	for _, s := range servers {
+		errs = append(errs, s.Shutdown(shutdownCtx))
+	}
+	for i := len(o.hooks) - 1; i >= 0; i-- {
+		errs = append(errs, o.hooks[i](shutdownCtx))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,89 @@
+package server
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/config"
+)
+
+// newTLSConfig accepts TLS 1.2 and up and, on 1.2, only forward-secret AEAD
+// suites, which is also what HTTP/2 requires. TLS 1.3 suites aren't
+// configurable and are all fine. With domains, certificates come from the
+// returned autocert manager; otherwise the caller loads them from files.
+func newTLSConfig(cfg config.TLSConfig) (*tls.Config, *autocert.Manager) {
+	tc := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		CipherSuites: []uint16{
+			tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
+		},
+		NextProtos: []string{"h2", "http/1.1"},
+	}
+	if len(cfg.Domains) == 0 {
+		return tc, nil
+	}
+
+	m := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(cfg.Domains...),
+		Cache:      autocert.DirCache(cfg.CacheDir),
+		Email:      cfg.Email,
+	}
+	tc.GetCertificate = m.GetCertificate
+	// lets Let's Encrypt validate over the HTTPS port itself (tls-alpn-01)
+	tc.NextProtos = append(tc.NextProtos, acme.ALPNProto)
+	return tc, m
+}
+
+// redirectHandler sends plain HTTP requests to the same host and path on
+// httpsAddr. 308 keeps the method and body, so a POST isn't turned into a
+// GET on the way.
+func redirectHandler(httpsAddr string) http.Handler {
+	_, port, _ := net.SplitHostPort(httpsAddr)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if port != "" && port != "443" {
+			host = net.JoinHostPort(host, port)
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+}
+
+// hsts tells browsers that reached the server over HTTPS to skip plain HTTP
+// for the next year.
+func hsts(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS != nil {
+			w.Header().Set("Strict-Transport-Security", "max-age=31536000")
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// connInfo reports how the request arrived, to check that HTTPS clients get
+// HTTP/2:
+//
+//	curl --http2 https://localhost:8443/debug/conn
+func connInfo(c *gin.Context) {
+	info := gin.H{"proto": c.Request.Proto, "tls": c.Request.TLS != nil}
+	if cs := c.Request.TLS; cs != nil {
+		info["tls_version"] = tls.VersionName(cs.Version)
+		info["cipher_suite"] = tls.CipherSuiteName(cs.CipherSuite)
+		info["alpn"] = cs.NegotiatedProtocol
+		info["server_name"] = cs.ServerName
+	}
+	c.JSON(http.StatusOK, info)
+}
@@ -0,0 +1,170 @@
+// Package sse implements a small Server-Sent Events broker: numbered events,
+// replay from Last-Event-ID, and heartbeats for idle connections.
+package sse
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Event is one message on the stream.
+type Event struct {
+	ID   uint64 `json:"id"`
+	Name string `json:"event"`
+	Data any    `json:"data"`
+}
+
+// Broker fans events out to subscribers and remembers the last few so a
+// reconnecting client can resume where it left off.
+type Broker struct {
+	mu        sync.Mutex
+	nextID    uint64
+	backlog   []Event // oldest first, at most backlogSize long
+	size      int
+	subs      map[chan Event]struct{}
+	closed    bool
+	heartbeat time.Duration
+}
+
+// NewBroker keeps backlogSize events for replay and sends a heartbeat comment
+// every heartbeat so proxies don't close idle streams.
+func NewBroker(backlogSize int, heartbeat time.Duration) *Broker {
+	return &Broker{
+		size:      backlogSize,
+		subs:      map[chan Event]struct{}{},
+		heartbeat: heartbeat,
+	}
+}
+
+// Publish assigns the next ID to an event and delivers it to every
+// subscriber. Subscribers that can't keep up miss the event and have to
+// reconnect with Last-Event-ID to catch up.
+func (b *Broker) Publish(name string, data any) Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.nextID++
+	ev := Event{ID: b.nextID, Name: name, Data: data}
+	b.backlog = append(b.backlog, ev)
+	if len(b.backlog) > b.size {
+		b.backlog = b.backlog[len(b.backlog)-b.size:]
+	}
+	if b.closed {
+		return ev
+	}
+	for ch := range b.subs {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+	return ev
+}
+
+// subscribe returns the backlog after lastID and a channel for new events.
+func (b *Broker) subscribe(lastID uint64) ([]Event, chan Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var replay []Event
+	for _, ev := range b.backlog {
+		if ev.ID > lastID {
+			replay = append(replay, ev)
+		}
+	}
+	ch := make(chan Event, 16)
+	if b.closed {
+		close(ch)
+		return replay, ch
+	}
+	b.subs[ch] = struct{}{}
+	return replay, ch
+}
+
+func (b *Broker) unsubscribe(ch chan Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.subs[ch]; ok {
+		delete(b.subs, ch)
+		close(ch)
+	}
+}
+
+// Close ends every open stream; use it as a shutdown hook.
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for ch := range b.subs {
+		delete(b.subs, ch)
+		close(ch)
+	}
+}
+
+func write(w io.Writer, ev Event) error {
+	data, err := json.Marshal(ev.Data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", ev.ID, ev.Name, data)
+	return err
+}
+
+// Handler streams events to the client, first replaying anything newer than
+// the Last-Event-ID header (or ?last_event_id= for manual testing).
+func (b *Broker) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		lastID := c.GetHeader("Last-Event-ID")
+		if lastID == "" {
+			lastID = c.Query("last_event_id")
+		}
+		last, _ := strconv.ParseUint(lastID, 10, 64)
+
+		replay, ch := b.subscribe(last)
+		defer b.unsubscribe(ch)
+
+		// streams outlive the server's WriteTimeout
+		http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+
+		c.Header("Content-Type", "text/event-stream")
+		c.Header("Cache-Control", "no-cache")
+		c.Header("Connection", "keep-alive")
+		c.Header("X-Accel-Buffering", "no") // disable nginx buffering
+		c.Status(http.StatusOK)
+
+		for _, ev := range replay {
+			if write(c.Writer, ev) != nil {
+				return
+			}
+		}
+		c.Writer.Flush()
+
+		ticker := time.NewTicker(b.heartbeat)
+		defer ticker.Stop()
+		for {
+			select {
+			case ev, ok := <-ch:
+				if !ok {
+					return
+				}
+				if write(c.Writer, ev) != nil {
+					return
+				}
+			case <-ticker.C:
+				if _, err := io.WriteString(c.Writer, ": heartbeat\n\n"); err != nil {
+					return
+				}
+			case <-c.Request.Context().Done():
+				return
+			}
+			c.Writer.Flush()
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Local keeps files under a directory, creating it and its subdirectories
+// as needed.
+type Local struct {
+	root string
+}
+
+func NewLocal(root string) *Local {
+	return &Local{root: root}
+}
+
+// path is where name lives. Names that would leave the root, such as
+// "../x" or "/etc/passwd", don't exist.
+func (l *Local) path(name string) (string, error) {
+	local, err := filepath.Localize(name)
+	if err != nil {
+		return "", ErrNotExist
+	}
+	return filepath.Join(l.root, local), nil
+}
+
+// Save writes to a temporary file next to the destination and renames it
+// into place.
+func (l *Local) Save(_ context.Context, name string, r io.Reader, _ int64) error {
+	dst, err := l.path(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(dst), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dst)
+}
+
+func (l *Local) Open(_ context.Context, name string) (io.ReadSeekCloser, error) {
+	p, err := l.path(name)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := f.Stat(); err == nil && info.IsDir() {
+		f.Close()
+		return nil, ErrNotExist
+	}
+	return f, nil
+}
+
+func (l *Local) Stat(_ context.Context, name string) (Info, error) {
+	p, err := l.path(name)
+	if err != nil {
+		return Info{}, err
+	}
+	info, err := os.Stat(p)
+	if err != nil {
+		return Info{}, err
+	}
+	if info.IsDir() {
+		return Info{}, ErrNotExist
+	}
+	return Info{Name: name, Size: info.Size(), ModTime: info.ModTime()}, nil
+}
+
+// List walks the directory the prefix names, or the root, so it reads no
+// more of the tree than the prefix allows.
+func (l *Local) List(ctx context.Context, prefix string) ([]Info, error) {
+	dir := l.root
+	if d := path.Dir(prefix); strings.Contains(prefix, "/") && d != "." {
+		local, err := filepath.Localize(d)
+		if err != nil {
+			return nil, nil
+		}
+		dir = filepath.Join(l.root, local)
+	}
+	var out []Info
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(l.root, p)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+		if !strings.HasPrefix(name, prefix) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		out = append(out, Info{Name: name, Size: info.Size(), ModTime: info.ModTime()})
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	slices.SortFunc(out, func(a, b Info) int { return strings.Compare(a.Name, b.Name) })
+	return out, err
+}
+
+func (l *Local) Delete(_ context.Context, name string) error {
+	p, err := l.path(name)
+	if err != nil {
+		return nil
+	}
+	err = os.Remove(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
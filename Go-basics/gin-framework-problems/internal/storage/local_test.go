@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestLocal(t *testing.T) {
+	ctx := t.Context()
+	s := NewLocal(t.TempDir())
+	for _, name := range []string{"b", "a", "thumbnails/128/a.png", "thumbnails/512/a.png"} {
+		if err := s.Save(ctx, name, strings.NewReader("content of "+name), -1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// replaces, never appends
+	if err := s.Save(ctx, "a", strings.NewReader("new"), 3); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := s.Open(ctx, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := io.ReadAll(f)
+	f.Close()
+	if string(got) != "new" {
+		t.Errorf("a = %q, want the second save", got)
+	}
+	if info, err := s.Stat(ctx, "b"); err != nil || info.Size != int64(len("content of b")) {
+		t.Errorf("Stat(b) = %+v, %v", info, err)
+	}
+
+	names := func(prefix string) []string {
+		infos, err := s.List(ctx, prefix)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out []string
+		for _, i := range infos {
+			out = append(out, i.Name)
+		}
+		return out
+	}
+	if got, want := names(""), []string{"a", "b", "thumbnails/128/a.png", "thumbnails/512/a.png"}; !slices.Equal(got, want) {
+		t.Errorf("List all = %q, want %q", got, want)
+	}
+	if got, want := names("thumbnails/5"), []string{"thumbnails/512/a.png"}; !slices.Equal(got, want) {
+		t.Errorf("List thumbnails/5 = %q, want %q", got, want)
+	}
+	if got := names("missing/"); len(got) != 0 {
+		t.Errorf("List missing/ = %q, want none", got)
+	}
+
+	if err := s.Delete(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete(ctx, "a"); err != nil {
+		t.Errorf("second Delete: %v, want nil", err)
+	}
+	for _, name := range []string{"a", "thumbnails", "../b", "/etc/passwd"} {
+		if _, err := s.Open(ctx, name); !errors.Is(err, ErrNotExist) {
+			t.Errorf("Open(%q): err = %v, want ErrNotExist", name, err)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/config"
+)
+
+// S3 keeps files as objects in a bucket of any S3-compatible service, named
+// by the configured prefix and the file's name.
+type S3 struct {
+	client *minio.Client
+	bucket string
+	prefix string
+}
+
+// NewS3 connects lazily: a wrong endpoint or key shows up on the first call,
+// and in Ping.
+func NewS3(cfg config.S3Config) (*S3, error) {
+	client, err := minio.New(cfg.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure: cfg.UseSSL,
+		Region: cfg.Region,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &S3{client: client, bucket: cfg.Bucket, prefix: cfg.Prefix}, nil
+}
+
+// notExist turns S3's missing-object errors into ErrNotExist.
+func notExist(err error) error {
+	switch minio.ToErrorResponse(err).Code {
+	case "NoSuchKey", "NotFound":
+		return ErrNotExist
+	}
+	return err
+}
+
+// Save uploads in one request, or in parts when size is -1 or large.
+func (s *S3) Save(ctx context.Context, name string, r io.Reader, size int64) error {
+	_, err := s.client.PutObject(ctx, s.bucket, s.prefix+name, r, size, minio.PutObjectOptions{})
+	return err
+}
+
+func (s *S3) Open(ctx context.Context, name string) (io.ReadSeekCloser, error) {
+	obj, err := s.client.GetObject(ctx, s.bucket, s.prefix+name, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, notExist(err)
+	}
+	// GetObject doesn't ask the server anything until the first read
+	if _, err := obj.Stat(); err != nil {
+		obj.Close()
+		return nil, notExist(err)
+	}
+	return obj, nil
+}
+
+func (s *S3) Stat(ctx context.Context, name string) (Info, error) {
+	obj, err := s.client.StatObject(ctx, s.bucket, s.prefix+name, minio.StatObjectOptions{})
+	if err != nil {
+		return Info{}, notExist(err)
+	}
+	return Info{Name: name, Size: obj.Size, ModTime: obj.LastModified}, nil
+}
+
+// List pages through the bucket; S3 returns keys in order already.
+func (s *S3) List(ctx context.Context, prefix string) ([]Info, error) {
+	var out []Info
+	for obj := range s.client.ListObjects(ctx, s.bucket, minio.ListObjectsOptions{Prefix: s.prefix + prefix, Recursive: true}) {
+		if obj.Err != nil {
+			return nil, obj.Err
+		}
+		out = append(out, Info{Name: strings.TrimPrefix(obj.Key, s.prefix), Size: obj.Size, ModTime: obj.LastModified})
+	}
+	return out, nil
+}
+
+// Delete succeeds for a missing object, as S3 does.
+func (s *S3) Delete(ctx context.Context, name string) error {
+	return s.client.RemoveObject(ctx, s.bucket, s.prefix+name, minio.RemoveObjectOptions{})
+}
+
+// Ping is a readiness check: the bucket must exist and the keys reach it.
+func (s *S3) Ping(ctx context.Context) error {
+	ok, err := s.client.BucketExists(ctx, s.bucket)
+	if err == nil && !ok {
+		err = minio.ErrorResponse{Code: "NoSuchBucket", Message: "bucket " + s.bucket + " does not exist"}
+	}
+	return err
+}
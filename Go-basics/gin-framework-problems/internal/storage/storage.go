@@ -0,0 +1,51 @@
+// Package storage keeps uploaded files on local disk or in an S3-compatible
+// bucket (AWS S3, MinIO, ...), behind one interface, so an example written
+// against it runs the same on a laptop and in a container without a volume.
+//
+// Files are named by slash-separated paths such as "thumbnails/128/<id>.png";
+// Local maps them under its directory and S3 uses them as object keys.
+package storage
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"time"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/config"
+)
+
+// ErrNotExist is what Open and Stat fail with for a file that isn't stored.
+var ErrNotExist = fs.ErrNotExist
+
+// Storage keeps files by name.
+type Storage interface {
+	// Save stores r's content as name, replacing any file of that name.
+	// size is the content's length, or -1 when unknown. Readers never see a
+	// half-written file.
+	Save(ctx context.Context, name string, r io.Reader, size int64) error
+	// Open returns name's content for the caller to close. It can seek, so
+	// http.ServeContent can serve ranges of it.
+	Open(ctx context.Context, name string) (io.ReadSeekCloser, error)
+	Stat(ctx context.Context, name string) (Info, error)
+	// List returns the files whose names start with prefix, sorted by name.
+	List(ctx context.Context, prefix string) ([]Info, error)
+	// Delete removes name. A file that is already gone is not an error.
+	Delete(ctx context.Context, name string) error
+}
+
+// Info describes a stored file.
+type Info struct {
+	Name    string
+	Size    int64
+	ModTime time.Time
+}
+
+// New returns the storage storage.backend picks: Local under
+// storage.upload_dir, or S3 with storage.s3.
+func New(cfg config.StorageConfig) (Storage, error) {
+	if cfg.Backend == "s3" {
+		return NewS3(cfg.S3)
+	}
+	return NewLocal(cfg.UploadDir), nil
+}
@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"context"
+	"io"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/tracing"
+)
+
+// Traced wraps s so each call is a child span of the one ctx carries, named
+// storage.<operation> and carrying backend and the file name. The result has
+// only Storage's methods, so check s for others, such as Ping, first.
+func Traced(s Storage, backend string) Storage {
+	return traced{s, backend}
+}
+
+type traced struct {
+	Storage
+	backend string
+}
+
+func (t traced) start(ctx context.Context, op, name string) (context.Context, func(error)) {
+	ctx, span := tracing.Start(ctx, "storage."+op,
+		attribute.String("storage.backend", t.backend), attribute.String("file.name", name))
+	return ctx, func(err error) { tracing.End(span, err) }
+}
+
+func (t traced) Save(ctx context.Context, name string, r io.Reader, size int64) (err error) {
+	ctx, end := t.start(ctx, "save", name)
+	defer func() { end(err) }()
+	return t.Storage.Save(ctx, name, r, size)
+}
+
+func (t traced) Open(ctx context.Context, name string) (_ io.ReadSeekCloser, err error) {
+	ctx, end := t.start(ctx, "open", name)
+	defer func() { end(err) }()
+	return t.Storage.Open(ctx, name)
+}
+
+func (t traced) Stat(ctx context.Context, name string) (_ Info, err error) {
+	ctx, end := t.start(ctx, "stat", name)
+	defer func() { end(err) }()
+	return t.Storage.Stat(ctx, name)
+}
+
+func (t traced) List(ctx context.Context, prefix string) (_ []Info, err error) {
+	ctx, end := t.start(ctx, "list", prefix)
+	defer func() { end(err) }()
+	return t.Storage.List(ctx, prefix)
+}
+
+func (t traced) Delete(ctx context.Context, name string) (err error) {
+	ctx, end := t.start(ctx, "delete", name)
+	defer func() { end(err) }()
+	return t.Storage.Delete(ctx, name)
+}
@@ -0,0 +1,53 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTraced(t *testing.T) {
+	spans := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	ctx := t.Context()
+	s := Traced(NewLocal(t.TempDir()), "local")
+	if err := s.Save(ctx, "a", strings.NewReader("a"), 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Stat(ctx, "missing"); err == nil {
+		t.Fatal("Stat of a missing file succeeded")
+	}
+
+	ended := spans.Ended()
+	if len(ended) != 2 {
+		t.Fatalf("%d spans, want 2", len(ended))
+	}
+	tests := []struct {
+		name, file string
+		status     codes.Code
+	}{
+		{"storage.save", "a", codes.Unset},
+		{"storage.stat", "missing", codes.Error},
+	}
+	for i, tt := range tests {
+		span := ended[i]
+		if span.Name() != tt.name || span.Status().Code != tt.status {
+			t.Errorf("span %d = %s (%v), want %s (%v)", i, span.Name(), span.Status().Code, tt.name, tt.status)
+		}
+		attrs := attribute.NewSet(span.Attributes()...)
+		if v, _ := attrs.Value("file.name"); v.AsString() != tt.file {
+			t.Errorf("%s: file.name = %q, want %q", tt.name, v.AsString(), tt.file)
+		}
+		if v, _ := attrs.Value("storage.backend"); v.AsString() != "local" {
+			t.Errorf("%s: storage.backend = %q, want local", tt.name, v.AsString())
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package tenant
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/apperror"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/audit"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+)
+
+var (
+	errTenantNotFound = apperror.NotFound("tenant not found")
+	errTenantDisabled = apperror.Forbidden("tenant disabled")
+)
+
+// Middleware scopes each request to a tenant: the one the header names,
+// else the one whose ID is the subdomain of baseDomain the request was sent
+// to, as acme for acme.hub.example.com, else the default one. It answers 404
+// for a tenant that doesn't exist and 403 for a disabled one, and stores the
+// ID under middleware.TenantKey too, so Auth refuses other tenants' users.
+func (r *Registry) Middleware(header, baseDomain string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		t, err := r.Resolve(c.GetHeader(header), c.Request.Host, baseDomain)
+		if err != nil {
+			middleware.Fail(c, err)
+			return
+		}
+		c.Set(middleware.TenantKey, t.ID)
+		c.Request = c.Request.WithContext(NewContext(c.Request.Context(), t.ID))
+		c.Next()
+	}
+}
+
+// Resolve is the tenant a request is for, by Middleware's rules: named, the
+// one whose ID is host's subdomain of baseDomain, or the default one. It
+// fails with a 404 for a tenant that doesn't exist and a 403 for a disabled
+// one. Servers other than gin's, such as a gRPC one, scope their calls with
+// it.
+func (r *Registry) Resolve(named, host, baseDomain string) (Tenant, error) {
+	id := named
+	if id == "" {
+		id = subdomain(host, baseDomain)
+	}
+	if id == "" {
+		id = DefaultID
+	}
+	t, ok := r.Get(id)
+	if !ok {
+		return Tenant{}, errTenantNotFound
+	}
+	if t.Disabled {
+		return Tenant{}, errTenantDisabled
+	}
+	return t, nil
+}
+
+// subdomain is the label of host just under baseDomain, or "" when host is
+// baseDomain itself, another domain, or baseDomain is empty.
+func subdomain(host, baseDomain string) string {
+	if baseDomain == "" {
+		return ""
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	label, ok := strings.CutSuffix(strings.ToLower(host), "."+strings.ToLower(baseDomain))
+	if !ok || strings.Contains(label, ".") {
+		return ""
+	}
+	return label
+}
+
+// Routes mounts the admin API on g:
+//
+//	GET    /      every tenant
+//	POST   /      create {id, name}
+//	GET    /:id
+//	PATCH  /:id   change {name, disabled}
+//	DELETE /:id   refuse the tenant's requests from now on; its data is kept
+func (r *Registry) Routes(g gin.IRouter) {
+	g.GET("", r.list)
+	g.POST("", middleware.Handle(r.create))
+	g.GET("/:id", middleware.Handle(r.get))
+	g.PATCH("/:id", middleware.Handle(r.patch))
+	g.DELETE("/:id", middleware.Handle(r.remove))
+}
+
+func (r *Registry) list(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"tenants": r.List()})
+}
+
+func (r *Registry) get(c *gin.Context) error {
+	t, ok := r.Get(c.Param("id"))
+	if !ok {
+		return errTenantNotFound
+	}
+	c.JSON(http.StatusOK, t)
+	return nil
+}
+
+func (r *Registry) create(c *gin.Context) error {
+	var req struct {
+		ID   string `json:"id" binding:"required"`
+		Name string `json:"name" binding:"max=100"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return middleware.BadInput(c, err)
+	}
+	t, err := r.Create(req.ID, req.Name)
+	if err != nil {
+		return changeError(err)
+	}
+	recordChange(c, t.ID, "create")
+	c.JSON(http.StatusCreated, t)
+	return nil
+}
+
+func (r *Registry) patch(c *gin.Context) error {
+	var req struct {
+		Name     *string `json:"name" binding:"omitempty,max=100"`
+		Disabled *bool   `json:"disabled"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return middleware.BadInput(c, err)
+	}
+	t, err := r.Update(c.Param("id"), func(t *Tenant) {
+		if req.Name != nil && strings.TrimSpace(*req.Name) != "" {
+			t.Name = strings.TrimSpace(*req.Name)
+		}
+		if req.Disabled != nil {
+			t.Disabled = *req.Disabled
+		}
+	})
+	if err != nil {
+		return changeError(err)
+	}
+	recordChange(c, t.ID, "update")
+	c.JSON(http.StatusOK, t)
+	return nil
+}
+
+func (r *Registry) remove(c *gin.Context) error {
+	if err := r.Delete(c.Param("id")); err != nil {
+		return changeError(err)
+	}
+	recordChange(c, c.Param("id"), "delete")
+	c.Status(http.StatusNoContent)
+	return nil
+}
+
+// changeError is the answer to a failed change: 404 for a tenant that
+// doesn't exist, 409 for one that does already, 400 for anything else.
+func changeError(err error) error {
+	switch {
+	case errors.Is(err, ErrUnknownTenant):
+		return errTenantNotFound
+	case errors.Is(err, ErrTenantExists):
+		return apperror.Conflict(err.Error())
+	}
+	return apperror.BadRequest(err.Error())
+}
+
+// recordChange writes a successful change to a tenant to the audit log.
+func recordChange(c *gin.Context, id, change string) {
+	audit.Record(c, audit.Event{Action: audit.TenantUpdate, Outcome: audit.Success, Target: id,
+		Details: map[string]string{"change": change}})
+}
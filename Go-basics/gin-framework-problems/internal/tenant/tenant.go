@@ -0,0 +1,170 @@
+// Package tenant partitions the users, books and files examples between
+// tenants. Middleware works out a request's tenant from its X-Tenant-ID
+// header or its subdomain and puts it in the request context, and the
+// examples' stores keep each user, book and file with the tenant it was
+// created for, hiding it from every other tenant's requests.
+//
+// Tenants are managed by the operator, through the admin API NewEngine
+// mounts at /admin/tenants. The default tenant always exists: it serves
+// requests that name no tenant and owns the data stored before tenancy was
+// turned on.
+package tenant
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultID is the ID of the default tenant.
+const DefaultID = "default"
+
+var (
+	ErrInvalidID     = errors.New("tenant ID must be 2 to 32 lowercase letters, digits or dashes")
+	ErrTenantExists  = errors.New("tenant already exists")
+	ErrUnknownTenant = errors.New("tenant not found")
+	ErrDefaultTenant = errors.New("the default tenant can't be deleted")
+)
+
+// validID keeps IDs usable as subdomains.
+var validID = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,30}[a-z0-9]$`)
+
+// Tenant is one customer of the hub. A disabled tenant's requests are
+// refused, but its data is kept.
+type Tenant struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Disabled  bool      `json:"disabled"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type contextKey struct{}
+
+// NewContext returns ctx scoped to tenant id.
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the tenant ctx is scoped to. ok is false for
+// contexts that aren't, such as those of scheduled tasks and of requests
+// while tenancy is off, which see every tenant's data.
+func FromContext(ctx context.Context) (id string, ok bool) {
+	id, _ = ctx.Value(contextKey{}).(string)
+	return id, id != ""
+}
+
+// Unscoped returns ctx, keeping its deadline and values, but seeing every
+// tenant's data, for work done on behalf of all of them.
+func Unscoped(ctx context.Context) context.Context {
+	return context.WithValue(ctx, contextKey{}, "")
+}
+
+// Visible reports whether data stored for owner may be read in ctx. Data
+// stored without a tenant belongs to the default one.
+func Visible(ctx context.Context, owner string) bool {
+	id, ok := FromContext(ctx)
+	return !ok || id == Owner(owner)
+}
+
+// Owner is the tenant of data stored for owner, the default one when
+// owner is empty.
+func Owner(owner string) string {
+	if owner == "" {
+		return DefaultID
+	}
+	return owner
+}
+
+// Assign is the tenant new data created in ctx belongs to: ctx's, or the
+// default one.
+func Assign(ctx context.Context) string {
+	id, _ := FromContext(ctx)
+	return Owner(id)
+}
+
+// Registry holds the tenants. It is safe for concurrent use.
+type Registry struct {
+	mu      sync.RWMutex
+	tenants map[string]Tenant
+}
+
+// New returns a registry with only the default tenant.
+func New() *Registry {
+	return &Registry{tenants: map[string]Tenant{
+		DefaultID: {ID: DefaultID, Name: "Default", CreatedAt: time.Now().UTC()},
+	}}
+}
+
+// Default is the registry the middleware and the admin API share.
+var Default = New()
+
+// List returns every tenant, ordered by ID.
+func (r *Registry) List() []Tenant {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]Tenant, 0, len(r.tenants))
+	for _, t := range r.tenants {
+		out = append(out, t)
+	}
+	slices.SortFunc(out, func(a, b Tenant) int { return strings.Compare(a.ID, b.ID) })
+	return out
+}
+
+func (r *Registry) Get(id string) (Tenant, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	t, ok := r.tenants[id]
+	return t, ok
+}
+
+// Create adds a tenant, named after its ID unless it has a name.
+func (r *Registry) Create(id, name string) (Tenant, error) {
+	if !validID.MatchString(id) {
+		return Tenant{}, ErrInvalidID
+	}
+	t := Tenant{ID: id, Name: strings.TrimSpace(name), CreatedAt: time.Now().UTC()}
+	if t.Name == "" {
+		t.Name = id
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.tenants[id]; ok {
+		return Tenant{}, ErrTenantExists
+	}
+	r.tenants[id] = t
+	return t, nil
+}
+
+// Update applies fn to a stored tenant. The ID can't change.
+func (r *Registry) Update(id string, fn func(*Tenant)) (Tenant, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t, ok := r.tenants[id]
+	if !ok {
+		return Tenant{}, ErrUnknownTenant
+	}
+	fn(&t)
+	t.ID = id
+	r.tenants[id] = t
+	return t, nil
+}
+
+// Delete removes a tenant, so its requests are refused. What it stored is
+// kept, and comes back if a tenant with the same ID is created.
+func (r *Registry) Delete(id string) error {
+	if id == DefaultID {
+		return ErrDefaultTenant
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.tenants[id]; !ok {
+		return ErrUnknownTenant
+	}
+	delete(r.tenants, id)
+	return nil
+}
@@ -0,0 +1,40 @@
+package testutil
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is a clock that only moves when told to, for server.Deps,
+// middleware.WithClock and jwt.WithClock (as clock.Now). Tests advance it
+// past a window instead of sleeping through it.
+type Clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewClock returns a clock stopped at now.
+func NewClock(now time.Time) *Clock {
+	return &Clock{now: now}
+}
+
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance moves the clock on by d and returns the new time.
+func (c *Clock) Advance(d time.Duration) time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	return c.now
+}
+
+// Set stops the clock at now, which may be earlier than it was.
+func (c *Clock) Set(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = now
+}
@@ -0,0 +1,50 @@
+package testutil
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// Server starts h on a real listener, for tests that need a network client:
+// streaming, WebSockets, the hub client. It is closed when the test ends.
+func Server(t testing.TB, h http.Handler) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// File is one file part of a multipart form.
+type File struct {
+	Field   string // form field, e.g. "file" or "cover"
+	Name    string
+	Content []byte
+}
+
+// DoMultipart posts fields and files as multipart/form-data, as a browser
+// upload form would.
+func DoMultipart(t testing.TB, h http.Handler, method, path string, fields map[string]string, files []File, opts ...RequestOption) *httptest.ResponseRecorder {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for k, v := range fields {
+		if err := mw.WriteField(k, v); err != nil {
+			t.Fatalf("write field %s: %v", k, err)
+		}
+	}
+	for _, f := range files {
+		part, err := mw.CreateFormFile(f.Field, f.Name)
+		if err != nil {
+			t.Fatalf("create form file %s: %v", f.Field, err)
+		}
+		part.Write(f.Content)
+	}
+	if err := mw.Close(); err != nil {
+		t.Fatalf("close multipart body: %v", err)
+	}
+	opts = append([]RequestOption{WithHeader("Content-Type", mw.FormDataContentType())}, opts...)
+	return Do(t, h, method, path, &body, opts...)
+}
@@ -0,0 +1,189 @@
+// Package testutil has helpers for testing the examples with httptest:
+// building router deps against throwaway config, sending JSON and multipart
+// requests, starting live test servers, comparing JSON bodies with readable
+// diffs and logging in test users.
+//
+// Every router builds its stores, scheduler and API docs from the Deps it
+// is given, so tests don't share data. Examples with stores of their own
+// also take them in their Deps, for tests that need to look inside them.
+package testutil
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/apidocs"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/config"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/events"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/scheduler"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/server"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// Config returns the default config with every directory and file under
+// t.TempDir(), the scheduler off and quiet logs.
+func Config(t testing.TB) *config.Config {
+	t.Helper()
+	cfg := config.Default()
+	dir := t.TempDir()
+	cfg.Storage.UploadDir = dir + "/uploads"
+	cfg.Storage.BackupDir = dir + "/backups"
+	cfg.Mail.SinkDir = dir + "/mail"
+	cfg.Database.Path = dir + "/hub.db"
+	cfg.Scheduler.Enabled = false
+	cfg.Log.Level = "error"
+	return cfg
+}
+
+// Deps returns deps for a router built on cfg (Config(t) when nil) with the
+// system clock, a scheduler and API docs of its own, and runs the hooks the
+// router adds when the test ends.
+func Deps(t testing.TB, cfg *config.Config) server.Deps {
+	t.Helper()
+	if cfg == nil {
+		cfg = Config(t)
+	}
+	// the previous test's hooks closed the shared bus
+	events.Default = events.NewMemoryBus(256)
+
+	hooks := new(server.Hooks)
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		for i := len(*hooks) - 1; i >= 0; i-- {
+			if err := (*hooks)[i](ctx); err != nil {
+				t.Errorf("shutdown hook: %v", err)
+			}
+		}
+	})
+	return server.Deps{Config: cfg, Hooks: hooks, Scheduler: scheduler.New(), Docs: &apidocs.Registry{}}
+}
+
+// Router builds an example router from Deps(t, cfg).
+func Router(t testing.TB, newRouter func(server.Deps) *gin.Engine, cfg *config.Config) *gin.Engine {
+	t.Helper()
+	return newRouter(Deps(t, cfg))
+}
+
+// Engine returns a bare engine with request IDs and the given middleware,
+// for testing middleware on its own.
+func Engine(mw ...gin.HandlerFunc) *gin.Engine {
+	router := gin.New()
+	router.Use(middleware.RequestID())
+	router.Use(mw...)
+	return router
+}
+
+// RequestOption changes a request before it is sent.
+type RequestOption func(*http.Request)
+
+// WithToken sends token as a bearer Authorization header.
+func WithToken(token string) RequestOption {
+	return func(r *http.Request) { r.Header.Set("Authorization", "Bearer "+token) }
+}
+
+// WithBasicAuth sends username and password as basic auth, as the admin
+// routes take them.
+func WithBasicAuth(username, password string) RequestOption {
+	return func(r *http.Request) { r.SetBasicAuth(username, password) }
+}
+
+func WithHeader(key, value string) RequestOption {
+	return func(r *http.Request) { r.Header.Set(key, value) }
+}
+
+// Do sends a request with a raw body to h and returns the recorded response.
+func Do(t testing.TB, h http.Handler, method, path string, body io.Reader, opts ...RequestOption) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, path, body)
+	for _, opt := range opts {
+		opt(req)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+// DoJSON sends body (if not nil) as JSON. A string or []byte body is sent
+// as is, so tests can post malformed JSON.
+func DoJSON(t testing.TB, h http.Handler, method, path string, body any, opts ...RequestOption) *httptest.ResponseRecorder {
+	t.Helper()
+	var r io.Reader
+	switch b := body.(type) {
+	case nil:
+	case string:
+		r = bytes.NewBufferString(b)
+	case []byte:
+		r = bytes.NewReader(b)
+	default:
+		data, err := json.Marshal(b)
+		if err != nil {
+			t.Fatalf("marshal request body: %v", err)
+		}
+		r = bytes.NewReader(data)
+	}
+	opts = append([]RequestOption{WithHeader("Content-Type", "application/json")}, opts...)
+	return Do(t, h, method, path, r, opts...)
+}
+
+// Decode unmarshals the response body into a T.
+func Decode[T any](t testing.TB, w *httptest.ResponseRecorder) T {
+	t.Helper()
+	var v T
+	if err := json.Unmarshal(w.Body.Bytes(), &v); err != nil {
+		t.Fatalf("decode response %q: %v", w.Body.String(), err)
+	}
+	return v
+}
+
+// AssertStatus fails the test if the response status isn't want.
+func AssertStatus(t testing.TB, w *httptest.ResponseRecorder, want int) {
+	t.Helper()
+	if w.Code != want {
+		t.Fatalf("status = %d, want %d; body: %s", w.Code, want, w.Body.String())
+	}
+}
+
+// AssertJSON checks the status and that the body equals want once both are
+// decoded, reporting a diff. Keys listed in ignore (e.g. "request_id", "id")
+// are dropped from top-level objects before comparing.
+func AssertJSON(t testing.TB, w *httptest.ResponseRecorder, status int, want any, ignore ...string) {
+	t.Helper()
+	AssertStatus(t, w, status)
+
+	var got any
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("response is not JSON: %v; body: %s", err, w.Body.String())
+	}
+	// round-trip want so both sides use the same types (float64, map[string]any)
+	data, err := json.Marshal(want)
+	if err != nil {
+		t.Fatalf("marshal want: %v", err)
+	}
+	var wantv any
+	json.Unmarshal(data, &wantv)
+
+	for _, v := range []any{got, wantv} {
+		if m, ok := v.(map[string]any); ok {
+			for _, k := range ignore {
+				delete(m, k)
+			}
+		}
+	}
+	if diff := cmp.Diff(wantv, got); diff != "" {
+		t.Errorf("response body mismatch (-want +got):\n%s", diff)
+	}
+}
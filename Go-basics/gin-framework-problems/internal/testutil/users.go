@@ -0,0 +1,39 @@
+package testutil
+
+import (
+	"net/http"
+	"testing"
+)
+
+// RegisterUser creates an account through POST /api/register and logs it in
+// through POST /api/login, returning the bearer token. h must mount the
+// users example's handlers (users, graphqlapi, caching). The password is
+// "password123".
+func RegisterUser(t testing.TB, h http.Handler, username string) string {
+	t.Helper()
+	w := DoJSON(t, h, http.MethodPost, "/api/register", map[string]string{
+		"username": username,
+		"email":    username + "@example.com",
+		"password": "password123",
+	})
+	AssertStatus(t, w, http.StatusCreated)
+	return Login(t, h, "/api/login", username, "password123")
+}
+
+// Login posts credentials to path and returns the token from the response.
+// opts change the request, e.g. to sign in to a tenant.
+func Login(t testing.TB, h http.Handler, path, username, password string, opts ...RequestOption) string {
+	t.Helper()
+	w := DoJSON(t, h, http.MethodPost, path, map[string]string{
+		"username": username,
+		"password": password,
+	}, opts...)
+	AssertStatus(t, w, http.StatusOK)
+	token := Decode[struct {
+		Token string `json:"token"`
+	}](t, w).Token
+	if token == "" {
+		t.Fatalf("login response has no token: %s", w.Body.String())
+	}
+	return token
+}
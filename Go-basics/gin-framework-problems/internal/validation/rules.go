@@ -0,0 +1,101 @@
+package validation
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/go-playground/validator/v10"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/i18n"
+)
+
+// rules are the custom tags registered on gin's validator.
+var rules = []struct {
+	tag string
+	fn  validator.Func
+	msg string
+}{
+	{"notblank", notBlank, "{{.Field}} must not be blank"},
+	{"password", password, "{{.Field}} must be 8 to 72 characters with a letter and a digit"},
+	// replaces the validator's own isbn, which rejects hyphens and spaces
+	{"isbn", isbn, "{{.Field}} must be a valid ISBN-10 or ISBN-13"},
+	{"locale", locale, "{{.Field}} must be a language with a catalog in internal/i18n"},
+	{"slug", slug, "{{.Field}} must be lowercase letters and digits, with single hyphens between words"},
+}
+
+// notBlank rejects strings that are empty or only whitespace; "required"
+// lets "   " through.
+func notBlank(fl validator.FieldLevel) bool {
+	return strings.TrimSpace(fl.Field().String()) != ""
+}
+
+// password wants at least 8 characters including a letter and a digit, and
+// at most 72 bytes, the most bcrypt hashes.
+func password(fl validator.FieldLevel) bool {
+	s := fl.Field().String()
+	var letter, digit bool
+	for _, r := range s {
+		switch {
+		case unicode.IsLetter(r):
+			letter = true
+		case unicode.IsDigit(r):
+			digit = true
+		}
+	}
+	return len([]rune(s)) >= 8 && len(s) <= 72 && letter && digit
+}
+
+// locale accepts a language tag that internal/i18n can translate into.
+func locale(fl validator.FieldLevel) bool {
+	return i18n.Supported(fl.Field().String())
+}
+
+// slug accepts lowercase ASCII words of letters and digits joined by single
+// hyphens, e.g. "science-fiction", fit for a URL path.
+func slug(fl validator.FieldLevel) bool {
+	for _, word := range strings.Split(fl.Field().String(), "-") {
+		if word == "" || strings.IndexFunc(word, func(r rune) bool {
+			return (r < 'a' || r > 'z') && (r < '0' || r > '9')
+		}) >= 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// isbn accepts an ISBN-10 or ISBN-13 with a valid check digit, ignoring
+// hyphens and spaces.
+func isbn(fl validator.FieldLevel) bool {
+	s := strings.NewReplacer("-", "", " ", "").Replace(fl.Field().String())
+	switch len(s) {
+	case 10:
+		sum := 0
+		for i, r := range s {
+			var d int
+			switch {
+			case r >= '0' && r <= '9':
+				d = int(r - '0')
+			case (r == 'X' || r == 'x') && i == 9:
+				d = 10
+			default:
+				return false
+			}
+			sum += d * (10 - i)
+		}
+		return sum%11 == 0
+	case 13:
+		sum := 0
+		for i, r := range s {
+			if r < '0' || r > '9' {
+				return false
+			}
+			d := int(r - '0')
+			if i%2 == 1 {
+				d *= 3
+			}
+			sum += d
+		}
+		return sum%10 == 0
+	}
+	return false
+}
@@ -0,0 +1,114 @@
+// Package validation sets up gin's validator for the examples: fields are
+// reported by their JSON (or form) name, a few custom rules are registered,
+// and failures are translated into one localized message per field.
+//
+// Importing the package (middleware does) is enough to install it.
+package validation
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/i18n"
+)
+
+var (
+	messagesMu sync.RWMutex
+	// messages maps a validation tag to the English text for a failure; the
+	// catalog in internal/i18n translates it. {{.Field}} and {{.Param}} are
+	// filled in from the failure.
+	messages = map[string]string{
+		"required": "{{.Field}} is required",
+		// without the field it names
+		"required_without": "{{.Field}} is required",
+		"email":            "{{.Field}} must be a valid email address",
+		"http_url":         "{{.Field}} must be an http or https URL",
+		"numeric":          "{{.Field}} must be a number",
+		"number":           "{{.Field}} must be a number",
+		"oneof":            "{{.Field}} must be one of {{.Param}}",
+	}
+)
+
+func init() {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+	// report fields by the name clients sent
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		for _, tag := range []string{"json", "form"} {
+			name, _, _ := strings.Cut(f.Tag.Get(tag), ",")
+			if name == "-" {
+				return ""
+			}
+			if name != "" {
+				return name
+			}
+		}
+		return f.Name
+	})
+	for _, r := range rules {
+		if err := v.RegisterValidation(r.tag, r.fn); err != nil {
+			panic(err)
+		}
+		RegisterMessage(r.tag, r.msg)
+	}
+}
+
+// RegisterMessage sets the message for a validation tag, for rules added
+// outside this package. Add the text to the i18n catalogs to translate it.
+func RegisterMessage(tag, msg string) {
+	messagesMu.Lock()
+	defer messagesMu.Unlock()
+	messages[tag] = msg
+}
+
+// message is the English text for fe.
+func message(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "min", "gte", "max", "lte":
+		// lengths for strings, values for everything else
+		msg := "{{.Field}} must be at least {{.Param}}"
+		if t := fe.Tag(); t == "max" || t == "lte" {
+			msg = "{{.Field}} must be at most {{.Param}}"
+		}
+		if fe.Kind() == reflect.String {
+			msg += " characters long"
+		}
+		return msg
+	}
+	messagesMu.RLock()
+	defer messagesMu.RUnlock()
+	if msg, ok := messages[fe.Tag()]; ok {
+		return msg
+	}
+	return "{{.Field}} is invalid"
+}
+
+// Translate turns an error from c.ShouldBind* into field → localized message.
+// It reports false when err isn't a validation failure (malformed JSON, a
+// wrong type), which callers should treat as a plain bad request. Only the
+// first failure per field is kept.
+func Translate(c *gin.Context, err error) (map[string]string, bool) {
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return nil, false
+	}
+	fields := make(map[string]string, len(verrs))
+	for _, fe := range verrs {
+		if _, seen := fields[fe.Field()]; seen {
+			continue
+		}
+		fields[fe.Field()] = i18n.Localize(c, message(fe), map[string]any{
+			"Field": fe.Field(),
+			"Param": fe.Param(),
+		})
+	}
+	return fields, true
+}
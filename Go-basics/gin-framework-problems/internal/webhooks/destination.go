@@ -0,0 +1,64 @@
+package webhooks
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// sharedAddressSpace is carrier-grade NAT, private in all but name.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// public reports whether ip is an address on the internet rather than the
+// host itself, a private network or a link-local one, where cloud metadata
+// services such as 169.254.169.254 answer.
+func public(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return ip.IsValid() && !ip.IsUnspecified() && !ip.IsLoopback() && !ip.IsPrivate() &&
+		!ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() && !ip.IsInterfaceLocalMulticast() &&
+		!ip.IsMulticast() && !sharedAddressSpace.Contains(ip)
+}
+
+// checkHost refuses a URL host that names a non-public address outright.
+// Other names are only known once resolved, which the dialer of NewClient
+// checks.
+func checkHost(host string) error {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrPrivateAddress
+	}
+	if ip, err := netip.ParseAddr(strings.Trim(host, "[]")); err == nil && !public(ip) {
+		return ErrPrivateAddress
+	}
+	return nil
+}
+
+// publicTransport is http.DefaultTransport with a dialer that refuses to
+// connect to anything but public addresses. It checks the address being
+// dialed, after DNS, so a name that resolves to 127.0.0.1, now or on a later
+// attempt, gets nowhere either.
+func publicTransport() *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			ap, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !public(ap.Addr()) {
+				return fmt.Errorf("%w: %s", ErrPrivateAddress, ap.Addr())
+			}
+			return nil
+		},
+	}
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	// a proxy would dial on the endpoint's behalf, unchecked
+	t.Proxy = nil
+	t.DialContext = dialer.DialContext
+	return t
+}
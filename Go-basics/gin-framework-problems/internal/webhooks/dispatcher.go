@@ -0,0 +1,563 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/httpclient"
+)
+
+// Dispatcher keeps the registered endpoints and delivers events to them on a
+// fixed number of workers. A failed attempt is retried after
+// base*2^(attempt-1), capped at maxBackoff; network errors, 408, 429 and 5xx
+// are retried, any other non-2xx answer kills the delivery right away.
+type Dispatcher struct {
+	client      *http.Client
+	workers     int
+	maxAttempts int
+	backoff     time.Duration
+	maxBackoff  time.Duration
+	history     int
+	logger      *slog.Logger
+	events      []string // the names endpoints may subscribe to; nil allows any
+	consumer    []string // the names consumers' endpoints may subscribe to
+	private     bool     // endpoints may be on private networks
+
+	mu         sync.Mutex
+	endpoints  map[string]*Endpoint
+	deliveries map[string]*Delivery
+	order      []string // delivery IDs, oldest first
+	succeeded  int
+	queue      chan string
+
+	ctx  context.Context // canceled by Shutdown
+	stop context.CancelFunc
+	wg   sync.WaitGroup
+}
+
+type Option func(*Dispatcher)
+
+// NewClient returns a client for deliveries: each attempt times out after
+// timeout, and redirects are not followed. It doesn't retry on its own, since
+// the dispatcher does with its longer backoff, but an endpoint that keeps
+// failing trips its circuit breaker. Unless private is set it connects only
+// to public addresses, so an endpoint can't reach the services next to this
+// one.
+func NewClient(timeout time.Duration, private bool) *http.Client {
+	opts := []httpclient.Option{httpclient.WithTimeout(timeout), httpclient.WithRetries(0), httpclient.WithoutRedirects()}
+	if !private {
+		opts = append(opts, httpclient.WithBase(publicTransport()))
+	}
+	return httpclient.New("webhooks", opts...)
+}
+
+// WithClient replaces the default NewClient(10 * time.Second, private).
+func WithClient(c *http.Client) Option {
+	return func(d *Dispatcher) { d.client = c }
+}
+
+func WithWorkers(n int) Option {
+	return func(d *Dispatcher) { d.workers = n }
+}
+
+func WithMaxAttempts(n int) Option {
+	return func(d *Dispatcher) { d.maxAttempts = n }
+}
+
+func WithBackoff(base, max time.Duration) Option {
+	return func(d *Dispatcher) {
+		d.backoff = base
+		d.maxBackoff = max
+	}
+}
+
+// WithHistory sets how many succeeded deliveries are kept (default 1000).
+// Dead letters are kept until they are redelivered or discarded.
+func WithHistory(n int) Option {
+	return func(d *Dispatcher) { d.history = n }
+}
+
+// WithEvents restricts subscriptions to the given event names and "*", so a
+// typo is refused when the endpoint is registered rather than never
+// delivered to. Without it any name is accepted.
+func WithEvents(names ...string) Option {
+	return func(d *Dispatcher) { d.events = names }
+}
+
+// WithConsumerEvents lists the event names the endpoints consumers register
+// through ConsumerRoutes may subscribe to; "*" and an empty list stand for
+// all of them. Without it consumers' endpoints only get pings. A consumer
+// receives only what PublishOwned addresses to it, never what Publish sends.
+func WithConsumerEvents(names ...string) Option {
+	return func(d *Dispatcher) { d.consumer = names }
+}
+
+// WithPrivateNetworks lets endpoints be on loopback, private and link-local
+// addresses, for development and tests. Without it AddEndpoint refuses such
+// URLs and the default client won't dial them.
+func WithPrivateNetworks() Option {
+	return func(d *Dispatcher) { d.private = true }
+}
+
+func WithLogger(logger *slog.Logger) Option {
+	return func(d *Dispatcher) { d.logger = logger }
+}
+
+// NewDispatcher returns a dispatcher with no endpoints. Call Start before
+// publishing.
+func NewDispatcher(opts ...Option) *Dispatcher {
+	d := &Dispatcher{
+		workers:     4,
+		maxAttempts: 6,
+		backoff:     2 * time.Second,
+		maxBackoff:  5 * time.Minute,
+		history:     1000,
+		logger:      slog.Default(),
+		endpoints:   map[string]*Endpoint{},
+		deliveries:  map[string]*Delivery{},
+		queue:       make(chan string, 1000),
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	if d.client == nil {
+		d.client = NewClient(10*time.Second, d.private)
+	}
+	d.ctx, d.stop = context.WithCancel(context.Background())
+	return d
+}
+
+// Start launches the workers.
+func (d *Dispatcher) Start() {
+	for range d.workers {
+		d.wg.Add(1)
+		go d.work()
+	}
+}
+
+// Shutdown stops sending, interrupts attempts in flight and waits for the
+// workers to return or ctx to expire. Unfinished deliveries stay pending or
+// retrying.
+func (d *Dispatcher) Shutdown(ctx context.Context) error {
+	d.stop()
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("webhooks: workers still running: %w", ctx.Err())
+	}
+}
+
+// AddEndpoint registers e. ID and CreatedAt are filled in, and so is Secret
+// when it is empty; the returned endpoint is the only place the caller sees
+// a generated secret. An endpoint with an Owner may only subscribe to the
+// events of WithConsumerEvents.
+func (d *Dispatcher) AddEndpoint(e Endpoint) (Endpoint, error) {
+	u, err := url.Parse(e.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return Endpoint{}, ErrInvalidURL
+	}
+	if !d.private {
+		if err := checkHost(u.Hostname()); err != nil {
+			return Endpoint{}, err
+		}
+	}
+	allowed := d.events
+	if e.Owner != "" {
+		allowed = d.consumer
+		if allowed == nil {
+			allowed = []string{}
+		}
+	}
+	if allowed != nil {
+		for _, ev := range e.Events {
+			if ev != "*" && !slices.Contains(allowed, ev) {
+				return Endpoint{}, fmt.Errorf("%w %q", ErrUnknownEvent, ev)
+			}
+		}
+	}
+	e.ID = "ep_" + randomHex(8)
+	if e.Secret == "" {
+		e.Secret = NewSecret()
+	}
+	e.Events = append([]string{}, e.Events...)
+	e.CreatedAt = time.Now().UTC()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.endpoints[e.ID] = &e
+	return e, nil
+}
+
+// Endpoints returns the registered endpoints, oldest first.
+func (d *Dispatcher) Endpoints() []Endpoint {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	list := make([]Endpoint, 0, len(d.endpoints))
+	for _, e := range d.endpoints {
+		list = append(list, *e)
+	}
+	slices.SortFunc(list, func(a, b Endpoint) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	return list
+}
+
+func (d *Dispatcher) Endpoint(id string) (Endpoint, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	e, ok := d.endpoints[id]
+	if !ok {
+		return Endpoint{}, false
+	}
+	return *e, true
+}
+
+// RemoveEndpoint unregisters an endpoint. Its deliveries that haven't
+// succeeded yet become dead letters on their next attempt.
+func (d *Dispatcher) RemoveEndpoint(id string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, ok := d.endpoints[id]; !ok {
+		return ErrNotFound
+	}
+	delete(d.endpoints, id)
+	return nil
+}
+
+// Publish queues a delivery of event to every endpoint without an Owner
+// that subscribed to it. data is marshaled to JSON.
+func (d *Dispatcher) Publish(ctx context.Context, event string, data any) ([]Delivery, error) {
+	return d.PublishOwned(ctx, event, "", data)
+}
+
+// PublishOwned is Publish for an event about something owner owns: owner's
+// endpoints that subscribed to it get it too, if it is one of the consumer
+// events. An empty owner is no consumer.
+func (d *Dispatcher) PublishOwned(ctx context.Context, event, owner string, data any) ([]Delivery, error) {
+	toConsumer := owner != "" && slices.Contains(d.consumer, event)
+	d.mu.Lock()
+	var ids []string
+	for _, e := range d.endpoints {
+		if e.Wants(event) && (e.Owner == "" || toConsumer && e.Owner == owner) {
+			ids = append(ids, e.ID)
+		}
+	}
+	d.mu.Unlock()
+	return d.send(ctx, ids, event, data)
+}
+
+// Send queues a delivery of event to one endpoint, subscribed or not; the
+// API uses it for test pings.
+func (d *Dispatcher) Send(ctx context.Context, endpointID, event string, data any) (Delivery, error) {
+	if _, ok := d.Endpoint(endpointID); !ok {
+		return Delivery{}, ErrNotFound
+	}
+	list, err := d.send(ctx, []string{endpointID}, event, data)
+	if err != nil {
+		return Delivery{}, err
+	}
+	return list[0], nil
+}
+
+func (d *Dispatcher) send(ctx context.Context, endpointIDs []string, event string, data any) ([]Delivery, error) {
+	if d.ctx.Err() != nil {
+		return nil, ErrClosed
+	}
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	list := make([]Delivery, 0, len(endpointIDs))
+
+	d.mu.Lock()
+	for _, id := range endpointIDs {
+		dl := &Delivery{
+			ID:         "dlv_" + randomHex(8),
+			EndpointID: id,
+			Event:      event,
+			Payload:    payload,
+			Status:     StatusPending,
+			Attempts:   []Attempt{},
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+		d.deliveries[dl.ID] = dl
+		d.order = append(d.order, dl.ID)
+		list = append(list, *dl)
+	}
+	d.mu.Unlock()
+
+	for _, dl := range list {
+		d.logger.InfoContext(ctx, "webhook queued", "delivery_id", dl.ID, "endpoint_id", dl.EndpointID, "event", event)
+		d.enqueue(dl.ID)
+	}
+	return list, nil
+}
+
+// Filter narrows Deliveries; empty fields match everything.
+type Filter struct {
+	EndpointID string
+	Event      string
+	Status     Status
+}
+
+// Deliveries returns the deliveries that match f, newest first.
+func (d *Dispatcher) Deliveries(f Filter) []Delivery {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var list []Delivery
+	for i := len(d.order) - 1; i >= 0; i-- {
+		dl := d.deliveries[d.order[i]]
+		if (f.EndpointID == "" || dl.EndpointID == f.EndpointID) &&
+			(f.Event == "" || dl.Event == f.Event) &&
+			(f.Status == "" || dl.Status == f.Status) {
+			list = append(list, copyDelivery(dl))
+		}
+	}
+	return list
+}
+
+func (d *Dispatcher) Delivery(id string) (Delivery, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	dl, ok := d.deliveries[id]
+	if !ok {
+		return Delivery{}, false
+	}
+	return copyDelivery(dl), true
+}
+
+// Redeliver queues a dead letter again with a fresh set of attempts, e.g.
+// once the receiver is fixed. Its earlier attempts stay in its history.
+func (d *Dispatcher) Redeliver(id string) (Delivery, error) {
+	if d.ctx.Err() != nil {
+		return Delivery{}, ErrClosed
+	}
+	d.mu.Lock()
+	dl, ok := d.deliveries[id]
+	if !ok {
+		d.mu.Unlock()
+		return Delivery{}, ErrNotFound
+	}
+	if dl.Status != StatusDead {
+		d.mu.Unlock()
+		return copyDelivery(dl), ErrNotDead
+	}
+	dl.Status = StatusPending
+	dl.tries = 0
+	dl.UpdatedAt = time.Now().UTC()
+	out := copyDelivery(dl)
+	d.mu.Unlock()
+
+	d.enqueue(id)
+	return out, nil
+}
+
+// Discard drops a dead letter for good.
+func (d *Dispatcher) Discard(id string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	dl, ok := d.deliveries[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if dl.Status != StatusDead {
+		return ErrNotDead
+	}
+	d.remove(id)
+	return nil
+}
+
+// enqueue hands a delivery to the workers, or retries the hand-off after the
+// first backoff when the queue is full.
+func (d *Dispatcher) enqueue(id string) {
+	select {
+	case d.queue <- id:
+	default:
+		d.wg.Add(1)
+		go d.retry(id, d.backoff)
+	}
+}
+
+func (d *Dispatcher) retry(id string, delay time.Duration) {
+	defer d.wg.Done()
+	t := time.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-t.C:
+	case <-d.ctx.Done():
+		return
+	}
+	select {
+	case d.queue <- id:
+	case <-d.ctx.Done():
+	}
+}
+
+func (d *Dispatcher) work() {
+	defer d.wg.Done()
+	for {
+		select {
+		case id := <-d.queue:
+			d.attempt(id)
+		case <-d.ctx.Done():
+			return
+		}
+	}
+}
+
+func (d *Dispatcher) attempt(id string) {
+	d.mu.Lock()
+	dl, ok := d.deliveries[id]
+	if !ok || (dl.Status != StatusPending && dl.Status != StatusRetrying) {
+		// discarded, or queued twice
+		d.mu.Unlock()
+		return
+	}
+	dl.tries++
+	dl.NextAttemptAt = nil
+	e, ok := d.endpoints[dl.EndpointID]
+	var ep Endpoint
+	if ok {
+		ep = *e
+	}
+	event, payload, created, tries := dl.Event, dl.Payload, dl.CreatedAt, dl.tries
+	d.mu.Unlock()
+
+	log := d.logger.With("delivery_id", id, "endpoint_id", dl.EndpointID, "event", event, "attempt", tries)
+	start := time.Now()
+	var code int
+	var err error
+	if ok {
+		code, err = d.post(ep, id, event, created, payload)
+	} else {
+		err = fmt.Errorf("endpoint %s was removed", dl.EndpointID)
+	}
+	att := Attempt{At: start.UTC(), StatusCode: code, DurationMS: time.Since(start).Milliseconds()}
+	if err != nil {
+		att.Error = err.Error()
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	dl.Attempts = append(dl.Attempts, att)
+	dl.UpdatedAt = time.Now().UTC()
+
+	switch {
+	case err == nil:
+		dl.Status = StatusSucceeded
+		d.succeeded++
+		d.trim()
+		log.Info("webhook delivered", "status", code)
+	case d.ctx.Err() != nil:
+		// shutting down: the attempt was interrupted, not refused
+		dl.Status = StatusRetrying
+		log.Warn("webhook interrupted by shutdown", "error", err)
+	case !ok || !retryable(code) || tries >= d.maxAttempts:
+		dl.Status = StatusDead
+		log.Error("webhook moved to dead letters", "status", code, "error", err)
+	default:
+		delay := d.delay(tries)
+		next := time.Now().Add(delay).UTC()
+		dl.Status = StatusRetrying
+		dl.NextAttemptAt = &next
+		log.Warn("webhook will be retried", "status", code, "error", err, "retry_in", delay)
+		d.wg.Add(1)
+		go d.retry(id, delay)
+	}
+}
+
+// post makes one attempt and returns the receiver's status code, 0 if it
+// didn't answer. Any non-2xx answer is an error.
+func (d *Dispatcher) post(ep Endpoint, id, event string, created time.Time, payload json.RawMessage) (int, error) {
+	body, err := json.Marshal(map[string]any{
+		"id":         id,
+		"event":      event,
+		"created_at": created,
+		"data":       payload,
+	})
+	if err != nil {
+		return 0, err
+	}
+	req, err := http.NewRequestWithContext(d.ctx, http.MethodPost, ep.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	now := time.Now()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "tech-learning-hub-webhooks")
+	req.Header.Set("X-Webhook-ID", id)
+	req.Header.Set("X-Webhook-Event", event)
+	req.Header.Set("X-Webhook-Timestamp", strconv.FormatInt(now.Unix(), 10))
+	req.Header.Set("X-Webhook-Signature", Sign(ep.Secret, now, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	// drain a little so the connection can be reused
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("endpoint answered %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// retryable reports whether an attempt that got code (0: no answer) may
+// succeed later.
+func retryable(code int) bool {
+	return code == 0 || code == http.StatusRequestTimeout || code == http.StatusTooManyRequests || code >= 500
+}
+
+func (d *Dispatcher) delay(attempt int) time.Duration {
+	b := d.backoff
+	for i := 1; i < attempt && b < d.maxBackoff; i++ {
+		b *= 2
+	}
+	return min(b, d.maxBackoff)
+}
+
+// trim drops the oldest succeeded deliveries beyond the history limit.
+// Callers hold d.mu.
+func (d *Dispatcher) trim() {
+	for i := 0; d.succeeded > d.history && i < len(d.order); {
+		if d.deliveries[d.order[i]].Status == StatusSucceeded {
+			d.remove(d.order[i])
+			continue
+		}
+		i++
+	}
+}
+
+// remove forgets a delivery. Callers hold d.mu.
+func (d *Dispatcher) remove(id string) {
+	if d.deliveries[id].Status == StatusSucceeded {
+		d.succeeded--
+	}
+	delete(d.deliveries, id)
+	if i := slices.Index(d.order, id); i >= 0 {
+		d.order = slices.Delete(d.order, i, i+1)
+	}
+}
+
+func copyDelivery(dl *Delivery) Delivery {
+	out := *dl
+	out.Attempts = slices.Clone(dl.Attempts)
+	return out
+}
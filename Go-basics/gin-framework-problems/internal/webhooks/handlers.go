@@ -0,0 +1,241 @@
+package webhooks
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/apperror"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/middleware"
+	"github.com/sivaganesz/Tech-Learning-Hub/Go-basics/gin-framework-problems/internal/pagination"
+)
+
+// Routes mounts the management API on r:
+//
+//	POST   /endpoints                  register {url, events, description, secret}
+//	GET    /endpoints
+//	GET    /endpoints/:id
+//	DELETE /endpoints/:id
+//	POST   /endpoints/:id/ping         send a webhook.ping delivery
+//	GET    /deliveries                 history; ?endpoint_id, ?event, ?status
+//	GET    /deliveries/:id             with every attempt
+//	POST   /deliveries/:id/redeliver   retry a dead letter
+//	GET    /dead-letters
+//	DELETE /dead-letters/:id
+func (d *Dispatcher) Routes(r gin.IRouter) {
+	r.POST("/endpoints", middleware.Handle(d.createEndpoint))
+	r.GET("/endpoints", d.listEndpoints)
+	r.GET("/endpoints/:id", middleware.Handle(d.getEndpoint))
+	r.DELETE("/endpoints/:id", middleware.Handle(d.deleteEndpoint))
+	r.POST("/endpoints/:id/ping", middleware.Handle(d.ping))
+	r.GET("/deliveries", d.listDeliveries(""))
+	r.GET("/deliveries/:id", middleware.Handle(d.getDelivery))
+	r.POST("/deliveries/:id/redeliver", middleware.Handle(d.redeliver))
+	r.GET("/dead-letters", d.listDeliveries(StatusDead))
+	r.DELETE("/dead-letters/:id", middleware.Handle(d.discard))
+}
+
+// ConsumerRoutes mounts on r the API consumers manage their own endpoints
+// with; owner names the consumer of a request, e.g. the signed-in user's ID.
+// A consumer sees only the endpoints it registered, and their deliveries,
+// subscribes only to the events of WithConsumerEvents and receives only
+// those PublishOwned addresses to it:
+//
+//	POST   /                   register {url, events, description, secret}
+//	GET    /
+//	GET    /:id
+//	DELETE /:id
+//	POST   /:id/ping
+//	GET    /:id/deliveries     its delivery log; ?event, ?status
+func (d *Dispatcher) ConsumerRoutes(r gin.IRouter, owner func(*gin.Context) string) {
+	api := consumerAPI{d: d, owner: owner}
+	r.POST("", middleware.Handle(func(c *gin.Context) error { return d.register(c, owner(c)) }))
+	r.GET("", api.listEndpoints)
+	r.GET("/:id", middleware.Handle(api.getEndpoint))
+	r.DELETE("/:id", middleware.Handle(api.own(d.deleteEndpoint)))
+	r.POST("/:id/ping", middleware.Handle(api.own(d.ping)))
+	r.GET("/:id/deliveries", middleware.Handle(api.own(func(c *gin.Context) error {
+		return d.writeDeliveries(c, Filter{EndpointID: c.Param("id"), Event: c.Query("event"), Status: Status(c.Query("status"))})
+	})))
+}
+
+type consumerAPI struct {
+	d     *Dispatcher
+	owner func(*gin.Context) string
+}
+
+// endpoint is the endpoint with the :id param, if the caller owns it. Others'
+// endpoints are not found, so their IDs don't leak.
+func (a consumerAPI) endpoint(c *gin.Context) (Endpoint, error) {
+	e, ok := a.d.Endpoint(c.Param("id"))
+	if !ok || e.Owner != a.owner(c) {
+		return Endpoint{}, errEndpointNotFound
+	}
+	return e, nil
+}
+
+// own runs h once the caller is known to own the endpoint.
+func (a consumerAPI) own(h func(*gin.Context) error) func(*gin.Context) error {
+	return func(c *gin.Context) error {
+		if _, err := a.endpoint(c); err != nil {
+			return err
+		}
+		return h(c)
+	}
+}
+
+func (a consumerAPI) listEndpoints(c *gin.Context) {
+	owner := a.owner(c)
+	list := slices.DeleteFunc(a.d.Endpoints(), func(e Endpoint) bool { return e.Owner != owner })
+	c.JSON(http.StatusOK, gin.H{"endpoints": list})
+}
+
+func (a consumerAPI) getEndpoint(c *gin.Context) error {
+	e, err := a.endpoint(c)
+	if err != nil {
+		return err
+	}
+	c.JSON(http.StatusOK, e)
+	return nil
+}
+
+var (
+	errEndpointNotFound = apperror.NotFound("endpoint not found")
+	errDeliveryNotFound = apperror.NotFound("delivery not found")
+)
+
+type createEndpointRequest struct {
+	URL         string   `json:"url" binding:"required"`
+	Events      []string `json:"events"`
+	Description string   `json:"description"`
+	Secret      string   `json:"secret" binding:"omitempty,min=16"`
+}
+
+// createdEndpoint shows the secret, which later responses leave out.
+type createdEndpoint struct {
+	Endpoint
+	Secret string `json:"secret"`
+}
+
+func (d *Dispatcher) createEndpoint(c *gin.Context) error {
+	return d.register(c, "")
+}
+
+// register adds the endpoint in the request body for owner.
+func (d *Dispatcher) register(c *gin.Context, owner string) error {
+	var req createEndpointRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return middleware.BadInput(c, err)
+	}
+	e, err := d.AddEndpoint(Endpoint{
+		URL:         req.URL,
+		Events:      req.Events,
+		Description: req.Description,
+		Secret:      req.Secret,
+		Owner:       owner,
+	})
+	if err != nil {
+		return apperror.BadRequest(err.Error())
+	}
+	c.Header("Location", c.FullPath()+"/"+e.ID)
+	c.JSON(http.StatusCreated, createdEndpoint{Endpoint: e, Secret: e.Secret})
+	return nil
+}
+
+func (d *Dispatcher) listEndpoints(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"endpoints": d.Endpoints()})
+}
+
+func (d *Dispatcher) getEndpoint(c *gin.Context) error {
+	e, ok := d.Endpoint(c.Param("id"))
+	if !ok {
+		return errEndpointNotFound
+	}
+	c.JSON(http.StatusOK, e)
+	return nil
+}
+
+func (d *Dispatcher) deleteEndpoint(c *gin.Context) error {
+	if err := d.RemoveEndpoint(c.Param("id")); err != nil {
+		return errEndpointNotFound
+	}
+	c.Status(http.StatusNoContent)
+	return nil
+}
+
+func (d *Dispatcher) ping(c *gin.Context) error {
+	dl, err := d.Send(c.Request.Context(), c.Param("id"), "webhook.ping", gin.H{"message": "ping"})
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return errEndpointNotFound
+	case err != nil:
+		return unavailable(err)
+	}
+	c.JSON(http.StatusAccepted, dl)
+	return nil
+}
+
+func (d *Dispatcher) listDeliveries(status Status) gin.HandlerFunc {
+	return middleware.Handle(func(c *gin.Context) error {
+		f := Filter{EndpointID: c.Query("endpoint_id"), Event: c.Query("event"), Status: status}
+		if f.Status == "" {
+			f.Status = Status(c.Query("status"))
+		}
+		return d.writeDeliveries(c, f)
+	})
+}
+
+// writeDeliveries answers with the page of the deliveries matching f that
+// the query asks for.
+func (d *Dispatcher) writeDeliveries(c *gin.Context, f Filter) error {
+	p, err := pagination.ParseParams(c)
+	if err != nil {
+		return apperror.BadRequest(err.Error())
+	}
+	// newest first, so a page can shift while deliveries come in
+	pagination.Write(c, pagination.NewPage(d.Deliveries(f), p))
+	return nil
+}
+
+func (d *Dispatcher) getDelivery(c *gin.Context) error {
+	dl, ok := d.Delivery(c.Param("id"))
+	if !ok {
+		return errDeliveryNotFound
+	}
+	c.JSON(http.StatusOK, dl)
+	return nil
+}
+
+func (d *Dispatcher) redeliver(c *gin.Context) error {
+	dl, err := d.Redeliver(c.Param("id"))
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return errDeliveryNotFound
+	case errors.Is(err, ErrNotDead):
+		return apperror.Conflict("delivery is " + string(dl.Status) + ", not dead")
+	case err != nil:
+		return unavailable(err)
+	}
+	c.JSON(http.StatusAccepted, dl)
+	return nil
+}
+
+func (d *Dispatcher) discard(c *gin.Context) error {
+	err := d.Discard(c.Param("id"))
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return errDeliveryNotFound
+	case errors.Is(err, ErrNotDead):
+		return apperror.Conflict("delivery is not a dead letter")
+	}
+	c.Status(http.StatusNoContent)
+	return nil
+}
+
+// unavailable reports a delivery that couldn't be queued, with the reason,
+// which is about the queue rather than anything internal.
+func unavailable(err error) error {
+	return apperror.New(http.StatusServiceUnavailable, apperror.CodeUnavailable, err.Error())
+}
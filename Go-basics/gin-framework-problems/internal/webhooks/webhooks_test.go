@@ -0,0 +1,358 @@
+package webhooks
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"event":"user.registered"}`)
+	now := time.Now()
+	sig := Sign("s3cret", now, body)
+	ts := strconv.FormatInt(now.Unix(), 10)
+
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		signature string
+		body      string
+		want      error
+	}{
+		{"valid", "s3cret", ts, sig, string(body), nil},
+		{"one of several", "s3cret", ts, "v1=00, " + sig, string(body), nil},
+		{"wrong secret", "other", ts, sig, string(body), ErrBadSignature},
+		{"tampered body", "s3cret", ts, sig, `{"event":"user.deleted"}`, ErrBadSignature},
+		{"other timestamp", "s3cret", strconv.FormatInt(now.Unix()-1, 10), sig, string(body), ErrBadSignature},
+		{"stale", "s3cret", strconv.FormatInt(now.Add(-time.Hour).Unix(), 10), Sign("s3cret", now.Add(-time.Hour), body), string(body), ErrStaleTimestamp},
+		{"not a timestamp", "s3cret", "yesterday", sig, string(body), ErrStaleTimestamp},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Verify(tt.secret, tt.timestamp, tt.signature, []byte(tt.body), time.Minute); err != tt.want {
+				t.Errorf("Verify = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestWants(t *testing.T) {
+	tests := []struct {
+		events []string
+		want   bool
+	}{
+		{nil, true},
+		{[]string{"*"}, true},
+		{[]string{"file.uploaded", "user.registered"}, true},
+		{[]string{"file.uploaded"}, false},
+	}
+	for _, tt := range tests {
+		if got := (Endpoint{Events: tt.events}).Wants("user.registered"); got != tt.want {
+			t.Errorf("Wants with events %q = %v, want %v", tt.events, got, tt.want)
+		}
+	}
+}
+
+func TestDelay(t *testing.T) {
+	d := NewDispatcher(WithBackoff(time.Second, 5*time.Second))
+	for attempt, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second} {
+		if got := d.delay(attempt + 1); got != want {
+			t.Errorf("delay(%d) = %v, want %v", attempt+1, got, want)
+		}
+	}
+}
+
+// receiver answers each path with its list of statuses in turn, repeating
+// the last one, and counts the requests whose signature verifies.
+type receiver struct {
+	mu       sync.Mutex
+	statuses map[string][]int
+	verified int
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if Verify("s3cret", req.Header.Get("X-Webhook-Timestamp"), req.Header.Get("X-Webhook-Signature"), body, time.Minute) == nil {
+		r.verified++
+	}
+	codes := r.statuses[req.URL.Path]
+	w.WriteHeader(codes[0])
+	if len(codes) > 1 {
+		r.statuses[req.URL.Path] = codes[1:]
+	}
+}
+
+func newDispatcher(t *testing.T, opts ...Option) *Dispatcher {
+	t.Helper()
+	// the receivers are on 127.0.0.1
+	d := NewDispatcher(append([]Option{WithPrivateNetworks(), WithMaxAttempts(3), WithBackoff(time.Millisecond, time.Millisecond)}, opts...)...)
+	d.Start()
+	t.Cleanup(func() { d.Shutdown(t.Context()) })
+	return d
+}
+
+// settled waits for the delivery to succeed or die.
+func settled(t *testing.T, d *Dispatcher, id string) Delivery {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		dl, ok := d.Delivery(id)
+		if !ok {
+			t.Fatalf("delivery %s gone", id)
+		}
+		if dl.Status == StatusSucceeded || dl.Status == StatusDead {
+			return dl
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("delivery %s still %s", id, dl.Status)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestDeliver(t *testing.T) {
+	rcv := &receiver{statuses: map[string][]int{
+		"/ok":      {http.StatusNoContent},
+		"/flaky":   {http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusOK},
+		"/busy":    {http.StatusTooManyRequests},
+		"/gone":    {http.StatusGone},
+		"/moved":   {http.StatusFound},
+		"/timeout": {http.StatusRequestTimeout, http.StatusOK},
+	}}
+	srv := httptest.NewServer(rcv)
+	t.Cleanup(srv.Close)
+	d := newDispatcher(t)
+
+	tests := []struct {
+		path     string
+		status   Status
+		attempts int
+	}{
+		{"/ok", StatusSucceeded, 1},
+		{"/flaky", StatusSucceeded, 3},
+		{"/busy", StatusDead, 3},
+		{"/gone", StatusDead, 1},
+		{"/moved", StatusDead, 1},
+		{"/timeout", StatusSucceeded, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			ep, err := d.AddEndpoint(Endpoint{URL: srv.URL + tt.path, Secret: "s3cret"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			dl, err := d.Send(t.Context(), ep.ID, "ping", map[string]string{"path": tt.path})
+			if err != nil {
+				t.Fatal(err)
+			}
+			dl = settled(t, d, dl.ID)
+			if dl.Status != tt.status || len(dl.Attempts) != tt.attempts {
+				t.Errorf("%s after %d attempts, want %s after %d", dl.Status, len(dl.Attempts), tt.status, tt.attempts)
+			}
+		})
+	}
+	rcv.mu.Lock()
+	defer rcv.mu.Unlock()
+	if rcv.verified != 11 {
+		t.Errorf("%d requests verified, want all 11", rcv.verified)
+	}
+}
+
+func TestAddEndpoint(t *testing.T) {
+	d := NewDispatcher()
+	for _, u := range []string{"ftp://example.com/hook", "/hook", "http://", "::"} {
+		if _, err := d.AddEndpoint(Endpoint{URL: u}); err != ErrInvalidURL {
+			t.Errorf("AddEndpoint(%q) = %v, want ErrInvalidURL", u, err)
+		}
+	}
+	ep, err := d.AddEndpoint(Endpoint{URL: "https://example.com/hook"})
+	if err != nil || ep.ID == "" || ep.Secret == "" {
+		t.Fatalf("AddEndpoint = %+v, %v; want an ID and a generated secret", ep, err)
+	}
+	if err := d.RemoveEndpoint(ep.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.RemoveEndpoint(ep.ID); err != ErrNotFound {
+		t.Errorf("second RemoveEndpoint = %v, want ErrNotFound", err)
+	}
+
+	d = NewDispatcher(WithEvents("book.updated"))
+	if _, err := d.AddEndpoint(Endpoint{URL: "https://example.com/hook", Events: []string{"book.updated", "book.udpated"}}); !errors.Is(err, ErrUnknownEvent) {
+		t.Errorf("AddEndpoint with a misspelt event = %v, want ErrUnknownEvent", err)
+	}
+	if _, err := d.AddEndpoint(Endpoint{URL: "https://example.com/hook", Events: []string{"*"}}); err != nil {
+		t.Errorf("AddEndpoint for every event = %v", err)
+	}
+
+	// consumers get their own, shorter list
+	d = NewDispatcher(WithEvents("book.updated", "user.registered"), WithConsumerEvents("book.updated"))
+	if _, err := d.AddEndpoint(Endpoint{URL: "https://example.com/hook", Events: []string{"user.registered"}, Owner: "1"}); !errors.Is(err, ErrUnknownEvent) {
+		t.Errorf("consumer AddEndpoint for an operator event = %v, want ErrUnknownEvent", err)
+	}
+	if _, err := d.AddEndpoint(Endpoint{URL: "https://example.com/hook", Events: []string{"book.updated"}, Owner: "1"}); err != nil {
+		t.Errorf("consumer AddEndpoint for a consumer event = %v", err)
+	}
+	if _, err := NewDispatcher().AddEndpoint(Endpoint{URL: "https://example.com/hook", Events: []string{"book.updated"}, Owner: "1"}); !errors.Is(err, ErrUnknownEvent) {
+		t.Errorf("consumer AddEndpoint without consumer events = %v, want ErrUnknownEvent", err)
+	}
+}
+
+func TestPrivateAddresses(t *testing.T) {
+	d := NewDispatcher()
+	for _, u := range []string{
+		"http://localhost:9000/hook",
+		"http://api.localhost/hook",
+		"http://127.0.0.1/hook",
+		"http://[::1]:8080/hook",
+		"http://10.1.2.3/hook",
+		"http://172.16.0.1/hook",
+		"http://192.168.1.1/hook",
+		"http://100.64.0.1/hook",
+		"http://169.254.169.254/latest/meta-data/",
+		"http://[fe80::1]/hook",
+		"http://[fd00:ec2::254]/hook",
+		"http://[::ffff:127.0.0.1]/hook",
+		"http://0.0.0.0/hook",
+	} {
+		if _, err := d.AddEndpoint(Endpoint{URL: u}); !errors.Is(err, ErrPrivateAddress) {
+			t.Errorf("AddEndpoint(%q) = %v, want ErrPrivateAddress", u, err)
+		}
+	}
+	for _, u := range []string{"https://example.com/hook", "http://93.184.215.14/hook"} {
+		if _, err := d.AddEndpoint(Endpoint{URL: u}); err != nil {
+			t.Errorf("AddEndpoint(%q) = %v", u, err)
+		}
+	}
+	if _, err := NewDispatcher(WithPrivateNetworks()).AddEndpoint(Endpoint{URL: "http://127.0.0.1/hook"}); err != nil {
+		t.Errorf("AddEndpoint on 127.0.0.1 with private networks = %v", err)
+	}
+
+	// a name can resolve to anything, so the client checks what it dials
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("private address dialed")
+	}))
+	t.Cleanup(srv.Close)
+	req, _ := http.NewRequestWithContext(t.Context(), http.MethodPost, srv.URL, nil)
+	if _, err := NewClient(time.Second, false).Do(req); !errors.Is(err, ErrPrivateAddress) {
+		t.Errorf("POST %s = %v, want ErrPrivateAddress", srv.URL, err)
+	}
+}
+
+func TestPublish(t *testing.T) {
+	d := NewDispatcher()
+	users, _ := d.AddEndpoint(Endpoint{URL: "http://example.com/users", Events: []string{"user.registered"}})
+	d.AddEndpoint(Endpoint{URL: "http://example.com/files", Events: []string{"file.uploaded"}})
+	all, _ := d.AddEndpoint(Endpoint{URL: "http://example.com/all"})
+
+	list, err := d.Publish(t.Context(), "user.registered", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]bool{}
+	for _, dl := range list {
+		got[dl.EndpointID] = true
+	}
+	if len(got) != 2 || !got[users.ID] || !got[all.ID] {
+		t.Errorf("delivered to %v, want %s and %s", got, users.ID, all.ID)
+	}
+
+	d.Shutdown(t.Context())
+	if _, err := d.Publish(t.Context(), "user.registered", nil); err != ErrClosed {
+		t.Errorf("Publish after Shutdown = %v, want ErrClosed", err)
+	}
+}
+
+func TestPublishOwned(t *testing.T) {
+	d := NewDispatcher(WithConsumerEvents("book.updated"))
+	operator, _ := d.AddEndpoint(Endpoint{URL: "http://example.com/all"})
+	mine, _ := d.AddEndpoint(Endpoint{URL: "http://example.com/mine", Owner: "1"})
+	d.AddEndpoint(Endpoint{URL: "http://example.com/theirs", Owner: "2"})
+
+	tests := []struct {
+		name, event, owner string
+		want               []string
+	}{
+		{"owned", "book.updated", "1", []string{operator.ID, mine.ID}},
+		{"no owner", "book.updated", "", []string{operator.ID}},
+		{"not a consumer event", "user.registered", "1", []string{operator.ID}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, err := d.PublishOwned(t.Context(), tt.event, tt.owner, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, dl := range list {
+				got = append(got, dl.EndpointID)
+			}
+			slices.Sort(got)
+			if want := slices.Sorted(slices.Values(tt.want)); !slices.Equal(got, want) {
+				t.Errorf("delivered to %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestDeadLetters(t *testing.T) {
+	rcv := &receiver{statuses: map[string][]int{"/hook": {http.StatusInternalServerError}}}
+	srv := httptest.NewServer(rcv)
+	t.Cleanup(srv.Close)
+	d := newDispatcher(t)
+	ep, _ := d.AddEndpoint(Endpoint{URL: srv.URL + "/hook", Secret: "s3cret"})
+	dl, _ := d.Send(t.Context(), ep.ID, "ping", nil)
+	if dl = settled(t, d, dl.ID); dl.Status != StatusDead {
+		t.Fatalf("delivery %s, want dead", dl.Status)
+	}
+
+	// fixed: a redelivery gets a fresh set of attempts and keeps the old ones
+	rcv.mu.Lock()
+	rcv.statuses["/hook"] = []int{http.StatusOK}
+	rcv.mu.Unlock()
+	if _, err := d.Redeliver(dl.ID); err != nil {
+		t.Fatal(err)
+	}
+	if dl = settled(t, d, dl.ID); dl.Status != StatusSucceeded || len(dl.Attempts) != 4 {
+		t.Errorf("redelivery %s after %d attempts, want succeeded after 4", dl.Status, len(dl.Attempts))
+	}
+
+	if _, err := d.Redeliver(dl.ID); err != ErrNotDead {
+		t.Errorf("Redeliver of a success = %v, want ErrNotDead", err)
+	}
+	if err := d.Discard(dl.ID); err != ErrNotDead {
+		t.Errorf("Discard of a success = %v, want ErrNotDead", err)
+	}
+	if err := d.Discard("dlv_missing"); err != ErrNotFound {
+		t.Errorf("Discard of a missing delivery = %v, want ErrNotFound", err)
+	}
+}
+
+func TestHistory(t *testing.T) {
+	rcv := &receiver{statuses: map[string][]int{"/hook": {http.StatusOK}}}
+	srv := httptest.NewServer(rcv)
+	t.Cleanup(srv.Close)
+	d := newDispatcher(t, WithHistory(2), WithWorkers(1))
+	ep, _ := d.AddEndpoint(Endpoint{URL: srv.URL + "/hook"})
+
+	var ids []string
+	for range 3 {
+		dl, _ := d.Send(t.Context(), ep.ID, "ping", nil)
+		settled(t, d, dl.ID)
+		ids = append(ids, dl.ID)
+	}
+	if _, ok := d.Delivery(ids[0]); ok {
+		t.Error("oldest success kept beyond the history limit")
+	}
+	if n := len(d.Deliveries(Filter{Status: StatusSucceeded})); n != 2 {
+		t.Errorf("%d succeeded deliveries kept, want 2", n)
+	}
+}